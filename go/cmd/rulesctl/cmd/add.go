@@ -22,6 +22,10 @@ var (
 	addOptQueryRE           string
 	addOptLeadingCommentRE  string
 	addOptTrailingCommentRE string
+	addOptMaxQPS            float64
+	addOptMaxConcurrency    int
+	addOptCommentHint       string
+	addOptMaxExecutionTime  int64
 	// TODO: other stuff, bind vars etc
 )
 
@@ -30,6 +34,18 @@ func runAdd(cmd *cobra.Command, args []string) {
 	ruleAction := mkAction()
 
 	rule := vtrules.NewQueryRule(addOptDescription, addOptName, ruleAction)
+	switch ruleAction {
+	case vtrules.QRThrottle:
+		if addOptMaxQPS <= 0 && addOptMaxConcurrency <= 0 {
+			log.Fatalf("Action throttle needs --max-qps or --max-concurrency")
+		}
+		rule.SetThrottle(addOptMaxQPS, addOptMaxConcurrency)
+	case vtrules.QRRewrite:
+		if addOptCommentHint == "" && addOptMaxExecutionTime <= 0 {
+			log.Fatalf("Action rewrite needs --comment-hint or --max-execution-time")
+		}
+		rule.SetRewrite(addOptCommentHint, addOptMaxExecutionTime)
+	}
 	for _, pt := range rulePlans {
 		rule.AddPlanCond(pt)
 	}
@@ -105,6 +121,10 @@ func mkAction() vtrules.Action {
 		return vtrules.QRFailRetry
 	case "continue":
 		return vtrules.QRContinue
+	case "throttle":
+		return vtrules.QRThrottle
+	case "rewrite":
+		return vtrules.QRRewrite
 	default:
 		log.Fatalf("Unknown action '%v'", addOptAction)
 	}
@@ -139,7 +159,7 @@ func Add() *cobra.Command {
 		&addOptAction,
 		"action", "a",
 		"",
-		"What action should be taken when this rule is matched {continue, fail, fail-retry} (required)")
+		"What action should be taken when this rule is matched {continue, fail, fail-retry, throttle, rewrite} (required)")
 	addCmd.Flags().StringSliceVarP(
 		&addOptPlans,
 		"plan", "p",
@@ -165,6 +185,26 @@ func Add() *cobra.Command {
 		"trailing-comment", "r",
 		"",
 		"A regexp that will be applied to comments after a SQL statement")
+	addCmd.Flags().Float64Var(
+		&addOptMaxQPS,
+		"max-qps",
+		0,
+		"The maximum rate of queries per second a throttle rule lets through")
+	addCmd.Flags().IntVar(
+		&addOptMaxConcurrency,
+		"max-concurrency",
+		0,
+		"The maximum number of concurrent queries a throttle rule lets through")
+	addCmd.Flags().StringVar(
+		&addOptCommentHint,
+		"comment-hint",
+		"",
+		"An optimizer hint a rewrite rule adds to the query, e.g. NO_INDEX_MERGE(t)")
+	addCmd.Flags().Int64Var(
+		&addOptMaxExecutionTime,
+		"max-execution-time",
+		0,
+		"The MAX_EXECUTION_TIME in milliseconds a rewrite rule adds to SELECT queries")
 
 	for _, f := range []string{"name", "action"} {
 		addCmd.MarkFlagRequired(f)
@@ -102,6 +102,37 @@ func TestAdd(t *testing.T) {
     ]
   }
 ]
+`,
+		},
+		{
+			name: "Action throttle",
+			args: []string{"--dry-run=true", "--name=Rule", `--description="New rules that will be added to the file"`, "--action=throttle", "--plan=Select", "--max-qps=10", "--max-concurrency=2"},
+			expectedOutput: `[
+  {
+    "Description": "Some value",
+    "Name": "Name",
+    "Action": "FAIL"
+  },
+  {
+    "Description": "\"New rules that will be added to the file\"",
+    "Name": "Rule",
+    "Query": "secret",
+    "LeadingComment": "None",
+    "TrailingComment": "Yoho",
+    "Plans": [
+      "Select",
+      "Select",
+      "Select",
+      "Select"
+    ],
+    "TableNames": [
+      "Temp"
+    ],
+    "Action": "THROTTLE",
+    "MaxQPS": 10,
+    "MaxConcurrency": 2
+  }
+]
 `,
 		},
 	}
@@ -744,7 +744,7 @@ func pruneBackups(ctx context.Context, backupStorage backupstorage.BackupStorage
 		}
 		// Remove the backup.
 		log.Infof("Removing old backup %v from %v, since it's older than min_retention_time of %v", backup.Name(), backupDir, minRetentionTime)
-		if err := backupStorage.RemoveBackup(ctx, backupDir, backup.Name()); err != nil {
+		if err := mysqlctl.RemoveBackup(ctx, backupStorage, backupDir, backup.Name()); err != nil {
 			return fmt.Errorf("couldn't remove backup %v from %v: %v", backup.Name(), backupDir, err)
 		}
 		// We successfully removed one backup. Can we afford to prune any more?
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/acl"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtcdc"
+	"vitess.io/vitess/go/vt/vtgate/vtgateconn"
+	"vitess.io/vitess/go/vt/vttls"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+
+	// Include the gRPC client to vtgate.
+	_ "vitess.io/vitess/go/vt/vtgate/grpcvtgateconn"
+)
+
+var (
+	server = "localhost:15991"
+	config = vtcdc.Config{
+		TabletType:         topodatapb.TabletType_PRIMARY,
+		TopicPrefix:        "vitess",
+		Snapshot:           true,
+		CheckpointInterval: time.Second,
+		RetryDelay:         5 * time.Second,
+	}
+	kafkaConfig = vtcdc.KafkaConfig{
+		ClientID: "vtcdc",
+	}
+	kafkaTLS           bool
+	kafkaTLSCA         string
+	kafkaTLSCert       string
+	kafkaTLSKey        string
+	kafkaTLSServerName string
+
+	checkpointFile  string
+	checkpointTable string
+	checkpointName  = "vtcdc"
+
+	Main = &cobra.Command{
+		Use:   "vtcdc",
+		Short: "vtcdc publishes the row changes of a keyspace to a Kafka-compatible broker, as Debezium change events.",
+		Long: "`vtcdc` streams the row changes of a keyspace from vtgate, and publishes them to a Kafka-compatible broker as Debezium JSON change events, without schemas.\n\n" +
+			"The changes of a table go to the topic `<topic-prefix>.<keyspace>.<table>`, keyed by the primary key of the row. " +
+			"With `--snapshot`, the existing rows are published first, with the `r` operation.\n\n" +
+			"The position of the last published transaction is saved to `--checkpoint-file`, or to a row of `--checkpoint-table`, and `vtcdc` resumes from there when restarted. " +
+			"The changes published after the last saved position are published again then, so consumers see every change at least once.",
+		Example: `vtcdc \
+	--server=localhost:15991 \
+	--keyspace=commerce \
+	--kafka-brokers=kafka1:9092,kafka2:9092 \
+	--checkpoint-file=${VTDATAROOT}/vtcdc/commerce.json`,
+		Args:    cobra.NoArgs,
+		Version: servenv.AppVersion.String(),
+		PreRunE: servenv.CobraPreRunE,
+		RunE:    run,
+	}
+)
+
+func init() {
+	servenv.RegisterDefaultFlags()
+	servenv.RegisterFlags()
+
+	servenv.MoveFlagsToCobraCommand(Main)
+
+	Main.Flags().StringVar(&server, "server", server, "The address of the vtgate to stream from.")
+	Main.Flags().StringVar(&config.Keyspace, "keyspace", config.Keyspace, "The keyspace to stream.")
+	Main.Flags().StringSliceVar(&config.Tables, "tables", config.Tables, "Comma-separated list of the tables to stream. All the tables of the keyspace if empty.")
+	Main.Flags().Var((*topoproto.TabletTypeFlag)(&config.TabletType), "tablet-type", "The type of the tablets to stream from.")
+	Main.Flags().StringVar(&config.TopicPrefix, "topic-prefix", config.TopicPrefix, "The prefix of the topics, also the name of the source of the change events.")
+	Main.Flags().BoolVar(&config.Snapshot, "snapshot", config.Snapshot, "Publish the existing rows first, when starting without a saved position. Without it, only the changes from when vtcdc starts are published.")
+	Main.Flags().BoolVar(&config.TombstonesOnDelete, "tombstones-on-delete", config.TombstonesOnDelete, "Publish a record without value after each deletion, so that compacted topics can drop the row.")
+	Main.Flags().DurationVar(&config.RetryDelay, "retry-delay", config.RetryDelay, "How long to wait before resuming a stream that failed.")
+
+	Main.Flags().StringSliceVar(&kafkaConfig.Brokers, "kafka-brokers", kafkaConfig.Brokers, "Comma-separated list of the addresses of the brokers to bootstrap from.")
+	Main.Flags().StringVar(&kafkaConfig.ClientID, "kafka-client-id", kafkaConfig.ClientID, "The client ID to publish with.")
+	Main.Flags().BoolVar(&kafkaConfig.AllowAutoTopicCreation, "kafka-allow-auto-topic-creation", kafkaConfig.AllowAutoTopicCreation, "Let the brokers create the topics they do not have yet.")
+	Main.Flags().BoolVar(&kafkaTLS, "kafka-tls", kafkaTLS, "Connect to the brokers with TLS.")
+	Main.Flags().StringVar(&kafkaTLSCA, "kafka-tls-ca", kafkaTLSCA, "The CA to validate the certificates of the brokers with. The system CAs if empty.")
+	Main.Flags().StringVar(&kafkaTLSCert, "kafka-tls-cert", kafkaTLSCert, "The client certificate to connect to the brokers with.")
+	Main.Flags().StringVar(&kafkaTLSKey, "kafka-tls-key", kafkaTLSKey, "The key of the client certificate.")
+	Main.Flags().StringVar(&kafkaTLSServerName, "kafka-tls-server-name", kafkaTLSServerName, "The server name to validate the certificates of the brokers with.")
+	Main.Flags().StringVar(&kafkaConfig.SASLMechanism, "kafka-sasl-mechanism", kafkaConfig.SASLMechanism, "The SASL mechanism to authenticate with: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512. No authentication if empty.")
+	Main.Flags().StringVar(&kafkaConfig.SASLUser, "kafka-sasl-user", kafkaConfig.SASLUser, "The user to authenticate as.")
+	Main.Flags().StringVar(&kafkaConfig.SASLPassword, "kafka-sasl-password", kafkaConfig.SASLPassword, "The password to authenticate with.")
+
+	Main.Flags().StringVar(&checkpointFile, "checkpoint-file", checkpointFile, "The file to save the position in.")
+	Main.Flags().StringVar(&checkpointTable, "checkpoint-table", checkpointTable, "The table to save the position in, as <keyspace>.<table>, created if needed. The keyspace must be unsharded, and not the streamed keyspace.")
+	Main.Flags().StringVar(&checkpointName, "checkpoint-name", checkpointName, "The name of the row of --checkpoint-table to save the position in. Each vtcdc sharing the table needs its own.")
+	Main.Flags().DurationVar(&config.CheckpointInterval, "checkpoint-interval", config.CheckpointInterval, "The minimum time between two saves of the position.")
+
+	acl.RegisterFlags(Main.Flags())
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	if config.Keyspace == "" {
+		return fmt.Errorf("--keyspace is required")
+	}
+	if len(kafkaConfig.Brokers) == 0 {
+		return fmt.Errorf("--kafka-brokers is required")
+	}
+	if (checkpointFile == "") == (checkpointTable == "") {
+		return fmt.Errorf("exactly one of --checkpoint-file and --checkpoint-table is required")
+	}
+	if checkpointKeyspace, _, _ := strings.Cut(checkpointTable, "."); checkpointKeyspace == config.Keyspace {
+		return fmt.Errorf("--checkpoint-table must not be in the streamed keyspace %v", config.Keyspace)
+	}
+	if kafkaTLS {
+		var err error
+		kafkaConfig.TLS, err = vttls.ClientConfig(vttls.VerifyIdentity, kafkaTLSCert, kafkaTLSKey, kafkaTLSCA, "", kafkaTLSServerName, tls.VersionTLS12)
+		if err != nil {
+			return fmt.Errorf("failed to load the Kafka TLS configuration: %w", err)
+		}
+	}
+
+	servenv.Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := vtgateconn.Dial(ctx, server)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to connect to vtgate: %w", err)
+	}
+	producer, err := vtcdc.NewKafkaProducer(kafkaConfig)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	var checkpoints vtcdc.CheckpointStore
+	if checkpointFile != "" {
+		checkpoints = vtcdc.NewFileCheckpointStore(checkpointFile)
+	} else {
+		checkpoints, err = vtcdc.NewTableCheckpointStore(conn.Session("@primary", nil), checkpointTable, checkpointName)
+		if err != nil {
+			cancel()
+			producer.Close()
+			conn.Close()
+			return err
+		}
+	}
+
+	sink := vtcdc.NewSink(config, conn, producer, checkpoints)
+	done := make(chan struct{})
+	servenv.OnRun(func() {
+		go func() {
+			defer close(done)
+			if err := sink.Run(ctx); err != nil {
+				if ctx.Err() == nil {
+					log.Exitf("vtcdc failed: %v", err)
+				}
+				log.Errorf("Failed to save the position on exit: %v", err)
+			}
+		}()
+	})
+	servenv.OnTermSync(func() {
+		cancel()
+		<-done
+	})
+	servenv.OnClose(func() {
+		producer.Close()
+		conn.Close()
+	})
+	servenv.RunDefault()
+	return nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"time"
+
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vtctl/backuppolicy"
+	"vitess.io/vitess/go/vt/vtctl/grpcvtctldserver"
+	"vitess.io/vitess/go/vt/vtctl/localvtctldclient"
+)
+
+var (
+	backupPolicyCheckInterval = time.Minute
+	backupPolicyConcurrency   = 4
+)
+
+func init() {
+	Main.Flags().DurationVar(&backupPolicyCheckInterval, "backup-policy-check-interval", backupPolicyCheckInterval, "How often the backup policies of the keyspaces are checked for backups to take and prune. Zero disables scheduled backups and pruning.")
+	Main.Flags().IntVar(&backupPolicyConcurrency, "backup-policy-concurrency", backupPolicyConcurrency, "How many shards the backup policies take backups of and prune at the same time.")
+}
+
+func initBackupPolicyScheduler() {
+	if backupPolicyCheckInterval <= 0 {
+		return
+	}
+	client := localvtctldclient.New(grpcvtctldserver.NewVtctldServer(env, ts))
+	scheduler := backuppolicy.NewScheduler(ts, client, backupPolicyCheckInterval, backupPolicyConcurrency)
+	scheduler.Open()
+	servenv.OnClose(scheduler.Close)
+}
@@ -81,6 +81,9 @@ func run(cmd *cobra.Command, args []string) error {
 	// Start schema manager service.
 	initSchema(cmd.Context())
 
+	// Start applying the backup policies of the keyspaces.
+	initBackupPolicyScheduler()
+
 	// And run the server.
 	servenv.RunDefault()
 
@@ -76,6 +76,23 @@ If no replica-type tablet can be found, the backup can be taken on the primary i
 		Args:                  cobra.ExactArgs(1),
 		RunE:                  commandRestoreFromBackup,
 	}
+	// VerifyBackup makes a VerifyBackup gRPC call to a vtctld.
+	VerifyBackup = &cobra.Command{
+		Use:   "VerifyBackup [--tablet-alias <tablet_alias>] [--concurrency <concurrency>] <keyspace/shard> [<backup name>]",
+		Short: "Restores a backup into a scratch mysqld on a tablet of the shard, and checks the integrity of its tables.",
+		Long: `Restores a backup into a scratch mysqld on a tablet of the shard, and checks the integrity of its tables.
+
+The latest backup of the shard is verified if no backup name is given. Incremental backups are restored on top of
+the full backup they build on. The scratch mysqld runs with --skip-networking next to the tablet's own mysqld,
+which keeps serving. Every table is checked with CHECK TABLE and CHECKSUM TABLE, and the checksums are compared
+with the ones recorded by full backups taken with --builtinbackup-table-checksums.
+
+The tablet is picked among the REPLICA, RDONLY, BACKUP and SPARE tablets of the shard, unless --tablet-alias is given.
+The command fails if any table fails verification.`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.RangeArgs(1, 2),
+		RunE:                  commandVerifyBackup,
+	}
 )
 
 var backupOptions = struct {
@@ -278,6 +295,49 @@ func commandRestoreFromBackup(cmd *cobra.Command, args []string) error {
 	}
 }
 
+var verifyBackupOptions = struct {
+	TabletAlias string
+	Concurrency int32
+}{}
+
+func commandVerifyBackup(cmd *cobra.Command, args []string) error {
+	keyspace, shard, err := topoproto.ParseKeyspaceShard(cmd.Flags().Arg(0))
+	if err != nil {
+		return err
+	}
+
+	req := &vtctldatapb.VerifyBackupRequest{
+		Keyspace:    keyspace,
+		Shard:       shard,
+		BackupName:  cmd.Flags().Arg(1),
+		Concurrency: verifyBackupOptions.Concurrency,
+	}
+	if verifyBackupOptions.TabletAlias != "" {
+		req.TabletAlias, err = topoproto.ParseTabletAlias(verifyBackupOptions.TabletAlias)
+		if err != nil {
+			return err
+		}
+	}
+
+	cli.FinishedParsing(cmd)
+
+	resp, err := client.VerifyBackup(commandCtx, req)
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	if !resp.Verification.GetOk() {
+		return fmt.Errorf("backup %s failed verification", resp.Verification.GetBackupName())
+	}
+	return nil
+}
+
 func init() {
 	Backup.Flags().BoolVar(&backupOptions.AllowPrimary, "allow-primary", false, "Allow the primary of a shard to be used for the backup. WARNING: If using the builtin backup engine, this will shutdown mysqld on the primary and stop writes for the duration of the backup.")
 	Backup.Flags().Int32Var(&backupOptions.Concurrency, "concurrency", 4, "Specifies the number of compression/checksum jobs to run simultaneously.")
@@ -303,4 +363,8 @@ func init() {
 	RestoreFromBackup.Flags().StringVar(&restoreFromBackupOptions.RestoreToTimestamp, "restore-to-timestamp", "", "Run a point in time recovery that restores up to, and excluding, given timestamp in RFC3339 format (`2006-01-02T15:04:05Z07:00`). This will attempt to use one full backup followed by zero or more incremental backups")
 	RestoreFromBackup.Flags().BoolVar(&restoreFromBackupOptions.DryRun, "dry-run", false, "Only validate restore steps, do not actually restore data")
 	Root.AddCommand(RestoreFromBackup)
+
+	VerifyBackup.Flags().StringVar(&verifyBackupOptions.TabletAlias, "tablet-alias", "", "Alias of the tablet to verify the backup on. Defaults to a REPLICA, RDONLY, BACKUP or SPARE tablet of the shard.")
+	VerifyBackup.Flags().Int32Var(&verifyBackupOptions.Concurrency, "concurrency", 4, "Specifies the number of files to restore simultaneously.")
+	Root.AddCommand(VerifyBackup)
 }
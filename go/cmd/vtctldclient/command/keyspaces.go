@@ -95,6 +95,24 @@ Otherwise, the keyspace must be empty (have no shards), or returns an error.`,
 		Args:                  cobra.ExactArgs(2),
 		RunE:                  commandRemoveKeyspaceCell,
 	}
+	// SetKeyspaceBackupPolicy makes a SetKeyspaceBackupPolicy gRPC call to a vtctld.
+	SetKeyspaceBackupPolicy = &cobra.Command{
+		Use:   "SetKeyspaceBackupPolicy [--full-backup-interval <duration>] [--incremental-backup-interval <duration>] [--max-incremental-chain-length <n>] [--keep-last <n>] [--keep-daily <n>] [--keep-weekly <n>] [--keep-monthly <n>] [--incremental-retention <duration>] [--allow-primary] [--concurrency <n>] <keyspace name>",
+		Short: "Sets the backup policy that vtctld applies to every shard of the specified keyspace.",
+		Long: `Sets the backup policy that vtctld applies to every shard of the specified keyspace.
+The policy replaces any previous one; running the command without any flag removes the backup policy of the keyspace.
+
+vtctld takes full and incremental backups at the given intervals, and prunes the full backups that none of the keep rules retain.
+An incremental backup is kept along with the full backup it builds on, or for --incremental-retention if set, and a backup that
+a kept incremental backup depends on is never pruned. Pruning only happens if at least one of the keep rules is set.
+
+To take a daily full backup of the customer keyspace with hourly incremental backups, and keep a week of daily backups and
+three months of monthly backups, you would use the following command:
+SetKeyspaceBackupPolicy --full-backup-interval=24h --incremental-backup-interval=1h --keep-daily=7 --keep-monthly=3 customer`,
+		DisableFlagsInUseLine: true,
+		Args:                  cobra.ExactArgs(1),
+		RunE:                  commandSetKeyspaceBackupPolicy,
+	}
 	// SetKeyspaceDurabilityPolicy makes a SetKeyspaceDurabilityPolicy gRPC call to a vtcltd.
 	SetKeyspaceDurabilityPolicy = &cobra.Command{
 		Use:   "SetKeyspaceDurabilityPolicy [--durability-policy=policy_name] <keyspace name>",
@@ -325,6 +343,59 @@ func commandRemoveKeyspaceCell(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var setKeyspaceBackupPolicyOptions = struct {
+	FullBackupInterval        time.Duration
+	IncrementalBackupInterval time.Duration
+	MaxIncrementalChainLength uint32
+	KeepLast                  uint32
+	KeepDaily                 uint32
+	KeepWeekly                uint32
+	KeepMonthly               uint32
+	IncrementalRetention      time.Duration
+	AllowPrimary              bool
+	Concurrency               int32
+}{}
+
+func commandSetKeyspaceBackupPolicy(cmd *cobra.Command, args []string) error {
+	keyspace := cmd.Flags().Arg(0)
+	cli.FinishedParsing(cmd)
+
+	policy := &topodatapb.BackupPolicy{
+		MaxIncrementalChainLength: setKeyspaceBackupPolicyOptions.MaxIncrementalChainLength,
+		KeepLast:                  setKeyspaceBackupPolicyOptions.KeepLast,
+		KeepDaily:                 setKeyspaceBackupPolicyOptions.KeepDaily,
+		KeepWeekly:                setKeyspaceBackupPolicyOptions.KeepWeekly,
+		KeepMonthly:               setKeyspaceBackupPolicyOptions.KeepMonthly,
+		AllowPrimary:              setKeyspaceBackupPolicyOptions.AllowPrimary,
+		Concurrency:               setKeyspaceBackupPolicyOptions.Concurrency,
+	}
+	if d := setKeyspaceBackupPolicyOptions.FullBackupInterval; d != 0 {
+		policy.FullBackupInterval = protoutil.DurationToProto(d)
+	}
+	if d := setKeyspaceBackupPolicyOptions.IncrementalBackupInterval; d != 0 {
+		policy.IncrementalBackupInterval = protoutil.DurationToProto(d)
+	}
+	if d := setKeyspaceBackupPolicyOptions.IncrementalRetention; d != 0 {
+		policy.IncrementalRetention = protoutil.DurationToProto(d)
+	}
+
+	resp, err := client.SetKeyspaceBackupPolicy(commandCtx, &vtctldatapb.SetKeyspaceBackupPolicyRequest{
+		Keyspace:     keyspace,
+		BackupPolicy: policy,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := cli.MarshalJSON(resp)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s\n", data)
+	return nil
+}
+
 var setKeyspaceDurabilityPolicyOptions = struct {
 	DurabilityPolicy string
 }{}
@@ -425,6 +496,18 @@ func init() {
 	RemoveKeyspaceCell.Flags().BoolVarP(&removeKeyspaceCellOptions.Recursive, "recursive", "r", false, "Also delete all tablets in that cell beloning to the specified keyspace.")
 	Root.AddCommand(RemoveKeyspaceCell)
 
+	SetKeyspaceBackupPolicy.Flags().DurationVar(&setKeyspaceBackupPolicyOptions.FullBackupInterval, "full-backup-interval", 0, "How often to take a full backup of each shard. Zero disables scheduled full backups.")
+	SetKeyspaceBackupPolicy.Flags().DurationVar(&setKeyspaceBackupPolicyOptions.IncrementalBackupInterval, "incremental-backup-interval", 0, "How often to take an incremental backup of each shard, on top of its latest backup. Zero disables scheduled incremental backups.")
+	SetKeyspaceBackupPolicy.Flags().Uint32Var(&setKeyspaceBackupPolicyOptions.MaxIncrementalChainLength, "max-incremental-chain-length", 0, "Take a full backup instead of an incremental one once this many incremental backups follow the latest full backup. Zero means no limit.")
+	SetKeyspaceBackupPolicy.Flags().Uint32Var(&setKeyspaceBackupPolicyOptions.KeepLast, "keep-last", 0, "Keep this many most recent full backups.")
+	SetKeyspaceBackupPolicy.Flags().Uint32Var(&setKeyspaceBackupPolicyOptions.KeepDaily, "keep-daily", 0, "Keep the latest full backup of each of this many most recent days that have one.")
+	SetKeyspaceBackupPolicy.Flags().Uint32Var(&setKeyspaceBackupPolicyOptions.KeepWeekly, "keep-weekly", 0, "Keep the latest full backup of each of this many most recent weeks that have one.")
+	SetKeyspaceBackupPolicy.Flags().Uint32Var(&setKeyspaceBackupPolicyOptions.KeepMonthly, "keep-monthly", 0, "Keep the latest full backup of each of this many most recent months that have one.")
+	SetKeyspaceBackupPolicy.Flags().DurationVar(&setKeyspaceBackupPolicyOptions.IncrementalRetention, "incremental-retention", 0, "How long to keep incremental backups that no longer follow the latest full backup. Zero keeps them as long as the full backup they build on.")
+	SetKeyspaceBackupPolicy.Flags().BoolVar(&setKeyspaceBackupPolicyOptions.AllowPrimary, "allow-primary", false, "Allow scheduled backups to be taken on the primary when no other tablet of the shard can take them.")
+	SetKeyspaceBackupPolicy.Flags().Int32Var(&setKeyspaceBackupPolicyOptions.Concurrency, "concurrency", 0, "Number of files to back up simultaneously. Zero uses the default of 4.")
+	Root.AddCommand(SetKeyspaceBackupPolicy)
+
 	SetKeyspaceDurabilityPolicy.Flags().StringVar(&setKeyspaceDurabilityPolicyOptions.DurabilityPolicy, "durability-policy", "none", "Type of durability to enforce for this keyspace. Default is none. Other values include 'semi_sync' and others as dictated by registered plugins.")
 	Root.AddCommand(SetKeyspaceDurabilityPolicy)
 
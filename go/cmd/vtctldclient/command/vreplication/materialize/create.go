@@ -28,13 +28,17 @@ import (
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/topo/topoproto"
 
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
 
 var (
 	createOptions = struct {
-		SourceKeyspace string
-		TableSettings  tableSettings
+		SourceKeyspace          string
+		TableSettings           tableSettings
+		BidirectionalPeer       string
+		ConflictResolution      string
+		ConflictTimestampColumn string
 	}{}
 
 	// create makes a MaterializeCreate gRPC call to a vtctld.
@@ -77,6 +81,9 @@ should be copied as-is from the source keyspace. Here's an example value for tab
 			if err := common.ParseAndValidateCreateOptions(cmd); err != nil {
 				return err
 			}
+			if _, ok := binlogdatapb.ConflictResolution_value[strings.ToUpper(createOptions.ConflictResolution)]; !ok {
+				return fmt.Errorf("invalid conflict-resolution value: %s", createOptions.ConflictResolution)
+			}
 			return nil
 		},
 		RunE: commandCreate,
@@ -110,6 +117,9 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		TabletTypes:               topoproto.MakeStringTypeCSV(common.CreateOptions.TabletTypes),
 		TabletSelectionPreference: tsp,
 		WorkflowOptions:           workflowOptions,
+		BidirectionalPeer:         createOptions.BidirectionalPeer,
+		ConflictResolution:        binlogdatapb.ConflictResolution(binlogdatapb.ConflictResolution_value[strings.ToUpper(createOptions.ConflictResolution)]),
+		ConflictTimestampColumn:   createOptions.ConflictTimestampColumn,
 	}
 
 	createOptions.TableSettings.parser, err = sqlparser.New(sqlparser.Options{
@@ -24,6 +24,8 @@ import (
 	"vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/common"
 	"vitess.io/vitess/go/mysql/config"
 	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 )
 
 var (
@@ -52,6 +54,9 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().StringVar(&common.CreateOptions.MySQLServerVersion, "mysql_server_version", fmt.Sprintf("%s-Vitess", config.DefaultMySQLVersion), "Configure the MySQL version to use for example for the parser.")
 	create.Flags().IntVar(&common.CreateOptions.TruncateUILen, "sql-max-length-ui", 512, "truncate queries in debug UIs to the given length (default 512)")
 	create.Flags().IntVar(&common.CreateOptions.TruncateErrLen, "sql-max-length-errors", 0, "truncate queries in error logs to the given length (default unlimited)")
+	create.Flags().StringVar(&createOptions.BidirectionalPeer, "bidirectional-peer", "", "The workflow that replicates in the other direction, from the target keyspace to the source keyspace. The changes it applies are not replicated back.")
+	create.Flags().StringVar(&createOptions.ConflictResolution, "conflict-resolution", binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION.String(), "How the changes that conflict with the changes made on the target are resolved, with a bidirectional peer. Possible values are NO_CONFLICT_RESOLUTION, LAST_WRITER_WINS, and RECORD_CONFLICTS.")
+	create.Flags().StringVar(&createOptions.ConflictTimestampColumn, "conflict-timestamp-column", "", "The column of the target tables that has the time of the last change of a row, used to resolve the conflicts.")
 	base.AddCommand(create)
 
 	// Generic workflow commands.
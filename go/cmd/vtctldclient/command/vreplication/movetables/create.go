@@ -25,21 +25,25 @@ import (
 	"vitess.io/vitess/go/cmd/vtctldclient/cli"
 	"vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/common"
 
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
 )
 
 var (
 	createOptions = struct {
-		SourceKeyspace      string
-		SourceShards        []string
-		ExternalClusterName string
-		AllTables           bool
-		IncludeTables       []string
-		ExcludeTables       []string
-		SourceTimeZone      string
-		NoRoutingRules      bool
-		AtomicCopy          bool
-		WorkflowOptions     vtctldatapb.WorkflowOptions
+		SourceKeyspace          string
+		SourceShards            []string
+		ExternalClusterName     string
+		AllTables               bool
+		IncludeTables           []string
+		ExcludeTables           []string
+		SourceTimeZone          string
+		NoRoutingRules          bool
+		AtomicCopy              bool
+		WorkflowOptions         vtctldatapb.WorkflowOptions
+		BidirectionalPeer       string
+		ConflictResolution      string
+		ConflictTimestampColumn string
 	}{}
 
 	// create makes a MoveTablesCreate gRPC call to a vtctld.
@@ -78,6 +82,9 @@ var (
 			if err := checkAtomicCopyOptions(); err != nil {
 				return err
 			}
+			if _, ok := binlogdatapb.ConflictResolution_value[strings.ToUpper(createOptions.ConflictResolution)]; !ok {
+				return fmt.Errorf("invalid conflict-resolution value: %s", createOptions.ConflictResolution)
+			}
 
 			tenantId := createOptions.WorkflowOptions.GetTenantId()
 			if len(createOptions.WorkflowOptions.GetShards()) > 0 && tenantId == "" {
@@ -112,6 +119,7 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		TargetKeyspace:            common.BaseOptions.TargetKeyspace,
 		SourceKeyspace:            createOptions.SourceKeyspace,
 		SourceShards:              createOptions.SourceShards,
+		ExternalClusterName:       createOptions.ExternalClusterName,
 		SourceTimeZone:            createOptions.SourceTimeZone,
 		Cells:                     common.CreateOptions.Cells,
 		TabletTypes:               common.CreateOptions.TabletTypes,
@@ -126,6 +134,9 @@ func commandCreate(cmd *cobra.Command, args []string) error {
 		NoRoutingRules:            createOptions.NoRoutingRules,
 		AtomicCopy:                createOptions.AtomicCopy,
 		WorkflowOptions:           &createOptions.WorkflowOptions,
+		BidirectionalPeer:         createOptions.BidirectionalPeer,
+		ConflictResolution:        binlogdatapb.ConflictResolution(binlogdatapb.ConflictResolution_value[strings.ToUpper(createOptions.ConflictResolution)]),
+		ConflictTimestampColumn:   createOptions.ConflictTimestampColumn,
 	}
 
 	resp, err := common.GetClient().MoveTablesCreate(common.GetCommandCtx(), req)
@@ -21,6 +21,8 @@ import (
 
 	"vitess.io/vitess/go/cmd/vtctldclient/command/vreplication/common"
 	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
 )
 
 var (
@@ -42,6 +44,7 @@ func registerCommands(root *cobra.Command) {
 	create.PersistentFlags().StringVar(&createOptions.SourceKeyspace, "source-keyspace", "", "Keyspace where the tables are being moved from.")
 	create.MarkPersistentFlagRequired("source-keyspace")
 	create.Flags().StringSliceVar(&createOptions.SourceShards, "source-shards", nil, "Source shards to copy data from when performing a partial MoveTables (experimental).")
+	create.Flags().StringVar(&createOptions.ExternalClusterName, "external-cluster-name", "", "The name of the mounted external Vitess cluster that the source keyspace lives in. See the Mount command.")
 	create.Flags().StringVar(&createOptions.SourceTimeZone, "source-time-zone", "", "Specifying this causes any DATETIME fields to be converted from the given time zone into UTC.")
 	create.Flags().BoolVar(&createOptions.AllTables, "all-tables", false, "Copy all tables from the source.")
 	create.Flags().StringSliceVar(&createOptions.IncludeTables, "tables", nil, "Source tables to copy.")
@@ -51,6 +54,9 @@ func registerCommands(root *cobra.Command) {
 	create.Flags().StringVar(&createOptions.WorkflowOptions.TenantId, "tenant-id", "", "(EXPERIMENTAL: Multi-tenant migrations only) The tenant ID to use for the MoveTables workflow into a multi-tenant keyspace.")
 	create.Flags().BoolVar(&createOptions.WorkflowOptions.StripShardedAutoIncrement, "remove-sharded-auto-increment", true, "If moving the table(s) to a sharded keyspace, remove any auto_increment clauses when copying the schema to the target as sharded keyspaces should rely on either user/application generated values or Vitess sequences to ensure uniqueness.")
 	create.Flags().StringSliceVar(&createOptions.WorkflowOptions.Shards, "shards", nil, "(EXPERIMENTAL: Multi-tenant migrations only) Specify that vreplication streams should only be created on this subset of target shards. Warning: you should first ensure that all rows on the source route to the specified subset of target shards using your VIndex of choice or you could lose data during the migration.")
+	create.Flags().StringVar(&createOptions.BidirectionalPeer, "bidirectional-peer", "", "The workflow that replicates in the other direction, from the target keyspace to the source keyspace, possibly in the external cluster. The changes it applies are not replicated back.")
+	create.Flags().StringVar(&createOptions.ConflictResolution, "conflict-resolution", binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION.String(), "How the changes that conflict with the changes made on the target are resolved, with a bidirectional peer. Possible values are NO_CONFLICT_RESOLUTION, LAST_WRITER_WINS, and RECORD_CONFLICTS.")
+	create.Flags().StringVar(&createOptions.ConflictTimestampColumn, "conflict-timestamp-column", "", "The column of the target tables that has the time of the last change of a row, used to resolve the conflicts.")
 	base.AddCommand(create)
 
 	opts := &common.SubCommandsOpts{
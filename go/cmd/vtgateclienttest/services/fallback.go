@@ -64,6 +64,10 @@ func (c fallbackClient) VStream(ctx context.Context, tabletType topodatapb.Table
 	return c.fallback.VStream(ctx, tabletType, vgtid, filter, flags, send)
 }
 
+func (c fallbackClient) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	return c.fallback.VStreamAck(ctx, consumerName, vgtid)
+}
+
 func (c fallbackClient) HandlePanic(err *error) {
 	c.fallback.HandlePanic(err)
 }
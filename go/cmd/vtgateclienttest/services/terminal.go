@@ -75,6 +75,10 @@ func (c *terminalClient) VStream(ctx context.Context, tabletType topodatapb.Tabl
 	return errTerminal
 }
 
+func (c *terminalClient) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	return errTerminal
+}
+
 func (c *terminalClient) HandlePanic(err *error) {
 	if x := recover(); x != nil {
 		log.Errorf("Uncaught panic:\n%v\n%s", x, tb.Stack(4))
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"vitess.io/vitess/go/acl"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo/rafttopo"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+var (
+	config = rafttopo.DefaultNodeConfig()
+	peers  []string
+
+	Main = &cobra.Command{
+		Use:   "vttopo",
+		Short: "vttopo is a member of a cluster of topo servers replicated with raft.",
+		Long: "`vttopo` serves a key-value store that its members replicate with raft, and that Vitess components use as their topo server with `--topo_implementation=raft`.\n\n" +
+			"Every member of a cluster is started with the same `--peers`, which maps the raft ID of each member to the address of its gRPC server. " +
+			"The members talk to each other on that port, and the topo clients connect to it with `--topo_global_server_address` set to the comma-separated list of the member addresses.\n\n" +
+			"A member persists its raft log in `--data-dir`. When restarted with an existing data directory, it catches up with the cluster from there.",
+		Example: `vttopo \
+	--id=1 \
+	--peers=1=host1:15999,2=host2:15999,3=host3:15999 \
+	--data-dir=${VTDATAROOT}/vttopo \
+	--grpc_port=15999`,
+		Args:    cobra.NoArgs,
+		Version: servenv.AppVersion.String(),
+		PreRunE: servenv.CobraPreRunE,
+		RunE:    run,
+	}
+)
+
+func init() {
+	servenv.RegisterDefaultFlags()
+	servenv.RegisterFlags()
+	servenv.RegisterGRPCServerFlags()
+	servenv.RegisterGRPCServerAuthFlags()
+	servenv.RegisterServiceMapFlag()
+
+	servenv.MoveFlagsToCobraCommand(Main)
+
+	Main.Flags().Uint64Var(&config.ID, "id", config.ID, "Raft ID of this member, one of the IDs in --peers.")
+	Main.Flags().StringSliceVar(&peers, "peers", peers, "Comma-separated list of the members of the cluster, as <raft ID>=<gRPC address>. All the members must use the same list.")
+	Main.Flags().StringVar(&config.DataDir, "data-dir", config.DataDir, "Directory where the member persists its raft log and snapshots.")
+	Main.Flags().DurationVar(&config.TickInterval, "tick-interval", config.TickInterval, "Duration of a raft tick.")
+	Main.Flags().IntVar(&config.ElectionTicks, "election-ticks", config.ElectionTicks, "Number of ticks without hearing from the leader after which a member starts an election.")
+	Main.Flags().IntVar(&config.HeartbeatTicks, "heartbeat-ticks", config.HeartbeatTicks, "Number of ticks between leader heartbeats.")
+	Main.Flags().Uint64Var(&config.SnapshotCount, "snapshot-count", config.SnapshotCount, "Number of applied entries after which the member snapshots its store and compacts its raft log.")
+	Main.Flags().DurationVar(&config.RequestTimeout, "request-timeout", config.RequestTimeout, "How long a request waits for the cluster to commit or confirm it before failing.")
+
+	acl.RegisterFlags(Main.Flags())
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	var err error
+	if config.Peers, err = parsePeers(peers); err != nil {
+		return err
+	}
+	if config.TickInterval <= 0 {
+		return fmt.Errorf("--tick-interval must be positive")
+	}
+
+	servenv.Init()
+
+	node, err := rafttopo.NewNode(config)
+	if err != nil {
+		return fmt.Errorf("failed to start raft member: %w", err)
+	}
+	servenv.OnRun(func() {
+		rafttopopb.RegisterRaftTopoServer(servenv.GRPCServer, node)
+	})
+	servenv.OnClose(func() {
+		log.Infof("stopping raft member %v", config.ID)
+		node.Close()
+	})
+
+	servenv.RunDefault()
+	return nil
+}
+
+// parsePeers parses the <raft ID>=<address> list of members.
+func parsePeers(list []string) (map[uint64]string, error) {
+	result := make(map[uint64]string, len(list))
+	for _, peer := range list {
+		id, addr, ok := strings.Cut(peer, "=")
+		if !ok || addr == "" {
+			return nil, fmt.Errorf("invalid peer %q, expected <raft ID>=<address>", peer)
+		}
+		raftID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil || raftID == 0 {
+			return nil, fmt.Errorf("invalid raft ID in peer %q", peer)
+		}
+		if _, ok := result[raftID]; ok {
+			return nil, fmt.Errorf("duplicate raft ID %v in --peers", raftID)
+		}
+		result[raftID] = addr
+	}
+	return result, nil
+}
@@ -46,6 +46,9 @@ var (
 	//go:embed vtaclcheck.txt
 	vtaclcheckTxt string
 
+	//go:embed vtcdc.txt
+	vtcdcTxt string
+
 	//go:embed vtcombo.txt
 	vtcomboTxt string
 
@@ -79,6 +82,9 @@ var (
 	//go:embed vttestserver.txt
 	vttestserverTxt string
 
+	//go:embed vttopo.txt
+	vttopoTxt string
+
 	//go:embed zkctld.txt
 	zkctldTxt string
 
@@ -97,6 +103,7 @@ var (
 		"topo2topo":        topo2topoTxt,
 		"vtaclcheck":       vtaclcheckTxt,
 		"vtbackup":         vtbackupTxt,
+		"vtcdc":            vtcdcTxt,
 		"vtcombo":          vtcomboTxt,
 		"vtctlclient":      vtctlclientTxt,
 		"vtctld":           vtctldTxt,
@@ -108,6 +115,7 @@ var (
 		"vttablet":         vttabletTxt,
 		"vttestserver":     vttestserverTxt,
 		"vttlstest":        vttlstestTxt,
+		"vttopo":           vttopoTxt,
 		"zk":               zkTxt,
 		"zkctl":            zkctlTxt,
 		"zkctld":           zkctldTxt,
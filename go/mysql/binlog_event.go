@@ -61,6 +61,10 @@ type BinlogEvent interface {
 	// IsXID returns true if this is an XID_EVENT, which is an alternate
 	// form of COMMIT.
 	IsXID() bool
+	// IsRowsQuery returns true if this is a ROWS_QUERY_EVENT, which
+	// precedes the row events of a statement when
+	// binlog_rows_query_log_events is enabled.
+	IsRowsQuery() bool
 	// IsStop returns true if this is a STOP_EVENT.
 	IsStop() bool
 	// IsGTID returns true if this is a GTID_EVENT.
@@ -104,6 +108,9 @@ type BinlogEvent interface {
 	// Query returns a Query struct representing data from a QUERY_EVENT.
 	// This is only valid if IsQuery() returns true.
 	Query(BinlogFormat) (Query, error)
+	// RowsQuery returns the statement of a ROWS_QUERY_EVENT.
+	// This is only valid if IsRowsQuery() returns true.
+	RowsQuery(BinlogFormat) (string, error)
 	// IntVar returns the type and value of the variable for an INTVAR_EVENT.
 	// This is only valid if IsIntVar() returns true.
 	IntVar(BinlogFormat) (byte, uint64, error)
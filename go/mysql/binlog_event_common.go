@@ -143,6 +143,11 @@ func (ev binlogEvent) IsXID() bool {
 	return ev.Type() == eXIDEvent
 }
 
+// IsRowsQuery implements BinlogEvent.IsRowsQuery().
+func (ev binlogEvent) IsRowsQuery() bool {
+	return ev.Type() == eRowsQueryEvent
+}
+
 // IsStop implements BinlogEvent.IsStop().
 func (ev binlogEvent) IsStop() bool {
 	return ev.Type() == eStopEvent
@@ -319,6 +324,21 @@ varsLoop:
 	return query, nil
 }
 
+// RowsQuery implements BinlogEvent.RowsQuery().
+//
+// Expected format (L = total length of event data):
+//
+//	# bytes   field
+//	1         length of the statement, truncated to 255 (ignored)
+//	L-1       statement (no NULL terminator)
+func (ev binlogEvent) RowsQuery(f BinlogFormat) (string, error) {
+	data := ev.Bytes()[f.HeaderLength:]
+	if len(data) < 1 {
+		return "", vterrors.Errorf(vtrpc.Code_INTERNAL, "ROWS_QUERY event is too short (%v < 1)", len(data))
+	}
+	return string(data[1:]), nil
+}
+
 // IntVar implements BinlogEvent.IntVar().
 //
 // Expected format (L = total length of event data):
@@ -159,6 +159,10 @@ func (ev filePosFakeEvent) IsXID() bool {
 	return false
 }
 
+func (ev filePosFakeEvent) IsRowsQuery() bool {
+	return false
+}
+
 func (ev filePosFakeEvent) IsStop() bool {
 	return false
 }
@@ -227,6 +231,10 @@ func (ev filePosFakeEvent) Query(BinlogFormat) (Query, error) {
 	return Query{}, nil
 }
 
+func (ev filePosFakeEvent) RowsQuery(BinlogFormat) (string, error) {
+	return "", nil
+}
+
 func (ev filePosFakeEvent) IntVar(BinlogFormat) (byte, uint64, error) {
 	return 0, 0, nil
 }
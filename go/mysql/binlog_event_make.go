@@ -264,6 +264,17 @@ func NewInvalidQueryEvent(f BinlogFormat, s *FakeBinlogStream) BinlogEvent {
 	return NewMysql56BinlogEvent(ev)
 }
 
+// NewRowsQueryEvent returns a RowsQuery event for the statement.
+func NewRowsQueryEvent(f BinlogFormat, s *FakeBinlogStream, sql string) BinlogEvent {
+	length := 1 + len(sql)
+	data := make([]byte, length)
+	data[0] = byte(min(len(sql), 255))
+	copy(data[1:], sql)
+
+	ev := s.Packetize(f, eRowsQueryEvent, 0, data)
+	return NewMysql56BinlogEvent(ev)
+}
+
 // NewXIDEvent returns a XID event. We do not use the data, so keep it 0.
 func NewXIDEvent(f BinlogFormat, s *FakeBinlogStream) BinlogEvent {
 	length := 8
@@ -85,6 +85,23 @@ func TestQueryEvent(t *testing.T) {
 
 }
 
+func TestRowsQueryEvent(t *testing.T) {
+	f := NewMySQL56BinlogFormat()
+	s := NewFakeBinlogStream()
+
+	sql := "/* tagged */ insert into t1(id) values (1)"
+	event := NewRowsQueryEvent(f, s, sql)
+	require.True(t, event.IsValid(), "NewRowsQueryEvent returned an invalid event")
+	require.True(t, event.IsRowsQuery(), "NewRowsQueryEvent returned a non-rows-query event: %v", event)
+
+	event, _, err := event.StripChecksum(f)
+	require.NoError(t, err, "StripChecksum failed: %v", err)
+
+	got, err := event.RowsQuery(f)
+	require.NoError(t, err, "event.RowsQuery() failed: %v", err)
+	require.Equal(t, sql, got)
+}
+
 func TestXIDEvent(t *testing.T) {
 	f := NewMySQL56BinlogFormat()
 	s := NewFakeBinlogStream()
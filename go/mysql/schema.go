@@ -34,6 +34,18 @@ const (
 		ORDER BY table_name, SEQ_IN_INDEX`
 	// ShowRowsRead is the query used to find the number of rows read.
 	ShowRowsRead = "show status like 'Innodb_rows_read'"
+	// ShowTableRowCounts is the query used to fetch the approximate number of rows of the tables.
+	ShowTableRowCounts = `
+		SELECT TABLE_NAME as table_name, TABLE_ROWS as table_rows
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY table_name`
+	// ShowIndexCardinalities is the query used to fetch the estimated cardinality of the indexes.
+	ShowIndexCardinalities = `
+		SELECT TABLE_NAME as table_name, INDEX_NAME as index_name, COLUMN_NAME as column_name, CARDINALITY as cardinality
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = DATABASE()
+		ORDER BY table_name, index_name, SEQ_IN_INDEX`
 
 	// GetColumnNamesQueryPatternForTable is used for mocking queries in unit tests
 	GetColumnNamesQueryPatternForTable = `SELECT COLUMN_NAME.*TABLE_NAME.*%s.*`
@@ -221,6 +221,7 @@ var stateToMysqlCode = map[vterrors.State]mysqlCode{
 	vterrors.WrongValueCountOnRow:                {num: ERWrongValueCountOnRow, state: SSWrongValueCountOnRow},
 	vterrors.WrongArguments:                      {num: ERWrongArguments, state: SSUnknownSQLState},
 	vterrors.ViewWrongList:                       {num: ERViewWrongList, state: SSUnknownSQLState},
+	vterrors.WrongUsage:                          {num: ERWrongUsage, state: SSUnknownSQLState},
 	vterrors.UnknownStmtHandler:                  {num: ERUnknownStmtHandler, state: SSUnknownSQLState},
 	vterrors.KeyDoesNotExist:                     {num: ERKeyDoesNotExist, state: SSClientError},
 	vterrors.UnknownTimeZone:                     {num: ERUnknownTimeZone, state: SSUnknownSQLState},
@@ -2,6 +2,7 @@ package vreplication
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"testing"
 
@@ -34,13 +35,19 @@ func getSidecarDBTables(t *testing.T, tabletID string) (numTablets int, tables [
 
 var sidecarDBTables []string
 var numSidecarDBTables int
+
+// sidecarDBTablesWithoutLog are the sidecar tables left once ddls1 drops vreplication_log.
+var sidecarDBTablesWithoutLog []string
 var ddls1, ddls2 []string
 
 func init() {
 	sidecarDBTables = []string{"copy_state", "dt_participant", "dt_state", "heartbeat", "post_copy_action",
 		"redo_state", "redo_statement", "reparent_journal", "resharding_journal", "schema_migrations", "schema_version",
-		"tables", "udfs", "vdiff", "vdiff_log", "vdiff_table", "views", "vreplication", "vreplication_log"}
+		"tables", "udfs", "vdiff", "vdiff_log", "vdiff_table", "views", "vreplication", "vreplication_conflicts", "vreplication_log", "vstream_checkpoint", "vstream_consumer_lease"}
 	numSidecarDBTables = len(sidecarDBTables)
+	sidecarDBTablesWithoutLog = slices.DeleteFunc(slices.Clone(sidecarDBTables), func(table string) bool {
+		return table == "vreplication_log"
+	})
 	ddls1 = []string{
 		"drop table _vt.vreplication_log",
 		"alter table _vt.vreplication drop column defer_secondary_keys",
@@ -86,8 +93,8 @@ func TestSidecarDB(t *testing.T) {
 
 	t.Run("modify schema, prs, and self heal on primary", func(t *testing.T) {
 		numChanges := modifySidecarDBSchema(t, vc, currentPrimary, ddls1)
-		validateSidecarDBTables(t, tablet100, sidecarDBTables[0:numSidecarDBTables-1])
-		validateSidecarDBTables(t, tablet101, sidecarDBTables[0:numSidecarDBTables-1])
+		validateSidecarDBTables(t, tablet100, sidecarDBTablesWithoutLog)
+		validateSidecarDBTables(t, tablet101, sidecarDBTablesWithoutLog)
 
 		prs(t, keyspace, shard)
 		currentPrimary = tablet101
@@ -625,6 +625,25 @@ func TestGroupConcatAggregation(t *testing.T) {
 	compareRow(t, mQr, vtQr, nil, []int{0})
 }
 
+// TestGroupConcatEvaluatedOnVtgate tests group_concat with ordering, distinct and multiple
+// arguments, which vitess evaluates from the rows of all the shards.
+func TestGroupConcatEvaluatedOnVtgate(t *testing.T) {
+	utils.SkipIfBinaryIsBelowVersion(t, 21, "vtgate")
+	mcmp, closer := start(t)
+	defer closer()
+	mcmp.Exec("insert into t1(t1_id, `name`, `value`, shardkey) values(1,'a1',null,100), (2,'b1','foo',20), (3,'c1','foo',10), (4,'a1','foo',100), (5,'d1','toto',200), (6,'c1',null,893), (10,'a1','titi',2380), (20,'b1','tete',12833), (9,'e1','yoyo',783493)")
+	mcmp.Exec("insert into t2(id, shardKey) values (1, 10), (2, 20)")
+
+	mcmp.Exec(`SELECT group_concat(name order by t1_id desc) FROM t1`)
+	mcmp.Exec(`SELECT name, group_concat(value order by t1_id separator '|') FROM t1 group by name order by name`)
+	mcmp.Exec(`SELECT group_concat(distinct name, value order by name, value) FROM t1`)
+	mcmp.Exec(`SELECT group_concat(distinct value order by value desc) FROM t1`)
+	mcmp.Exec(`SELECT group_concat(value order by t1.t1_id) FROM t1 join t2 on t1.shardKey = t2.shardKey`)
+
+	mcmp.Exec("set group_concat_max_len = 10")
+	mcmp.Exec(`SELECT group_concat(name order by t1_id) FROM t1`)
+}
+
 func compareRow(t *testing.T, mRes *sqltypes.Result, vtRes *sqltypes.Result, grpCols []int, fCols []int) {
 	require.Equal(t, len(mRes.Rows), len(vtRes.Rows), "mysql and vitess result count does not match")
 	for _, row := range vtRes.Rows {
@@ -664,20 +683,26 @@ func TestDistinctAggregation(t *testing.T) {
 		expectedErr string
 		minVersion  int
 	}{{
-		query:       `SELECT COUNT(DISTINCT value), SUM(DISTINCT shardkey) FROM t1`,
-		expectedErr: "VT12001: unsupported: only one DISTINCT aggregation is allowed in a SELECT: sum(distinct shardkey) (errno 1235) (sqlstate 42000)",
+		minVersion: 21,
+		query:      `SELECT COUNT(DISTINCT value), SUM(DISTINCT shardkey) FROM t1`,
 	}, {
 		query: `SELECT a.t1_id, SUM(DISTINCT b.shardkey) FROM t1 a, t1 b group by a.t1_id`,
 	}, {
 		query: `SELECT a.value, SUM(DISTINCT b.shardkey) FROM t1 a, t1 b group by a.value`,
 	}, {
-		query:       `SELECT count(distinct a.value), SUM(DISTINCT b.t1_id) FROM t1 a, t1 b`,
-		expectedErr: "VT12001: unsupported: only one DISTINCT aggregation is allowed in a SELECT: sum(distinct b.t1_id) (errno 1235) (sqlstate 42000)",
+		minVersion: 21,
+		query:      `SELECT count(distinct a.value), SUM(DISTINCT b.t1_id) FROM t1 a, t1 b`,
 	}, {
 		query: `SELECT a.value, SUM(DISTINCT b.t1_id), min(DISTINCT a.t1_id) FROM t1 a, t1 b group by a.value`,
 	}, {
 		minVersion: 19,
 		query:      `SELECT count(distinct name, shardkey) from t1`,
+	}, {
+		minVersion: 21,
+		query:      `SELECT name, COUNT(DISTINCT value), COUNT(DISTINCT shardkey), AVG(DISTINCT t1_id) FROM t1 group by name`,
+	}, {
+		minVersion: 21,
+		query:      `SELECT AVG(DISTINCT shardkey) FROM t1`,
 	}}
 
 	for _, tc := range tcases {
@@ -183,6 +183,60 @@ func TestUpdateWithLimit(t *testing.T) {
 
 }
 
+// TestBatchedDeleteWithOrderByLimit purges rows in ordered batches, the way a purge job would.
+func TestBatchedDeleteWithOrderByLimit(t *testing.T) {
+	mcmp, closer := start(t)
+	defer closer()
+
+	mcmp.Exec("insert into s_tbl(id, num) values (1,10), (2,10), (3,10), (4,20), (5,5), (6,15), (7,17), (8,80)")
+
+	// each batch must remove the lowest ids among the matching rows, even though they live on different shards.
+	qr := mcmp.Exec(`delete from s_tbl where num < 20 order by id limit 2`)
+	require.EqualValues(t, 2, qr.RowsAffected)
+	mcmp.AssertMatches(`select id from s_tbl order by id`,
+		`[[INT64(3)] [INT64(4)] [INT64(5)] [INT64(6)] [INT64(7)] [INT64(8)]]`)
+
+	qr = mcmp.Exec(`delete from s_tbl where num < 20 order by id desc limit 2`)
+	require.EqualValues(t, 2, qr.RowsAffected)
+	mcmp.AssertMatches(`select id from s_tbl order by id`,
+		`[[INT64(3)] [INT64(4)] [INT64(5)] [INT64(8)]]`)
+
+	qr = mcmp.Exec(`delete from s_tbl where num < 20 order by id limit 2`)
+	require.EqualValues(t, 2, qr.RowsAffected)
+	qr = mcmp.Exec(`delete from s_tbl where num < 20 order by id limit 2`)
+	require.EqualValues(t, 0, qr.RowsAffected)
+	mcmp.AssertMatches(`select id from s_tbl order by id`,
+		`[[INT64(4)] [INT64(8)]]`)
+}
+
+// TestUpdatePrimaryVindex updates the primary vindex column of a table that allows it,
+// which moves the rows to the shards owning their new keyspace ids.
+func TestUpdatePrimaryVindex(t *testing.T) {
+	mcmp, closer := start(t)
+	defer closer()
+
+	// region 1, 2 and 3 live on -80, region 4 on 80-.
+	mcmp.Exec("insert into mv_tbl(id, region_id, col) values (1,1,'a'), (2,2,'b'), (3,3,'c')")
+
+	// moving a row to another shard needs TWOPC or the user's consent.
+	utils.AssertContainsError(t, mcmp.VtConn, "update mv_tbl set region_id = 4 where id = 1", "VT09031")
+	mcmp.AssertMatches("select id, region_id, col from mv_tbl order by id",
+		`[[INT64(1) INT64(1) VARCHAR("a")] [INT64(2) INT64(2) VARCHAR("b")] [INT64(3) INT64(3) VARCHAR("c")]]`)
+
+	// staying on the same shard is always allowed.
+	mcmp.Exec("update mv_tbl set region_id = 1 where id = 3")
+
+	qr := mcmp.Exec("update /*vt+ ALLOW_NON_ATOMIC_ROW_MOVE */ mv_tbl set region_id = 4, col = 'x' where id in (1, 2)")
+	assert.EqualValues(t, 2, qr.RowsAffected)
+	mcmp.AssertMatches("select id, region_id, col from mv_tbl order by id",
+		`[[INT64(1) INT64(4) VARCHAR("x")] [INT64(2) INT64(4) VARCHAR("x")] [INT64(3) INT64(1) VARCHAR("c")]]`)
+
+	// the rows are found through the lookup vindex and the primary vindex after the move.
+	mcmp.AssertMatches("select id, region_id from mv_tbl where id = 1", `[[INT64(1) INT64(4)]]`)
+	mcmp.AssertMatches("select id from mv_tbl where region_id = 4 order by id", `[[INT64(1)] [INT64(2)]]`)
+	utils.AssertMatches(t, mcmp.VtConn, "select count(*) from mv_tbl", `[[INT64(3)]]`)
+}
+
 // TestMultiTableUpdate executed multi-table update queries
 func TestMultiTableUpdate(t *testing.T) {
 	mcmp, closer := start(t)
@@ -192,6 +246,10 @@ func TestMultiTableUpdate(t *testing.T) {
 	mcmp.Exec("insert into order_tbl(region_id, oid, cust_no) values (1,1,4), (1,2,2), (2,3,5), (2,4,55)")
 	mcmp.Exec("insert into oevent_tbl(oid, ename) values (1,'a'), (2,'b'), (3,'a'), (4,'c')")
 
+	// multi table update does not allow ORDER BY or LIMIT
+	mcmp.AssertContainsError(`update order_tbl o join oevent_tbl ev on o.oid = ev.oid set ev.ename = 'a' limit 1`,
+		`Incorrect usage of UPDATE and LIMIT`)
+
 	// multi table update
 	qr := mcmp.Exec(`update order_tbl o join oevent_tbl ev on o.oid = ev.oid set ev.ename = 'a' where ev.oid > 3`)
 	assert.EqualValues(t, 1, qr.RowsAffected)
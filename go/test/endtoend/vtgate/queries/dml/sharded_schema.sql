@@ -93,4 +93,19 @@ create table j_tbl
     id  bigint,
     jdoc json,
     primary key (id)
-) Engine = InnoDB;
\ No newline at end of file
+) Engine = InnoDB;
+
+create table mv_tbl
+(
+    id        bigint,
+    region_id bigint,
+    col       varchar(50),
+    primary key (id)
+) Engine = InnoDB;
+
+create table mv_id_idx
+(
+    id          bigint,
+    keyspace_id varbinary(20),
+    primary key (id)
+) Engine = InnoDB;
@@ -0,0 +1,639 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	logutilpb "vitess.io/vitess/go/vt/proto/logutil"
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// Chunked backups split the files of a full backup into chunks whose boundaries depend on
+// their content, so that the chunks of the pages that did not change since a previous backup
+// are the same, and are only uploaded once. The chunks of all the backups of a shard are kept
+// in a chunk store, and each file of a chunked backup lists the chunks to concatenate to restore it.
+//
+// The chunk store of a shard lives next to its backups, in the backup storage directory
+// _chunks/<keyspace>/<shard>. Each chunk is stored there as a backup named after the sha256 of its
+// contents, or for encrypted chunks after their HMAC-SHA256 under a secret derived from the key that
+// wraps the data keys, so that the names do not reveal the contents. The backup holds a single file that starts with a JSON header describing how the chunk was
+// compressed and encrypted. Chunks are self-describing because they outlive the backup that
+// uploaded them.
+
+const (
+	// backupChunksDir is the backup storage directory holding the chunk stores of all the shards.
+	backupChunksDir = "_chunks"
+	// backupChunkFileName is the name of the only file of a chunk.
+	backupChunkFileName = "chunk"
+
+	// Chunks are at least minBackupChunkSize bytes long, except for the last chunk of a file,
+	// and at most maxBackupChunkSize bytes long. A chunk ends after minBackupChunkSize bytes
+	// when the backupChunkMaskBits top bits of the rolling hash of its contents are zero,
+	// which makes chunks about 1 MiB long on average.
+	minBackupChunkSize   = 256 * 1024
+	maxBackupChunkSize   = 4 * 1024 * 1024
+	backupChunkMaskBits  = 20
+	backupChunkMask      = uint64(1<<backupChunkMaskBits-1) << (64 - backupChunkMaskBits)
+	maxBackupChunkHeader = 4096
+)
+
+var (
+	// builtinBackupChunking enables chunked, deduplicated full backups in the builtin backup engine.
+	builtinBackupChunking = false
+
+	// backupChunkGear maps each byte to the random value it adds to the rolling hash.
+	// It must never change, or new backups would not share chunks with the existing ones.
+	backupChunkGear [256]uint64
+
+	// discardLogger swallows the messages logged for every chunk, like the compression engine in use.
+	discardLogger = logutil.NewCallbackLogger(func(*logutilpb.Event) {})
+
+	// existingBackupChunk is the finished upload of the chunks that were already in the chunk store.
+	existingBackupChunk = &backupChunkUpload{done: make(chan struct{})}
+)
+
+func init() {
+	// splitmix64, from a fixed seed
+	seed := uint64(0x766974657373)
+	for i := range backupChunkGear {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		backupChunkGear[i] = z ^ (z >> 31)
+	}
+	close(existingBackupChunk.done)
+}
+
+// backupChunkStoreDir returns the directory of the chunk store of the backups stored in the given directory.
+func backupChunkStoreDir(backupDir string) string {
+	return path.Join(backupChunksDir, backupDir)
+}
+
+// backupChunkBoundary returns the length of the chunk at the start of data.
+// data must hold maxBackupChunkSize bytes, unless it is the end of the file.
+func backupChunkBoundary(data []byte) int {
+	if len(data) <= minBackupChunkSize {
+		return len(data)
+	}
+	end := min(len(data), maxBackupChunkSize)
+	var h uint64
+	for i := minBackupChunkSize; i < end; i++ {
+		h = (h << 1) + backupChunkGear[data[i]]
+		if h&backupChunkMask == 0 {
+			return i + 1
+		}
+	}
+	return end
+}
+
+// backupChunker splits the data it reads into content-defined chunks.
+type backupChunker struct {
+	r     io.Reader
+	buf   []byte
+	start int
+	end   int
+	eof   bool
+}
+
+func newBackupChunker(r io.Reader) *backupChunker {
+	return &backupChunker{r: r, buf: make([]byte, maxBackupChunkSize)}
+}
+
+// next returns the next chunk, which is only valid until the following call, or io.EOF once all the data was read.
+func (c *backupChunker) next() ([]byte, error) {
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	if !c.eof {
+		n, err := io.ReadFull(c.r, c.buf[c.end:])
+		c.end += n
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			c.eof = true
+		default:
+			return nil, err
+		}
+	}
+	if c.end == 0 {
+		return nil, io.EOF
+	}
+	c.start = backupChunkBoundary(c.buf[:c.end])
+	return c.buf[:c.start], nil
+}
+
+// backupChunkHeader starts the file of every chunk, and tells how to read the rest of it.
+type backupChunkHeader struct {
+	// CompressionEngine is the builtin compression engine the chunk was compressed with,
+	// or empty if it is not compressed.
+	CompressionEngine string `json:",omitempty"`
+
+	// EncryptionKeyProvider, EncryptionKeyID and EncryptedDataKey are the key of the backup
+	// that uploaded the chunk, as in its manifest, if the chunk is encrypted.
+	EncryptionKeyProvider string `json:",omitempty"`
+	EncryptionKeyID       string `json:",omitempty"`
+	EncryptedDataKey      []byte `json:",omitempty"`
+}
+
+// backupChunkUpload is the upload of a chunk, that all the files having the chunk wait for.
+type backupChunkUpload struct {
+	done chan struct{}
+	err  error
+}
+
+// backupChunkWriter uploads the chunks of a backup to a chunk store.
+type backupChunkWriter struct {
+	bs     backupstorage.BackupStorage
+	dir    string
+	enc    *backupEncryption
+	keyTag string
+	header []byte
+
+	mu sync.Mutex
+	// chunks holds the chunks the backup can reference: the chunks referenced by the complete
+	// backups of the shard, and the chunks uploaded by this backup.
+	chunks map[string]*backupChunkUpload
+	// stale holds the chunks of the store no complete backup references, left by failed backups.
+	// They are uploaded again if the backup needs them, since they may not be whole.
+	stale map[string]bool
+
+	total    atomic.Int64
+	uploaded atomic.Int64
+}
+
+// newBackupChunkWriter returns a chunk writer to the chunk store of the backups of the given directory.
+// The chunks are encrypted if enc is not nil, and compressed with the builtin compression engine if
+// compression is enabled.
+func newBackupChunkWriter(ctx context.Context, bs backupstorage.BackupStorage, backupDir string, enc *backupEncryption) (*backupChunkWriter, error) {
+	header := backupChunkHeader{}
+	if backupStorageCompress {
+		if ExternalCompressorCmd != "" {
+			return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "chunked backups cannot use an external compressor")
+		}
+		header.CompressionEngine = CompressionEngineName
+	}
+	cw := &backupChunkWriter{
+		bs:     bs,
+		dir:    backupChunkStoreDir(backupDir),
+		enc:    enc,
+		chunks: make(map[string]*backupChunkUpload),
+		stale:  make(map[string]bool),
+	}
+	if enc != nil {
+		// Encrypted chunks are only shared by the backups encrypted with the same key.
+		sum := sha256.Sum256([]byte(enc.keyProvider + ":" + enc.keyID))
+		cw.keyTag = hex.EncodeToString(sum[:8])
+		header.EncryptionKeyProvider = enc.keyProvider
+		header.EncryptionKeyID = enc.keyID
+		header.EncryptedDataKey = enc.wrappedKey
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	cw.header = append(data, '\n')
+
+	referenced, err := referencedBackupChunks(ctx, bs, backupDir, true /* skipIncomplete */)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := listBackupChunks(ctx, bs, cw.dir)
+	if err != nil {
+		return nil, err
+	}
+	for id := range stored {
+		if referenced[id] {
+			cw.chunks[id] = existingBackupChunk
+		} else {
+			cw.stale[id] = true
+		}
+	}
+	return cw, nil
+}
+
+// chunkID returns the id of the chunk with the given contents.
+func (cw *backupChunkWriter) chunkID(data []byte) string {
+	id := backupChunkHash(cw.enc, data)
+	if cw.keyTag != "" {
+		id += "-" + cw.keyTag
+	}
+	return id
+}
+
+// backupChunkHash returns the hash part of the id of a chunk: the sha256 of its contents,
+// or their HMAC-SHA256 if the chunk is encrypted.
+func backupChunkHash(enc *backupEncryption, data []byte) string {
+	if enc == nil {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, enc.chunkIDKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// put stores a chunk, unless the chunk store already has it, and returns its id.
+func (cw *backupChunkWriter) put(ctx context.Context, data []byte) (string, error) {
+	id := cw.chunkID(data)
+	cw.total.Add(1)
+
+	cw.mu.Lock()
+	up, ok := cw.chunks[id]
+	if ok {
+		cw.mu.Unlock()
+		select {
+		case <-up.done:
+			return id, up.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	up = &backupChunkUpload{done: make(chan struct{})}
+	cw.chunks[id] = up
+	stale := cw.stale[id]
+	cw.mu.Unlock()
+
+	up.err = cw.upload(ctx, id, data, stale)
+	close(up.done)
+	if up.err == nil {
+		cw.uploaded.Add(1)
+	}
+	return id, up.err
+}
+
+func (cw *backupChunkWriter) upload(ctx context.Context, id string, data []byte, stale bool) (finalErr error) {
+	if stale {
+		if err := cw.bs.RemoveBackup(ctx, cw.dir, id); err != nil {
+			return vterrors.Wrapf(err, "cannot remove stale chunk %v", id)
+		}
+	}
+	bh, err := cw.bs.StartBackup(ctx, cw.dir, id)
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot start upload of chunk %v", id)
+	}
+	defer func() {
+		if finalErr != nil {
+			if err := bh.AbortBackup(ctx); err != nil {
+				finalErr = errors.Join(finalErr, vterrors.Wrapf(err, "cannot abort upload of chunk %v", id))
+			}
+			return
+		}
+		if err := bh.EndBackup(ctx); err != nil {
+			finalErr = vterrors.Wrapf(err, "cannot end upload of chunk %v", id)
+		}
+	}()
+
+	dest, err := bh.AddFile(ctx, backupChunkFileName, backupstorage.FileSizeUnknown)
+	if err != nil {
+		return vterrors.Wrapf(err, "cannot add file of chunk %v", id)
+	}
+	if err := cw.writeChunk(dest, id, data); err != nil {
+		dest.Close()
+		return vterrors.Wrapf(err, "cannot write chunk %v", id)
+	}
+	if err := dest.Close(); err != nil {
+		return vterrors.Wrapf(err, "cannot close file of chunk %v", id)
+	}
+	return nil
+}
+
+// writeChunk writes the header of a chunk followed by its contents, compressed and then encrypted.
+func (cw *backupChunkWriter) writeChunk(w io.Writer, id string, data []byte) error {
+	if _, err := w.Write(cw.header); err != nil {
+		return err
+	}
+	var closers []io.Closer
+	if cw.enc != nil {
+		encryptor, err := cw.enc.newWriter(id, w)
+		if err != nil {
+			return err
+		}
+		w = encryptor
+		closers = append(closers, encryptor)
+	}
+	if backupStorageCompress {
+		compressor, err := newBuiltinCompressor(CompressionEngineName, w, discardLogger)
+		if err != nil {
+			return err
+		}
+		w = compressor
+		closers = append(closers, compressor)
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	// close the compressor before the encryptor
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verify checks that the chunk store still has all the chunks of the given files, which could have
+// been garbage collected if the backups that referenced them were removed during the backup.
+func (cw *backupChunkWriter) verify(ctx context.Context, fes []FileEntry) error {
+	stored, err := listBackupChunks(ctx, cw.bs, cw.dir)
+	if err != nil {
+		return err
+	}
+	for _, fe := range fes {
+		for _, id := range fe.Chunks {
+			if !stored[id] {
+				return vterrors.Errorf(vtrpc.Code_ABORTED, "chunk %v of %v was removed from the chunk store during the backup", id, fe.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// backupChunkReader reads the chunks of a backup from a chunk store.
+type backupChunkReader struct {
+	dir    string
+	chunks map[string]backupstorage.BackupHandle
+
+	mu sync.Mutex
+	// encs holds the decryption of the chunks, keyed by their wrapped data key.
+	encs map[string]*backupEncryption
+}
+
+func newBackupChunkReader(ctx context.Context, bs backupstorage.BackupStorage, dir string) (*backupChunkReader, error) {
+	bhs, err := bs.ListBackups(ctx, dir)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot list chunks in %v", dir)
+	}
+	cr := &backupChunkReader{
+		dir:    dir,
+		chunks: make(map[string]backupstorage.BackupHandle, len(bhs)),
+		encs:   make(map[string]*backupEncryption),
+	}
+	for _, bh := range bhs {
+		cr.chunks[bh.Name()] = bh
+	}
+	return cr, nil
+}
+
+// read returns the contents of a chunk, after checking they match its id.
+func (cr *backupChunkReader) read(ctx context.Context, id string) (data []byte, finalErr error) {
+	bh, ok := cr.chunks[id]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpc.Code_NOT_FOUND, "chunk %v is missing from %v", id, cr.dir)
+	}
+	source, err := bh.ReadFile(ctx, backupChunkFileName)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot open chunk %v", id)
+	}
+	defer source.Close()
+
+	br := bufio.NewReader(io.LimitReader(source, maxBackupChunkHeader))
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read header of chunk %v", id)
+	}
+	var header backupChunkHeader
+	if err := json.Unmarshal(line, &header); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot decode header of chunk %v", id)
+	}
+
+	// the rest of the chunk is what the limited reader has buffered, followed by the source
+	var reader io.Reader = io.MultiReader(br, source)
+	var enc *backupEncryption
+	if header.EncryptedDataKey != nil {
+		enc, err = cr.decryption(ctx, header)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot decrypt chunk %v", id)
+		}
+		reader = enc.newReader(id, reader)
+	}
+	if header.CompressionEngine != "" {
+		engine := header.CompressionEngine
+		if engine == PargzipCompressor {
+			engine = PgzipCompressor
+		}
+		decompressor, err := newBuiltinDecompressor(engine, reader, discardLogger)
+		if err != nil {
+			return nil, vterrors.Wrapf(err, "cannot decompress chunk %v", id)
+		}
+		defer func() {
+			if err := decompressor.Close(); err != nil && finalErr == nil {
+				finalErr = vterrors.Wrapf(err, "cannot close decompressor of chunk %v", id)
+			}
+		}()
+		reader = decompressor
+	}
+
+	data, err = io.ReadAll(io.LimitReader(reader, maxBackupChunkSize+1))
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read chunk %v", id)
+	}
+	// The ids of encrypted chunks carry the tag of their key, the ids of plain chunks do not.
+	hash, _, tagged := strings.Cut(id, "-")
+	if len(data) > maxBackupChunkSize || tagged != (enc != nil) || hash != backupChunkHash(enc, data) {
+		return nil, vterrors.Errorf(vtrpc.Code_DATA_LOSS, "chunk %v is corrupted", id)
+	}
+	return data, nil
+}
+
+// decryption returns the decryption of the chunks uploaded with the data key of the given header.
+// Data keys are unwrapped once per backup that uploaded chunks.
+func (cr *backupChunkReader) decryption(ctx context.Context, header backupChunkHeader) (*backupEncryption, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if enc, ok := cr.encs[string(header.EncryptedDataKey)]; ok {
+		return enc, nil
+	}
+	enc, err := openBackupEncryption(ctx, &builtinBackupManifest{
+		EncryptionKeyProvider: header.EncryptionKeyProvider,
+		EncryptionKeyID:       header.EncryptionKeyID,
+		EncryptedDataKey:      header.EncryptedDataKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	cr.encs[string(header.EncryptedDataKey)] = enc
+	return enc, nil
+}
+
+// listBackupChunks returns the ids of the chunks of a chunk store.
+func listBackupChunks(ctx context.Context, bs backupstorage.BackupStorage, dir string) (map[string]bool, error) {
+	bhs, err := bs.ListBackups(ctx, dir)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot list chunks in %v", dir)
+	}
+	chunks := make(map[string]bool, len(bhs))
+	for _, bh := range bhs {
+		chunks[bh.Name()] = true
+	}
+	return chunks, nil
+}
+
+// referencedBackupChunks returns the ids of the chunks referenced by the backups of the given directory.
+// Backups whose manifest cannot be read are skipped if skipIncomplete is true, and are an error otherwise:
+// it is not possible to tell whether they reference any chunk.
+func referencedBackupChunks(ctx context.Context, bs backupstorage.BackupStorage, backupDir string, skipIncomplete bool) (map[string]bool, error) {
+	bhs, err := bs.ListBackups(ctx, backupDir)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot list backups in %v", backupDir)
+	}
+	chunks := make(map[string]bool)
+	for _, bh := range bhs {
+		var bm builtinBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &bm); err != nil {
+			if skipIncomplete {
+				continue
+			}
+			return nil, vterrors.Wrapf(err, "cannot tell which chunks backup %v references", bh.Name())
+		}
+		for _, fe := range bm.FileEntries {
+			for _, id := range fe.Chunks {
+				chunks[id] = true
+			}
+		}
+	}
+	return chunks, nil
+}
+
+// removeUnreferencedBackupChunks removes the chunks of the chunk store of the given directory that
+// none of its backups references anymore. It must not run while a chunked backup of the directory
+// is in progress: the chunks of the backup are not referenced until its manifest is written.
+// Such a backup notices the chunks it lost and fails.
+func removeUnreferencedBackupChunks(ctx context.Context, bs backupstorage.BackupStorage, backupDir string) (removed int, err error) {
+	dir := backupChunkStoreDir(backupDir)
+	stored, err := listBackupChunks(ctx, bs, dir)
+	if err != nil || len(stored) == 0 {
+		return 0, err
+	}
+	referenced, err := referencedBackupChunks(ctx, bs, backupDir, false /* skipIncomplete */)
+	if err != nil {
+		return 0, err
+	}
+	for id := range stored {
+		if referenced[id] {
+			continue
+		}
+		if err := bs.RemoveBackup(ctx, dir, id); err != nil {
+			return removed, vterrors.Wrapf(err, "cannot remove chunk %v", id)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RemoveBackup removes a backup from the backup storage, and then removes the chunks
+// of the chunked backups that no remaining backup of the directory references.
+func RemoveBackup(ctx context.Context, bs backupstorage.BackupStorage, dir, name string) error {
+	if err := bs.RemoveBackup(ctx, dir, name); err != nil {
+		return err
+	}
+	if _, err := removeUnreferencedBackupChunks(ctx, bs, dir); err != nil {
+		return vterrors.Wrapf(err, "backup %v was removed, but its chunks could not be garbage collected", name)
+	}
+	return nil
+}
+
+// backupChunkedFile backs up an individual file as a list of chunks, and uploads the chunks
+// the chunk store does not have yet.
+func (be *BuiltinBackupEngine) backupChunkedFile(ctx context.Context, params BackupParams, cw *backupChunkWriter, fe *FileEntry) error {
+	source, err := fe.open(params.Cnf, true)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	fi, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	params.Logger.Infof("Backing up file in chunks: %v", fe.Name)
+	br := newBackupReader(fe.Name, fi.Size(), source)
+	go br.ReportProgress(builtinBackupProgress, params.Logger, false /*restore*/)
+	defer br.Close()
+
+	chunker := newBackupChunker(br)
+	fe.Chunks = nil
+	for {
+		chunk, err := chunker.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return vterrors.Wrapf(err, "cannot read %v", fe.Name)
+		}
+		id, err := cw.put(ctx, chunk)
+		if err != nil {
+			return err
+		}
+		fe.Chunks = append(fe.Chunks, id)
+	}
+
+	// The hash of a chunked file is the hash of its contents.
+	fe.Hash = br.HashString()
+	return nil
+}
+
+// restoreChunkedFile restores an individual file from its chunks.
+func (be *BuiltinBackupEngine) restoreChunkedFile(ctx context.Context, params RestoreParams, cr *backupChunkReader, fe *FileEntry) (finalErr error) {
+	dest, err := fe.open(params.Cnf, false)
+	if err != nil {
+		return vterrors.Wrap(err, "can't open destination file for writing")
+	}
+	defer func() {
+		if cerr := dest.Close(); cerr != nil {
+			finalErr = errors.Join(finalErr, vterrors.Wrap(cerr, "failed to close destination file"))
+		}
+	}()
+
+	bw := newBackupWriter(fe.Name, int(builtinBackupFileWriteBufferSize), 0, dest)
+	go bw.ReportProgress(builtinBackupProgress, params.Logger, true /*restore*/)
+	defer bw.Close()
+
+	for _, id := range fe.Chunks {
+		data, err := cr.read(ctx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := bw.Write(data); err != nil {
+			return vterrors.Wrap(err, "failed to copy file contents")
+		}
+	}
+
+	if hash := bw.HashString(); hash != fe.Hash {
+		return vterrors.Errorf(vtrpc.Code_INTERNAL, "hash mismatch for %v, got %v expected %v", fe.Name, hash, fe.Hash)
+	}
+	if err := bw.Close(); err != nil {
+		return vterrors.Wrap(err, "failed to flush destination buffer")
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstats"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/mysqlctl/filebackupstorage"
+)
+
+func randomBackupData(seed uint64, size int) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(r.Uint32())
+	}
+	return data
+}
+
+func splitBackupChunks(t *testing.T, data []byte) [][]byte {
+	var chunks [][]byte
+	chunker := newBackupChunker(bytes.NewReader(data))
+	for {
+		chunk, err := chunker.next()
+		if err == io.EOF {
+			return chunks
+		}
+		require.NoError(t, err)
+		chunks = append(chunks, bytes.Clone(chunk))
+	}
+}
+
+func TestBackupChunker(t *testing.T) {
+	data := randomBackupData(1, 20*1024*1024)
+	chunks := splitBackupChunks(t, data)
+	require.Greater(t, len(chunks), 5)
+	for _, chunk := range chunks[:len(chunks)-1] {
+		assert.GreaterOrEqual(t, len(chunk), minBackupChunkSize)
+		assert.LessOrEqual(t, len(chunk), maxBackupChunkSize)
+	}
+	assert.Equal(t, data, bytes.Join(chunks, nil))
+
+	// inserting data at the start of a file only changes its first chunks
+	edited := append([]byte("some new page"), data...)
+	editedChunks := splitBackupChunks(t, edited)
+	assert.Equal(t, chunks[2:], editedChunks[len(editedChunks)-len(chunks)+2:])
+
+	assert.Empty(t, splitBackupChunks(t, nil))
+	assert.Equal(t, [][]byte{[]byte("small")}, splitBackupChunks(t, []byte("small")))
+}
+
+// setFileBackupStorage makes the backups use a file backup storage for the duration of the test.
+func setFileBackupStorage(t *testing.T) backupstorage.BackupStorage {
+	oldImplementation, oldRoot := backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot
+	backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = "file", t.TempDir()
+	t.Cleanup(func() {
+		backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = oldImplementation, oldRoot
+	})
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	return bs
+}
+
+// backupChunked takes a chunked backup of a single file with the given contents,
+// and returns the number of chunks it uploaded.
+func backupChunked(t *testing.T, bs backupstorage.BackupStorage, name string, contents []byte) int64 {
+	ctx := context.Background()
+	cnf := &Mycnf{DataDir: t.TempDir()}
+	require.NoError(t, os.WriteFile(path.Join(cnf.DataDir, "t.ibd"), contents, 0600))
+
+	enc, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+	cw, err := newBackupChunkWriter(ctx, bs, "ks/0", enc)
+	require.NoError(t, err)
+	fe := FileEntry{Base: backupData, Name: "t.ibd"}
+	be := &BuiltinBackupEngine{}
+	require.NoError(t, be.backupChunkedFile(ctx, BackupParams{
+		Cnf:    cnf,
+		Logger: logutil.NewMemoryLogger(),
+		Stats:  backupstats.NoStats(),
+	}, cw, &fe))
+	require.NoError(t, cw.verify(ctx, []FileEntry{fe}))
+
+	bm := builtinBackupManifest{
+		BackupManifest: BackupManifest{BackupName: name, BackupMethod: builtinBackupEngineName},
+		FileEntries:    []FileEntry{fe},
+		ChunkStore:     cw.dir,
+	}
+	if enc != nil {
+		bm.EncryptionKeyProvider = enc.keyProvider
+		bm.EncryptionKeyID = enc.keyID
+		bm.EncryptedDataKey = enc.wrappedKey
+	}
+	bh, err := bs.StartBackup(ctx, "ks/0", name)
+	require.NoError(t, err)
+	wc, err := bh.AddFile(ctx, backupManifestFileName, backupstorage.FileSizeUnknown)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(wc).Encode(bm))
+	require.NoError(t, wc.Close())
+	require.NoError(t, bh.EndBackup(ctx))
+	return cw.uploaded.Load()
+}
+
+// restoreChunked restores the file of a chunked backup and returns its contents.
+func restoreChunked(t *testing.T, bs backupstorage.BackupStorage, name string) ([]byte, error) {
+	ctx := context.Background()
+	bhs, err := bs.ListBackups(ctx, "ks/0")
+	require.NoError(t, err)
+	for _, bh := range bhs {
+		if bh.Name() != name {
+			continue
+		}
+		var bm builtinBackupManifest
+		require.NoError(t, getBackupManifestInto(ctx, bh, &bm))
+		cnf := &Mycnf{DataDir: t.TempDir()}
+		be := &BuiltinBackupEngine{}
+		if _, err := be.restoreFiles(ctx, RestoreParams{
+			Cnf:         cnf,
+			Logger:      logutil.NewMemoryLogger(),
+			Stats:       backupstats.NoStats(),
+			Concurrency: 1,
+		}, bh, bm); err != nil {
+			return nil, err
+		}
+		return os.ReadFile(path.Join(cnf.DataDir, "t.ibd"))
+	}
+	require.FailNow(t, "backup not found", name)
+	return nil, nil
+}
+
+func TestChunkedBackup(t *testing.T) {
+	for _, encrypted := range []bool{false, true} {
+		t.Run(map[bool]string{false: "plain", true: "encrypted"}[encrypted], func(t *testing.T) {
+			ctx := context.Background()
+			if encrypted {
+				setBackupEncryptionKey(t)
+			}
+			bs := setFileBackupStorage(t)
+			defer bs.Close()
+
+			first := randomBackupData(2, 8*1024*1024)
+			uploaded := backupChunked(t, bs, "first", first)
+			firstChunks, err := listBackupChunks(ctx, bs, backupChunkStoreDir("ks/0"))
+			require.NoError(t, err)
+			assert.EqualValues(t, len(firstChunks), uploaded)
+
+			// a second backup of a file whose middle changed only uploads the changed chunks
+			second := bytes.Clone(first)
+			copy(second[4*1024*1024:], "changed innodb page")
+			uploaded = backupChunked(t, bs, "second", second)
+			assert.Greater(t, uploaded, int64(0))
+			assert.LessOrEqual(t, uploaded, int64(2))
+
+			restored, err := restoreChunked(t, bs, "first")
+			require.NoError(t, err)
+			assert.Equal(t, first, restored)
+			restored, err = restoreChunked(t, bs, "second")
+			require.NoError(t, err)
+			assert.Equal(t, second, restored)
+
+			// the chunks only the first backup used are removed along with it
+			require.NoError(t, RemoveBackup(ctx, bs, "ks/0", "first"))
+			chunks, err := listBackupChunks(ctx, bs, backupChunkStoreDir("ks/0"))
+			require.NoError(t, err)
+			assert.Len(t, chunks, len(firstChunks))
+			restored, err = restoreChunked(t, bs, "second")
+			require.NoError(t, err)
+			assert.Equal(t, second, restored)
+
+			require.NoError(t, RemoveBackup(ctx, bs, "ks/0", "second"))
+			chunks, err = listBackupChunks(ctx, bs, backupChunkStoreDir("ks/0"))
+			require.NoError(t, err)
+			assert.Empty(t, chunks)
+		})
+	}
+}
+
+func TestEncryptedBackupChunkID(t *testing.T) {
+	ctx := context.Background()
+	setBackupEncryptionKey(t)
+	bs := setFileBackupStorage(t)
+	defer bs.Close()
+
+	data := randomBackupData(4, minBackupChunkSize)
+	sum := sha256.Sum256(data)
+
+	enc, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+	cw, err := newBackupChunkWriter(ctx, bs, "ks/0", enc)
+	require.NoError(t, err)
+	id := cw.chunkID(data)
+	hash, tag, ok := strings.Cut(id, "-")
+	require.True(t, ok)
+	assert.Equal(t, cw.keyTag, tag)
+	// the id of an encrypted chunk does not reveal the sha256 of its contents
+	assert.NotEqual(t, hex.EncodeToString(sum[:]), hash)
+
+	// backups encrypted with the same key share the chunk, whatever their data key
+	other, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, enc.wrappedKey, other.wrappedKey)
+	assert.Equal(t, hash, backupChunkHash(other, data))
+
+	// backups encrypted with another key do not
+	setBackupEncryptionKey(t)
+	other, err = newBackupEncryption(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, backupChunkHash(other, data))
+
+	// the ids of plain chunks are the sha256 of their contents
+	assert.Equal(t, hex.EncodeToString(sum[:]), backupChunkHash(nil, data))
+}
+
+func TestChunkedBackupCorruption(t *testing.T) {
+	ctx := context.Background()
+	bs := setFileBackupStorage(t)
+	defer bs.Close()
+
+	contents := randomBackupData(3, 2*1024*1024)
+	backupChunked(t, bs, "backup", contents)
+
+	chunkDir := path.Join(filebackupstorage.FileBackupStorageRoot, backupChunkStoreDir("ks/0"))
+	entries, err := os.ReadDir(chunkDir)
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	chunkFile := path.Join(chunkDir, entries[0].Name(), backupChunkFileName)
+
+	// a corrupted chunk fails the restore
+	data, err := os.ReadFile(chunkFile)
+	require.NoError(t, err)
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)-1] ^= 1
+	require.NoError(t, os.WriteFile(chunkFile, corrupted, 0600))
+	_, err = restoreChunked(t, bs, "backup")
+	assert.Error(t, err)
+
+	// a missing chunk fails the restore
+	require.NoError(t, bs.RemoveBackup(ctx, backupChunkStoreDir("ks/0"), entries[0].Name()))
+	_, err = restoreChunked(t, bs, "backup")
+	assert.ErrorContains(t, err, "is missing from")
+
+	// chunks are not garbage collected while a backup of the shard cannot be read
+	bh, err := bs.StartBackup(ctx, "ks/0", "incomplete")
+	require.NoError(t, err)
+	require.NoError(t, bh.EndBackup(ctx))
+	err = RemoveBackup(ctx, bs, "ks/0", "backup")
+	assert.ErrorContains(t, err, "cannot tell which chunks backup incomplete references")
+	chunks, err := listBackupChunks(ctx, bs, backupChunkStoreDir("ks/0"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, chunks)
+}
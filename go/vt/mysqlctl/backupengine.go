@@ -81,6 +81,9 @@ type BackupParams struct {
 	// Position of last known backup. If non empty, then this value indicates the backup should be incremental
 	// and as of this position
 	IncrementalFromPos string
+	// SkipBinlogFlush, when true, makes an incremental backup skip FLUSH BINARY LOGS, so that it only
+	// covers binary logs that were already closed when the backup started.
+	SkipBinlogFlush bool
 	// Stats let's backup engines report detailed backup timings.
 	Stats backupstats.Stats
 	// UpgradeSafe indicates whether the backup is safe for upgrade and created with innodb_fast_shutdown=0
@@ -102,6 +105,7 @@ func (b *BackupParams) Copy() BackupParams {
 		TabletAlias:          b.TabletAlias,
 		BackupTime:           b.BackupTime,
 		IncrementalFromPos:   b.IncrementalFromPos,
+		SkipBinlogFlush:      b.SkipBinlogFlush,
 		Stats:                b.Stats,
 		UpgradeSafe:          b.UpgradeSafe,
 		MysqlShutdownTimeout: b.MysqlShutdownTimeout,
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+)
+
+var (
+	// binlogArchiveInterval is how often the binlog archiver copies binary
+	// logs to backup storage. Zero disables archiving.
+	binlogArchiveInterval time.Duration
+	// binlogArchiveInProgress makes the archiver rotate the binary log on
+	// every run, so that the archive includes the binary log that was being
+	// written to. Otherwise only binary logs that mysqld already closed are
+	// archived.
+	binlogArchiveInProgress = false
+
+	binlogArchiveCount = stats.NewCountersWithSingleLabel(
+		"BinlogArchiveCount",
+		"Number of binary log archive runs, by result",
+		"Result")
+)
+
+func init() {
+	servenv.OnParseFor("vttablet", registerBinlogArchiverFlags)
+}
+
+func registerBinlogArchiverFlags(fs *pflag.FlagSet) {
+	fs.DurationVar(&binlogArchiveInterval, "binlog-archive-interval", binlogArchiveInterval, "if non-zero, a primary tablet copies its binary logs to backup storage at this interval, as incremental backups that restoring to a position or timestamp picks up automatically. Zero disables binary log archiving.")
+	fs.BoolVar(&binlogArchiveInProgress, "binlog-archive-in-progress", binlogArchiveInProgress, "if set, the binary log archiver rotates the binary log on every run, so that the binary log being written to is archived as well. Otherwise only binary logs that were already closed are archived.")
+}
+
+// BinlogArchiveInterval returns the interval at which binary logs are
+// archived, or zero if archiving is disabled.
+func BinlogArchiveInterval() time.Duration {
+	return binlogArchiveInterval
+}
+
+// BinlogArchiver periodically copies the binary logs of a tablet to backup
+// storage. Each run is an incremental backup that picks up from the
+// position of the latest backup of the shard, so the archives form a chain
+// of GTID ranges on top of the full backups. Restoring to a position or a
+// timestamp stitches a full backup and these archives together.
+type BinlogArchiver struct {
+	interval   time.Duration
+	inProgress bool
+	// params returns the parameters of the next archive run, along with a
+	// function to call once the run is over, or false if the tablet is not
+	// in a position to archive right now.
+	params func() (BackupParams, func(), bool)
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewBinlogArchiver returns a BinlogArchiver that archives binary logs
+// every interval, as configured by the command line flags. params is
+// consulted before each run.
+func NewBinlogArchiver(interval time.Duration, params func() (BackupParams, func(), bool)) *BinlogArchiver {
+	return &BinlogArchiver{
+		interval:   interval,
+		inProgress: binlogArchiveInProgress,
+		params:     params,
+	}
+}
+
+// Open starts archiving in the background. It is a no-op if the archiver
+// is already running.
+func (ba *BinlogArchiver) Open() {
+	ba.mu.Lock()
+	defer ba.mu.Unlock()
+	if ba.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ba.cancel = cancel
+	ba.done = make(chan struct{})
+	go ba.run(ctx, ba.done)
+}
+
+// Close stops archiving and waits for a run in progress to finish.
+func (ba *BinlogArchiver) Close() {
+	ba.mu.Lock()
+	cancel, done := ba.cancel, ba.done
+	ba.cancel, ba.done = nil, nil
+	ba.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (ba *BinlogArchiver) run(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(ba.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		params, release, ok := ba.params()
+		if !ok {
+			binlogArchiveCount.Add("Skipped", 1)
+			continue
+		}
+		if err := ba.Archive(ctx, params); err != nil {
+			log.Errorf("binlog archiver: %v", err)
+		}
+		release()
+	}
+}
+
+// Archive copies the binary logs written since the latest backup of the
+// shard to backup storage. When there is nothing new to archive, no backup
+// is left behind.
+func (ba *BinlogArchiver) Archive(ctx context.Context, params BackupParams) error {
+	params.IncrementalFromPos = AutoIncrementalFromPos
+	params.SkipBinlogFlush = !ba.inProgress
+	if params.BackupTime.IsZero() {
+		params.BackupTime = time.Now()
+	}
+	if err := Backup(ctx, params); err != nil {
+		binlogArchiveCount.Add("Failed", 1)
+		return err
+	}
+	binlogArchiveCount.Add("Succeeded", 1)
+	return nil
+}
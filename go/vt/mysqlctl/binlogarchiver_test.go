@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/mysql/replication"
+	"vitess.io/vitess/go/vt/logutil"
+)
+
+func TestBinlogArchiverArchive(t *testing.T) {
+	ctx := context.Background()
+	bs := setFileBackupStorage(t)
+	defer bs.Close()
+
+	oldEngine := backupEngineImplementation
+	backupEngineImplementation = builtinBackupEngineName
+	defer func() { backupEngineImplementation = oldEngine }()
+
+	pos, err := replication.DecodePosition("MySQL56/16b1039f-22b6-11ed-b765-0a43f95f28a3:1-615")
+	require.NoError(t, err)
+	addBackupManifest(t, bs, &builtinBackupManifest{BackupManifest: BackupManifest{
+		BackupName:   "2024-01-01.000000.zone1-0000000100",
+		BackupMethod: builtinBackupEngineName,
+		Position:     pos,
+	}})
+
+	tcases := []struct {
+		name       string
+		inProgress bool
+		queries    []string
+	}{
+		{
+			name:    "closed binary logs",
+			queries: []string{"FAKE SHOW BINARY LOGS"},
+		},
+		{
+			name:       "in-progress binary log",
+			inProgress: true,
+			queries:    []string{"FAKE FLUSH BINARY LOGS", "FAKE SHOW BINARY LOGS"},
+		},
+	}
+	for i, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			fakedb := fakesqldb.New(t)
+			defer fakedb.Close()
+			mysqld := NewFakeMysqlDaemon(fakedb)
+			defer mysqld.Close()
+			mysqld.ExpectedExecuteSuperQueryList = tcase.queries
+
+			ba := NewBinlogArchiver(time.Minute, nil)
+			ba.inProgress = tcase.inProgress
+			failed := binlogArchiveCount.Counts()["Failed"]
+			err := ba.Archive(ctx, BackupParams{
+				Cnf:         &Mycnf{},
+				Mysqld:      mysqld,
+				Logger:      logutil.NewMemoryLogger(),
+				Keyspace:    "ks",
+				Shard:       "0",
+				TabletAlias: "zone1-0000000100",
+				BackupTime:  time.Date(2024, 1, 2, 0, 0, i, 0, time.UTC),
+			})
+			// The fake mysqld has no gtid_purged, so the archive stops right after
+			// listing the binary logs, which is all we need to check here.
+			require.ErrorContains(t, err, "failed to parse a valid MySQL GTID set")
+			assert.Equal(t, len(tcase.queries), mysqld.ExpectedExecuteSuperQueryCurrent)
+			assert.Equal(t, failed+1, binlogArchiveCount.Counts()["Failed"])
+
+			bhs, err := bs.ListBackups(ctx, "ks/0")
+			require.NoError(t, err)
+			assert.Len(t, bhs, 1, "a failed archive leaves no backup behind")
+		})
+	}
+}
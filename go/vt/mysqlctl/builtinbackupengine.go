@@ -128,6 +128,26 @@ type builtinBackupManifest struct {
 	// ExternalDecompressor will be used. If neither are set, the restore will
 	// abort.
 	ExternalDecompressor string
+
+	// EncryptionKeyProvider is the key provider that wrapped the data key the
+	// backup files are encrypted with. It is empty if the files are not encrypted.
+	EncryptionKeyProvider string `json:",omitempty"`
+
+	// EncryptionKeyID identifies the key the provider wrapped the data key with.
+	EncryptionKeyID string `json:",omitempty"`
+
+	// EncryptedDataKey is the data key the backup files are encrypted with,
+	// wrapped by the key provider.
+	EncryptedDataKey []byte `json:",omitempty"`
+
+	// ChunkStore is the backup storage directory holding the chunks of the files
+	// of a chunked backup. It is empty if the backup is not chunked.
+	ChunkStore string `json:",omitempty"`
+
+	// TableChecksums holds the CHECKSUM TABLE results of the tables of a full
+	// backup, keyed by <schema>.<table>. It is only recorded with
+	// --builtinbackup-table-checksums, and is used to verify the backup.
+	TableChecksums map[string]string `json:",omitempty"`
 }
 
 // FileEntry is one file to backup
@@ -144,8 +164,12 @@ type FileEntry struct {
 
 	// Hash is the hash of the final data (transformed and
 	// compressed if specified) stored in the BackupStorage.
+	// For chunked backups, it is the hash of the file contents.
 	Hash string
 
+	// Chunks lists the ids of the chunks that make up the file, in order, for chunked backups.
+	Chunks []string `json:",omitempty"`
+
 	// ParentPath is an optional prefix to the Base path. If empty, it is ignored. Useful
 	// for writing files in a temporary directory
 	ParentPath string
@@ -162,6 +186,8 @@ func registerBuiltinBackupEngineFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&builtinBackupProgress, "builtinbackup_progress", builtinBackupProgress, "how often to send progress updates when backing up large files.")
 	fs.UintVar(&builtinBackupFileReadBufferSize, "builtinbackup-file-read-buffer-size", builtinBackupFileReadBufferSize, "read files using an IO buffer of this many bytes. Golang defaults are used when set to 0.")
 	fs.UintVar(&builtinBackupFileWriteBufferSize, "builtinbackup-file-write-buffer-size", builtinBackupFileWriteBufferSize, "write files using an IO buffer of this many bytes. Golang defaults are used when set to 0.")
+	fs.BoolVar(&builtinBackupChunking, "builtinbackup-chunking", builtinBackupChunking, "split the files of full backups into content-defined chunks, and only upload the chunks that previous backups of the shard did not upload already.")
+	fs.BoolVar(&builtinBackupTableChecksums, "builtinbackup-table-checksums", builtinBackupTableChecksums, "record the checksums of the tables in the manifest of full backups, so that verifying the backup can compare against them. Checksumming reads every table before mysqld is shut down for the backup.")
 	fs.StringVar(&builtinIncrementalRestorePath, "builtinbackup-incremental-restore-path", builtinIncrementalRestorePath, "the directory where incremental restore files, namely binlog files, are extracted to. In k8s environments, this should be set to a directory that is shared between the vttablet and mysqld pods. The path should exist. When empty, the default OS temp dir is assumed.")
 }
 
@@ -287,7 +313,9 @@ func (be *BuiltinBackupEngine) executeIncrementalBackup(ctx context.Context, par
 	// Shortly we will compare a binlog's "Previous GTIDs" with the backup's position. For the purpose of comparison, we
 	// ignore the purged GTIDs:
 
-	if err := params.Mysqld.FlushBinaryLogs(ctx); err != nil {
+	if params.SkipBinlogFlush {
+		params.Logger.Infof("skipping binary log flush; only closed binary logs are backed up")
+	} else if err := params.Mysqld.FlushBinaryLogs(ctx); err != nil {
 		return BackupUnusable, vterrors.Wrapf(err, "cannot flush binary logs in incremental backup")
 	}
 	binaryLogs, err := params.Mysqld.GetBinaryLogs(ctx)
@@ -327,6 +355,24 @@ func (be *BuiltinBackupEngine) executeIncrementalBackup(ctx context.Context, par
 		previousGTIDs[binlog] = gtids
 		return gtids, nil
 	}
+	if params.SkipBinlogFlush {
+		// Without a flush, the requested position may well be within the binary log that is still
+		// open. There is nothing closed to back up until that binary log is rotated.
+		if len(binaryLogs) == 0 {
+			return BackupEmpty, nil
+		}
+		lastBinlogPreviousGTIDs, err := getBinlogPreviousGTIDs(ctx, binaryLogs[len(binaryLogs)-1])
+		if err != nil {
+			return BackupUnusable, vterrors.Wrapf(err, "cannot get previous gtids for binlog %v", binaryLogs[len(binaryLogs)-1])
+		}
+		lastBinlogPreviousPos, err := replication.ParsePosition(replication.Mysql56FlavorID, lastBinlogPreviousGTIDs)
+		if err != nil {
+			return BackupUnusable, vterrors.Wrapf(err, "cannot parse position %v", lastBinlogPreviousGTIDs)
+		}
+		if backupFromGTIDSet.Contains(lastBinlogPreviousPos.GTIDSet.Union(purgedGTIDSet)) {
+			return BackupEmpty, nil
+		}
+	}
 	binaryLogsToBackup, incrementalBackupFromGTID, incrementalBackupToGTID, err := ChooseBinlogsForIncrementalBackup(ctx, backupFromGTIDSet, purgedGTIDSet, binaryLogs, getBinlogPreviousGTIDs)
 	if err != nil {
 		return BackupUnusable, vterrors.Wrapf(err, "cannot get binary logs to backup in incremental backup")
@@ -383,7 +429,7 @@ func (be *BuiltinBackupEngine) executeIncrementalBackup(ctx context.Context, par
 	// incrementalBackupFromGTID is the "previous GTIDs" of the first binlog file we back up.
 	// It is a fact that incrementalBackupFromGTID is earlier or equal to params.IncrementalFromPos.
 	// In the backup manifest file, we document incrementalBackupFromGTID, not the user's requested position.
-	if err := be.backupFiles(ctx, params, bh, incrementalBackupToPosition, gtidPurged, incrementalBackupFromPosition, fromBackupName, binaryLogsToBackup, serverUUID, mysqlVersion, incrDetails); err != nil {
+	if err := be.backupFiles(ctx, params, bh, incrementalBackupToPosition, gtidPurged, incrementalBackupFromPosition, fromBackupName, binaryLogsToBackup, serverUUID, mysqlVersion, incrDetails, nil); err != nil {
 		return BackupUnusable, err
 	}
 	return BackupUsable, nil
@@ -477,6 +523,16 @@ func (be *BuiltinBackupEngine) executeFullBackup(ctx context.Context, params Bac
 		return BackupUnusable, vterrors.Wrap(err, "can't get MySQL version")
 	}
 
+	// Checksum the tables while mysqld is still up. Replication is stopped, or
+	// super_read_only is set, so the checksums match the files of the backup.
+	var checksums map[string]string
+	if builtinBackupTableChecksums {
+		params.Logger.Infof("checksumming tables")
+		if checksums, err = tableChecksums(ctx, params.Mysqld); err != nil {
+			return BackupUnusable, vterrors.Wrap(err, "can't checksum tables")
+		}
+	}
+
 	// check if we need to set innodb_fast_shutdown=0 for a backup safe for upgrades
 	if params.UpgradeSafe {
 		if _, err := params.Mysqld.FetchSuperQuery(ctx, "SET GLOBAL innodb_fast_shutdown=0"); err != nil {
@@ -493,7 +549,7 @@ func (be *BuiltinBackupEngine) executeFullBackup(ctx context.Context, params Bac
 	}
 
 	// Backup everything, capture the error.
-	backupErr := be.backupFiles(ctx, params, bh, replicationPosition, gtidPurgedPosition, replication.Position{}, "", nil, serverUUID, mysqlVersion, nil)
+	backupErr := be.backupFiles(ctx, params, bh, replicationPosition, gtidPurgedPosition, replication.Position{}, "", nil, serverUUID, mysqlVersion, nil, checksums)
 	backupResult := BackupUnusable
 	if backupErr == nil {
 		backupResult = BackupUsable
@@ -584,6 +640,7 @@ func (be *BuiltinBackupEngine) backupFiles(
 	serverUUID string,
 	mysqlVersion string,
 	incrDetails *IncrementalBackupDetails,
+	tableChecksums map[string]string,
 ) (finalErr error) {
 	// Get the files to backup.
 	// We don't care about totalSize because we add each file separately.
@@ -599,6 +656,25 @@ func (be *BuiltinBackupEngine) backupFiles(
 	}
 	params.Logger.Infof("found %v files to backup", len(fes))
 
+	// All the files of the backup are encrypted with the same data key.
+	enc, err := newBackupEncryption(ctx)
+	if err != nil {
+		return vterrors.Wrap(err, "can't set up backup encryption")
+	}
+
+	// Full backups may only upload the chunks of their files that previous backups did not upload.
+	var cw *backupChunkWriter
+	if builtinBackupChunking && !isIncrementalBackup(params) {
+		bs, err := backupstorage.GetBackupStorage()
+		if err != nil {
+			return vterrors.Wrap(err, "unable to get backup storage")
+		}
+		defer bs.Close()
+		if cw, err = newBackupChunkWriter(ctx, bs, bh.Directory(), enc); err != nil {
+			return vterrors.Wrap(err, "can't set up backup chunk store")
+		}
+	}
+
 	// Backup with the provided concurrency.
 	sema := semaphore.NewWeighted(int64(params.Concurrency))
 	wg := sync.WaitGroup{}
@@ -633,8 +709,12 @@ func (be *BuiltinBackupEngine) backupFiles(
 			}
 
 			// Backup the individual file.
+			if cw != nil {
+				bh.RecordError(be.backupChunkedFile(ctx, params, cw, fe))
+				return
+			}
 			name := fmt.Sprintf("%v", i)
-			bh.RecordError(be.backupFile(ctx, params, bh, fe, name))
+			bh.RecordError(be.backupFile(ctx, params, bh, fe, name, enc))
 		}(i)
 	}
 
@@ -652,6 +732,13 @@ func (be *BuiltinBackupEngine) backupFiles(
 		return bh.Error()
 	}
 
+	if cw != nil {
+		params.Logger.Infof("uploaded %v of the %v chunks of the backup", cw.uploaded.Load(), cw.total.Load())
+		if err := cw.verify(ctx, fes); err != nil {
+			return err
+		}
+	}
+
 	// open the MANIFEST
 	wc, err := bh.AddFile(ctx, backupManifestFileName, backupstorage.FileSizeUnknown)
 	if err != nil {
@@ -691,6 +778,15 @@ func (be *BuiltinBackupEngine) backupFiles(
 		SkipCompress:         !backupStorageCompress,
 		CompressionEngine:    CompressionEngineName,
 		ExternalDecompressor: ManifestExternalDecompressorCmd,
+		TableChecksums:       tableChecksums,
+	}
+	if enc != nil {
+		bm.EncryptionKeyProvider = enc.keyProvider
+		bm.EncryptionKeyID = enc.keyID
+		bm.EncryptedDataKey = enc.wrappedKey
+	}
+	if cw != nil {
+		bm.ChunkStore = cw.dir
 	}
 	data, err := json.MarshalIndent(bm, "", "  ")
 	if err != nil {
@@ -790,8 +886,8 @@ func (bp *backupPipe) ReportProgress(period time.Duration, logger logutil.Logger
 	}
 }
 
-// backupFile backs up an individual file.
-func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string) (finalErr error) {
+// backupFile backs up an individual file, and encrypts it if enc is not nil.
+func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupParams, bh backupstorage.BackupHandle, fe *FileEntry, name string, enc *backupEncryption) (finalErr error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	// Open the source file for reading.
@@ -844,8 +940,8 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 	bw := newBackupWriter(fe.Name, builtinBackupStorageWriteBufferSize, fi.Size(), timedDest)
 
 	// We create the following inner function because:
-	// - we must `defer` the compressor's Close() function
-	// - but it must take place before we close the pipe reader&writer
+	// - we must `defer` the compressor's and the encryptor's Close() functions
+	// - but they must take place before we close the pipe reader&writer
 	createAndCopy := func() (createAndCopyErr error) {
 		var reader io.Reader = br
 		var writer io.Writer = bw
@@ -860,6 +956,23 @@ func (be *BuiltinBackupEngine) backupFile(ctx context.Context, params BackupPara
 				createAndCopyErr = errors.Join(createAndCopyErr, vterrors.Wrap(err, "failed to close the source reader"))
 			}
 		}()
+		// Create the encryption pipe, if necessary. Data is compressed before it is encrypted.
+		if enc != nil {
+			encryptor, err := enc.newWriter(name, writer)
+			if err != nil {
+				return vterrors.Wrap(err, "can't create encryptor")
+			}
+			writer = encryptor
+
+			defer func() {
+				// Close the encryptor to write the last chunk, after the compressor is flushed.
+				if cerr := encryptor.Close(); cerr != nil {
+					cerr = vterrors.Wrapf(cerr, "failed to close encryptor %v", name)
+					params.Logger.Error(cerr)
+					createAndCopyErr = errors.Join(createAndCopyErr, cerr)
+				}
+			}()
+		}
 		// Create the gzip compression pipe, if necessary.
 		if backupStorageCompress {
 			var compressor io.WriteCloser
@@ -1006,6 +1119,24 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 		}()
 	}
 
+	// Unwrap the data key once for all the files of an encrypted backup.
+	enc, err := openBackupEncryption(ctx, &bm)
+	if err != nil {
+		return "", vterrors.Wrap(err, "can't set up backup decryption")
+	}
+
+	var cr *backupChunkReader
+	if bm.ChunkStore != "" {
+		bs, err := backupstorage.GetBackupStorage()
+		if err != nil {
+			return "", vterrors.Wrap(err, "unable to get backup storage")
+		}
+		defer bs.Close()
+		if cr, err = newBackupChunkReader(ctx, bs, bm.ChunkStore); err != nil {
+			return "", vterrors.Wrap(err, "can't set up backup chunk store")
+		}
+	}
+
 	if bm.Incremental {
 		createdDir, err = os.MkdirTemp(builtinIncrementalRestorePath, "restore-incremental-*")
 		if err != nil {
@@ -1050,7 +1181,12 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 			// And restore the file.
 			name := fmt.Sprintf("%v", i)
 			params.Logger.Infof("Copying file %v: %v", name, fe.Name)
-			err := be.restoreFile(ctx, params, bh, fe, bm, name)
+			var err error
+			if cr != nil {
+				err = be.restoreChunkedFile(ctx, params, cr, fe)
+			} else {
+				err = be.restoreFile(ctx, params, bh, fe, bm, name, enc)
+			}
 			if err != nil {
 				rec.RecordError(vterrors.Wrapf(err, "can't restore file %v to %v", name, fe.Name))
 			}
@@ -1060,8 +1196,8 @@ func (be *BuiltinBackupEngine) restoreFiles(ctx context.Context, params RestoreP
 	return createdDir, rec.Error()
 }
 
-// restoreFile restores an individual file.
-func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string) (finalErr error) {
+// restoreFile restores an individual file, and decrypts it if enc is not nil.
+func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestoreParams, bh backupstorage.BackupHandle, fe *FileEntry, bm builtinBackupManifest, name string, enc *backupEncryption) (finalErr error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	// Open the source file for reading.
@@ -1106,6 +1242,11 @@ func (be *BuiltinBackupEngine) restoreFile(ctx context.Context, params RestorePa
 
 	bufferedDest := bufio.NewWriterSize(timedDest, int(builtinBackupFileWriteBufferSize))
 
+	// Create the decryptor if needed. Data is decrypted before it is decompressed.
+	if enc != nil {
+		reader = enc.newReader(name, reader)
+	}
+
 	// Create the uncompresser if needed.
 	if !bm.SkipCompress {
 		var decompressor io.ReadCloser
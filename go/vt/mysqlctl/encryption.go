@@ -0,0 +1,441 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	"vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// FileKeyProvider wraps the data keys of backups with a key read from a local file.
+	FileKeyProvider = "file"
+
+	// encryptionChunkSize is the size of the plaintext chunks the backup files are encrypted in.
+	encryptionChunkSize = 64 * 1024
+
+	// encryptionNoncePrefixSize is the size of the random prefix of the nonces of a file.
+	// The nonce of a chunk is this prefix followed by the index of the chunk.
+	encryptionNoncePrefixSize = 8
+
+	// encryptionFinalChunk flags the length of the last chunk of a file, so that
+	// a truncated file is detected.
+	encryptionFinalChunk = 1 << 31
+
+	// backupChunkIDLabel is the label of the secret that the ids of encrypted backup chunks
+	// are computed with.
+	backupChunkIDLabel = "vitess backup chunk id"
+)
+
+var (
+	// backupEncryptionKeyProvider is the key provider that wraps the data keys of new backups.
+	// New backups are not encrypted if it is empty.
+	backupEncryptionKeyProvider string
+
+	// backupEncryptionKeyFile is the file the "file" key provider reads its key from.
+	backupEncryptionKeyFile string
+
+	// BackupKeyProviderMap contains the registered key providers, by name.
+	// Restores use the key provider recorded in the MANIFEST of the backup.
+	BackupKeyProviderMap = map[string]func() (BackupKeyProvider, error){
+		FileKeyProvider: newFileKeyProvider,
+	}
+
+	errEncryptedBackupCorrupted = errors.New("encrypted backup file is corrupted")
+)
+
+func init() {
+	for _, cmd := range []string{"vtbackup", "vtcombo", "vttablet", "vttestserver"} {
+		servenv.OnParseFor(cmd, registerBackupEncryptionFlags)
+	}
+}
+
+func registerBackupEncryptionFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&backupEncryptionKeyProvider, "backup-encryption-key-provider", backupEncryptionKeyProvider, "if set, the files of builtin backups are encrypted with a data key wrapped by this key provider. Supported values are 'file'.")
+	fs.StringVar(&backupEncryptionKeyFile, "backup-encryption-key-file", backupEncryptionKeyFile, "path to the file holding the hex encoded 256-bit key that the 'file' backup encryption key provider wraps data keys with.")
+}
+
+// BackupKeyProvider wraps the data keys that the files of builtin backups are encrypted with.
+type BackupKeyProvider interface {
+	// KeyID identifies the key the provider wraps data keys with.
+	// It is recorded in the MANIFEST of the backups.
+	KeyID() string
+
+	// WrapKey encrypts a data key.
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a data key that was wrapped with the key identified by keyID.
+	UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error)
+
+	// DeriveSecret returns a secret derived from the key identified by keyID for the given label.
+	// It must always return the same secret for the same key and label, and the secret must not
+	// be computable without the key.
+	DeriveSecret(ctx context.Context, keyID string, label string) ([]byte, error)
+}
+
+// fileKeyProvider wraps data keys with AES-GCM, using a key read from a local file.
+type fileKeyProvider struct {
+	keyID string
+	key   []byte
+	aead  cipher.AEAD
+}
+
+func newFileKeyProvider() (BackupKeyProvider, error) {
+	if backupEncryptionKeyFile == "" {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "--backup-encryption-key-file is required by the %q backup encryption key provider", FileKeyProvider)
+	}
+	data, err := os.ReadFile(backupEncryptionKeyFile)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot read backup encryption key file")
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != 32 {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "backup encryption key file %v must hold a hex encoded 256-bit key", backupEncryptionKeyFile)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	// The key is identified by a fingerprint, so that a restore with the wrong key fails with a clear error.
+	sum := sha256.Sum256(key)
+	return &fileKeyProvider{keyID: "sha256:" + hex.EncodeToString(sum[:8]), key: key, aead: aead}, nil
+}
+
+// KeyID is part of the BackupKeyProvider interface.
+func (p *fileKeyProvider) KeyID() string {
+	return p.keyID
+}
+
+// WrapKey is part of the BackupKeyProvider interface.
+func (p *fileKeyProvider) WrapKey(ctx context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot generate nonce")
+	}
+	return p.aead.Seal(nonce, nonce, dataKey, []byte(p.keyID)), nil
+}
+
+// UnwrapKey is part of the BackupKeyProvider interface.
+func (p *fileKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrappedKey []byte) ([]byte, error) {
+	if keyID != p.keyID {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "backup data key was wrapped with key %v, but the backup encryption key file holds key %v", keyID, p.keyID)
+	}
+	if len(wrappedKey) < p.aead.NonceSize() {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "wrapped backup data key is too short")
+	}
+	nonce, sealed := wrappedKey[:p.aead.NonceSize()], wrappedKey[p.aead.NonceSize():]
+	dataKey, err := p.aead.Open(nil, nonce, sealed, []byte(keyID))
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot unwrap backup data key")
+	}
+	return dataKey, nil
+}
+
+// DeriveSecret is part of the BackupKeyProvider interface.
+func (p *fileKeyProvider) DeriveSecret(ctx context.Context, keyID string, label string) ([]byte, error) {
+	if keyID != p.keyID {
+		return nil, vterrors.Errorf(vtrpc.Code_FAILED_PRECONDITION, "backup secret was derived from key %v, but the backup encryption key file holds key %v", keyID, p.keyID)
+	}
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(label))
+	return mac.Sum(nil), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot create cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// backupEncryption encrypts the files of a backup with a data key generated for the backup.
+type backupEncryption struct {
+	keyProvider string
+	keyID       string
+	wrappedKey  []byte
+	aead        cipher.AEAD
+
+	// chunkIDKey is the secret the ids of the chunks encrypted with the key are computed
+	// with, so that they do not reveal the contents of the chunks.
+	chunkIDKey []byte
+}
+
+// newBackupEncryption generates the data key of a new backup, and wraps it with the configured
+// key provider. It returns nil if backups are not encrypted.
+func newBackupEncryption(ctx context.Context) (*backupEncryption, error) {
+	if backupEncryptionKeyProvider == "" {
+		return nil, nil
+	}
+	provider, err := getBackupKeyProvider(backupEncryptionKeyProvider)
+	if err != nil {
+		return nil, err
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot generate backup data key")
+	}
+	wrappedKey, err := provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot wrap backup data key")
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	chunkIDKey, err := provider.DeriveSecret(ctx, provider.KeyID(), backupChunkIDLabel)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot derive backup chunk id key")
+	}
+	return &backupEncryption{
+		keyProvider: backupEncryptionKeyProvider,
+		keyID:       provider.KeyID(),
+		wrappedKey:  wrappedKey,
+		aead:        aead,
+		chunkIDKey:  chunkIDKey,
+	}, nil
+}
+
+// openBackupEncryption unwraps the data key of an existing backup with the key provider recorded
+// in its MANIFEST. It returns nil if the backup is not encrypted.
+func openBackupEncryption(ctx context.Context, bm *builtinBackupManifest) (*backupEncryption, error) {
+	if bm.EncryptionKeyProvider == "" {
+		return nil, nil
+	}
+	provider, err := getBackupKeyProvider(bm.EncryptionKeyProvider)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := provider.UnwrapKey(ctx, bm.EncryptionKeyID, bm.EncryptedDataKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	chunkIDKey, err := provider.DeriveSecret(ctx, bm.EncryptionKeyID, backupChunkIDLabel)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "cannot derive backup chunk id key")
+	}
+	return &backupEncryption{
+		keyProvider: bm.EncryptionKeyProvider,
+		keyID:       bm.EncryptionKeyID,
+		wrappedKey:  bm.EncryptedDataKey,
+		aead:        aead,
+		chunkIDKey:  chunkIDKey,
+	}, nil
+}
+
+func getBackupKeyProvider(name string) (BackupKeyProvider, error) {
+	factory, ok := BackupKeyProviderMap[name]
+	if !ok {
+		return nil, vterrors.Errorf(vtrpc.Code_INVALID_ARGUMENT, "unknown backup encryption key provider %q", name)
+	}
+	return factory()
+}
+
+// newWriter returns a writer that encrypts the data of the backup file with the given name to w.
+// The file starts with the random prefix of its nonces, followed by chunks of at most
+// encryptionChunkSize bytes of plaintext, each sealed with AES-GCM and preceded by its length.
+// The index of a chunk is part of its nonce, and the name of the file and whether the chunk is
+// the last one are authenticated with it, so chunks cannot be reordered, truncated or moved
+// between files. The writer must be closed to write the last chunk.
+func (enc *backupEncryption) newWriter(name string, w io.Writer) (io.WriteCloser, error) {
+	ew := &encryptedWriter{
+		aead:  enc.aead,
+		w:     w,
+		name:  name,
+		nonce: make([]byte, enc.aead.NonceSize()),
+		buf:   make([]byte, 0, encryptionChunkSize),
+	}
+	if _, err := rand.Read(ew.nonce[:encryptionNoncePrefixSize]); err != nil {
+		return nil, vterrors.Wrapf(err, "cannot generate nonce")
+	}
+	if _, err := w.Write(ew.nonce[:encryptionNoncePrefixSize]); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+// newReader returns a reader that decrypts the data of the backup file with the given name from r.
+func (enc *backupEncryption) newReader(name string, r io.Reader) io.Reader {
+	return &encryptedReader{
+		aead:  enc.aead,
+		r:     r,
+		name:  name,
+		nonce: make([]byte, enc.aead.NonceSize()),
+	}
+}
+
+type encryptedWriter struct {
+	aead   cipher.AEAD
+	w      io.Writer
+	name   string
+	nonce  []byte
+	chunk  uint32
+	buf    []byte
+	sealed []byte
+	closed bool
+}
+
+func (ew *encryptedWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if len(ew.buf) == encryptionChunkSize {
+			if err := ew.flush(false); err != nil {
+				return n, err
+			}
+		}
+		c := copy(ew.buf[len(ew.buf):encryptionChunkSize], p)
+		ew.buf = ew.buf[:len(ew.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// Close writes the last chunk of the file. It does not close the underlying writer.
+func (ew *encryptedWriter) Close() error {
+	if ew.closed {
+		return nil
+	}
+	ew.closed = true
+	return ew.flush(true)
+}
+
+func (ew *encryptedWriter) flush(final bool) error {
+	if ew.chunk == math.MaxUint32 {
+		return vterrors.Errorf(vtrpc.Code_RESOURCE_EXHAUSTED, "backup file %v is too large to be encrypted", ew.name)
+	}
+	binary.BigEndian.PutUint32(ew.nonce[encryptionNoncePrefixSize:], ew.chunk)
+	ew.chunk++
+
+	length := uint32(len(ew.buf) + ew.aead.Overhead())
+	if final {
+		length |= encryptionFinalChunk
+	}
+	ew.sealed = binary.BigEndian.AppendUint32(ew.sealed[:0], length)
+	ew.sealed = ew.aead.Seal(ew.sealed, ew.nonce, ew.buf, encryptionChunkData(ew.name, final))
+	ew.buf = ew.buf[:0]
+	_, err := ew.w.Write(ew.sealed)
+	return err
+}
+
+type encryptedReader struct {
+	aead    cipher.AEAD
+	r       io.Reader
+	name    string
+	nonce   []byte
+	chunk   uint32
+	started bool
+	final   bool
+	sealed  []byte
+	plain   []byte
+}
+
+func (er *encryptedReader) Read(p []byte) (int, error) {
+	for len(er.plain) == 0 {
+		if er.final {
+			// nothing may follow the last chunk
+			var b [1]byte
+			if n, _ := io.ReadFull(er.r, b[:]); n > 0 {
+				return 0, errEncryptedBackupCorrupted
+			}
+			return 0, io.EOF
+		}
+		if err := er.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, er.plain)
+	er.plain = er.plain[n:]
+	return n, nil
+}
+
+// next reads and decrypts the next chunk of the file.
+func (er *encryptedReader) next() error {
+	if !er.started {
+		if _, err := io.ReadFull(er.r, er.nonce[:encryptionNoncePrefixSize]); err != nil {
+			return encryptedReadError(err)
+		}
+		er.started = true
+	}
+	var header [4]byte
+	if _, err := io.ReadFull(er.r, header[:]); err != nil {
+		return encryptedReadError(err)
+	}
+	length := binary.BigEndian.Uint32(header[:])
+	er.final = length&encryptionFinalChunk != 0
+	length &^= encryptionFinalChunk
+	if length < uint32(er.aead.Overhead()) || length > uint32(encryptionChunkSize+er.aead.Overhead()) {
+		return errEncryptedBackupCorrupted
+	}
+	if cap(er.sealed) < int(length) {
+		er.sealed = make([]byte, length)
+	}
+	er.sealed = er.sealed[:length]
+	if _, err := io.ReadFull(er.r, er.sealed); err != nil {
+		return encryptedReadError(err)
+	}
+
+	binary.BigEndian.PutUint32(er.nonce[encryptionNoncePrefixSize:], er.chunk)
+	er.chunk++
+	plain, err := er.aead.Open(er.sealed[:0], er.nonce, er.sealed, encryptionChunkData(er.name, er.final))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errEncryptedBackupCorrupted, err)
+	}
+	er.plain = plain
+	return nil
+}
+
+// encryptionChunkData returns the additional data authenticated with a chunk.
+func encryptionChunkData(name string, final bool) []byte {
+	data := make([]byte, 0, len(name)+1)
+	data = append(data, name...)
+	if final {
+		return append(data, 1)
+	}
+	return append(data, 0)
+}
+
+func encryptedReadError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		// the file ended before its last chunk
+		return errEncryptedBackupCorrupted
+	}
+	return err
+}
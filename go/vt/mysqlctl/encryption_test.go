@@ -0,0 +1,203 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstats"
+)
+
+// setBackupEncryptionKey enables backup encryption with a new key file for the duration of the test.
+func setBackupEncryptionKey(t *testing.T) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keyFile := path.Join(t.TempDir(), "backup.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0600))
+
+	oldProvider, oldKeyFile := backupEncryptionKeyProvider, backupEncryptionKeyFile
+	backupEncryptionKeyProvider, backupEncryptionKeyFile = FileKeyProvider, keyFile
+	t.Cleanup(func() {
+		backupEncryptionKeyProvider, backupEncryptionKeyFile = oldProvider, oldKeyFile
+	})
+}
+
+func TestEncryptedFileRoundTrip(t *testing.T) {
+	setBackupEncryptionKey(t)
+	enc, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, encryptionChunkSize - 1, encryptionChunkSize, 3*encryptionChunkSize + 17} {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		var encrypted bytes.Buffer
+		w, err := enc.newWriter("0", &encrypted)
+		require.NoError(t, err)
+		_, err = w.Write(plaintext)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		// Short plaintexts can show up in the ciphertext by chance.
+		if size >= 4096 {
+			assert.NotContains(t, encrypted.String(), string(plaintext[:4096]))
+		}
+
+		got, err := io.ReadAll(enc.newReader("0", bytes.NewReader(encrypted.Bytes())))
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, got)
+	}
+}
+
+func TestEncryptedFileTampering(t *testing.T) {
+	setBackupEncryptionKey(t)
+	enc, err := newBackupEncryption(context.Background())
+	require.NoError(t, err)
+
+	plaintext := bytes.Repeat([]byte("hello, world!"), encryptionChunkSize/4)
+	var buf bytes.Buffer
+	w, err := enc.newWriter("0", &buf)
+	require.NoError(t, err)
+	_, err = w.Write(plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	encrypted := buf.Bytes()
+
+	firstChunkEnd := encryptionNoncePrefixSize + 4 + encryptionChunkSize + enc.aead.Overhead()
+	flipped := bytes.Clone(encrypted)
+	flipped[len(flipped)/2] ^= 1
+
+	testcases := []struct {
+		name string
+		file string
+		data []byte
+	}{
+		{name: "modified data", file: "0", data: flipped},
+		{name: "truncated after a chunk", file: "0", data: encrypted[:firstChunkEnd]},
+		{name: "truncated in a chunk", file: "0", data: encrypted[:firstChunkEnd-10]},
+		{name: "trailing data", file: "0", data: append(bytes.Clone(encrypted), 0)},
+		{name: "other file", file: "1", data: encrypted},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := io.ReadAll(enc.newReader(tc.file, bytes.NewReader(tc.data)))
+			assert.ErrorIs(t, err, errEncryptedBackupCorrupted)
+		})
+	}
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	ctx := context.Background()
+	setBackupEncryptionKey(t)
+	enc, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+
+	bm := &builtinBackupManifest{
+		EncryptionKeyProvider: enc.keyProvider,
+		EncryptionKeyID:       enc.keyID,
+		EncryptedDataKey:      enc.wrappedKey,
+	}
+	_, err = openBackupEncryption(ctx, bm)
+	require.NoError(t, err)
+
+	// unencrypted backups need no key
+	dec, err := openBackupEncryption(ctx, &builtinBackupManifest{})
+	require.NoError(t, err)
+	assert.Nil(t, dec)
+
+	// a backup cannot be decrypted with another key
+	setBackupEncryptionKey(t)
+	_, err = openBackupEncryption(ctx, bm)
+	assert.ErrorContains(t, err, "backup data key was wrapped with key "+enc.keyID)
+
+	backupEncryptionKeyFile = ""
+	_, err = openBackupEncryption(ctx, bm)
+	assert.ErrorContains(t, err, "--backup-encryption-key-file is required")
+
+	bm.EncryptionKeyProvider = "kms"
+	_, err = openBackupEncryption(ctx, bm)
+	assert.ErrorContains(t, err, `unknown backup encryption key provider "kms"`)
+}
+
+type bufferWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*bufferWriteCloser) Close() error {
+	return nil
+}
+
+func TestBackupAndRestoreEncryptedFile(t *testing.T) {
+	ctx := context.Background()
+	setBackupEncryptionKey(t)
+	enc, err := newBackupEncryption(ctx)
+	require.NoError(t, err)
+
+	contents := bytes.Repeat([]byte("some innodb page "), 10000)
+	backupCnf := &Mycnf{DataDir: t.TempDir()}
+	require.NoError(t, os.WriteFile(path.Join(backupCnf.DataDir, "t.ibd"), contents, 0600))
+
+	stored := &bufferWriteCloser{}
+	bh := &FakeBackupHandle{
+		AddFileReturn: FakeBackupHandleAddFileReturn{WriteCloser: stored},
+		ReadFileReturnF: func(ctx context.Context, filename string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(stored.Bytes())), nil
+		},
+	}
+	fe := &FileEntry{Base: backupData, Name: "t.ibd"}
+	be := &BuiltinBackupEngine{}
+	require.NoError(t, be.backupFile(ctx, BackupParams{
+		Cnf:    backupCnf,
+		Logger: logutil.NewMemoryLogger(),
+		Stats:  backupstats.NoStats(),
+	}, bh, fe, "0", enc))
+
+	// the backup storage never holds readable data, not even compressed
+	assert.NotContains(t, stored.String(), "some innodb page")
+
+	restoreCnf := &Mycnf{DataDir: t.TempDir()}
+	bm := builtinBackupManifest{
+		SkipCompress:          !backupStorageCompress,
+		CompressionEngine:     CompressionEngineName,
+		EncryptionKeyProvider: enc.keyProvider,
+		EncryptionKeyID:       enc.keyID,
+		EncryptedDataKey:      enc.wrappedKey,
+	}
+	dec, err := openBackupEncryption(ctx, &bm)
+	require.NoError(t, err)
+	require.NoError(t, be.restoreFile(ctx, RestoreParams{
+		Cnf:    restoreCnf,
+		Logger: logutil.NewMemoryLogger(),
+		Stats:  backupstats.NoStats(),
+	}, bh, fe, bm, "0", dec))
+
+	restored, err := os.ReadFile(path.Join(restoreCnf.DataDir, "t.ibd"))
+	require.NoError(t, err)
+	assert.Equal(t, contents, restored)
+}
@@ -0,0 +1,510 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/vt/dbconfigs"
+	vtenv "vitess.io/vitess/go/vt/env"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstats"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// This file verifies backups: it restores a backup into a scratch mysqld
+// next to the tablet's own, and checks the tables of the restored data.
+
+// builtinBackupTableChecksums tells full builtin backups to record the
+// checksums of the tables, so that VerifyBackup can compare against them.
+var builtinBackupTableChecksums = false
+
+// userTablesQuery lists the tables whose integrity is checked, leaving out the
+// system schemas.
+const userTablesQuery = `SELECT TABLE_SCHEMA, TABLE_NAME FROM information_schema.TABLES
+	WHERE TABLE_TYPE = 'BASE TABLE' AND TABLE_SCHEMA NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema')
+	ORDER BY TABLE_SCHEMA, TABLE_NAME`
+
+// VerifyBackupParams are the parameters of VerifyBackup.
+type VerifyBackupParams struct {
+	// Cnf is the configuration of the tablet's mysqld. The scratch mysqld
+	// gets a copy of it, with all the paths moved to a scratch directory.
+	Cnf *Mycnf
+	// Mysqld is the tablet's mysqld, which is left alone.
+	Mysqld *Mysqld
+	Logger logutil.Logger
+	// Concurrency is how many files are restored in parallel.
+	Concurrency int
+	Keyspace    string
+	Shard       string
+	// BackupName is the backup to verify. The latest backup is verified when it is empty.
+	BackupName string
+	Stats      backupstats.Stats
+	// MysqlShutdownTimeout is how long to wait for the scratch mysqld to shut down.
+	MysqlShutdownTimeout time.Duration
+}
+
+// VerifyBackup restores a backup, along with the backups it builds on, into a
+// scratch mysqld that is started with --skip-networking. It then runs CHECK TABLE
+// and CHECKSUM TABLE on every table, and compares the checksums with the ones the
+// backup recorded, if any. The scratch mysqld and its data are removed afterwards.
+// Only builtin backups can be verified.
+func VerifyBackup(ctx context.Context, params VerifyBackupParams) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	// The scratch mysqld is started by this process, on this host.
+	if socketFile != "" || params.Mysqld.dbcfgs.HasGlobalSettings() {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "cannot verify backups on a tablet whose mysqld is not managed locally")
+	}
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return nil, err
+	}
+	defer bs.Close()
+
+	backupDir := GetBackupDir(params.Keyspace, params.Shard)
+	bhs, err := bs.ListBackups(ctx, backupDir)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "ListBackups failed")
+	}
+	restoreParams := RestoreParams{
+		Cnf:                  params.Cnf,
+		Mysqld:               params.Mysqld,
+		Logger:               params.Logger,
+		Concurrency:          params.Concurrency,
+		Keyspace:             params.Keyspace,
+		Shard:                params.Shard,
+		Stats:                params.Stats,
+		MysqlShutdownTimeout: params.MysqlShutdownTimeout,
+	}
+	restorePath, manifest, err := findBackupToVerify(ctx, &restoreParams, bhs, params.BackupName)
+	if err != nil {
+		return nil, err
+	}
+	params.Logger.Infof("VerifyBackup: %v", restorePath.String())
+
+	resp := &tabletmanagerdatapb.VerifyBackupResponse{BackupName: manifest.BackupName}
+	for _, m := range restorePath.manifests {
+		resp.RestoredBackups = append(resp.RestoredBackups, restorePath.manifestHandleMap.Handle(m).Name())
+	}
+
+	// Restore into a scratch directory next to the tablet's own files.
+	dir, err := os.MkdirTemp(params.Cnf.TabletDir(), "verify-backup-")
+	if err != nil {
+		return nil, vterrors.Wrap(err, "can't create scratch directory")
+	}
+	defer os.RemoveAll(dir)
+	cnf, err := newScratchMycnf(params.Cnf, dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range cnf.directoryList() {
+		if err := os.MkdirAll(d, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+	if err := params.Mysqld.initConfig(cnf, cnf.Path); err != nil {
+		return nil, vterrors.Wrap(err, "can't write scratch my.cnf")
+	}
+	restoreParams.Cnf = cnf
+
+	be := &BuiltinBackupEngine{}
+	var bm builtinBackupManifest
+	bh := restorePath.FullBackupHandle()
+	if err := getBackupManifestInto(ctx, bh, &bm); err != nil {
+		return nil, err
+	}
+	params.Logger.Infof("VerifyBackup: restoring backup %v into %v", bh.Name(), dir)
+	if _, err := be.restoreFiles(ctx, restoreParams, bh, bm); err != nil {
+		return nil, vterrors.Wrapf(err, "failed to restore backup %v", bh.Name())
+	}
+
+	scratch, err := startScratchMysqld(ctx, params.Mysqld, cnf)
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.stop(params.Logger, params.MysqlShutdownTimeout)
+
+	restoreParams.Mysqld = scratch.mysqld
+	for _, bh := range restorePath.IncrementalBackupHandles() {
+		var bm builtinBackupManifest
+		if err := getBackupManifestInto(ctx, bh, &bm); err != nil {
+			return nil, err
+		}
+		params.Logger.Infof("VerifyBackup: applying incremental backup %v", bh.Name())
+		if err := be.executeRestoreIncrementalBackup(ctx, restoreParams, bh, bm); err != nil {
+			return nil, vterrors.Wrapf(err, "failed to apply incremental backup %v", bh.Name())
+		}
+	}
+
+	// Only full backups record checksums, since incremental backups run while
+	// mysqld serves writes.
+	var expected map[string]string
+	if !manifest.Incremental {
+		expected = bm.TableChecksums
+	}
+	resp.Tables, err = checkScratchTables(ctx, params.Logger, scratch.mysqld, expected)
+	if err != nil {
+		return nil, err
+	}
+	resp.Ok = true
+	for _, table := range resp.Tables {
+		resp.Ok = resp.Ok && table.Ok
+	}
+	params.Logger.Infof("VerifyBackup: checked %v tables of backup %v, ok: %v", len(resp.Tables), manifest.BackupName, resp.Ok)
+	return resp, nil
+}
+
+// findBackupToVerify returns the restore path of the given backup, or of the
+// latest readable backup when backupName is empty, along with its manifest.
+func findBackupToVerify(ctx context.Context, params *RestoreParams, bhs []backupstorage.BackupHandle, backupName string) (*RestorePath, *BackupManifest, error) {
+	var bh backupstorage.BackupHandle
+	var manifest *BackupManifest
+	// backups are sorted by name, which starts with the backup time
+	for i := len(bhs) - 1; i >= 0; i-- {
+		if backupName != "" && bhs[i].Name() != backupName {
+			continue
+		}
+		bm, err := GetBackupManifest(ctx, bhs[i])
+		if err != nil {
+			if backupName != "" {
+				return nil, nil, vterrors.Wrapf(err, "can't read the manifest of backup %v", backupName)
+			}
+			params.Logger.Warningf("Possibly incomplete backup %v: can't read MANIFEST: %v", bhs[i].Name(), err)
+			continue
+		}
+		bh, manifest = bhs[i], bm
+		break
+	}
+	if manifest == nil {
+		if backupName != "" {
+			return nil, nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "could not find backup %q for %s/%s", backupName, params.Keyspace, params.Shard)
+		}
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "no backup to verify for %s/%s", params.Keyspace, params.Shard)
+	}
+
+	var restorePath *RestorePath
+	if manifest.Incremental {
+		// restore the chain of backups that leads to the position of the incremental backup
+		params.RestoreToPos = manifest.Position
+		var err error
+		if restorePath, err = FindBackupToRestore(ctx, *params, bhs); err != nil {
+			return nil, nil, vterrors.Wrapf(err, "can't find the backups incremental backup %v builds on", bh.Name())
+		}
+	} else {
+		restorePath = &RestorePath{manifestHandleMap: NewManifestHandleMap()}
+		restorePath.manifestHandleMap.Map(manifest, bh)
+		restorePath.Add(manifest)
+	}
+	if restorePath.IsEmpty() {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "empty restore path")
+	}
+	for _, m := range restorePath.manifests {
+		if m.BackupMethod != "" && m.BackupMethod != builtinBackupEngineName {
+			return nil, nil, vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "cannot verify backup %v taken with backup engine %v", restorePath.manifestHandleMap.Handle(m).Name(), m.BackupMethod)
+		}
+	}
+	return restorePath, manifest, nil
+}
+
+// newScratchMycnf returns a copy of cnf whose files all live under dir.
+func newScratchMycnf(cnf *Mycnf, dir string) (*Mycnf, error) {
+	scratch := *cnf
+	scratch.mycnfMap = nil
+	scratch.Path = path.Join(dir, "my.cnf")
+	scratch.DataDir = path.Join(dir, dataDir)
+	scratch.InnodbDataHomeDir = path.Join(dir, innodbDataSubdir)
+	scratch.InnodbLogGroupHomeDir = path.Join(dir, innodbLogSubdir)
+	scratch.SocketFile = path.Join(dir, "mysql.sock")
+	scratch.GeneralLogPath = path.Join(dir, "general.log")
+	scratch.ErrorLogPath = path.Join(dir, "error.log")
+	scratch.SlowLogPath = path.Join(dir, "slow-query.log")
+	scratch.RelayLogPath = path.Join(dir, relayLogDir, path.Base(cnf.RelayLogPath))
+	scratch.RelayLogIndexPath = scratch.RelayLogPath + ".index"
+	scratch.RelayLogInfoPath = path.Join(dir, relayLogDir, "relay-log.info")
+	scratch.BinLogPath = path.Join(dir, binLogDir, path.Base(cnf.BinLogPath))
+	scratch.MasterInfoFile = path.Join(dir, "master.info")
+	scratch.PidFile = path.Join(dir, "mysql.pid")
+	scratch.TmpDir = path.Join(dir, "tmp")
+	if cnf.SecureFilePriv != "" {
+		scratch.SecureFilePriv = scratch.TmpDir
+	}
+	if err := scratch.RandomizeMysqlServerID(); err != nil {
+		return nil, err
+	}
+	return &scratch, nil
+}
+
+// scratchMysqld is a mysqld started by VerifyBackup.
+type scratchMysqld struct {
+	cmd *exec.Cmd
+	// exited is closed once the process exits.
+	exited chan struct{}
+	// mysqld connects to the scratch mysqld through its socket.
+	mysqld *Mysqld
+}
+
+// startScratchMysqld runs mysqld with the given configuration, and waits until it accepts
+// connections. Unlike Mysqld.Start, it neither runs the mysqld_start hook nor mysqld_safe,
+// so that the process can be stopped without touching the tablet's own mysqld.
+func startScratchMysqld(ctx context.Context, mysqld *Mysqld, cnf *Mycnf) (*scratchMysqld, error) {
+	vtMysqlRoot, err := vtenv.VtMysqlRoot()
+	if err != nil {
+		return nil, err
+	}
+	name, err := binaryPath(vtMysqlRoot, "mysqld")
+	if err != nil {
+		return nil, err
+	}
+	mysqlBaseDir, err := vtenv.VtMysqlBaseDir()
+	if err != nil {
+		return nil, err
+	}
+	env, err := buildLdPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	// The same database users exist in the restored data, so connect as the
+	// tablet does, but through the scratch socket.
+	dbcfgs := mysqld.dbcfgs.Clone()
+	var connParams []mysql.ConnParams
+	for _, connector := range []dbconfigs.Connector{dbcfgs.DbaConnector(), dbcfgs.AppWithDB(), dbcfgs.FilteredWithDB()} {
+		cp, err := connector.MysqlParams()
+		if err != nil {
+			return nil, err
+		}
+		cp.UnixSocket, cp.Host, cp.Port = cnf.SocketFile, "", 0
+		connParams = append(connParams, *cp)
+	}
+	dbcfgs.SetDbParams(connParams[0], connParams[1], connParams[2])
+
+	ts := fmt.Sprintf("VerifyBackup mysqld(%v)", time.Now().Unix())
+	cmd := exec.Command(name,
+		"--defaults-file="+cnf.Path,
+		"--basedir="+mysqlBaseDir,
+		"--skip-networking",
+		// skip-slave-start was renamed in MySQL 8.0.26, and removed in 8.4
+		"--loose-skip-slave-start",
+		"--loose-skip-replica-start",
+	)
+	cmd.Dir = vtMysqlRoot
+	cmd.Env = env
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Infof("%v stderr: %v", ts, scanner.Text())
+		}
+	}()
+	log.Infof("%v %#v", ts, cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, vterrors.Wrapf(err, "failed to start scratch mysqld")
+	}
+	scratch := &scratchMysqld{
+		cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+	go func() {
+		err := cmd.Wait()
+		log.Infof("%v exit: %v", ts, err)
+		close(scratch.exited)
+	}()
+
+	// Stop waiting as soon as mysqld exits, which it does when the restored data is unusable.
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-scratch.exited:
+			cancel()
+		case <-waitCtx.Done():
+		}
+	}()
+	scratch.mysqld = NewMysqld(dbcfgs)
+	if err := scratch.mysqld.Wait(waitCtx, cnf); err != nil {
+		scratch.stop(logutil.NewConsoleLogger(), 0)
+		select {
+		case <-scratch.exited:
+			return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "scratch mysqld exited while starting, see its error log for details: %v", cnf.ErrorLogPath)
+		default:
+			return nil, vterrors.Wrap(err, "scratch mysqld did not start")
+		}
+	}
+	return scratch, nil
+}
+
+// stop shuts the scratch mysqld down, and kills it if it does not exit within the timeout.
+func (scratch *scratchMysqld) stop(logger logutil.Logger, timeout time.Duration) {
+	scratch.mysqld.Close()
+	select {
+	case <-scratch.exited:
+		return
+	default:
+	}
+	if err := scratch.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		logger.Warningf("can't signal scratch mysqld: %v", err)
+	}
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	select {
+	case <-scratch.exited:
+	case <-time.After(timeout):
+		logger.Warningf("scratch mysqld did not shut down within %v, killing it", timeout)
+		scratch.cmd.Process.Kill()
+		<-scratch.exited
+	}
+}
+
+// checkScratchTables runs CHECK TABLE and CHECKSUM TABLE on every table of the restored data.
+// A table that has an expected checksum is only ok if its checksum matches, and the tables
+// that have an expected checksum but are missing from the restored data are reported too.
+func checkScratchTables(ctx context.Context, logger logutil.Logger, mysqld MysqlDaemon, expected map[string]string) ([]*tabletmanagerdatapb.VerifyBackupTable, error) {
+	tables, err := listUserTables(ctx, mysqld)
+	if err != nil {
+		return nil, err
+	}
+	var result []*tabletmanagerdatapb.VerifyBackupTable
+	seen := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		name := table[0] + "." + table[1]
+		seen[name] = true
+		check, ok, err := checkTable(ctx, mysqld, table[0], table[1])
+		if err != nil {
+			return nil, err
+		}
+		checksum, err := checksumTable(ctx, mysqld, table[0], table[1])
+		if err != nil {
+			return nil, err
+		}
+		t := &tabletmanagerdatapb.VerifyBackupTable{
+			Name:             name,
+			Check:            check,
+			Checksum:         checksum,
+			ExpectedChecksum: expected[name],
+		}
+		t.Ok = ok && (t.ExpectedChecksum == "" || t.ExpectedChecksum == t.Checksum)
+		if !t.Ok {
+			logger.Warningf("VerifyBackup: table %v failed verification: check %q, checksum %v, expected checksum %v", name, check, checksum, t.ExpectedChecksum)
+		}
+		result = append(result, t)
+	}
+	var missing []string
+	for name := range expected {
+		if !seen[name] {
+			missing = append(missing, name)
+		}
+	}
+	slices.Sort(missing)
+	for _, name := range missing {
+		logger.Warningf("VerifyBackup: table %v is missing from the restored data", name)
+		result = append(result, &tabletmanagerdatapb.VerifyBackupTable{
+			Name:             name,
+			Check:            "missing",
+			ExpectedChecksum: expected[name],
+		})
+	}
+	return result, nil
+}
+
+// listUserTables returns the schema and name of the tables outside of the system schemas.
+func listUserTables(ctx context.Context, mysqld MysqlDaemon) ([][2]string, error) {
+	qr, err := mysqld.FetchSuperQuery(ctx, userTablesQuery)
+	if err != nil {
+		return nil, vterrors.Wrap(err, "can't list tables")
+	}
+	tables := make([][2]string, 0, len(qr.Rows))
+	for _, row := range qr.Rows {
+		tables = append(tables, [2]string{row[0].ToString(), row[1].ToString()})
+	}
+	return tables, nil
+}
+
+// checkTable runs CHECK TABLE, and returns the messages it reported and whether the table is ok.
+func checkTable(ctx context.Context, mysqld MysqlDaemon, schema, table string) (string, bool, error) {
+	qr, err := mysqld.FetchSuperQuery(ctx, "CHECK TABLE "+sqlescape.EscapeID(schema)+"."+sqlescape.EscapeID(table))
+	if err != nil {
+		return "", false, vterrors.Wrapf(err, "can't check table %v.%v", schema, table)
+	}
+	// the rows are: Table, Op, Msg_type, Msg_text
+	var messages []string
+	ok := false
+	for _, row := range qr.Rows {
+		if len(row) < 4 {
+			continue
+		}
+		msgType, msgText := row[2].ToString(), row[3].ToString()
+		if msgType == "status" && msgText == "OK" {
+			ok = true
+			continue
+		}
+		messages = append(messages, msgType+": "+msgText)
+	}
+	if ok && len(messages) == 0 {
+		return "OK", true, nil
+	}
+	return strings.Join(messages, "; "), ok, nil
+}
+
+// checksumTable runs CHECKSUM TABLE, and returns the checksum of the table.
+func checksumTable(ctx context.Context, mysqld MysqlDaemon, schema, table string) (string, error) {
+	qr, err := mysqld.FetchSuperQuery(ctx, "CHECKSUM TABLE "+sqlescape.EscapeID(schema)+"."+sqlescape.EscapeID(table))
+	if err != nil {
+		return "", vterrors.Wrapf(err, "can't checksum table %v.%v", schema, table)
+	}
+	if len(qr.Rows) != 1 || len(qr.Rows[0]) < 2 {
+		return "", vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unexpected CHECKSUM TABLE result for %v.%v: %v", schema, table, qr.Rows)
+	}
+	return qr.Rows[0][1].ToString(), nil
+}
+
+// tableChecksums returns the checksums of the tables outside of the system schemas,
+// keyed by <schema>.<table>.
+func tableChecksums(ctx context.Context, mysqld MysqlDaemon) (map[string]string, error) {
+	tables, err := listUserTables(ctx, mysqld)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string, len(tables))
+	for _, table := range tables {
+		checksum, err := checksumTable(ctx, mysqld, table[0], table[1])
+		if err != nil {
+			return nil, err
+		}
+		checksums[table[0]+"."+table[1]] = checksum
+	}
+	return checksums, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlctl
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestNewScratchMycnf(t *testing.T) {
+	cnf := NewMycnf(100, 17100)
+	scratch, err := newScratchMycnf(cnf, "/vt/vt_0000000100/verify-backup-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/vt/vt_0000000100/verify-backup-1/my.cnf", scratch.Path)
+	assert.Equal(t, "/vt/vt_0000000100/verify-backup-1", scratch.TabletDir())
+	assert.Equal(t, "/vt/vt_0000000100/verify-backup-1/bin-logs/vt-0000000100-bin", scratch.BinLogPath)
+	assert.Equal(t, scratch.TmpDir, scratch.SecureFilePriv)
+	for _, p := range []string{
+		scratch.DataDir, scratch.InnodbDataHomeDir, scratch.InnodbLogGroupHomeDir, scratch.SocketFile,
+		scratch.GeneralLogPath, scratch.ErrorLogPath, scratch.SlowLogPath, scratch.RelayLogPath,
+		scratch.RelayLogIndexPath, scratch.RelayLogInfoPath, scratch.MasterInfoFile, scratch.PidFile, scratch.TmpDir,
+	} {
+		assert.True(t, strings.HasPrefix(p, "/vt/vt_0000000100/verify-backup-1/"), p)
+	}
+	assert.NotEqual(t, cnf.ServerID, scratch.ServerID)
+	// the tablet's configuration is left alone
+	assert.Equal(t, NewMycnf(100, 17100).DataDir, cnf.DataDir)
+}
+
+func TestCheckScratchTables(t *testing.T) {
+	ctx := context.Background()
+	fakedb := fakesqldb.New(t)
+	defer fakedb.Close()
+	mysqld := NewFakeMysqlDaemon(fakedb)
+	defer mysqld.Close()
+
+	checkFields := sqltypes.MakeTestFields("Table|Op|Msg_type|Msg_text", "varchar|varchar|varchar|varchar")
+	checksumFields := sqltypes.MakeTestFields("Table|Checksum", "varchar|int64")
+	mysqld.FetchSuperQueryMap = map[string]*sqltypes.Result{
+		userTablesQuery: sqltypes.MakeTestResult(sqltypes.MakeTestFields("TABLE_SCHEMA|TABLE_NAME", "varchar|varchar"),
+			"vt_ks|corrupt", "vt_ks|good", "vt_ks|modified"),
+		"CHECK TABLE `vt_ks`.`corrupt`":     sqltypes.MakeTestResult(checkFields, "vt_ks.corrupt|check|error|Corrupt"),
+		"CHECK TABLE `vt_ks`.`good`":        sqltypes.MakeTestResult(checkFields, "vt_ks.good|check|status|OK"),
+		"CHECK TABLE `vt_ks`.`modified`":    sqltypes.MakeTestResult(checkFields, "vt_ks.modified|check|status|OK"),
+		"CHECKSUM TABLE `vt_ks`.`corrupt`":  sqltypes.MakeTestResult(checksumFields, "vt_ks.corrupt|1"),
+		"CHECKSUM TABLE `vt_ks`.`good`":     sqltypes.MakeTestResult(checksumFields, "vt_ks.good|2"),
+		"CHECKSUM TABLE `vt_ks`.`modified`": sqltypes.MakeTestResult(checksumFields, "vt_ks.modified|3"),
+	}
+
+	checksums, err := tableChecksums(ctx, mysqld)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"vt_ks.corrupt": "1", "vt_ks.good": "2", "vt_ks.modified": "3"}, checksums)
+
+	// without recorded checksums, only CHECK TABLE decides
+	tables, err := checkScratchTables(ctx, logutil.NewMemoryLogger(), mysqld, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*tabletmanagerdatapb.VerifyBackupTable{
+		{Name: "vt_ks.corrupt", Check: "error: Corrupt", Checksum: "1"},
+		{Name: "vt_ks.good", Check: "OK", Checksum: "2", Ok: true},
+		{Name: "vt_ks.modified", Check: "OK", Checksum: "3", Ok: true},
+	}, tables)
+
+	tables, err = checkScratchTables(ctx, logutil.NewMemoryLogger(), mysqld, map[string]string{
+		"vt_ks.corrupt":  "1",
+		"vt_ks.good":     "2",
+		"vt_ks.modified": "4",
+		"vt_ks.dropped":  "5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*tabletmanagerdatapb.VerifyBackupTable{
+		{Name: "vt_ks.corrupt", Check: "error: Corrupt", Checksum: "1", ExpectedChecksum: "1"},
+		{Name: "vt_ks.good", Check: "OK", Checksum: "2", ExpectedChecksum: "2", Ok: true},
+		{Name: "vt_ks.modified", Check: "OK", Checksum: "3", ExpectedChecksum: "4"},
+		{Name: "vt_ks.dropped", Check: "missing", ExpectedChecksum: "5"},
+	}, tables)
+}
+
+// addBackupManifest creates a backup of ks/0 holding only the given manifest.
+func addBackupManifest(t *testing.T, bs backupstorage.BackupStorage, bm *builtinBackupManifest) {
+	ctx := context.Background()
+	bh, err := bs.StartBackup(ctx, "ks/0", bm.BackupName)
+	require.NoError(t, err)
+	wc, err := bh.AddFile(ctx, backupManifestFileName, backupstorage.FileSizeUnknown)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(wc).Encode(bm))
+	require.NoError(t, wc.Close())
+	require.NoError(t, bh.EndBackup(ctx))
+}
+
+func TestFindBackupToVerify(t *testing.T) {
+	ctx := context.Background()
+	bs := setFileBackupStorage(t)
+	defer bs.Close()
+
+	addBackupManifest(t, bs, &builtinBackupManifest{BackupManifest: BackupManifest{BackupName: "2024-01-01.000000.zone1-0000000100", BackupMethod: builtinBackupEngineName}})
+	addBackupManifest(t, bs, &builtinBackupManifest{BackupManifest: BackupManifest{BackupName: "2024-01-02.000000.zone1-0000000100", BackupMethod: "xtrabackup"}})
+	addBackupManifest(t, bs, &builtinBackupManifest{BackupManifest: BackupManifest{BackupName: "2024-01-03.000000.zone1-0000000100", BackupMethod: builtinBackupEngineName}})
+	// an incomplete backup is skipped when looking for the latest backup
+	bh, err := bs.StartBackup(ctx, "ks/0", "2024-01-04.000000.zone1-0000000100")
+	require.NoError(t, err)
+	require.NoError(t, bh.EndBackup(ctx))
+
+	bhs, err := bs.ListBackups(ctx, "ks/0")
+	require.NoError(t, err)
+	params := &RestoreParams{Logger: logutil.NewMemoryLogger(), Keyspace: "ks", Shard: "0"}
+
+	restorePath, manifest, err := findBackupToVerify(ctx, params, bhs, "")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-03.000000.zone1-0000000100", manifest.BackupName)
+	assert.Equal(t, "2024-01-03.000000.zone1-0000000100", restorePath.FullBackupHandle().Name())
+	assert.Empty(t, restorePath.IncrementalBackupHandles())
+
+	restorePath, manifest, err = findBackupToVerify(ctx, params, bhs, "2024-01-01.000000.zone1-0000000100")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01.000000.zone1-0000000100", manifest.BackupName)
+	assert.Equal(t, 1, restorePath.Len())
+
+	_, _, err = findBackupToVerify(ctx, params, bhs, "2024-01-02.000000.zone1-0000000100")
+	assert.Equal(t, vtrpcpb.Code_UNIMPLEMENTED, vterrors.Code(err))
+
+	_, _, err = findBackupToVerify(ctx, params, bhs, "2024-01-04.000000.zone1-0000000100")
+	assert.ErrorContains(t, err, "can't read the manifest of backup")
+
+	_, _, err = findBackupToVerify(ctx, params, bhs, "nonexistent")
+	assert.Equal(t, vtrpcpb.Code_NOT_FOUND, vterrors.Code(err))
+}
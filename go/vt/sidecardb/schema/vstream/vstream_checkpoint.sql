@@ -0,0 +1,26 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+CREATE TABLE IF NOT EXISTS vstream_checkpoint
+(
+    `consumer`   varbinary(255)   NOT NULL,
+    `keyspace`   varbinary(255)   NOT NULL,
+    `shard`      varbinary(255)   NOT NULL,
+    `gtid`       varbinary(10000) NOT NULL,
+    `table_pks`  longblob                  DEFAULT NULL,
+    `updated_at` timestamp        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (`consumer`, `keyspace`, `shard`)
+) ENGINE = InnoDB CHARSET = utf8mb4
@@ -0,0 +1,23 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+CREATE TABLE IF NOT EXISTS vstream_consumer_lease
+(
+    `consumer`   varbinary(255) NOT NULL,
+    `owner`      varbinary(255) NOT NULL,
+    `expires_at` timestamp      NOT NULL,
+    PRIMARY KEY (`consumer`)
+) ENGINE = InnoDB CHARSET = utf8mb4
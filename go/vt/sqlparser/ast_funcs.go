@@ -2205,6 +2205,10 @@ func ContainsAggregation(e SQLNode) bool {
 			// so we don't need to worry about aggregation in the original
 			return false, nil
 		case AggrFunc:
+			if GetOverClause(node) != nil {
+				// aggregations with an OVER clause are window functions and do not aggregate rows
+				return true, nil
+			}
 			hasAggregates = true
 			return false, io.EOF
 		}
@@ -2214,6 +2218,86 @@ func ContainsAggregation(e SQLNode) bool {
 	return hasAggregates
 }
 
+// GetOverClause returns the OVER clause of a window function call.
+// Aggregation functions are only window functions when they have an OVER clause.
+// For all other expressions, nil is returned
+func GetOverClause(node SQLNode) *OverClause {
+	switch node := node.(type) {
+	case *ArgumentLessWindowExpr:
+		return node.OverClause
+	case *FirstOrLastValueExpr:
+		return node.OverClause
+	case *NtileExpr:
+		return node.OverClause
+	case *NTHValueExpr:
+		return node.OverClause
+	case *LagLeadExpr:
+		return node.OverClause
+	case *Count:
+		return node.OverClause
+	case *CountStar:
+		return node.OverClause
+	case *Avg:
+		return node.OverClause
+	case *Max:
+		return node.OverClause
+	case *Min:
+		return node.OverClause
+	case *Sum:
+		return node.OverClause
+	case *BitAnd:
+		return node.OverClause
+	case *BitOr:
+		return node.OverClause
+	case *BitXor:
+		return node.OverClause
+	case *Std:
+		return node.OverClause
+	case *StdDev:
+		return node.OverClause
+	case *StdPop:
+		return node.OverClause
+	case *StdSamp:
+		return node.OverClause
+	case *VarPop:
+		return node.OverClause
+	case *VarSamp:
+		return node.OverClause
+	case *Variance:
+		return node.OverClause
+	case *JSONArrayAgg:
+		return node.OverClause
+	case *JSONObjectAgg:
+		return node.OverClause
+	}
+	return nil
+}
+
+// IsWindowFunc returns true if the node is a window function call
+func IsWindowFunc(node SQLNode) bool {
+	return GetOverClause(node) != nil
+}
+
+// ContainsWindowFunc returns true if the expression contains a window function call
+func ContainsWindowFunc(e SQLNode) bool {
+	hasWindowFunc := false
+	_ = Walk(func(node SQLNode) (kontinue bool, err error) {
+		switch node.(type) {
+		case *Offset:
+			// offsets here indicate that the window function has already been handled by an input
+			return false, nil
+		case *Subquery:
+			return false, nil
+		}
+		if IsWindowFunc(node) {
+			hasWindowFunc = true
+			return false, io.EOF
+		}
+		return true, nil
+	}, e)
+	return hasWindowFunc
+}
+
 // setFuncArgs sets the arguments for the aggregation function, while checking that there is only one argument
 func setFuncArgs(aggr AggrFunc, exprs Exprs, name string) error {
 	if len(exprs) != 1 {
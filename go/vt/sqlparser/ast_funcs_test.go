@@ -219,3 +219,42 @@ func TestExtractTables(t *testing.T) {
 		})
 	}
 }
+
+// TestWindowFunctions verifies that window function calls are told apart from plain aggregations.
+func TestWindowFunctions(t *testing.T) {
+	tcases := []struct {
+		expr        string
+		window      bool
+		aggregation bool
+	}{{
+		expr:        "count(*)",
+		aggregation: true,
+	}, {
+		expr:   "count(*) over ()",
+		window: true,
+	}, {
+		expr:   "sum(a) over (partition by b order by c)",
+		window: true,
+	}, {
+		expr:   "row_number() over w",
+		window: true,
+	}, {
+		expr:   "lag(a, 2) over (order by b) + 1",
+		window: true,
+	}, {
+		expr:        "sum(max(a)) over (partition by b)",
+		window:      true,
+		aggregation: true,
+	}, {
+		expr: "a + 1",
+	}}
+	parser := NewTestParser()
+	for _, tcase := range tcases {
+		t.Run(tcase.expr, func(t *testing.T) {
+			expr, err := parser.ParseExpr(tcase.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tcase.window, ContainsWindowFunc(expr))
+			assert.Equal(t, tcase.aggregation, ContainsAggregation(expr))
+		})
+	}
+}
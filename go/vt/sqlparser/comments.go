@@ -58,6 +58,12 @@ const (
 	// DirectivePriority specifies the priority of a workload. It should be an integer between 0 and MaxPriorityValue,
 	// where 0 is the highest priority, and MaxPriorityValue is the lowest one.
 	DirectivePriority = "PRIORITY"
+	// DirectiveAllowNonAtomicRowMove lets an UPDATE of the primary vindex columns move rows
+	// between shards when the transaction mode does not guarantee atomicity.
+	DirectiveAllowNonAtomicRowMove = "ALLOW_NON_ATOMIC_ROW_MOVE"
+	// DirectiveResultCacheTTL lets vtgate cache the results of a SELECT on a replica or rdonly target
+	// for the given duration, such as 5s.
+	DirectiveResultCacheTTL = "RESULT_CACHE_TTL"
 
 	// MaxPriorityValue specifies the maximum value allowed for the priority query directive. Valid priority values are
 	// between zero and MaxPriorityValue.
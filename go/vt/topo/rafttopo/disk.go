@@ -0,0 +1,289 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+
+	"vitess.io/vitess/go/vt/log"
+)
+
+const (
+	snapshotFile = "snapshot"
+	logFile      = "raft.log"
+
+	recordHardState byte = 1
+	recordEntry     byte = 2
+
+	// recordHeaderSize is the size of the length, checksum and type of a record.
+	recordHeaderSize = 9
+)
+
+// diskStorage persists the raft state of a member in its data directory:
+// the last snapshot in one file, and the hard state and the entries since
+// the snapshot in a log. The log is appended to on every Ready, and
+// rewritten on every snapshot.
+type diskStorage struct {
+	dir string
+	log *os.File
+}
+
+func newDiskStorage(dir string) (*diskStorage, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &diskStorage{dir: dir}, nil
+}
+
+// exists returns true if the member has persisted raft state, which means
+// it is restarting rather than joining the cluster for the first time.
+func (d *diskStorage) exists() bool {
+	_, err := os.Stat(path.Join(d.dir, logFile))
+	return err == nil
+}
+
+// load loads the persisted raft state into ms, and returns the snapshot it
+// starts from. A torn record at the end of the log, left behind by a crash,
+// is dropped.
+func (d *diskStorage) load(ms *raft.MemoryStorage) (raftpb.Snapshot, error) {
+	var snapshot raftpb.Snapshot
+	data, err := os.ReadFile(path.Join(d.dir, snapshotFile))
+	switch {
+	case err == nil:
+		if err := snapshot.Unmarshal(data); err != nil {
+			return snapshot, fmt.Errorf("cannot read %v: %w", snapshotFile, err)
+		}
+		if err := ms.ApplySnapshot(snapshot); err != nil {
+			return snapshot, err
+		}
+	case !os.IsNotExist(err):
+		return snapshot, err
+	}
+
+	f, err := os.OpenFile(path.Join(d.dir, logFile), os.O_RDWR, 0o600)
+	if err != nil {
+		return snapshot, err
+	}
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		recordType, payload, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Warningf("dropping the end of %v at offset %v: %v", logFile, offset, err)
+			if err := f.Truncate(offset); err != nil {
+				f.Close()
+				return snapshot, err
+			}
+			break
+		}
+		offset += int64(recordHeaderSize + len(payload))
+
+		switch recordType {
+		case recordHardState:
+			var hs raftpb.HardState
+			if err := hs.Unmarshal(payload); err != nil {
+				f.Close()
+				return snapshot, err
+			}
+			if err := ms.SetHardState(hs); err != nil {
+				f.Close()
+				return snapshot, err
+			}
+		case recordEntry:
+			var entry raftpb.Entry
+			if err := entry.Unmarshal(payload); err != nil {
+				f.Close()
+				return snapshot, err
+			}
+			if err := ms.Append([]raftpb.Entry{entry}); err != nil {
+				f.Close()
+				return snapshot, err
+			}
+		}
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return snapshot, err
+	}
+	d.log = f
+	return snapshot, nil
+}
+
+// create creates an empty log, for a member that starts from scratch.
+func (d *diskStorage) create() error {
+	f, err := os.OpenFile(path.Join(d.dir, logFile), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	d.log = f
+	return syncDir(d.dir)
+}
+
+// save appends the hard state and the entries to the log.
+func (d *diskStorage) save(hs raftpb.HardState, entries []raftpb.Entry) error {
+	if raft.IsEmptyHardState(hs) && len(entries) == 0 {
+		return nil
+	}
+	if err := writeRecords(d.log, hs, entries); err != nil {
+		return err
+	}
+	return d.log.Sync()
+}
+
+// saveSnapshot replaces the snapshot.
+func (d *diskStorage) saveSnapshot(snapshot raftpb.Snapshot) error {
+	data, err := snapshot.Marshal()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(d.dir, snapshotFile, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// rewrite replaces the log with one that only has the hard state and the
+// entries, once a snapshot made the others useless.
+func (d *diskStorage) rewrite(hs raftpb.HardState, entries []raftpb.Entry) error {
+	if err := writeFileAtomically(d.dir, logFile, func(f *os.File) error {
+		return writeRecords(f, hs, entries)
+	}); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path.Join(d.dir, logFile), os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	d.log.Close()
+	d.log = f
+	return nil
+}
+
+func (d *diskStorage) close() {
+	if d.log != nil {
+		d.log.Close()
+	}
+}
+
+func writeRecords(w io.Writer, hs raftpb.HardState, entries []raftpb.Entry) error {
+	bw := bufio.NewWriter(w)
+	for i := range entries {
+		data, err := entries[i].Marshal()
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(bw, recordEntry, data); err != nil {
+			return err
+		}
+	}
+	// The hard state goes last, so that its commit index never points past
+	// the entries of the log.
+	if !raft.IsEmptyHardState(hs) {
+		data, err := hs.Marshal()
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(bw, recordHardState, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeRecord writes a record: the length of the payload, a checksum of the
+// type and payload, the type, and the payload.
+func writeRecord(w io.Writer, recordType byte, payload []byte) error {
+	var header [recordHeaderSize]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], recordChecksum(recordType, payload))
+	header[8] = recordType
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readRecord(r io.Reader) (byte, []byte, error) {
+	var header [recordHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, nil, errors.New("truncated record header")
+		}
+		return 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[0:4]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, errors.New("truncated record")
+	}
+	if recordChecksum(header[8], payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return 0, nil, errors.New("record checksum mismatch")
+	}
+	return header[8], payload, nil
+}
+
+func recordChecksum(recordType byte, payload []byte) uint32 {
+	crc := crc32.Update(0, crc32.IEEETable, []byte{recordType})
+	return crc32.Update(crc, crc32.IEEETable, payload)
+}
+
+// writeFileAtomically writes a file through a temporary file that replaces
+// it once it is synced.
+func writeFileAtomically(dir, name string, write func(f *os.File) error) error {
+	tmp := path.Join(dir, name+".tmp")
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path.Join(dir, name)); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"path"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+// NewLeaderParticipation is part of the topo.Server interface
+func (s *Server) NewLeaderParticipation(name, id string) (topo.LeaderParticipation, error) {
+	return &raftLeaderParticipation{
+		s:    s,
+		name: name,
+		id:   id,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// raftLeaderParticipation implements topo.LeaderParticipation.
+//
+// We use a directory (in global election path, with the name) with
+// ephemeral files in it, that contains the id.  The oldest revision
+// wins the election.
+type raftLeaderParticipation struct {
+	// s is our parent raft topo Server
+	s *Server
+
+	// name is the name of this LeaderParticipation
+	name string
+
+	// id is the process's current id.
+	id string
+
+	// stop is a channel closed when Stop is called.
+	stop chan struct{}
+
+	// done is a channel closed when we're done processing the Stop
+	done chan struct{}
+}
+
+// WaitForLeadership is part of the topo.LeaderParticipation interface.
+func (mp *raftLeaderParticipation) WaitForLeadership() (context.Context, error) {
+	// If Stop was already called, mp.done is closed, so we are interrupted.
+	select {
+	case <-mp.done:
+		return nil, topo.NewError(topo.Interrupted, "Leadership")
+	default:
+	}
+
+	electionPath := path.Join(electionsPath, mp.name)
+	var ld topo.LockDescriptor
+
+	// We use a cancelable context here. If stop is closed,
+	// we just cancel that context.
+	lockCtx, lockCancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-mp.s.running:
+			return
+		case <-mp.stop:
+		}
+		if ld != nil {
+			if err := ld.Unlock(context.Background()); err != nil {
+				log.Errorf("failed to unlock electionPath %v: %v", electionPath, err)
+			}
+		}
+		lockCancel()
+		close(mp.done)
+	}()
+
+	// Try to get the primaryship, by getting a lock.
+	var err error
+	ld, err = mp.s.lock(lockCtx, electionPath, mp.id, leaseTTL)
+	if err != nil {
+		// It can be that we were interrupted.
+		return nil, err
+	}
+
+	// We got the lock. Return the lockContext. If Stop() is called,
+	// it will cancel the lockCtx, and cancel the returned context.
+	return lockCtx, nil
+}
+
+// Stop is part of the topo.LeaderParticipation interface
+func (mp *raftLeaderParticipation) Stop() {
+	close(mp.stop)
+	<-mp.done
+}
+
+// GetCurrentLeaderID is part of the topo.LeaderParticipation interface
+func (mp *raftLeaderParticipation) GetCurrentLeaderID(ctx context.Context) (string, error) {
+	electionPath := path.Join(mp.s.root, electionsPath, mp.name)
+	leader, _, err := mp.s.currentLeader(ctx, electionPath)
+	return leader, err
+}
+
+// WaitForNewLeader is part of the topo.LeaderParticipation interface
+func (mp *raftLeaderParticipation) WaitForNewLeader(ctx context.Context) (<-chan string, error) {
+	electionPath := path.Join(mp.s.root, electionsPath, mp.name)
+
+	notifications := make(chan string, 8)
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Watch the election directory. The watch starts with the current
+	// participants, so we can tell who the leader is from the start.
+	var stream rafttopopb.RaftTopo_WatchClient
+	var initial *rafttopopb.WatchResponse
+	err := mp.s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		if stream, err = client.Watch(ctx, &rafttopopb.WatchRequest{Key: electionPath + "/", Prefix: true}); err != nil {
+			return err
+		}
+		initial, err = stream.Recv()
+		return err
+	})
+	if err != nil {
+		cancel()
+		return nil, convertError(err, electionPath)
+	}
+	if leader := oldest(initial.Kvs); leader != nil {
+		notifications <- string(leader.Value)
+	}
+
+	go func() {
+		defer cancel()
+		defer close(notifications)
+		for {
+			select {
+			case <-mp.s.running:
+				return
+			case <-mp.done:
+				return
+			case <-ctx.Done():
+				return
+			default:
+			}
+
+			if _, err := stream.Recv(); err != nil {
+				return
+			}
+			leader, ok, err := mp.s.currentLeader(ctx, electionPath)
+			if err != nil || !ok {
+				continue
+			}
+			notifications <- leader
+		}
+	}()
+
+	return notifications, nil
+}
+
+// currentLeader returns the id of the oldest participant of the election,
+// if there is one.
+func (s *Server) currentLeader(ctx context.Context, electionPath string) (string, bool, error) {
+	response, err := s.rangeKeys(ctx, electionPath+"/", true)
+	if err != nil {
+		return "", false, convertError(err, electionPath)
+	}
+	leader := oldest(response.Kvs)
+	if leader == nil {
+		// No key starts with this prefix, means nobody is the primary.
+		return "", false, nil
+	}
+	return string(leader.Value), true, nil
+}
+
+// oldest returns the file created first, or nil.
+func oldest(kvs []*rafttopopb.KeyValue) *rafttopopb.KeyValue {
+	var result *rafttopopb.KeyValue
+	for _, kv := range kvs {
+		if result == nil || kv.CreateRevision < result.CreateRevision {
+			result = kv
+		}
+	}
+	return result
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// errNodeStopped is returned by the requests a member gets while it stops.
+var errNodeStopped = vterrors.New(vtrpcpb.Code_UNAVAILABLE, "raft member is stopped")
+
+// isUnavailable returns true if the error is from a member that can't
+// serve requests right now, either because it is down or because it can't
+// reach the rest of the cluster.
+func isUnavailable(err error) bool {
+	if s, ok := status.FromError(err); ok && err != nil {
+		return s.Code() == codes.Unavailable
+	}
+	return false
+}
+
+// convertError converts a gRPC error from a member into a topo error.
+func convertError(err error, nodePath string) error {
+	if err == nil {
+		return nil
+	}
+
+	if s, ok := status.FromError(err); ok {
+		switch s.Code() {
+		case codes.NotFound:
+			return topo.NewError(topo.NoNode, nodePath)
+		case codes.AlreadyExists:
+			return topo.NewError(topo.NodeExists, nodePath)
+		case codes.FailedPrecondition:
+			return topo.NewError(topo.BadVersion, nodePath)
+		case codes.Canceled:
+			return topo.NewError(topo.Interrupted, nodePath)
+		case codes.DeadlineExceeded, codes.Unavailable:
+			// Members are unavailable when they can't reach a quorum
+			// of the cluster in time, so this is a timeout too.
+			return topo.NewError(topo.Timeout, nodePath)
+		case codes.ResourceExhausted:
+			return topo.NewError(topo.ResourceExhausted, nodePath)
+		default:
+			return err
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return topo.NewError(topo.Interrupted, nodePath)
+	case errors.Is(err, context.DeadlineExceeded):
+		return topo.NewError(topo.Timeout, nodePath)
+	default:
+		return err
+	}
+}
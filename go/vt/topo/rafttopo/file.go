@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"path"
+
+	"vitess.io/vitess/go/vt/topo"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+// Create is part of the topo.Conn interface.
+func (s *Server) Create(ctx context.Context, filePath string, contents []byte) (topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+	response, err := s.put(ctx, &rafttopopb.PutRequest{Key: nodePath, Value: contents, Create: true})
+	if err != nil {
+		return nil, convertError(err, nodePath)
+	}
+	return RaftVersion(response.Revision), nil
+}
+
+// Update is part of the topo.Conn interface.
+func (s *Server) Update(ctx context.Context, filePath string, contents []byte, version topo.Version) (topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+	request := &rafttopopb.PutRequest{Key: nodePath, Value: contents}
+	if version != nil {
+		request.Version = int64(version.(RaftVersion))
+	}
+	response, err := s.put(ctx, request)
+	if err != nil {
+		return nil, convertError(err, nodePath)
+	}
+	return RaftVersion(response.Revision), nil
+}
+
+// Get is part of the topo.Conn interface.
+func (s *Server) Get(ctx context.Context, filePath string) ([]byte, topo.Version, error) {
+	nodePath := path.Join(s.root, filePath)
+	response, err := s.rangeKeys(ctx, nodePath, false)
+	if err != nil {
+		return nil, nil, convertError(err, nodePath)
+	}
+	if len(response.Kvs) != 1 {
+		return nil, nil, topo.NewError(topo.NoNode, nodePath)
+	}
+	return response.Kvs[0].Value, RaftVersion(response.Kvs[0].ModRevision), nil
+}
+
+// GetVersion is part of the topo.Conn interface.
+// The store only keeps the current version of the files.
+func (s *Server) GetVersion(ctx context.Context, filePath string, version int64) ([]byte, error) {
+	return nil, topo.NewError(topo.NoImplementation, "GetVersion not supported in raft topo")
+}
+
+// List is part of the topo.Conn interface.
+func (s *Server) List(ctx context.Context, filePathPrefix string) ([]topo.KVInfo, error) {
+	nodePathPrefix := path.Join(s.root, filePathPrefix)
+	response, err := s.rangeKeys(ctx, nodePathPrefix, true)
+	if err != nil {
+		return []topo.KVInfo{}, convertError(err, nodePathPrefix)
+	}
+	if len(response.Kvs) == 0 {
+		return []topo.KVInfo{}, topo.NewError(topo.NoNode, nodePathPrefix)
+	}
+	results := make([]topo.KVInfo, len(response.Kvs))
+	for n, kv := range response.Kvs {
+		results[n].Key = []byte(kv.Key)
+		results[n].Value = kv.Value
+		results[n].Version = RaftVersion(kv.ModRevision)
+	}
+	return results, nil
+}
+
+// Delete is part of the topo.Conn interface.
+func (s *Server) Delete(ctx context.Context, filePath string, version topo.Version) error {
+	nodePath := path.Join(s.root, filePath)
+	request := &rafttopopb.DeleteRequest{Key: nodePath}
+	if version != nil {
+		request.Version = int64(version.(RaftVersion))
+	}
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		_, err := client.Delete(ctx, request)
+		return err
+	})
+	return convertError(err, nodePath)
+}
+
+func (s *Server) put(ctx context.Context, request *rafttopopb.PutRequest) (*rafttopopb.PutResponse, error) {
+	var response *rafttopopb.PutResponse
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		response, err = client.Put(ctx, request)
+		return err
+	})
+	return response, err
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+var (
+	leaseTTL = 30 // This is the default used for all non-named locks
+)
+
+func init() {
+	for _, cmd := range topo.FlagBinaries {
+		servenv.OnParseFor(cmd, registerRaftTopoLockFlags)
+	}
+}
+
+func registerRaftTopoLockFlags(fs *pflag.FlagSet) {
+	fs.IntVar(&leaseTTL, "topo_raft_lease_ttl", leaseTTL, "Lease TTL in seconds for locks and leader election. The client keeps the lease alive until it releases it.")
+}
+
+// raftLockDescriptor implements topo.LockDescriptor.
+type raftLockDescriptor struct {
+	s       *Server
+	leaseID int64
+	// stop stops keeping the lease alive.
+	stop context.CancelFunc
+}
+
+// TryLock is part of the topo.Conn interface.
+func (s *Server) TryLock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	// We list all the entries under dirPath
+	entries, err := s.ListDir(ctx, dirPath, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// If there is a folder '/locks' with some entries in it then we can assume that someone else already has a lock.
+	// Throw error in this case
+	for _, e := range entries {
+		if e.Name == locksPath && e.Type == topo.TypeDirectory && e.Ephemeral {
+			return nil, topo.NewError(topo.NodeExists, fmt.Sprintf("lock already exists at path %s", dirPath))
+		}
+	}
+
+	// everything is good let's acquire the lock.
+	return s.lock(ctx, dirPath, contents, leaseTTL)
+}
+
+// Lock is part of the topo.Conn interface.
+func (s *Server) Lock(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	// We list the directory first to make sure it exists.
+	if _, err := s.ListDir(ctx, dirPath, false /*full*/); err != nil {
+		return nil, err
+	}
+
+	return s.lock(ctx, dirPath, contents, leaseTTL)
+}
+
+// LockWithTTL is part of the topo.Conn interface.
+func (s *Server) LockWithTTL(ctx context.Context, dirPath, contents string, ttl time.Duration) (topo.LockDescriptor, error) {
+	// We list the directory first to make sure it exists.
+	if _, err := s.ListDir(ctx, dirPath, false /*full*/); err != nil {
+		return nil, err
+	}
+
+	return s.lock(ctx, dirPath, contents, int(ttl.Seconds()))
+}
+
+// LockName is part of the topo.Conn interface.
+func (s *Server) LockName(ctx context.Context, dirPath, contents string) (topo.LockDescriptor, error) {
+	return s.lock(ctx, dirPath, contents, int(topo.NamedLockTTL.Seconds()))
+}
+
+// lock is used by both Lock() and primary election.
+// It creates a file linked to a new lease in the locks directory, and
+// waits until all the older files in there are gone.
+func (s *Server) lock(ctx context.Context, nodePath, contents string, ttl int) (topo.LockDescriptor, error) {
+	nodePath = path.Join(s.root, nodePath, locksPath)
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	// Get a lease, keep it alive until we unlock.
+	var grant *rafttopopb.LeaseGrantResponse
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		grant, err = client.LeaseGrant(ctx, &rafttopopb.LeaseGrantRequest{Ttl: int64(ttl)})
+		return err
+	})
+	if err != nil {
+		return nil, convertError(err, nodePath)
+	}
+	keepAliveCtx, stop := context.WithCancel(context.Background())
+	ld := &raftLockDescriptor{
+		s:       s,
+		leaseID: grant.Id,
+		stop:    stop,
+	}
+	go s.keepAlive(keepAliveCtx, grant.Id, time.Duration(ttl)*time.Second)
+
+	// Use the lease ID as the file name, so it's guaranteed unique.
+	key := fmt.Sprintf("%v/%v", nodePath, grant.Id)
+	put, err := s.put(ctx, &rafttopopb.PutRequest{Key: key, Value: []byte(contents), Create: true, Lease: grant.Id})
+	if err == nil {
+		err = s.waitForOlderLocks(ctx, nodePath, put.Revision)
+	}
+	if err != nil {
+		// Revoking the lease deletes the file, if we created it.
+		if uerr := ld.Unlock(context.Background()); uerr != nil {
+			log.Warningf("failed to revoke lease %v, may have left %v behind: %v", grant.Id, key, uerr)
+		}
+		return nil, convertError(err, nodePath)
+	}
+	return ld, nil
+}
+
+// waitForOlderLocks waits until the files in the locks directory that were
+// created before the given revision are gone.
+func (s *Server) waitForOlderLocks(ctx context.Context, nodePath string, revision int64) error {
+	for {
+		response, err := s.rangeKeys(ctx, nodePath+"/", true)
+		if err != nil {
+			return err
+		}
+		var blocking *rafttopopb.KeyValue
+		for _, kv := range response.Kvs {
+			if kv.CreateRevision < revision && (blocking == nil || kv.CreateRevision > blocking.CreateRevision) {
+				blocking = kv
+			}
+		}
+		if blocking == nil {
+			// No older file, we're it!
+			return nil
+		}
+
+		// Wait for the release of the youngest older file. There might
+		// still be older files once it is gone.
+		if err := s.waitForDeletion(ctx, blocking.Key); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForDeletion waits until the file is deleted.
+func (s *Server) waitForDeletion(ctx context.Context, key string) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var stream rafttopopb.RaftTopo_WatchClient
+	var initial *rafttopopb.WatchResponse
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		if stream, err = client.Watch(watchCtx, &rafttopopb.WatchRequest{Key: key}); err != nil {
+			return err
+		}
+		initial, err = stream.Recv()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if len(initial.Kvs) == 0 {
+		return nil
+	}
+	for {
+		response, err := stream.Recv()
+		if err != nil {
+			if isUnavailable(err) {
+				// The member went away, the caller looks again.
+				return nil
+			}
+			return err
+		}
+		for _, event := range response.Events {
+			if event.Type == rafttopopb.Event_DELETE {
+				return nil
+			}
+		}
+	}
+}
+
+// keepAlive keeps the lease alive until ctx is canceled, or the lease is
+// gone.
+func (s *Server) keepAlive(ctx context.Context, leaseID int64, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.running:
+			return
+		case <-ticker.C:
+		}
+		err := s.call(func(client rafttopopb.RaftTopoClient) error {
+			_, err := client.LeaseKeepAlive(ctx, &rafttopopb.LeaseKeepAliveRequest{Id: leaseID})
+			return err
+		})
+		if topo.IsErrType(convertError(err, "lease"), topo.NoNode) {
+			log.Warningf("lease %v expired", leaseID)
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Warningf("failed to keep lease %v alive: %v", leaseID, err)
+		}
+	}
+}
+
+// Check is part of the topo.LockDescriptor interface.
+// We keep the lease alive once to make sure it is still active and well.
+func (ld *raftLockDescriptor) Check(ctx context.Context) error {
+	err := ld.s.call(func(client rafttopopb.RaftTopoClient) error {
+		_, err := client.LeaseKeepAlive(ctx, &rafttopopb.LeaseKeepAliveRequest{Id: ld.leaseID})
+		return err
+	})
+	return convertError(err, "lease")
+}
+
+// Unlock is part of the topo.LockDescriptor interface.
+func (ld *raftLockDescriptor) Unlock(ctx context.Context) error {
+	ld.stop()
+	err := ld.s.call(func(client rafttopopb.RaftTopoClient) error {
+		_, err := client.LeaseRevoke(ctx, &rafttopopb.LeaseRevokeRequest{Id: ld.leaseID})
+		return err
+	})
+	return convertError(err, "lease")
+}
@@ -0,0 +1,566 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// readIndexRetryInterval is how often a read that hasn't been confirmed
+	// by the leader asks again, as raft can drop the request.
+	readIndexRetryInterval = 500 * time.Millisecond
+
+	// leaseCheckInterval is how often the leader looks for expired leases.
+	leaseCheckInterval = 500 * time.Millisecond
+
+	// snapshotCatchUpEntries is the number of entries kept in the log after
+	// a snapshot, so that slightly lagging members can catch up without one.
+	snapshotCatchUpEntries = 1000
+)
+
+func init() {
+	raft.SetLogger(raftLogger{})
+}
+
+// NodeConfig is the configuration of a vttopo member.
+type NodeConfig struct {
+	// ID is the raft ID of the member. It can't be 0.
+	ID uint64
+
+	// Peers maps the raft IDs of all the members of the cluster, this one
+	// included, to the address of their gRPC server. The members of a
+	// cluster must all be started with the same peers.
+	Peers map[uint64]string
+
+	// DataDir is where the member persists its raft log and snapshots.
+	DataDir string
+
+	// TickInterval is the duration of a raft tick.
+	TickInterval time.Duration
+
+	// ElectionTicks is the number of ticks without hearing from the leader
+	// after which a member starts an election.
+	ElectionTicks int
+
+	// HeartbeatTicks is the number of ticks between leader heartbeats.
+	HeartbeatTicks int
+
+	// SnapshotCount is the number of applied entries after which the store
+	// is snapshotted and the log compacted.
+	SnapshotCount uint64
+
+	// RequestTimeout is how long a request waits for the cluster to commit
+	// or confirm it before failing as unavailable.
+	RequestTimeout time.Duration
+}
+
+// DefaultNodeConfig returns the configuration of a member with the
+// default timings.
+func DefaultNodeConfig() NodeConfig {
+	return NodeConfig{
+		TickInterval:   100 * time.Millisecond,
+		ElectionTicks:  10,
+		HeartbeatTicks: 1,
+		SnapshotCount:  10000,
+		RequestTimeout: 5 * time.Second,
+	}
+}
+
+// Node is a member of a vttopo cluster. It replicates its store to the
+// other members with raft, and serves the RaftTopo service to the topo
+// clients and to the other members.
+type Node struct {
+	rafttopopb.UnimplementedRaftTopoServer
+
+	config    NodeConfig
+	raft      raft.Node
+	storage   *raft.MemoryStorage
+	disk      *diskStorage
+	store     *store
+	transport *transport
+	leader    atomic.Bool
+
+	// The following fields are only used by the raft loop.
+	confState     raftpb.ConfState
+	snapshotIndex uint64
+	appliedIndex  uint64
+
+	mu sync.Mutex
+	// applied is the index of the last entry applied to the store, and
+	// appliedChanged is closed when it moves.
+	applied        uint64
+	appliedChanged chan struct{}
+	// proposals are the requests waiting for their command to be applied,
+	// by command ID.
+	proposals map[uint64]chan proposalResult
+	// reads are the reads waiting for their read index, by request context.
+	reads map[string]chan uint64
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+type proposalResult struct {
+	response any
+	err      error
+}
+
+// NewNode starts a member. A member with persisted raft state in its data
+// directory restarts from it, and one without joins the cluster of its
+// peers for the first time.
+func NewNode(config NodeConfig) (*Node, error) {
+	if config.ID == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the raft ID of a member can't be 0")
+	}
+	if _, ok := config.Peers[config.ID]; !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "member %v is not one of its peers", config.ID)
+	}
+	if config.DataDir == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "a member needs a data directory")
+	}
+
+	disk, err := newDiskStorage(config.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		config:         config,
+		storage:        raft.NewMemoryStorage(),
+		disk:           disk,
+		store:          newStore(),
+		appliedChanged: make(chan struct{}),
+		proposals:      make(map[uint64]chan proposalResult),
+		reads:          make(map[string]chan uint64),
+		stop:           make(chan struct{}),
+	}
+	raftConfig := &raft.Config{
+		ID:              config.ID,
+		ElectionTick:    config.ElectionTicks,
+		HeartbeatTick:   config.HeartbeatTicks,
+		Storage:         n.storage,
+		MaxSizePerMsg:   1024 * 1024,
+		MaxInflightMsgs: 256,
+		CheckQuorum:     true,
+		PreVote:         true,
+	}
+
+	if disk.exists() {
+		snapshot, err := disk.load(n.storage)
+		if err != nil {
+			disk.close()
+			return nil, fmt.Errorf("cannot load the raft state from %v: %w", config.DataDir, err)
+		}
+		if !raft.IsEmptySnap(snapshot) {
+			if err := n.store.restore(snapshot.Data); err != nil {
+				disk.close()
+				return nil, err
+			}
+			n.confState = snapshot.Metadata.ConfState
+			n.snapshotIndex = snapshot.Metadata.Index
+			n.appliedIndex = snapshot.Metadata.Index
+			n.applied = snapshot.Metadata.Index
+		}
+		// The entries committed after the snapshot get applied again.
+		raftConfig.Applied = n.appliedIndex
+		n.raft = raft.RestartNode(raftConfig)
+		log.Infof("restarted raft member %v from %v", config.ID, config.DataDir)
+	} else {
+		if err := disk.create(); err != nil {
+			return nil, err
+		}
+		peers := make([]raft.Peer, 0, len(config.Peers))
+		for id := range config.Peers {
+			peers = append(peers, raft.Peer{ID: id})
+		}
+		n.raft = raft.StartNode(raftConfig, peers)
+		log.Infof("started raft member %v in %v", config.ID, config.DataDir)
+	}
+
+	n.transport = newTransport(n)
+	n.done.Add(2)
+	go n.run()
+	go n.expireLeases()
+	return n, nil
+}
+
+// Close stops the member.
+func (n *Node) Close() {
+	close(n.stop)
+	n.done.Wait()
+	n.raft.Stop()
+	n.transport.close()
+	n.disk.close()
+}
+
+// IsLeader returns true if the member is the leader of the cluster.
+func (n *Node) IsLeader() bool {
+	return n.leader.Load()
+}
+
+// run is the raft loop. It ticks the raft clock, and persists, sends and
+// applies what raft is ready with.
+func (n *Node) run() {
+	defer n.done.Done()
+	ticker := time.NewTicker(n.config.TickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+			n.raft.Tick()
+		case rd := <-n.raft.Ready():
+			if err := n.handleReady(rd); err != nil {
+				// The raft state can't be trusted if it wasn't persisted.
+				log.Fatalf("raft member %v failed to persist its state: %v", n.config.ID, err)
+			}
+			n.raft.Advance()
+		}
+	}
+}
+
+func (n *Node) handleReady(rd raft.Ready) error {
+	if rd.SoftState != nil {
+		leader := rd.SoftState.RaftState == raft.StateLeader
+		if leader && !n.leader.Load() {
+			log.Infof("raft member %v is the leader", n.config.ID)
+			n.store.resetLeaseDeadlines()
+		}
+		n.leader.Store(leader)
+	}
+
+	if !raft.IsEmptySnap(rd.Snapshot) {
+		// A snapshot from the leader replaces everything we had.
+		hs := rd.HardState
+		if raft.IsEmptyHardState(hs) {
+			var err error
+			if hs, _, err = n.storage.InitialState(); err != nil {
+				return err
+			}
+		}
+		if err := n.disk.saveSnapshot(rd.Snapshot); err != nil {
+			return err
+		}
+		if err := n.disk.rewrite(hs, rd.Entries); err != nil {
+			return err
+		}
+		if err := n.storage.ApplySnapshot(rd.Snapshot); err != nil {
+			return err
+		}
+		if err := n.store.restore(rd.Snapshot.Data); err != nil {
+			return err
+		}
+		n.confState = rd.Snapshot.Metadata.ConfState
+		n.snapshotIndex = rd.Snapshot.Metadata.Index
+		n.appliedIndex = rd.Snapshot.Metadata.Index
+	} else if err := n.disk.save(rd.HardState, rd.Entries); err != nil {
+		return err
+	}
+	if !raft.IsEmptyHardState(rd.HardState) {
+		if err := n.storage.SetHardState(rd.HardState); err != nil {
+			return err
+		}
+	}
+	if err := n.storage.Append(rd.Entries); err != nil {
+		return err
+	}
+
+	n.transport.send(rd.Messages)
+	for _, rs := range rd.ReadStates {
+		n.mu.Lock()
+		if ch, ok := n.reads[string(rs.RequestCtx)]; ok {
+			select {
+			case ch <- rs.Index:
+			default:
+			}
+		}
+		n.mu.Unlock()
+	}
+	n.applyEntries(rd.CommittedEntries)
+	return n.maybeSnapshot()
+}
+
+func (n *Node) applyEntries(entries []raftpb.Entry) {
+	for _, entry := range entries {
+		if entry.Index <= n.appliedIndex {
+			continue
+		}
+		switch entry.Type {
+		case raftpb.EntryNormal:
+			// Empty entries are appended by new leaders.
+			if len(entry.Data) > 0 {
+				n.applyCommand(entry)
+			}
+		case raftpb.EntryConfChange:
+			var cc raftpb.ConfChange
+			if err := cc.Unmarshal(entry.Data); err != nil {
+				log.Errorf("raft member %v skipped conf change %v: %v", n.config.ID, entry.Index, err)
+				break
+			}
+			n.confState = *n.raft.ApplyConfChange(cc)
+		case raftpb.EntryConfChangeV2:
+			var cc raftpb.ConfChangeV2
+			if err := cc.Unmarshal(entry.Data); err != nil {
+				log.Errorf("raft member %v skipped conf change %v: %v", n.config.ID, entry.Index, err)
+				break
+			}
+			n.confState = *n.raft.ApplyConfChange(cc)
+		}
+		n.appliedIndex = entry.Index
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.applied != n.appliedIndex {
+		n.applied = n.appliedIndex
+		close(n.appliedChanged)
+		n.appliedChanged = make(chan struct{})
+	}
+}
+
+func (n *Node) applyCommand(entry raftpb.Entry) {
+	cmd := &rafttopopb.Command{}
+	if err := cmd.UnmarshalVT(entry.Data); err != nil {
+		// Every member skips it the same way.
+		log.Errorf("raft member %v skipped entry %v: %v", n.config.ID, entry.Index, err)
+		return
+	}
+	response, err := n.store.apply(entry.Index, cmd)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if ch, ok := n.proposals[cmd.Id]; ok {
+		ch <- proposalResult{response: response, err: err}
+		delete(n.proposals, cmd.Id)
+	}
+}
+
+// maybeSnapshot snapshots the store and compacts the log once enough
+// entries were applied since the last snapshot.
+func (n *Node) maybeSnapshot() error {
+	if n.appliedIndex-n.snapshotIndex < n.config.SnapshotCount {
+		return nil
+	}
+	data, err := n.store.snapshot()
+	if err != nil {
+		return err
+	}
+	snapshot, err := n.storage.CreateSnapshot(n.appliedIndex, &n.confState, data)
+	if err != nil {
+		return err
+	}
+	if err := n.disk.saveSnapshot(snapshot); err != nil {
+		return err
+	}
+	if n.appliedIndex > snapshotCatchUpEntries {
+		if err := n.storage.Compact(n.appliedIndex - snapshotCatchUpEntries); err != nil {
+			return err
+		}
+	}
+	hs, _, err := n.storage.InitialState()
+	if err != nil {
+		return err
+	}
+	first, err := n.storage.FirstIndex()
+	if err != nil {
+		return err
+	}
+	last, err := n.storage.LastIndex()
+	if err != nil {
+		return err
+	}
+	entries, err := n.storage.Entries(first, last+1, math.MaxUint64)
+	if err != nil {
+		return err
+	}
+	if err := n.disk.rewrite(hs, entries); err != nil {
+		return err
+	}
+	log.Infof("raft member %v snapshotted its store at index %v", n.config.ID, n.appliedIndex)
+	n.snapshotIndex = n.appliedIndex
+	return nil
+}
+
+// expireLeases revokes the leases that weren't kept alive, while the member
+// is the leader.
+func (n *Node) expireLeases() {
+	defer n.done.Done()
+	ticker := time.NewTicker(leaseCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-n.stop:
+			return
+		case <-ticker.C:
+		}
+		if !n.leader.Load() {
+			continue
+		}
+		for _, id := range n.store.expiredLeases() {
+			_, err := n.propose(context.Background(), &rafttopopb.Command{
+				Request: &rafttopopb.Command_LeaseRevoke{LeaseRevoke: &rafttopopb.LeaseRevokeRequest{Id: id}},
+			})
+			if err != nil && vterrors.Code(err) != vtrpcpb.Code_NOT_FOUND {
+				log.Warningf("raft member %v failed to revoke expired lease %v: %v", n.config.ID, id, err)
+			}
+		}
+	}
+}
+
+// propose replicates the command, and returns the response of the store
+// once it is applied.
+func (n *Node) propose(ctx context.Context, cmd *rafttopopb.Command) (any, error) {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, n.config.RequestTimeout)
+	defer cancel()
+
+	cmd.Id = rand.Uint64()
+	data, err := cmd.MarshalVT()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan proposalResult, 1)
+	n.mu.Lock()
+	n.proposals[cmd.Id] = ch
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.proposals, cmd.Id)
+		n.mu.Unlock()
+	}()
+
+	if err := n.raft.Propose(ctx, data); err != nil {
+		return nil, n.requestError(parent, err)
+	}
+	select {
+	case result := <-ch:
+		return result.response, result.err
+	case <-ctx.Done():
+		return nil, n.requestError(parent, ctx.Err())
+	case <-n.stop:
+		return nil, errNodeStopped
+	}
+}
+
+// linearizableRead waits until the store has applied everything the
+// cluster committed when it was called.
+func (n *Node) linearizableRead(ctx context.Context) error {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, n.config.RequestTimeout)
+	defer cancel()
+
+	requestCtx := make([]byte, 8)
+	binary.BigEndian.PutUint64(requestCtx, rand.Uint64())
+	ch := make(chan uint64, 1)
+	n.mu.Lock()
+	n.reads[string(requestCtx)] = ch
+	n.mu.Unlock()
+	defer func() {
+		n.mu.Lock()
+		delete(n.reads, string(requestCtx))
+		n.mu.Unlock()
+	}()
+
+	retry := time.NewTicker(readIndexRetryInterval)
+	defer retry.Stop()
+	if err := n.raft.ReadIndex(ctx, requestCtx); err != nil {
+		return n.requestError(parent, err)
+	}
+	var index uint64
+	for index == 0 {
+		select {
+		case index = <-ch:
+		case <-retry.C:
+			if err := n.raft.ReadIndex(ctx, requestCtx); err != nil {
+				return n.requestError(parent, err)
+			}
+		case <-ctx.Done():
+			return n.requestError(parent, ctx.Err())
+		case <-n.stop:
+			return errNodeStopped
+		}
+	}
+
+	for {
+		n.mu.Lock()
+		applied, changed := n.applied, n.appliedChanged
+		n.mu.Unlock()
+		if applied >= index {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return n.requestError(parent, ctx.Err())
+		case <-n.stop:
+			return errNodeStopped
+		}
+	}
+}
+
+// requestError returns the error of a request that raft didn't get to
+// the end of. It is the error of the caller's context if that is what
+// ended it, and an unavailable error otherwise.
+func (n *Node) requestError(parent context.Context, err error) error {
+	if parent.Err() != nil {
+		return parent.Err()
+	}
+	return vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "raft member %v could not reach the cluster: %v", n.config.ID, err)
+}
+
+// raftLogger sends the logs of the raft library to the Vitess log.
+type raftLogger struct{}
+
+func (raftLogger) Debug(v ...any) {
+	if log.V(2) {
+		log.Info(v...)
+	}
+}
+
+func (raftLogger) Debugf(format string, v ...any) {
+	if log.V(2) {
+		log.Infof(format, v...)
+	}
+}
+
+func (raftLogger) Info(v ...any)                    { log.Info(v...) }
+func (raftLogger) Infof(format string, v ...any)    { log.Infof(format, v...) }
+func (raftLogger) Warning(v ...any)                 { log.Warning(v...) }
+func (raftLogger) Warningf(format string, v ...any) { log.Warningf(format, v...) }
+func (raftLogger) Error(v ...any)                   { log.Error(v...) }
+func (raftLogger) Errorf(format string, v ...any)   { log.Errorf(format, v...) }
+func (raftLogger) Fatal(v ...any)                   { log.Fatal(v...) }
+func (raftLogger) Fatalf(format string, v ...any)   { log.Fatalf(format, v...) }
+func (raftLogger) Panic(v ...any)                   { panic(fmt.Sprint(v...)) }
+func (raftLogger) Panicf(format string, v ...any)   { panic(fmt.Sprintf(format, v...)) }
@@ -0,0 +1,198 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package rafttopo implements topo.Server on top of vttopo, a key-value store
+replicated with raft that Vitess runs itself, so that small deployments
+don't need to run etcd, ZooKeeper or Consul.
+
+The package has both sides:
+
+  - Node is a member of a vttopo cluster. It applies the raft log to an
+    in-memory store that it persists in its data directory, and serves the
+    RaftTopo gRPC service to the topo clients and to the other members.
+  - Server is the topo.Conn the clients use. It talks to any member of the
+    cluster, and moves to the next one when that member is unavailable.
+
+The store works like a tiny etcd: every change bumps a revision that is the
+version of the files it changes, files can be attached to leases that the
+leader revokes when they are not kept alive, and watches start from the
+current content of the files they watch. Locks and elections are built with
+leases the same way etcd2topo builds them.
+
+We follow these conventions within this package:
+
+  - Call convertError(err) on any errors returned from the gRPC client.
+    Functions defined in this package can be assumed to have already
+    converted errors as necessary.
+*/
+package rafttopo
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc"
+
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var (
+	clientCertPath string
+	clientKeyPath  string
+	serverCaPath   string
+	serverName     string
+)
+
+// Factory is the raft topo.Factory implementation.
+type Factory struct{}
+
+// HasGlobalReadOnlyCell is part of the topo.Factory interface.
+func (f Factory) HasGlobalReadOnlyCell(serverAddr, root string) bool {
+	return false
+}
+
+// Create is part of the topo.Factory interface.
+func (f Factory) Create(cell, serverAddr, root string) (topo.Conn, error) {
+	return NewServer(serverAddr, root)
+}
+
+// Server is the implementation of topo.Server for vttopo.
+type Server struct {
+	// clients are the clients of all the members of the cluster.
+	clients []rafttopopb.RaftTopoClient
+	conns   []*grpc.ClientConn
+
+	// root is the root path for this client.
+	root string
+
+	mu sync.Mutex
+	// current is the member requests go to.
+	current int
+
+	running chan struct{}
+}
+
+func init() {
+	for _, cmd := range append(topo.FlagBinaries, "vttopo") {
+		servenv.OnParseFor(cmd, registerRaftTopoFlags)
+	}
+	topo.RegisterFactory("raft", Factory{})
+}
+
+func registerRaftTopoFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&clientCertPath, "topo_raft_tls_cert", clientCertPath, "path to the client cert to use to connect to the vttopo members, requires topo_raft_tls_key, enables TLS")
+	fs.StringVar(&clientKeyPath, "topo_raft_tls_key", clientKeyPath, "path to the client key to use to connect to the vttopo members, enables TLS")
+	fs.StringVar(&serverCaPath, "topo_raft_tls_ca", serverCaPath, "path to the ca to use to validate the server cert when connecting to the vttopo members")
+	fs.StringVar(&serverName, "topo_raft_tls_server_name", serverName, "the server name to use to validate the server cert when connecting to the vttopo members")
+}
+
+// dialOption returns the dial option to connect to the vttopo members with.
+func dialOption() (grpc.DialOption, error) {
+	return grpcclient.SecureDialOption(clientCertPath, clientKeyPath, serverCaPath, "", serverName)
+}
+
+// NewServer returns a new rafttopo.Server. serverAddr is the comma-separated
+// list of the gRPC addresses of the vttopo members.
+func NewServer(serverAddr, root string) (*Server, error) {
+	opt, err := dialOption()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		root:    root,
+		running: make(chan struct{}),
+	}
+	for _, addr := range strings.Split(serverAddr, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		cc, err := grpcclient.DialContext(context.Background(), addr, grpcclient.FailFast(true), opt)
+		if err != nil {
+			s.closeConns()
+			return nil, err
+		}
+		s.conns = append(s.conns, cc)
+		s.clients = append(s.clients, rafttopopb.NewRaftTopoClient(cc))
+	}
+	if len(s.clients) == 0 {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "no vttopo member address in %q", serverAddr)
+	}
+	return s, nil
+}
+
+// Close implements topo.Server.Close.
+// It will nil out the clients, so any attempt to
+// re-use this server will panic.
+func (s *Server) Close() {
+	close(s.running)
+	s.closeConns()
+	s.clients = nil
+}
+
+func (s *Server) closeConns() {
+	for _, cc := range s.conns {
+		cc.Close()
+	}
+	s.conns = nil
+}
+
+// call runs the request against the current member. When that member is
+// unavailable, it moves on to the next one and tries again, until every
+// member was tried once.
+func (s *Server) call(f func(client rafttopopb.RaftTopoClient) error) error {
+	var err error
+	for range s.clients {
+		s.mu.Lock()
+		current := s.current
+		s.mu.Unlock()
+
+		err = f(s.clients[current])
+		if !isUnavailable(err) {
+			return err
+		}
+		s.failover(current)
+	}
+	return err
+}
+
+// failover moves the requests to the member after the given one, unless
+// another request already moved them.
+func (s *Server) failover(from int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == from {
+		s.current = (from + 1) % len(s.clients)
+	}
+}
+
+func (s *Server) rangeKeys(ctx context.Context, key string, prefix bool) (*rafttopopb.RangeResponse, error) {
+	var response *rafttopopb.RangeResponse
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		response, err = client.Range(ctx, &rafttopopb.RangeRequest{Key: key, Prefix: prefix})
+		return err
+	})
+	return response, err
+}
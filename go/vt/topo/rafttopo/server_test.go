@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/test"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// testMember is a member of an in-process test cluster.
+type testMember struct {
+	config   NodeConfig
+	listener net.Listener
+	server   *grpc.Server
+	node     *Node
+}
+
+// startCluster starts a cluster of the given size, with fast timings, and
+// returns its members with the comma-separated list of their addresses.
+func startCluster(t *testing.T, size int) ([]*testMember, string) {
+	peers := make(map[uint64]string)
+	listeners := make([]net.Listener, size)
+	var addrs []string
+	for i := range listeners {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listeners[i] = listener
+		peers[uint64(i+1)] = listener.Addr().String()
+		addrs = append(addrs, listener.Addr().String())
+	}
+
+	members := make([]*testMember, size)
+	for i, listener := range listeners {
+		config := DefaultNodeConfig()
+		config.ID = uint64(i + 1)
+		config.Peers = peers
+		config.DataDir = t.TempDir()
+		config.TickInterval = 10 * time.Millisecond
+		config.SnapshotCount = 100
+		members[i] = &testMember{config: config, listener: listener}
+		members[i].start(t)
+	}
+	t.Cleanup(func() {
+		for _, member := range members {
+			member.stop()
+		}
+	})
+	return members, strings.Join(addrs, ",")
+}
+
+// start starts the member on its listener.
+func (m *testMember) start(t *testing.T) {
+	if m.listener == nil {
+		listener, err := net.Listen("tcp", m.config.Peers[m.config.ID])
+		require.NoError(t, err)
+		m.listener = listener
+	}
+	node, err := NewNode(m.config)
+	require.NoError(t, err)
+	m.node = node
+	m.server = grpc.NewServer()
+	rafttopopb.RegisterRaftTopoServer(m.server, node)
+	go m.server.Serve(m.listener)
+}
+
+// stop stops the member, if it is running.
+func (m *testMember) stop() {
+	if m.node == nil {
+		return
+	}
+	m.server.Stop()
+	m.node.Close()
+	m.node = nil
+	m.listener = nil
+}
+
+func TestRaftTopo(t *testing.T) {
+	_, addrs := startCluster(t, 3)
+
+	testIndex := 0
+	newServer := func() *topo.Server {
+		// Each test will use its own sub-directories.
+		testRoot := fmt.Sprintf("/test-%v", testIndex)
+		testIndex++
+
+		// Create the server on the new root.
+		ts, err := topo.OpenServer("raft", addrs, path.Join(testRoot, topo.GlobalCell))
+		if err != nil {
+			t.Fatalf("OpenServer() failed: %v", err)
+		}
+
+		// Create the CellInfo.
+		if err := ts.CreateCellInfo(context.Background(), test.LocalCellName, &topodatapb.CellInfo{
+			ServerAddress: addrs,
+			Root:          path.Join(testRoot, test.LocalCellName),
+		}); err != nil {
+			t.Fatalf("CreateCellInfo() failed: %v", err)
+		}
+
+		return ts
+	}
+
+	// Run the TopoServerTestSuite tests.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	test.TopoServerTestSuite(t, ctx, func() *topo.Server {
+		return newServer()
+	}, []string{})
+}
+
+// TestRaftTopoFailover checks the cluster keeps serving when a member goes
+// away, and that a restarted member catches up from its data directory.
+func TestRaftTopoFailover(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	members, addrs := startCluster(t, 3)
+
+	conn, err := NewServer(addrs, "/failover")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Write enough to get the members to snapshot and compact their logs.
+	for i := 0; i < 250; i++ {
+		_, err := conn.Create(ctx, fmt.Sprintf("file-%v", i), []byte("contents"))
+		require.NoError(t, err)
+	}
+	_, wch, err := conn.Watch(ctx, "file-0")
+	require.NoError(t, err)
+
+	// Stop the member the client talks to, the client and its watch
+	// move to another one.
+	members[0].stop()
+	version, err := conn.Update(ctx, "file-0", []byte("updated"), nil)
+	require.NoError(t, err)
+	for wd := range wch {
+		require.NoError(t, wd.Err)
+		if wd.Version.String() == version.String() {
+			assert.Equal(t, "updated", string(wd.Contents))
+			break
+		}
+	}
+
+	// Restart the member, and check it serves what was written while it
+	// was away.
+	members[0].start(t)
+	restarted, err := NewServer(members[0].config.Peers[1], "/failover")
+	require.NoError(t, err)
+	defer restarted.Close()
+	contents, _, err := restarted.Get(ctx, "file-0")
+	require.NoError(t, err)
+	assert.Equal(t, "updated", string(contents))
+	entries, err := restarted.ListDir(ctx, "/", false)
+	require.NoError(t, err)
+	assert.Len(t, entries, 250)
+}
+
+// TestRaftTopoLeaseExpiry checks the files of a lease that is not kept
+// alive are deleted.
+func TestRaftTopoLeaseExpiry(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	members, addrs := startCluster(t, 3)
+
+	conn, err := NewServer(addrs, "/lease")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var grant *rafttopopb.LeaseGrantResponse
+	err = conn.call(func(client rafttopopb.RaftTopoClient) error {
+		grant, err = client.LeaseGrant(ctx, &rafttopopb.LeaseGrantRequest{Ttl: 1})
+		return err
+	})
+	require.NoError(t, err)
+	_, err = conn.put(ctx, &rafttopopb.PutRequest{Key: "/lease/ephemeral", Value: []byte("contents"), Create: true, Lease: grant.Id})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		_, _, err := conn.Get(ctx, "ephemeral")
+		return topo.IsErrType(err, topo.NoNode)
+	}, 30*time.Second, 100*time.Millisecond)
+
+	// Every member applies the deletion.
+	for _, member := range members {
+		assert.Eventually(t, func() bool {
+			kvs, _ := member.node.store.rangeKeys("/lease/ephemeral", false)
+			return len(kvs) == 0
+		}, 10*time.Second, 100*time.Millisecond)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+
+	"go.etcd.io/raft/v3/raftpb"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var _ rafttopopb.RaftTopoServer = (*Node)(nil)
+
+// Range is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) Range(ctx context.Context, request *rafttopopb.RangeRequest) (*rafttopopb.RangeResponse, error) {
+	if err := n.linearizableRead(ctx); err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	kvs, revision := n.store.rangeKeys(request.Key, request.Prefix)
+	return &rafttopopb.RangeResponse{Kvs: kvs, Revision: revision}, nil
+}
+
+// Put is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) Put(ctx context.Context, request *rafttopopb.PutRequest) (*rafttopopb.PutResponse, error) {
+	response, err := n.propose(ctx, &rafttopopb.Command{Request: &rafttopopb.Command_Put{Put: request}})
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return response.(*rafttopopb.PutResponse), nil
+}
+
+// Delete is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) Delete(ctx context.Context, request *rafttopopb.DeleteRequest) (*rafttopopb.DeleteResponse, error) {
+	response, err := n.propose(ctx, &rafttopopb.Command{Request: &rafttopopb.Command_Delete{Delete: request}})
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return response.(*rafttopopb.DeleteResponse), nil
+}
+
+// LeaseGrant is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) LeaseGrant(ctx context.Context, request *rafttopopb.LeaseGrantRequest) (*rafttopopb.LeaseGrantResponse, error) {
+	if request.Ttl <= 0 {
+		return nil, vterrors.ToGRPC(vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid lease TTL %v", request.Ttl))
+	}
+	response, err := n.propose(ctx, &rafttopopb.Command{Request: &rafttopopb.Command_LeaseGrant{LeaseGrant: request}})
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return response.(*rafttopopb.LeaseGrantResponse), nil
+}
+
+// LeaseKeepAlive is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) LeaseKeepAlive(ctx context.Context, request *rafttopopb.LeaseKeepAliveRequest) (*rafttopopb.LeaseKeepAliveResponse, error) {
+	response, err := n.propose(ctx, &rafttopopb.Command{Request: &rafttopopb.Command_LeaseKeepAlive{LeaseKeepAlive: request}})
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return response.(*rafttopopb.LeaseKeepAliveResponse), nil
+}
+
+// LeaseRevoke is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) LeaseRevoke(ctx context.Context, request *rafttopopb.LeaseRevokeRequest) (*rafttopopb.LeaseRevokeResponse, error) {
+	response, err := n.propose(ctx, &rafttopopb.Command{Request: &rafttopopb.Command_LeaseRevoke{LeaseRevoke: request}})
+	if err != nil {
+		return nil, vterrors.ToGRPC(err)
+	}
+	return response.(*rafttopopb.LeaseRevokeResponse), nil
+}
+
+// Watch is part of the rafttopopb.RaftTopoServer interface. The first
+// response has the current content of the watched files, and the next ones
+// the changes. The stream ends with an unavailable error if the watcher
+// falls behind, in which case the client watches again.
+func (n *Node) Watch(request *rafttopopb.WatchRequest, stream rafttopopb.RaftTopo_WatchServer) error {
+	ctx := stream.Context()
+	if err := n.linearizableRead(ctx); err != nil {
+		return vterrors.ToGRPC(err)
+	}
+	kvs, revision, w := n.store.watch(request.Key, request.Prefix)
+	defer n.store.unwatch(w)
+	if err := stream.Send(&rafttopopb.WatchResponse{Kvs: kvs, Revision: revision}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return vterrors.ToGRPC(ctx.Err())
+		case <-n.stop:
+			return vterrors.ToGRPC(errNodeStopped)
+		case events, ok := <-w.events:
+			if !ok {
+				return vterrors.ToGRPC(vterrors.Errorf(vtrpcpb.Code_UNAVAILABLE, "watch of %v fell behind", request.Key))
+			}
+			if err := stream.Send(&rafttopopb.WatchResponse{
+				Events:   events,
+				Revision: events[len(events)-1].Kv.ModRevision,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Raft is part of the rafttopopb.RaftTopoServer interface.
+func (n *Node) Raft(ctx context.Context, request *rafttopopb.RaftRequest) (*rafttopopb.RaftResponse, error) {
+	for _, data := range request.Messages {
+		var m raftpb.Message
+		if err := m.Unmarshal(data); err != nil {
+			return nil, vterrors.ToGRPC(vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid raft message: %v", err))
+		}
+		if err := n.raft.Step(ctx, m); err != nil {
+			return nil, vterrors.ToGRPC(err)
+		}
+	}
+	return &rafttopopb.RaftResponse{}, nil
+}
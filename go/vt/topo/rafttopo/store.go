@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// watchBufferSize is the number of changes a watcher can fall behind by
+// before its watch is ended.
+const watchBufferSize = 100
+
+// store is the key-value state machine every member applies the raft log
+// to. Every change bumps the revision of the store, which becomes the
+// mod_revision of the files it changes.
+type store struct {
+	mu       sync.Mutex
+	revision int64
+	kvs      map[string]*rafttopopb.KeyValue
+	// keys are the keys of kvs, sorted, for the prefix ranges.
+	keys     []string
+	leases   map[int64]*lease
+	watchers map[*watcher]bool
+}
+
+// lease is a lease of the store. The deadline is local to each member, and
+// only the leader acts on it.
+type lease struct {
+	id       int64
+	ttl      int64
+	keys     map[string]bool
+	deadline time.Time
+}
+
+// watcher receives the changes to a file, or to the files under a prefix.
+// The store closes its channel when the watcher falls behind, or when the
+// store is restored from a snapshot.
+type watcher struct {
+	key    string
+	prefix bool
+	events chan []*rafttopopb.Event
+}
+
+func newStore() *store {
+	return &store{
+		kvs:      make(map[string]*rafttopopb.KeyValue),
+		leases:   make(map[int64]*lease),
+		watchers: make(map[*watcher]bool),
+	}
+}
+
+func (w *watcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+	return key == w.key
+}
+
+// apply applies a command committed at the given index of the raft log,
+// and returns the response for the request that proposed it. A failed
+// command does not change the store.
+func (s *store) apply(index uint64, cmd *rafttopopb.Command) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch req := cmd.Request.(type) {
+	case *rafttopopb.Command_Put:
+		return s.put(req.Put)
+	case *rafttopopb.Command_Delete:
+		return s.delete(req.Delete)
+	case *rafttopopb.Command_LeaseGrant:
+		// The index of the command is as unique as a lease ID needs to be.
+		l := &lease{
+			id:       int64(index),
+			ttl:      req.LeaseGrant.Ttl,
+			keys:     make(map[string]bool),
+			deadline: time.Now().Add(time.Duration(req.LeaseGrant.Ttl) * time.Second),
+		}
+		s.leases[l.id] = l
+		return &rafttopopb.LeaseGrantResponse{Id: l.id}, nil
+	case *rafttopopb.Command_LeaseKeepAlive:
+		l, ok := s.leases[req.LeaseKeepAlive.Id]
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "lease %v not found", req.LeaseKeepAlive.Id)
+		}
+		l.deadline = time.Now().Add(time.Duration(l.ttl) * time.Second)
+		return &rafttopopb.LeaseKeepAliveResponse{}, nil
+	case *rafttopopb.Command_LeaseRevoke:
+		l, ok := s.leases[req.LeaseRevoke.Id]
+		if !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "lease %v not found", req.LeaseRevoke.Id)
+		}
+		s.revoke(l)
+		return &rafttopopb.LeaseRevokeResponse{}, nil
+	default:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INTERNAL, "unknown command %T", cmd.Request)
+	}
+}
+
+func (s *store) put(req *rafttopopb.PutRequest) (*rafttopopb.PutResponse, error) {
+	existing, exists := s.kvs[req.Key]
+	if req.Create && exists {
+		return nil, vterrors.Errorf(vtrpcpb.Code_ALREADY_EXISTS, "%v already exists", req.Key)
+	}
+	if req.Version != 0 && (!exists || existing.ModRevision != req.Version) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "%v is not at version %v", req.Key, req.Version)
+	}
+	if req.Lease != 0 {
+		if _, ok := s.leases[req.Lease]; !ok {
+			return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "lease %v not found", req.Lease)
+		}
+	}
+
+	s.revision++
+	kv := &rafttopopb.KeyValue{
+		Key:            req.Key,
+		Value:          req.Value,
+		CreateRevision: s.revision,
+		ModRevision:    s.revision,
+		Lease:          req.Lease,
+	}
+	if exists {
+		kv.CreateRevision = existing.CreateRevision
+		if l, ok := s.leases[existing.Lease]; ok {
+			delete(l.keys, req.Key)
+		}
+	} else {
+		i, _ := slices.BinarySearch(s.keys, req.Key)
+		s.keys = slices.Insert(s.keys, i, req.Key)
+	}
+	if l, ok := s.leases[req.Lease]; ok {
+		l.keys[req.Key] = true
+	}
+	s.kvs[req.Key] = kv
+	s.notify([]*rafttopopb.Event{{Type: rafttopopb.Event_PUT, Kv: kv}})
+	return &rafttopopb.PutResponse{Revision: s.revision}, nil
+}
+
+func (s *store) delete(req *rafttopopb.DeleteRequest) (*rafttopopb.DeleteResponse, error) {
+	existing, exists := s.kvs[req.Key]
+	if !exists {
+		return nil, vterrors.Errorf(vtrpcpb.Code_NOT_FOUND, "%v not found", req.Key)
+	}
+	if req.Version != 0 && existing.ModRevision != req.Version {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "%v is not at version %v", req.Key, req.Version)
+	}
+
+	s.revision++
+	s.notify([]*rafttopopb.Event{s.remove(existing)})
+	return &rafttopopb.DeleteResponse{Revision: s.revision}, nil
+}
+
+// revoke deletes the lease and all its files, in a single revision.
+func (s *store) revoke(l *lease) {
+	delete(s.leases, l.id)
+	if len(l.keys) == 0 {
+		return
+	}
+
+	s.revision++
+	keys := make([]string, 0, len(l.keys))
+	for key := range l.keys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	events := make([]*rafttopopb.Event, 0, len(keys))
+	for _, key := range keys {
+		events = append(events, s.remove(s.kvs[key]))
+	}
+	s.notify(events)
+}
+
+// remove removes the file at the current revision, and returns the event.
+func (s *store) remove(kv *rafttopopb.KeyValue) *rafttopopb.Event {
+	delete(s.kvs, kv.Key)
+	if i, found := slices.BinarySearch(s.keys, kv.Key); found {
+		s.keys = slices.Delete(s.keys, i, i+1)
+	}
+	if l, ok := s.leases[kv.Lease]; ok {
+		delete(l.keys, kv.Key)
+	}
+	deleted := kv.CloneVT()
+	deleted.ModRevision = s.revision
+	return &rafttopopb.Event{Type: rafttopopb.Event_DELETE, Kv: deleted}
+}
+
+// notify sends the events to the watchers they match. A watcher that
+// can't keep up is dropped.
+func (s *store) notify(events []*rafttopopb.Event) {
+	for w := range s.watchers {
+		var matched []*rafttopopb.Event
+		for _, event := range events {
+			if w.matches(event.Kv.Key) {
+				matched = append(matched, event)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		select {
+		case w.events <- matched:
+		default:
+			delete(s.watchers, w)
+			close(w.events)
+		}
+	}
+}
+
+// rangeKeys returns the file at key, or the files under the key prefix.
+func (s *store) rangeKeys(key string, prefix bool) ([]*rafttopopb.KeyValue, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rangeLocked(key, prefix), s.revision
+}
+
+func (s *store) rangeLocked(key string, prefix bool) []*rafttopopb.KeyValue {
+	if !prefix {
+		if kv, ok := s.kvs[key]; ok {
+			return []*rafttopopb.KeyValue{kv}
+		}
+		return nil
+	}
+	var kvs []*rafttopopb.KeyValue
+	i, _ := slices.BinarySearch(s.keys, key)
+	for ; i < len(s.keys) && strings.HasPrefix(s.keys[i], key); i++ {
+		kvs = append(kvs, s.kvs[s.keys[i]])
+	}
+	return kvs
+}
+
+// watch returns the current content of the watched files, and registers a
+// watcher for their changes.
+func (s *store) watch(key string, prefix bool) ([]*rafttopopb.KeyValue, int64, *watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &watcher{
+		key:    key,
+		prefix: prefix,
+		events: make(chan []*rafttopopb.Event, watchBufferSize),
+	}
+	s.watchers[w] = true
+	return s.rangeLocked(key, prefix), s.revision, w
+}
+
+// unwatch unregisters the watcher, if the store hasn't dropped it already.
+func (s *store) unwatch(w *watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.watchers[w] {
+		delete(s.watchers, w)
+		close(w.events)
+	}
+}
+
+// resetLeaseDeadlines gives every lease a full TTL. A new leader does it
+// before expiring leases, as it can't know when they were last kept alive
+// on the previous leader.
+func (s *store) resetLeaseDeadlines() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, l := range s.leases {
+		l.deadline = now.Add(time.Duration(l.ttl) * time.Second)
+	}
+}
+
+// expiredLeases returns the IDs of the leases past their deadline.
+func (s *store) expiredLeases() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []int64
+	now := time.Now()
+	for id, l := range s.leases {
+		if now.After(l.deadline) {
+			expired = append(expired, id)
+		}
+	}
+	return expired
+}
+
+// snapshot returns the marshaled content of the store.
+func (s *store) snapshot() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot := &rafttopopb.Snapshot{
+		Revision: s.revision,
+		Kvs:      make([]*rafttopopb.KeyValue, 0, len(s.keys)),
+	}
+	for _, key := range s.keys {
+		snapshot.Kvs = append(snapshot.Kvs, s.kvs[key])
+	}
+	for _, l := range s.leases {
+		snapshot.Leases = append(snapshot.Leases, &rafttopopb.Lease{Id: l.id, Ttl: l.ttl})
+	}
+	slices.SortFunc(snapshot.Leases, func(a, b *rafttopopb.Lease) int {
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return snapshot.MarshalVT()
+}
+
+// restore replaces the content of the store with a snapshot. The watchers
+// can't tell what changed, so they are all dropped.
+func (s *store) restore(data []byte) error {
+	snapshot := &rafttopopb.Snapshot{}
+	if err := snapshot.UnmarshalVT(data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revision = snapshot.Revision
+	s.kvs = make(map[string]*rafttopopb.KeyValue, len(snapshot.Kvs))
+	s.keys = make([]string, 0, len(snapshot.Kvs))
+	s.leases = make(map[int64]*lease, len(snapshot.Leases))
+	now := time.Now()
+	for _, l := range snapshot.Leases {
+		s.leases[l.Id] = &lease{
+			id:       l.Id,
+			ttl:      l.Ttl,
+			keys:     make(map[string]bool),
+			deadline: now.Add(time.Duration(l.Ttl) * time.Second),
+		}
+	}
+	for _, kv := range snapshot.Kvs {
+		s.kvs[kv.Key] = kv
+		s.keys = append(s.keys, kv.Key)
+		if l, ok := s.leases[kv.Lease]; ok {
+			l.keys[kv.Key] = true
+		}
+	}
+	slices.Sort(s.keys)
+	for w := range s.watchers {
+		delete(s.watchers, w)
+		close(w.events)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.etcd.io/raft/v3"
+	"go.etcd.io/raft/v3/raftpb"
+	"google.golang.org/grpc"
+
+	"vitess.io/vitess/go/vt/grpcclient"
+	"vitess.io/vitess/go/vt/log"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+const (
+	// peerQueueSize is the number of messages waiting to be sent to a peer
+	// after which new ones are dropped. Raft sends them again.
+	peerQueueSize = 1024
+
+	// peerBatchSize is the maximum number of messages sent in one request.
+	peerBatchSize = 64
+
+	// peerSendTimeout bounds a request to a peer.
+	peerSendTimeout = 5 * time.Second
+)
+
+// transport sends the raft messages of a member to its peers. Every peer
+// has its own queue and goroutine, so that a slow or dead peer doesn't hold
+// the others back.
+type transport struct {
+	node  *Node
+	peers map[uint64]*peer
+	wg    sync.WaitGroup
+}
+
+type peer struct {
+	id     uint64
+	addr   string
+	queue  chan raftpb.Message
+	cc     *grpc.ClientConn
+	client rafttopopb.RaftTopoClient
+}
+
+func newTransport(n *Node) *transport {
+	t := &transport{
+		node:  n,
+		peers: make(map[uint64]*peer),
+	}
+	for id, addr := range n.config.Peers {
+		if id == n.config.ID {
+			continue
+		}
+		p := &peer{
+			id:    id,
+			addr:  addr,
+			queue: make(chan raftpb.Message, peerQueueSize),
+		}
+		t.peers[id] = p
+		t.wg.Add(1)
+		go t.run(p)
+	}
+	return t
+}
+
+// send queues the messages for their peers.
+func (t *transport) send(messages []raftpb.Message) {
+	for _, m := range messages {
+		p, ok := t.peers[m.To]
+		if !ok {
+			log.Warningf("raft member %v has no peer %v", t.node.config.ID, m.To)
+			continue
+		}
+		select {
+		case p.queue <- m:
+		default:
+			t.unreachable(p, m)
+		}
+	}
+}
+
+func (t *transport) unreachable(p *peer, m raftpb.Message) {
+	t.node.raft.ReportUnreachable(p.id)
+	if m.Type == raftpb.MsgSnap {
+		t.node.raft.ReportSnapshot(p.id, raft.SnapshotFailure)
+	}
+}
+
+// run sends the queued messages to the peer, in batches.
+func (t *transport) run(p *peer) {
+	defer t.wg.Done()
+	for {
+		var m raftpb.Message
+		select {
+		case <-t.node.stop:
+			return
+		case m = <-p.queue:
+		}
+		batch := []raftpb.Message{m}
+	batching:
+		for len(batch) < peerBatchSize {
+			select {
+			case m = <-p.queue:
+				batch = append(batch, m)
+			default:
+				break batching
+			}
+		}
+
+		if err := t.sendBatch(p, batch); err != nil {
+			log.V(2).Infof("raft member %v failed to send %v messages to %v at %v: %v", t.node.config.ID, len(batch), p.id, p.addr, err)
+			for _, m := range batch {
+				t.unreachable(p, m)
+			}
+			continue
+		}
+		for _, m := range batch {
+			if m.Type == raftpb.MsgSnap {
+				t.node.raft.ReportSnapshot(p.id, raft.SnapshotFinish)
+			}
+		}
+	}
+}
+
+func (t *transport) sendBatch(p *peer, batch []raftpb.Message) error {
+	if p.client == nil {
+		opt, err := dialOption()
+		if err != nil {
+			return err
+		}
+		cc, err := grpcclient.DialContext(context.Background(), p.addr, grpcclient.FailFast(true), opt)
+		if err != nil {
+			return err
+		}
+		p.cc = cc
+		p.client = rafttopopb.NewRaftTopoClient(cc)
+	}
+
+	request := &rafttopopb.RaftRequest{Messages: make([][]byte, 0, len(batch))}
+	for i := range batch {
+		data, err := batch[i].Marshal()
+		if err != nil {
+			return err
+		}
+		request.Messages = append(request.Messages, data)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), peerSendTimeout)
+	defer cancel()
+	_, err := p.client.Raft(ctx, request)
+	return err
+}
+
+// close stops sending messages. It must be called once the member is
+// stopped.
+func (t *transport) close() {
+	t.wg.Wait()
+	for _, p := range t.peers {
+		if p.cc != nil {
+			p.cc.Close()
+		}
+	}
+}
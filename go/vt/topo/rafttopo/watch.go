@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rafttopo
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+
+	rafttopopb "vitess.io/vitess/go/vt/proto/rafttopo"
+)
+
+// watchRetryDelay is how long a broken watch waits before watching again.
+const watchRetryDelay = time.Second
+
+// Watch is part of the topo.Conn interface.
+func (s *Server) Watch(ctx context.Context, filePath string) (*topo.WatchData, <-chan *topo.WatchData, error) {
+	nodePath := path.Join(s.root, filePath)
+
+	// Create a context, will be used to cancel the watch on return.
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	stream, initial, err := s.watch(watchCtx, nodePath, false)
+	if err != nil {
+		watchCancel()
+		return nil, nil, convertError(err, nodePath)
+	}
+	if len(initial.Kvs) != 1 {
+		// Node doesn't exist.
+		watchCancel()
+		return nil, nil, topo.NewError(topo.NoNode, nodePath)
+	}
+	wd := &topo.WatchData{
+		Contents: initial.Kvs[0].Value,
+		Version:  RaftVersion(initial.Kvs[0].ModRevision),
+	}
+
+	// Create the notifications channel, send updates to it.
+	notifications := make(chan *topo.WatchData, 10)
+	go func() {
+		defer close(notifications)
+		defer watchCancel()
+
+		for {
+			response, err := stream.Recv()
+			if err != nil && isUnavailable(err) {
+				// The member went away, or we fell behind. Watch again,
+				// which starts with the current content of the file.
+				log.Warningf("watch %v failed, watching again: %v", nodePath, err)
+				stream, response, err = s.rewatch(watchCtx, nodePath, false)
+				if err == nil {
+					if len(response.Kvs) != 1 {
+						notifications <- &topo.WatchData{Err: topo.NewError(topo.NoNode, nodePath)}
+						return
+					}
+					response.Events = []*rafttopopb.Event{{Type: rafttopopb.Event_PUT, Kv: response.Kvs[0]}}
+				}
+			}
+			if err != nil {
+				select {
+				case <-s.running:
+					return
+				default:
+				}
+				// This includes context cancellation errors.
+				notifications <- &topo.WatchData{Err: convertError(err, nodePath)}
+				return
+			}
+
+			for _, event := range response.Events {
+				switch event.Type {
+				case rafttopopb.Event_PUT:
+					notifications <- &topo.WatchData{
+						Contents: event.Kv.Value,
+						Version:  RaftVersion(event.Kv.ModRevision),
+					}
+				case rafttopopb.Event_DELETE:
+					// Node is gone, send a final notice.
+					notifications <- &topo.WatchData{Err: topo.NewError(topo.NoNode, nodePath)}
+					return
+				}
+			}
+		}
+	}()
+
+	return wd, notifications, nil
+}
+
+// WatchRecursive is part of the topo.Conn interface.
+func (s *Server) WatchRecursive(ctx context.Context, dirpath string) ([]*topo.WatchDataRecursive, <-chan *topo.WatchDataRecursive, error) {
+	nodePath := path.Join(s.root, dirpath)
+	if !strings.HasSuffix(nodePath, "/") {
+		nodePath = nodePath + "/"
+	}
+
+	// Create a context, will be used to cancel the watch on return.
+	watchCtx, watchCancel := context.WithCancel(ctx)
+	stream, initial, err := s.watch(watchCtx, nodePath, true)
+	if err != nil {
+		watchCancel()
+		return nil, nil, convertError(err, nodePath)
+	}
+
+	// known are the files we sent, so that the ones deleted while we
+	// watch again can be reported.
+	known := make(map[string]bool)
+	var initialwd []*topo.WatchDataRecursive
+	for _, kv := range initial.Kvs {
+		known[kv.Key] = true
+		initialwd = append(initialwd, &topo.WatchDataRecursive{
+			Path: kv.Key,
+			WatchData: topo.WatchData{
+				Contents: kv.Value,
+				Version:  RaftVersion(kv.ModRevision),
+			},
+		})
+	}
+
+	// Create the notifications channel, send updates to it.
+	notifications := make(chan *topo.WatchDataRecursive, 10)
+	go func() {
+		defer close(notifications)
+		defer watchCancel()
+
+		for {
+			response, err := stream.Recv()
+			if err != nil && isUnavailable(err) {
+				// The member went away, or we fell behind. Watch again,
+				// which starts with the current content of the files.
+				log.Warningf("watch %v failed, watching again: %v", nodePath, err)
+				stream, response, err = s.rewatch(watchCtx, nodePath, true)
+				if err == nil {
+					current := make(map[string]bool)
+					for _, kv := range response.Kvs {
+						current[kv.Key] = true
+						response.Events = append(response.Events, &rafttopopb.Event{Type: rafttopopb.Event_PUT, Kv: kv})
+					}
+					for key := range known {
+						if !current[key] {
+							response.Events = append(response.Events, &rafttopopb.Event{Type: rafttopopb.Event_DELETE, Kv: &rafttopopb.KeyValue{Key: key}})
+						}
+					}
+				}
+			}
+			if err != nil {
+				select {
+				case <-s.running:
+					return
+				default:
+				}
+				// This includes context cancellation errors.
+				notifications <- &topo.WatchDataRecursive{
+					WatchData: topo.WatchData{Err: convertError(err, nodePath)},
+				}
+				return
+			}
+
+			for _, event := range response.Events {
+				switch event.Type {
+				case rafttopopb.Event_PUT:
+					known[event.Kv.Key] = true
+					notifications <- &topo.WatchDataRecursive{
+						Path: event.Kv.Key,
+						WatchData: topo.WatchData{
+							Contents: event.Kv.Value,
+							Version:  RaftVersion(event.Kv.ModRevision),
+						},
+					}
+				case rafttopopb.Event_DELETE:
+					delete(known, event.Kv.Key)
+					notifications <- &topo.WatchDataRecursive{
+						Path: event.Kv.Key,
+						WatchData: topo.WatchData{
+							Err: topo.NewError(topo.NoNode, event.Kv.Key),
+						},
+					}
+				}
+			}
+		}
+	}()
+
+	return initialwd, notifications, nil
+}
+
+// watch starts watching the key, and returns the stream with its first
+// response, the current content of the watched files.
+func (s *Server) watch(ctx context.Context, key string, prefix bool) (rafttopopb.RaftTopo_WatchClient, *rafttopopb.WatchResponse, error) {
+	var stream rafttopopb.RaftTopo_WatchClient
+	var initial *rafttopopb.WatchResponse
+	err := s.call(func(client rafttopopb.RaftTopoClient) error {
+		var err error
+		if stream, err = client.Watch(ctx, &rafttopopb.WatchRequest{Key: key, Prefix: prefix}); err != nil {
+			return err
+		}
+		initial, err = stream.Recv()
+		return err
+	})
+	return stream, initial, err
+}
+
+// rewatch watches the key again after its watch broke, until a member
+// takes the watch or ctx is done.
+func (s *Server) rewatch(ctx context.Context, key string, prefix bool) (rafttopopb.RaftTopo_WatchClient, *rafttopopb.WatchResponse, error) {
+	for {
+		stream, initial, err := s.watch(ctx, key, prefix)
+		if !isUnavailable(err) {
+			return stream, initial, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-s.running:
+			return nil, nil, err
+		case <-time.After(watchRetryDelay):
+		}
+	}
+}
@@ -159,6 +159,10 @@ func (f *fakeVTGateService) VStream(ctx context.Context, tabletType topodatapb.T
 	return nil
 }
 
+func (f *fakeVTGateService) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	return nil
+}
+
 // HandlePanic is part of the VTGateService interface
 func (f *fakeVTGateService) HandlePanic(err *error) {
 	if x := recover(); x != nil {
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"vitess.io/vitess/go/json2"
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// CheckpointStore persists the position of a sink, so that it resumes from
+// there after a restart.
+type CheckpointStore interface {
+	// Load returns the saved position, or nil if there is none.
+	Load(ctx context.Context) (*binlogdatapb.VGtid, error)
+	// Save saves the position.
+	Save(ctx context.Context, vgtid *binlogdatapb.VGtid) error
+}
+
+// FileCheckpointStore saves the position in a local file, as JSON.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore returns a CheckpointStore that saves the position
+// in the file at path.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load is part of the CheckpointStore interface.
+func (fcs *FileCheckpointStore) Load(ctx context.Context) (*binlogdatapb.VGtid, error) {
+	data, err := os.ReadFile(fcs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vgtid := &binlogdatapb.VGtid{}
+	if err := json2.UnmarshalPB(data, vgtid); err != nil {
+		return nil, vterrors.Wrapf(err, "invalid checkpoint in %v", fcs.path)
+	}
+	return vgtid, nil
+}
+
+// Save is part of the CheckpointStore interface. The file is replaced
+// atomically, so that a crash leaves either the old or the new position.
+func (fcs *FileCheckpointStore) Save(ctx context.Context, vgtid *binlogdatapb.VGtid) error {
+	data, err := json2.MarshalPB(vgtid)
+	if err != nil {
+		return err
+	}
+	tmp := fcs.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fcs.path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(fcs.path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Executor executes queries. *vtgateconn.VTGateSession implements it.
+type Executor interface {
+	Execute(ctx context.Context, query string, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error)
+}
+
+const (
+	sqlCreateCheckpointTable = `create table if not exists %s (
+  name varbinary(255) not null,
+  vgtid longblob not null,
+  primary key (name)
+)`
+	sqlSaveCheckpoint = "insert into %s (name, vgtid) values (:name, :vgtid) on duplicate key update vgtid = values(vgtid)"
+	sqlLoadCheckpoint = "select vgtid from %s where name = :name"
+	sqlShowShards     = "show vitess_shards like %s"
+)
+
+// TableCheckpointStore saves the position in a row of a table of an
+// unsharded keyspace, that it creates if needed. Several sinks can share
+// the table, each with its own name.
+type TableCheckpointStore struct {
+	executor Executor
+	keyspace string
+	table    string
+	name     string
+	created  bool
+}
+
+// NewTableCheckpointStore returns a CheckpointStore that saves the position
+// under name in table, through executor. The table is qualified by its
+// keyspace, as <keyspace>.<table>.
+func NewTableCheckpointStore(executor Executor, table, name string) (*TableCheckpointStore, error) {
+	keyspace, tableName, ok := strings.Cut(table, ".")
+	if !ok || keyspace == "" || tableName == "" {
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid checkpoint table %q, expected <keyspace>.<table>", table)
+	}
+	return &TableCheckpointStore{
+		executor: executor,
+		keyspace: keyspace,
+		table:    sqlescape.EscapeID(keyspace) + "." + sqlescape.EscapeID(tableName),
+		name:     name,
+	}, nil
+}
+
+// Load is part of the CheckpointStore interface.
+func (tcs *TableCheckpointStore) Load(ctx context.Context) (*binlogdatapb.VGtid, error) {
+	if err := tcs.createTable(ctx); err != nil {
+		return nil, err
+	}
+	query := sqlparser.BuildParsedQuery(sqlLoadCheckpoint, tcs.table).Query
+	qr, err := tcs.executor.Execute(ctx, query, map[string]*querypb.BindVariable{
+		"name": sqltypes.StringBindVariable(tcs.name),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(qr.Rows) == 0 {
+		return nil, nil
+	}
+	data, err := qr.Rows[0][0].ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	vgtid := &binlogdatapb.VGtid{}
+	if err := json2.UnmarshalPB(data, vgtid); err != nil {
+		return nil, vterrors.Wrapf(err, "invalid checkpoint %v in %v", tcs.name, tcs.table)
+	}
+	return vgtid, nil
+}
+
+// Save is part of the CheckpointStore interface.
+func (tcs *TableCheckpointStore) Save(ctx context.Context, vgtid *binlogdatapb.VGtid) error {
+	if err := tcs.createTable(ctx); err != nil {
+		return err
+	}
+	data, err := json2.MarshalPB(vgtid)
+	if err != nil {
+		return err
+	}
+	query := sqlparser.BuildParsedQuery(sqlSaveCheckpoint, tcs.table).Query
+	_, err = tcs.executor.Execute(ctx, query, map[string]*querypb.BindVariable{
+		"name":  sqltypes.StringBindVariable(tcs.name),
+		"vgtid": sqltypes.BytesBindVariable(data),
+	})
+	return err
+}
+
+// createTable creates the table, the first time it is used.
+func (tcs *TableCheckpointStore) createTable(ctx context.Context) error {
+	if tcs.created {
+		return nil
+	}
+	if err := tcs.checkUnsharded(ctx); err != nil {
+		return err
+	}
+	query := sqlparser.BuildParsedQuery(sqlCreateCheckpointTable, tcs.table).Query
+	if _, err := tcs.executor.Execute(ctx, query, nil); err != nil {
+		return vterrors.Wrapf(err, "failed to create checkpoint table %v", tcs.table)
+	}
+	tcs.created = true
+	return nil
+}
+
+// checkUnsharded returns an error if the keyspace of the table does not
+// have exactly one shard. In a sharded keyspace, the table would be
+// created on every shard and the checkpoints of a sink could be split
+// across them.
+func (tcs *TableCheckpointStore) checkUnsharded(ctx context.Context) error {
+	query := sqlparser.BuildParsedQuery(sqlShowShards, sqltypes.EncodeStringSQL(tcs.keyspace+"/%")).Query
+	qr, err := tcs.executor.Execute(ctx, query, nil)
+	if err != nil {
+		return vterrors.Wrapf(err, "failed to get the shards of checkpoint keyspace %v", tcs.keyspace)
+	}
+	shards := 0
+	for _, row := range qr.Rows {
+		// _ matches any character in the pattern, so it can match other keyspaces
+		if keyspace, _, _ := strings.Cut(row[0].ToString(), "/"); keyspace == tcs.keyspace {
+			shards++
+		}
+	}
+	if shards != 1 {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "checkpoint keyspace %v must be unsharded, it has %d shards", tcs.keyspace, shards)
+	}
+	return nil
+}
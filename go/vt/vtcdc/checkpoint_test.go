@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"context"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+var testCheckpoint = &binlogdatapb.VGtid{
+	ShardGtids: []*binlogdatapb.ShardGtid{{
+		Keyspace: "ks",
+		Shard:    "-80",
+		Gtid:     "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:1-5",
+		TablePKs: []*binlogdatapb.TableLastPK{{
+			TableName: "t1",
+			Lastpk:    sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "10")),
+		}},
+	}, {
+		Keyspace: "ks",
+		Shard:    "80-",
+		Gtid:     "MySQL56/3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7",
+	}},
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(path.Join(t.TempDir(), "checkpoint.json"))
+
+	vgtid, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, vgtid)
+
+	require.NoError(t, store.Save(ctx, testCheckpoint))
+	vgtid, err = store.Load(ctx)
+	require.NoError(t, err)
+	utils.MustMatch(t, testCheckpoint, vgtid)
+}
+
+// fakeExecutor is an Executor over a table of checkpoints in memory.
+type fakeExecutor struct {
+	// shards are the shards show vitess_shards returns.
+	shards      []string
+	queries     []string
+	checkpoints map[string][]byte
+}
+
+// Execute is part of the Executor interface.
+func (fe *fakeExecutor) Execute(ctx context.Context, query string, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	fe.queries = append(fe.queries, query)
+	switch {
+	case strings.HasPrefix(query, "show vitess_shards"):
+		return sqltypes.MakeTestResult(sqltypes.MakeTestFields("Shards", "varchar"), fe.shards...), nil
+	case strings.HasPrefix(query, "insert"):
+		fe.checkpoints[string(bindVars["name"].Value)] = bindVars["vgtid"].Value
+	case strings.HasPrefix(query, "select"):
+		result := &sqltypes.Result{Fields: sqltypes.MakeTestFields("vgtid", "blob")}
+		if data, ok := fe.checkpoints[string(bindVars["name"].Value)]; ok {
+			result.Rows = append(result.Rows, []sqltypes.Value{sqltypes.MakeTrusted(sqltypes.Blob, data)})
+		}
+		return result, nil
+	}
+	return &sqltypes.Result{}, nil
+}
+
+func TestTableCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	executor := &fakeExecutor{shards: []string{"cdc/0", "cdc2/-80", "cdc2/80-"}, checkpoints: make(map[string][]byte)}
+	store, err := NewTableCheckpointStore(executor, "cdc.vtcdc_checkpoint", "sink1")
+	require.NoError(t, err)
+	other, err := NewTableCheckpointStore(executor, "cdc.vtcdc_checkpoint", "sink2")
+	require.NoError(t, err)
+
+	vgtid, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, vgtid)
+	assert.Equal(t, "show vitess_shards like 'cdc/%'", executor.queries[0])
+	assert.True(t, strings.HasPrefix(executor.queries[1], "create table if not exists `cdc`.`vtcdc_checkpoint`"), executor.queries[1])
+
+	require.NoError(t, store.Save(ctx, testCheckpoint))
+	vgtid, err = store.Load(ctx)
+	require.NoError(t, err)
+	utils.MustMatch(t, testCheckpoint, vgtid)
+
+	// Each sink has its own checkpoint.
+	vgtid, err = other.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, vgtid)
+
+	// The table must be in an unsharded keyspace.
+	sharded, err := NewTableCheckpointStore(executor, "cdc2.vtcdc_checkpoint", "sink1")
+	require.NoError(t, err)
+	_, err = sharded.Load(ctx)
+	assert.ErrorContains(t, err, "checkpoint keyspace cdc2 must be unsharded, it has 2 shards")
+
+	_, err = NewTableCheckpointStore(executor, "vtcdc_checkpoint", "sink1")
+	assert.ErrorContains(t, err, "expected <keyspace>.<table>")
+}
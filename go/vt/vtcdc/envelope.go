@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// Connector is the name of the connector in the source of the envelopes.
+const Connector = "vitess"
+
+// The operations of a change event, as Debezium names them.
+const (
+	OpCreate = "c"
+	OpUpdate = "u"
+	OpDelete = "d"
+	OpRead   = "r"
+)
+
+// Envelope is the value of a change event, in the Debezium JSON format
+// without schemas.
+type Envelope struct {
+	Before *Row   `json:"before"`
+	After  *Row   `json:"after"`
+	Source Source `json:"source"`
+	Op     string `json:"op"`
+	TsMs   int64  `json:"ts_ms"`
+}
+
+// Source describes where a change event comes from.
+type Source struct {
+	Version   string `json:"version"`
+	Connector string `json:"connector"`
+	Name      string `json:"name"`
+	TsMs      int64  `json:"ts_ms"`
+	Snapshot  string `json:"snapshot"`
+	DB        string `json:"db"`
+	Keyspace  string `json:"keyspace"`
+	Table     string `json:"table"`
+	Shard     string `json:"shard"`
+	// Vgtid is the JSON encoded position of the stream after the
+	// transaction of the change event.
+	Vgtid string `json:"vgtid"`
+}
+
+// Row is the image of a row. It is encoded as a JSON object with one
+// member per column, in the order of the columns of the table.
+type Row struct {
+	Fields []*querypb.Field
+	Values []sqltypes.Value
+}
+
+// MarshalJSON is part of the json.Marshaler interface.
+func (r *Row) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r.Fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(columnValue(field, r.Values[i]))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// key returns the columns of the row that are part of the primary key, or
+// nil if the table has no primary key.
+func (r *Row) key() *Row {
+	key := &Row{}
+	for i, field := range r.Fields {
+		if field.Flags&uint32(querypb.MySqlFlag_PRI_KEY_FLAG) != 0 {
+			key.Fields = append(key.Fields, field)
+			key.Values = append(key.Values, r.Values[i])
+		}
+	}
+	if len(key.Fields) == 0 {
+		return nil
+	}
+	return key
+}
+
+// columnValue returns the JSON representation of a column value: numbers
+// for the integral and floating point types, base64 encoded bytes for the
+// binary types, and strings for everything else, including decimals and
+// temporal types.
+func columnValue(field *querypb.Field, value sqltypes.Value) any {
+	if value.IsNull() {
+		return nil
+	}
+	switch {
+	case sqltypes.IsSigned(field.Type):
+		if v, err := value.ToInt64(); err == nil {
+			return v
+		}
+	case sqltypes.IsUnsigned(field.Type):
+		if v, err := value.ToUint64(); err == nil {
+			return v
+		}
+	case sqltypes.IsFloat(field.Type):
+		if v, err := strconv.ParseFloat(value.ToString(), 64); err == nil {
+			return v
+		}
+	case field.Type == sqltypes.Bit, field.Type == sqltypes.Geometry:
+		return value.Raw()
+	case sqltypes.IsBinary(field.Type) && field.Charset == collations.CollationBinaryID:
+		// Text columns may be sent as blobs, with the charset of the column.
+		return value.Raw()
+	}
+	return value.ToString()
+}
+
+// rowChange is a change to a row, with the fields of its table.
+type rowChange struct {
+	event  *binlogdatapb.VEvent
+	fields []*querypb.Field
+	change *binlogdatapb.RowChange
+}
+
+// newRow returns the image of a row from its proto, or nil if there is no
+// image.
+func newRow(fields []*querypb.Field, row *querypb.Row) *Row {
+	if row == nil {
+		return nil
+	}
+	return &Row{Fields: fields, Values: sqltypes.MakeRowTrusted(fields, row)}
+}
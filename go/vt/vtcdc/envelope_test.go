@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestRowJSON(t *testing.T) {
+	fields := []*querypb.Field{
+		{Name: "id", Type: sqltypes.Int64, Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG)},
+		{Name: "u", Type: sqltypes.Uint64},
+		{Name: "f", Type: sqltypes.Float64},
+		{Name: "d", Type: sqltypes.Decimal},
+		{Name: "s", Type: sqltypes.VarChar, Charset: collations.CollationUtf8mb4ID},
+		{Name: "txt", Type: sqltypes.Blob, Charset: collations.CollationUtf8mb4ID},
+		{Name: "b", Type: sqltypes.VarBinary, Charset: collations.CollationBinaryID},
+		{Name: "j", Type: sqltypes.TypeJSON, Charset: collations.CollationBinaryID},
+		{Name: "dt", Type: sqltypes.Datetime},
+		{Name: "region", Type: sqltypes.VarChar, Flags: uint32(querypb.MySqlFlag_PRI_KEY_FLAG)},
+		{Name: "n", Type: sqltypes.Int32},
+	}
+	row := &Row{
+		Fields: fields,
+		Values: []sqltypes.Value{
+			sqltypes.NewInt64(-1),
+			sqltypes.NewUint64(18446744073709551615),
+			sqltypes.NewFloat64(1.5),
+			sqltypes.NewDecimal("12.30"),
+			sqltypes.NewVarChar("text"),
+			sqltypes.MakeTrusted(sqltypes.Blob, []byte("long text")),
+			sqltypes.NewVarBinary("\x00\x01"),
+			sqltypes.MakeTrusted(sqltypes.TypeJSON, []byte(`{"a": 1}`)),
+			sqltypes.NewDatetime("2024-01-02 03:04:05"),
+			sqltypes.NewVarChar("eu"),
+			sqltypes.NULL,
+		},
+	}
+
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":-1,"u":18446744073709551615,"f":1.5,"d":"12.30","s":"text","txt":"long text","b":"AAE=","j":"{\"a\": 1}","dt":"2024-01-02 03:04:05","region":"eu","n":null}`, string(data))
+
+	// The key has the primary key columns, in order.
+	data, err = json.Marshal(row.key())
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":-1,"region":"eu"}`, string(data))
+
+	row.Fields = fields[1:3]
+	assert.Nil(t, row.key())
+}
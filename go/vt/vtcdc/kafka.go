@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+)
+
+// Record is a message to publish.
+type Record struct {
+	Topic string
+	// Key is the primary key of the changed row. Records with the same
+	// key go to the same partition. It is nil for tables without a
+	// primary key.
+	Key []byte
+	// Value is the change event. It is nil for tombstones.
+	Value []byte
+}
+
+// Producer publishes records.
+type Producer interface {
+	// Produce publishes the records, in order, and returns once the
+	// broker acknowledged all of them.
+	Produce(ctx context.Context, records []*Record) error
+	// Close closes the producer.
+	Close()
+}
+
+// KafkaConfig is the configuration of a producer for a Kafka-compatible
+// broker.
+type KafkaConfig struct {
+	// Brokers are the addresses of the brokers to bootstrap from.
+	Brokers []string
+	// ClientID is the client ID the producer uses.
+	ClientID string
+	// TLS is the TLS configuration, nil to connect in plain text.
+	TLS *tls.Config
+	// SASLMechanism is the SASL mechanism to authenticate with: PLAIN,
+	// SCRAM-SHA-256, SCRAM-SHA-512, or empty for no authentication.
+	SASLMechanism string
+	SASLUser      string
+	SASLPassword  string
+	// AllowAutoTopicCreation lets the broker create the topics it does
+	// not have yet.
+	AllowAutoTopicCreation bool
+}
+
+// kafkaProducer is a Producer for a Kafka-compatible broker.
+type kafkaProducer struct {
+	client *kgo.Client
+}
+
+// NewKafkaProducer returns a Producer that publishes to a Kafka-compatible
+// broker. The producer is idempotent, records are acknowledged by all the
+// in-sync replicas, and are partitioned by hashing their key the same way
+// as the Kafka clients.
+func NewKafkaProducer(config KafkaConfig) (Producer, error) {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(config.Brokers...),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+	}
+	if config.ClientID != "" {
+		opts = append(opts, kgo.ClientID(config.ClientID))
+	}
+	if config.TLS != nil {
+		opts = append(opts, kgo.DialTLSConfig(config.TLS))
+	}
+	if config.SASLMechanism != "" {
+		mechanism, err := saslMechanism(config)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, kgo.SASL(mechanism))
+	}
+	if config.AllowAutoTopicCreation {
+		opts = append(opts, kgo.AllowAutoTopicCreation())
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaProducer{client: client}, nil
+}
+
+// saslMechanism returns the SASL mechanism of the configuration.
+func saslMechanism(config KafkaConfig) (sasl.Mechanism, error) {
+	switch config.SASLMechanism {
+	case "PLAIN":
+		return plain.Auth{User: config.SASLUser, Pass: config.SASLPassword}.AsMechanism(), nil
+	case "SCRAM-SHA-256":
+		return scram.Auth{User: config.SASLUser, Pass: config.SASLPassword}.AsSha256Mechanism(), nil
+	case "SCRAM-SHA-512":
+		return scram.Auth{User: config.SASLUser, Pass: config.SASLPassword}.AsSha512Mechanism(), nil
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", config.SASLMechanism)
+}
+
+// Produce is part of the Producer interface.
+func (kp *kafkaProducer) Produce(ctx context.Context, records []*Record) error {
+	krs := make([]*kgo.Record, 0, len(records))
+	for _, record := range records {
+		krs = append(krs, &kgo.Record{
+			Topic: record.Topic,
+			Key:   record.Key,
+			Value: record.Value,
+		})
+	}
+	return kp.client.ProduceSync(ctx, krs...).FirstErr()
+}
+
+// Close is part of the Producer interface.
+func (kp *kafkaProducer) Close() {
+	kp.client.Close()
+}
@@ -0,0 +1,368 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package vtcdc publishes the row changes of a keyspace, as streamed by
+// vtgate, to a Kafka-compatible broker as Debezium change events.
+//
+// A Sink streams the keyspace with VStream, and publishes one record per
+// changed row, to the topic <prefix>.<keyspace>.<table>. The key of a record
+// is the primary key of the row, so that the changes of a row stay in order
+// in their partition. Records are published one transaction at a time, and
+// the position of the last published transaction is saved to a
+// CheckpointStore, from where the sink resumes after a restart. Changes
+// published after the last saved position are published again then, so
+// consumers see every change at least once.
+package vtcdc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/sqlescape"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/vtgateconn"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+var (
+	recordsPublished = stats.NewCountersWithSingleLabel("VtcdcRecordsPublished", "Records published, by topic", "Topic")
+	streamErrors     = stats.NewCounter("VtcdcStreamErrors", "Errors that restarted the stream")
+	checkpointErrors = stats.NewCounter("VtcdcCheckpointErrors", "Failures to save the position")
+)
+
+// VStreamer starts a VStream. *vtgateconn.VTGateConn implements it.
+type VStreamer interface {
+	VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags) (vtgateconn.VStreamReader, error)
+}
+
+// Config is the configuration of a Sink.
+type Config struct {
+	// Keyspace is the keyspace to stream.
+	Keyspace string
+	// Tables are the tables to stream, all of them if empty.
+	Tables []string
+	// TabletType is the type of the tablets to stream from.
+	TabletType topodatapb.TabletType
+	// TopicPrefix prefixes the topics, and is the name of the source of
+	// the change events.
+	TopicPrefix string
+	// Snapshot makes a sink without a saved position publish the rows of
+	// the tables first, before their changes. Without it, the sink only
+	// publishes the changes from when it starts.
+	Snapshot bool
+	// TombstonesOnDelete publishes a record without value after each
+	// deletion, so that compacted topics can drop the row.
+	TombstonesOnDelete bool
+	// CheckpointInterval is the minimum time between two saves of the
+	// position.
+	CheckpointInterval time.Duration
+	// RetryDelay is how long the sink waits before resuming a stream that
+	// failed.
+	RetryDelay time.Duration
+}
+
+// Sink publishes the changes streamed from a keyspace.
+type Sink struct {
+	config      Config
+	streamer    VStreamer
+	producer    Producer
+	checkpoints CheckpointStore
+
+	// vgtid is the position of the last published records.
+	vgtid *binlogdatapb.VGtid
+	// lastCheckpoint is when the position was last saved.
+	lastCheckpoint time.Time
+	saved          bool
+}
+
+// NewSink returns a Sink that streams with streamer, publishes with
+// producer, and saves its position in checkpoints.
+func NewSink(config Config, streamer VStreamer, producer Producer, checkpoints CheckpointStore) *Sink {
+	return &Sink{
+		config:      config,
+		streamer:    streamer,
+		producer:    producer,
+		checkpoints: checkpoints,
+	}
+}
+
+// Run publishes the changes until ctx is done, resuming the stream when it
+// fails. It saves the position before returning.
+func (s *Sink) Run(ctx context.Context) error {
+	vgtid, err := s.checkpoints.Load(ctx)
+	if err != nil {
+		return vterrors.Wrapf(err, "failed to load checkpoint")
+	}
+	if vgtid == nil {
+		vgtid = s.startPosition()
+		log.Infof("No checkpoint, starting from %v", vgtid)
+	} else {
+		log.Infof("Resuming from checkpoint %v", vgtid)
+	}
+	s.vgtid = vgtid
+	s.saved = true
+
+	for ctx.Err() == nil {
+		err := s.stream(ctx)
+		if ctx.Err() != nil {
+			break
+		}
+		streamErrors.Add(1)
+		log.Warningf("Stream failed, resuming from %v in %v: %v", s.vgtid, s.config.RetryDelay, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.config.RetryDelay):
+		}
+	}
+
+	// ctx is done, save the position with a context of our own.
+	saveCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return s.checkpoint(saveCtx, true)
+}
+
+// startPosition returns the position to stream from without checkpoint.
+func (s *Sink) startPosition() *binlogdatapb.VGtid {
+	gtid := "current"
+	if s.config.Snapshot {
+		// An empty position copies the tables first.
+		gtid = ""
+	}
+	return &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{{
+			Keyspace: s.config.Keyspace,
+			Gtid:     gtid,
+		}},
+	}
+}
+
+// filter returns the filter of the stream.
+func (s *Sink) filter() *binlogdatapb.Filter {
+	filter := &binlogdatapb.Filter{}
+	if len(s.config.Tables) == 0 {
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{Match: "/.*/"})
+	}
+	for _, table := range s.config.Tables {
+		filter.Rules = append(filter.Rules, &binlogdatapb.Rule{
+			Match:  table,
+			Filter: "select * from " + sqlescape.EscapeID(table),
+		})
+	}
+	return filter
+}
+
+// tableKey identifies the fields of a table on a shard.
+type tableKey struct {
+	shard string
+	table string
+}
+
+// stream streams from the last published position, until the stream fails
+// or ctx is done. Records are published once the events of their whole
+// transaction are received.
+func (s *Sink) stream(ctx context.Context) error {
+	reader, err := s.streamer.VStream(ctx, s.config.TabletType, s.vgtid.CloneVT(), s.filter(), &vtgatepb.VStreamFlags{})
+	if err != nil {
+		return err
+	}
+
+	fields := make(map[tableKey][]*querypb.Field)
+	// pending are the changes of the transaction being received.
+	var pending []*rowChange
+	for {
+		events, err := reader.Recv()
+		if err != nil {
+			return err
+		}
+
+		// records are the records of the transactions received in full,
+		// and vgtid the position after them.
+		var records []*Record
+		var vgtid *binlogdatapb.VGtid
+		for _, event := range events {
+			switch event.Type {
+			case binlogdatapb.VEventType_FIELD:
+				fields[tableKey{event.FieldEvent.Shard, event.FieldEvent.TableName}] = event.FieldEvent.Fields
+			case binlogdatapb.VEventType_ROW:
+				key := tableKey{event.RowEvent.Shard, event.RowEvent.TableName}
+				tableFields, ok := fields[key]
+				if !ok {
+					return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "no fields for table %v on shard %v", key.table, key.shard)
+				}
+				for _, change := range event.RowEvent.RowChanges {
+					pending = append(pending, &rowChange{event: event, fields: tableFields, change: change})
+				}
+			case binlogdatapb.VEventType_VGTID:
+				changeRecords, err := s.records(pending, event.Vgtid)
+				if err != nil {
+					return err
+				}
+				records = append(records, changeRecords...)
+				pending = nil
+				vgtid = event.Vgtid
+			case binlogdatapb.VEventType_COPY_COMPLETED:
+				if event.Keyspace == "" && event.Shard == "" {
+					log.Infof("Copy of keyspace %v completed", s.config.Keyspace)
+				}
+			}
+		}
+		if vgtid == nil {
+			continue
+		}
+
+		if len(records) > 0 {
+			if err := s.producer.Produce(ctx, records); err != nil {
+				return vterrors.Wrapf(err, "failed to publish records")
+			}
+			for _, record := range records {
+				recordsPublished.Add(record.Topic, 1)
+			}
+		}
+		s.vgtid = vgtid
+		s.saved = false
+		if err := s.checkpoint(ctx, false); err != nil {
+			// We retry on the next transaction.
+			log.Warningf("Failed to save checkpoint: %v", err)
+		}
+	}
+}
+
+// checkpoint saves the position, unless it was saved less than the
+// checkpoint interval ago and force is false.
+func (s *Sink) checkpoint(ctx context.Context, force bool) error {
+	if s.saved || (!force && time.Since(s.lastCheckpoint) < s.config.CheckpointInterval) {
+		return nil
+	}
+	if err := s.checkpoints.Save(ctx, s.vgtid); err != nil {
+		checkpointErrors.Add(1)
+		return err
+	}
+	s.lastCheckpoint = time.Now()
+	s.saved = true
+	return nil
+}
+
+// shardPosition is the position of a shard, as written in the source of the
+// change events.
+type shardPosition struct {
+	Keyspace string `json:"keyspace"`
+	Shard    string `json:"shard"`
+	Gtid     string `json:"gtid"`
+}
+
+// records returns the records of the changes of a transaction, that ends
+// at vgtid.
+func (s *Sink) records(changes []*rowChange, vgtid *binlogdatapb.VGtid) ([]*Record, error) {
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	var positions []shardPosition
+	for _, sgtid := range vgtid.ShardGtids {
+		positions = append(positions, shardPosition{Keyspace: sgtid.Keyspace, Shard: sgtid.Shard, Gtid: sgtid.Gtid})
+	}
+	position, err := json.Marshal(positions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	var records []*Record
+	for _, rc := range changes {
+		keyspace := rc.event.RowEvent.Keyspace
+		shard := rc.event.RowEvent.Shard
+		table := strings.TrimPrefix(rc.event.RowEvent.TableName, keyspace+".")
+		envelope := &Envelope{
+			Before: newRow(rc.fields, rc.change.Before),
+			After:  newRow(rc.fields, rc.change.After),
+			Source: Source{
+				Version:   servenv.AppVersion.ToStringMap()["version"],
+				Connector: Connector,
+				Name:      s.config.TopicPrefix,
+				TsMs:      rc.event.Timestamp * 1000,
+				Snapshot:  "false",
+				DB:        keyspace,
+				Keyspace:  keyspace,
+				Table:     table,
+				Shard:     shard,
+				Vgtid:     string(position),
+			},
+			TsMs: now,
+		}
+		switch {
+		case envelope.Before == nil && isCopying(vgtid, keyspace, shard, table):
+			// Rows read by the copy phase, before the changes.
+			envelope.Op = OpRead
+			envelope.Source.Snapshot = "true"
+		case envelope.Before == nil:
+			envelope.Op = OpCreate
+		case envelope.After == nil:
+			envelope.Op = OpDelete
+		default:
+			envelope.Op = OpUpdate
+		}
+		if envelope.Source.TsMs == 0 {
+			envelope.Source.TsMs = now
+		}
+
+		var key []byte
+		keyRow := envelope.After
+		if keyRow == nil {
+			keyRow = envelope.Before
+		}
+		if pk := keyRow.key(); pk != nil {
+			if key, err = json.Marshal(pk); err != nil {
+				return nil, err
+			}
+		}
+		value, err := json.Marshal(envelope)
+		if err != nil {
+			return nil, err
+		}
+
+		topic := s.config.TopicPrefix + "." + keyspace + "." + table
+		records = append(records, &Record{Topic: topic, Key: key, Value: value})
+		if envelope.Op == OpDelete && s.config.TombstonesOnDelete && key != nil {
+			records = append(records, &Record{Topic: topic, Key: key})
+		}
+	}
+	return records, nil
+}
+
+// isCopying returns true if the table is still being copied on the shard
+// at vgtid.
+func isCopying(vgtid *binlogdatapb.VGtid, keyspace, shard, table string) bool {
+	for _, sgtid := range vgtid.ShardGtids {
+		if sgtid.Keyspace != keyspace || sgtid.Shard != shard {
+			continue
+		}
+		for _, tablePK := range sgtid.TablePKs {
+			if tablePK.TableName == table {
+				return true
+			}
+		}
+	}
+	return false
+}
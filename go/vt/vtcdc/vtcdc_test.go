@@ -0,0 +1,312 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtcdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vtgate/vtgateconn"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+)
+
+// fakeStream is what a fakeStreamer streams on one VStream call: the batches
+// of events, then err, or nothing until ctx is done if err is nil.
+type fakeStream struct {
+	batches [][]*binlogdatapb.VEvent
+	err     error
+}
+
+// fakeStreamer is a VStreamer that replays fake streams.
+type fakeStreamer struct {
+	mu      sync.Mutex
+	streams []*fakeStream
+	// vgtids are the positions the VStream calls started from.
+	vgtids []*binlogdatapb.VGtid
+}
+
+// VStream is part of the VStreamer interface.
+func (fs *fakeStreamer) VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags) (vtgateconn.VStreamReader, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.vgtids = append(fs.vgtids, vgtid)
+	stream := &fakeStream{}
+	if len(fs.streams) > 0 {
+		stream, fs.streams = fs.streams[0], fs.streams[1:]
+	}
+	return &fakeReader{ctx: ctx, stream: stream}, nil
+}
+
+func (fs *fakeStreamer) startPositions() []*binlogdatapb.VGtid {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.vgtids
+}
+
+type fakeReader struct {
+	ctx    context.Context
+	stream *fakeStream
+}
+
+// Recv is part of the VStreamReader interface.
+func (fr *fakeReader) Recv() ([]*binlogdatapb.VEvent, error) {
+	if len(fr.stream.batches) > 0 {
+		batch := fr.stream.batches[0]
+		fr.stream.batches = fr.stream.batches[1:]
+		return batch, nil
+	}
+	if fr.stream.err != nil {
+		return nil, fr.stream.err
+	}
+	<-fr.ctx.Done()
+	return nil, fr.ctx.Err()
+}
+
+var testFields = func() []*querypb.Field {
+	fields := sqltypes.MakeTestFields("id|name", "int64|varchar")
+	fields[0].Flags |= uint32(querypb.MySqlFlag_PRI_KEY_FLAG)
+	return fields
+}()
+
+func fieldEvent() *binlogdatapb.VEvent {
+	return &binlogdatapb.VEvent{
+		Type:     binlogdatapb.VEventType_FIELD,
+		Keyspace: "ks",
+		Shard:    "0",
+		FieldEvent: &binlogdatapb.FieldEvent{
+			TableName: "ks.t1",
+			Fields:    testFields,
+			Keyspace:  "ks",
+			Shard:     "0",
+		},
+	}
+}
+
+func testRow(id int64, name string) *querypb.Row {
+	return sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(id), sqltypes.NewVarChar(name)})
+}
+
+func rowEvent(changes ...*binlogdatapb.RowChange) *binlogdatapb.VEvent {
+	return &binlogdatapb.VEvent{
+		Type:      binlogdatapb.VEventType_ROW,
+		Keyspace:  "ks",
+		Shard:     "0",
+		Timestamp: 1700000000,
+		RowEvent: &binlogdatapb.RowEvent{
+			TableName:  "ks.t1",
+			RowChanges: changes,
+			Keyspace:   "ks",
+			Shard:      "0",
+		},
+	}
+}
+
+func vgtidEvent(gtid string, copying bool) *binlogdatapb.VEvent {
+	sgtid := &binlogdatapb.ShardGtid{Keyspace: "ks", Shard: "0", Gtid: gtid}
+	if copying {
+		sgtid.TablePKs = []*binlogdatapb.TableLastPK{{TableName: "t1"}}
+	}
+	return &binlogdatapb.VEvent{
+		Type:  binlogdatapb.VEventType_VGTID,
+		Vgtid: &binlogdatapb.VGtid{ShardGtids: []*binlogdatapb.ShardGtid{sgtid}},
+	}
+}
+
+func txn(events ...*binlogdatapb.VEvent) []*binlogdatapb.VEvent {
+	events = append([]*binlogdatapb.VEvent{{Type: binlogdatapb.VEventType_BEGIN}}, events...)
+	return append(events, &binlogdatapb.VEvent{Type: binlogdatapb.VEventType_COMMIT})
+}
+
+// fakeProducer is a Producer that keeps the records it publishes.
+type fakeProducer struct {
+	mu      sync.Mutex
+	records []*Record
+}
+
+// Produce is part of the Producer interface.
+func (fp *fakeProducer) Produce(ctx context.Context, records []*Record) error {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+	fp.records = append(fp.records, records...)
+	return nil
+}
+
+// Close is part of the Producer interface.
+func (fp *fakeProducer) Close() {}
+
+// consume returns the first count records published to the topic.
+func (fp *fakeProducer) consume(t *testing.T, topic string, count int) []*Record {
+	var records []*Record
+	require.Eventually(t, func() bool {
+		fp.mu.Lock()
+		defer fp.mu.Unlock()
+		records = nil
+		for _, record := range fp.records {
+			if record.Topic == topic {
+				records = append(records, record)
+			}
+		}
+		return len(records) >= count
+	}, 10*time.Second, 10*time.Millisecond)
+	return records[:count]
+}
+
+// testEvent is the part of an envelope the tests check.
+type testEvent struct {
+	Before map[string]any `json:"before"`
+	After  map[string]any `json:"after"`
+	Source struct {
+		Name     string `json:"name"`
+		Snapshot string `json:"snapshot"`
+		Keyspace string `json:"keyspace"`
+		Table    string `json:"table"`
+		Shard    string `json:"shard"`
+		TsMs     int64  `json:"ts_ms"`
+	} `json:"source"`
+	Op string `json:"op"`
+}
+
+func TestSink(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	producer := &fakeProducer{}
+
+	streamer := &fakeStreamer{streams: []*fakeStream{{
+		batches: [][]*binlogdatapb.VEvent{
+			// The copy phase.
+			append([]*binlogdatapb.VEvent{fieldEvent()}, txn(
+				rowEvent(&binlogdatapb.RowChange{After: testRow(1, "a")}, &binlogdatapb.RowChange{After: testRow(2, "b")}),
+				vgtidEvent("pos1", true),
+			)...),
+			txn(vgtidEvent("pos1", false)),
+			{{Type: binlogdatapb.VEventType_COPY_COMPLETED, Keyspace: "ks", Shard: "0"}, {Type: binlogdatapb.VEventType_COPY_COMPLETED}},
+			// Changes.
+			txn(
+				rowEvent(&binlogdatapb.RowChange{After: testRow(3, "c")}),
+				rowEvent(&binlogdatapb.RowChange{Before: testRow(1, "a"), After: testRow(1, "A")}),
+				rowEvent(&binlogdatapb.RowChange{Before: testRow(2, "b")}),
+				vgtidEvent("pos2", false),
+			),
+			// A transaction the stream fails in the middle of.
+			{{Type: binlogdatapb.VEventType_BEGIN}, rowEvent(&binlogdatapb.RowChange{After: testRow(4, "d")})},
+		},
+		err: errors.New("stream broke"),
+	}, {
+		batches: [][]*binlogdatapb.VEvent{
+			append([]*binlogdatapb.VEvent{fieldEvent()}, txn(
+				rowEvent(&binlogdatapb.RowChange{After: testRow(4, "d")}),
+				vgtidEvent("pos3", false),
+			)...),
+		},
+	}}}
+
+	checkpointFile := path.Join(t.TempDir(), "checkpoint.json")
+	config := Config{
+		Keyspace:           "ks",
+		TabletType:         topodatapb.TabletType_PRIMARY,
+		TopicPrefix:        "vt",
+		Snapshot:           true,
+		TombstonesOnDelete: true,
+		CheckpointInterval: time.Hour,
+		RetryDelay:         10 * time.Millisecond,
+	}
+	sink := NewSink(config, streamer, producer, NewFileCheckpointStore(checkpointFile))
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error)
+	go func() {
+		done <- sink.Run(runCtx)
+	}()
+
+	records := producer.consume(t, "vt.ks.t1", 7)
+	stop()
+	require.NoError(t, <-done)
+
+	type published struct {
+		key string
+		op  string
+	}
+	var got []published
+	for _, record := range records {
+		if record.Value == nil {
+			got = append(got, published{key: string(record.Key), op: "tombstone"})
+			continue
+		}
+		var event testEvent
+		require.NoError(t, json.Unmarshal(record.Value, &event))
+		assert.Equal(t, "vt", event.Source.Name)
+		assert.Equal(t, "ks", event.Source.Keyspace)
+		assert.Equal(t, "t1", event.Source.Table)
+		assert.Equal(t, "0", event.Source.Shard)
+		assert.Equal(t, event.Op == OpRead, event.Source.Snapshot == "true")
+		if event.Op != OpRead {
+			assert.EqualValues(t, 1700000000000, event.Source.TsMs)
+		}
+		switch event.Op {
+		case OpUpdate:
+			assert.Equal(t, map[string]any{"id": float64(1), "name": "a"}, event.Before)
+			assert.Equal(t, map[string]any{"id": float64(1), "name": "A"}, event.After)
+		case OpDelete:
+			assert.Nil(t, event.After)
+		default:
+			assert.Nil(t, event.Before)
+		}
+		got = append(got, published{key: string(record.Key), op: event.Op})
+	}
+	assert.ElementsMatch(t, []published{
+		{`{"id":1}`, OpRead},
+		{`{"id":2}`, OpRead},
+		{`{"id":3}`, OpCreate},
+		{`{"id":1}`, OpUpdate},
+		{`{"id":2}`, OpDelete},
+		{`{"id":2}`, "tombstone"},
+		{`{"id":4}`, OpCreate},
+	}, got)
+
+	// The failed stream resumed from the last published transaction.
+	positions := streamer.startPositions()
+	require.Len(t, positions, 2)
+	assert.Equal(t, "", positions[0].ShardGtids[0].Gtid)
+	assert.Equal(t, "pos2", positions[1].ShardGtids[0].Gtid)
+
+	// The position was saved on exit, a new sink resumes from there.
+	streamer = &fakeStreamer{}
+	sink = NewSink(config, streamer, producer, NewFileCheckpointStore(checkpointFile))
+	runCtx, stop = context.WithCancel(ctx)
+	go func() {
+		done <- sink.Run(runCtx)
+	}()
+	assert.Eventually(t, func() bool {
+		return len(streamer.startPositions()) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	stop()
+	require.NoError(t, <-done)
+	assert.Equal(t, "pos3", streamer.startPositions()[0].ShardGtids[0].Gtid)
+}
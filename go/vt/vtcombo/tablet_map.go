@@ -994,6 +994,10 @@ func (itmc *internalTabletManagerClient) RestoreFromBackup(context.Context, *top
 	return nil, fmt.Errorf("not implemented in vtcombo")
 }
 
+func (itmc *internalTabletManagerClient) VerifyBackup(context.Context, *topodatapb.Tablet, *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	return nil, fmt.Errorf("not implemented in vtcombo")
+}
+
 func (itmc *internalTabletManagerClient) CheckThrottler(context.Context, *topodatapb.Tablet, *tabletmanagerdatapb.CheckThrottlerRequest) (*tabletmanagerdatapb.CheckThrottlerResponse, error) {
 	return nil, fmt.Errorf("not implemented in vtcombo")
 }
@@ -0,0 +1,293 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backuppolicy evaluates the backup policies of keyspaces: when the
+// shards of a keyspace are due for a backup, and which of their backups the
+// policy keeps.
+package backuppolicy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/mysqlctl"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/proto/vttime"
+)
+
+// Backup is what a policy needs to know about a backup.
+type Backup struct {
+	Name        string
+	Time        time.Time
+	Incremental bool
+	// FromBackup is the name of the backup that an incremental backup builds
+	// on, if known.
+	FromBackup string
+}
+
+// IsEmpty returns true if the policy neither schedules nor prunes backups.
+func IsEmpty(policy *topodatapb.BackupPolicy) bool {
+	return policy == nil || proto.Equal(policy, &topodatapb.BackupPolicy{})
+}
+
+// Validate checks that the durations of the policy make sense.
+func Validate(policy *topodatapb.BackupPolicy) error {
+	for name, d := range map[string]*vttime.Duration{
+		"full_backup_interval":        policy.GetFullBackupInterval(),
+		"incremental_backup_interval": policy.GetIncrementalBackupInterval(),
+		"incremental_retention":       policy.GetIncrementalRetention(),
+	} {
+		v, _, err := protoutil.DurationFromProto(d)
+		if err != nil {
+			return vterrors.Wrapf(err, "invalid %s", name)
+		}
+		if v < 0 {
+			return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "%s cannot be negative: %v", name, v)
+		}
+	}
+	if policy.GetConcurrency() < 0 {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "concurrency cannot be negative: %v", policy.GetConcurrency())
+	}
+	return nil
+}
+
+// prunes returns true if the policy has any retention rule. A policy
+// without one keeps every backup.
+func prunes(policy *topodatapb.BackupPolicy) bool {
+	return policy.GetKeepLast() > 0 || policy.GetKeepDaily() > 0 || policy.GetKeepWeekly() > 0 || policy.GetKeepMonthly() > 0
+}
+
+// duration returns the value of a validated duration field.
+func duration(d *vttime.Duration) time.Duration {
+	v, _, _ := protoutil.DurationFromProto(d)
+	return v
+}
+
+// LoadBackups lists the backups of a shard, oldest first. Backups whose
+// manifest can't be read, such as the ones still in progress, are left
+// out: a policy neither counts nor prunes them.
+func LoadBackups(ctx context.Context, bs backupstorage.BackupStorage, keyspace, shard string) ([]Backup, error) {
+	bhs, err := bs.ListBackups(ctx, path.Join(keyspace, shard))
+	if err != nil {
+		return nil, vterrors.Wrap(err, "ListBackups failed")
+	}
+	return readBackups(ctx, bhs), nil
+}
+
+// PrunableBackups returns the names of the backups of a shard that the
+// policy does not keep, given all the backup handles of the shard. The
+// manifests are only read if the policy prunes backups.
+func PrunableBackups(ctx context.Context, policy *topodatapb.BackupPolicy, bhs []backupstorage.BackupHandle, now time.Time) []string {
+	if !prunes(policy) {
+		return nil
+	}
+	return Prune(policy, readBackups(ctx, bhs), now)
+}
+
+// readBackups reads the manifests of the backups, see LoadBackups.
+func readBackups(ctx context.Context, bhs []backupstorage.BackupHandle) []Backup {
+	backups := make([]Backup, 0, len(bhs))
+	for _, bh := range bhs {
+		manifest, err := mysqlctl.GetBackupManifest(ctx, bh)
+		if err != nil {
+			log.Warningf("Skipping backup %v/%v: %v", bh.Directory(), bh.Name(), err)
+			continue
+		}
+		backupTime, err := time.Parse(time.RFC3339, manifest.BackupTime)
+		if err != nil {
+			// Older manifests may not have the time; fall back to the name,
+			// which starts with the backup time.
+			if len(bh.Name()) < len(mysqlctl.BackupTimestampFormat) {
+				log.Warningf("Skipping backup %v/%v: no backup time", bh.Directory(), bh.Name())
+				continue
+			}
+			backupTime, err = time.Parse(mysqlctl.BackupTimestampFormat, bh.Name()[:len(mysqlctl.BackupTimestampFormat)])
+			if err != nil {
+				log.Warningf("Skipping backup %v/%v: %v", bh.Directory(), bh.Name(), err)
+				continue
+			}
+		}
+		backups = append(backups, Backup{
+			Name:        bh.Name(),
+			Time:        backupTime,
+			Incremental: manifest.Incremental,
+			FromBackup:  manifest.FromBackup,
+		})
+	}
+	sortBackups(backups)
+	return backups
+}
+
+func sortBackups(backups []Backup) {
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Time.Before(backups[j].Time)
+	})
+}
+
+// NextBackup returns whether the policy wants a backup taken now, given the
+// backups a shard already has, and if so whether it is an incremental one.
+func NextBackup(policy *topodatapb.BackupPolicy, backups []Backup, now time.Time) (due bool, incremental bool) {
+	backups = append([]Backup(nil), backups...)
+	sortBackups(backups)
+
+	var latest, latestFull *Backup
+	chainLength := 0
+	for i := range backups {
+		latest = &backups[i]
+		if backups[i].Incremental {
+			chainLength++
+		} else {
+			latestFull = &backups[i]
+			chainLength = 0
+		}
+	}
+
+	fullInterval := duration(policy.GetFullBackupInterval())
+	if fullInterval > 0 && (latestFull == nil || now.Sub(latestFull.Time) >= fullInterval) {
+		return true, false
+	}
+	incrementalInterval := duration(policy.GetIncrementalBackupInterval())
+	if incrementalInterval <= 0 || latestFull == nil || now.Sub(latest.Time) < incrementalInterval {
+		return false, false
+	}
+	if maxChainLength := policy.GetMaxIncrementalChainLength(); maxChainLength > 0 && uint32(chainLength) >= maxChainLength {
+		return true, false
+	}
+	return true, true
+}
+
+// Prune returns the names of the backups that the policy does not keep,
+// oldest first. Full backups are kept by the KeepLast, KeepDaily,
+// KeepWeekly and KeepMonthly rules, and the latest full backup is always
+// kept. Incremental backups are kept along with the full backup they build
+// on, or for IncrementalRetention if set. Whatever a kept incremental
+// backup depends on is kept too, so that pruning never breaks a chain.
+func Prune(policy *topodatapb.BackupPolicy, backups []Backup, now time.Time) []string {
+	if !prunes(policy) {
+		return nil
+	}
+	backups = append([]Backup(nil), backups...)
+	sortBackups(backups)
+
+	byName := make(map[string]int, len(backups))
+	for i, b := range backups {
+		byName[b.Name] = i
+	}
+	// dependency returns the index of the backup that the given incremental
+	// backup builds on, or -1. Without a recorded FromBackup, we assume the
+	// backup right before it.
+	dependency := func(i int) int {
+		if !backups[i].Incremental {
+			return -1
+		}
+		if backups[i].FromBackup != "" {
+			if j, ok := byName[backups[i].FromBackup]; ok && j < i {
+				return j
+			}
+			return -1
+		}
+		return i - 1
+	}
+	baseFull := func(i int) int {
+		for i >= 0 && backups[i].Incremental {
+			i = dependency(i)
+		}
+		return i
+	}
+
+	keep := make([]bool, len(backups))
+	var fulls []int // newest first
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !backups[i].Incremental {
+			fulls = append(fulls, i)
+		}
+	}
+	if len(fulls) > 0 {
+		keep[fulls[0]] = true
+	}
+	for n := 0; n < len(fulls) && n < int(policy.GetKeepLast()); n++ {
+		keep[fulls[n]] = true
+	}
+	keepPeriods := func(count uint32, period func(t time.Time) string) {
+		seen := map[string]bool{}
+		for _, i := range fulls {
+			if len(seen) >= int(count) {
+				return
+			}
+			p := period(backups[i].Time.UTC())
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			keep[i] = true
+		}
+	}
+	keepPeriods(policy.GetKeepDaily(), func(t time.Time) string { return t.Format("2006-01-02") })
+	keepPeriods(policy.GetKeepWeekly(), func(t time.Time) string {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%d", year, week)
+	})
+	keepPeriods(policy.GetKeepMonthly(), func(t time.Time) string { return t.Format("2006-01") })
+
+	latestFull := -1
+	if len(fulls) > 0 {
+		latestFull = fulls[0]
+	}
+	retention := duration(policy.GetIncrementalRetention())
+	for i, b := range backups {
+		if !b.Incremental {
+			continue
+		}
+		base := baseFull(i)
+		switch {
+		case base >= 0 && base == latestFull:
+			keep[i] = true
+		case retention > 0:
+			keep[i] = now.Sub(b.Time) < retention
+		default:
+			keep[i] = base >= 0 && keep[base]
+		}
+	}
+
+	// Never break a chain: keep whatever a kept incremental backup depends on.
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !keep[i] {
+			continue
+		}
+		for j := dependency(i); j >= 0 && !keep[j]; j = dependency(j) {
+			keep[j] = true
+		}
+	}
+
+	var pruned []string
+	for i, b := range backups {
+		if !keep[i] {
+			pruned = append(pruned, b.Name)
+		}
+	}
+	return pruned
+}
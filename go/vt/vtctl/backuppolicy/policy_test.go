@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuppolicy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"vitess.io/vitess/go/protoutil"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+var now = time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func full(name string, ago time.Duration) Backup {
+	return Backup{Name: name, Time: now.Add(-ago)}
+}
+
+func incremental(name string, ago time.Duration, from string) Backup {
+	return Backup{Name: name, Time: now.Add(-ago), Incremental: true, FromBackup: from}
+}
+
+const day = 24 * time.Hour
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil))
+	assert.NoError(t, Validate(&topodatapb.BackupPolicy{FullBackupInterval: protoutil.DurationToProto(day)}))
+	assert.EqualError(t, Validate(&topodatapb.BackupPolicy{IncrementalRetention: protoutil.DurationToProto(-day)}), "incremental_retention cannot be negative: -24h0m0s")
+	assert.EqualError(t, Validate(&topodatapb.BackupPolicy{Concurrency: -1}), "concurrency cannot be negative: -1")
+}
+
+func TestNextBackup(t *testing.T) {
+	policy := &topodatapb.BackupPolicy{
+		FullBackupInterval:        protoutil.DurationToProto(day),
+		IncrementalBackupInterval: protoutil.DurationToProto(time.Hour),
+		MaxIncrementalChainLength: 2,
+	}
+	tcases := []struct {
+		name        string
+		policy      *topodatapb.BackupPolicy
+		backups     []Backup
+		due         bool
+		incremental bool
+	}{
+		{
+			name:   "no backups",
+			policy: policy,
+			due:    true,
+		},
+		{
+			name:    "full backup is due",
+			policy:  policy,
+			backups: []Backup{full("f1", 25*time.Hour)},
+			due:     true,
+		},
+		{
+			name:        "incremental backup is due",
+			policy:      policy,
+			backups:     []Backup{full("f1", 2*time.Hour)},
+			due:         true,
+			incremental: true,
+		},
+		{
+			name:    "nothing is due",
+			policy:  policy,
+			backups: []Backup{full("f1", 2*time.Hour), incremental("i1", 30*time.Minute, "f1")},
+		},
+		{
+			name:    "chain is full",
+			policy:  policy,
+			backups: []Backup{full("f1", 5*time.Hour), incremental("i1", 3*time.Hour, "f1"), incremental("i2", 2*time.Hour, "i1")},
+			due:     true,
+		},
+		{
+			name:   "incremental backups need a full backup",
+			policy: &topodatapb.BackupPolicy{IncrementalBackupInterval: protoutil.DurationToProto(time.Hour)},
+		},
+		{
+			name:    "no schedule",
+			policy:  &topodatapb.BackupPolicy{KeepLast: 3},
+			backups: []Backup{full("f1", 50*day)},
+		},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			due, incremental := NextBackup(tcase.policy, tcase.backups, now)
+			assert.Equal(t, tcase.due, due)
+			assert.Equal(t, tcase.incremental, incremental)
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tcases := []struct {
+		name    string
+		policy  *topodatapb.BackupPolicy
+		backups []Backup
+		pruned  []string
+	}{
+		{
+			name:    "no retention rule",
+			policy:  &topodatapb.BackupPolicy{FullBackupInterval: protoutil.DurationToProto(day)},
+			backups: []Backup{full("f1", 3*day), full("f2", 2*day), full("f3", day)},
+		},
+		{
+			name:    "keep last",
+			policy:  &topodatapb.BackupPolicy{KeepLast: 2},
+			backups: []Backup{full("f1", 3*day), full("f2", 2*day), full("f3", day)},
+			pruned:  []string{"f1"},
+		},
+		{
+			name:   "keep daily",
+			policy: &topodatapb.BackupPolicy{KeepDaily: 2},
+			backups: []Backup{
+				full("f1", 2*day+time.Hour),
+				full("f2", day+2*time.Hour),
+				full("f3", day+time.Hour),
+				full("f4", time.Hour),
+			},
+			pruned: []string{"f1", "f2"},
+		},
+		{
+			name:   "keep weekly and monthly",
+			policy: &topodatapb.BackupPolicy{KeepWeekly: 1, KeepMonthly: 2},
+			backups: []Backup{
+				full("feb", 30*day),
+				full("early-march", 12*day),
+				full("march", day),
+				full("latest", 0),
+			},
+			pruned: []string{"early-march", "march"},
+		},
+		{
+			name:   "incremental backups follow their full backup",
+			policy: &topodatapb.BackupPolicy{KeepLast: 1},
+			backups: []Backup{
+				full("f1", 3*day),
+				incremental("i1", 2*day, "f1"),
+				full("f2", day),
+				incremental("i2", 12*time.Hour, "f2"),
+			},
+			pruned: []string{"f1", "i1"},
+		},
+		{
+			name:   "a kept incremental backup keeps its chain",
+			policy: &topodatapb.BackupPolicy{KeepLast: 1, IncrementalRetention: protoutil.DurationToProto(2 * day)},
+			backups: []Backup{
+				full("f1", 5*day),
+				incremental("i1", 4*day, "f1"),
+				incremental("i2", 3*day, "i1"),
+				incremental("i3", day+time.Hour, "i2"),
+				full("f2", day),
+			},
+			pruned: nil,
+		},
+		{
+			name:   "expired incremental backups go away",
+			policy: &topodatapb.BackupPolicy{KeepLast: 2, IncrementalRetention: protoutil.DurationToProto(day)},
+			backups: []Backup{
+				full("f1", 5*day),
+				incremental("i1", 4*day, "f1"),
+				incremental("i2", 3*day, "i1"),
+				full("f2", 2*day),
+				incremental("i3", day+time.Hour, "f2"),
+			},
+			pruned: []string{"i1", "i2"},
+		},
+		{
+			name:   "without a recorded base, an incremental backup depends on the previous backup",
+			policy: &topodatapb.BackupPolicy{KeepLast: 1, IncrementalRetention: protoutil.DurationToProto(3 * day)},
+			backups: []Backup{
+				full("f0", 6*day),
+				full("f1", 5*day),
+				incremental("i1", 4*day, ""),
+				incremental("i2", 2*day, ""),
+				full("f2", day),
+			},
+			pruned: []string{"f0"},
+		},
+		{
+			name:   "the latest chain is always kept",
+			policy: &topodatapb.BackupPolicy{KeepLast: 1, IncrementalRetention: protoutil.DurationToProto(time.Hour)},
+			backups: []Backup{
+				full("f1", 3*day),
+				incremental("i1", 2*day, "f1"),
+			},
+		},
+	}
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			assert.Equal(t, tcase.pruned, Prune(tcase.policy, tcase.backups, now))
+		})
+	}
+}
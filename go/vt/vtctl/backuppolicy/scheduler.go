@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuppolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"vitess.io/vitess/go/timer"
+	"vitess.io/vitess/go/vt/concurrency"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/mysqlctl"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vtctl/vtctldclient"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+)
+
+// defaultConcurrency is used when a policy does not set a concurrency, and
+// matches the default of `vtctldclient BackupShard`.
+const defaultConcurrency = 4
+
+// Scheduler periodically applies the backup policies stored in the topo:
+// it takes the backups that are due, and prunes the ones that are no
+// longer kept. Each shard is handled under a topo lock, so that several
+// vtctlds can run schedulers side by side.
+type Scheduler struct {
+	ts     *topo.Server
+	client vtctldclient.VtctldClient
+	timer  *timer.Timer
+	// shardConcurrency is how many shards are handled at the same time.
+	shardConcurrency int
+
+	// now is replaced in tests.
+	now func() time.Time
+}
+
+// NewScheduler returns a Scheduler that checks the backup policies every
+// interval, and runs backups and prunes through client, for up to
+// shardConcurrency shards at a time.
+func NewScheduler(ts *topo.Server, client vtctldclient.VtctldClient, interval time.Duration, shardConcurrency int) *Scheduler {
+	return &Scheduler{
+		ts:               ts,
+		client:           client,
+		timer:            timer.NewTimer(interval),
+		shardConcurrency: max(shardConcurrency, 1),
+		now:              time.Now,
+	}
+}
+
+// Open starts checking the backup policies in the background.
+func (s *Scheduler) Open() {
+	s.timer.Start(func() {
+		if err := s.Run(context.Background()); err != nil {
+			log.Errorf("Backup policy scheduler: %v", err)
+		}
+	})
+}
+
+// Close stops the scheduler, and waits for a run in progress to finish.
+func (s *Scheduler) Close() {
+	s.timer.Stop()
+}
+
+// Run applies the backup policy of every keyspace once. The shards are
+// handled concurrently, and the failure of one does not stop the others.
+func (s *Scheduler) Run(ctx context.Context) error {
+	keyspaces, err := s.ts.GetKeyspaces(ctx)
+	if err != nil {
+		return err
+	}
+	rec := concurrency.AllErrorRecorder{}
+	var eg errgroup.Group
+	eg.SetLimit(s.shardConcurrency)
+	for _, keyspace := range keyspaces {
+		ki, err := s.ts.GetKeyspace(ctx, keyspace)
+		if err != nil {
+			rec.RecordError(err)
+			continue
+		}
+		if IsEmpty(ki.BackupPolicy) {
+			continue
+		}
+		if err := Validate(ki.BackupPolicy); err != nil {
+			rec.RecordError(fmt.Errorf("keyspace %v: %w", keyspace, err))
+			continue
+		}
+		shards, err := s.ts.GetShardNames(ctx, keyspace)
+		if err != nil {
+			rec.RecordError(err)
+			continue
+		}
+		for _, shard := range shards {
+			eg.Go(func() error {
+				if err := s.runShard(ctx, keyspace, shard, ki.BackupPolicy); err != nil {
+					rec.RecordError(fmt.Errorf("shard %v/%v: %w", keyspace, shard, err))
+				}
+				return nil
+			})
+		}
+	}
+	_ = eg.Wait()
+	return rec.Error()
+}
+
+func (s *Scheduler) runShard(ctx context.Context, keyspace, shard string, policy *topodatapb.BackupPolicy) (err error) {
+	ctx, unlock, err := s.ts.LockName(ctx, path.Join("backup-policy", keyspace, shard), "BackupPolicy")
+	if err != nil {
+		return err
+	}
+	defer unlock(&err)
+
+	bs, err := backupstorage.GetBackupStorage()
+	if err != nil {
+		return err
+	}
+	defer bs.Close()
+
+	backups, err := LoadBackups(ctx, bs, keyspace, shard)
+	if err != nil {
+		return err
+	}
+	if due, incremental := NextBackup(policy, backups, s.now()); due {
+		if err := s.backup(ctx, keyspace, shard, policy, incremental); err != nil {
+			return err
+		}
+		if backups, err = LoadBackups(ctx, bs, keyspace, shard); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range Prune(policy, backups, s.now()) {
+		log.Infof("Backup policy of keyspace %v prunes backup %v/%v", keyspace, shard, name)
+		if _, err := s.client.RemoveBackup(ctx, &vtctldatapb.RemoveBackupRequest{
+			Keyspace: keyspace,
+			Shard:    shard,
+			Name:     name,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) backup(ctx context.Context, keyspace, shard string, policy *topodatapb.BackupPolicy, incremental bool) error {
+	req := &vtctldatapb.BackupShardRequest{
+		Keyspace:     keyspace,
+		Shard:        shard,
+		AllowPrimary: policy.AllowPrimary,
+		Concurrency:  policy.Concurrency,
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = defaultConcurrency
+	}
+	if incremental {
+		req.IncrementalFromPos = mysqlctl.AutoIncrementalFromPos
+	}
+	log.Infof("Backup policy of keyspace %v takes a backup of shard %v (incremental: %v)", keyspace, shard, incremental)
+
+	stream, err := s.client.BackupShard(ctx, req)
+	if err != nil {
+		return err
+	}
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
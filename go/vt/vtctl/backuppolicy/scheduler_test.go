@@ -0,0 +1,252 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backuppolicy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/vt/mysqlctl"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
+	"vitess.io/vitess/go/vt/mysqlctl/filebackupstorage"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/vtctl/vtctldclient"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtctlservicepb "vitess.io/vitess/go/vt/proto/vtctlservice"
+)
+
+// fakeClient takes backups by writing their manifest to backup storage.
+type fakeClient struct {
+	vtctldclient.VtctldClient
+
+	t   *testing.T
+	now func() time.Time
+	// backupDuration is how long a backup takes.
+	backupDuration time.Duration
+
+	mu      sync.Mutex
+	backups []*vtctldatapb.BackupShardRequest
+	removed []string
+	// running and maxRunning are the number of backups in progress, now
+	// and at most.
+	running    int
+	maxRunning int
+}
+
+func (c *fakeClient) BackupShard(ctx context.Context, req *vtctldatapb.BackupShardRequest, opts ...grpc.CallOption) (vtctlservicepb.Vtctld_BackupShardClient, error) {
+	c.mu.Lock()
+	c.backups = append(c.backups, req)
+	c.running++
+	c.maxRunning = max(c.maxRunning, c.running)
+	c.mu.Unlock()
+	time.Sleep(c.backupDuration)
+	addBackup(c.t, req.Keyspace, req.Shard, c.now(), req.IncrementalFromPos != "")
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+	return &backupStream{}, nil
+}
+
+func (c *fakeClient) RemoveBackup(ctx context.Context, req *vtctldatapb.RemoveBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.RemoveBackupResponse, error) {
+	c.mu.Lock()
+	c.removed = append(c.removed, req.Name)
+	c.mu.Unlock()
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(c.t, err)
+	defer bs.Close()
+	return &vtctldatapb.RemoveBackupResponse{}, bs.RemoveBackup(ctx, path.Join(req.Keyspace, req.Shard), req.Name)
+}
+
+type backupStream struct {
+	vtctlservicepb.Vtctld_BackupShardClient
+}
+
+func (s *backupStream) Recv() (*vtctldatapb.BackupResponse, error) {
+	return nil, io.EOF
+}
+
+func addBackup(t *testing.T, keyspace, shard string, backupTime time.Time, incremental bool) {
+	ctx := context.Background()
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	defer bs.Close()
+
+	name := backupTime.UTC().Format(mysqlctl.BackupTimestampFormat) + ".zone1-0000000101"
+	bh, err := bs.StartBackup(ctx, path.Join(keyspace, shard), name)
+	require.NoError(t, err)
+	wc, err := bh.AddFile(ctx, "MANIFEST", backupstorage.FileSizeUnknown)
+	require.NoError(t, err)
+	require.NoError(t, json.NewEncoder(wc).Encode(&mysqlctl.BackupManifest{
+		BackupName:  name,
+		BackupTime:  backupTime.UTC().Format(time.RFC3339),
+		Incremental: incremental,
+	}))
+	require.NoError(t, wc.Close())
+	require.NoError(t, bh.EndBackup(ctx))
+}
+
+func TestSchedulerRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldImplementation, oldRoot := backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot
+	backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = "file", t.TempDir()
+	defer func() {
+		backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = oldImplementation, oldRoot
+	}()
+
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+	require.NoError(t, ts.CreateKeyspace(ctx, "ks", &topodatapb.Keyspace{
+		BackupPolicy: &topodatapb.BackupPolicy{
+			FullBackupInterval:        protoutil.DurationToProto(24 * time.Hour),
+			IncrementalBackupInterval: protoutil.DurationToProto(time.Hour),
+			KeepLast:                  1,
+		},
+	}))
+	require.NoError(t, ts.CreateShard(ctx, "ks", "0"))
+	// A keyspace without a policy is left alone.
+	require.NoError(t, ts.CreateKeyspace(ctx, "other", &topodatapb.Keyspace{}))
+	require.NoError(t, ts.CreateShard(ctx, "other", "0"))
+	addBackup(t, "other", "0", now.Add(-72*time.Hour), false)
+
+	clock := now
+	client := &fakeClient{t: t, now: func() time.Time { return clock }}
+	s := NewScheduler(ts, client, time.Minute, 1)
+	s.now = func() time.Time { return clock }
+
+	// The first run takes a full backup.
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, client.backups, 1)
+	assert.Empty(t, client.backups[0].IncrementalFromPos)
+	assert.EqualValues(t, defaultConcurrency, client.backups[0].Concurrency)
+
+	// Nothing is due yet.
+	clock = clock.Add(30 * time.Minute)
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, client.backups, 1)
+
+	// Then an incremental backup.
+	clock = clock.Add(time.Hour)
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, client.backups, 2)
+	assert.Equal(t, mysqlctl.AutoIncrementalFromPos, client.backups[1].IncrementalFromPos)
+	assert.Empty(t, client.removed)
+
+	// The next full backup prunes the previous chain.
+	clock = clock.Add(24 * time.Hour)
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, client.backups, 3)
+	assert.Empty(t, client.backups[2].IncrementalFromPos)
+	assert.Len(t, client.removed, 2)
+
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	defer bs.Close()
+	backups, err := LoadBackups(ctx, bs, "ks", "0")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	assert.Equal(t, clock, backups[0].Time)
+
+	backups, err = LoadBackups(ctx, bs, "other", "0")
+	require.NoError(t, err)
+	assert.Len(t, backups, 1)
+}
+
+func TestSchedulerRunShardsConcurrently(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldImplementation, oldRoot := backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot
+	backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = "file", t.TempDir()
+	defer func() {
+		backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = oldImplementation, oldRoot
+	}()
+
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+	require.NoError(t, ts.CreateKeyspace(ctx, "ks", &topodatapb.Keyspace{
+		BackupPolicy: &topodatapb.BackupPolicy{
+			FullBackupInterval: protoutil.DurationToProto(24 * time.Hour),
+		},
+	}))
+	shards := []string{"-40", "40-80", "80-c0", "c0-"}
+	for _, shard := range shards {
+		require.NoError(t, ts.CreateShard(ctx, "ks", shard))
+	}
+
+	client := &fakeClient{t: t, now: func() time.Time { return now }, backupDuration: 100 * time.Millisecond}
+	s := NewScheduler(ts, client, time.Minute, 2)
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Run(ctx))
+	require.Len(t, client.backups, len(shards))
+	assert.Equal(t, 2, client.maxRunning)
+}
+
+// countingHandle counts the files read from a backup.
+type countingHandle struct {
+	backupstorage.BackupHandle
+	reads *int
+}
+
+func (h *countingHandle) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	*h.reads++
+	return h.BackupHandle.ReadFile(ctx, filename)
+}
+
+func TestPrunableBackups(t *testing.T) {
+	ctx := context.Background()
+	oldImplementation, oldRoot := backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot
+	backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = "file", t.TempDir()
+	defer func() {
+		backupstorage.BackupStorageImplementation, filebackupstorage.FileBackupStorageRoot = oldImplementation, oldRoot
+	}()
+
+	addBackup(t, "ks", "0", now.Add(-48*time.Hour), false)
+	addBackup(t, "ks", "0", now.Add(-24*time.Hour), false)
+	bs, err := backupstorage.GetBackupStorage()
+	require.NoError(t, err)
+	defer bs.Close()
+	bhs, err := bs.ListBackups(ctx, "ks/0")
+	require.NoError(t, err)
+	reads := 0
+	for i, bh := range bhs {
+		bhs[i] = &countingHandle{BackupHandle: bh, reads: &reads}
+	}
+
+	// A policy that keeps every backup does not need the manifests.
+	policy := &topodatapb.BackupPolicy{FullBackupInterval: protoutil.DurationToProto(24 * time.Hour)}
+	assert.Empty(t, PrunableBackups(ctx, policy, bhs, now))
+	assert.Zero(t, reads)
+
+	policy.KeepLast = 1
+	assert.Equal(t, []string{bhs[0].Name()}, PrunableBackups(ctx, policy, bhs, now))
+	assert.Equal(t, len(bhs), reads)
+}
@@ -812,6 +812,15 @@ func (client *gRPCVtctldClient) RunHealthCheck(ctx context.Context, in *vtctldat
 	return client.c.RunHealthCheck(ctx, in, opts...)
 }
 
+// SetKeyspaceBackupPolicy is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) SetKeyspaceBackupPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceBackupPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceBackupPolicyResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.SetKeyspaceBackupPolicy(ctx, in, opts...)
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) SetKeyspaceDurabilityPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceDurabilityPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceDurabilityPolicyResponse, error) {
 	if client.c == nil {
@@ -1073,6 +1082,15 @@ func (client *gRPCVtctldClient) ValidateVersionShard(ctx context.Context, in *vt
 	return client.c.ValidateVersionShard(ctx, in, opts...)
 }
 
+// VerifyBackup is part of the vtctlservicepb.VtctldClient interface.
+func (client *gRPCVtctldClient) VerifyBackup(ctx context.Context, in *vtctldatapb.VerifyBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.VerifyBackupResponse, error) {
+	if client.c == nil {
+		return nil, status.Error(codes.Unavailable, connClosedMsg)
+	}
+
+	return client.c.VerifyBackup(ctx, in, opts...)
+}
+
 // WorkflowDelete is part of the vtctlservicepb.VtctldClient interface.
 func (client *gRPCVtctldClient) WorkflowDelete(ctx context.Context, in *vtctldatapb.WorkflowDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowDeleteResponse, error) {
 	if client.c == nil {
@@ -70,6 +70,7 @@ import (
 	"vitess.io/vitess/go/vt/topo/topoproto"
 	"vitess.io/vitess/go/vt/topotools"
 	"vitess.io/vitess/go/vt/topotools/events"
+	"vitess.io/vitess/go/vt/vtctl/backuppolicy"
 	"vitess.io/vitess/go/vt/vtctl/reparentutil"
 	"vitess.io/vitess/go/vt/vtctl/schematools"
 	"vitess.io/vitess/go/vt/vtctl/workflow"
@@ -1474,9 +1475,30 @@ func (s *VtctldServer) GetBackups(ctx context.Context, req *vtctldatapb.GetBacku
 		backups = append(backups, bi)
 	}
 
-	return &vtctldatapb.GetBackupsResponse{
+	resp = &vtctldatapb.GetBackupsResponse{
 		Backups: backups,
-	}, nil
+	}
+
+	ki, err := s.ts.GetKeyspace(ctx, req.Keyspace)
+	switch {
+	case topo.IsErrType(err, topo.NoNode):
+		// Backups can outlive their keyspace, which then has no policy.
+		return resp, nil
+	case err != nil:
+		return nil, err
+	}
+	if backuppolicy.IsEmpty(ki.BackupPolicy) {
+		return resp, nil
+	}
+	resp.BackupPolicy = ki.BackupPolicy
+
+	// Which backups are prunable depends on all the backups of the shard, whose
+	// manifests we don't read when only the most recent ones are requested.
+	if req.Limit == 0 {
+		resp.PrunableBackups = backuppolicy.PrunableBackups(ctx, ki.BackupPolicy, bhs, time.Now())
+	}
+
+	return resp, nil
 }
 
 // GetCellInfoNames is part of the vtctlservicepb.VtctldServer interface.
@@ -3341,7 +3363,7 @@ func (s *VtctldServer) RemoveBackup(ctx context.Context, req *vtctldatapb.Remove
 	}
 	defer bs.Close()
 
-	if err = bs.RemoveBackup(ctx, bucket, req.Name); err != nil {
+	if err = mysqlctl.RemoveBackup(ctx, bs, bucket, req.Name); err != nil {
 		return nil, err
 	}
 
@@ -3624,6 +3646,47 @@ func (s *VtctldServer) RunHealthCheck(ctx context.Context, req *vtctldatapb.RunH
 	return &vtctldatapb.RunHealthCheckResponse{}, nil
 }
 
+// SetKeyspaceBackupPolicy is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) SetKeyspaceBackupPolicy(ctx context.Context, req *vtctldatapb.SetKeyspaceBackupPolicyRequest) (resp *vtctldatapb.SetKeyspaceBackupPolicyResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.SetKeyspaceBackupPolicy")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+
+	if err = backuppolicy.Validate(req.BackupPolicy); err != nil {
+		return nil, err
+	}
+
+	ctx, unlock, lockErr := s.ts.LockKeyspace(ctx, req.Keyspace, "SetKeyspaceBackupPolicy")
+	if lockErr != nil {
+		err = lockErr
+		return nil, err
+	}
+
+	defer unlock(&err)
+
+	ki, err := s.ts.GetKeyspace(ctx, req.Keyspace)
+	if err != nil {
+		return nil, err
+	}
+
+	ki.BackupPolicy = req.BackupPolicy
+	if backuppolicy.IsEmpty(ki.BackupPolicy) {
+		ki.BackupPolicy = nil
+	}
+
+	err = s.ts.UpdateKeyspace(ctx, ki)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vtctldatapb.SetKeyspaceBackupPolicyResponse{
+		Keyspace: ki.Keyspace,
+	}, nil
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) SetKeyspaceDurabilityPolicy(ctx context.Context, req *vtctldatapb.SetKeyspaceDurabilityPolicyRequest) (resp *vtctldatapb.SetKeyspaceDurabilityPolicyResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.SetKeyspaceDurabilityPolicy")
@@ -5119,6 +5182,69 @@ func (s *VtctldServer) ValidateVSchema(ctx context.Context, req *vtctldatapb.Val
 	return resp, err
 }
 
+// VerifyBackup is part of the vtctlservicepb.VtctldServer interface.
+func (s *VtctldServer) VerifyBackup(ctx context.Context, req *vtctldatapb.VerifyBackupRequest) (resp *vtctldatapb.VerifyBackupResponse, err error) {
+	span, ctx := trace.NewSpan(ctx, "VtctldServer.VerifyBackup")
+	defer span.Finish()
+
+	defer panicHandler(&err)
+
+	span.Annotate("keyspace", req.Keyspace)
+	span.Annotate("shard", req.Shard)
+	span.Annotate("backup_name", req.BackupName)
+	span.Annotate("concurrency", req.Concurrency)
+
+	var tablet *topodatapb.Tablet
+	if req.TabletAlias != nil {
+		ti, err := s.ts.GetTablet(ctx, req.TabletAlias)
+		if err != nil {
+			return nil, err
+		}
+		if ti.Keyspace != req.Keyspace || ti.Shard != req.Shard {
+			err = vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "tablet %v is not in shard %v/%v", topoproto.TabletAliasString(req.TabletAlias), req.Keyspace, req.Shard)
+			return nil, err
+		}
+		tablet = ti.Tablet
+	} else {
+		tabletMap, err := s.ts.GetTabletMapForShard(ctx, req.Keyspace, req.Shard)
+		if err != nil {
+			return nil, err
+		}
+		// Verifying a backup needs disk space and IO, so keep it away from the primary.
+		aliases := make([]string, 0, len(tabletMap))
+		for alias := range tabletMap {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		for _, alias := range aliases {
+			switch ti := tabletMap[alias]; ti.Type {
+			case topodatapb.TabletType_REPLICA, topodatapb.TabletType_RDONLY, topodatapb.TabletType_BACKUP, topodatapb.TabletType_SPARE:
+				tablet = ti.Tablet
+			}
+			if tablet != nil {
+				break
+			}
+		}
+		if tablet == nil {
+			err = vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "no non-primary tablet in shard %v/%v to verify the backup with", req.Keyspace, req.Shard)
+			return nil, err
+		}
+	}
+	span.Annotate("tablet_alias", topoproto.TabletAliasString(tablet.Alias))
+
+	verification, err := s.tmc.VerifyBackup(ctx, tablet, &tabletmanagerdatapb.VerifyBackupRequest{
+		BackupName:  req.BackupName,
+		Concurrency: req.Concurrency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &vtctldatapb.VerifyBackupResponse{
+		TabletAlias:  tablet.Alias,
+		Verification: verification,
+	}, nil
+}
+
 // VDiffCreate is part of the vtctlservicepb.VtctldServer interface.
 func (s *VtctldServer) VDiffCreate(ctx context.Context, req *vtctldatapb.VDiffCreateRequest) (resp *vtctldatapb.VDiffCreateResponse, err error) {
 	span, ctx := trace.NewSpan(ctx, "VtctldServer.VDiffCreate")
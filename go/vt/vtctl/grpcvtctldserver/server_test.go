@@ -5468,6 +5468,23 @@ func TestGetBackups(t *testing.T) {
 		assert.Less(t, len(limited.Backups), len(unlimited.Backups), "expected limited backups to be less than unlimited")
 		utils.MustMatch(t, limited.Backups[0], unlimited.Backups[len(unlimited.Backups)-1], "expected limiting to keep N most recent")
 	})
+
+	t.Run("backup policy", func(t *testing.T) {
+		policy := &topodatapb.BackupPolicy{KeepLast: 1}
+		testutil.AddKeyspace(ctx, t, ts, &vtctldatapb.Keyspace{
+			Name:     "testkeyspace",
+			Keyspace: &topodatapb.Keyspace{BackupPolicy: policy},
+		})
+
+		resp, err := vtctld.GetBackups(ctx, &vtctldatapb.GetBackupsRequest{
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+		})
+		require.NoError(t, err)
+		utils.MustMatch(t, policy, resp.BackupPolicy)
+		// Backups without a readable manifest are never pruned.
+		assert.Empty(t, resp.PrunableBackups)
+	})
 }
 
 func TestGetKeyspace(t *testing.T) {
@@ -10988,6 +11005,110 @@ func TestRunHealthCheck(t *testing.T) {
 	}
 }
 
+func TestSetKeyspaceBackupPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := &topodatapb.BackupPolicy{
+		FullBackupInterval: &vttime.Duration{Seconds: 86400},
+		KeepDaily:          7,
+	}
+	tests := []struct {
+		name        string
+		keyspaces   []*vtctldatapb.Keyspace
+		req         *vtctldatapb.SetKeyspaceBackupPolicyRequest
+		expected    *vtctldatapb.SetKeyspaceBackupPolicyResponse
+		expectedErr string
+	}{
+		{
+			name: "ok",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name:     "ks1",
+					Keyspace: &topodatapb.Keyspace{},
+				},
+			},
+			req: &vtctldatapb.SetKeyspaceBackupPolicyRequest{
+				Keyspace:     "ks1",
+				BackupPolicy: policy,
+			},
+			expected: &vtctldatapb.SetKeyspaceBackupPolicyResponse{
+				Keyspace: &topodatapb.Keyspace{
+					BackupPolicy: policy,
+				},
+			},
+		},
+		{
+			name: "empty policy removes it",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name: "ks1",
+					Keyspace: &topodatapb.Keyspace{
+						BackupPolicy: policy,
+					},
+				},
+			},
+			req: &vtctldatapb.SetKeyspaceBackupPolicyRequest{
+				Keyspace:     "ks1",
+				BackupPolicy: &topodatapb.BackupPolicy{},
+			},
+			expected: &vtctldatapb.SetKeyspaceBackupPolicyResponse{
+				Keyspace: &topodatapb.Keyspace{},
+			},
+		},
+		{
+			name: "keyspace not found",
+			req: &vtctldatapb.SetKeyspaceBackupPolicyRequest{
+				Keyspace: "ks1",
+			},
+			expectedErr: "node doesn't exist: keyspaces/ks1",
+		},
+		{
+			name: "negative interval",
+			keyspaces: []*vtctldatapb.Keyspace{
+				{
+					Name:     "ks1",
+					Keyspace: &topodatapb.Keyspace{},
+				},
+			},
+			req: &vtctldatapb.SetKeyspaceBackupPolicyRequest{
+				Keyspace: "ks1",
+				BackupPolicy: &topodatapb.BackupPolicy{
+					IncrementalBackupInterval: &vttime.Duration{Seconds: -60},
+				},
+			},
+			expectedErr: "incremental_backup_interval cannot be negative: -1m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ts := memorytopo.NewServer(ctx, "zone1")
+			testutil.AddKeyspaces(ctx, t, ts, tt.keyspaces...)
+
+			vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, nil, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+				return NewVtctldServer(vtenv.NewTestEnv(), ts)
+			})
+			resp, err := vtctld.SetKeyspaceBackupPolicy(ctx, tt.req)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.expected, resp)
+
+			ki, err := ts.GetKeyspace(ctx, tt.req.Keyspace)
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.expected.Keyspace.BackupPolicy, ki.BackupPolicy)
+		})
+	}
+}
+
 func TestSetKeyspaceDurabilityPolicy(t *testing.T) {
 	t.Parallel()
 
@@ -14420,6 +14541,130 @@ func TestValidateShard(t *testing.T) {
 		})
 	}
 }
+func TestVerifyBackup(t *testing.T) {
+	t.Parallel()
+
+	tablets := []*topodatapb.Tablet{
+		{
+			Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+			Type:     topodatapb.TabletType_PRIMARY,
+		},
+		{
+			Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 101},
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+			Type:     topodatapb.TabletType_DRAINED,
+		},
+		{
+			Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 102},
+			Keyspace: "testkeyspace",
+			Shard:    "-",
+			Type:     topodatapb.TabletType_RDONLY,
+		},
+		{
+			Alias:    &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+			Keyspace: "otherkeyspace",
+			Shard:    "-",
+			Type:     topodatapb.TabletType_REPLICA,
+		},
+	}
+	verification := &tabletmanagerdatapb.VerifyBackupResponse{
+		BackupName:      "2024-01-01.000000.zone1-0000000102",
+		RestoredBackups: []string{"2024-01-01.000000.zone1-0000000102"},
+		Tables: []*tabletmanagerdatapb.VerifyBackupTable{
+			{Name: "vt_testkeyspace.t1", Check: "OK", Checksum: "1", ExpectedChecksum: "1", Ok: true},
+		},
+		Ok: true,
+	}
+
+	tests := []struct {
+		name      string
+		tablets   []*topodatapb.Tablet
+		req       *vtctldatapb.VerifyBackupRequest
+		expected  *vtctldatapb.VerifyBackupResponse
+		shouldErr string
+	}{
+		{
+			name:    "picks a non-primary tablet",
+			tablets: tablets,
+			req: &vtctldatapb.VerifyBackupRequest{
+				Keyspace: "testkeyspace",
+				Shard:    "-",
+			},
+			expected: &vtctldatapb.VerifyBackupResponse{
+				TabletAlias:  &topodatapb.TabletAlias{Cell: "zone1", Uid: 102},
+				Verification: verification,
+			},
+		},
+		{
+			name:    "given tablet",
+			tablets: tablets,
+			req: &vtctldatapb.VerifyBackupRequest{
+				Keyspace:    "testkeyspace",
+				Shard:       "-",
+				TabletAlias: &topodatapb.TabletAlias{Cell: "zone1", Uid: 100},
+			},
+			shouldErr: "backup storage is gone",
+		},
+		{
+			name:    "tablet of another shard",
+			tablets: tablets,
+			req: &vtctldatapb.VerifyBackupRequest{
+				Keyspace:    "testkeyspace",
+				Shard:       "-",
+				TabletAlias: &topodatapb.TabletAlias{Cell: "zone1", Uid: 200},
+			},
+			shouldErr: "is not in shard testkeyspace/-",
+		},
+		{
+			name:    "no non-primary tablet",
+			tablets: tablets[:2],
+			req: &vtctldatapb.VerifyBackupRequest{
+				Keyspace: "testkeyspace",
+				Shard:    "-",
+			},
+			shouldErr: "no non-primary tablet in shard testkeyspace/-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ts := memorytopo.NewServer(ctx, "zone1")
+			defer ts.Close()
+
+			testutil.AddTablets(ctx, t, ts, nil, tt.tablets...)
+			tmc := &testutil.TabletManagerClient{
+				VerifyBackupResults: map[string]struct {
+					Response *tabletmanagerdatapb.VerifyBackupResponse
+					Error    error
+				}{
+					"zone1-0000000100": {Error: errors.New("backup storage is gone")},
+					"zone1-0000000102": {Response: verification},
+				},
+			}
+			vtctld := testutil.NewVtctldServerWithTabletManagerClient(t, ts, tmc, func(ts *topo.Server) vtctlservicepb.VtctldServer {
+				return NewVtctldServer(vtenv.NewTestEnv(), ts)
+			})
+
+			resp, err := vtctld.VerifyBackup(ctx, tt.req)
+			if tt.shouldErr != "" {
+				assert.ErrorContains(t, err, tt.shouldErr)
+				return
+			}
+
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.expected, resp)
+		})
+	}
+}
+
 func TestMain(m *testing.M) {
 	_flag.ParseFlagsForTest()
 	os.Exit(m.Run())
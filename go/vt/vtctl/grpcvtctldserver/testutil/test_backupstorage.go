@@ -19,6 +19,8 @@ package testutil
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 
 	"vitess.io/vitess/go/vt/mysqlctl/backupstorage"
@@ -92,6 +94,12 @@ type backupHandle struct {
 func (bh *backupHandle) Directory() string { return bh.directory }
 func (bh *backupHandle) Name() string      { return bh.name }
 
+// ReadFile is part of the backupstorage.BackupHandle interface. The test
+// backups hold no files.
+func (bh *backupHandle) ReadFile(ctx context.Context, filename string) (io.ReadCloser, error) {
+	return nil, fmt.Errorf("%v/%v/%v: %w", bh.directory, bh.name, filename, os.ErrNotExist)
+}
+
 // handlesByName implements the sort interface for backup handles by Name().
 type handlesByName []backupstorage.BackupHandle
 
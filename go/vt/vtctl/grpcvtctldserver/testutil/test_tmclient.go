@@ -358,6 +358,11 @@ type TabletManagerClient struct {
 	UndoDemotePrimaryDelays map[string]time.Duration
 	// keyed by tablet alias
 	UndoDemotePrimaryResults map[string]error
+	// keyed by tablet alias.
+	VerifyBackupResults map[string]struct {
+		Response *tabletmanagerdatapb.VerifyBackupResponse
+		Error    error
+	}
 	// tablet alias => duration
 	VReplicationExecDelays map[string]time.Duration
 	// tablet alias => query string => result
@@ -1445,6 +1450,24 @@ func (fake *TabletManagerClient) UndoDemotePrimary(ctx context.Context, tablet *
 	return assert.AnError
 }
 
+// VerifyBackup is part of the tmclient.TabletManagerClient interface.
+func (fake *TabletManagerClient) VerifyBackup(ctx context.Context, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	if fake.VerifyBackupResults == nil {
+		return nil, assert.AnError
+	}
+
+	if tablet.Alias == nil {
+		return nil, assert.AnError
+	}
+
+	key := topoproto.TabletAliasString(tablet.Alias)
+	if result, ok := fake.VerifyBackupResults[key]; ok {
+		return result.Response, result.Error
+	}
+
+	return nil, assert.AnError
+}
+
 // VReplicationExec is part of the tmclient.TabletManagerClient interface.
 func (fake *TabletManagerClient) VReplicationExec(ctx context.Context, tablet *topodatapb.Tablet, query string) (*querypb.QueryResult, error) {
 	if fake.VReplicationExecResults == nil {
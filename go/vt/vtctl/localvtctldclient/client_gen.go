@@ -602,6 +602,11 @@ func (client *localVtctldClient) RunHealthCheck(ctx context.Context, in *vtctlda
 	return client.s.RunHealthCheck(ctx, in)
 }
 
+// SetKeyspaceBackupPolicy is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) SetKeyspaceBackupPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceBackupPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceBackupPolicyResponse, error) {
+	return client.s.SetKeyspaceBackupPolicy(ctx, in)
+}
+
 // SetKeyspaceDurabilityPolicy is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) SetKeyspaceDurabilityPolicy(ctx context.Context, in *vtctldatapb.SetKeyspaceDurabilityPolicyRequest, opts ...grpc.CallOption) (*vtctldatapb.SetKeyspaceDurabilityPolicyResponse, error) {
 	return client.s.SetKeyspaceDurabilityPolicy(ctx, in)
@@ -747,6 +752,11 @@ func (client *localVtctldClient) ValidateVersionShard(ctx context.Context, in *v
 	return client.s.ValidateVersionShard(ctx, in)
 }
 
+// VerifyBackup is part of the vtctlservicepb.VtctldClient interface.
+func (client *localVtctldClient) VerifyBackup(ctx context.Context, in *vtctldatapb.VerifyBackupRequest, opts ...grpc.CallOption) (*vtctldatapb.VerifyBackupResponse, error) {
+	return client.s.VerifyBackup(ctx, in)
+}
+
 // WorkflowDelete is part of the vtctlservicepb.VtctldClient interface.
 func (client *localVtctldClient) WorkflowDelete(ctx context.Context, in *vtctldatapb.WorkflowDeleteRequest, opts ...grpc.CallOption) (*vtctldatapb.WorkflowDeleteResponse, error) {
 	return client.s.WorkflowDelete(ctx, in)
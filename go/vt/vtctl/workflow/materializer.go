@@ -38,6 +38,7 @@ import (
 	"vitess.io/vitess/go/vt/vtenv"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
+	vttablet "vitess.io/vitess/go/vt/vttablet/common"
 	"vitess.io/vitess/go/vt/vttablet/tabletmanager/vreplication"
 	"vitess.io/vitess/go/vt/vttablet/tmclient"
 
@@ -45,6 +46,7 @@ import (
 	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
 	vtctldatapb "vitess.io/vitess/go/vt/proto/vtctldata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
 const (
@@ -181,6 +183,15 @@ func (mz *materializer) generateBinlogSources(ctx context.Context, targetShard *
 			TargetTimeZone:  mz.ms.TargetTimeZone,
 			OnDdl:           binlogdatapb.OnDDLAction(binlogdatapb.OnDDLAction_value[mz.ms.OnDdl]),
 		}
+		if mz.ms.BidirectionalPeer != "" {
+			// The streams tag the statements they apply with the name of the
+			// workflow, and skip the changes tagged by the peer workflow that
+			// replicates in the other direction, so that they don't loop.
+			bls.SourceTag = mz.ms.Workflow
+			bls.Filter.SkipSourceTag = mz.ms.BidirectionalPeer
+			bls.ConflictResolution = mz.ms.ConflictResolution
+			bls.ConflictTimestampColumn = mz.ms.ConflictTimestampColumn
+		}
 
 		var tenantClause *sqlparser.Expr
 		var err error
@@ -260,6 +271,30 @@ func (mz *materializer) generateBinlogSources(ctx context.Context, targetShard *
 	return blses, nil
 }
 
+// validateBidirectionalSettings validates the settings of a workflow that
+// replicates one direction of a bidirectional replication.
+func validateBidirectionalSettings(ms *vtctldatapb.MaterializeSettings) error {
+	hasConflictResolution := ms.ConflictResolution != binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION
+	if ms.BidirectionalPeer == "" {
+		if hasConflictResolution || ms.ConflictTimestampColumn != "" {
+			return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "conflict resolution requires a bidirectional peer workflow")
+		}
+		return nil
+	}
+	if ms.BidirectionalPeer == ms.Workflow {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the bidirectional peer of workflow %s must be another workflow", ms.Workflow)
+	}
+	for _, tag := range []string{ms.Workflow, ms.BidirectionalPeer} {
+		if err := vttablet.ValidateSourceTag(tag); err != nil {
+			return vterrors.Wrapf(err, "invalid workflow name for a bidirectional replication")
+		}
+	}
+	if hasConflictResolution && ms.ConflictTimestampColumn == "" {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "conflict resolution %s requires a conflict timestamp column", ms.ConflictResolution)
+	}
+	return nil
+}
+
 func (mz *materializer) deploySchema() error {
 	var sourceDDLs map[string]string
 	var mu sync.Mutex
@@ -410,6 +445,9 @@ func (mz *materializer) deploySchema() error {
 func (mz *materializer) buildMaterializer() error {
 	ctx := mz.ctx
 	ms := mz.ms
+	if err := validateBidirectionalSettings(ms); err != nil {
+		return err
+	}
 	vschema, err := mz.ts.GetVSchema(ctx, ms.TargetKeyspace)
 	if err != nil {
 		return err
@@ -31,6 +31,7 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/test/utils"
 	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/topo"
 	"vitess.io/vitess/go/vt/topo/memorytopo"
 	"vitess.io/vitess/go/vt/vtenv"
 	"vitess.io/vitess/go/vt/vtgate/vindexes"
@@ -668,6 +669,56 @@ func TestMoveTablesNoRoutingRules(t *testing.T) {
 	require.Zerof(t, len(rr.Rules), "routing rules should be empty, found %+v", rr.Rules)
 }
 
+// TestMoveTablesBidirectional confirms that MoveTables passes the bidirectional
+// settings of the request on to the streams of the workflow.
+func TestMoveTablesBidirectional(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:       "workflow",
+		SourceKeyspace: "sourceks",
+		TargetKeyspace: "targetks",
+		TableSettings: []*vtctldatapb.TableMaterializeSettings{{
+			TargetTable:      "t1",
+			SourceExpression: "select * from t1",
+		}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := newTestMaterializerEnv(t, ctx, ms, []string{"0"}, []string{"0"})
+	defer env.close()
+
+	env.tmc.expectVRQuery(100, mzCheckJournal, &sqltypes.Result{})
+	env.tmc.expectVRQuery(200, mzGetCopyState, &sqltypes.Result{})
+	env.tmc.expectVRQuery(200, mzGetLatestCopyState, &sqltypes.Result{})
+	env.tmc.expectCreateVReplicationWorkflowRequest(200, &tabletmanagerdatapb.CreateVReplicationWorkflowRequest{
+		Workflow:     ms.Workflow,
+		WorkflowType: binlogdatapb.VReplicationWorkflowType_MoveTables,
+		BinlogSource: []*binlogdatapb.BinlogSource{{
+			Keyspace: ms.SourceKeyspace,
+			Shard:    "0",
+			Filter: &binlogdatapb.Filter{
+				Rules:         []*binlogdatapb.Rule{{Match: "t1", Filter: "select * from t1"}},
+				SkipSourceTag: "workflow_reverse",
+			},
+			SourceTag:               ms.Workflow,
+			ConflictResolution:      binlogdatapb.ConflictResolution_RECORD_CONFLICTS,
+			ConflictTimestampColumn: "updated_at",
+		}},
+		Options: "{}",
+	})
+
+	_, err := env.ws.MoveTablesCreate(ctx, &vtctldatapb.MoveTablesCreateRequest{
+		Workflow:                ms.Workflow,
+		SourceKeyspace:          ms.SourceKeyspace,
+		TargetKeyspace:          ms.TargetKeyspace,
+		IncludeTables:           []string{"t1"},
+		BidirectionalPeer:       "workflow_reverse",
+		ConflictResolution:      binlogdatapb.ConflictResolution_RECORD_CONFLICTS,
+		ConflictTimestampColumn: "updated_at",
+	})
+	require.NoError(t, err)
+}
+
 func TestCreateLookupVindexFull(t *testing.T) {
 	ms := &vtctldatapb.MaterializeSettings{
 		Workflow:       "lookup",
@@ -2723,3 +2774,91 @@ func TestKeyRangesEqualOptimization(t *testing.T) {
 		})
 	}
 }
+
+func TestBidirectionalBinlogSources(t *testing.T) {
+	ms := &vtctldatapb.MaterializeSettings{
+		Workflow:                "east_to_west",
+		SourceKeyspace:          "east",
+		TargetKeyspace:          "west",
+		TableSettings:           []*vtctldatapb.TableMaterializeSettings{{TargetTable: "t1"}},
+		BidirectionalPeer:       "west_to_east",
+		ConflictResolution:      binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		ConflictTimestampColumn: "updated_at",
+	}
+	require.NoError(t, validateBidirectionalSettings(ms))
+	mz := &materializer{ms: ms}
+	sourceShard := topo.NewShardInfo(ms.SourceKeyspace, "0", &topodatapb.Shard{}, nil)
+	targetShard := topo.NewShardInfo(ms.TargetKeyspace, "0", &topodatapb.Shard{}, nil)
+	blses, err := mz.generateBinlogSources(context.Background(), targetShard, []*topo.ShardInfo{sourceShard}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, []*binlogdatapb.BinlogSource{{
+		Keyspace: "east",
+		Shard:    "0",
+		Filter: &binlogdatapb.Filter{
+			Rules:         []*binlogdatapb.Rule{{Match: "t1"}},
+			SkipSourceTag: "west_to_east",
+		},
+		SourceTag:               "east_to_west",
+		ConflictResolution:      binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		ConflictTimestampColumn: "updated_at",
+	}}, blses)
+
+	// The source keyspace, and the peer workflow, can be in an external
+	// cluster, such as with MoveTables --external-cluster-name.
+	ms.ExternalCluster = "ext1"
+	require.NoError(t, validateBidirectionalSettings(ms))
+	blses, err = mz.generateBinlogSources(context.Background(), targetShard, []*topo.ShardInfo{sourceShard}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, []*binlogdatapb.BinlogSource{{
+		Keyspace: "east",
+		Shard:    "0",
+		Filter: &binlogdatapb.Filter{
+			Rules:         []*binlogdatapb.Rule{{Match: "t1"}},
+			SkipSourceTag: "west_to_east",
+		},
+		ExternalCluster:         "ext1",
+		SourceTag:               "east_to_west",
+		ConflictResolution:      binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		ConflictTimestampColumn: "updated_at",
+	}}, blses)
+
+	testcases := []struct {
+		name string
+		ms   *vtctldatapb.MaterializeSettings
+		err  string
+	}{{
+		name: "conflict resolution without a peer",
+		ms: &vtctldatapb.MaterializeSettings{
+			Workflow:           "wf",
+			ConflictResolution: binlogdatapb.ConflictResolution_RECORD_CONFLICTS,
+		},
+		err: "conflict resolution requires a bidirectional peer workflow",
+	}, {
+		name: "self peer",
+		ms: &vtctldatapb.MaterializeSettings{
+			Workflow:          "wf",
+			BidirectionalPeer: "wf",
+		},
+		err: "the bidirectional peer of workflow wf must be another workflow",
+	}, {
+		name: "invalid peer name",
+		ms: &vtctldatapb.MaterializeSettings{
+			Workflow:          "wf",
+			BidirectionalPeer: "wf */",
+		},
+		err: "invalid source tag",
+	}, {
+		name: "no timestamp column",
+		ms: &vtctldatapb.MaterializeSettings{
+			Workflow:           "wf",
+			BidirectionalPeer:  "wf_reverse",
+			ConflictResolution: binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		},
+		err: "conflict resolution LAST_WRITER_WINS requires a conflict timestamp column",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			require.ErrorContains(t, validateBidirectionalSettings(tcase.ms), tcase.err)
+		})
+	}
+}
@@ -1418,6 +1418,9 @@ func (s *Server) moveTablesCreate(ctx context.Context, req *vtctldatapb.MoveTabl
 		DeferSecondaryKeys:        req.DeferSecondaryKeys,
 		AtomicCopy:                req.AtomicCopy,
 		WorkflowOptions:           req.WorkflowOptions,
+		BidirectionalPeer:         req.BidirectionalPeer,
+		ConflictResolution:        req.ConflictResolution,
+		ConflictTimestampColumn:   req.ConflictTimestampColumn,
 	}
 	if req.SourceTimeZone != "" {
 		ms.SourceTimeZone = req.SourceTimeZone
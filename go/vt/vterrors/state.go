@@ -50,6 +50,7 @@ const (
 	BadNullError
 	InvalidGroupFuncUse
 	ViewWrongList
+	WrongUsage
 
 	// failed precondition
 	NoDB
@@ -18,7 +18,10 @@ package engine
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"vitess.io/vitess/go/mysql/collations"
 	"vitess.io/vitess/go/slice"
@@ -41,6 +44,9 @@ type AggregateParams struct {
 	KeyCol int
 	WCol   int
 	Type   evalengine.Type
+	// HashDistinct is set when the input is not ordered by the distinct column;
+	// the values of each group are then de-duplicated through a hash table.
+	HashDistinct bool
 
 	Alias    string
 	Func     sqlparser.AggrFunc
@@ -50,6 +56,12 @@ type AggregateParams struct {
 	// not what we use to aggregate at the engine primitive level.
 	OrigOpcode AggregateOpcode
 
+	// These are used only for GROUP_CONCAT evaluated on vtgate from the rows of each group,
+	// instead of concatenating the per-shard results.
+	// GroupConcatArgs are the arguments of the function, the first one being at Col.
+	GroupConcatArgs    []CheckCol
+	GroupConcatOrderBy evalengine.Comparison
+
 	CollationEnv *collations.Environment
 }
 
@@ -73,6 +85,9 @@ func (ap *AggregateParams) WAssigned() bool {
 
 func (ap *AggregateParams) String() string {
 	keyCol := strconv.Itoa(ap.Col)
+	if len(ap.GroupConcatArgs) > 0 {
+		keyCol = ap.groupConcatString()
+	}
 	if ap.WAssigned() {
 		keyCol = fmt.Sprintf("%s|%d", keyCol, ap.WCol)
 	}
@@ -89,6 +104,28 @@ func (ap *AggregateParams) String() string {
 	return fmt.Sprintf("%s%s(%s)", ap.Opcode.String(), dispOrigOp, keyCol)
 }
 
+func (ap *AggregateParams) groupConcatString() string {
+	var sb strings.Builder
+	if gc, ok := ap.Func.(*sqlparser.GroupConcatExpr); ok && gc.Distinct {
+		sb.WriteString("distinct ")
+	}
+	for i, arg := range ap.GroupConcatArgs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(arg.Col))
+	}
+	for i, order := range ap.GroupConcatOrderBy {
+		if i == 0 {
+			sb.WriteString(" order by ")
+		} else {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(order.String())
+	}
+	return sb.String()
+}
+
 func (ap *AggregateParams) typ(inputType querypb.Type) querypb.Type {
 	if ap.OrigOpcode != AggregateUnassigned {
 		return ap.OrigOpcode.SQLType(inputType)
@@ -108,9 +145,40 @@ type aggregatorDistinct struct {
 	coll         collations.ID
 	collationEnv *collations.Environment
 	values       *evalengine.EnumSetValues
+
+	// seen is used instead of last when the input is not ordered by the distinct column.
+	seen *probeTable
+}
+
+func newAggregatorDistinct(aggr *AggregateParams, column int) aggregatorDistinct {
+	distinct := aggregatorDistinct{
+		column:       column,
+		coll:         aggr.Type.Collation(),
+		collationEnv: aggr.CollationEnv,
+		values:       aggr.Type.Values(),
+	}
+	if column >= 0 && aggr.HashDistinct {
+		checkCol := CheckCol{
+			Col:          column,
+			Type:         aggr.Type,
+			CollationEnv: aggr.CollationEnv,
+		}
+		switch {
+		case aggr.WAssigned() && column == aggr.WCol:
+			checkCol.Type = evalengine.NewType(sqltypes.VarBinary, collations.CollationBinaryID)
+		case aggr.WAssigned():
+			checkCol.WsCol = &aggr.WCol
+		}
+		distinct.seen = newProbeTable([]CheckCol{checkCol}, aggr.CollationEnv)
+	}
+	return distinct
 }
 
 func (a *aggregatorDistinct) shouldReturn(row []sqltypes.Value) (bool, error) {
+	if a.seen != nil {
+		found, err := a.seen.exists(row)
+		return found == nil, err
+	}
 	if a.column >= 0 {
 		last := a.last
 		next := row[a.column]
@@ -132,6 +200,9 @@ func (a *aggregatorDistinct) shouldReturn(row []sqltypes.Value) (bool, error) {
 
 func (a *aggregatorDistinct) reset() {
 	a.last = sqltypes.NULL
+	if a.seen != nil {
+		a.seen = newProbeTable(a.seen.checkCols, a.seen.collationEnv)
+	}
 }
 
 type aggregatorCount struct {
@@ -258,33 +329,107 @@ type aggregatorGroupConcat struct {
 	from      int
 	type_     sqltypes.Type
 	separator []byte
+	maxLen    int // 0 when the result is not truncated
+
+	distinct aggregatorDistinct
+
+	// args and orderBy are only set when the function is evaluated from the rows
+	// of the group. Ordered values are buffered until the group is finished.
+	args    []int
+	orderBy evalengine.Comparison
+	rows    []sqltypes.Row
 
 	concat []byte
 	n      int
 }
 
-func (a *aggregatorGroupConcat) add(row []sqltypes.Value) error {
-	if row[a.from].IsNull() {
+func (a *aggregatorGroupConcat) add(row []sqltypes.Value) (err error) {
+	if a.args == nil {
+		if row[a.from].IsNull() {
+			return nil
+		}
+		if ret, err := a.distinct.shouldReturn(row); ret {
+			return err
+		}
+		a.append(row[a.from])
+		return nil
+	}
+
+	for _, arg := range a.args {
+		if row[arg].IsNull() {
+			return nil
+		}
+	}
+	if ret, err := a.distinct.shouldReturn(row); ret {
+		return err
+	}
+	if len(a.orderBy) == 0 {
+		a.appendRow(row)
 		return nil
 	}
+
+	// The rows are sorted once the group is finished, which can't fail: comparing each row
+	// with the previous one surfaces the values that can't be compared right away.
+	if len(a.rows) > 0 {
+		defer evalengine.PanicHandler(&err)
+		a.orderBy.Compare(a.rows[len(a.rows)-1], row)
+	}
+	a.rows = append(a.rows, row)
+	return nil
+}
+
+func (a *aggregatorGroupConcat) appendRow(row []sqltypes.Value) {
+	for i, arg := range a.args {
+		if i == 0 {
+			a.append(row[arg])
+			continue
+		}
+		a.concat = append(a.concat, row[arg].Raw()...)
+	}
+}
+
+func (a *aggregatorGroupConcat) append(value sqltypes.Value) {
 	if a.n > 0 {
 		a.concat = append(a.concat, a.separator...)
 	}
-	a.concat = append(a.concat, row[a.from].Raw()...)
+	a.concat = append(a.concat, value.Raw()...)
 	a.n++
-	return nil
 }
 
 func (a *aggregatorGroupConcat) finish() sqltypes.Value {
+	// rows comparing equal stay in arrival order
+	slices.SortStableFunc(a.rows, a.orderBy.Compare)
+	for _, row := range a.rows {
+		if a.maxLen > 0 && len(a.concat) > a.maxLen {
+			break
+		}
+		a.appendRow(row)
+	}
 	if a.n == 0 {
 		return sqltypes.NULL
 	}
+	if a.maxLen > 0 && len(a.concat) > a.maxLen {
+		a.concat = a.truncate()
+	}
 	return sqltypes.MakeTrusted(a.type_, a.concat)
 }
 
+// truncate cuts the result at group_concat_max_len bytes, without splitting a character of a text result.
+func (a *aggregatorGroupConcat) truncate() []byte {
+	end := a.maxLen
+	if !sqltypes.IsBinary(a.type_) {
+		for end > 0 && !utf8.RuneStart(a.concat[end]) {
+			end--
+		}
+	}
+	return a.concat[:end]
+}
+
 func (a *aggregatorGroupConcat) reset() {
 	a.n = 0
 	a.concat = nil // not safe to reuse this byte slice as it's returned as MakeTrusted
+	a.rows = nil
+	a.distinct.reset()
 }
 
 type aggregatorGtid struct {
@@ -354,7 +499,23 @@ func isComparable(typ sqltypes.Type) bool {
 	return false
 }
 
-func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (aggregationState, []*querypb.Field, error) {
+// groupConcatMaxLen returns the group_concat_max_len set in the session, or 0 when it is not set.
+// The global value of the tablets is unknown to vtgate, so the results are only truncated when
+// the session sets the limit.
+func groupConcatMaxLen(vcursor VCursor) int {
+	maxLen := 0
+	vcursor.Session().GetSystemVariables(func(k string, v string) {
+		if k != "group_concat_max_len" {
+			return
+		}
+		if n, err := strconv.Atoi(strings.Trim(v, "'")); err == nil && n > 0 {
+			maxLen = n
+		}
+	})
+	return maxLen
+}
+
+func newAggregation(vcursor VCursor, fields []*querypb.Field, aggregates []*AggregateParams) (aggregationState, []*querypb.Field, error) {
 	fields = slice.Map(fields, func(from *querypb.Field) *querypb.Field { return from.CloneVT() })
 
 	agstate := make([]aggregator, len(fields))
@@ -384,13 +545,8 @@ func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (agg
 
 		case AggregateCount, AggregateCountDistinct:
 			ag = &aggregatorCount{
-				from: aggr.Col,
-				distinct: aggregatorDistinct{
-					column:       distinct,
-					coll:         aggr.Type.Collation(),
-					collationEnv: aggr.CollationEnv,
-					values:       aggr.Type.Values(),
-				},
+				from:     aggr.Col,
+				distinct: newAggregatorDistinct(aggr, distinct),
 			}
 
 		case AggregateSum, AggregateSumDistinct:
@@ -403,14 +559,9 @@ func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (agg
 			}
 
 			ag = &aggregatorSum{
-				from: aggr.Col,
-				sum:  sum,
-				distinct: aggregatorDistinct{
-					column:       distinct,
-					coll:         aggr.Type.Collation(),
-					collationEnv: aggr.CollationEnv,
-					values:       aggr.Type.Values(),
-				},
+				from:     aggr.Col,
+				sum:      sum,
+				distinct: newAggregatorDistinct(aggr, distinct),
 			}
 
 		case AggregateMin:
@@ -438,11 +589,25 @@ func newAggregation(fields []*querypb.Field, aggregates []*AggregateParams) (agg
 		case AggregateGroupConcat:
 			gcFunc := aggr.Func.(*sqlparser.GroupConcatExpr)
 			separator := []byte(gcFunc.Separator)
-			ag = &aggregatorGroupConcat{
+			gc := &aggregatorGroupConcat{
 				from:      aggr.Col,
 				type_:     targetType,
 				separator: separator,
+				maxLen:    groupConcatMaxLen(vcursor),
+				orderBy:   aggr.GroupConcatOrderBy,
+				distinct:  aggregatorDistinct{column: -1},
+			}
+			switch {
+			case len(aggr.GroupConcatArgs) > 0:
+				gc.args = slice.Map(aggr.GroupConcatArgs, func(from CheckCol) int { return from.Col })
+				if gcFunc.Distinct {
+					gc.distinct.seen = newProbeTable(aggr.GroupConcatArgs, aggr.CollationEnv)
+				}
+			case gcFunc.Distinct:
+				// the shards return each value once per group, duplicates come from different shards
+				gc.distinct = newAggregatorDistinct(aggr, aggr.Col)
 			}
+			ag = gc
 
 		default:
 			panic("BUG: unexpected Aggregation opcode")
@@ -35,7 +35,7 @@ func (cached *AggregateParams) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(112)
+		size += int64(176)
 	}
 	// field Type vitess.io/vitess/go/vt/vtgate/evalengine.Type
 	size += cached.Type.CachedSize(false)
@@ -47,6 +47,20 @@ func (cached *AggregateParams) CachedSize(alloc bool) int64 {
 	}
 	// field Original *vitess.io/vitess/go/vt/sqlparser.AliasedExpr
 	size += cached.Original.CachedSize(true)
+	// field GroupConcatArgs []vitess.io/vitess/go/vt/vtgate/engine.CheckCol
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.GroupConcatArgs)) * int64(48))
+		for _, elem := range cached.GroupConcatArgs {
+			size += elem.CachedSize(false)
+		}
+	}
+	// field GroupConcatOrderBy vitess.io/vitess/go/vt/vtgate/evalengine.Comparison
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.GroupConcatOrderBy)) * int64(56))
+		for _, elem := range cached.GroupConcatOrderBy {
+			size += elem.CachedSize(false)
+		}
+	}
 	// field CollationEnv *vitess.io/vitess/go/mysql/collations.Environment
 	size += cached.CollationEnv.CachedSize(true)
 	return size
@@ -113,6 +127,52 @@ func (cached *Concatenate) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+
+//go:nocheckptr
+func (cached *CorrelatedSubquery) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(112)
+	}
+	// field SubqueryResult string
+	size += hack.RuntimeAllocSize(int64(len(cached.SubqueryResult)))
+	// field HasValues string
+	size += hack.RuntimeAllocSize(int64(len(cached.HasValues)))
+	// field Vars map[string]int
+	if cached.Vars != nil {
+		size += int64(48)
+		hmap := reflect.ValueOf(cached.Vars)
+		numBuckets := int(math.Pow(2, float64((*(*uint8)(unsafe.Pointer(hmap.Pointer() + uintptr(9)))))))
+		numOldBuckets := (*(*uint16)(unsafe.Pointer(hmap.Pointer() + uintptr(10))))
+		size += hack.RuntimeAllocSize(int64(numOldBuckets * 208))
+		if len(cached.Vars) > 0 || numBuckets > 1 {
+			size += hack.RuntimeAllocSize(int64(numBuckets * 208))
+		}
+		for k := range cached.Vars {
+			size += hack.RuntimeAllocSize(int64(len(k)))
+		}
+	}
+	// field Predicate vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Predicate.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field ASTPredicate vitess.io/vitess/go/vt/sqlparser.Expr
+	if cc, ok := cached.ASTPredicate.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Outer vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Outer.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Subquery vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Subquery.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
 func (cached *DBDDL) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -801,7 +861,7 @@ func (cached *Plan) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(144)
+		size += int64(160)
 	}
 	// field Original string
 	size += hack.RuntimeAllocSize(int64(len(cached.Original)))
@@ -1032,6 +1092,35 @@ func (cached *RoutingParameters) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *RowMove) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(112)
+	}
+	// field Table string
+	size += hack.RuntimeAllocSize(int64(len(cached.Table)))
+	// field PKOffsets []int
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PKOffsets)) * int64(8))
+	}
+	// field PKValues []vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PKValues)) * int64(16))
+		for _, elem := range cached.PKValues {
+			if cc, ok := elem.(cachedObject); ok {
+				size += cc.CachedSize(true)
+			}
+		}
+	}
+	// field SelectQuery string
+	size += hack.RuntimeAllocSize(int64(len(cached.SelectQuery)))
+	// field DeleteQuery string
+	size += hack.RuntimeAllocSize(int64(len(cached.DeleteQuery)))
+	return size
+}
 func (cached *Rows) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
@@ -1371,7 +1460,7 @@ func (cached *Update) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(16)
+		size += int64(24)
 	}
 	// field DML *vitess.io/vitess/go/vt/vtgate/engine.DML
 	size += cached.DML.CachedSize(true)
@@ -1390,6 +1479,8 @@ func (cached *Update) CachedSize(alloc bool) int64 {
 			size += v.CachedSize(true)
 		}
 	}
+	// field RowMove *vitess.io/vitess/go/vt/vtgate/engine.RowMove
+	size += cached.RowMove.CachedSize(true)
 	return size
 }
 func (cached *UpdateTarget) CachedSize(alloc bool) int64 {
@@ -1597,6 +1688,67 @@ func (cached *VitessMetadata) CachedSize(alloc bool) int64 {
 	size += hack.RuntimeAllocSize(int64(len(cached.Value)))
 	return size
 }
+func (cached *Window) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(96)
+	}
+	// field PartitionBy []*vitess.io/vitess/go/vt/vtgate/engine.GroupByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.PartitionBy)) * int64(8))
+		for _, elem := range cached.PartitionBy {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field OrderBy []*vitess.io/vitess/go/vt/vtgate/engine.GroupByParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.OrderBy)) * int64(8))
+		for _, elem := range cached.OrderBy {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field Functions []*vitess.io/vitess/go/vt/vtgate/engine.WindowParams
+	{
+		size += hack.RuntimeAllocSize(int64(cap(cached.Functions)) * int64(8))
+		for _, elem := range cached.Functions {
+			size += elem.CachedSize(true)
+		}
+	}
+	// field Input vitess.io/vitess/go/vt/vtgate/engine.Primitive
+	if cc, ok := cached.Input.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	return size
+}
+func (cached *WindowParams) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(80)
+	}
+	// field Aggregate *vitess.io/vitess/go/vt/vtgate/engine.AggregateParams
+	size += cached.Aggregate.CachedSize(true)
+	// field Offset vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Offset.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Default vitess.io/vitess/go/vt/vtgate/evalengine.Expr
+	if cc, ok := cached.Default.(cachedObject); ok {
+		size += cc.CachedSize(true)
+	}
+	// field Frame *vitess.io/vitess/go/vt/vtgate/engine.WindowFrame
+	if cached.Frame != nil {
+		size += hack.RuntimeAllocSize(int64(40))
+	}
+	// field Alias string
+	size += hack.RuntimeAllocSize(int64(len(cached.Alias)))
+	return size
+}
 func (cached *percentBasedMirror) CachedSize(alloc bool) int64 {
 	if cached == nil {
 		return int64(0)
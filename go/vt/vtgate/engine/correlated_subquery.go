@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"maps"
+
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*CorrelatedSubquery)(nil)
+
+// CorrelatedSubquery executes the subquery once per row of the outer query,
+// passing the outer columns listed in Vars as bind variables, the same way
+// a Join does. The subquery result is exposed to Predicate through the
+// SubqueryResult and HasValues bind variables, and only the outer rows for
+// which Predicate evaluates to true are returned.
+type CorrelatedSubquery struct {
+	Opcode PulloutOpcode
+
+	// SubqueryResult and HasValues are the bindvars the subquery result is stored in
+	SubqueryResult string
+	HasValues      string
+
+	// Vars defines the list of bind variables that need to
+	// be built from each outer row before invoking the subquery.
+	Vars map[string]int
+
+	Predicate    evalengine.Expr
+	ASTPredicate sqlparser.Expr
+
+	Outer    Primitive
+	Subquery Primitive
+}
+
+// TryExecute performs a non-streaming exec.
+func (cs *CorrelatedSubquery) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool) (*sqltypes.Result, error) {
+	outer, err := vcursor.ExecutePrimitive(ctx, cs.Outer, bindVars, wantfields)
+	if err != nil {
+		return nil, err
+	}
+	result := &sqltypes.Result{Fields: outer.Fields}
+	result.Rows, err = cs.filterRows(ctx, vcursor, bindVars, outer.Rows)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TryStreamExecute performs a streaming exec.
+func (cs *CorrelatedSubquery) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
+	return vcursor.StreamExecutePrimitive(ctx, cs.Outer, bindVars, wantfields, func(outer *sqltypes.Result) error {
+		rows, err := cs.filterRows(ctx, vcursor, bindVars, outer.Rows)
+		if err != nil {
+			return err
+		}
+		return callback(&sqltypes.Result{Fields: outer.Fields, Rows: rows})
+	})
+}
+
+func (cs *CorrelatedSubquery) filterRows(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rows [][]sqltypes.Value) ([][]sqltypes.Value, error) {
+	var out [][]sqltypes.Value
+	for _, row := range rows {
+		rowVars := maps.Clone(bindVars)
+		if rowVars == nil {
+			rowVars = make(map[string]*querypb.BindVariable, len(cs.Vars)+2)
+		}
+		for k, col := range cs.Vars {
+			rowVars[k] = sqltypes.ValueBindVariable(row[col])
+		}
+		sqResult, err := vcursor.ExecutePrimitive(ctx, cs.Subquery, rowVars, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := setPulloutVars(cs.Opcode, cs.SubqueryResult, cs.HasValues, sqResult, rowVars); err != nil {
+			return nil, err
+		}
+
+		env := evalengine.NewExpressionEnv(ctx, rowVars, vcursor)
+		env.Row = row
+		evalResult, err := env.Evaluate(cs.Predicate)
+		if err != nil {
+			return nil, err
+		}
+		if evalResult.ToBoolean() {
+			out = append(out, row)
+		}
+	}
+	return out, nil
+}
+
+// GetFields fetches the field info.
+func (cs *CorrelatedSubquery) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	return cs.Outer.GetFields(ctx, vcursor, bindVars)
+}
+
+// Inputs returns the input primitives for this CorrelatedSubquery
+func (cs *CorrelatedSubquery) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{cs.Outer, cs.Subquery}, []map[string]any{{
+		inputName: "Outer",
+	}, {
+		inputName: "SubQuery",
+	}}
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (cs *CorrelatedSubquery) RouteType() string {
+	return "CorrelatedSubquery"
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (cs *CorrelatedSubquery) GetKeyspaceName() string {
+	if cs.Outer.GetKeyspaceName() == cs.Subquery.GetKeyspaceName() {
+		return cs.Outer.GetKeyspaceName()
+	}
+	return cs.Outer.GetKeyspaceName() + "_" + cs.Subquery.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (cs *CorrelatedSubquery) GetTableName() string {
+	return cs.Outer.GetTableName() + "_" + cs.Subquery.GetTableName()
+}
+
+// NeedsTransaction implements the Primitive interface
+func (cs *CorrelatedSubquery) NeedsTransaction() bool {
+	return cs.Outer.NeedsTransaction() || cs.Subquery.NeedsTransaction()
+}
+
+func (cs *CorrelatedSubquery) description() PrimitiveDescription {
+	other := map[string]any{
+		"Predicate": sqlparser.String(cs.ASTPredicate),
+	}
+	if len(cs.Vars) > 0 {
+		other["JoinVars"] = orderedStringIntMap(cs.Vars)
+	}
+	var pulloutVars []string
+	if cs.HasValues != "" {
+		pulloutVars = append(pulloutVars, cs.HasValues)
+	}
+	if cs.SubqueryResult != "" {
+		pulloutVars = append(pulloutVars, cs.SubqueryResult)
+	}
+	if len(pulloutVars) > 0 {
+		other["PulloutVars"] = pulloutVars
+	}
+	return PrimitiveDescription{
+		OperatorType: "CorrelatedSubquery",
+		Variant:      cs.Opcode.String(),
+		Other:        other,
+	}
+}
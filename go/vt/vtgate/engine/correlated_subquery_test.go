@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtenv"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func translateCorrelatedPredicate(t *testing.T, expr string, fields []*querypb.Field) (sqlparser.Expr, evalengine.Expr) {
+	ast, err := sqlparser.NewTestParser().ParseExpr(expr)
+	require.NoError(t, err)
+	pred, err := evalengine.Translate(ast, &evalengine.Config{
+		Collation:     collations.MySQL8().DefaultConnectionCharset(),
+		ResolveColumn: evalengine.FieldResolver(fields).Column,
+		Environment:   vtenv.NewTestEnv(),
+	})
+	require.NoError(t, err)
+	return ast, pred
+}
+
+func TestCorrelatedSubqueryValue(t *testing.T) {
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	outer := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|30"),
+		},
+	}
+	sqFields := sqltypes.MakeTestFields("cnt", "int64")
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqFields, "5"),
+			sqltypes.MakeTestResult(sqFields, "25"),
+			sqltypes.MakeTestResult(sqFields),
+		},
+	}
+
+	ast, pred := translateCorrelatedPredicate(t, "col > :__sq1", outerFields)
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"user_id": 0},
+		Predicate:      pred,
+		ASTPredicate:   ast,
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	subquery.ExpectLog(t, []string{
+		`Execute user_id: type:INT64 value:"1" false`,
+		`Execute user_id: type:INT64 value:"2" false`,
+		`Execute user_id: type:INT64 value:"3" false`,
+	})
+	// the last subquery returns no rows, so the comparison is against NULL
+	utils.MustMatch(t, sqltypes.MakeTestResult(outerFields, "1|10"), r)
+
+	outer.rewind()
+	subquery.rewind()
+	r, err = wrapStreamExecute(cs, &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(outerFields, "1|10"), r)
+}
+
+func TestCorrelatedSubqueryNotIn(t *testing.T) {
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	outer := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(outerFields, "1|10", "2|20", "3|30"),
+		},
+	}
+	sqFields := sqltypes.MakeTestFields("col", "int64")
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqFields, "10", "11"),
+			sqltypes.MakeTestResult(sqFields, "21"),
+			sqltypes.MakeTestResult(sqFields),
+		},
+	}
+
+	ast, pred := translateCorrelatedPredicate(t, "not :__sq_has_values or col not in ::__sq1", outerFields)
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutNotIn,
+		SubqueryResult: "__sq1",
+		HasValues:      "__sq_has_values",
+		Vars:           map[string]int{"user_id": 0},
+		Predicate:      pred,
+		ASTPredicate:   ast,
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	r, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(outerFields, "2|20", "3|30"), r)
+}
+
+func TestCorrelatedSubqueryTooManyRows(t *testing.T) {
+	outerFields := sqltypes.MakeTestFields("id|col", "int64|int64")
+	outer := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(outerFields, "1|10"),
+		},
+	}
+	sqFields := sqltypes.MakeTestFields("cnt", "int64")
+	subquery := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(sqFields, "5", "6"),
+		},
+	}
+
+	ast, pred := translateCorrelatedPredicate(t, "col > :__sq1", outerFields)
+	cs := &CorrelatedSubquery{
+		Opcode:         PulloutValue,
+		SubqueryResult: "__sq1",
+		Vars:           map[string]int{"user_id": 0},
+		Predicate:      pred,
+		ASTPredicate:   ast,
+		Outer:          outer,
+		Subquery:       subquery,
+	}
+
+	_, err := cs.TryExecute(context.Background(), &noopVCursor{}, map[string]*querypb.BindVariable{}, true)
+	require.EqualError(t, err, "subquery returned more than one row")
+}
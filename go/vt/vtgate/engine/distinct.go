@@ -34,6 +34,7 @@ var _ Primitive = (*Distinct)(nil)
 
 type (
 	// Distinct Primitive is used to uniqueify results
+	// When streaming, rows that exceed the memory of the query are made distinct in partitions on disk.
 	Distinct struct {
 		Source    Primitive
 		CheckCols []CheckCol
@@ -134,6 +135,20 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 	var mu sync.Mutex
 
 	pt := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+
+	// When the distinct rows do not fit in the memory of the query, the rows that have
+	// been sent are still remembered, but new rows are split into partitions on disk
+	// by their hash code, and each partition is made distinct once all rows are read.
+	qm := vcursor.QueryMemory()
+	var parts *spillPartitions
+	var used int64
+	defer func() {
+		parts.close()
+		if qm != nil {
+			qm.release(used)
+		}
+	}()
+
 	err := vcursor.StreamExecutePrimitive(ctx, d.Source, bindVars, wantfields, func(input *sqltypes.Result) error {
 		result := &sqltypes.Result{
 			Fields:   input.Fields,
@@ -142,18 +157,73 @@ func (d *Distinct) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVa
 		mu.Lock()
 		defer mu.Unlock()
 		for _, row := range input.Rows {
+			if parts != nil {
+				code, err := pt.hashCodeForRow(row)
+				if err != nil {
+					return err
+				}
+				if _, found := pt.seenRows[code]; found {
+					continue
+				}
+				if err := parts.add(code, row); err != nil {
+					return err
+				}
+				spilledRows.Add("Distinct", 1)
+				continue
+			}
 			appendRow, err := pt.exists(row)
 			if err != nil {
 				return err
 			}
-			if appendRow != nil {
-				result.Rows = append(result.Rows, appendRow)
+			if appendRow == nil {
+				continue
+			}
+			result.Rows = append(result.Rows, appendRow)
+			if qm == nil {
+				continue
+			}
+			size := rowMemorySize(appendRow)
+			used += size
+			if qm.grow(size, used) {
+				if parts, err = newSpillPartitions(qm); err != nil {
+					return err
+				}
 			}
 		}
 		return callback(result.Truncate(len(d.CheckCols)))
 	})
+	if err != nil || parts == nil {
+		return err
+	}
 
-	return err
+	// this will only be called when all the concurrent access to the partitions
+	// has ceased, so we don't need to lock them here
+	for _, part := range parts.files {
+		partPT := newProbeTable(d.CheckCols, vcursor.Environment().CollationEnv())
+		result := &sqltypes.Result{}
+		err := part.readAll(func(row sqltypes.Row) error {
+			appendRow, err := partPT.exists(row)
+			if err != nil || appendRow == nil {
+				return err
+			}
+			result.Rows = append(result.Rows, appendRow)
+			if len(result.Rows) < spillBatchRows {
+				return nil
+			}
+			err = callback(result.Truncate(len(d.CheckCols)))
+			result = &sqltypes.Result{}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Rows) != 0 {
+			if err := callback(result.Truncate(len(d.CheckCols))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 // RouteType implements the Primitive interface
@@ -184,6 +184,50 @@ func TestDistinctStreamAsync(t *testing.T) {
 [VARCHAR("a") INT64(1) INT64(1) VARCHAR("t")]]`, qr.Rows))
 }
 
+func TestDistinctStreamSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields("myid|id|name", "varchar|int64|varchar")
+	distinct := &Distinct{
+		Source: &fakePrimitive{
+			results: []*sqltypes.Result{sqltypes.MakeTestResult(fields,
+				"a|1|a",
+				"a|1|a",
+				"b|2|null",
+				"c|3|a",
+				"a|1|a",
+				"b|2|null",
+				"d|4|a",
+				"c|3|a",
+				"b|2|b",
+			)},
+		},
+		CheckCols: []CheckCol{
+			{Col: 0, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+			{Col: 1, Type: evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)},
+			{Col: 2, Type: evalengine.NewType(sqltypes.VarChar, collations.CollationUtf8mb4ID)},
+		},
+	}
+
+	// the first row exceeds the memory of the query,
+	// so all the rows that follow are written to disk
+	qm := NewQueryMemory(t.TempDir(), 1)
+	spilled := spilledRows.Counts()["Distinct"]
+	qr := &sqltypes.Result{Fields: fields}
+	err := distinct.TryStreamExecute(context.Background(), &loggingVCursor{queryMemory: qm}, nil, true, func(result *sqltypes.Result) error {
+		qr.Rows = append(qr.Rows, result.Rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	expectResultAnyOrder(t, qr, sqltypes.MakeTestResult(fields,
+		"a|1|a",
+		"b|2|null",
+		"c|3|a",
+		"d|4|a",
+		"b|2|b",
+	))
+	require.Greater(t, spilledRows.Counts()["Distinct"], spilled)
+	require.Zero(t, qm.Used())
+}
+
 func TestWeightStringFallBack(t *testing.T) {
 	offsetOne := 1
 	checkCols := []CheckCol{{
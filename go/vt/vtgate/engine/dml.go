@@ -94,6 +94,10 @@ func (dml *DML) execMultiDestination(ctx context.Context, primitive Primitive, v
 			BindVariables: bvs[i],
 		}
 	}
+	if dml.PreventAutoCommit {
+		result, errs := vcursor.ExecuteMultiShard(ctx, primitive, rss, queries, true /* rollbackOnError */, false /* canAutocommit */)
+		return result, vterrors.Aggregate(errs)
+	}
 	return execMultiShard(ctx, primitive, vcursor, rss, queries, dml.MultiShardAutocommit)
 }
 
@@ -188,7 +188,6 @@ func (t *noopVCursor) HasSystemVariables() bool {
 }
 
 func (t *noopVCursor) GetSystemVariables(func(k string, v string)) {
-	panic("implement me")
 }
 
 func (t *noopVCursor) GetWarnings() []*querypb.QueryWarning {
@@ -325,6 +324,10 @@ func (t *noopVCursor) SetTransactionMode(vtgatepb.TransactionMode) {
 	panic("implement me")
 }
 
+func (t *noopVCursor) TransactionMode() vtgatepb.TransactionMode {
+	return vtgatepb.TransactionMode_MULTI
+}
+
 func (t *noopVCursor) SetWorkload(querypb.ExecuteOptions_Workload) {
 	panic("implement me")
 }
@@ -357,6 +360,10 @@ func (t *noopVCursor) ExceedsMaxMemoryRows(numRows int) bool {
 	return !testIgnoreMaxMemoryRows && numRows > testMaxMemoryRows
 }
 
+func (t *noopVCursor) QueryMemory() *QueryMemory {
+	return nil
+}
+
 func (t *noopVCursor) GetKeyspace() string {
 	return "test_ks"
 }
@@ -437,12 +444,15 @@ type loggingVCursor struct {
 	inReservedConn  bool
 	systemVariables map[string]string
 	disableSetVar   bool
+	txMode          vtgatepb.TransactionMode
 
 	// map different shards to keyspaces in the test.
 	ksShardMap map[string][]string
 
 	shardSession []*srvtopo.ResolvedShard
 
+	queryMemory *QueryMemory
+
 	parser *sqlparser.Parser
 
 	handleMirrorClonesFn   func(context.Context) VCursor
@@ -450,6 +460,10 @@ type loggingVCursor struct {
 	onStreamExecuteMultiFn func(context.Context, Primitive, string, []*srvtopo.ResolvedShard, []map[string]*querypb.BindVariable, bool, bool, func(*sqltypes.Result) error)
 }
 
+func (f *loggingVCursor) QueryMemory() *QueryMemory {
+	return f.queryMemory
+}
+
 func (f *loggingVCursor) HasCreatedTempTable() {
 	f.log = append(f.log, "temp table getting created")
 }
@@ -492,8 +506,10 @@ func (f *loggingVCursor) HasSystemVariables() bool {
 	return len(f.systemVariables) > 0
 }
 
-func (f *loggingVCursor) GetSystemVariables(func(k string, v string)) {
-	panic("implement me")
+func (f *loggingVCursor) GetSystemVariables(fn func(k string, v string)) {
+	for k, v := range f.systemVariables {
+		fn(k, v)
+	}
 }
 
 func (f *loggingVCursor) SetFoundRows(u uint64) {
@@ -814,6 +830,13 @@ func (f *loggingVCursor) SetTransactionMode(vtgatepb.TransactionMode) {
 	panic("implement me")
 }
 
+func (f *loggingVCursor) TransactionMode() vtgatepb.TransactionMode {
+	if f.txMode == vtgatepb.TransactionMode_UNSPECIFIED {
+		return vtgatepb.TransactionMode_MULTI
+	}
+	return f.txMode
+}
+
 func (f *loggingVCursor) SetWorkload(querypb.ExecuteOptions_Workload) {
 	panic("implement me")
 }
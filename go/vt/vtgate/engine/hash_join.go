@@ -39,6 +39,7 @@ type (
 	// The key to the map is the hashcode of the value for column that we are joining by.
 	// Then the RHS is fetched, and we can check if the rows from the RHS matches any from the LHS.
 	// When they match by hash code, we double-check that we are not working with a false positive by comparing the values.
+	// When streaming, rows that exceed the memory of the query are joined in partitions on disk.
 	HashJoin struct {
 		Opcode JoinOpcode
 
@@ -135,6 +136,21 @@ func (hj *HashJoin) TryExecute(ctx context.Context, vcursor VCursor, bindVars ma
 func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, wantfields bool, callback func(*sqltypes.Result) error) error {
 	// build the probe table from the LHS result
 	pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
+
+	// When the LHS does not fit in the memory of the query, the rows of both sides are split
+	// into partitions on disk by the hash of their join column, and the partitions are joined
+	// one at a time once both sides have been read.
+	qm := vcursor.QueryMemory()
+	var lparts, rparts *spillPartitions
+	var used int64
+	defer func() {
+		lparts.close()
+		rparts.close()
+		if qm != nil {
+			qm.release(used)
+		}
+	}()
+
 	var lfields []*querypb.Field
 	var mu sync.Mutex
 	err := vcursor.StreamExecutePrimitive(ctx, hj.Left, bindVars, wantfields, func(result *sqltypes.Result) error {
@@ -144,10 +160,37 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 			lfields = result.Fields
 		}
 		for _, current := range result.Rows {
+			if lparts != nil {
+				hash, err := pt.hash(current[pt.lhsKey])
+				if err != nil {
+					return err
+				}
+				if err := lparts.add(hash, current); err != nil {
+					return err
+				}
+				spilledRows.Add("HashJoin", 1)
+				continue
+			}
 			err := pt.addLeftRow(current)
 			if err != nil {
 				return err
 			}
+			if qm == nil {
+				continue
+			}
+			size := rowMemorySize(current)
+			used += size
+			if !qm.grow(size, used) {
+				continue
+			}
+			if lparts, err = pt.spill(qm); err != nil {
+				return err
+			}
+			if rparts, err = newSpillPartitions(qm); err != nil {
+				return err
+			}
+			qm.release(used)
+			used = 0
 		}
 		return nil
 	})
@@ -167,6 +210,22 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 			res.Fields = joinFields(lfields, result.Fields, hj.Cols)
 		}
 		for _, currentRHSRow := range result.Rows {
+			if rparts != nil {
+				// rows with a NULL join column cannot match any row of the LHS
+				val := currentRHSRow[pt.rhsKey]
+				if val.IsNull() {
+					continue
+				}
+				hash, err := pt.hash(val)
+				if err != nil {
+					return err
+				}
+				if err := rparts.add(hash, currentRHSRow); err != nil {
+					return err
+				}
+				spilledRows.Add("HashJoin", 1)
+				continue
+			}
 			results, err := pt.get(currentRHSRow)
 			if err != nil {
 				return err
@@ -182,17 +241,32 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 		return err
 	}
 
-	if hj.Opcode == LeftJoin {
-		res := &sqltypes.Result{}
-		if sendFields.CompareAndSwap(true, false) {
-			// If we still have not sent the fields, we need to fetch
-			// the fields from the RHS to be able to build the result fields
-			rres, err := hj.Right.GetFields(ctx, vcursor, bindVars)
-			if err != nil {
+	res := &sqltypes.Result{}
+	if hj.Opcode == LeftJoin && sendFields.CompareAndSwap(true, false) {
+		// If we still have not sent the fields, we need to fetch
+		// the fields from the RHS to be able to build the result fields
+		rres, err := hj.Right.GetFields(ctx, vcursor, bindVars)
+		if err != nil {
+			return err
+		}
+		res.Fields = joinFields(lfields, rres.Fields, hj.Cols)
+	}
+	if lparts != nil {
+		// this will only be called when all the concurrent access to the partitions
+		// has ceased, so we don't need to lock them here
+		err := hj.joinPartitions(lparts, rparts, func(rows []sqltypes.Row) error {
+			res.Rows = rows
+			if err := callback(res); err != nil {
 				return err
 			}
-			res.Fields = joinFields(lfields, rres.Fields, hj.Cols)
+			res = &sqltypes.Result{}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
+	}
+	if hj.Opcode == LeftJoin {
 		// this will only be called when all the concurrent access to the pt has
 		// ceased, so we don't need to lock it here
 		res.Rows = pt.notFetched()
@@ -201,6 +275,44 @@ func (hj *HashJoin) TryStreamExecute(ctx context.Context, vcursor VCursor, bindV
 	return nil
 }
 
+// joinPartitions joins the rows of the LHS and RHS that were written to disk, one partition
+// at a time. Only the rows of the LHS that belong to the current partition are kept in memory.
+func (hj *HashJoin) joinPartitions(lparts, rparts *spillPartitions, callback func([]sqltypes.Row) error) error {
+	for i := range lparts.files {
+		pt := newHashJoinProbeTable(hj.Collation, hj.ComparisonType, hj.LHSKey, hj.RHSKey, hj.Cols, hj.Values)
+		if err := lparts.files[i].readAll(pt.addLeftRow); err != nil {
+			return err
+		}
+
+		var rows []sqltypes.Row
+		err := rparts.files[i].readAll(func(row sqltypes.Row) error {
+			matches, err := pt.get(row)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, matches...)
+			if len(rows) < spillBatchRows {
+				return nil
+			}
+			err = callback(rows)
+			rows = nil
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if hj.Opcode == LeftJoin {
+			rows = append(rows, pt.notFetched()...)
+		}
+		if len(rows) != 0 {
+			if err := callback(rows); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // RouteType implements the Primitive interface
 func (hj *HashJoin) RouteType() string {
 	return "HashJoin"
@@ -290,6 +402,25 @@ func (pt *hashJoinProbeTable) addLeftRow(r sqltypes.Row) error {
 	return nil
 }
 
+// spill moves the rows of the probe table to partitions on disk, and empties the probe table.
+func (pt *hashJoinProbeTable) spill(qm *QueryMemory) (*spillPartitions, error) {
+	parts, err := newSpillPartitions(qm)
+	if err != nil {
+		return nil, err
+	}
+	for hash, e := range pt.innerMap {
+		for ; e != nil; e = e.next {
+			if err := parts.add(hash, e.row); err != nil {
+				parts.close()
+				return nil, err
+			}
+			spilledRows.Add("HashJoin", 1)
+		}
+	}
+	pt.innerMap = map[vthash.Hash]*probeTableEntry{}
+	return parts, nil
+}
+
 func (pt *hashJoinProbeTable) hash(val sqltypes.Value) (vthash.Hash, error) {
 	err := evalengine.NullsafeHashcode128(&pt.hasher, val, pt.coll, pt.typ, pt.sqlmode, pt.values)
 	if err != nil {
@@ -159,6 +159,17 @@ func TestHashJoinVariations(t *testing.T) {
 			require.NoError(t, err)
 			expectResultAnyOrder(t, r, expected)
 		})
+		t.Run("Spilling "+tc.name, func(t *testing.T) {
+			jn.Left = first()
+			jn.Right = last()
+			qm := NewQueryMemory(t.TempDir(), 1)
+			spilled := spilledRows.Counts()["HashJoin"]
+			r, err := wrapStreamExecute(jn, &loggingVCursor{queryMemory: qm}, map[string]*querypb.BindVariable{}, true)
+			require.NoError(t, err)
+			expectResultAnyOrder(t, r, expected)
+			require.Greater(t, spilledRows.Counts()["HashJoin"], spilled)
+			require.Zero(t, qm.Used())
+		})
 	}
 }
 
@@ -33,6 +33,7 @@ import (
 var _ Primitive = (*MemorySort)(nil)
 
 // MemorySort is a primitive that performs in-memory sorting.
+// When streaming, rows that exceed the memory of the query are sorted in runs on disk.
 type MemorySort struct {
 	UpperLimit evalengine.Expr
 	OrderBy    evalengine.Comparison
@@ -93,10 +94,27 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 		return callback(qr.Truncate(ms.TruncateColumnCount))
 	}
 
-	sorter := &evalengine.Sorter{
-		Compare: ms.OrderBy,
-		Limit:   count,
+	newSorter := func() *evalengine.Sorter {
+		return &evalengine.Sorter{
+			Compare: ms.OrderBy,
+			Limit:   count,
+		}
 	}
+	sorter := newSorter()
+
+	// When the rows do not fit in the memory of the query, they are sorted in runs
+	// that are written to disk, and merged back together once all the rows are read.
+	qm := vcursor.QueryMemory()
+	var runs []*spillFile
+	var used int64
+	defer func() {
+		for _, run := range runs {
+			run.close()
+		}
+		if qm != nil {
+			qm.release(used)
+		}
+	}()
 
 	var mu sync.Mutex
 	err = vcursor.StreamExecutePrimitive(ctx, ms.Input, bindVars, wantfields, func(qr *sqltypes.Result) error {
@@ -108,9 +126,26 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 			}
 		}
 		for _, row := range qr.Rows {
+			n := sorter.Len()
 			sorter.Push(row)
+			if qm == nil || sorter.Len() == n {
+				continue
+			}
+			size := rowMemorySize(row)
+			used += size
+			if !qm.grow(size, used) {
+				continue
+			}
+			run, err := ms.spillRun(qm, sorter.Sorted())
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			qm.release(used)
+			used = 0
+			sorter = newSorter()
 		}
-		if vcursor.ExceedsMaxMemoryRows(sorter.Len()) {
+		if qm == nil && vcursor.ExceedsMaxMemoryRows(sorter.Len()) {
 			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
 		}
 		return nil
@@ -118,7 +153,26 @@ func (ms *MemorySort) TryStreamExecute(ctx context.Context, vcursor VCursor, bin
 	if err != nil {
 		return err
 	}
-	return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	if len(runs) == 0 {
+		return cb(&sqltypes.Result{Rows: sorter.Sorted()})
+	}
+	return mergeSpilledRuns(ms.OrderBy, runs, sorter.Sorted(), count, cb)
+}
+
+// spillRun writes sorted rows to a new spill file.
+func (ms *MemorySort) spillRun(qm *QueryMemory, rows []sqltypes.Row) (*spillFile, error) {
+	run, err := qm.newSpillFile()
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := run.write(row); err != nil {
+			run.close()
+			return nil, err
+		}
+	}
+	spilledRows.Add("Sort", int64(len(rows)))
+	return run, nil
 }
 
 // GetFields satisfies the Primitive interface.
@@ -148,6 +148,82 @@ func TestMemorySortStreamExecuteWeightString(t *testing.T) {
 	})
 }
 
+func TestMemorySortStreamExecuteSpill(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2",
+		"varbinary|decimal",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"a|1",
+			"g|2",
+			"a|1",
+			"c|4",
+			"null|5",
+			"c|3",
+			"b|null",
+		)},
+	}
+
+	ms := &MemorySort{
+		OrderBy: []evalengine.OrderByParams{{
+			WeightStringCol: -1,
+			Col:             1,
+			Type:            evalengine.NewType(sqltypes.Decimal, collations.CollationBinaryID),
+		}},
+		Input: fp,
+	}
+
+	// every row exceeds the memory of the query, so each of them is written to its own run
+	qm := NewQueryMemory(t.TempDir(), 1)
+	vc := &loggingVCursor{queryMemory: qm}
+
+	t.Run("merge runs", func(t *testing.T) {
+		spilled := spilledRows.Counts()["Sort"]
+		var results []*sqltypes.Result
+		err := ms.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+			results = append(results, qr)
+			return nil
+		})
+		require.NoError(t, err)
+
+		wantResults := sqltypes.MakeTestStreamingResults(
+			fields,
+			"b|null",
+			"a|1",
+			"a|1",
+			"g|2",
+			"c|3",
+			"c|4",
+			"null|5",
+		)
+		utils.MustMatch(t, wantResults, results)
+		require.Greater(t, spilledRows.Counts()["Sort"], spilled)
+		require.Zero(t, qm.Used())
+	})
+
+	t.Run("merge runs with limit", func(t *testing.T) {
+		fp.rewind()
+		ms.UpperLimit = evalengine.NewLiteralInt(3)
+		var results []*sqltypes.Result
+		err := ms.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+			results = append(results, qr)
+			return nil
+		})
+		require.NoError(t, err)
+
+		wantResults := sqltypes.MakeTestStreamingResults(
+			fields,
+			"b|null",
+			"a|1",
+			"a|1",
+		)
+		utils.MustMatch(t, wantResults, results)
+		require.Zero(t, qm.Used())
+	})
+}
+
 func TestMemorySortExecuteWeightString(t *testing.T) {
 	fields := sqltypes.MakeTestFields(
 		"c1|c2",
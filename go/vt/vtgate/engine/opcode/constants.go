@@ -200,3 +200,122 @@ func (code AggregateOpcode) IsDistinct() bool {
 		return false
 	}
 }
+
+// WindowOpcode is the window function Opcode.
+type WindowOpcode int
+
+// These constants list the possible window function opcodes.
+const (
+	WindowUnassigned = WindowOpcode(iota)
+	WindowRowNumber
+	WindowRank
+	WindowDenseRank
+	WindowPercentRank
+	WindowCumeDist
+	WindowLag
+	WindowLead
+	WindowFirstValue
+	WindowLastValue
+	// WindowAggregate is used for aggregation functions with an OVER clause.
+	// The aggregation itself is described by an AggregateOpcode
+	WindowAggregate
+	_NumOfWindowOpCodes // This line must be last of the opcodes!
+)
+
+// SupportedWindowFunctions maps the list of window functions
+// that can be evaluated on the vtgate to their opcodes.
+var SupportedWindowFunctions = map[string]WindowOpcode{
+	"row_number":   WindowRowNumber,
+	"rank":         WindowRank,
+	"dense_rank":   WindowDenseRank,
+	"percent_rank": WindowPercentRank,
+	"cume_dist":    WindowCumeDist,
+	"lag":          WindowLag,
+	"lead":         WindowLead,
+	"first_value":  WindowFirstValue,
+	"last_value":   WindowLastValue,
+}
+
+var WindowName = map[WindowOpcode]string{
+	WindowRowNumber:   "row_number",
+	WindowRank:        "rank",
+	WindowDenseRank:   "dense_rank",
+	WindowPercentRank: "percent_rank",
+	WindowCumeDist:    "cume_dist",
+	WindowLag:         "lag",
+	WindowLead:        "lead",
+	WindowFirstValue:  "first_value",
+	WindowLastValue:   "last_value",
+	WindowAggregate:   "aggregate",
+}
+
+func (code WindowOpcode) String() string {
+	name := WindowName[code]
+	if name == "" {
+		name = "ERROR"
+	}
+	return name
+}
+
+// MarshalJSON serializes the WindowOpcode as a JSON string.
+// It's used for testing and diagnostics.
+func (code WindowOpcode) MarshalJSON() ([]byte, error) {
+	return ([]byte)(fmt.Sprintf("\"%s\"", code.String())), nil
+}
+
+// SQLType returns the type of the window function, given the type of its argument.
+// For WindowAggregate, the type is decided by the AggregateOpcode of the aggregation
+func (code WindowOpcode) SQLType(typ querypb.Type) querypb.Type {
+	switch code {
+	case WindowUnassigned:
+		return sqltypes.Null
+	case WindowRowNumber, WindowRank, WindowDenseRank:
+		return sqltypes.Uint64
+	case WindowPercentRank, WindowCumeDist:
+		return sqltypes.Float64
+	case WindowLag, WindowLead, WindowFirstValue, WindowLastValue, WindowAggregate:
+		return typ
+	default:
+		panic(code.String()) // we have a unit test checking we never reach here
+	}
+}
+
+// Nullable returns true if the window function can produce NULL values
+func (code WindowOpcode) Nullable() bool {
+	switch code {
+	case WindowRowNumber, WindowRank, WindowDenseRank, WindowPercentRank, WindowCumeDist:
+		return false
+	default:
+		return true
+	}
+}
+
+// NeedsArgument returns true if the window function reads a value from its input rows
+func (code WindowOpcode) NeedsArgument() bool {
+	switch code {
+	case WindowLag, WindowLead, WindowFirstValue, WindowLastValue, WindowAggregate:
+		return true
+	default:
+		return false
+	}
+}
+
+// UsesFrame returns true if the window function is evaluated over the window frame
+// instead of over the whole partition
+func (code WindowOpcode) UsesFrame() bool {
+	switch code {
+	case WindowFirstValue, WindowLastValue, WindowAggregate:
+		return true
+	default:
+		return false
+	}
+}
+
+func (code WindowOpcode) ResolveType(t evalengine.Type, env *collations.Environment) evalengine.Type {
+	sqltype := code.SQLType(t.Type())
+	if sqltype == t.Type() {
+		return evalengine.NewTypeEx(sqltype, t.Collation(), true, t.Size(), t.Scale(), t.Values())
+	}
+	collation := collations.CollationForType(sqltype, env.DefaultConnectionCharset())
+	return evalengine.NewTypeEx(sqltype, collation, code.Nullable(), 0, 0, nil)
+}
@@ -161,3 +161,33 @@ func TestIsDistinct(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckAllWindowOpCodes(t *testing.T) {
+	// This test is just checking that we never reach the panic when using SQLType() on valid opcodes
+	for i := WindowOpcode(0); i < _NumOfWindowOpCodes; i++ {
+		i.SQLType(sqltypes.Null)
+	}
+}
+
+func TestWindowType(t *testing.T) {
+	tt := []struct {
+		opcode WindowOpcode
+		typ    querypb.Type
+		out    querypb.Type
+	}{
+		{WindowUnassigned, sqltypes.VarChar, sqltypes.Null},
+		{WindowRowNumber, sqltypes.Null, sqltypes.Uint64},
+		{WindowDenseRank, sqltypes.Null, sqltypes.Uint64},
+		{WindowCumeDist, sqltypes.Null, sqltypes.Float64},
+		{WindowLag, sqltypes.VarChar, sqltypes.VarChar},
+		{WindowLastValue, sqltypes.Int32, sqltypes.Int32},
+		{WindowAggregate, sqltypes.Decimal, sqltypes.Decimal},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.opcode.String()+"_"+tc.typ.String(), func(t *testing.T) {
+			out := tc.opcode.SQLType(tc.typ)
+			assert.Equal(t, tc.out, out)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -875,6 +876,67 @@ func TestMultiDistinct(t *testing.T) {
 	utils.MustMatch(t, want, results)
 }
 
+// TestMultiDistinctUnordered tests distinct aggregations over input that is not ordered by their columns.
+func TestMultiDistinctUnordered(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2|c3",
+		"int64|int64|int64",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			fields,
+			"10|2|3",
+			"10|3|1",
+			"10|2|3",
+			"10|null|1",
+			"20|null|null",
+			"30|1|2",
+			"30|2|1",
+			"30|1|2",
+			"30|3|1",
+		)},
+	}
+
+	intType := evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID)
+	countDistinct := NewAggregateParam(AggregateCountDistinct, 1, "count(distinct c2)", collations.MySQL8())
+	countDistinct.Type = intType
+	countDistinct.HashDistinct = true
+	sumDistinct := NewAggregateParam(AggregateSumDistinct, 2, "sum(distinct c3)", collations.MySQL8())
+	sumDistinct.Type = intType
+	sumDistinct.HashDistinct = true
+	oa := &OrderedAggregate{
+		Aggregates:  []*AggregateParams{countDistinct, sumDistinct},
+		GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+		Input:       fp,
+	}
+
+	want := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"c1|count(distinct c2)|sum(distinct c3)",
+			"int64|int64|decimal",
+		),
+		`10|2|4`,
+		`20|0|null`,
+		`30|3|3`,
+	)
+
+	qr, err := oa.TryExecute(context.Background(), &noopVCursor{}, nil, false)
+	require.NoError(t, err)
+	utils.MustMatch(t, want, qr)
+
+	fp.rewind()
+	results := &sqltypes.Result{}
+	err = oa.TryStreamExecute(context.Background(), &noopVCursor{}, nil, true, func(qr *sqltypes.Result) error {
+		if qr.Fields != nil {
+			results.Fields = qr.Fields
+		}
+		results.Rows = append(results.Rows, qr.Rows...)
+		return nil
+	})
+	require.NoError(t, err)
+	utils.MustMatch(t, want, results)
+}
+
 func TestOrderedAggregateCollate(t *testing.T) {
 	fields := sqltypes.MakeTestFields(
 		"col|count(*)",
@@ -1169,3 +1231,140 @@ func TestGroupConcat(t *testing.T) {
 		})
 	}
 }
+
+// TestGroupConcatFromRows tests group_concat evaluated on the engine from the rows of each group.
+func TestGroupConcatFromRows(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"c1|c2|c3|c4",
+		"int64|varchar|varchar|int64",
+	)
+
+	outFields := sqltypes.MakeTestFields(
+		"c1|group_concat(c2, c3)",
+		"int64|text",
+	)
+
+	input := sqltypes.MakeTestResult(fields,
+		"10|a|x|3", "10|b|y|1", "10|a|x|2", "10|A|x|4",
+		"20|c|null|1", "20|d|z|2",
+		"30|null|x|1",
+	)
+
+	var tcases = []struct {
+		name      string
+		distinct  bool
+		orderBy   evalengine.Comparison
+		sysVars   map[string]string
+		expResult *sqltypes.Result
+	}{{
+		name: "multiple arguments",
+		expResult: sqltypes.MakeTestResult(outFields,
+			`10|ax,by,ax,Ax`,
+			`20|dz`,
+			`30|null`),
+	}, {
+		name: "order by",
+		orderBy: evalengine.Comparison{{
+			Col:             3,
+			WeightStringCol: -1,
+			Desc:            true,
+			Type:            evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID),
+		}},
+		expResult: sqltypes.MakeTestResult(outFields,
+			`10|Ax,ax,ax,by`,
+			`20|dz`,
+			`30|null`),
+	}, {
+		name:     "distinct",
+		distinct: true,
+		expResult: sqltypes.MakeTestResult(outFields,
+			`10|ax,by`,
+			`20|dz`,
+			`30|null`),
+	}, {
+		name:     "distinct with order by",
+		distinct: true,
+		orderBy: evalengine.Comparison{{
+			Col:             3,
+			WeightStringCol: -1,
+			Type:            evalengine.NewType(sqltypes.Int64, collations.CollationBinaryID),
+		}},
+		expResult: sqltypes.MakeTestResult(outFields,
+			`10|by,ax`,
+			`20|dz`,
+			`30|null`),
+	}, {
+		name:    "group_concat_max_len",
+		sysVars: map[string]string{"group_concat_max_len": "7"},
+		expResult: sqltypes.MakeTestResult(outFields,
+			`10|ax,by,a`,
+			`20|dz`,
+			`30|null`),
+	}}
+
+	for _, tcase := range tcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			fp := &fakePrimitive{results: []*sqltypes.Result{input}}
+			varchar := evalengine.NewType(sqltypes.VarChar, collations.MySQL8().DefaultConnectionCharset())
+			agp := NewAggregateParam(AggregateGroupConcat, 1, "group_concat(c2, c3)", collations.MySQL8())
+			agp.Func = &sqlparser.GroupConcatExpr{Distinct: tcase.distinct, Separator: ","}
+			agp.GroupConcatArgs = []CheckCol{
+				{Col: 1, Type: varchar, CollationEnv: collations.MySQL8()},
+				{Col: 2, Type: varchar, CollationEnv: collations.MySQL8()},
+			}
+			agp.GroupConcatOrderBy = tcase.orderBy
+			oa := &OrderedAggregate{
+				Aggregates:          []*AggregateParams{agp},
+				GroupByKeys:         []*GroupByParams{{KeyCol: 0}},
+				TruncateColumnCount: 2,
+				Input:               fp,
+			}
+			vc := &loggingVCursor{systemVariables: tcase.sysVars}
+			qr, err := oa.TryExecute(context.Background(), vc, nil, false)
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResult, qr)
+
+			fp.rewind()
+			results := &sqltypes.Result{}
+			err = oa.TryStreamExecute(context.Background(), vc, nil, true, func(qr *sqltypes.Result) error {
+				if qr.Fields != nil {
+					results.Fields = qr.Fields
+				}
+				results.Rows = append(results.Rows, qr.Rows...)
+				return nil
+			})
+			require.NoError(t, err)
+			utils.MustMatch(t, tcase.expResult, results)
+		})
+	}
+}
+
+// TestGroupConcatMaxLen tests that the concatenated values are only truncated when the session sets group_concat_max_len.
+func TestGroupConcatMaxLen(t *testing.T) {
+	long := strings.Repeat("x", 2000)
+	fields := sqltypes.MakeTestFields("c1|group_concat(c2)", "int64|text")
+	input := sqltypes.MakeTestResult(fields, "10|"+long, "10|"+long)
+
+	for _, tcase := range []struct {
+		sysVars map[string]string
+		want    string
+	}{{
+		want: long + "," + long,
+	}, {
+		sysVars: map[string]string{"group_concat_max_len": "2010"},
+		want:    (long + "," + long)[:2010],
+	}} {
+		fp := &fakePrimitive{results: []*sqltypes.Result{input}}
+		agp := NewAggregateParam(AggregateGroupConcat, 1, "group_concat(c2)", collations.MySQL8())
+		agp.Func = &sqlparser.GroupConcatExpr{Separator: ","}
+		oa := &OrderedAggregate{
+			Aggregates:  []*AggregateParams{agp},
+			GroupByKeys: []*GroupByParams{{KeyCol: 0}},
+			Input:       fp,
+		}
+		qr, err := oa.TryExecute(context.Background(), &loggingVCursor{systemVariables: tcase.sysVars}, nil, false)
+		require.NoError(t, err)
+		require.Len(t, qr.Rows, 1)
+		assert.Equal(t, tcase.want, qr.Rows[0][1].ToString())
+	}
+}
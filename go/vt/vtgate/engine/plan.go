@@ -38,6 +38,8 @@ type Plan struct {
 	BindVarNeeds *sqlparser.BindVarNeeds // Stores BindVars needed to be provided as part of expression rewriting
 	Warnings     []*query.QueryWarning   // Warnings that need to be yielded every time this query runs
 	TablesUsed   []string                // TablesUsed is the list of tables that this plan will query
+	// ResultCacheTTL is how long vtgate may cache the results of this plan, if it is not zero.
+	ResultCacheTTL time.Duration
 
 	ExecCount    uint64 // Count of times this plan was executed
 	ExecTime     uint64 // Total execution time
@@ -87,6 +89,7 @@ func (p *Plan) MarshalJSON() ([]byte, error) {
 		RowsReturned uint64                `json:",omitempty"`
 		Errors       uint64                `json:",omitempty"`
 		TablesUsed   []string              `json:",omitempty"`
+		ResultCache  string                `json:",omitempty"`
 	}{
 		QueryType:    p.Type.String(),
 		Original:     p.Original,
@@ -99,6 +102,9 @@ func (p *Plan) MarshalJSON() ([]byte, error) {
 		Errors:       atomic.LoadUint64(&p.Errors),
 		TablesUsed:   p.TablesUsed,
 	}
+	if p.ResultCacheTTL > 0 {
+		marshalPlan.ResultCache = p.ResultCacheTTL.String()
+	}
 
 	b := new(bytes.Buffer)
 	enc := json.NewEncoder(b)
@@ -56,6 +56,10 @@ type (
 		// if the max memory rows override directive is set to true
 		ExceedsMaxMemoryRows(numRows int) bool
 
+		// QueryMemory returns the memory accounting of the query, used by the primitives
+		// that buffer rows to spill them to disk. Returns nil if spilling is disabled.
+		QueryMemory() *QueryMemory
+
 		Execute(ctx context.Context, method string, query string, bindVars map[string]*querypb.BindVariable, rollbackOnError bool, co vtgatepb.CommitOrder) (*sqltypes.Result, error)
 		AutocommitApproval() bool
 
@@ -172,6 +176,9 @@ type (
 		SetSkipQueryPlanCache(context.Context, bool) error
 		SetSQLSelectLimit(int64) error
 		SetTransactionMode(vtgatepb.TransactionMode)
+		// TransactionMode returns the transaction mode in effect for the session,
+		// falling back to the vtgate default when the session does not set one.
+		TransactionMode() vtgatepb.TransactionMode
 		SetWorkload(querypb.ExecuteOptions_Workload)
 		SetPlannerVersion(querypb.ExecuteOptions_PlannerVersion)
 		SetConsolidator(querypb.ExecuteOptions_Consolidator)
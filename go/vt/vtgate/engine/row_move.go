@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/key"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/srvtopo"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vtgate/vindexes"
+)
+
+// RowMove contains the instructions to move the rows of an update that changes
+// the primary vindex columns to the shards that own their new keyspace ids.
+// The update itself runs in place; the rows are then read back, inserted on
+// their new shard and deleted from the old one.
+// An Update with a RowMove lists the primary vindex first in its Vindexes.
+type RowMove struct {
+	// Table is the name of the updated table.
+	Table string
+
+	// PKOffsets are the offsets of the primary key columns in the result of the owned vindex query.
+	PKOffsets []int
+	// PKValues are the values the update assigns to the primary key columns,
+	// nil for the columns it does not change.
+	PKValues []evalengine.Expr
+
+	// SelectQuery reads the rows to move by their primary key, DeleteQuery removes them.
+	SelectQuery string
+	DeleteQuery string
+
+	// AllowNonAtomic allows moving rows when the transaction mode is not TWOPC.
+	AllowNonAtomic bool
+}
+
+// rowsToMove contains the new primary key values of the updated rows
+// that have to be moved out of a shard.
+type rowsToMove struct {
+	from *srvtopo.ResolvedShard
+	pks  []sqltypes.Row
+}
+
+func (rm *RowMove) description() map[string]any {
+	other := map[string]any{
+		"SelectQuery": rm.SelectQuery,
+		"DeleteQuery": rm.DeleteQuery,
+	}
+	if rm.AllowNonAtomic {
+		other["AllowNonAtomic"] = true
+	}
+	return other
+}
+
+// newPrimaryKey returns the values of the primary key columns of the row once the update is applied.
+func (rm *RowMove) newPrimaryKey(env *evalengine.ExpressionEnv, vcursor VCursor, row sqltypes.Row) (sqltypes.Row, error) {
+	pk := make(sqltypes.Row, 0, len(rm.PKOffsets))
+	for i, offset := range rm.PKOffsets {
+		if rm.PKValues[i] == nil {
+			pk = append(pk, row[offset])
+			continue
+		}
+		res, err := env.Evaluate(rm.PKValues[i])
+		if err != nil {
+			return nil, err
+		}
+		pk = append(pk, res.Value(vcursor.ConnCollation()))
+	}
+	return pk, nil
+}
+
+// insertQuery builds the statement inserting the rows read by SelectQuery.
+func (rm *RowMove) insertQuery(fields []*querypb.Field, rows []sqltypes.Row) (string, map[string]*querypb.BindVariable) {
+	columns := make(sqlparser.Columns, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, sqlparser.NewIdentifierCI(field.Name))
+	}
+	bindVars := make(map[string]*querypb.BindVariable, len(rows)*len(fields))
+	values := make(sqlparser.Values, 0, len(rows))
+	for i, row := range rows {
+		tuple := make(sqlparser.ValTuple, 0, len(row))
+		for j, val := range row {
+			name := fmt.Sprintf("_mv%d_%d", i, j)
+			bindVars[name] = sqltypes.ValueBindVariable(val)
+			tuple = append(tuple, sqlparser.NewArgument(name))
+		}
+		values = append(values, tuple)
+	}
+	ins := &sqlparser.Insert{
+		Table:   sqlparser.NewAliasedTableExpr(sqlparser.NewTableName(rm.Table), ""),
+		Columns: columns,
+		Rows:    values,
+	}
+	return sqlparser.String(ins), bindVars
+}
+
+// newKeyspaceID returns the keyspace id of the row once the update has changed its primary vindex columns.
+func (upd *Update) newKeyspaceID(ctx context.Context, vcursor VCursor, env *evalengine.ExpressionEnv, row sqltypes.Row, ksid []byte) ([]byte, error) {
+	if upd.RowMove == nil {
+		return ksid, nil
+	}
+	primary := upd.Vindexes[0]
+	updColValues, ok := upd.ChangedVindexValues[primary.Name]
+	if !ok {
+		return ksid, nil
+	}
+	unchanged, err := vindexValuesUnchanged(row, updColValues.Offset)
+	if err != nil || unchanged {
+		return ksid, err
+	}
+	// The primary vindex columns are the first columns of the owned vindex query.
+	newValues := make([]sqltypes.Value, 0, len(primary.Columns))
+	for i, col := range primary.Columns {
+		colValue, ok := updColValues.EvalExprMap[col.String()]
+		if !ok {
+			newValues = append(newValues, row[i])
+			continue
+		}
+		resolvedVal, err := env.Evaluate(colValue)
+		if err != nil {
+			return nil, err
+		}
+		newValues = append(newValues, resolvedVal.Value(vcursor.ConnCollation()))
+	}
+	return resolveKeyspaceID(ctx, vcursor, upd.KsidVindex, newValues)
+}
+
+// findRowMoves groups the rows whose new keyspace id lives on another shard by their current shard.
+// Moving rows between shards is refused unless it can be done atomically or the user allowed it.
+func (upd *Update) findRowMoves(ctx context.Context, vcursor VCursor, env *evalengine.ExpressionEnv, rows []sqltypes.Row, ksids, newKsids [][]byte) ([]*rowsToMove, error) {
+	var moves []*rowsToMove
+	byShard := make(map[string]*rowsToMove)
+	for i, row := range rows {
+		if bytes.Equal(ksids[i], newKsids[i]) {
+			continue
+		}
+		from, err := upd.resolveShard(ctx, vcursor, ksids[i])
+		if err != nil {
+			return nil, err
+		}
+		to, err := upd.resolveShard(ctx, vcursor, newKsids[i])
+		if err != nil {
+			return nil, err
+		}
+		if from.Target.Shard == to.Target.Shard {
+			continue
+		}
+		if !upd.RowMove.AllowNonAtomic && vcursor.Session().TransactionMode() != vtgatepb.TransactionMode_TWOPC {
+			return nil, vterrors.VT09031()
+		}
+		pk, err := upd.RowMove.newPrimaryKey(env, vcursor, row)
+		if err != nil {
+			return nil, err
+		}
+		move, ok := byShard[from.Target.Shard]
+		if !ok {
+			move = &rowsToMove{from: from}
+			byShard[from.Target.Shard] = move
+			moves = append(moves, move)
+		}
+		move.pks = append(move.pks, pk)
+	}
+	return moves, nil
+}
+
+// reassignVindexEntries points the owned vindex entries of a row at its new keyspace id.
+func (upd *Update) reassignVindexEntries(ctx context.Context, vcursor VCursor, env *evalengine.ExpressionEnv, fieldColNumMap map[string]int, row sqltypes.Row, ksid, newKsid []byte) error {
+	for _, colVindex := range upd.Vindexes[1:] {
+		updColValues := upd.ChangedVindexValues[colVindex.Name]
+		if !colVindex.Owned && updColValues == nil {
+			// Vindexes that are not owned by the table are maintained outside of Vitess.
+			continue
+		}
+		fromIds, vindexColumnKeys, err := vindexRowValues(env, vcursor, fieldColNumMap, row, colVindex, updColValues)
+		if err != nil {
+			return err
+		}
+		if !colVindex.Owned {
+			if err := verifyVindexValues(ctx, vcursor, colVindex, vindexColumnKeys, newKsid); err != nil {
+				return err
+			}
+			continue
+		}
+		lookup := colVindex.Vindex.(vindexes.Lookup)
+		if err := lookup.Delete(ctx, vcursor, [][]sqltypes.Value{fromIds}, ksid); err != nil {
+			return err
+		}
+		if err := lookup.Create(ctx, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{newKsid}, false /* ignoreMode */); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveRows moves the updated rows out of the shards that no longer own them.
+// The rows are inserted on their new shard before they are deleted from the old one.
+func (upd *Update) moveRows(ctx context.Context, vcursor VCursor, moves []*rowsToMove) error {
+	for _, move := range moves {
+		var pks *querypb.BindVariable
+		if len(upd.RowMove.PKOffsets) == 1 {
+			pks = getBVSingle(move.pks, 0)
+		} else {
+			offsets := make([]int, len(upd.RowMove.PKOffsets))
+			for i := range offsets {
+				offsets[i] = i
+			}
+			pks = getBVMulti(move.pks, offsets)
+		}
+		bindVars := map[string]*querypb.BindVariable{DmlVals: pks}
+
+		qr, err := execShard(ctx, upd, vcursor, upd.RowMove.SelectQuery, bindVars, move.from, false /* rollbackOnError */, false /* canAutocommit */)
+		if err != nil {
+			return err
+		}
+		if err := upd.insertMovedRows(ctx, vcursor, qr); err != nil {
+			return err
+		}
+		if _, err := execShard(ctx, upd, vcursor, upd.RowMove.DeleteQuery, bindVars, move.from, true /* rollbackOnError */, false /* canAutocommit */); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertMovedRows inserts the rows on the shards owning their keyspace ids.
+func (upd *Update) insertMovedRows(ctx context.Context, vcursor VCursor, qr *sqltypes.Result) error {
+	primary := upd.Vindexes[0]
+	colOffsets := make([]int, len(primary.Columns))
+	for i, col := range primary.Columns {
+		colOffsets[i] = -1
+		for j, field := range qr.Fields {
+			if col.EqualString(field.Name) {
+				colOffsets[i] = j
+				break
+			}
+		}
+		if colOffsets[i] == -1 {
+			return vterrors.VT13001(fmt.Sprintf("primary vindex column %s not found in the moved rows of %s", col.String(), upd.RowMove.Table))
+		}
+	}
+
+	var shards []*srvtopo.ResolvedShard
+	rowsByShard := make(map[string][]sqltypes.Row)
+	for _, row := range qr.Rows {
+		vindexKey := make([]sqltypes.Value, 0, len(colOffsets))
+		for _, offset := range colOffsets {
+			vindexKey = append(vindexKey, row[offset])
+		}
+		ksid, err := resolveKeyspaceID(ctx, vcursor, upd.KsidVindex, vindexKey)
+		if err != nil {
+			return err
+		}
+		rs, err := upd.resolveShard(ctx, vcursor, ksid)
+		if err != nil {
+			return err
+		}
+		if _, ok := rowsByShard[rs.Target.Shard]; !ok {
+			shards = append(shards, rs)
+		}
+		rowsByShard[rs.Target.Shard] = append(rowsByShard[rs.Target.Shard], row)
+	}
+
+	for _, rs := range shards {
+		query, bindVars := upd.RowMove.insertQuery(qr.Fields, rowsByShard[rs.Target.Shard])
+		if _, err := execShard(ctx, upd, vcursor, query, bindVars, rs, true /* rollbackOnError */, false /* canAutocommit */); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (upd *Update) resolveShard(ctx context.Context, vcursor VCursor, ksid []byte) (*srvtopo.ResolvedShard, error) {
+	rss, _, err := vcursor.ResolveDestinations(ctx, upd.Keyspace.Name, nil, []key.Destination{key.DestinationKeyspaceID(ksid)})
+	if err != nil {
+		return nil, err
+	}
+	if len(rss) != 1 {
+		return nil, vterrors.VT13001(fmt.Sprintf("keyspace id %x resolved to %d shards", ksid, len(rss)))
+	}
+	return rss[0], nil
+}
@@ -64,7 +64,7 @@ func (sa *ScalarAggregate) GetFields(ctx context.Context, vcursor VCursor, bindV
 		return nil, err
 	}
 
-	_, fields, err := newAggregation(qr.Fields, sa.Aggregates)
+	_, fields, err := newAggregation(vcursor, qr.Fields, sa.Aggregates)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +85,7 @@ func (sa *ScalarAggregate) TryExecute(ctx context.Context, vcursor VCursor, bind
 		return nil, err
 	}
 
-	agg, fields, err := newAggregation(result.Fields, sa.Aggregates)
+	agg, fields, err := newAggregation(vcursor, result.Fields, sa.Aggregates)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +123,7 @@ func (sa *ScalarAggregate) TryStreamExecute(ctx context.Context, vcursor VCursor
 
 		if agg == nil && len(result.Fields) != 0 {
 			var err error
-			agg, fields, err = newAggregation(result.Fields, sa.Aggregates)
+			agg, fields, err = newAggregation(vcursor, result.Fields, sa.Aggregates)
 			if err != nil {
 				return err
 			}
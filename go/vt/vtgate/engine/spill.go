@@ -0,0 +1,294 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+	"vitess.io/vitess/go/vt/vthash"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	// spillPartitionCount is the number of partitions the rows of a hash join
+	// or a distinct are split into when they do not fit in memory.
+	spillPartitionCount = 16
+
+	// spillBatchRows is the number of rows sent at once when reading rows back from disk.
+	spillBatchRows = 1000
+
+	// minSpillShare is the inverse of the smallest share of the memory of a query
+	// that a primitive must hold before it writes its rows to disk.
+	minSpillShare = 8
+
+	// valueMemorySize is the estimated memory used by a sqltypes.Value, not counting its contents.
+	valueMemorySize = 32
+)
+
+var spilledRows = stats.NewCountersWithSingleLabel(
+	"SpilledRows",
+	"Number of rows written to temporary files by primitives that exceeded the memory limit of their query",
+	"OperatorType")
+
+// QueryMemory accounts for the memory used by the rows that the primitives of a query
+// keep in memory. Primitives that need to buffer their input, such as sorts, hash joins
+// and distincts, write their rows to temporary files in the spill directory once the memory
+// used by the query exceeds its limit, and read them back when they produce their output.
+// Only streaming execution spills: TryExecute receives the whole input of a primitive in a
+// single result, so there is nothing left to move out of memory.
+// A nil QueryMemory disables spilling, and the primitives enforce the max memory rows instead.
+type QueryMemory struct {
+	dir   string
+	limit int64
+	used  atomic.Int64
+}
+
+// NewQueryMemory returns the memory accounting for a single query.
+func NewQueryMemory(dir string, limit int64) *QueryMemory {
+	return &QueryMemory{dir: dir, limit: limit}
+}
+
+// Used returns the memory currently used by the buffered rows of the query.
+func (qm *QueryMemory) Used() int64 {
+	return qm.used.Load()
+}
+
+// grow adds memory used by a primitive that now holds owned bytes of the memory of the query,
+// and returns true if the primitive should write its rows to disk: when the query uses more memory
+// than its limit, and the primitive holds a meaningful share of it. This keeps a primitive with only
+// a few rows from writing tiny files when the memory of the query is held by other primitives.
+func (qm *QueryMemory) grow(size, owned int64) bool {
+	return qm.used.Add(size) > qm.limit && owned*minSpillShare >= qm.limit
+}
+
+// release gives back memory previously added with grow.
+func (qm *QueryMemory) release(size int64) {
+	qm.used.Add(-size)
+}
+
+// rowMemorySize estimates the memory used by a row.
+func rowMemorySize(row sqltypes.Row) int64 {
+	size := int64(len(row)) * valueMemorySize
+	for _, v := range row {
+		size += int64(len(v.Raw()))
+	}
+	return size
+}
+
+// spillFile is a temporary file rows are written to, and read back from once all of them are written.
+type spillFile struct {
+	file *os.File
+	w    *bufio.Writer
+	r    *bufio.Reader
+	buf  []byte
+}
+
+// newSpillFile creates a temporary file in the spill directory of the query.
+// The file is unlinked right away, so that it is removed once it is closed,
+// even if vtgate does not get the chance to do so.
+func (qm *QueryMemory) newSpillFile() (*spillFile, error) {
+	f, err := os.CreateTemp(qm.dir, "vtgate-spill-")
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "could not create spill file")
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		f.Close()
+		return nil, vterrors.Wrapf(err, "could not unlink spill file")
+	}
+	return &spillFile{file: f, w: bufio.NewWriter(f)}, nil
+}
+
+// write appends a row to the file. Every value is encoded as its type and length followed
+// by its contents, so the rows can be read back without knowing the fields of the result.
+func (sf *spillFile) write(row sqltypes.Row) error {
+	sf.buf = binary.AppendUvarint(sf.buf[:0], uint64(len(row)))
+	for _, v := range row {
+		sf.buf = binary.AppendUvarint(sf.buf, uint64(v.Type()))
+		sf.buf = binary.AppendUvarint(sf.buf, uint64(len(v.Raw())))
+		sf.buf = append(sf.buf, v.Raw()...)
+	}
+	if _, err := sf.w.Write(sf.buf); err != nil {
+		return vterrors.Wrapf(err, "could not write spill file")
+	}
+	return nil
+}
+
+// rewind flushes the rows written so far, and prepares the file for reading them back from the start.
+func (sf *spillFile) rewind() error {
+	if err := sf.w.Flush(); err != nil {
+		return vterrors.Wrapf(err, "could not write spill file")
+	}
+	if _, err := sf.file.Seek(0, io.SeekStart); err != nil {
+		return vterrors.Wrapf(err, "could not read spill file")
+	}
+	sf.r = bufio.NewReader(sf.file)
+	return nil
+}
+
+// read returns the next row of the file, or nil once all the rows have been read.
+func (sf *spillFile) read() (sqltypes.Row, error) {
+	cols, err := binary.ReadUvarint(sf.r)
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "could not read spill file")
+	}
+	row := make(sqltypes.Row, cols)
+	for i := range row {
+		typ, err := binary.ReadUvarint(sf.r)
+		if err != nil {
+			return nil, spillFileCorrupted(err)
+		}
+		length, err := binary.ReadUvarint(sf.r)
+		if err != nil {
+			return nil, spillFileCorrupted(err)
+		}
+		val := make([]byte, length)
+		if _, err := io.ReadFull(sf.r, val); err != nil {
+			return nil, spillFileCorrupted(err)
+		}
+		row[i] = sqltypes.MakeTrusted(querypb.Type(typ), val)
+	}
+	return row, nil
+}
+
+// readAll reads back all the rows of the file, and calls f for each of them.
+func (sf *spillFile) readAll(f func(sqltypes.Row) error) error {
+	if err := sf.rewind(); err != nil {
+		return err
+	}
+	for {
+		row, err := sf.read()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			return nil
+		}
+		if err := f(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (sf *spillFile) close() {
+	sf.file.Close()
+}
+
+func spillFileCorrupted(err error) error {
+	return vterrors.Errorf(vtrpcpb.Code_INTERNAL, "spill file is corrupted: %v", err)
+}
+
+// spillPartitions splits rows between several spill files by their hash code,
+// so that rows with the same hash code end up in the same file.
+type spillPartitions struct {
+	files []*spillFile
+}
+
+func newSpillPartitions(qm *QueryMemory) (*spillPartitions, error) {
+	sp := &spillPartitions{}
+	for i := 0; i < spillPartitionCount; i++ {
+		sf, err := qm.newSpillFile()
+		if err != nil {
+			sp.close()
+			return nil, err
+		}
+		sp.files = append(sp.files, sf)
+	}
+	return sp, nil
+}
+
+func (sp *spillPartitions) add(hash vthash.Hash, row sqltypes.Row) error {
+	return sp.files[binary.LittleEndian.Uint64(hash[:8])%uint64(len(sp.files))].write(row)
+}
+
+func (sp *spillPartitions) close() {
+	if sp == nil {
+		return
+	}
+	for _, sf := range sp.files {
+		sf.close()
+	}
+}
+
+// mergeSpilledRuns merges the sorted rows of the given spill files and the given sorted rows that
+// are still in memory, and sends at most limit of them to the callback, in batches.
+func mergeSpilledRuns(compare evalengine.Comparison, runs []*spillFile, inMemory []sqltypes.Row, limit int, callback func(*sqltypes.Result) error) error {
+	next := func(source int) (sqltypes.Row, error) {
+		if source < len(runs) {
+			return runs[source].read()
+		}
+		if len(inMemory) == 0 {
+			return nil, nil
+		}
+		row := inMemory[0]
+		inMemory = inMemory[1:]
+		return row, nil
+	}
+
+	merge := &evalengine.Merger{Compare: compare}
+	for _, run := range runs {
+		if err := run.rewind(); err != nil {
+			return err
+		}
+	}
+	for source := 0; source <= len(runs); source++ {
+		row, err := next(source)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			merge.Push(row, source)
+		}
+	}
+	merge.Init()
+
+	var rows []sqltypes.Row
+	for sent := 0; merge.Len() > 0 && sent < limit; sent++ {
+		row, source := merge.Pop()
+		rows = append(rows, row)
+		if len(rows) == spillBatchRows {
+			if err := callback(&sqltypes.Result{Rows: rows}); err != nil {
+				return err
+			}
+			rows = nil
+		}
+		row, err := next(source)
+		if err != nil {
+			return err
+		}
+		if row != nil {
+			merge.Push(row, source)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return callback(&sqltypes.Result{Rows: rows})
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+)
+
+func TestSpillFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	qm := NewQueryMemory(dir, 1024)
+	sf, err := qm.newSpillFile()
+	require.NoError(t, err)
+	defer sf.close()
+
+	// the file is unlinked as soon as it is created
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	rows := []sqltypes.Row{
+		{sqltypes.NewInt64(-1), sqltypes.NewVarChar("abc"), sqltypes.NULL},
+		{sqltypes.NewUint64(1 << 63), sqltypes.NewVarChar(""), sqltypes.NewDecimal("1.50")},
+		{},
+		{sqltypes.NewVarBinary(string([]byte{0, 1, 2, 255})), sqltypes.NewFloat64(2.5), sqltypes.NewDatetime("2024-01-01 00:00:00")},
+	}
+	for _, row := range rows {
+		require.NoError(t, sf.write(row))
+	}
+
+	var got []sqltypes.Row
+	require.NoError(t, sf.readAll(func(row sqltypes.Row) error {
+		got = append(got, row)
+		return nil
+	}))
+	require.Len(t, got, len(rows))
+	for i := range rows {
+		require.Len(t, got[i], len(rows[i]))
+		for j := range rows[i] {
+			require.Equal(t, rows[i][j].String(), got[i][j].String())
+		}
+	}
+}
+
+func TestQueryMemory(t *testing.T) {
+	qm := NewQueryMemory(t.TempDir(), 100)
+
+	// the query is below its limit
+	require.False(t, qm.grow(60, 60))
+	// the query exceeds its limit, and the primitive holds enough of the memory to spill
+	require.True(t, qm.grow(60, 60))
+	qm.release(60)
+	// the query exceeds its limit, but the primitive only holds a few bytes of it
+	require.False(t, qm.grow(60, 5))
+	qm.release(120)
+	require.Zero(t, qm.Used())
+}
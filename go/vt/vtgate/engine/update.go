@@ -17,6 +17,7 @@ limitations under the License.
 package engine
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sort"
@@ -49,6 +50,9 @@ type Update struct {
 
 	// ChangedVindexValues contains values for updated Vindexes during an update statement.
 	ChangedVindexValues map[string]*VindexValues
+
+	// RowMove is set when the update is allowed to change the primary vindex columns.
+	RowMove *RowMove
 }
 
 // TryExecute performs a non-streaming exec.
@@ -66,7 +70,19 @@ func (upd *Update) TryExecute(ctx context.Context, vcursor VCursor, bindVars map
 	case Unsharded:
 		return upd.execUnsharded(ctx, upd, vcursor, bindVars, rss)
 	case Equal, EqualUnique, IN, Scatter, ByDestination, SubShard, MultiEqual:
-		return upd.execMultiDestination(ctx, upd, vcursor, bindVars, rss, upd.updateVindexEntries, bvs)
+		var moves []*rowsToMove
+		updateVindexEntries := func(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rss []*srvtopo.ResolvedShard) (err error) {
+			moves, err = upd.updateVindexEntries(ctx, vcursor, bindVars, rss)
+			return err
+		}
+		qr, err := upd.execMultiDestination(ctx, upd, vcursor, bindVars, rss, updateVindexEntries, bvs)
+		if err != nil {
+			return nil, err
+		}
+		if err := upd.moveRows(ctx, vcursor, moves); err != nil {
+			return nil, err
+		}
+		return qr, nil
 	default:
 		// Unreachable.
 		return nil, fmt.Errorf("unsupported opcode: %v", upd.Opcode)
@@ -89,14 +105,15 @@ func (upd *Update) GetFields(ctx context.Context, vcursor VCursor, bindVars map[
 }
 
 // updateVindexEntries performs an update when a vindex is being modified
-// by the statement.
+// by the statement. It returns the rows that have to be moved to another
+// shard because the update changes their keyspace id.
 // Note: the commit order may be different from the DML order because it's possible
 // for DMLs to reuse existing transactions.
 // Note 2: While changes are being committed, the changing row could be
 // unreachable by either the new or old column values.
-func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rss []*srvtopo.ResolvedShard) error {
+func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, rss []*srvtopo.ResolvedShard) ([]*rowsToMove, error) {
 	if len(upd.ChangedVindexValues) == 0 {
-		return nil
+		return nil, nil
 	}
 	queries := make([]*querypb.BoundQuery, len(rss))
 	for i := range rss {
@@ -105,12 +122,12 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 	subQueryResult, errors := vcursor.ExecuteMultiShard(ctx, upd, rss, queries, false /* rollbackOnError */, false /* canAutocommit */)
 	for _, err := range errors {
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	if len(subQueryResult.Rows) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	fieldColNumMap := make(map[string]int)
@@ -119,78 +136,136 @@ func (upd *Update) updateVindexEntries(ctx context.Context, vcursor VCursor, bin
 	}
 	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
 
-	for _, row := range subQueryResult.Rows {
+	ksids := make([][]byte, len(subQueryResult.Rows))
+	newKsids := make([][]byte, len(subQueryResult.Rows))
+	for i, row := range subQueryResult.Rows {
 		ksid, err := resolveKeyspaceID(ctx, vcursor, upd.KsidVindex, row[0:upd.KsidLength])
 		if err != nil {
-			return err
+			return nil, err
+		}
+		ksids[i] = ksid
+		newKsids[i], err = upd.newKeyspaceID(ctx, vcursor, env, row, ksid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Rows that move between shards are all checked before any vindex entry is changed.
+	moves, err := upd.findRowMoves(ctx, vcursor, env, subQueryResult.Rows, ksids, newKsids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range subQueryResult.Rows {
+		ksid, newKsid := ksids[i], newKsids[i]
+		if !bytes.Equal(ksid, newKsid) {
+			if err := upd.reassignVindexEntries(ctx, vcursor, env, fieldColNumMap, row, ksid, newKsid); err != nil {
+				return nil, err
+			}
+			continue
 		}
 
-		for _, colVindex := range upd.Vindexes {
+		for i, colVindex := range upd.Vindexes {
+			if i == 0 && upd.RowMove != nil {
+				// The primary vindex of a row is changed by moving the row.
+				continue
+			}
 			// Skip this vindex if no rows are being changed
 			updColValues, ok := upd.ChangedVindexValues[colVindex.Name]
 			if !ok {
 				continue
 			}
 
-			offset := updColValues.Offset
-			if !row[offset].IsNull() {
-				val, err := row[offset].ToCastInt64()
-				if err != nil {
-					return err
-				}
-				if val == int64(1) { // 1 means that the old and new value are same and vindex update is not required.
-					continue
-				}
+			unchanged, err := vindexValuesUnchanged(row, updColValues.Offset)
+			if err != nil {
+				return nil, err
+			}
+			if unchanged {
+				continue
 			}
 
-			fromIds := make([]sqltypes.Value, 0, len(colVindex.Columns))
-			var vindexColumnKeys []sqltypes.Value
-			for _, vCol := range colVindex.Columns {
-				// Fetch the column values.
-				origColValue := row[fieldColNumMap[vCol.String()]]
-				fromIds = append(fromIds, origColValue)
-				if colValue, exists := updColValues.EvalExprMap[vCol.String()]; exists {
-					resolvedVal, err := env.Evaluate(colValue)
-					if err != nil {
-						return err
-					}
-					vindexColumnKeys = append(vindexColumnKeys, resolvedVal.Value(vcursor.ConnCollation()))
-				} else {
-					// Set the column value to original as this column in vindex is not updated.
-					vindexColumnKeys = append(vindexColumnKeys, origColValue)
-				}
+			fromIds, vindexColumnKeys, err := vindexRowValues(env, vcursor, fieldColNumMap, row, colVindex, updColValues)
+			if err != nil {
+				return nil, err
 			}
 
 			if colVindex.Owned {
 				if err := colVindex.Vindex.(vindexes.Lookup).Update(ctx, vcursor, fromIds, ksid, vindexColumnKeys); err != nil {
-					return err
-				}
-			} else {
-				allNulls := true
-				for _, key := range vindexColumnKeys {
-					allNulls = key.IsNull()
-					if !allNulls {
-						break
-					}
+					return nil, err
 				}
+			} else if err := verifyVindexValues(ctx, vcursor, colVindex, vindexColumnKeys, ksid); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return moves, nil
+}
 
-				// All columns for this Vindex are set to null, so we can skip verification
-				if allNulls {
-					continue
-				}
+// vindexValuesUnchanged reports whether the owned vindex query found the
+// new vindex values of the row to be the same as the old ones.
+func vindexValuesUnchanged(row sqltypes.Row, offset int) (bool, error) {
+	if row[offset].IsNull() {
+		return false, nil
+	}
+	val, err := row[offset].ToCastInt64()
+	if err != nil {
+		return false, err
+	}
+	// 1 means that the old and new value are same and vindex update is not required.
+	return val == int64(1), nil
+}
 
-				// If values were supplied, we validate against keyspace id.
-				verified, err := vindexes.Verify(ctx, colVindex.Vindex, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{ksid})
-				if err != nil {
-					return err
-				}
+// vindexRowValues returns the old and new values of the vindex columns of the row.
+// A nil updColValues means that the update does not change the vindex.
+func vindexRowValues(env *evalengine.ExpressionEnv, vcursor VCursor, fieldColNumMap map[string]int, row sqltypes.Row, colVindex *vindexes.ColumnVindex, updColValues *VindexValues) (fromIds, vindexColumnKeys []sqltypes.Value, err error) {
+	fromIds = make([]sqltypes.Value, 0, len(colVindex.Columns))
+	for _, vCol := range colVindex.Columns {
+		// Fetch the column values.
+		origColValue := row[fieldColNumMap[vCol.String()]]
+		fromIds = append(fromIds, origColValue)
+		var colValue evalengine.Expr
+		if updColValues != nil {
+			colValue = updColValues.EvalExprMap[vCol.String()]
+		}
+		if colValue == nil {
+			// Set the column value to original as this column in vindex is not updated.
+			vindexColumnKeys = append(vindexColumnKeys, origColValue)
+			continue
+		}
+		resolvedVal, err := env.Evaluate(colValue)
+		if err != nil {
+			return nil, nil, err
+		}
+		vindexColumnKeys = append(vindexColumnKeys, resolvedVal.Value(vcursor.ConnCollation()))
+	}
+	return fromIds, vindexColumnKeys, nil
+}
 
-				if !verified[0] {
-					return fmt.Errorf("values %v for column %v does not map to keyspace ids", vindexColumnKeys, colVindex.Columns)
-				}
-			}
+// verifyVindexValues checks that the values of a vindex that is not owned
+// by the table map to the keyspace id of the row.
+func verifyVindexValues(ctx context.Context, vcursor VCursor, colVindex *vindexes.ColumnVindex, vindexColumnKeys []sqltypes.Value, ksid []byte) error {
+	allNulls := true
+	for _, key := range vindexColumnKeys {
+		allNulls = key.IsNull()
+		if !allNulls {
+			break
 		}
 	}
+
+	// All columns for this Vindex are set to null, so we can skip verification
+	if allNulls {
+		return nil
+	}
+
+	// If values were supplied, we validate against keyspace id.
+	verified, err := vindexes.Verify(ctx, colVindex.Vindex, vcursor, [][]sqltypes.Value{vindexColumnKeys}, [][]byte{ksid})
+	if err != nil {
+		return err
+	}
+
+	if !verified[0] {
+		return fmt.Errorf("values %v for column %v does not map to keyspace ids", vindexColumnKeys, colVindex.Columns)
+	}
 	return nil
 }
 
@@ -214,6 +289,9 @@ func (upd *Update) description() PrimitiveDescription {
 	if len(changedVindexes) > 0 {
 		other["ChangedVindexValues"] = changedVindexes
 	}
+	if upd.RowMove != nil {
+		other["RowMove"] = upd.RowMove.description()
+	}
 
 	return PrimitiveDescription{
 		OperatorType:     "Update",
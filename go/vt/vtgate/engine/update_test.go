@@ -33,6 +33,7 @@ import (
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	vschemapb "vitess.io/vitess/go/vt/proto/vschema"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
 )
 
 func TestUpdateUnsharded(t *testing.T) {
@@ -588,6 +589,118 @@ func TestUpdateScatterChangedVindex(t *testing.T) {
 
 }
 
+func TestUpdateChangedPrimaryVindex(t *testing.T) {
+	ks := buildTestVSchema().Keyspaces["sharded"]
+	upd := &Update{
+		DML: &DML{
+			RoutingParameters: &RoutingParameters{
+				Opcode:   Equal,
+				Keyspace: ks.Keyspace,
+				Vindex:   ks.Vindexes["hash"],
+				Values:   []evalengine.Expr{evalengine.NewLiteralInt(1)},
+			},
+			Query:             "dummy_update",
+			TableNames:        []string{ks.Tables["t1"].Name.String()},
+			Vindexes:          ks.Tables["t1"].ColumnVindexes,
+			OwnedVindexQuery:  "dummy_subquery",
+			KsidVindex:        ks.Vindexes["hash"],
+			KsidLength:        1,
+			PreventAutoCommit: true,
+		},
+		ChangedVindexValues: map[string]*VindexValues{
+			"hash": {
+				EvalExprMap: map[string]evalengine.Expr{
+					"id": evalengine.NewLiteralInt(2),
+				},
+				Offset: 4,
+			},
+		},
+		RowMove: &RowMove{
+			Table:       "t1",
+			PKOffsets:   []int{5},
+			PKValues:    []evalengine.Expr{evalengine.NewLiteralInt(2)},
+			SelectQuery: "dummy_select",
+			DeleteQuery: "dummy_delete",
+		},
+	}
+
+	ovqResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"id|c1|c2|c3|hash|id",
+			"int64|int64|int64|int64|int64|int64",
+		),
+		"1|4|5|6|0|1",
+	)
+
+	// The row moves from -20 to 20-, which is refused outside of TWOPC
+	// before anything is changed.
+	vc := newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"-20", "-20", "20-"}
+	vc.results = []*sqltypes.Result{ovqResult}
+	_, err := upd.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.ErrorContains(t, err, "VT09031: moving rows to another shard requires the TWOPC transaction mode")
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.-20: dummy_subquery {} false false`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+	})
+
+	// With TWOPC the lookup entries are moved to the new keyspace id,
+	// the row is updated in place and then moved to its new shard.
+	vc = newDMLTestVCursor("-20", "20-")
+	vc.txMode = vtgatepb.TransactionMode_TWOPC
+	vc.shardForKsid = []string{"-20", "-20", "20-", "20-"}
+	vc.results = []*sqltypes.Result{
+		ovqResult,
+		nil, nil, nil, nil, nil,
+		sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"id|c1|c2|c3",
+				"int64|int64|int64|int64",
+			),
+			"2|4|5|6",
+		),
+	}
+	_, err = upd.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.-20: dummy_subquery {} false false`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+		`Execute delete from lkp2 where from1 = :from1 and from2 = :from2 and toc = :toc from1: type:INT64 value:"4" from2: type:INT64 value:"5" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp2(from1, from2, toc) values(:from1_0, :from2_0, :toc_0) from1_0: type:INT64 value:"4" from2_0: type:INT64 value:"5" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`Execute delete from lkp1 where from = :from and toc = :toc from: type:INT64 value:"6" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0) from_0: type:INT64 value:"6" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`ExecuteMultiShard sharded.-20: dummy_update {} true false`,
+		// The updated row is read back by its new primary key, inserted on 20- and deleted from -20.
+		`ExecuteMultiShard sharded.-20: dummy_select {dml_vals: type:TUPLE values:{type:INT64 value:"2"}} false false`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+		`ExecuteMultiShard sharded.20-: insert into t1(id, c1, c2, c3) values (:_mv0_0, :_mv0_1, :_mv0_2, :_mv0_3) {_mv0_0: type:INT64 value:"2" _mv0_1: type:INT64 value:"4" _mv0_2: type:INT64 value:"5" _mv0_3: type:INT64 value:"6"} true false`,
+		`ExecuteMultiShard sharded.-20: dummy_delete {dml_vals: type:TUPLE values:{type:INT64 value:"2"}} true false`,
+	})
+
+	// Rows whose new keyspace id stays on the same shard are only updated in place,
+	// even outside of TWOPC.
+	vc = newDMLTestVCursor("-20", "20-")
+	vc.shardForKsid = []string{"-20", "-20", "-20"}
+	vc.results = []*sqltypes.Result{ovqResult}
+	_, err = upd.TryExecute(context.Background(), vc, map[string]*querypb.BindVariable{}, false)
+	require.NoError(t, err)
+	vc.ExpectLog(t, []string{
+		`ResolveDestinations sharded [type:INT64 value:"1"] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ExecuteMultiShard sharded.-20: dummy_subquery {} false false`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(166b40b44aba4bd6)`,
+		`ResolveDestinations sharded [] Destinations:DestinationKeyspaceID(06e7ea22ce92708f)`,
+		`Execute delete from lkp2 where from1 = :from1 and from2 = :from2 and toc = :toc from1: type:INT64 value:"4" from2: type:INT64 value:"5" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp2(from1, from2, toc) values(:from1_0, :from2_0, :toc_0) from1_0: type:INT64 value:"4" from2_0: type:INT64 value:"5" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`Execute delete from lkp1 where from = :from and toc = :toc from: type:INT64 value:"6" toc: type:VARBINARY value:"\x16k@\xb4J\xbaK\xd6" true`,
+		`Execute insert into lkp1(from, toc) values(:from_0, :toc_0) from_0: type:INT64 value:"6" toc_0: type:VARBINARY value:"\x06\xe7\xea\"Βp\x8f" true`,
+		`ExecuteMultiShard sharded.-20: dummy_update {} true false`,
+	})
+}
+
 func TestUpdateIn(t *testing.T) {
 	ks := buildTestVSchema().Keyspaces["sharded"]
 	upd := &Update{
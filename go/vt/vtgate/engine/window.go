@@ -0,0 +1,614 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"vitess.io/vitess/go/slice"
+	"vitess.io/vitess/go/sqltypes"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+var _ Primitive = (*Window)(nil)
+
+// Window is a primitive that evaluates window functions on the vtgate.
+// It expects the underlying primitive to feed results sorted by the
+// PartitionBy keys followed by the OrderBy keys. Every window function reads
+// its argument from the column where its result is written, so all other
+// columns are passed through untouched.
+type Window struct {
+	// PartitionBy specifies the columns that split the input into partitions.
+	PartitionBy []*GroupByParams
+
+	// OrderBy specifies the columns that decide which rows are peers
+	// inside a partition.
+	OrderBy []*GroupByParams
+
+	// Functions specifies the window functions to evaluate.
+	Functions []*WindowParams
+
+	// TruncateColumnCount specifies the number of columns to return
+	// in the final result. Rest of the columns are truncated
+	// from the result received. If 0, no truncation happens.
+	TruncateColumnCount int
+
+	// Input is the primitive that will feed into this Primitive.
+	Input Primitive
+}
+
+// WindowParams specify the parameters for each window function.
+type WindowParams struct {
+	Opcode WindowOpcode
+
+	// Col is the column the argument is read from, and the result is written to.
+	Col int
+
+	// Aggregate is only used for WindowAggregate, and describes the aggregation
+	// that is evaluated over the window frame.
+	Aggregate *AggregateParams
+
+	// Offset and Default are only used for LAG and LEAD.
+	Offset  evalengine.Expr
+	Default evalengine.Expr
+
+	// Frame is nil when the function uses the default frame.
+	Frame *WindowFrame
+
+	Alias string
+}
+
+// WindowFrame describes the rows of a partition a window function is evaluated over.
+type WindowFrame struct {
+	Unit       sqlparser.FrameUnitType
+	Start, End WindowFrameBound
+}
+
+// WindowFrameBound is one end of a WindowFrame.
+// Offset is only used for the ExprPrecedingType and ExprFollowingType bounds.
+type WindowFrameBound struct {
+	Type   sqlparser.FramePointType
+	Offset int
+}
+
+// String returns a string. Used for plan descriptions
+func (wp *WindowParams) String() string {
+	var arg string
+	switch wp.Opcode {
+	case WindowAggregate:
+		arg = wp.Aggregate.String()
+	case WindowLag, WindowLead:
+		args := []string{strconv.Itoa(wp.Col)}
+		if wp.Offset != nil {
+			args = append(args, sqlparser.String(wp.Offset))
+		}
+		if wp.Default != nil {
+			args = append(args, sqlparser.String(wp.Default))
+		}
+		arg = fmt.Sprintf("%s(%s)", wp.Opcode.String(), strings.Join(args, ", "))
+	default:
+		arg = fmt.Sprintf("%s(%d)", wp.Opcode.String(), wp.Col)
+	}
+	if wp.Frame != nil {
+		arg += " " + wp.Frame.String()
+	}
+	if wp.Alias != "" && wp.Opcode != WindowAggregate {
+		arg += " AS " + wp.Alias
+	}
+	return arg
+}
+
+// String returns a string. Used for plan descriptions
+func (wf *WindowFrame) String() string {
+	return fmt.Sprintf("%s between %s and %s", wf.Unit.ToString(), wf.Start.String(), wf.End.String())
+}
+
+// String returns a string. Used for plan descriptions
+func (b WindowFrameBound) String() string {
+	switch b.Type {
+	case sqlparser.ExprPrecedingType:
+		return fmt.Sprintf("%d preceding", b.Offset)
+	case sqlparser.ExprFollowingType:
+		return fmt.Sprintf("%d following", b.Offset)
+	default:
+		return b.Type.ToString()
+	}
+}
+
+// RouteType returns a description of the query routing type used by the primitive
+func (w *Window) RouteType() string {
+	return w.Input.RouteType()
+}
+
+// GetKeyspaceName specifies the Keyspace that this primitive routes to.
+func (w *Window) GetKeyspaceName() string {
+	return w.Input.GetKeyspaceName()
+}
+
+// GetTableName specifies the table that this primitive routes to.
+func (w *Window) GetTableName() string {
+	return w.Input.GetTableName()
+}
+
+// TryExecute is a Primitive function.
+func (w *Window) TryExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool) (*sqltypes.Result, error) {
+	result, err := vcursor.ExecutePrimitive(
+		ctx,
+		w.Input,
+		bindVars,
+		true, /*wantFields - we need the input fields types to correctly calculate the output types*/
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := w.newState(ctx, vcursor, bindVars, result.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &sqltypes.Result{
+		Fields: state.fields,
+		Rows:   make([]sqltypes.Row, 0, len(result.Rows)),
+	}
+
+	var partition []sqltypes.Row
+	for _, row := range result.Rows {
+		if len(partition) > 0 {
+			same, err := sameKeys(w.PartitionBy, partition[0], row)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				rows, err := state.evaluate(partition)
+				if err != nil {
+					return nil, err
+				}
+				out.Rows = append(out.Rows, rows...)
+				partition = partition[:0]
+			}
+		}
+		partition = append(partition, row)
+	}
+
+	if len(partition) > 0 {
+		rows, err := state.evaluate(partition)
+		if err != nil {
+			return nil, err
+		}
+		out.Rows = append(out.Rows, rows...)
+	}
+
+	return out.Truncate(w.TruncateColumnCount), nil
+}
+
+// TryStreamExecute is a Primitive function.
+func (w *Window) TryStreamExecute(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, _ bool, callback func(*sqltypes.Result) error) (err error) {
+	defer evalengine.PanicHandler(&err)
+
+	cb := func(qr *sqltypes.Result) error {
+		return callback(qr.Truncate(w.TruncateColumnCount))
+	}
+
+	var (
+		mu        sync.Mutex
+		state     *windowState
+		partition []sqltypes.Row
+	)
+
+	flush := func() error {
+		if len(partition) == 0 {
+			return nil
+		}
+		rows, err := state.evaluate(partition)
+		if err != nil {
+			return err
+		}
+		partition = nil
+		return cb(&sqltypes.Result{Rows: rows})
+	}
+
+	visitor := func(qr *sqltypes.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if state == nil && len(qr.Fields) != 0 {
+			var err error
+			state, err = w.newState(ctx, vcursor, bindVars, qr.Fields)
+			if err != nil {
+				return err
+			}
+			if err := cb(&sqltypes.Result{Fields: state.fields}); err != nil {
+				return err
+			}
+		}
+
+		for _, row := range qr.Rows {
+			if len(partition) > 0 {
+				same, err := sameKeys(w.PartitionBy, partition[0], row)
+				if err != nil {
+					return err
+				}
+				if !same {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			partition = append(partition, row)
+		}
+
+		if vcursor.ExceedsMaxMemoryRows(len(partition)) {
+			return fmt.Errorf("in-memory row count exceeded allowed limit of %d", vcursor.MaxMemoryRows())
+		}
+		return nil
+	}
+
+	/* we need the input fields types to correctly calculate the output types */
+	err = vcursor.StreamExecutePrimitive(ctx, w.Input, bindVars, true, visitor)
+	if err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+// GetFields is a Primitive function.
+func (w *Window) GetFields(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable) (*sqltypes.Result, error) {
+	qr, err := w.Input.GetFields(ctx, vcursor, bindVars)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := w.fields(vcursor, qr.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	qr = &sqltypes.Result{Fields: fields}
+	return qr.Truncate(w.TruncateColumnCount), nil
+}
+
+// Inputs returns the Primitive input for this window
+func (w *Window) Inputs() ([]Primitive, []map[string]any) {
+	return []Primitive{w.Input}, nil
+}
+
+// NeedsTransaction implements the Primitive interface
+func (w *Window) NeedsTransaction() bool {
+	return w.Input.NeedsTransaction()
+}
+
+func (w *Window) fields(vcursor VCursor, in []*querypb.Field) ([]*querypb.Field, error) {
+	fields := slice.Map(in, func(from *querypb.Field) *querypb.Field { return from.CloneVT() })
+	for _, fn := range w.Functions {
+		if fn.Col >= len(fields) {
+			return nil, fmt.Errorf("window function column %d out of range", fn.Col)
+		}
+		if fn.Opcode == WindowAggregate {
+			_, aggrFields, err := newAggregation(vcursor, in, []*AggregateParams{fn.Aggregate})
+			if err != nil {
+				return nil, err
+			}
+			fields[fn.Col] = aggrFields[fn.Col]
+			continue
+		}
+		fields[fn.Col].Type = fn.Opcode.SQLType(fields[fn.Col].Type)
+		if fn.Alias != "" {
+			fields[fn.Col].Name = fn.Alias
+		}
+	}
+	return fields, nil
+}
+
+// windowState holds everything that is needed to evaluate the window functions
+// over a single partition. It is created once per execution.
+type windowState struct {
+	w      *Window
+	fields []*querypb.Field
+
+	// one entry per function. only used for WindowAggregate
+	aggregators []aggregator
+	// one entry per function. only used for LAG and LEAD
+	offsets  []int
+	defaults []sqltypes.Value
+}
+
+func (w *Window) newState(ctx context.Context, vcursor VCursor, bindVars map[string]*querypb.BindVariable, in []*querypb.Field) (*windowState, error) {
+	fields, err := w.fields(vcursor, in)
+	if err != nil {
+		return nil, err
+	}
+	state := &windowState{
+		w:           w,
+		fields:      fields,
+		aggregators: make([]aggregator, len(w.Functions)),
+		offsets:     make([]int, len(w.Functions)),
+		defaults:    make([]sqltypes.Value, len(w.Functions)),
+	}
+
+	env := evalengine.NewExpressionEnv(ctx, bindVars, vcursor)
+	for i, fn := range w.Functions {
+		switch fn.Opcode {
+		case WindowAggregate:
+			agg, _, err := newAggregation(vcursor, in, []*AggregateParams{fn.Aggregate})
+			if err != nil {
+				return nil, err
+			}
+			state.aggregators[i] = agg[fn.Aggregate.Col]
+		case WindowLag, WindowLead:
+			state.offsets[i] = 1
+			if fn.Offset != nil {
+				state.offsets[i], err = getIntFrom(env, vcursor, fn.Offset)
+				if err != nil {
+					return nil, err
+				}
+			}
+			state.defaults[i] = sqltypes.NULL
+			if fn.Default != nil {
+				res, err := env.Evaluate(fn.Default)
+				if err != nil {
+					return nil, err
+				}
+				state.defaults[i] = res.Value(vcursor.ConnCollation())
+			}
+		}
+	}
+	return state, nil
+}
+
+// evaluate calculates the window functions for all rows of a single partition.
+// The input rows are never modified, so the function arguments stay available
+// while the results are being calculated.
+func (s *windowState) evaluate(partition []sqltypes.Row) ([]sqltypes.Row, error) {
+	peers, err := s.peerGroups(partition)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]sqltypes.Row, len(partition))
+	for i, row := range partition {
+		out[i] = slices.Clone(row)
+	}
+
+	for idx, fn := range s.w.Functions {
+		if err := s.evaluateFunction(idx, fn, partition, peers, out); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// peerGroup is a range of rows in a partition that have equal ORDER BY values.
+type peerGroup struct {
+	// first and last are inclusive row indexes into the partition
+	first, last int
+	// number is the 1-based position of this group in the partition
+	number int
+}
+
+// peerGroups returns the peer group of each row in the partition
+func (s *windowState) peerGroups(partition []sqltypes.Row) ([]*peerGroup, error) {
+	peers := make([]*peerGroup, len(partition))
+	current := &peerGroup{number: 1}
+	for i, row := range partition {
+		if i > 0 {
+			same, err := sameKeys(s.w.OrderBy, partition[current.first], row)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				current = &peerGroup{first: i, number: current.number + 1}
+			}
+		}
+		current.last = i
+		peers[i] = current
+	}
+	return peers, nil
+}
+
+func (s *windowState) evaluateFunction(idx int, fn *WindowParams, partition []sqltypes.Row, peers []*peerGroup, out []sqltypes.Row) error {
+	size := len(partition)
+	switch fn.Opcode {
+	case WindowRowNumber:
+		for i := range out {
+			out[i][fn.Col] = sqltypes.NewUint64(uint64(i + 1))
+		}
+	case WindowRank:
+		for i := range out {
+			out[i][fn.Col] = sqltypes.NewUint64(uint64(peers[i].first + 1))
+		}
+	case WindowDenseRank:
+		for i := range out {
+			out[i][fn.Col] = sqltypes.NewUint64(uint64(peers[i].number))
+		}
+	case WindowPercentRank:
+		for i := range out {
+			var rank float64
+			if size > 1 {
+				rank = float64(peers[i].first) / float64(size-1)
+			}
+			out[i][fn.Col] = sqltypes.NewFloat64(rank)
+		}
+	case WindowCumeDist:
+		for i := range out {
+			out[i][fn.Col] = sqltypes.NewFloat64(float64(peers[i].last+1) / float64(size))
+		}
+	case WindowLag, WindowLead:
+		offset := s.offsets[idx]
+		if fn.Opcode == WindowLag {
+			offset = -offset
+		}
+		for i := range out {
+			from := i + offset
+			if from < 0 || from >= size {
+				out[i][fn.Col] = s.defaults[idx]
+				continue
+			}
+			out[i][fn.Col] = partition[from][fn.Col]
+		}
+	case WindowFirstValue, WindowLastValue:
+		for i := range out {
+			start, end := s.frame(fn, i, size, peers)
+			switch {
+			case start > end:
+				out[i][fn.Col] = sqltypes.NULL
+			case fn.Opcode == WindowFirstValue:
+				out[i][fn.Col] = partition[start][fn.Col]
+			default:
+				out[i][fn.Col] = partition[end][fn.Col]
+			}
+		}
+	case WindowAggregate:
+		return s.evaluateAggregate(idx, fn, partition, peers, out)
+	default:
+		return fmt.Errorf("BUG: unexpected window function opcode: %s", fn.Opcode.String())
+	}
+	return nil
+}
+
+// evaluateAggregate calculates an aggregation over the frame of every row.
+// As long as the frames share the same start and only grow, as they do with the
+// default frame, the aggregation state is reused instead of recalculated.
+func (s *windowState) evaluateAggregate(idx int, fn *WindowParams, partition []sqltypes.Row, peers []*peerGroup, out []sqltypes.Row) error {
+	agg := s.aggregators[idx]
+	agg.reset()
+	lastStart, lastEnd := 0, -1
+
+	for i := range out {
+		start, end := s.frame(fn, i, len(partition), peers)
+		if start != lastStart || end < lastEnd {
+			agg.reset()
+			lastStart, lastEnd = start, start-1
+		}
+		for j := lastEnd + 1; j <= end; j++ {
+			if err := agg.add(partition[j]); err != nil {
+				return err
+			}
+		}
+		if end > lastEnd {
+			lastEnd = end
+		}
+		out[i][fn.Col] = agg.finish()
+	}
+	return nil
+}
+
+// frame returns the first and last row index of the frame for the row at idx.
+// If the frame is empty, start will be greater than end.
+func (s *windowState) frame(fn *WindowParams, idx, size int, peers []*peerGroup) (start, end int) {
+	if fn.Frame == nil {
+		if len(s.w.OrderBy) == 0 {
+			// without ORDER BY, all rows of the partition are peers
+			return 0, size - 1
+		}
+		// RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW
+		return 0, peers[idx].last
+	}
+
+	start = fn.Frame.Start.position(fn.Frame.Unit, idx, size, peers, true)
+	end = fn.Frame.End.position(fn.Frame.Unit, idx, size, peers, false)
+	start = max(start, 0)
+	end = min(end, size-1)
+	return start, end
+}
+
+func (b WindowFrameBound) position(unit sqlparser.FrameUnitType, idx, size int, peers []*peerGroup, isStart bool) int {
+	switch b.Type {
+	case sqlparser.UnboundedPrecedingType:
+		if isStart {
+			return 0
+		}
+		return -1
+	case sqlparser.UnboundedFollowingType:
+		if isStart {
+			return size
+		}
+		return size - 1
+	case sqlparser.ExprPrecedingType:
+		return idx - b.Offset
+	case sqlparser.ExprFollowingType:
+		return idx + b.Offset
+	default:
+		if unit == sqlparser.FrameRowsType {
+			return idx
+		}
+		// with RANGE, the current row includes all the peers of the current row
+		if isStart {
+			return peers[idx].first
+		}
+		return peers[idx].last
+	}
+}
+
+// sameKeys returns true if the two rows have equal values for all the keys
+func sameKeys(keys []*GroupByParams, row1, row2 sqltypes.Row) (bool, error) {
+	for _, key := range keys {
+		v1 := row1[key.KeyCol]
+		v2 := row2[key.KeyCol]
+		if v1.TinyWeightCmp(v2) != 0 {
+			return false, nil
+		}
+
+		cmp, err := evalengine.NullsafeCompare(v1, v2, key.CollationEnv, key.Type.Collation(), key.Type.Values())
+		if err != nil {
+			_, isCollationErr := err.(evalengine.UnsupportedCollationError)
+			if !isCollationErr || key.WeightStringCol == -1 {
+				return false, err
+			}
+			cmp, err = evalengine.NullsafeCompare(row1[key.WeightStringCol], row2[key.WeightStringCol], key.CollationEnv, key.Type.Collation(), key.Type.Values())
+			if err != nil {
+				return false, err
+			}
+		}
+		if cmp != 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func windowParamsToString(i any) string {
+	return i.(*WindowParams).String()
+}
+
+func (w *Window) description() PrimitiveDescription {
+	other := map[string]any{
+		"Functions": GenericJoin(w.Functions, windowParamsToString),
+	}
+	if len(w.PartitionBy) > 0 {
+		other["PartitionBy"] = GenericJoin(w.PartitionBy, groupByParamsToString)
+	}
+	if len(w.OrderBy) > 0 {
+		other["OrderBy"] = GenericJoin(w.OrderBy, groupByParamsToString)
+	}
+	if w.TruncateColumnCount > 0 {
+		other["ResultColumns"] = w.TruncateColumnCount
+	}
+	return PrimitiveDescription{
+		OperatorType: "Window",
+		Other:        other,
+	}
+}
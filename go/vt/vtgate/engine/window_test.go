@@ -0,0 +1,225 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package engine
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/sqlparser"
+	. "vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
+)
+
+func TestWindowRanking(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"grp|val|rn|rk|drk|pr|cd",
+				"varbinary|int64|int64|int64|int64|int64|int64",
+			),
+			"a|10|null|null|null|null|null",
+			"a|20|null|null|null|null|null",
+			"a|20|null|null|null|null|null",
+			"a|30|null|null|null|null|null",
+			"b|5|null|null|null|null|null",
+		)},
+	}
+
+	w := &Window{
+		PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		OrderBy:     []*GroupByParams{{KeyCol: 1, WeightStringCol: -1}},
+		Functions: []*WindowParams{
+			{Opcode: WindowRowNumber, Col: 2},
+			{Opcode: WindowRank, Col: 3},
+			{Opcode: WindowDenseRank, Col: 4},
+			{Opcode: WindowPercentRank, Col: 5},
+			{Opcode: WindowCumeDist, Col: 6},
+		},
+		Input: fp,
+	}
+
+	wantResult := sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"grp|val|rn|rk|drk|pr|cd",
+			"varbinary|int64|uint64|uint64|uint64|float64|float64",
+		),
+		"a|10|1|1|1|0|0.25",
+		"a|20|2|2|2|0.3333333333333333|0.75",
+		"a|20|3|2|2|0.3333333333333333|0.75",
+		"a|30|4|4|3|1|1",
+		"b|5|1|1|1|0|1",
+	)
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, wantResult, result)
+
+	fp.rewind()
+	result, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, wantResult, result)
+}
+
+func TestWindowLagLead(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"grp|val|lag|lead",
+				"varbinary|int64|int64|int64",
+			),
+			"a|1|1|1",
+			"a|2|2|2",
+			"a|3|3|3",
+			"b|4|4|4",
+		)},
+	}
+
+	w := &Window{
+		PartitionBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		OrderBy:     []*GroupByParams{{KeyCol: 1, WeightStringCol: -1}},
+		Functions: []*WindowParams{
+			{Opcode: WindowLag, Col: 2},
+			{
+				Opcode:  WindowLead,
+				Col:     3,
+				Offset:  evalengine.NewLiteralInt(2),
+				Default: evalengine.NewLiteralInt(-1),
+			},
+		},
+		Input: fp,
+	}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"grp|val|lag|lead",
+			"varbinary|int64|int64|int64",
+		),
+		"a|1|null|3",
+		"a|2|1|-1",
+		"a|3|2|-1",
+		"b|4|null|-1",
+	), result)
+}
+
+func TestWindowFrames(t *testing.T) {
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{sqltypes.MakeTestResult(
+			sqltypes.MakeTestFields(
+				"val|running|moving|first|last|total",
+				"int64|int64|int64|int64|int64|int64",
+			),
+			"1|1|1|1|1|1",
+			"2|2|2|2|2|2",
+			"2|2|2|2|2|2",
+			"3|3|3|3|3|3",
+		)},
+	}
+
+	sum := func(col int) *AggregateParams {
+		return NewAggregateParam(AggregateSum, col, "", collations.MySQL8())
+	}
+
+	w := &Window{
+		OrderBy: []*GroupByParams{{KeyCol: 0, WeightStringCol: -1}},
+		Functions: []*WindowParams{
+			{Opcode: WindowAggregate, Col: 1, Aggregate: sum(1)},
+			{
+				Opcode:    WindowAggregate,
+				Col:       2,
+				Aggregate: sum(2),
+				Frame: &WindowFrame{
+					Unit:  sqlparser.FrameRowsType,
+					Start: WindowFrameBound{Type: sqlparser.ExprPrecedingType, Offset: 1},
+					End:   WindowFrameBound{Type: sqlparser.ExprFollowingType, Offset: 1},
+				},
+			},
+			{Opcode: WindowFirstValue, Col: 3},
+			{Opcode: WindowLastValue, Col: 4},
+			{
+				Opcode:    WindowAggregate,
+				Col:       5,
+				Aggregate: NewAggregateParam(AggregateCountStar, 5, "", collations.MySQL8()),
+				Frame: &WindowFrame{
+					Unit:  sqlparser.FrameRangeType,
+					Start: WindowFrameBound{Type: sqlparser.UnboundedPrecedingType},
+					End:   WindowFrameBound{Type: sqlparser.UnboundedFollowingType},
+				},
+			},
+		},
+		Input: fp,
+	}
+
+	result, err := w.TryExecute(context.Background(), &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"val|running|moving|first|last|total",
+			"int64|decimal|decimal|int64|int64|int64",
+		),
+		"1|1|3|1|1|4",
+		"2|5|5|1|2|4",
+		"2|5|7|1|2|4",
+		"3|8|5|1|3|4",
+	), result)
+}
+
+func TestWindowStreamTruncateAndMemoryLimit(t *testing.T) {
+	fields := sqltypes.MakeTestFields(
+		"grp|rn|weight_string(grp)",
+		"varchar|int64|varbinary",
+	)
+	fp := &fakePrimitive{
+		results: []*sqltypes.Result{
+			sqltypes.MakeTestResult(fields, "a|null|A", "A|null|A", "b|null|B"),
+		},
+	}
+
+	w := &Window{
+		PartitionBy:         []*GroupByParams{{KeyCol: 2, WeightStringCol: -1}},
+		Functions:           []*WindowParams{{Opcode: WindowRowNumber, Col: 1, Alias: "rn"}},
+		TruncateColumnCount: 2,
+		Input:               fp,
+	}
+
+	result, err := wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.NoError(t, err)
+	utils.MustMatch(t, sqltypes.MakeTestResult(
+		sqltypes.MakeTestFields(
+			"grp|rn",
+			"varchar|uint64",
+		),
+		"a|1",
+		"A|2",
+		"b|1",
+	), result)
+
+	saveMax := testMaxMemoryRows
+	testMaxMemoryRows = 1
+	defer func() { testMaxMemoryRows = saveMax }()
+
+	fp.rewind()
+	_, err = wrapStreamExecute(w, &noopVCursor{}, nil, true)
+	require.EqualError(t, err, "in-memory row count exceeded allowed limit of 1")
+}
@@ -96,7 +96,10 @@ func (expr *LikeExpr) simplify(env *ExpressionEnv) error {
 }
 
 func (inexpr *InExpr) simplify(env *ExpressionEnv) error {
-	if err := inexpr.BinaryExpr.simplify(env); err != nil {
+	// The right side must stay a tuple, only its elements get folded.
+	var err error
+	inexpr.Left, err = simplifyExpr(env, inexpr.Left)
+	if err != nil {
 		return err
 	}
 
@@ -331,6 +331,42 @@ func TestEvaluate(t *testing.T) {
 	}
 }
 
+func TestEvaluateInWithTypedColumn(t *testing.T) {
+	venv := vtenv.NewTestEnv()
+	stmt, err := sqlparser.NewTestParser().Parse("select id in (1, 2) from t")
+	require.NoError(t, err)
+	astExpr := stmt.(*sqlparser.Select).SelectExprs[0].(*sqlparser.AliasedExpr).Expr
+
+	// The tuple is constant, but must not be folded into a literal for the
+	// expression to compile.
+	expr, err := Translate(astExpr, &Config{
+		ResolveColumn: func(name *sqlparser.ColName) (int, error) {
+			return 0, nil
+		},
+		ResolveType: func(expr sqlparser.Expr) (Type, bool) {
+			return NewType(sqltypes.Int64, collations.CollationBinaryID), true
+		},
+		Collation:   venv.CollationEnv().DefaultConnectionCharset(),
+		Environment: venv,
+	})
+	require.NoError(t, err)
+
+	env := EmptyExpressionEnv(venv)
+	for _, test := range []struct {
+		id       sqltypes.Value
+		expected sqltypes.Value
+	}{
+		{sqltypes.NewInt64(1), sqltypes.NewInt64(1)},
+		{sqltypes.NewInt64(3), sqltypes.NewInt64(0)},
+		{sqltypes.NULL, sqltypes.NULL},
+	} {
+		env.Row = []sqltypes.Value{test.id}
+		r, err := env.Evaluate(expr)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, r.Value(collations.MySQL8().DefaultConnectionCharset()))
+	}
+}
+
 func TestEvaluateTuple(t *testing.T) {
 	type testCase struct {
 		expression string
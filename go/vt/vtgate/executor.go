@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -116,6 +117,10 @@ type Executor struct {
 	plans *PlanCache
 	epoch atomic.Uint32
 
+	// results caches the results of the queries that enable the result cache, if it is not nil.
+	// Both the plans and the results are dropped when the epoch changes.
+	results *ResultCache
+
 	normalize       bool
 	warnShardedOnly bool
 
@@ -176,6 +181,7 @@ func NewExecutor(
 		allowScatter:        !noScatter,
 		pv:                  pv,
 		plans:               plans,
+		results:             DefaultResultCache(),
 		warmingReadsPercent: warmingReadsPercent,
 		warmingReadsChannel: make(chan bool, warmingReadsConcurrency),
 	}
@@ -210,6 +216,18 @@ func NewExecutor(
 		stats.NewCounterFunc("QueryPlanCacheMisses", "Query plan cache misses", func() int64 {
 			return e.plans.Metrics.Misses()
 		})
+		stats.NewGaugeFunc("ResultCacheLength", "Query result cache length", func() int64 {
+			if e.results == nil {
+				return 0
+			}
+			return int64(e.results.Len())
+		})
+		stats.NewGaugeFunc("ResultCacheSize", "Query result cache size", func() int64 {
+			if e.results == nil {
+				return 0
+			}
+			return int64(e.results.UsedCapacity())
+		})
 		servenv.HTTPHandle(pathQueryPlans, e)
 		servenv.HTTPHandle(pathScatterStats, e)
 		servenv.HTTPHandle(pathVSchema, e)
@@ -1291,6 +1309,51 @@ func (e *Executor) ClearPlans() {
 	e.epoch.Add(1)
 }
 
+// tableStatisticsChangeFactor is the factor by which the estimated row count of a table
+// has to change before the cached plans using the table are planned again.
+const tableStatisticsChangeFactor = 2
+
+// UpdateTableStatistics sets the table statistics of a keyspace on the current vschema
+// without rebuilding it. The cached plans are only evicted for the tables whose estimated
+// row count changed by more than tableStatisticsChangeFactor, the other plans are kept.
+func (e *Executor) UpdateTableStatistics(ks string, stats map[string]*vindexes.TableStatistics) {
+	vschema := e.VSchema()
+	if vschema == nil || vschema.Keyspaces[ks] == nil {
+		return
+	}
+	var changed []string
+	for name, tbl := range vschema.Keyspaces[ks].Tables {
+		if tableStatisticsChanged(tbl.Statistics(), stats[name]) {
+			changed = append(changed, ks+"."+name)
+		}
+		tbl.SetStatistics(stats[name])
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	var evict []PlanCacheKey
+	e.plans.Range(e.epoch.Load(), func(key PlanCacheKey, plan *engine.Plan) bool {
+		if slices.ContainsFunc(plan.TablesUsed, func(tbl string) bool {
+			return slices.Contains(changed, tbl)
+		}) {
+			evict = append(evict, key)
+		}
+		return true
+	})
+	for _, key := range evict {
+		e.plans.Delete(key)
+	}
+}
+
+func tableStatisticsChanged(old, new *vindexes.TableStatistics) bool {
+	if old == nil || new == nil {
+		return old != new
+	}
+	lo, hi := min(old.RowCount, new.RowCount), max(old.RowCount, new.RowCount)
+	return float64(hi) > float64(max(lo, 1))*tableStatisticsChangeFactor
+}
+
 func (e *Executor) updateQueryCounts(planType, keyspace, tableName string, shardQueries int64) {
 	queriesProcessed.Add(planType, 1)
 	queriesRouted.Add(planType, shardQueries)
@@ -1614,12 +1677,19 @@ func (e *Executor) Close() {
 	}
 	topo.Close()
 	e.plans.Close()
+	if e.results != nil {
+		e.results.Close()
+	}
 }
 
 func (e *Executor) environment() *vtenv.Environment {
 	return e.env
 }
 
+func (e *Executor) transactionMode() vtgatepb.TransactionMode {
+	return e.txConn.mode
+}
+
 func (e *Executor) ReadTransaction(ctx context.Context, transactionID string) (*querypb.TransactionMetadata, error) {
 	return e.txConn.ReadTransaction(ctx, transactionID)
 }
@@ -3104,18 +3104,18 @@ func TestDeleteMultiTable(t *testing.T) {
 	}
 
 	bq := &querypb.BoundQuery{
-		Sql:           "select 1 from music where music.user_id = 1 and music.col = :user_col",
+		Sql:           "select 1 from music where music.user_id = 1 and music.col = :user_col lock in share mode",
 		BindVariables: map[string]*querypb.BindVariable{"user_col": sqltypes.StringBindVariable("foo")},
 	}
 	wantQueries := []*querypb.BoundQuery{
-		{Sql: "select `user`.id, `user`.col from `user`", BindVariables: map[string]*querypb.BindVariable{}},
+		{Sql: "select `user`.id, `user`.col from `user` lock in share mode", BindVariables: map[string]*querypb.BindVariable{}},
 		bq, bq, bq, bq, bq, bq, bq, bq,
 		{Sql: "select `user`.Id, `user`.`name` from `user` where `user`.id in ::dml_vals for update", BindVariables: map[string]*querypb.BindVariable{"dml_vals": {Type: querypb.Type_TUPLE, Values: dmlVals}}},
 		{Sql: "delete from `user` where `user`.id in ::dml_vals", BindVariables: map[string]*querypb.BindVariable{"__vals": sqltypes.TestBindVariable([]any{int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), int64(1), int64(1)}), "dml_vals": {Type: querypb.Type_TUPLE, Values: dmlVals}}}}
 	assertQueries(t, sbc1, wantQueries)
 
 	wantQueries = []*querypb.BoundQuery{
-		{Sql: "select `user`.id, `user`.col from `user`", BindVariables: map[string]*querypb.BindVariable{}},
+		{Sql: "select `user`.id, `user`.col from `user` lock in share mode", BindVariables: map[string]*querypb.BindVariable{}},
 		{Sql: "select `user`.Id, `user`.`name` from `user` where `user`.id in ::dml_vals for update", BindVariables: map[string]*querypb.BindVariable{"dml_vals": {Type: querypb.Type_TUPLE, Values: dmlVals}}},
 		{Sql: "delete from `user` where `user`.id in ::dml_vals", BindVariables: map[string]*querypb.BindVariable{"dml_vals": {Type: querypb.Type_TUPLE, Values: dmlVals}}},
 	}
@@ -2947,3 +2947,29 @@ func exec(executor *Executor, session *SafeSession, sql string) (*sqltypes.Resul
 func makeComments(text string) sqlparser.MarginComments {
 	return sqlparser.MarginComments{Trailing: text}
 }
+
+func TestUpdateTableStatistics(t *testing.T) {
+	r, _, _, _, ctx := createExecutorEnv(t)
+	vc, _ := newVCursorImpl(NewSafeSession(&vtgatepb.Session{TargetString: "@unknown"}), makeComments(""), r, nil, r.vm, r.VSchema(), r.resolver.resolver, nil, false, pv)
+
+	userPlan, _ := getPlanCached(t, ctx, r, vc, "select * from `user` where id = 1", makeComments(""), map[string]*querypb.BindVariable{}, false)
+	extraPlan, _ := getPlanCached(t, ctx, r, vc, "select * from user_extra where id = 1", makeComments(""), map[string]*querypb.BindVariable{}, false)
+	require.Equal(t, []string{KsTestSharded + ".user"}, userPlan.TablesUsed)
+	require.Equal(t, []string{KsTestSharded + ".user_extra"}, extraPlan.TablesUsed)
+	assertCacheSize(t, r.plans, 2)
+
+	stats := map[string]*vindexes.TableStatistics{"user": {RowCount: 1000}, "user_extra": {RowCount: 1000}}
+	r.UpdateTableStatistics(KsTestSharded, stats)
+	assert.EqualValues(t, 1000, r.VSchema().Keyspaces[KsTestSharded].Tables["user"].Statistics().RowCount)
+	assert.Eventually(t, func() bool { return r.plans.Len() == 0 }, 5*time.Second, 10*time.Millisecond)
+
+	getPlanCached(t, ctx, r, vc, "select * from `user` where id = 1", makeComments(""), map[string]*querypb.BindVariable{}, false)
+	getPlanCached(t, ctx, r, vc, "select * from user_extra where id = 1", makeComments(""), map[string]*querypb.BindVariable{}, false)
+	assertCacheSize(t, r.plans, 2)
+
+	// Small changes of the estimates keep the plans, large ones only evict the plans using the table.
+	r.UpdateTableStatistics(KsTestSharded, map[string]*vindexes.TableStatistics{"user": {RowCount: 1500}, "user_extra": {RowCount: 5000}})
+	assert.EqualValues(t, 1500, r.VSchema().Keyspaces[KsTestSharded].Tables["user"].Statistics().RowCount)
+	assert.Eventually(t, func() bool { return r.plans.Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assertCacheContains(t, r, nil, "select * from `user` where id = 1")
+}
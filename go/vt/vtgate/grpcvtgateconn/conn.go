@@ -50,6 +50,7 @@ func init() {
 
 	for _, cmd := range []string{
 		"vtbench",
+		"vtcdc",
 		"vtclient",
 		"vtcombo",
 		"vtctl",
@@ -265,6 +266,18 @@ func (conn *vtgateConn) VStream(ctx context.Context, tabletType topodatapb.Table
 	}, nil
 }
 
+func (conn *vtgateConn) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	request := &vtgatepb.VStreamAckRequest{
+		CallerId:     callerid.EffectiveCallerIDFromContext(ctx),
+		ConsumerName: consumerName,
+		Vgtid:        vgtid,
+	}
+	if _, err := conn.c.VStreamAck(ctx, request); err != nil {
+		return vterrors.FromGRPC(err)
+	}
+	return nil
+}
+
 func (conn *vtgateConn) Close() {
 	conn.cc.Close()
 }
@@ -234,6 +234,10 @@ func (f *fakeVTGateService) VStream(ctx context.Context, tabletType topodatapb.T
 	panic("unimplemented")
 }
 
+func (f *fakeVTGateService) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	panic("unimplemented")
+}
+
 // CreateFakeServer returns the fake server for the tests
 func CreateFakeServer(t *testing.T) vtgateservice.VTGateService {
 	return &fakeVTGateService{
@@ -323,16 +323,28 @@ func (e *Executor) executePlan(
 	execStart time.Time,
 ) (*sqltypes.Result, error) {
 
-	// 4: Execute!
+	// 4: Serve the result from the cache, if the plan allows it.
+	cacheKey, cacheable := e.resultCacheKey(ctx, safeSession, plan, vcursor, bindVars)
+	if cacheable {
+		if qr, ok := e.getCachedResult(cacheKey); ok {
+			e.setLogStats(logStats, plan, vcursor, execStart, nil, qr)
+			return qr, nil
+		}
+	}
+
+	// 5: Execute!
 	qr, err := vcursor.ExecutePrimitive(ctx, plan.Instructions, bindVars, true)
 
-	// 5: Log and add statistics
+	// 6: Log and add statistics
 	e.setLogStats(logStats, plan, vcursor, execStart, err, qr)
 
 	// Check if there was partial DML execution. If so, rollback the effect of the partially executed query.
 	if err != nil {
 		return nil, e.rollbackExecIfNeeded(ctx, safeSession, bindVars, logStats, err)
 	}
+	if cacheable {
+		e.cacheResult(cacheKey, plan, qr)
+	}
 	return qr, nil
 }
 
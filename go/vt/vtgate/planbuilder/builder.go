@@ -115,6 +115,7 @@ func BuildFromStmt(ctx context.Context, query string, stmt sqlparser.Statement,
 		BindVarNeeds: bindVarNeeds,
 		TablesUsed:   tablesUsed,
 	}
+	plan.ResultCacheTTL = resultCacheTTL(stmt, vschema, tablesUsed)
 	return plan, nil
 }
 
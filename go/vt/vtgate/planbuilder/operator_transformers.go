@@ -63,6 +63,8 @@ func transformToPrimitive(ctx *plancontext.PlanningContext, op operators.Operato
 		return transformAggregator(ctx, op)
 	case *operators.Distinct:
 		return transformDistinct(ctx, op)
+	case *operators.Window:
+		return transformWindow(ctx, op)
 	case *operators.FkCascade:
 		return transformFkCascade(ctx, op)
 	case *operators.FkVerify:
@@ -273,6 +275,19 @@ func transformSubQuery(ctx *plancontext.PlanningContext, op *operators.SubQuery)
 		}, nil
 	}
 
+	if op.CorrelatedPredicate != nil {
+		return &engine.CorrelatedSubquery{
+			Opcode:         op.FilterType,
+			SubqueryResult: op.SubqueryValueName,
+			HasValues:      op.HasValuesName,
+			Vars:           op.Vars,
+			Predicate:      op.PredicateWithOffsets,
+			ASTPredicate:   op.CorrelatedPredicate,
+			Outer:          outer,
+			Subquery:       inner,
+		}, nil
+	}
+
 	return &engine.SemiJoin{
 		Left:  outer,
 		Right: inner,
@@ -340,6 +355,10 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 		aggrParam.OrigOpcode = aggr.OriginalOpCode
 		aggrParam.WCol = aggr.WSOffset
 		aggrParam.Type = aggr.GetTypeCollation(ctx)
+		aggrParam.HashDistinct = aggr.HashDistinct
+		if len(aggr.ArgOffsets) > 0 {
+			aggrParam.GroupConcatArgs, aggrParam.GroupConcatOrderBy = groupConcatParams(ctx, aggr)
+		}
 		aggregates = append(aggregates, aggrParam)
 	}
 
@@ -370,6 +389,148 @@ func transformAggregator(ctx *plancontext.PlanningContext, op *operators.Aggrega
 	}, nil
 }
 
+// groupConcatParams returns the arguments and the ordering of a GROUP_CONCAT evaluated on vtgate from the rows of each group.
+func groupConcatParams(ctx *plancontext.PlanningContext, aggr operators.Aggr) ([]engine.CheckCol, evalengine.Comparison) {
+	gc := aggr.Func.(*sqlparser.GroupConcatExpr)
+	collationEnv := ctx.VSchema.Environment().CollationEnv()
+
+	args := make([]engine.CheckCol, 0, len(aggr.ArgOffsets))
+	for idx, offset := range aggr.ArgOffsets {
+		typ, _ := ctx.TypeForExpr(gc.Exprs[idx])
+		args = append(args, engine.CheckCol{
+			Col:          offset,
+			Type:         typ,
+			CollationEnv: collationEnv,
+		})
+	}
+
+	var orderBy evalengine.Comparison
+	for idx, order := range aggr.GroupConcatOrderBy() {
+		typ, _ := ctx.TypeForExpr(order.Expr)
+		orderBy = append(orderBy, evalengine.OrderByParams{
+			Col:             aggr.OrderOffsets[idx],
+			WeightStringCol: aggr.OrderWSOffsets[idx],
+			Desc:            order.Direction == sqlparser.DescOrder,
+			Type:            typ,
+			CollationEnv:    collationEnv,
+		})
+	}
+	return args, orderBy
+}
+
+func transformWindow(ctx *plancontext.PlanningContext, op *operators.Window) (engine.Primitive, error) {
+	src, err := transformToPrimitive(ctx, op.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	collationEnv := ctx.VSchema.Environment().CollationEnv()
+	cfg := &evalengine.Config{
+		Collation:   ctx.VSchema.ConnCollation(),
+		Environment: ctx.VSchema.Environment(),
+	}
+
+	keyParams := func(exprs []sqlparser.Expr, offsets, wsOffsets []int) []*engine.GroupByParams {
+		var keys []*engine.GroupByParams
+		for idx, expr := range exprs {
+			typ, _ := ctx.TypeForExpr(expr)
+			keys = append(keys, &engine.GroupByParams{
+				KeyCol:          offsets[idx],
+				WeightStringCol: wsOffsets[idx],
+				Expr:            expr,
+				Type:            typ,
+				CollationEnv:    collationEnv,
+			})
+		}
+		return keys
+	}
+
+	var functions []*engine.WindowParams
+	for _, fn := range op.Functions {
+		param := &engine.WindowParams{
+			Opcode: fn.OpCode,
+			Col:    fn.ColOffset,
+			Alias:  fn.Original.ColumnName(),
+		}
+
+		if fn.OpCode == opcode.WindowAggregate {
+			aggrParam := engine.NewAggregateParam(fn.AggrOpCode, fn.ColOffset, param.Alias, collationEnv)
+			aggrParam.Func = fn.Func.(sqlparser.AggrFunc)
+			aggrParam.Original = fn.Original
+			if arg := aggrParam.Func.GetArg(); arg != nil {
+				aggrParam.Type, _ = ctx.TypeForExpr(arg)
+			}
+			param.Aggregate = aggrParam
+		}
+
+		if fn.Offset != nil {
+			param.Offset, err = evalengine.Translate(fn.Offset, cfg)
+			if err != nil {
+				return nil, vterrors.Wrap(err, "unexpected offset in window function")
+			}
+		}
+		if fn.Default != nil {
+			param.Default, err = evalengine.Translate(fn.Default, cfg)
+			if err != nil {
+				return nil, vterrors.Wrap(err, "unexpected default value in window function")
+			}
+		}
+
+		if fn.Frame != nil {
+			param.Frame, err = createWindowFrame(fn.Frame)
+			if err != nil {
+				return nil, err
+			}
+		}
+		functions = append(functions, param)
+	}
+
+	var orderExprs []sqlparser.Expr
+	for _, order := range op.OrderBy {
+		orderExprs = append(orderExprs, order.SimplifiedExpr)
+	}
+
+	return &engine.Window{
+		PartitionBy: keyParams(op.PartitionBy, op.PartitionOffsets, op.PartitionWSOffsets),
+		OrderBy:     keyParams(orderExprs, op.OrderOffsets, op.OrderWSOffsets),
+		Functions:   functions,
+		Input:       src,
+	}, nil
+}
+
+func createWindowFrame(frame *sqlparser.FrameClause) (*engine.WindowFrame, error) {
+	bound := func(point *sqlparser.FramePoint) (engine.WindowFrameBound, error) {
+		if point == nil {
+			// a frame without an end is evaluated up to the current row
+			return engine.WindowFrameBound{Type: sqlparser.CurrentRowType}, nil
+		}
+		b := engine.WindowFrameBound{Type: point.Type}
+		if point.Type != sqlparser.ExprPrecedingType && point.Type != sqlparser.ExprFollowingType {
+			return b, nil
+		}
+		lit, ok := point.Expr.(*sqlparser.Literal)
+		if !ok || lit.Type != sqlparser.IntVal {
+			return b, vterrors.VT12001(fmt.Sprintf("window frame offset: %s", sqlparser.String(point.Expr)))
+		}
+		offset, err := strconv.Atoi(lit.Val)
+		if err != nil {
+			return b, err
+		}
+		b.Offset = offset
+		return b, nil
+	}
+
+	start, err := bound(frame.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := bound(frame.End)
+	if err != nil {
+		return nil, err
+	}
+	return &engine.WindowFrame{Unit: frame.Unit, Start: start, End: end}, nil
+}
+
 func transformDistinct(ctx *plancontext.PlanningContext, op *operators.Distinct) (engine.Primitive, error) {
 	src, err := transformToPrimitive(ctx, op.Source)
 	if err != nil {
@@ -559,7 +720,8 @@ func newRoutingParams(ctx *plancontext.PlanningContext, opCode engine.Opcode) *e
 
 type queryHints struct {
 	scatterErrorsAsWarnings,
-	multiShardAutocommit bool
+	multiShardAutocommit,
+	allowNonAtomicRowMove bool
 	queryTimeout int
 }
 
@@ -574,6 +736,7 @@ func getHints(cmt *sqlparser.ParsedComments) *queryHints {
 	return &queryHints{
 		scatterErrorsAsWarnings: scatterAsWarns,
 		multiShardAutocommit:    multiShardAutoCommit,
+		allowNonAtomicRowMove:   directives.IsSet(sqlparser.DirectiveAllowNonAtomicRowMove),
 		queryTimeout:            timeout,
 	}
 }
@@ -756,9 +919,19 @@ func buildUpdatePrimitive(
 	_ = updateSelectedVindexPredicate(rb.Routing)
 	edml := createDMLPrimitive(ctx, rb, hints, upd.Target.VTable, generateQuery(stmt), vindexes, vQuery)
 
+	if upd.RowMove != nil {
+		if hints != nil && hints.multiShardAutocommit {
+			return nil, vterrors.VT12001(fmt.Sprintf("%s when updating the primary vindex columns", sqlparser.DirectiveMultiShardAutocommit))
+		}
+		// The rows are moved after the update, so it cannot be committed on its own.
+		edml.PreventAutoCommit = true
+		upd.RowMove.AllowNonAtomic = hints != nil && hints.allowNonAtomicRowMove
+	}
+
 	return &engine.Update{
 		DML:                 edml,
 		ChangedVindexValues: upd.ChangedVindexValues,
+		RowMove:             upd.RowMove,
 	}, nil
 }
 
@@ -149,6 +149,13 @@ func pushAggregationThroughRoute(
 	aggregator *Aggregator,
 	route *Route,
 ) (Operator, *ApplyResult) {
+	if slices.ContainsFunc(aggregator.Aggregations, Aggr.groupConcatNeedsRows) {
+		// The whole aggregation is done on vtgate
+		_, distinctExprs := checkIfWeCanPush(ctx, aggregator)
+		aggregator.planDistinctOnVtgate(distinctExprs)
+		return nil, nil
+	}
+
 	// Create a new aggregator to be placed below the route.
 	aggrBelowRoute := aggregator.SplitAggregatorBelowOperators(ctx, route.Inputs())
 	aggrBelowRoute.Aggregations = nil
@@ -170,8 +177,9 @@ func pushAggregationThroughRoute(
 // pushAggregations splits aggregations between the original aggregator and the one we are pushing down
 func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator, aggrBelowRoute *Aggregator) {
 	canPushDistinctAggr, distinctExprs := checkIfWeCanPush(ctx, aggregator)
-
-	distinctAggrGroupByAdded := false
+	if !canPushDistinctAggr {
+		aggregator.planDistinctOnVtgate(distinctExprs)
+	}
 
 	for i, aggr := range aggregator.Aggregations {
 		if !aggr.Distinct || canPushDistinctAggr {
@@ -181,38 +189,32 @@ func pushAggregations(ctx *plancontext.PlanningContext, aggregator *Aggregator,
 			continue
 		}
 
-		if len(distinctExprs) != 1 {
-			errDistinctAggrWithMultiExpr(aggr.Func)
-		}
-
 		// We handle a distinct aggregation by turning it into a group by and
 		// doing the aggregating on the vtgate level instead
-		aeDistinctExpr := aeWrap(distinctExprs[0])
-		aggrBelowRoute.Columns[aggr.ColOffset] = aeDistinctExpr
+		distinctExpr := aggr.Func.GetArg()
+		aggrBelowRoute.Columns[aggr.ColOffset] = aeWrap(distinctExpr)
 
-		// We handle a distinct aggregation by turning it into a group by and
-		// doing the aggregating on the vtgate level instead
 		// Adding to group by can be done only once even though there are multiple distinct aggregation with same expression.
-		if !distinctAggrGroupByAdded {
-			groupBy := NewGroupBy(distinctExprs[0])
+		alreadyGrouped := slices.ContainsFunc(aggrBelowRoute.Grouping, func(gb GroupBy) bool {
+			return ctx.SemTable.EqualsExpr(gb.Inner, distinctExpr)
+		})
+		if !alreadyGrouped {
+			groupBy := NewGroupBy(distinctExpr)
 			groupBy.ColOffset = aggr.ColOffset
 			aggrBelowRoute.Grouping = append(aggrBelowRoute.Grouping, groupBy)
-			distinctAggrGroupByAdded = true
 		}
 	}
-
-	if !canPushDistinctAggr {
-		aggregator.DistinctExpr = distinctExprs[0]
-	}
 }
 
+// checkIfWeCanPush returns whether the distinct aggregations can be pushed to the shards,
+// and the distinct expressions of the aggregator.
 func checkIfWeCanPush(ctx *plancontext.PlanningContext, aggregator *Aggregator) (bool, sqlparser.Exprs) {
 	canPush := true
 	var distinctExprs sqlparser.Exprs
-	var differentExpr *sqlparser.AliasedExpr
 
 	for _, aggr := range aggregator.Aggregations {
-		if !aggr.Distinct {
+		if !aggr.Distinct || aggr.groupConcatNeedsRows() {
+			// a GROUP_CONCAT that needs all the rows de-duplicates its values on vtgate
 			continue
 		}
 
@@ -227,24 +229,38 @@ func checkIfWeCanPush(ctx *plancontext.PlanningContext, aggregator *Aggregator)
 		if !hasUniqVindex {
 			canPush = false
 		}
-		if len(distinctExprs) == 0 {
-			distinctExprs = args
-		}
-		for idx, expr := range distinctExprs {
-			if !ctx.SemTable.EqualsExpr(expr, args[idx]) {
-				differentExpr = aggr.Original
-				break
+		for _, arg := range args {
+			if !slices.ContainsFunc(distinctExprs, func(expr sqlparser.Expr) bool { return ctx.SemTable.EqualsExpr(expr, arg) }) {
+				distinctExprs = append(distinctExprs, arg)
 			}
 		}
 	}
 
-	if !canPush && differentExpr != nil {
-		panic(vterrors.VT12001(fmt.Sprintf("only one DISTINCT aggregation is allowed in a SELECT: %s", sqlparser.String(differentExpr))))
-	}
-
 	return canPush, distinctExprs
 }
 
+// planDistinctOnVtgate prepares the distinct aggregations that are evaluated on vtgate.
+// A single distinct expression is de-duplicated by ordering the input on it, while
+// several ones are de-duplicated by hashing the values seen in each group.
+func (a *Aggregator) planDistinctOnVtgate(distinctExprs sqlparser.Exprs) {
+	for _, aggr := range a.Aggregations {
+		if aggr.Distinct && !aggr.groupConcatNeedsRows() && len(aggr.Func.GetArgs()) != 1 {
+			errDistinctAggrWithMultiExpr(aggr.Func)
+		}
+	}
+	switch len(distinctExprs) {
+	case 0:
+	case 1:
+		a.DistinctExpr = distinctExprs[0]
+	default:
+		for idx, aggr := range a.Aggregations {
+			if aggr.Distinct && !aggr.groupConcatNeedsRows() {
+				a.Aggregations[idx].HashDistinct = true
+			}
+		}
+	}
+}
+
 func pushAggregationThroughFilter(
 	ctx *plancontext.PlanningContext,
 	aggregator *Aggregator,
@@ -547,10 +563,7 @@ func splitAggrColumnsToLeftAndRight(
 	// Distinct aggregation cannot be pushed down in the join.
 	// We keep node of the distinct aggregation expression to be used later for ordering.
 	if !canPushDistinctAggr {
-		if len(distinctExprs) != 1 {
-			errDistinctAggrWithMultiExpr(nil)
-		}
-		aggregator.DistinctExpr = distinctExprs[0]
+		aggregator.planDistinctOnVtgate(distinctExprs)
 		return nil, errAbortAggrPushing
 	}
 
@@ -617,13 +630,9 @@ func splitAvgAggregations(ctx *plancontext.PlanningContext, aggr *Aggregator) (O
 			continue
 		}
 
-		if avg.Distinct {
-			panic(vterrors.VT12001("AVG(distinct <>)"))
-		}
-
 		// We have an AVG that we need to split
-		sumExpr := &sqlparser.Sum{Arg: avg.Arg}
-		countExpr := &sqlparser.Count{Args: []sqlparser.Expr{avg.Arg}}
+		sumExpr := &sqlparser.Sum{Arg: avg.Arg, Distinct: avg.Distinct}
+		countExpr := &sqlparser.Count{Args: []sqlparser.Expr{avg.Arg}, Distinct: avg.Distinct}
 		calcExpr := &sqlparser.BinaryExpr{
 			Operator: sqlparser.DivOp,
 			Left:     sumExpr,
@@ -638,10 +647,15 @@ func splitAvgAggregations(ctx *plancontext.PlanningContext, aggr *Aggregator) (O
 		for aggrOffset, aggregation := range aggr.Aggregations {
 			if offset == aggregation.ColOffset {
 				// We have found the AVG column. We'll change it to SUM, and then we add a COUNT as well
-				aggr.Aggregations[aggrOffset].OpCode = opcode.AggregateSum
+				sumCode, countCode := opcode.AggregateSum, opcode.AggregateCount
+				if avg.Distinct {
+					sumCode, countCode = opcode.AggregateSumDistinct, opcode.AggregateCountDistinct
+				}
+				aggr.Aggregations[aggrOffset].OpCode = sumCode
 
 				countExprAlias := aeWrap(countExpr)
-				countAggr := NewAggr(opcode.AggregateCount, countExpr, countExprAlias, sqlparser.String(countExpr))
+				countAggr := NewAggr(countCode, countExpr, countExprAlias, sqlparser.String(countExpr))
+				countAggr.Distinct = avg.Distinct
 				countAggr.ColOffset = len(aggr.Columns) + len(columns)
 				aggregations = append(aggregations, countAggr)
 				columns = append(columns, countExprAlias)
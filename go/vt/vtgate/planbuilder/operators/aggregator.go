@@ -422,10 +422,8 @@ func (aggr Aggr) getPushColumn() sqlparser.Expr {
 	case opcode.AggregateCountStar:
 		return sqlparser.NewIntLiteral("1")
 	case opcode.AggregateGroupConcat:
-		if len(aggr.Func.GetArgs()) > 1 {
-			panic(vterrors.VT12001("group_concat with more than 1 column"))
-		}
-		return aggr.Func.GetArg()
+		// the remaining arguments are added by pushGroupConcatColumns
+		return aggr.Func.GetArgs()[0]
 	default:
 		if len(aggr.Func.GetArgs()) > 1 {
 			panic(vterrors.VT03001(sqlparser.String(aggr.Func)))
@@ -465,6 +463,32 @@ func (a *Aggregator) planOffsetsNotPushed(ctx *plancontext.PlanningContext) {
 	}
 
 	a.pushRemainingGroupingColumnsAndWeightStrings(ctx)
+	a.pushGroupConcatColumns(ctx)
+}
+
+// pushGroupConcatColumns adds the columns needed to evaluate GROUP_CONCAT from the rows of
+// each group: all the arguments of the function and its ORDER BY expressions.
+func (a *Aggregator) pushGroupConcatColumns(ctx *plancontext.PlanningContext) {
+	for idx, aggr := range a.Aggregations {
+		gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+		if !ok || aggr.OpCode != opcode.AggregateGroupConcat {
+			continue
+		}
+		aggr.ArgOffsets = []int{aggr.ColOffset}
+		for _, arg := range gc.Exprs[1:] {
+			aggr.ArgOffsets = append(aggr.ArgOffsets, a.internalAddColumn(ctx, aeWrap(arg), false))
+		}
+		for _, order := range aggr.GroupConcatOrderBy() {
+			offset := a.internalAddColumn(ctx, aeWrap(order.Expr), false)
+			wsOffset := -1
+			if ctx.NeedsWeightString(order.Expr) {
+				wsOffset = a.internalAddWSColumn(ctx, offset, aeWrap(weightStringFor(order.Expr)))
+			}
+			aggr.OrderOffsets = append(aggr.OrderOffsets, offset)
+			aggr.OrderWSOffsets = append(aggr.OrderWSOffsets, wsOffset)
+		}
+		a.Aggregations[idx] = aggr
+	}
 }
 
 func (a *Aggregator) addIfAggregationColumn(ctx *plancontext.PlanningContext, colIdx int) int {
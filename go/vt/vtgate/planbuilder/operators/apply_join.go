@@ -265,13 +265,10 @@ func (aj *ApplyJoin) AddWSColumn(ctx *plancontext.PlanningContext, offset int, u
 		aj.JoinColumns.addRight(wsExpr)
 	}
 
-	if out >= 0 {
-		aj.addOffset(out)
-	} else {
-		col := aj.getJoinColumnFor(ctx, aeWrap(wsExpr), wsExpr, !ctx.ContainsAggr(wsExpr))
-		aj.JoinColumns.add(col)
-		aj.planOffsetFor(ctx, col)
-	}
+	// the weight_string column has been added to JoinColumns above, so only the offset is missing.
+	// adding it again would make JoinColumns longer than Columns, and FindCol would then
+	// return offsets that are out of range for the operators on top of this join
+	aj.addOffset(out)
 
 	return len(aj.Columns) - 1
 }
@@ -122,6 +122,10 @@ func cloneASTAndSemState[T sqlparser.SQLNode](ctx *plancontext.PlanningContext,
 // findTablesContained returns the TableSet of all the contained
 func findTablesContained(ctx *plancontext.PlanningContext, node sqlparser.SQLNode) (result semantics.TableSet) {
 	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, isSubq := node.(*sqlparser.Subquery); isSubq {
+			// tables of nested subqueries belong to those subqueries, not to this one
+			return false, nil
+		}
 		t, ok := node.(*sqlparser.AliasedTableExpr)
 		if !ok {
 			return true, nil
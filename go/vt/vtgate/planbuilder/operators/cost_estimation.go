@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+	"vitess.io/vitess/go/vt/vtgate/semantics"
+)
+
+const (
+	// queryCost is the estimated cost of sending a query to a single shard,
+	// expressed as the number of rows that could have been read instead.
+	queryCost = 100
+
+	// hashJoinMaxRows is the largest number of rows a hash join may hold in memory for either of its inputs.
+	hashJoinMaxRows = 100_000
+)
+
+// costEstimate is the estimated number of rows an operator produces,
+// and the estimated cost of producing them.
+type costEstimate struct {
+	rows, cost float64
+}
+
+// estimateCost estimates the rows produced by an operator tree and the cost of producing them,
+// using the table statistics reported by the tablets.
+// It returns false when the statistics of one of the tables are not known.
+func estimateCost(ctx *plancontext.PlanningContext, op Operator) (costEstimate, bool) {
+	switch op := op.(type) {
+	case *Route:
+		rows, ok := estimateRouteRows(ctx, op)
+		if !ok {
+			return costEstimate{}, false
+		}
+		return costEstimate{rows: rows, cost: rows + queryCost*float64(op.Cost())}, true
+	case *ApplyJoin:
+		// the right hand side is executed once for every row of the left hand side,
+		// with the join predicates bound to the values of that row
+		lhs, ok := estimateCost(ctx, op.LHS)
+		if !ok {
+			return costEstimate{}, false
+		}
+		rhs, ok := estimateCost(ctx, op.RHS)
+		if !ok {
+			return costEstimate{}, false
+		}
+		return costEstimate{rows: lhs.rows * rhs.rows, cost: lhs.cost + lhs.rows*rhs.cost}, true
+	case *HashJoin:
+		// both sides are executed once, and the rows of the left hand side are kept in memory
+		lhs, ok := estimateCost(ctx, op.LHS)
+		if !ok {
+			return costEstimate{}, false
+		}
+		rhs, ok := estimateCost(ctx, op.RHS)
+		if !ok {
+			return costEstimate{}, false
+		}
+		return costEstimate{rows: max(lhs.rows, rhs.rows), cost: lhs.cost + rhs.cost + lhs.rows}, true
+	}
+
+	var total costEstimate
+	for _, input := range op.Inputs() {
+		est, ok := estimateCost(ctx, input)
+		if !ok {
+			return costEstimate{}, false
+		}
+		total.rows += est.rows
+		total.cost += est.cost
+	}
+	return total, len(op.Inputs()) > 0
+}
+
+// estimateRouteRows estimates the rows returned by a route. Equality predicates on the leading
+// column of an index limit the rows of a table to the average number of rows per value of the column.
+// When the route joins several tables, we expect MySQL to drive the join from the smallest one.
+func estimateRouteRows(ctx *plancontext.PlanningContext, route *Route) (float64, bool) {
+	var tables []*Table
+	var predicates []sqlparser.Expr
+	_ = Visit(route.Source, func(op Operator) error {
+		switch op := op.(type) {
+		case *Table:
+			tables = append(tables, op)
+			predicates = append(predicates, op.QTable.Predicates...)
+		case *Filter:
+			predicates = append(predicates, op.Predicates...)
+		}
+		return nil
+	})
+	if len(tables) == 0 {
+		return 0, false
+	}
+
+	var rows float64
+	for i, tbl := range tables {
+		stats := tbl.VTable.Statistics()
+		if stats == nil {
+			return 0, false
+		}
+		tblRows := float64(stats.RowCount)
+		for _, pred := range predicates {
+			col := equalityColumn(ctx, pred, tbl.QTable.ID)
+			if col == nil {
+				continue
+			}
+			if perValue, ok := stats.RowsPerValue(col.Name); ok {
+				tblRows = min(tblRows, perValue)
+			}
+		}
+		if i == 0 || tblRows < rows {
+			rows = tblRows
+		}
+	}
+	return rows, true
+}
+
+// equalityColumn returns the column of the given table that the predicate compares for equality
+// with a value that does not depend on the table, such as a literal or a join bind variable.
+func equalityColumn(ctx *plancontext.PlanningContext, pred sqlparser.Expr, id semantics.TableSet) *sqlparser.ColName {
+	cmp, ok := pred.(*sqlparser.ComparisonExpr)
+	if !ok || cmp.Operator != sqlparser.EqualOp {
+		return nil
+	}
+	check := func(colExpr, valExpr sqlparser.Expr) *sqlparser.ColName {
+		col, ok := colExpr.(*sqlparser.ColName)
+		if !ok || ctx.SemTable.RecursiveDeps(col) != id || ctx.SemTable.RecursiveDeps(valExpr).IsOverlapping(id) {
+			return nil
+		}
+		return col
+	}
+	if col := check(cmp.Left, cmp.Right); col != nil {
+		return col
+	}
+	return check(cmp.Right, cmp.Left)
+}
+
+// isCheaper returns true if the plan a is cheaper than the plan b. Joins are compared using the
+// table statistics when they are known, anything else by the cost of its routing.
+func isCheaper(ctx *plancontext.PlanningContext, a, b Operator) bool {
+	if isJoin(a) && isJoin(b) {
+		if ea, ok := estimateCost(ctx, a); ok {
+			if eb, ok := estimateCost(ctx, b); ok && ea.cost != eb.cost {
+				return ea.cost < eb.cost
+			}
+		}
+	}
+	return CostOf(a) < CostOf(b)
+}
+
+func isJoin(op Operator) bool {
+	switch op.(type) {
+	case *ApplyJoin, *HashJoin:
+		return true
+	default:
+		return false
+	}
+}
+
+// cheaperHashJoin returns a hash join for an inner join if the table statistics show it to be cheaper
+// than the nested loop join. Hash joins are limited to a single equality comparison between two columns,
+// and to inputs small enough to be kept in memory.
+func cheaperHashJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, nestedLoop *ApplyJoin, joinPredicates []sqlparser.Expr) *HashJoin {
+	if !nestedLoop.IsInner() || len(joinPredicates) != 1 || ctx.SemTable.QuerySignature.DML || !canUseHashJoin(ctx, lhs, rhs, joinPredicates[0]) {
+		return nil
+	}
+	nestedLoopCost, ok := estimateCost(ctx, nestedLoop)
+	if !ok {
+		return nil
+	}
+	for _, side := range []Operator{lhs, rhs} {
+		est, ok := estimateCost(ctx, side)
+		if !ok || est.rows > hashJoinMaxRows {
+			return nil
+		}
+	}
+
+	join := NewHashJoin(Clone(lhs), Clone(rhs), false)
+	join.AddJoinPredicate(ctx, joinPredicates[0])
+	hashCost, ok := estimateCost(ctx, join)
+	if !ok || hashCost.cost >= nestedLoopCost.cost {
+		return nil
+	}
+	return join
+}
+
+// canUseHashJoin returns true if the predicate compares a column of each side of the join.
+func canUseHashJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, pred sqlparser.Expr) bool {
+	cmp, ok := pred.(*sqlparser.ComparisonExpr)
+	if !ok || !canBeSolvedWithHashJoin(cmp.Operator) {
+		return false
+	}
+	for _, expr := range []sqlparser.Expr{cmp.Left, cmp.Right} {
+		if _, ok := expr.(*sqlparser.ColName); !ok {
+			return false
+		}
+		// the engine needs the types of both columns to hash them the same way
+		if _, found := ctx.TypeForExpr(expr); !found {
+			return false
+		}
+	}
+	lID, rID := TableID(lhs), TableID(rhs)
+	lDeps, rDeps := ctx.SemTable.RecursiveDeps(cmp.Left), ctx.SemTable.RecursiveDeps(cmp.Right)
+	return (lDeps.IsSolvedBy(lID) && rDeps.IsSolvedBy(rID)) || (lDeps.IsSolvedBy(rID) && rDeps.IsSolvedBy(lID))
+}
@@ -74,9 +74,7 @@ func createOperatorFromDelete(ctx *plancontext.PlanningContext, deleteStmt *sqlp
 	var vTbl *vindexes.Table
 	op, vTbl = createDeleteOperator(ctx, deleteStmt)
 
-	if deleteStmt.Comments != nil {
-		op = newLockAndComment(op, deleteStmt.Comments, sqlparser.NoLock)
-	}
+	op = newLockAndComment(op, deleteStmt.Comments, sqlparser.ShareModeLock)
 
 	var err error
 	childFks, err = ctx.SemTable.GetChildForeignKeysForTable(deleteStmt.Targets[0])
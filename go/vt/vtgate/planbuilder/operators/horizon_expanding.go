@@ -187,15 +187,35 @@ func createProjectionFromSelect(ctx *plancontext.PlanningContext, horizon *Horiz
 		}
 	}
 
+	src := horizon.src()
+	sel, isSel := horizon.selectStatement().(*sqlparser.Select)
+	planWindows := isSel && qp.HasWindow && !canPushWindowFunctions(ctx, horizon, sel)
+
 	if qp.NeedsAggregation() {
+		if planWindows {
+			panic(vterrors.VT12001("window functions with aggregation on sharded keyspace"))
+		}
 		return createProjectionWithAggr(ctx, qp, dt, horizon)
 	}
 
-	projX := createProjectionWithoutAggr(ctx, qp, horizon.src())
+	if planWindows {
+		src = createWindowOperators(ctx, sel, qp, src)
+	}
+
+	projX := createProjectionWithoutAggr(ctx, qp, src)
 	projX.DT = dt
 	return projX
 }
 
+// canPushWindowFunctions returns true if MySQL is able to evaluate all the window functions of the query
+func canPushWindowFunctions(ctx *plancontext.PlanningContext, horizon *Horizon, sel *sqlparser.Select) bool {
+	if ks, _ := ctx.SemTable.SingleUnshardedKeyspace(); ks != nil {
+		return true
+	}
+	rb, isRoute := horizon.src().(*Route)
+	return isRoute && (rb.IsSingleShard() || windowsAlignedWithShardKey(ctx, sel))
+}
+
 func createProjectionWithAggr(ctx *plancontext.PlanningContext, qp *QueryProjection, dt *DerivedTable, horizon *Horizon) Operator {
 	aggregations, complexAggr := qp.AggregationExpressions(ctx, true)
 	src := horizon.Source
@@ -208,7 +208,8 @@ func pushOrExpandHorizon(ctx *plancontext.PlanningContext, in *Horizon) (Operato
 		!needsOrdering &&
 		!qp.NeedsAggregation() &&
 		!in.selectStatement().IsDistinct() &&
-		in.selectStatement().GetLimit() == nil
+		in.selectStatement().GetLimit() == nil &&
+		(!qp.HasWindow || isSel && windowsAlignedWithShardKey(ctx, sel))
 
 	if canPush {
 		return Swap(in, rb, "push horizon into route")
@@ -485,6 +486,9 @@ func setUpperLimit(in *Limit) (Operator, *ApplyResult) {
 		case *Join, *ApplyJoin, *SubQueryContainer, *SubQuery:
 			// we can't push limits down on either side
 			return SkipChildren
+		case *Window:
+			// window functions need to see all rows of a partition
+			return SkipChildren
 		case *Aggregator:
 			if len(op.Grouping) > 0 {
 				// we can't push limits down if we have a group by
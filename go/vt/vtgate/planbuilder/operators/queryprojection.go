@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -42,6 +43,7 @@ type (
 		// If you change the contents here, please update the toString() method
 		SelectExprs  []SelectExpr
 		HasAggr      bool
+		HasWindow    bool
 		Distinct     bool
 		WithRollup   bool
 		groupByExprs []GroupBy
@@ -76,12 +78,18 @@ type (
 
 		Alias string // The alias name for the aggregation result
 
-		Distinct bool // Whether the aggregation function is DISTINCT
+		Distinct     bool // Whether the aggregation function is DISTINCT
+		HashDistinct bool // Whether the distinct values are found by hashing instead of ordering the input
 
 		// Offsets pointing to columns within the same aggregator
 		ColOffset int // Offset for the column being aggregated
 		WSOffset  int // Offset for the weight string of the column
 
+		// Offsets of the arguments and the ORDER BY expressions of a GROUP_CONCAT evaluated on vtgate from the rows of each group
+		ArgOffsets     []int
+		OrderOffsets   []int
+		OrderWSOffsets []int
+
 		SubQueryExpression []*SubQuery // Subqueries associated with this aggregation
 
 		PushedDown bool // Whether the aggregation has been pushed down to the next layer
@@ -92,6 +100,35 @@ func (aggr Aggr) NeedsWeightString(ctx *plancontext.PlanningContext) bool {
 	return aggr.OpCode.NeedsComparableValues() && ctx.NeedsWeightString(aggr.Func.GetArg())
 }
 
+// groupConcatNeedsRows returns true for a GROUP_CONCAT that cannot be computed by concatenating
+// per-shard results, because its values have to be ordered or de-duplicated across all shards.
+func (aggr Aggr) groupConcatNeedsRows() bool {
+	gc, ok := aggr.Func.(*sqlparser.GroupConcatExpr)
+	if !ok {
+		return false
+	}
+	return len(gc.OrderBy) > 0 || (gc.Distinct && len(gc.Exprs) > 1)
+}
+
+// GroupConcatOrderBy returns the ORDER BY of a GROUP_CONCAT, with positions resolved to the arguments they refer to.
+func (aggr Aggr) GroupConcatOrderBy() sqlparser.OrderBy {
+	gc := aggr.Func.(*sqlparser.GroupConcatExpr)
+	orderBy := make(sqlparser.OrderBy, 0, len(gc.OrderBy))
+	for _, order := range gc.OrderBy {
+		lit, ok := order.Expr.(*sqlparser.Literal)
+		if !ok || lit.Type != sqlparser.IntVal {
+			orderBy = append(orderBy, order)
+			continue
+		}
+		pos, err := strconv.Atoi(lit.Val)
+		if err != nil || pos < 1 || pos > len(gc.Exprs) {
+			panic(vterrors.VT03014(lit.Val, "order clause"))
+		}
+		orderBy = append(orderBy, &sqlparser.Order{Expr: gc.Exprs[pos-1], Direction: order.Direction})
+	}
+	return orderBy
+}
+
 func (aggr Aggr) GetTypeCollation(ctx *plancontext.PlanningContext) evalengine.Type {
 	if aggr.Func == nil {
 		return evalengine.NewUnknownType()
@@ -100,6 +137,11 @@ func (aggr Aggr) GetTypeCollation(ctx *plancontext.PlanningContext) evalengine.T
 	case opcode.AggregateMin, opcode.AggregateMax, opcode.AggregateSumDistinct, opcode.AggregateCountDistinct:
 		typ, _ := ctx.TypeForExpr(aggr.Func.GetArg())
 		return typ
+	case opcode.AggregateGroupConcat:
+		if aggr.Distinct && !aggr.groupConcatNeedsRows() {
+			typ, _ := ctx.TypeForExpr(aggr.Func.GetArg())
+			return typ
+		}
 
 	}
 	return evalengine.Type{}
@@ -186,6 +228,9 @@ func (qp *QueryProjection) addSelectExpressions(ctx *plancontext.PlanningContext
 				col.Aggr = true
 				qp.HasAggr = true
 			}
+			if sqlparser.ContainsWindowFunc(selExp.Expr) {
+				qp.HasWindow = true
+			}
 
 			qp.SelectExprs = append(qp.SelectExprs, col)
 		case *sqlparser.StarExpr:
@@ -242,6 +287,7 @@ func (qp *QueryProjection) addOrderBy(ctx *plancontext.PlanningContext, orderBy
 			SimplifiedExpr: order.Expr,
 		})
 		canPushSorting = canPushSorting && !ctx.ContainsAggr(order.Expr)
+		qp.HasWindow = qp.HasWindow || sqlparser.ContainsWindowFunc(order.Expr)
 	}
 }
 
@@ -18,6 +18,7 @@ package operators
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 
 	querypb "vitess.io/vitess/go/vt/proto/query"
@@ -101,7 +102,7 @@ func buildVindexTableForDML(
 
 	tblName, ok := table.Alias.Expr.(sqlparser.TableName)
 	if !ok {
-		panic(vterrors.VT12001("multi shard UPDATE with LIMIT"))
+		panic(vterrors.VT13001(fmt.Sprintf("expected a table name as the %s target, got: %s", dmlType, sqlparser.String(table.Alias.Expr))))
 	}
 
 	_, _, _, typ, dest, err := ctx.VSchema.FindTableOrVindex(tblName)
@@ -248,7 +249,7 @@ func findBestJoin(
 				continue
 			}
 			plan := getJoinFor(ctx, planCache, lhs, rhs, joinPredicates)
-			if bestPlan == nil || CostOf(plan) < CostOf(bestPlan) {
+			if bestPlan == nil || isCheaper(ctx, plan, bestPlan) {
 				bestPlan = plan
 				// remember which plans we based on, so we can remove them later
 				lIdx = i
@@ -317,6 +318,11 @@ func mergeOrJoin(ctx *plancontext.PlanningContext, lhs, rhs Operator, joinPredic
 		join.AddJoinPredicate(ctx, pred)
 	}
 
+	if hashJoin := cheaperHashJoin(ctx, lhs, rhs, join, joinPredicates); hashJoin != nil {
+		ctx.SemTable.QuerySignature.HashJoin = true
+		return hashJoin, Rewrote("use a hash join because the table statistics show it is cheaper")
+	}
+
 	return join, Rewrote("logical join to applyJoin ")
 }
 
@@ -20,11 +20,14 @@ import (
 	"fmt"
 	"maps"
 	"slices"
+	"strings"
 
 	"vitess.io/vitess/go/slice"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/evalengine"
 	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
 	"vitess.io/vitess/go/vt/vtgate/semantics"
 )
@@ -52,6 +55,10 @@ type SubQuery struct {
 	// correlated stores whether this subquery is correlated or not.
 	// We use this information to fail the planning if we are unable to merge the subquery with a route.
 	correlated bool
+	// CorrelatedPredicate is the predicate evaluated on vtgate for each outer row when
+	// a correlated subquery could not be merged and is not a plain EXISTS.
+	CorrelatedPredicate  sqlparser.Expr
+	PredicateWithOffsets evalengine.Expr
 
 	// IsArgument is set to true if the subquery puts the
 	IsArgument bool
@@ -69,6 +76,23 @@ func (sq *SubQuery) planOffsets(ctx *plancontext.PlanningContext) Operator {
 			sq.Vars[lhsExpr.Name] = offset
 		}
 	}
+	if sq.CorrelatedPredicate == nil {
+		return nil
+	}
+
+	cfg := &evalengine.Config{
+		ResolveType: ctx.TypeForExpr,
+		Collation:   ctx.SemTable.Collation,
+		Environment: ctx.VSchema.Environment(),
+	}
+	rewritten := useOffsets(ctx, sq.CorrelatedPredicate, sq)
+	sq.PredicateWithOffsets, err = evalengine.Translate(rewritten, cfg)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), evalengine.ErrTranslateExprNotSupported) {
+			panic(vterrors.Errorf(vtrpcpb.Code_UNIMPLEMENTED, "%s: %s", evalengine.ErrTranslateExprNotSupported, sqlparser.String(sq.CorrelatedPredicate)))
+		}
+		panic(err)
+	}
 	return nil
 }
 
@@ -126,6 +150,7 @@ func (sq *SubQuery) Clone(inputs []Operator) Operator {
 	klone.JoinColumns = slices.Clone(sq.JoinColumns)
 	klone.Vars = maps.Clone(sq.Vars)
 	klone.Predicates = sqlparser.Clone(sq.Predicates)
+	klone.CorrelatedPredicate = sqlparser.CloneExpr(sq.CorrelatedPredicate)
 	return &klone
 }
 
@@ -168,6 +193,9 @@ func (sq *SubQuery) ShortDescription() string {
 		preds := append(sq.Predicates, sq.OuterPredicate)
 		pred = " MERGE ON " + sqlparser.String(sqlparser.AndExpressions(preds...))
 	}
+	if sq.CorrelatedPredicate != nil {
+		pred += " FILTER " + sqlparser.String(sq.CorrelatedPredicate)
+	}
 	return fmt.Sprintf(":%s %s %v%s", sq.ArgName, typ, sq.FilterType.String(), pred)
 }
 
@@ -213,8 +241,12 @@ func (sq *SubQuery) settle(ctx *plancontext.PlanningContext, outer Operator) Ope
 	if !sq.TopLevel && sq.correlated {
 		panic(subqueryNotAtTopErr)
 	}
-	if sq.correlated && sq.FilterType != opcode.PulloutExists {
-		panic(correlatedSubqueryErr)
+	if sq.correlated && !sq.correlationSolvedBy(ctx, outer) {
+		panic(nestedCorrelationErr)
+	}
+	if sq.correlated && len(sq.Predicates) == 0 && sq.FilterType != opcode.PulloutExists {
+		// the outer values are not used in predicates, so we have nothing to pass down as bind variables
+		panic(correlatedOutsidePredicatesErr)
 	}
 	if sq.IsArgument {
 		if len(sq.GetMergePredicates()) > 0 {
@@ -227,8 +259,24 @@ func (sq *SubQuery) settle(ctx *plancontext.PlanningContext, outer Operator) Ope
 	return sq.settleFilter(ctx, outer)
 }
 
-var correlatedSubqueryErr = vterrors.VT12001("correlated subquery is only supported for EXISTS")
-var subqueryNotAtTopErr = vterrors.VT12001("unmergable subquery can not be inside complex expression")
+var (
+	correlatedSubqueryErr          = vterrors.VT12001("correlated subquery is only supported as a filter")
+	correlatedOutsidePredicatesErr = vterrors.VT12001("correlated subquery is only supported when correlated through its WHERE clause")
+	nestedCorrelationErr           = vterrors.VT12001("correlated subquery referencing a query other than its immediate outer query")
+	subqueryNotAtTopErr            = vterrors.VT12001("unmergable subquery can not be inside complex expression")
+)
+
+// correlationSolvedBy returns true if the correlated predicates only depend on the subquery itself
+// and the given outer operator. Values can only be passed down one level, so anything else can't be planned.
+func (sq *SubQuery) correlationSolvedBy(ctx *plancontext.PlanningContext, outer Operator) bool {
+	available := TableID(outer).Merge(findTablesContained(ctx, sq.originalSubquery.Select))
+	for _, pred := range sq.Predicates {
+		if !ctx.SemTable.RecursiveDeps(pred).IsSolvedBy(available) {
+			return false
+		}
+	}
+	return true
+}
 
 func (sq *SubQuery) addLimit() {
 	// for a correlated subquery, we can add a limit 1 to the subquery
@@ -236,10 +284,8 @@ func (sq *SubQuery) addLimit() {
 }
 
 func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operator) Operator {
-	if len(sq.Predicates) > 0 {
-		if sq.FilterType != opcode.PulloutExists {
-			panic(correlatedSubqueryErr)
-		}
+	if len(sq.Predicates) > 0 && sq.FilterType == opcode.PulloutExists {
+		// correlated EXISTS is planned as a semi-join, no predicate needs to be evaluated
 		sq.addLimit()
 		return outer
 	}
@@ -306,6 +352,12 @@ func (sq *SubQuery) settleFilter(ctx *plancontext.PlanningContext, outer Operato
 		predicates = append(predicates, rhsPred)
 		sq.SubqueryValueName = sq.ArgName
 	}
+	if len(sq.Predicates) > 0 {
+		// the subquery has to be executed once per outer row, so the predicate
+		// using its result is evaluated by the subquery operator itself
+		sq.CorrelatedPredicate = sqlparser.AndExpressions(predicates...)
+		return outer
+	}
 	return newFilter(outer, predicates...)
 }
 
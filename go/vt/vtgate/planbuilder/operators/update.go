@@ -46,6 +46,10 @@ type (
 		// On merging this information will be lost, so subquery merge is blocked.
 		SubQueriesArgOnChangedVindex []string
 
+		// RowMove is set when the update changes the primary vindex columns,
+		// which moves the updated rows to other shards.
+		RowMove *engine.RowMove
+
 		VerifyAll bool
 
 		noColumns
@@ -371,7 +375,7 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 		Name:   name,
 	}
 
-	cvv, ovq, subQueriesArgOnChangedVindex := getUpdateVindexInformation(ctx, updStmt, targetTbl, assignments)
+	cvv, ovq, subQueriesArgOnChangedVindex, rowMove := getUpdateVindexInformation(ctx, updStmt, targetTbl, assignments)
 
 	updOp := &Update{
 		DMLCommon: &DMLCommon{
@@ -382,6 +386,7 @@ func createUpdateOperator(ctx *plancontext.PlanningContext, updStmt *sqlparser.U
 		Assignments:                  assignments,
 		ChangedVindexValues:          cvv,
 		SubQueriesArgOnChangedVindex: subQueriesArgOnChangedVindex,
+		RowMove:                      rowMove,
 		VerifyAll:                    ctx.VerifyAllFKs,
 	}
 
@@ -403,14 +408,17 @@ func getUpdateVindexInformation(
 	updStmt *sqlparser.Update,
 	table TargetTable,
 	assignments []SetExpr,
-) (map[string]*engine.VindexValues, *sqlparser.Select, []string) {
+) (map[string]*engine.VindexValues, *sqlparser.Select, []string, *engine.RowMove) {
 	if !table.VTable.Keyspace.Sharded {
-		return nil, nil, nil
+		return nil, nil, nil, nil
 	}
 
 	primaryVindex := getVindexInformation(table.ID, table.VTable)
-	changedVindexValues, ownedVindexQuery, subQueriesArgOnChangedVindex := buildChangedVindexesValues(ctx, updStmt, table.VTable, primaryVindex.Columns, assignments)
-	return changedVindexValues, ownedVindexQuery, subQueriesArgOnChangedVindex
+	changedVindexValues, ownedVindexQuery, subQueriesArgOnChangedVindex, rowMove := buildChangedVindexesValues(ctx, updStmt, table.VTable, primaryVindex.Columns, assignments)
+	if rowMove != nil && (len(ctx.SemTable.GetParentForeignKeysForTableSet(table.ID)) > 0 || len(ctx.SemTable.GetChildForeignKeysForTableSet(table.ID)) > 0) {
+		panic(vterrors.VT12001(fmt.Sprintf("updating the primary vindex columns of a table with foreign keys; invalid update on vindex: %v", table.VTable.ColumnVindexes[0].Name)))
+	}
+	return changedVindexValues, ownedVindexQuery, subQueriesArgOnChangedVindex, rowMove
 }
 
 func buildFkOperator(ctx *plancontext.PlanningContext, updOp Operator, updClone *sqlparser.Update, parentFks []vindexes.ParentFKInfo, childFks []vindexes.ChildFKInfo, targetTbl TargetTable) Operator {
@@ -1034,7 +1042,7 @@ func buildChangedVindexesValues(
 	table *vindexes.Table,
 	ksidCols []sqlparser.IdentifierCI,
 	assignments []SetExpr,
-) (changedVindexes map[string]*engine.VindexValues, ovq *sqlparser.Select, subQueriesArgOnChangedVindex []string) {
+) (changedVindexes map[string]*engine.VindexValues, ovq *sqlparser.Select, subQueriesArgOnChangedVindex []string, rowMove *engine.RowMove) {
 	changedVindexes = make(map[string]*engine.VindexValues)
 	selExprs, offset := initialQuery(ksidCols, table)
 	for i, vindex := range table.ColumnVindexes {
@@ -1049,9 +1057,10 @@ func buildChangedVindexesValues(
 			continue
 		}
 		if i == 0 {
-			panic(vterrors.VT12001(fmt.Sprintf("you cannot UPDATE primary vindex columns; invalid update on vindex: %v", vindex.Name)))
-		}
-		if _, ok := vindex.Vindex.(vindexes.Lookup); !ok {
+			if !table.AllowPrimaryVindexUpdate {
+				panic(vterrors.VT12001(fmt.Sprintf("you cannot UPDATE primary vindex columns; invalid update on vindex: %v", vindex.Name)))
+			}
+		} else if _, ok := vindex.Vindex.(vindexes.Lookup); !ok {
 			panic(vterrors.VT12001(fmt.Sprintf("you can only UPDATE lookup vindexes; invalid update on vindex: %v", vindex.Name)))
 		}
 
@@ -1064,7 +1073,10 @@ func buildChangedVindexesValues(
 		offset++
 	}
 	if len(changedVindexes) == 0 {
-		return nil, nil, nil
+		return nil, nil, nil, nil
+	}
+	if _, ok := changedVindexes[table.ColumnVindexes[0].Name]; ok {
+		rowMove, selExprs, subQueriesArgOnChangedVindex = buildRowMove(ctx, table, assignments, selExprs, offset, subQueriesArgOnChangedVindex)
 	}
 	// generate rest of the owned vindex query.
 	ovq = &sqlparser.Select{
@@ -1073,7 +1085,51 @@ func buildChangedVindexesValues(
 		Limit:       update.Limit,
 		Lock:        sqlparser.ForUpdateLock,
 	}
-	return changedVindexes, ovq, subQueriesArgOnChangedVindex
+	return changedVindexes, ovq, subQueriesArgOnChangedVindex, rowMove
+}
+
+// buildRowMove plans moving the rows whose primary vindex columns are updated.
+// The primary key columns are added to the owned vindex query, so that the
+// rows can be found by their new primary key once they are updated.
+func buildRowMove(
+	ctx *plancontext.PlanningContext,
+	table *vindexes.Table,
+	assignments []SetExpr,
+	selExprs sqlparser.SelectExprs,
+	offset int,
+	subQueriesArgOnChangedVindex []string,
+) (*engine.RowMove, sqlparser.SelectExprs, []string) {
+	if len(table.PrimaryKey) == 0 {
+		panic(vterrors.VT09015())
+	}
+	rowMove := &engine.RowMove{Table: table.Name.String()}
+	var pkCols sqlparser.ValTuple
+	for _, col := range table.PrimaryKey {
+		pkValues := make(map[string]evalengine.Expr)
+		subQueriesArgOnChangedVindex, _ = createAssignmentExpressions(ctx, assignments, col, subQueriesArgOnChangedVindex, pkValues, nil)
+		rowMove.PKOffsets = append(rowMove.PKOffsets, offset)
+		rowMove.PKValues = append(rowMove.PKValues, pkValues[col.String()])
+		selExprs = append(selExprs, aeWrap(sqlparser.NewColName(col.String())))
+		pkCols = append(pkCols, sqlparser.NewColName(col.String()))
+		offset++
+	}
+	var lhs sqlparser.Expr = pkCols
+	if len(pkCols) == 1 {
+		lhs = pkCols[0]
+	}
+	where := sqlparser.NewWhere(sqlparser.WhereClause, sqlparser.NewComparisonExpr(sqlparser.InOp, lhs, sqlparser.ListArg(engine.DmlVals), nil))
+	from := sqlparser.TableExprs{sqlparser.NewAliasedTableExpr(sqlparser.NewTableName(table.Name.String()), "")}
+	rowMove.SelectQuery = sqlparser.String(&sqlparser.Select{
+		SelectExprs: sqlparser.SelectExprs{&sqlparser.StarExpr{}},
+		From:        from,
+		Where:       where,
+		Lock:        sqlparser.ForUpdateLock,
+	})
+	rowMove.DeleteQuery = sqlparser.String(&sqlparser.Delete{
+		TableExprs: from,
+		Where:      where,
+	})
+	return rowMove, selExprs, subQueriesArgOnChangedVindex
 }
 
 func initialQuery(ksidCols []sqlparser.IdentifierCI, table *vindexes.Table) (sqlparser.SelectExprs, int) {
@@ -0,0 +1,472 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operators
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"vitess.io/vitess/go/slice"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vtgate/engine/opcode"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+type (
+	// Window evaluates window functions on the vtgate.
+	// It expects its input to be sorted by the PARTITION BY expressions followed by the ORDER BY
+	// expressions of the window. Columns are 1:1 with the source. For every window function,
+	// the source produces the argument of the function in the column where the result
+	// of the function is written.
+	Window struct {
+		unaryOperator
+		Columns []*sqlparser.AliasedExpr
+
+		PartitionBy []sqlparser.Expr
+		OrderBy     []OrderBy
+		Functions   []WindowFunc
+
+		// These are filled in during offset planning
+		PartitionOffsets, PartitionWSOffsets []int
+		OrderOffsets, OrderWSOffsets         []int
+
+		offsetPlanned bool
+	}
+
+	// WindowFunc contains all information needed to evaluate a single window function
+	WindowFunc struct {
+		Original *sqlparser.AliasedExpr
+		Func     sqlparser.Expr
+		OpCode   opcode.WindowOpcode
+
+		// AggrOpCode is only used when OpCode is WindowAggregate
+		AggrOpCode opcode.AggregateOpcode
+
+		// Offset and Default are only used for LAG and LEAD
+		Offset, Default sqlparser.Expr
+
+		// Frame is nil when the function uses the default frame
+		Frame *sqlparser.FrameClause
+
+		// ColOffset is the column the argument is fetched into, and the result written to
+		ColOffset int
+	}
+)
+
+func newWindow(src Operator, partitionBy []sqlparser.Expr, orderBy []OrderBy, functions []WindowFunc) *Window {
+	w := &Window{
+		unaryOperator: newUnaryOp(src),
+		PartitionBy:   partitionBy,
+		OrderBy:       orderBy,
+		Functions:     functions,
+	}
+	for idx, fn := range functions {
+		w.Functions[idx].ColOffset = len(w.Columns)
+		w.Columns = append(w.Columns, fn.Original)
+	}
+	return w
+}
+
+func (w *Window) Clone(inputs []Operator) Operator {
+	kopy := *w
+	kopy.Source = inputs[0]
+	kopy.Columns = slices.Clone(w.Columns)
+	kopy.PartitionBy = slices.Clone(w.PartitionBy)
+	kopy.OrderBy = slices.Clone(w.OrderBy)
+	kopy.Functions = slices.Clone(w.Functions)
+	kopy.PartitionOffsets = slices.Clone(w.PartitionOffsets)
+	kopy.PartitionWSOffsets = slices.Clone(w.PartitionWSOffsets)
+	kopy.OrderOffsets = slices.Clone(w.OrderOffsets)
+	kopy.OrderWSOffsets = slices.Clone(w.OrderWSOffsets)
+	return &kopy
+}
+
+func (w *Window) AddPredicate(_ *plancontext.PlanningContext, expr sqlparser.Expr) Operator {
+	// the result of a window function depends on all rows in the partition,
+	// so we can't filter rows before the window functions have been evaluated
+	return newFilter(w, expr)
+}
+
+func (w *Window) AddColumn(ctx *plancontext.PlanningContext, reuse bool, gb bool, ae *sqlparser.AliasedExpr) int {
+	w.planOffsets(ctx)
+
+	if reuse || sqlparser.IsWindowFunc(ae.Expr) {
+		offset := w.FindCol(ctx, ae.Expr, false)
+		if offset >= 0 {
+			return offset
+		}
+	}
+
+	offset := len(w.Columns)
+	w.Columns = append(w.Columns, ae)
+	incomingOffset := w.Source.AddColumn(ctx, false, gb, ae)
+	if offset != incomingOffset {
+		panic(errFailedToPlan(ae))
+	}
+	return offset
+}
+
+func (w *Window) AddWSColumn(ctx *plancontext.PlanningContext, offset int, underRoute bool) int {
+	if offset >= len(w.Columns) {
+		panic(vterrors.VT13001("offset out of range"))
+	}
+	if w.isFuncOffset(offset) {
+		panic(vterrors.VT12001("weight_string of a window function result"))
+	}
+	w.planOffsets(ctx)
+	return w.addWSColumn(ctx, offset)
+}
+
+func (w *Window) FindCol(ctx *plancontext.PlanningContext, expr sqlparser.Expr, _ bool) int {
+	return slices.IndexFunc(w.Columns, func(col *sqlparser.AliasedExpr) bool {
+		return ctx.SemTable.EqualsExprWithDeps(col.Expr, expr)
+	})
+}
+
+func (w *Window) GetColumns(*plancontext.PlanningContext) []*sqlparser.AliasedExpr {
+	return w.Columns
+}
+
+func (w *Window) GetSelectExprs(ctx *plancontext.PlanningContext) sqlparser.SelectExprs {
+	return transformColumnsToSelectExprs(ctx, w)
+}
+
+func (w *Window) ShortDescription() string {
+	return strings.Join(slice.Map(w.Columns, func(from *sqlparser.AliasedExpr) string {
+		return sqlparser.String(from)
+	}), ", ")
+}
+
+func (w *Window) GetOrdering(ctx *plancontext.PlanningContext) []OrderBy {
+	return w.Source.GetOrdering(ctx)
+}
+
+func (w *Window) planOffsets(ctx *plancontext.PlanningContext) Operator {
+	if w.offsetPlanned {
+		return nil
+	}
+	w.offsetPlanned = true
+
+	// every function needs a column of its own, since the result overwrites the argument.
+	// we use a projection to make sure that we get exactly the columns we ask for
+	w.Source = newAliasedProjection(w.Source)
+	for colIdx, col := range w.Columns {
+		expr := col.Expr
+		if fnIdx := slices.IndexFunc(w.Functions, func(fn WindowFunc) bool { return fn.ColOffset == colIdx }); fnIdx >= 0 {
+			expr = w.Functions[fnIdx].argument()
+		}
+		offset := w.Source.AddColumn(ctx, false, false, aeWrap(expr))
+		if offset != colIdx {
+			panic(errFailedToPlan(col))
+		}
+	}
+
+	for _, expr := range w.PartitionBy {
+		offset, wsOffset := w.addKeyColumn(ctx, expr)
+		w.PartitionOffsets = append(w.PartitionOffsets, offset)
+		w.PartitionWSOffsets = append(w.PartitionWSOffsets, wsOffset)
+	}
+
+	for _, order := range w.OrderBy {
+		offset, wsOffset := w.addKeyColumn(ctx, order.SimplifiedExpr)
+		w.OrderOffsets = append(w.OrderOffsets, offset)
+		w.OrderWSOffsets = append(w.OrderWSOffsets, wsOffset)
+	}
+	return nil
+}
+
+// addKeyColumn adds a column the engine uses to find partition and peer boundaries.
+// The engine reads these from the incoming rows, so they are allowed to share a column with a function argument
+func (w *Window) addKeyColumn(ctx *plancontext.PlanningContext, expr sqlparser.Expr) (offset, wsOffset int) {
+	offset = w.Source.AddColumn(ctx, true, false, aeWrap(expr))
+	if offset == len(w.Columns) {
+		w.Columns = append(w.Columns, aeWrap(expr))
+	}
+	if !ctx.NeedsWeightString(expr) {
+		return offset, -1
+	}
+	return offset, w.addWSColumn(ctx, offset)
+}
+
+func (w *Window) addWSColumn(ctx *plancontext.PlanningContext, offset int) int {
+	wsOffset := w.Source.AddWSColumn(ctx, offset, false)
+	if wsOffset == len(w.Columns) {
+		w.Columns = append(w.Columns, aeWrap(weightStringFor(w.Columns[offset].Expr)))
+	}
+	return wsOffset
+}
+
+func (w *Window) isFuncOffset(offset int) bool {
+	return slices.ContainsFunc(w.Functions, func(fn WindowFunc) bool {
+		return fn.ColOffset == offset
+	})
+}
+
+// argument returns the expression the function needs to read from its input.
+// Functions that don't read any values from their input still need a column to write the result to.
+func (fn WindowFunc) argument() sqlparser.Expr {
+	switch f := fn.Func.(type) {
+	case *sqlparser.LagLeadExpr:
+		return f.Expr
+	case *sqlparser.FirstOrLastValueExpr:
+		return f.Expr
+	case *sqlparser.CountStar:
+		return sqlparser.NewIntLiteral("1")
+	case sqlparser.AggrFunc:
+		return f.GetArg()
+	default:
+		return &sqlparser.NullVal{}
+	}
+}
+
+// newWindowFunc creates the WindowFunc for a window function call, and fails for
+// the window functions and frames that can't be evaluated on the vtgate
+func newWindowFunc(ctx *plancontext.PlanningContext, original *sqlparser.AliasedExpr, spec *sqlparser.WindowSpecification) WindowFunc {
+	fn := WindowFunc{
+		Original: original,
+		Func:     original.Expr,
+		Frame:    spec.FrameClause,
+	}
+
+	switch f := original.Expr.(type) {
+	case *sqlparser.ArgumentLessWindowExpr:
+		fn.OpCode = opcode.SupportedWindowFunctions[f.Type.ToString()]
+	case *sqlparser.LagLeadExpr:
+		checkNullTreatment(f.NullTreatmentClause)
+		fn.OpCode = opcode.SupportedWindowFunctions[f.Type.ToString()]
+		if f.N != nil {
+			switch f.N.(type) {
+			case *sqlparser.Literal, *sqlparser.Argument:
+			default:
+				panic(vterrors.VT12001(fmt.Sprintf("non-constant offset in %s", sqlparser.String(f))))
+			}
+			fn.Offset = f.N
+		}
+		if f.Default != nil {
+			if !ctx.SemTable.RecursiveDeps(f.Default).IsEmpty() || sqlparser.ContainsWindowFunc(f.Default) {
+				panic(vterrors.VT12001(fmt.Sprintf("non-constant default value in %s", sqlparser.String(f))))
+			}
+			fn.Default = f.Default
+		}
+	case *sqlparser.FirstOrLastValueExpr:
+		checkNullTreatment(f.NullTreatmentClause)
+		fn.OpCode = opcode.SupportedWindowFunctions[f.Type.ToString()]
+	case *sqlparser.CountStar:
+		fn.OpCode, fn.AggrOpCode = opcode.WindowAggregate, opcode.AggregateCountStar
+	case *sqlparser.Count:
+		if f.Distinct || len(f.Args) != 1 {
+			panic(vterrors.VT12001(fmt.Sprintf("window function %s", sqlparser.String(f))))
+		}
+		fn.OpCode, fn.AggrOpCode = opcode.WindowAggregate, opcode.AggregateCount
+	case *sqlparser.Sum:
+		fn.OpCode, fn.AggrOpCode = opcode.WindowAggregate, opcode.AggregateSum
+	case *sqlparser.Min:
+		fn.OpCode, fn.AggrOpCode = opcode.WindowAggregate, opcode.AggregateMin
+	case *sqlparser.Max:
+		fn.OpCode, fn.AggrOpCode = opcode.WindowAggregate, opcode.AggregateMax
+	}
+
+	if fn.OpCode == opcode.WindowUnassigned {
+		panic(vterrors.VT12001(fmt.Sprintf("window function %s on sharded keyspace", sqlparser.String(original.Expr))))
+	}
+
+	if fn.Frame != nil {
+		checkFramePoint(fn.Frame.Unit, fn.Frame.Start)
+		checkFramePoint(fn.Frame.Unit, fn.Frame.End)
+	}
+	return fn
+}
+
+func checkNullTreatment(clause *sqlparser.NullTreatmentClause) {
+	if clause != nil && clause.Type == sqlparser.IgnoreNullsType {
+		panic(vterrors.VT12001("IGNORE NULLS in window functions"))
+	}
+}
+
+func checkFramePoint(unit sqlparser.FrameUnitType, point *sqlparser.FramePoint) {
+	if point == nil || (point.Type != sqlparser.ExprPrecedingType && point.Type != sqlparser.ExprFollowingType) {
+		return
+	}
+	if unit == sqlparser.FrameRangeType {
+		panic(vterrors.VT12001("RANGE frame with an offset on sharded keyspace"))
+	}
+	if lit, ok := point.Expr.(*sqlparser.Literal); !ok || lit.Type != sqlparser.IntVal {
+		panic(vterrors.VT12001(fmt.Sprintf("ROWS frame with offset %s", sqlparser.String(point.Expr))))
+	}
+}
+
+// resolveWindowSpec returns the window specification used by an OVER clause,
+// resolving named windows from the WINDOW clause of the query
+func resolveWindowSpec(sel *sqlparser.Select, over *sqlparser.OverClause) *sqlparser.WindowSpecification {
+	spec := over.WindowSpec
+	if !over.WindowName.IsEmpty() {
+		spec = &sqlparser.WindowSpecification{Name: over.WindowName}
+	}
+	if spec == nil {
+		return &sqlparser.WindowSpecification{}
+	}
+
+	for !spec.Name.IsEmpty() {
+		base := findNamedWindow(sel, spec.Name)
+		// a window that refers to another window can only add ORDER BY or frame clauses
+		resolved := &sqlparser.WindowSpecification{
+			Name:            base.Name,
+			PartitionClause: base.PartitionClause,
+			OrderClause:     base.OrderClause,
+			FrameClause:     base.FrameClause,
+		}
+		if len(spec.OrderClause) > 0 {
+			resolved.OrderClause = spec.OrderClause
+		}
+		if spec.FrameClause != nil {
+			resolved.FrameClause = spec.FrameClause
+		}
+		spec = resolved
+	}
+	return spec
+}
+
+func findNamedWindow(sel *sqlparser.Select, name sqlparser.IdentifierCI) *sqlparser.WindowSpecification {
+	for _, namedWindow := range sel.Windows {
+		for _, def := range namedWindow.Windows {
+			if def.Name.Equal(name) {
+				return def.WindowSpec
+			}
+		}
+	}
+	panic(vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "Window name '%s' is not defined.", name.String()))
+}
+
+// sameWindow returns true if the two specifications split and order the rows in the same way
+func sameWindow(ctx *plancontext.PlanningContext, a, b *sqlparser.WindowSpecification) bool {
+	if len(a.PartitionClause) != len(b.PartitionClause) || len(a.OrderClause) != len(b.OrderClause) {
+		return false
+	}
+	for i, expr := range a.PartitionClause {
+		if !ctx.SemTable.EqualsExprWithDeps(expr, b.PartitionClause[i]) {
+			return false
+		}
+	}
+	for i, order := range a.OrderClause {
+		other := b.OrderClause[i]
+		if order.Direction != other.Direction || !ctx.SemTable.EqualsExprWithDeps(order.Expr, other.Expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// windowsAlignedWithShardKey returns true if all window functions of the query partition their rows
+// by a unique vindex column. Every partition then lives on a single shard, and the window functions
+// can be evaluated by MySQL even for scatter queries
+func windowsAlignedWithShardKey(ctx *plancontext.PlanningContext, sel *sqlparser.Select) bool {
+	aligned := true
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if _, isSubq := node.(*sqlparser.Subquery); isSubq {
+			return false, nil
+		}
+		over := sqlparser.GetOverClause(node)
+		if over == nil {
+			return true, nil
+		}
+		spec := resolveWindowSpec(sel, over)
+		if !slices.ContainsFunc(spec.PartitionClause, func(expr sqlparser.Expr) bool {
+			return exprHasUniqueVindex(ctx, expr)
+		}) {
+			aligned = false
+		}
+		return true, nil
+	}, sel.SelectExprs, sel.OrderBy)
+	return aligned
+}
+
+// createWindowOperators plans the window functions of the query that can't be evaluated by MySQL.
+// One Window operator is created per distinct window specification, each with its own ordering
+func createWindowOperators(ctx *plancontext.PlanningContext, sel *sqlparser.Select, qp *QueryProjection, src Operator) Operator {
+	var specs []*sqlparser.WindowSpecification
+	var functions [][]WindowFunc
+	addFunc := func(node sqlparser.Expr, alias sqlparser.IdentifierCI) {
+		spec := resolveWindowSpec(sel, sqlparser.GetOverClause(node))
+		idx := slices.IndexFunc(specs, func(other *sqlparser.WindowSpecification) bool {
+			return sameWindow(ctx, spec, other)
+		})
+		if idx < 0 {
+			idx = len(specs)
+			specs = append(specs, spec)
+			functions = append(functions, nil)
+		}
+		if slices.ContainsFunc(functions[idx], func(fn WindowFunc) bool {
+			return ctx.SemTable.EqualsExprWithDeps(fn.Func, node)
+		}) {
+			return
+		}
+		original := &sqlparser.AliasedExpr{Expr: node, As: alias}
+		functions[idx] = append(functions[idx], newWindowFunc(ctx, original, spec))
+	}
+
+	collect := func(expr sqlparser.Expr, alias sqlparser.IdentifierCI) {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			if _, isSubq := node.(*sqlparser.Subquery); isSubq {
+				return false, nil
+			}
+			e, ok := node.(sqlparser.Expr)
+			if !ok || !sqlparser.IsWindowFunc(e) {
+				return true, nil
+			}
+			if e != expr {
+				alias = sqlparser.IdentifierCI{}
+			}
+			addFunc(e, alias)
+			return false, nil
+		}, expr)
+	}
+
+	for _, selExpr := range qp.SelectExprs {
+		ae, ok := selExpr.Col.(*sqlparser.AliasedExpr)
+		if !ok {
+			continue
+		}
+		collect(ae.Expr, ae.As)
+	}
+	for _, order := range qp.OrderExprs {
+		collect(order.SimplifiedExpr, sqlparser.IdentifierCI{})
+	}
+
+	for idx, spec := range specs {
+		var order []OrderBy
+		for _, expr := range spec.PartitionClause {
+			order = append(order, OrderBy{
+				Inner:          &sqlparser.Order{Expr: expr, Direction: sqlparser.AscOrder},
+				SimplifiedExpr: expr,
+			})
+		}
+		for _, o := range spec.OrderClause {
+			order = append(order, OrderBy{
+				Inner:          ctx.SemTable.Clone(o).(*sqlparser.Order),
+				SimplifiedExpr: o.Expr,
+			})
+		}
+		if len(order) > 0 {
+			src = newOrdering(src, order)
+		}
+		src = newWindow(src, spec.PartitionClause, order[len(spec.PartitionClause):], functions[idx])
+	}
+	return src
+}
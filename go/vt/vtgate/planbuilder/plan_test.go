@@ -293,6 +293,43 @@ func (s *planTestSuite) TestViews() {
 	s.testFile("view_cases.json", vschemaWrapper, false)
 }
 
+// TestStatisticsPlanning tests the join planning when the tablets report table statistics.
+func (s *planTestSuite) TestStatisticsPlanning() {
+	vschema := loadSchema(s.T(), "vschemas/schema.json", true)
+	setStatistics(vschema)
+	vschemaWrapper := &vschemawrapper.VSchemaWrapper{
+		V:           vschema,
+		TestBuilder: TestBuilder,
+		Env:         vtenv.NewTestEnv(),
+	}
+
+	s.testFile("statistics_cases.json", vschemaWrapper, false)
+}
+
+// setStatistics sets the table statistics of a few tables of the user keyspace.
+func setStatistics(vschema *vindexes.VSchema) {
+	index := func(name string, col string, cardinality uint64) vindexes.IndexStatistics {
+		return vindexes.IndexStatistics{
+			Name:        name,
+			Columns:     []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI(col)},
+			Cardinality: []uint64{cardinality},
+		}
+	}
+	tables := vschema.Keyspaces["user"].Tables
+	tables["user"].SetStatistics(&vindexes.TableStatistics{
+		RowCount: 1_000_000,
+		Indexes:  []vindexes.IndexStatistics{index("PRIMARY", "id", 1_000_000), index("idx_col", "col", 100_000)},
+	})
+	tables["user_extra"].SetStatistics(&vindexes.TableStatistics{
+		RowCount: 50,
+		Indexes:  []vindexes.IndexStatistics{index("PRIMARY", "id", 50)},
+	})
+	tables["music"].SetStatistics(&vindexes.TableStatistics{
+		RowCount: 20_000,
+		Indexes:  []vindexes.IndexStatistics{index("PRIMARY", "id", 20_000)},
+	})
+}
+
 func (s *planTestSuite) TestOne() {
 	reset := operators.EnableDebugPrinting()
 	defer reset()
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package planbuilder
+
+import (
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/planbuilder/plancontext"
+)
+
+// resultCacheTTL returns how long vtgate may cache the results of a statement.
+// A RESULT_CACHE_TTL comment directive takes precedence over the vschema. Otherwise, all the tables
+// read by the statement must enable the result cache, and the shortest of their ttls is used.
+// Locking reads, and statements whose results depend on when or by whom they run, are never cached.
+func resultCacheTTL(stmt sqlparser.Statement, vschema plancontext.VSchema, tablesUsed []string) time.Duration {
+	sel, ok := stmt.(sqlparser.SelectStatement)
+	if !ok || sel.GetLock() != sqlparser.NoLock || isNonDeterministic(sel) {
+		return 0
+	}
+
+	directives := sel.GetParsedComments().Directives()
+	if val, ok := directives.GetString(sqlparser.DirectiveResultCacheTTL, ""); ok {
+		ttl, err := time.ParseDuration(val)
+		if err != nil || ttl < 0 {
+			return 0
+		}
+		return ttl
+	}
+
+	var ttl time.Duration
+	for _, used := range tablesUsed {
+		ks, name, found := strings.Cut(used, ".")
+		if !found {
+			return 0
+		}
+		tbl, _, _, _, err := vschema.FindTable(sqlparser.NewTableNameWithQualifier(name, ks))
+		if err != nil || tbl == nil || tbl.ResultCacheTTL <= 0 {
+			return 0
+		}
+		if ttl == 0 || tbl.ResultCacheTTL < ttl {
+			ttl = tbl.ResultCacheTTL
+		}
+	}
+	return ttl
+}
+
+// nonDeterministicFuncs are the functions whose results change from one execution to the next, or
+// depend on the session.
+var nonDeterministicFuncs = map[string]bool{
+	"connection_id":  true,
+	"curdate":        true,
+	"current_date":   true,
+	"current_role":   true,
+	"current_user":   true,
+	"found_rows":     true,
+	"last_insert_id": true,
+	"rand":           true,
+	"random_bytes":   true,
+	"row_count":      true,
+	"session_user":   true,
+	"sleep":          true,
+	"system_user":    true,
+	"unix_timestamp": true,
+	"user":           true,
+	"utc_date":       true,
+	"uuid":           true,
+	"uuid_short":     true,
+}
+
+// isNonDeterministic returns true if the statement uses the current time, a non-deterministic
+// function, or a user or system variable. The rewriter replaces some of them with bind variables
+// that vtgate fills in at execution, which are checked as well.
+func isNonDeterministic(stmt sqlparser.SQLNode) bool {
+	found := false
+	_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.CurTimeFuncExpr, *sqlparser.Variable:
+			found = true
+		case *sqlparser.FuncExpr:
+			if nonDeterministicFuncs[node.Name.Lowered()] {
+				found = true
+			}
+		case *sqlparser.Argument:
+			if node.Name == sqlparser.LastInsertIDName ||
+				node.Name == sqlparser.FoundRowsName ||
+				node.Name == sqlparser.RowCountName ||
+				strings.HasPrefix(node.Name, sqlparser.UserDefinedVariableName) {
+				found = true
+			}
+		}
+		return !found, nil
+	}, stmt)
+	return found
+}
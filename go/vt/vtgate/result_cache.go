@@ -0,0 +1,158 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"encoding/binary"
+	"maps"
+	"slices"
+	"time"
+
+	"vitess.io/vitess/go/cache/theine"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vtgate/engine"
+	"vitess.io/vitess/go/vt/vthash"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+var (
+	resultCacheHits   = stats.NewCounter("ResultCacheHits", "Number of query results served from the result cache")
+	resultCacheMisses = stats.NewCounter("ResultCacheMisses", "Number of cacheable queries whose results were not found in the result cache")
+)
+
+type ResultCacheKey = theine.HashKey256
+type ResultCache = theine.Store[ResultCacheKey, *cachedResult]
+
+// DefaultResultCache returns the result cache sized by the result-cache-memory flag,
+// or nil if the result cache is disabled.
+func DefaultResultCache() *ResultCache {
+	if resultCacheMemory <= 0 {
+		return nil
+	}
+	return theine.NewStore[ResultCacheKey, *cachedResult](resultCacheMemory, false)
+}
+
+// cachedResult is the result of a query, that can be served from the cache until it expires.
+type cachedResult struct {
+	result  *sqltypes.Result
+	expires time.Time
+}
+
+// CachedSize returns the memory used by the cached result.
+func (cr *cachedResult) CachedSize(alloc bool) int64 {
+	if cr == nil {
+		return 0
+	}
+	size := int64(0)
+	if alloc {
+		size += 32
+	}
+	return size + cr.result.CachedSize(true)
+}
+
+// resultCacheKey returns the key of the result of a plan executed with the given bind variables.
+// It returns false if the result cannot be cached: only SELECT statements that enable the result
+// cache, and run outside of transactions and reserved connections on a replica or rdonly target,
+// can use the result cache.
+func (e *Executor) resultCacheKey(ctx context.Context, safeSession *SafeSession, plan *engine.Plan, vcursor *vcursorImpl, bindVars map[string]*querypb.BindVariable) (ResultCacheKey, bool) {
+	if e.results == nil || plan.ResultCacheTTL <= 0 || plan.Type != sqlparser.StmtSelect {
+		return ResultCacheKey{}, false
+	}
+	if safeSession.InTransaction() || safeSession.InReservedConn() {
+		return ResultCacheKey{}, false
+	}
+	switch vcursor.TabletType() {
+	case topodatapb.TabletType_REPLICA, topodatapb.TabletType_RDONLY:
+	default:
+		return ResultCacheKey{}, false
+	}
+
+	// the target and the query are hashed the same way as for the plan cache,
+	// followed by the caller, whose table ACLs apply to the query, the system
+	// variables of the session, which are applied to the connections the query
+	// runs on, and the bind variables sorted by name
+	hasher := vthash.New256()
+	vcursor.keyForPlan(ctx, plan.Original, hasher)
+
+	var buf []byte
+	write := func(data []byte) {
+		buf = binary.AppendUvarint(buf[:0], uint64(len(data)))
+		buf = append(buf, data...)
+		_, _ = hasher.Write(buf)
+	}
+
+	write([]byte(callerid.GetUsername(callerid.ImmediateCallerIDFromContext(ctx))))
+	effectiveCaller := callerid.EffectiveCallerIDFromContext(ctx)
+	write([]byte(callerid.GetPrincipal(effectiveCaller)))
+	groups := slices.Clone(effectiveCaller.GetGroups())
+	slices.Sort(groups)
+	write(binary.AppendUvarint(nil, uint64(len(groups))))
+	for _, group := range groups {
+		write([]byte(group))
+	}
+
+	sysVars := make(map[string]string)
+	safeSession.GetSystemVariables(func(k, v string) {
+		sysVars[k] = v
+	})
+	sysVarNames := slices.Sorted(maps.Keys(sysVars))
+	write(binary.AppendUvarint(nil, uint64(len(sysVarNames))))
+	for _, name := range sysVarNames {
+		write([]byte(name))
+		write([]byte(sysVars[name]))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(bindVars)) {
+		val, err := bindVars[name].MarshalVT()
+		if err != nil {
+			return ResultCacheKey{}, false
+		}
+		write([]byte(name))
+		write(val)
+	}
+
+	var key ResultCacheKey
+	hasher.Sum(key[:0])
+	return key, true
+}
+
+// getCachedResult returns the result stored in the cache for the given key, if it has not expired.
+func (e *Executor) getCachedResult(key ResultCacheKey) (*sqltypes.Result, bool) {
+	cached, ok := e.results.Get(key, e.epoch.Load())
+	if !ok || time.Now().After(cached.expires) {
+		resultCacheMisses.Add(1)
+		return nil, false
+	}
+	resultCacheHits.Add(1)
+	return cached.result.ShallowCopy(), true
+}
+
+// cacheResult stores the result of a plan in the cache. Cached results are dropped after the ttl of the plan,
+// and whenever the vschema changes, which includes the schema changes reported by the schema tracker.
+func (e *Executor) cacheResult(key ResultCacheKey, plan *engine.Plan, qr *sqltypes.Result) {
+	cached := &cachedResult{
+		result:  qr.Copy(),
+		expires: time.Now().Add(plan.ResultCacheTTL),
+	}
+	e.results.Set(key, cached, cached.CachedSize(true), e.epoch.Load())
+}
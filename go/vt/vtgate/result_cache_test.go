@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/callerid"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestResultCache(t *testing.T) {
+	var primary, replica *sandboxconn.SandboxConn
+	executor, ctx := createExecutorEnvCallback(t, func(shard, ks string, tabletType topodatapb.TabletType, conn *sandboxconn.SandboxConn) {
+		if ks == KsTestUnsharded {
+			switch tabletType {
+			case topodatapb.TabletType_PRIMARY:
+				primary = conn
+			case topodatapb.TabletType_REPLICA:
+				replica = conn
+			}
+		}
+	})
+	executor.normalize = true
+
+	const query = "select /*vt+ RESULT_CACHE_TTL=1m */ id from music_user_map where id = :id"
+	exec := func(session *vtgatepb.Session, query string, id int64) *sqltypes.Result {
+		t.Helper()
+		qr, err := executorExec(ctx, executor, session, query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(id)})
+		require.NoError(t, err)
+		return qr
+	}
+
+	replicaSession := &vtgatepb.Session{TargetString: "@replica", Autocommit: true}
+	first := exec(replicaSession, query, 1)
+	require.EqualValues(t, 1, replica.ExecCount.Load())
+
+	// the same query with the same bind variables is served from the cache
+	hits := resultCacheHits.Get()
+	second := exec(replicaSession, query, 1)
+	require.EqualValues(t, 1, replica.ExecCount.Load())
+	require.Equal(t, hits+1, resultCacheHits.Get())
+	require.Equal(t, first.Rows, second.Rows)
+
+	// other bind variables are a different cache entry
+	exec(replicaSession, query, 2)
+	require.EqualValues(t, 2, replica.ExecCount.Load())
+
+	// other callers are a different cache entry, as their table ACLs may differ
+	otherCtx := callerid.NewContext(ctx, &vtrpcpb.CallerID{Principal: "other"}, &querypb.VTGateCallerID{Username: "other"})
+	_, err := executorExec(otherCtx, executor, replicaSession, query, map[string]*querypb.BindVariable{"id": sqltypes.Int64BindVariable(1)})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, replica.ExecCount.Load())
+
+	// so are sessions with other system variables
+	sysVarSession := &vtgatepb.Session{TargetString: "@replica", Autocommit: true, SystemVariables: map[string]string{"time_zone": "'+08:00'"}}
+	exec(sysVarSession, query, 1)
+	require.EqualValues(t, 4, replica.ExecCount.Load())
+	exec(sysVarSession, query, 1)
+	require.EqualValues(t, 4, replica.ExecCount.Load())
+
+	// queries on a primary are never cached
+	primarySession := &vtgatepb.Session{TargetString: "@primary", Autocommit: true}
+	exec(primarySession, query, 1)
+	exec(primarySession, query, 1)
+	require.EqualValues(t, 2, primary.ExecCount.Load())
+
+	// queries without a ttl are not cached
+	const uncached = "select id from music_user_map where id = :id"
+	exec(replicaSession, uncached, 1)
+	exec(replicaSession, uncached, 1)
+	require.EqualValues(t, 6, replica.ExecCount.Load())
+
+	// expired results are not served
+	const expiring = "select /*vt+ RESULT_CACHE_TTL=1ns */ id from music_user_map where id = :id"
+	exec(replicaSession, expiring, 1)
+	exec(replicaSession, expiring, 1)
+	require.EqualValues(t, 8, replica.ExecCount.Load())
+
+	// the cached results are dropped along with the plans
+	executor.ClearPlans()
+	exec(replicaSession, query, 1)
+	require.EqualValues(t, 9, replica.ExecCount.Load())
+}
@@ -35,6 +35,7 @@ import (
 
 type (
 	keyspaceStr  = string
+	shardStr     = string
 	tableNameStr = string
 	viewNameStr  = string
 
@@ -48,8 +49,15 @@ type (
 		views  *viewMap
 		udfs   map[keyspaceStr][]string
 		ctx    context.Context
+
+		// statistics contains the table statistics last sent by the primary tablet of each shard
+		statistics map[keyspaceStr]map[shardStr][]*querypb.TableStatistics
+
 		signal func() // a function that we'll call whenever we have new schema data
 
+		// statisticsReceiver is called with the table statistics of a keyspace whenever a shard sends new ones
+		statisticsReceiver func(ks string, stats map[string]*vindexes.TableStatistics)
+
 		// map of keyspace currently tracked
 		tracked      map[keyspaceStr]*updateController
 		consumeDelay time.Duration
@@ -70,6 +78,7 @@ func NewTracker(ch chan *discovery.TabletHealth, enableViews, enableUDFs bool, p
 		tracked:      map[keyspaceStr]*updateController{},
 		consumeDelay: defaultConsumeDelay,
 		parser:       parser,
+		statistics:   map[keyspaceStr]map[shardStr][]*querypb.TableStatistics{},
 	}
 
 	if enableViews {
@@ -221,7 +230,13 @@ func (t *Tracker) getKeyspaceUpdateController(th *discovery.TabletHealth) *updat
 }
 
 func (t *Tracker) newUpdateController() *updateController {
-	return &updateController{update: t.updateSchema, reloadKeyspace: t.initKeyspace, signal: t.signal, consumeDelay: t.consumeDelay}
+	return &updateController{
+		update:           t.updateSchema,
+		updateStatistics: t.updateTableStatistics,
+		reloadKeyspace:   t.initKeyspace,
+		signal:           t.signal,
+		consumeDelay:     t.consumeDelay,
+	}
 }
 
 func (t *Tracker) initKeyspace(th *discovery.TabletHealth) error {
@@ -279,7 +294,82 @@ func (t *Tracker) Tables(ks string) map[string]*vindexes.TableInfo {
 		return map[string]*vindexes.TableInfo{} // we know nothing about this KS, so that is the info we can give out
 	}
 
-	return maps.Clone(m)
+	tables := maps.Clone(m)
+	for tbl, stats := range t.keyspaceStatistics(ks) {
+		tblInfo, ok := tables[tbl]
+		if !ok {
+			continue
+		}
+		withStats := *tblInfo
+		withStats.Statistics = stats
+		tables[tbl] = &withStats
+	}
+	return tables
+}
+
+// updateTableStatistics stores the table statistics sent by the primary tablet of a shard,
+// and passes the statistics of the keyspace on to the statistics receiver.
+func (t *Tracker) updateTableStatistics(th *discovery.TabletHealth) {
+	ks := th.Target.Keyspace
+	t.mu.Lock()
+	m := t.statistics[ks]
+	if m == nil {
+		m = map[shardStr][]*querypb.TableStatistics{}
+		t.statistics[ks] = m
+	}
+	m[th.Target.Shard] = th.Stats.TableStatistics
+	receiver := t.statisticsReceiver
+	var stats map[tableNameStr]*vindexes.TableStatistics
+	if receiver != nil {
+		stats = t.keyspaceStatistics(ks)
+	}
+	t.mu.Unlock()
+
+	if receiver != nil {
+		receiver(ks, stats)
+	}
+}
+
+// keyspaceStatistics sums up the table statistics of all the shards of the keyspace.
+func (t *Tracker) keyspaceStatistics(ks string) map[tableNameStr]*vindexes.TableStatistics {
+	res := map[tableNameStr]*vindexes.TableStatistics{}
+	for _, shardStats := range t.statistics[ks] {
+		for _, ts := range shardStats {
+			stats := res[ts.Name]
+			if stats == nil {
+				stats = &vindexes.TableStatistics{}
+				res[ts.Name] = stats
+			}
+			stats.RowCount += ts.RowCount
+			for _, idx := range ts.Indexes {
+				stats.Indexes = addIndexStatistics(stats.Indexes, idx)
+			}
+		}
+	}
+	return res
+}
+
+func addIndexStatistics(indexes []vindexes.IndexStatistics, idx *querypb.IndexStatistics) []vindexes.IndexStatistics {
+	i := slices.IndexFunc(indexes, func(is vindexes.IndexStatistics) bool {
+		return is.Name == idx.Name
+	})
+	if i < 0 {
+		is := vindexes.IndexStatistics{
+			Name:        idx.Name,
+			Cardinality: make([]uint64, len(idx.Cardinality)),
+		}
+		for _, col := range idx.Columns {
+			is.Columns = append(is.Columns, sqlparser.NewIdentifierCI(col))
+		}
+		indexes = append(indexes, is)
+		i = len(indexes) - 1
+	}
+	for j, cardinality := range idx.Cardinality {
+		if j < len(indexes[i].Cardinality) {
+			indexes[i].Cardinality[j] += cardinality
+		}
+	}
+	return indexes
 }
 
 // Views returns all known views in the keyspace with their definition.
@@ -465,6 +555,14 @@ func (t *Tracker) updateViews(keyspace string, res map[string]string) {
 	}
 }
 
+// RegisterStatisticsReceiver allows a function to register to be called with the table statistics
+// of a keyspace whenever they are updated. Unlike schema changes, they don't signal a schema reload.
+func (t *Tracker) RegisterStatisticsReceiver(f func(ks string, stats map[string]*vindexes.TableStatistics)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statisticsReceiver = f
+}
+
 // RegisterSignalReceiver allows a function to register to be called when new schema is available
 func (t *Tracker) RegisterSignalReceiver(f func()) {
 	t.mu.Lock()
@@ -548,3 +548,79 @@ func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
 		return true // timed out
 	}
 }
+
+// TestTableStatisticsTracking tests that the tracker sums up the table statistics of all the shards of a keyspace.
+func TestTableStatisticsTracking(t *testing.T) {
+	tracker := NewTracker(nil, false, false, sqlparser.NewTestParser())
+	tracker.tables.set(keyspace, "t1", nil, nil, nil)
+	tracker.tables.set(keyspace, "t2", nil, nil, nil)
+
+	shardStats := func(shard string, stats ...*querypb.TableStatistics) *discovery.TabletHealth {
+		return &discovery.TabletHealth{
+			Target: &querypb.Target{Keyspace: keyspace, Shard: shard, TabletType: topodatapb.TabletType_PRIMARY},
+			Stats:  &querypb.RealtimeStats{TableStatistics: stats},
+		}
+	}
+	tracker.updateTableStatistics(shardStats("-80", &querypb.TableStatistics{
+		Name:     "t1",
+		RowCount: 100,
+		Indexes:  []*querypb.IndexStatistics{{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{100}}},
+	}, &querypb.TableStatistics{
+		Name:     "unknown",
+		RowCount: 5,
+	}))
+	tracker.updateTableStatistics(shardStats("80-", &querypb.TableStatistics{
+		Name:     "t1",
+		RowCount: 50,
+		Indexes:  []*querypb.IndexStatistics{{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{50}}},
+	}))
+
+	tables := tracker.Tables(keyspace)
+	require.Len(t, tables, 2)
+	utils.MustMatch(t, &vindexes.TableStatistics{
+		RowCount: 150,
+		Indexes: []vindexes.IndexStatistics{{
+			Name:        "PRIMARY",
+			Columns:     []sqlparser.IdentifierCI{sqlparser.NewIdentifierCI("id")},
+			Cardinality: []uint64{150},
+		}},
+	}, tables["t1"].Statistics)
+	assert.Nil(t, tables["t2"].Statistics)
+	// the statistics are not stored in the tracked tables themselves
+	assert.Nil(t, tracker.tables.get(keyspace, "t1").Statistics)
+
+	// a new message from a shard replaces its previous statistics
+	tracker.updateTableStatistics(shardStats("80-", &querypb.TableStatistics{Name: "t1", RowCount: 10}))
+	assert.EqualValues(t, 110, tracker.Tables(keyspace)["t1"].Statistics.RowCount)
+}
+
+// TestTableStatisticsUpdate tests that statistics-only health checks are passed on to the
+// statistics receiver without signaling a schema change.
+func TestTableStatisticsUpdate(t *testing.T) {
+	tracker := NewTracker(nil, false, false, sqlparser.NewTestParser())
+	var received map[string]*vindexes.TableStatistics
+	tracker.RegisterStatisticsReceiver(func(ks string, stats map[string]*vindexes.TableStatistics) {
+		assert.Equal(t, keyspace, ks)
+		received = stats
+	})
+	signals := 0
+	tracker.RegisterSignalReceiver(func() {
+		signals++
+	})
+
+	th := &discovery.TabletHealth{
+		Target:  &querypb.Target{Keyspace: keyspace, Shard: "-80", TabletType: topodatapb.TabletType_PRIMARY},
+		Serving: true,
+		Stats:   &querypb.RealtimeStats{TableStatistics: []*querypb.TableStatistics{{Name: "t1", RowCount: 100}}},
+	}
+	controller := tracker.newUpdateController()
+	controller.loaded = true
+	controller.add(th)
+
+	require.NotNil(t, received["t1"])
+	assert.EqualValues(t, 100, received["t1"].RowCount)
+	controller.mu.Lock()
+	assert.Nil(t, controller.queue)
+	controller.mu.Unlock()
+	assert.Zero(t, signals)
+}
@@ -41,6 +41,9 @@ type (
 		signal         func()
 		loaded         bool
 
+		// updateStatistics stores the table statistics of a health check as soon as it is received
+		updateStatistics func(th *discovery.TabletHealth)
+
 		// we'll only log a failed keyspace loading once
 		ignore bool
 	}
@@ -137,6 +140,11 @@ func (u *updateController) add(th *discovery.TabletHealth) {
 		return
 	}
 
+	// The statistics don't change the schema, so they are stored right away without queueing a schema reload.
+	if th.Serving && th.Stats.TableStatistics != nil && u.updateStatistics != nil {
+		u.updateStatistics(th)
+	}
+
 	u.mu.Lock()
 	defer u.mu.Unlock()
 
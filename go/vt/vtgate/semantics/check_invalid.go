@@ -52,10 +52,8 @@ func (a *analyzer) checkForInvalidConstructs(cursor *sqlparser.Cursor) error {
 		if !a.singleUnshardedKeyspace && node.Action == sqlparser.ReplaceAct {
 			return ShardedError{Inner: &UnsupportedConstruct{errString: "REPLACE INTO with sharded keyspace"}}
 		}
-	case *sqlparser.OverClause:
-		if !a.singleUnshardedKeyspace {
-			return ShardedError{Inner: &UnsupportedConstruct{errString: "OVER CLAUSE with sharded keyspace"}}
-		}
+	case *sqlparser.Update:
+		return checkUpdate(node)
 	}
 
 	return nil
@@ -94,6 +92,20 @@ func (a *analyzer) checkSubqueryColumns(parent sqlparser.SQLNode, subq *sqlparse
 	return nil
 }
 
+// checkUpdate checks that ORDER BY and LIMIT are only used with single-table updates, like MySQL does
+func checkUpdate(node *sqlparser.Update) error {
+	if !sqlparser.MultiTable(node.TableExprs) {
+		return nil
+	}
+	switch {
+	case len(node.OrderBy) > 0:
+		return &WrongUsageError{Stmt: "UPDATE", Clause: "ORDER BY"}
+	case node.Limit != nil:
+		return &WrongUsageError{Stmt: "UPDATE", Clause: "LIMIT"}
+	}
+	return nil
+}
+
 func checkDerived(node *sqlparser.DerivedTable) error {
 	if node.Lateral {
 		return vterrors.VT12001("lateral derived tables")
@@ -60,3 +60,39 @@ func TestCheckAliasedTableExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckUpdate(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr string
+	}{
+		{
+			name:  "single table with ORDER BY and LIMIT",
+			query: "update t1 set a = 1 order by id limit 10",
+		}, {
+			name:  "multiple tables without ORDER BY or LIMIT",
+			query: "update t1 join t2 on t1.id = t2.id set t1.a = 1",
+		}, {
+			name:    "multiple tables with LIMIT",
+			query:   "update t1, t2 set t1.a = 1 where t1.id = t2.id limit 10",
+			wantErr: "Incorrect usage of UPDATE and LIMIT",
+		}, {
+			name:    "multiple tables with ORDER BY and LIMIT",
+			query:   "update t1 join t2 on t1.id = t2.id set t1.a = 1 order by t1.id limit 10",
+			wantErr: "Incorrect usage of UPDATE and ORDER BY",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, err := sqlparser.NewTestParser().Parse(tt.query)
+			require.NoError(t, err)
+			err = checkUpdate(stmt.(*sqlparser.Update))
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
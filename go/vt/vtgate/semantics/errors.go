@@ -39,6 +39,7 @@ type (
 	UnionWithSQLCalcFoundRowsError struct{}
 	MissingInVSchemaError          struct{ Table TableInfo }
 	CantUseOptionHereError         struct{ Msg string }
+	WrongUsageError                struct{ Stmt, Clause string }
 	TableNotUpdatableError         struct{ Table string }
 	UnsupportedNaturalJoinError    struct{ JoinExpr *sqlparser.JoinTableExpr }
 	NotSequenceTableError          struct{ Table string }
@@ -168,6 +169,19 @@ func (e *CantUseOptionHereError) ErrorCode() vtrpcpb.Code {
 	return vtrpcpb.Code_INVALID_ARGUMENT
 }
 
+// WrongUsageError
+func (e *WrongUsageError) Error() string {
+	return eprintf(e, "Incorrect usage of %s and %s", e.Stmt, e.Clause)
+}
+
+func (e *WrongUsageError) ErrorState() vterrors.State {
+	return vterrors.WrongUsage
+}
+
+func (e *WrongUsageError) ErrorCode() vtrpcpb.Code {
+	return vtrpcpb.Code_INVALID_ARGUMENT
+}
+
 // MissingInVSchemaError
 func (e *MissingInVSchemaError) Error() string {
 	tableName, _ := e.Table.Name()
@@ -61,10 +61,25 @@ func (t *typer) up(cursor *sqlparser.Cursor) error {
 			}
 		}
 		t.m[node] = code.ResolveType(inputType, t.collationEnv)
+	case *sqlparser.ArgumentLessWindowExpr:
+		code := opcode.SupportedWindowFunctions[node.Type.ToString()]
+		t.m[node] = code.ResolveType(evalengine.Type{}, t.collationEnv)
+	case *sqlparser.LagLeadExpr:
+		t.setWindowTypeFor(node, opcode.SupportedWindowFunctions[node.Type.ToString()], node.Expr)
+	case *sqlparser.FirstOrLastValueExpr:
+		t.setWindowTypeFor(node, opcode.SupportedWindowFunctions[node.Type.ToString()], node.Expr)
 	}
 	return nil
 }
 
+func (t *typer) setWindowTypeFor(node sqlparser.Expr, code opcode.WindowOpcode, arg sqlparser.Expr) {
+	inputType, ok := t.m[arg]
+	if !ok {
+		return
+	}
+	t.m[node] = code.ResolveType(inputType, t.collationEnv)
+}
+
 func (t *typer) setTypeFor(node *sqlparser.ColName, typ evalengine.Type) {
 	t.m[node] = typ
 }
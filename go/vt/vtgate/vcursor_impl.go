@@ -89,6 +89,7 @@ type (
 		planPrepareStmt(ctx context.Context, vcursor *vcursorImpl, query string) (*engine.Plan, sqlparser.Statement, error)
 
 		environment() *vtenv.Environment
+		transactionMode() vtgatepb.TransactionMode
 		ReadTransaction(ctx context.Context, transactionID string) (*querypb.TransactionMetadata, error)
 		UnresolvedTransactions(ctx context.Context, targets []*querypb.Target) ([]*querypb.TransactionMetadata, error)
 	}
@@ -119,6 +120,7 @@ type (
 		// A nil value represents that no foreign_key_checks value was provided.
 		fkChecksState       *bool
 		ignoreMaxMemoryRows bool
+		queryMemory         *engine.QueryMemory
 		vschema             *vindexes.VSchema
 		vm                  VSchemaOperator
 		semTable            *semantics.SemTable
@@ -206,9 +208,18 @@ func newVCursorImpl(
 		warmingReadsPercent: warmingReadsPct,
 		warmingReadsChannel: warmingReadsChan,
 		resultsObserver:     nullResultsObserver{},
+		queryMemory:         newQueryMemory(),
 	}, nil
 }
 
+// newQueryMemory returns the memory accounting for a new query, or nil if spilling is disabled.
+func newQueryMemory() *engine.QueryMemory {
+	if spillDir == "" {
+		return nil
+	}
+	return engine.NewQueryMemory(spillDir, spillMemoryLimit)
+}
+
 // HasSystemVariables returns whether the session has set system variables or not
 func (vc *vcursorImpl) HasSystemVariables() bool {
 	return vc.safeSession.HasSystemVariables()
@@ -250,6 +261,11 @@ func (vc *vcursorImpl) ExceedsMaxMemoryRows(numRows int) bool {
 	return !vc.ignoreMaxMemoryRows && numRows > maxMemoryRows
 }
 
+// QueryMemory returns the memory accounting of the query, or nil if spilling is disabled.
+func (vc *vcursorImpl) QueryMemory() *engine.QueryMemory {
+	return vc.queryMemory
+}
+
 // SetIgnoreMaxMemoryRows sets the ignoreMaxMemoryRows value.
 func (vc *vcursorImpl) SetIgnoreMaxMemoryRows(ignoreMaxMemoryRows bool) {
 	vc.ignoreMaxMemoryRows = ignoreMaxMemoryRows
@@ -952,6 +968,14 @@ func (vc *vcursorImpl) SetTransactionMode(mode vtgatepb.TransactionMode) {
 	vc.safeSession.TransactionMode = mode
 }
 
+// TransactionMode implements the SessionActions interface
+func (vc *vcursorImpl) TransactionMode() vtgatepb.TransactionMode {
+	if mode := vc.safeSession.TransactionMode; mode != vtgatepb.TransactionMode_UNSPECIFIED {
+		return mode
+	}
+	return vc.executor.transactionMode()
+}
+
 // SetWorkload implements the SessionActions interface
 func (vc *vcursorImpl) SetWorkload(workload querypb.ExecuteOptions_Workload) {
 	vc.safeSession.GetOrCreateOptions().Workload = workload
@@ -1478,6 +1502,7 @@ func (vc *vcursorImpl) CloneForReplicaWarming(ctx context.Context) engine.VCurso
 		warnings:            vc.warnings,
 		pv:                  vc.pv,
 		resultsObserver:     nullResultsObserver{},
+		queryMemory:         newQueryMemory(),
 	}
 
 	v.marginComments.Trailing += "/* warming read */"
@@ -1510,6 +1535,7 @@ func (vc *vcursorImpl) CloneForMirroring(ctx context.Context) engine.VCursor {
 		warnings:            vc.warnings,
 		pv:                  vc.pv,
 		resultsObserver:     nullResultsObserver{},
+		queryMemory:         newQueryMemory(),
 	}
 
 	v.marginComments.Trailing += "/* mirror query */"
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vindexes
+
+import (
+	"sync"
+
+	"vitess.io/vitess/go/vt/sqlparser"
+)
+
+// statisticsMu protects the statistics of all the tables, which are replaced
+// while the tables are used for planning.
+var statisticsMu sync.RWMutex
+
+type (
+	// TableStatistics contains the approximate number of rows of a table and the
+	// cardinality of its indexes, summed over all the shards of the keyspace.
+	TableStatistics struct {
+		RowCount uint64            `json:"row_count"`
+		Indexes  []IndexStatistics `json:"indexes,omitempty"`
+	}
+
+	// IndexStatistics contains the estimated cardinality of an index.
+	// Cardinality holds, for every column of the index, the number of
+	// distinct values of the index prefix ending at that column.
+	IndexStatistics struct {
+		Name        string                   `json:"name"`
+		Columns     []sqlparser.IdentifierCI `json:"columns"`
+		Cardinality []uint64                 `json:"cardinality"`
+	}
+)
+
+// Statistics returns the approximate size of the table as reported by the tablets,
+// or nil if the schema tracker has not received any.
+func (t *Table) Statistics() *TableStatistics {
+	statisticsMu.RLock()
+	defer statisticsMu.RUnlock()
+	return t.statistics
+}
+
+// SetStatistics replaces the statistics of the table.
+func (t *Table) SetStatistics(stats *TableStatistics) {
+	statisticsMu.Lock()
+	defer statisticsMu.Unlock()
+	t.statistics = stats
+}
+
+// RowsPerValue returns the estimated number of rows sharing a value of the given column,
+// using the most selective index starting with the column.
+// It returns false when no index starts with the column.
+func (ts *TableStatistics) RowsPerValue(col sqlparser.IdentifierCI) (float64, bool) {
+	var cardinality uint64
+	for _, idx := range ts.Indexes {
+		if len(idx.Columns) == 0 || !idx.Columns[0].Equal(col) {
+			continue
+		}
+		cardinality = max(cardinality, idx.Cardinality[0])
+	}
+	if cardinality == 0 {
+		return 0, false
+	}
+	// The row count and the cardinalities are estimated separately, and can disagree.
+	return max(float64(ts.RowCount)/float64(cardinality), 1), true
+}
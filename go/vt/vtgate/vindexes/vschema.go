@@ -132,6 +132,12 @@ type Table struct {
 	Columns                 []Column               `json:"columns,omitempty"`
 	Pinned                  []byte                 `json:"pinned,omitempty"`
 	ColumnListAuthoritative bool                   `json:"column_list_authoritative,omitempty"`
+	// AllowPrimaryVindexUpdate allows updates to change the primary vindex columns,
+	// moving the updated rows to the shards that own their new keyspace ids.
+	AllowPrimaryVindexUpdate bool `json:"allow_primary_vindex_update,omitempty"`
+	// ResultCacheTTL is how long vtgate may cache the results of queries reading from this table.
+	// The results are not cached if it is zero.
+	ResultCacheTTL time.Duration `json:"result_cache_ttl,omitempty"`
 	// ReferencedBy is an inverse mapping of tables in other keyspaces that
 	// reference this table via Source.
 	//
@@ -150,6 +156,11 @@ type Table struct {
 	// MySQL error message: ERROR 3756 (HY000): The primary key cannot be a functional index
 	PrimaryKey sqlparser.Columns `json:"primary_key,omitempty"`
 	UniqueKeys []sqlparser.Exprs `json:"unique_keys,omitempty"`
+
+	// statistics contains the approximate size of the table as reported by the tablets,
+	// nil when the schema tracker has not received any. The schema tracker updates it in
+	// place, without rebuilding the vschema.
+	statistics *TableStatistics
 }
 
 // GetTableName gets the sqlparser.TableName for the vindex Table.
@@ -181,6 +192,7 @@ type TableInfo struct {
 	Columns     []Column
 	ForeignKeys []*sqlparser.ForeignKeyDefinition
 	Indexes     []*sqlparser.IndexDefinition
+	Statistics  *TableStatistics
 }
 
 // IsUnique is used to tell whether the ColumnVindex
@@ -629,9 +641,10 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 	}
 	for tname, table := range ks.Tables {
 		t := &Table{
-			Name:                    sqlparser.NewIdentifierCS(tname),
-			Keyspace:                keyspace,
-			ColumnListAuthoritative: table.ColumnListAuthoritative,
+			Name:                     sqlparser.NewIdentifierCS(tname),
+			Keyspace:                 keyspace,
+			ColumnListAuthoritative:  table.ColumnListAuthoritative,
+			AllowPrimaryVindexUpdate: table.AllowPrimaryVindexUpdate,
 		}
 		switch table.Type {
 		case "":
@@ -678,6 +691,18 @@ func buildTables(ks *vschemapb.Keyspace, vschema *VSchema, ksvschema *KeyspaceSc
 			}
 			t.Pinned = decoded
 		}
+		if table.ResultCacheTtl != "" {
+			ttl, err := time.ParseDuration(table.ResultCacheTtl)
+			if err != nil || ttl < 0 {
+				return vterrors.Errorf(
+					vtrpcpb.Code_INVALID_ARGUMENT,
+					"invalid result cache ttl %q for table: %s",
+					table.ResultCacheTtl,
+					tname,
+				)
+			}
+			t.ResultCacheTTL = ttl
+		}
 
 		// If keyspace is sharded, then any table that's not a reference or pinned must have vindexes.
 		if keyspace.Sharded && t.Type != TypeReference && table.Pinned == "" && len(table.ColumnVindexes) == 0 {
@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -613,6 +614,37 @@ func TestVSchemaPinned(t *testing.T) {
 	assert.Equal(t, "\x80", string(t1.Pinned))
 }
 
+func TestVSchemaResultCacheTTL(t *testing.T) {
+	good := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"unsharded": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ResultCacheTtl: "5s"},
+					"t2": {}}}}}
+
+	got := BuildVSchema(&good, sqlparser.NewTestParser())
+	require.NoError(t, got.Keyspaces["unsharded"].Error)
+
+	t1, err := got.FindTable("unsharded", "t1")
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, t1.ResultCacheTTL)
+
+	t2, err := got.FindTable("unsharded", "t2")
+	require.NoError(t, err)
+	assert.Zero(t, t2.ResultCacheTTL)
+
+	bad := vschemapb.SrvVSchema{
+		Keyspaces: map[string]*vschemapb.Keyspace{
+			"unsharded": {
+				Tables: map[string]*vschemapb.Table{
+					"t1": {
+						ResultCacheTtl: "5 seconds"}}}}}
+
+	got = BuildVSchema(&bad, sqlparser.NewTestParser())
+	require.EqualError(t, got.Keyspaces["unsharded"].Error, "invalid result cache ttl \"5 seconds\" for table: t1")
+}
+
 func TestShardedVSchemaOwned(t *testing.T) {
 	good := vschemapb.SrvVSchema{
 		Keyspaces: map[string]*vschemapb.Keyspace{
@@ -0,0 +1,218 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"time"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sidecardb"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/srvtopo"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+const (
+	sqlSelectVStreamCheckpoint = "select keyspace, shard, gtid, table_pks from %s.vstream_checkpoint where consumer = %a order by keyspace, shard"
+	sqlDeleteVStreamCheckpoint = "delete from %s.vstream_checkpoint where consumer = %a"
+	sqlInsertVStreamCheckpoint = "insert into %s.vstream_checkpoint(consumer, keyspace, shard, gtid, table_pks) values (%a, %a, %a, %a, %a)"
+
+	// The lease is taken over once it expired, and only extended by its owner.
+	sqlAcquireVStreamLease = "insert into %s.vstream_consumer_lease(consumer, owner, expires_at) values (%a, %a, now() + interval %a second) " +
+		"on duplicate key update owner = if(expires_at < now(), values(owner), owner), expires_at = if(owner = values(owner), values(expires_at), expires_at)"
+	sqlSelectVStreamLease  = "select 1 from %s.vstream_consumer_lease where consumer = %a and owner = %a"
+	sqlCheckVStreamLease   = "select 1 from %s.vstream_consumer_lease where consumer = %a and owner = %a and expires_at > now() for update"
+	sqlReleaseVStreamLease = "delete from %s.vstream_consumer_lease where consumer = %a and owner = %a"
+)
+
+// vstreamConsumerLeaseTTL is how long a durable consumer keeps its lease
+// without renewing it. A stream renews the lease of its consumer three times
+// per TTL, so that another vtgate can only take over once it ended.
+var vstreamConsumerLeaseTTL = 30 * time.Second
+
+// vstreamCheckpoints saves the positions of the durable VStream consumers, one
+// row per shard, in the vstream_checkpoint table of the sidecar database of a
+// single-shard keyspace.
+type vstreamCheckpoints struct {
+	keyspace string
+	resolver *srvtopo.Resolver
+}
+
+// target returns the primary to save the checkpoints on, and the sidecar
+// database identifier of its keyspace.
+func (vc *vstreamCheckpoints) target(ctx context.Context) (*querypb.Target, string, error) {
+	keyspace, _, shards, err := vc.resolver.GetKeyspaceShards(ctx, vc.keyspace, topodatapb.TabletType_PRIMARY)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(shards) != 1 {
+		return nil, "", vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the vstream checkpoint keyspace %s must have a single shard, it has %d", keyspace, len(shards))
+	}
+	sidecarDBID, err := sidecardb.GetIdentifierForKeyspace(keyspace)
+	if err != nil {
+		return nil, "", err
+	}
+	return &querypb.Target{
+		Keyspace:   keyspace,
+		Shard:      shards[0].Name,
+		TabletType: topodatapb.TabletType_PRIMARY,
+	}, sidecarDBID, nil
+}
+
+// acquire takes or renews the lease of the consumer for owner for ttl. It fails if
+// another owner holds an unexpired lease, for example a stream of the
+// consumer through another vtgate.
+func (vc *vstreamCheckpoints) acquire(ctx context.Context, consumer, owner string, ttl time.Duration) error {
+	target, sidecarDBID, err := vc.target(ctx)
+	if err != nil {
+		return err
+	}
+	gw := vc.resolver.GetGateway()
+	bindVars := map[string]*querypb.BindVariable{
+		"consumer": sqltypes.StringBindVariable(consumer),
+		"owner":    sqltypes.StringBindVariable(owner),
+		"ttl":      sqltypes.Int64BindVariable(int64(ttl / time.Second)),
+	}
+	query := sqlparser.BuildParsedQuery(sqlAcquireVStreamLease, sidecarDBID, ":consumer", ":owner", ":ttl").Query
+	if _, err := gw.Execute(ctx, target, query, bindVars, 0, 0, nil); err != nil {
+		return vterrors.Wrapf(err, "failed to acquire the lease of vstream consumer %s", consumer)
+	}
+	query = sqlparser.BuildParsedQuery(sqlSelectVStreamLease, sidecarDBID, ":consumer", ":owner").Query
+	qr, err := gw.Execute(ctx, target, query, bindVars, 0, 0, nil)
+	if err != nil {
+		return vterrors.Wrapf(err, "failed to acquire the lease of vstream consumer %s", consumer)
+	}
+	if len(qr.Rows) == 0 {
+		return vterrors.Errorf(vtrpcpb.Code_ALREADY_EXISTS, "vstream consumer %s is already streaming", consumer)
+	}
+	return nil
+}
+
+// release gives up the lease of the consumer if owner still holds it.
+func (vc *vstreamCheckpoints) release(ctx context.Context, consumer, owner string) error {
+	target, sidecarDBID, err := vc.target(ctx)
+	if err != nil {
+		return err
+	}
+	query := sqlparser.BuildParsedQuery(sqlReleaseVStreamLease, sidecarDBID, ":consumer", ":owner").Query
+	_, err = vc.resolver.GetGateway().Execute(ctx, target, query, map[string]*querypb.BindVariable{
+		"consumer": sqltypes.StringBindVariable(consumer),
+		"owner":    sqltypes.StringBindVariable(owner),
+	}, 0, 0, nil)
+	return err
+}
+
+// load returns the checkpoint of the consumer, or nil if it has none.
+func (vc *vstreamCheckpoints) load(ctx context.Context, consumer string) (*binlogdatapb.VGtid, error) {
+	target, sidecarDBID, err := vc.target(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := sqlparser.BuildParsedQuery(sqlSelectVStreamCheckpoint, sidecarDBID, ":consumer").Query
+	qr, err := vc.resolver.GetGateway().Execute(ctx, target, query, map[string]*querypb.BindVariable{
+		"consumer": sqltypes.StringBindVariable(consumer),
+	}, 0, 0, nil)
+	if err != nil {
+		return nil, vterrors.Wrapf(err, "failed to load the checkpoint of vstream consumer %s", consumer)
+	}
+	if len(qr.Rows) == 0 {
+		return nil, nil
+	}
+	vgtid := &binlogdatapb.VGtid{}
+	for _, row := range qr.Named().Rows {
+		sgtid := &binlogdatapb.ShardGtid{
+			Keyspace: row.AsString("keyspace", ""),
+			Shard:    row.AsString("shard", ""),
+			Gtid:     row.AsString("gtid", ""),
+		}
+		if tablePKs := row.AsBytes("table_pks", nil); len(tablePKs) > 0 {
+			copyState := &binlogdatapb.ShardGtid{}
+			if err := copyState.UnmarshalVT(tablePKs); err != nil {
+				return nil, vterrors.Wrapf(err, "invalid checkpoint of vstream consumer %s for %s/%s", consumer, sgtid.Keyspace, sgtid.Shard)
+			}
+			sgtid.TablePKs = copyState.TablePKs
+		}
+		vgtid.ShardGtids = append(vgtid.ShardGtids, sgtid)
+	}
+	return vgtid, nil
+}
+
+// save replaces the checkpoint of the consumer with vgtid in a transaction,
+// so that the shards the consumer no longer streams from, after a reshard,
+// are dropped along. The checkpoint is deleted if vgtid has no shards. The
+// transaction locks the lease of the consumer, and fails unless owner holds it.
+func (vc *vstreamCheckpoints) save(ctx context.Context, consumer, owner string, vgtid *binlogdatapb.VGtid) (err error) {
+	target, sidecarDBID, err := vc.target(ctx)
+	if err != nil {
+		return err
+	}
+	gw := vc.resolver.GetGateway()
+	bindVars := map[string]*querypb.BindVariable{
+		"consumer": sqltypes.StringBindVariable(consumer),
+		"owner":    sqltypes.StringBindVariable(owner),
+	}
+	query := sqlparser.BuildParsedQuery(sqlCheckVStreamLease, sidecarDBID, ":consumer", ":owner").Query
+	state, qr, err := gw.BeginExecute(ctx, target, nil, query, bindVars, 0, nil)
+	if err != nil {
+		return vterrors.Wrapf(err, "failed to save the checkpoint of vstream consumer %s", consumer)
+	}
+	defer func() {
+		if err != nil && state.TransactionID != 0 {
+			if _, rerr := gw.Rollback(ctx, target, state.TransactionID); rerr != nil {
+				log.Warningf("Failed to roll back the checkpoint of vstream consumer %s: %v", consumer, rerr)
+			}
+		}
+	}()
+	if len(qr.Rows) == 0 {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "the stream of vstream consumer %s through this vtgate lost its lease", consumer)
+	}
+
+	query = sqlparser.BuildParsedQuery(sqlDeleteVStreamCheckpoint, sidecarDBID, ":consumer").Query
+	if _, err = gw.Execute(ctx, target, query, bindVars, state.TransactionID, 0, nil); err != nil {
+		return vterrors.Wrapf(err, "failed to save the checkpoint of vstream consumer %s", consumer)
+	}
+
+	query = sqlparser.BuildParsedQuery(sqlInsertVStreamCheckpoint, sidecarDBID, ":consumer", ":keyspace", ":shard", ":gtid", ":table_pks").Query
+	for _, sgtid := range vgtid.GetShardGtids() {
+		tablePKs := sqltypes.NullBindVariable
+		if len(sgtid.TablePKs) > 0 {
+			data, err := (&binlogdatapb.ShardGtid{TablePKs: sgtid.TablePKs}).MarshalVT()
+			if err != nil {
+				return err
+			}
+			tablePKs = sqltypes.BytesBindVariable(data)
+		}
+		bindVars["keyspace"] = sqltypes.StringBindVariable(sgtid.Keyspace)
+		bindVars["shard"] = sqltypes.StringBindVariable(sgtid.Shard)
+		bindVars["gtid"] = sqltypes.StringBindVariable(sgtid.Gtid)
+		bindVars["table_pks"] = tablePKs
+		if _, err = gw.Execute(ctx, target, query, bindVars, state.TransactionID, 0, nil); err != nil {
+			return vterrors.Wrapf(err, "failed to save the checkpoint of vstream consumer %s", consumer)
+		}
+	}
+	if _, err = gw.Commit(ctx, target, state.TransactionID); err != nil {
+		return vterrors.Wrapf(err, "failed to save the checkpoint of vstream consumer %s", consumer)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vtgate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/discovery"
+	"vitess.io/vitess/go/vt/sidecardb"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/sandboxconn"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtgatepb "vitess.io/vitess/go/vt/proto/vtgate"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+func TestVStreamConsumerCheckpoints(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cell := "aa"
+	ks := "TestVStream"
+	_ = createSandbox(ks)
+	_ = createSandbox(KsTestUnsharded)
+	hc := discovery.NewFakeHealthCheck(nil)
+	st := getSandboxTopo(ctx, cell, ks, []string{"-20"})
+	if sdbc, _ := sidecardb.GetIdentifierCache(); sdbc != nil {
+		sdbc.Destroy()
+	}
+	sdbc, _ := sidecardb.NewIdentifierCache(func(context.Context, string) (string, error) {
+		return "_vt", nil
+	})
+	defer sdbc.Destroy()
+
+	vsm := newTestVStreamManager(ctx, hc, st, cell)
+	sbc0 := hc.AddTestTablet(cell, "1.1.1.1", 1001, ks, "-20", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	addTabletToSandboxTopo(t, ctx, st, ks, "-20", sbc0.Tablet())
+	sbcCheckpoint := hc.AddTestTablet(cell, "1.1.1.2", 1002, KsTestUnsharded, "0", topodatapb.TabletType_PRIMARY, true, 1, nil)
+
+	vgtid := &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{{
+			Keyspace: ks,
+			Shard:    "-20",
+			Gtid:     "pos",
+		}},
+	}
+	flags := &vtgatepb.VStreamFlags{ConsumerName: "consumer1"}
+	noop := func([]*binlogdatapb.VEvent) error { return nil }
+
+	// Durable consumers need a keyspace to save their checkpoints in.
+	err := vsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, nil, flags, noop)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+	err = vsm.VStreamAck(ctx, "consumer1", vgtid)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+	vsm.checkpoints = &vstreamCheckpoints{
+		keyspace: KsTestUnsharded,
+		resolver: vsm.resolver,
+	}
+
+	// Only the stream of a consumer can ack.
+	err = vsm.VStreamAck(ctx, "consumer1", vgtid)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+
+	// The consumer takes its lease, and resumes from its checkpoint, not from the requested position.
+	checkpointFields := sqltypes.MakeTestFields("keyspace|shard|gtid|table_pks", "varbinary|varbinary|varbinary|blob")
+	sbc0.StartPos = "pos1"
+	sbc0.AddVStreamEvents([]*binlogdatapb.VEvent{
+		{Type: binlogdatapb.VEventType_GTID, Gtid: "pos2"},
+		{Type: binlogdatapb.VEventType_COMMIT},
+	}, nil)
+	sbcCheckpoint.SetResults([]*sqltypes.Result{{RowsAffected: 1}, sandboxconn.SingleRowResult, {
+		Fields: checkpointFields,
+		Rows: [][]sqltypes.Value{{
+			sqltypes.NewVarBinary(ks),
+			sqltypes.NewVarBinary("-20"),
+			sqltypes.NewVarBinary("pos1"),
+			sqltypes.NULL,
+		}},
+	}})
+	ch := startVStream(ctx, t, vsm, vgtid, flags)
+	verifyEvents(t, ch, &binlogdatapb.VStreamResponse{Events: []*binlogdatapb.VEvent{
+		{Type: binlogdatapb.VEventType_VGTID, Vgtid: &binlogdatapb.VGtid{
+			ShardGtids: []*binlogdatapb.ShardGtid{{
+				Keyspace: ks,
+				Shard:    "-20",
+				Gtid:     "pos2",
+			}},
+		}},
+		{Type: binlogdatapb.VEventType_COMMIT},
+	}})
+	queries := sbcCheckpoint.Queries
+	require.Len(t, queries, 3)
+	assert.Equal(t, "insert into _vt.vstream_consumer_lease(consumer, owner, expires_at) values (:consumer, :owner, now() + interval :ttl second) "+
+		"on duplicate key update owner = if(expires_at < now(), values(owner), owner), expires_at = if(owner = values(owner), values(expires_at), expires_at)", queries[0].Sql)
+	assert.Equal(t, "select 1 from _vt.vstream_consumer_lease where consumer = :consumer and owner = :owner", queries[1].Sql)
+	assert.Equal(t, "select keyspace, shard, gtid, table_pks from _vt.vstream_checkpoint where consumer = :consumer order by keyspace, shard", queries[2].Sql)
+	owner := string(queries[0].BindVariables["owner"].Value)
+	assert.NotEmpty(t, owner)
+
+	// A consumer can only have one stream at a time.
+	err = vsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, nil, flags, noop)
+	assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(err), err)
+	// Including through another vtgate, which can't take the lease.
+	otherVsm := newTestVStreamManager(ctx, hc, st, cell)
+	otherVsm.checkpoints = &vstreamCheckpoints{
+		keyspace: KsTestUnsharded,
+		resolver: otherVsm.resolver,
+	}
+	sbcCheckpoint.SetResults([]*sqltypes.Result{{}, {}})
+	err = otherVsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, nil, flags, noop)
+	assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(err), err)
+	err = otherVsm.VStreamAck(ctx, "consumer1", vgtid)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+
+	// An ack replaces the checkpoint of the consumer in a transaction that checks the lease.
+	checkpoint := &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{{
+			Keyspace: ks,
+			Shard:    "-20",
+			Gtid:     "pos1",
+			TablePKs: []*binlogdatapb.TableLastPK{{
+				TableName: "t1",
+				Lastpk:    sqltypes.ResultToProto3(sqltypes.MakeTestResult(sqltypes.MakeTestFields("id", "int64"), "10")),
+			}},
+		}},
+	}
+	sbcCheckpoint.Queries = nil
+	require.NoError(t, vsm.VStreamAck(ctx, "consumer1", checkpoint))
+	queries = sbcCheckpoint.Queries
+	require.Len(t, queries, 3)
+	assert.Equal(t, "select 1 from _vt.vstream_consumer_lease where consumer = :consumer and owner = :owner and expires_at > now() for update", queries[0].Sql)
+	assert.Equal(t, owner, string(queries[0].BindVariables["owner"].Value))
+	assert.Equal(t, "delete from _vt.vstream_checkpoint where consumer = :consumer", queries[1].Sql)
+	assert.Equal(t, "insert into _vt.vstream_checkpoint(consumer, keyspace, shard, gtid, table_pks) values (:consumer, :keyspace, :shard, :gtid, :table_pks)", queries[2].Sql)
+	bindVars := queries[2].BindVariables
+	assert.Equal(t, "consumer1", string(bindVars["consumer"].Value))
+	assert.Equal(t, ks, string(bindVars["keyspace"].Value))
+	assert.Equal(t, "-20", string(bindVars["shard"].Value))
+	assert.Equal(t, "pos1", string(bindVars["gtid"].Value))
+	assert.EqualValues(t, 1, sbcCheckpoint.CommitCount.Load())
+
+	sbcCheckpoint.Queries = nil
+	sbcCheckpoint.SetResults([]*sqltypes.Result{{
+		Fields: checkpointFields,
+		Rows: [][]sqltypes.Value{{
+			sqltypes.NewVarBinary(ks),
+			sqltypes.NewVarBinary("-20"),
+			sqltypes.NewVarBinary("pos1"),
+			sqltypes.MakeTrusted(sqltypes.Blob, bindVars["table_pks"].Value),
+		}},
+	}})
+	loaded, err := vsm.checkpoints.load(ctx, "consumer1")
+	require.NoError(t, err)
+	utils.MustMatch(t, checkpoint, loaded)
+
+	// An empty ack deletes the checkpoint.
+	sbcCheckpoint.Queries = nil
+	require.NoError(t, vsm.VStreamAck(ctx, "consumer1", nil))
+	require.Len(t, sbcCheckpoint.Queries, 2)
+	assert.Equal(t, "delete from _vt.vstream_checkpoint where consumer = :consumer", sbcCheckpoint.Queries[1].Sql)
+	assert.EqualValues(t, 2, sbcCheckpoint.CommitCount.Load())
+
+	// Acks are rejected once the lease expired or was taken over.
+	sbcCheckpoint.Queries = nil
+	sbcCheckpoint.SetResults([]*sqltypes.Result{{}})
+	err = vsm.VStreamAck(ctx, "consumer1", checkpoint)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+	require.Len(t, sbcCheckpoint.Queries, 1)
+	assert.EqualValues(t, 2, sbcCheckpoint.CommitCount.Load())
+
+	err = vsm.VStreamAck(ctx, "", checkpoint)
+	assert.Equal(t, vtrpcpb.Code_INVALID_ARGUMENT, vterrors.Code(err), err)
+}
+
+func TestVStreamConsumerLostLease(t *testing.T) {
+	defer func(ttl time.Duration) {
+		vstreamConsumerLeaseTTL = ttl
+	}(vstreamConsumerLeaseTTL)
+	vstreamConsumerLeaseTTL = 300 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cell := "aa"
+	ks := "TestVStream"
+	_ = createSandbox(ks)
+	_ = createSandbox(KsTestUnsharded)
+	hc := discovery.NewFakeHealthCheck(nil)
+	st := getSandboxTopo(ctx, cell, ks, []string{"-20"})
+	if sdbc, _ := sidecardb.GetIdentifierCache(); sdbc != nil {
+		sdbc.Destroy()
+	}
+	sdbc, _ := sidecardb.NewIdentifierCache(func(context.Context, string) (string, error) {
+		return "_vt", nil
+	})
+	defer sdbc.Destroy()
+
+	vsm := newTestVStreamManager(ctx, hc, st, cell)
+	sbc0 := hc.AddTestTablet(cell, "1.1.1.1", 1001, ks, "-20", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	addTabletToSandboxTopo(t, ctx, st, ks, "-20", sbc0.Tablet())
+	sbcCheckpoint := hc.AddTestTablet(cell, "1.1.1.2", 1002, KsTestUnsharded, "0", topodatapb.TabletType_PRIMARY, true, 1, nil)
+	vsm.checkpoints = &vstreamCheckpoints{
+		keyspace: KsTestUnsharded,
+		resolver: vsm.resolver,
+	}
+
+	vgtid := &binlogdatapb.VGtid{
+		ShardGtids: []*binlogdatapb.ShardGtid{{
+			Keyspace: ks,
+			Shard:    "-20",
+			Gtid:     "pos",
+		}},
+	}
+	// The lease is taken, there is no checkpoint, and another owner holds the lease on renewal.
+	sbcCheckpoint.SetResults([]*sqltypes.Result{{RowsAffected: 1}, sandboxconn.SingleRowResult, {}, {}, {}})
+	err := vsm.VStream(ctx, topodatapb.TabletType_PRIMARY, vgtid, nil, &vtgatepb.VStreamFlags{ConsumerName: "consumer1"}, func([]*binlogdatapb.VEvent) error {
+		return nil
+	})
+	require.ErrorContains(t, err, "vstream consumer consumer1 lost its lease")
+	assert.Equal(t, vtrpcpb.Code_ALREADY_EXISTS, vterrors.Code(err), err)
+
+	// The stream gives up the lease if it still holds it.
+	queries := sbcCheckpoint.Queries
+	assert.Equal(t, "delete from _vt.vstream_consumer_lease where consumer = :consumer and owner = :owner", queries[len(queries)-1].Sql)
+	err = vsm.VStreamAck(ctx, "consumer1", vgtid)
+	assert.Equal(t, vtrpcpb.Code_FAILED_PRECONDITION, vterrors.Code(err), err)
+}
@@ -18,6 +18,7 @@ package vtgate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
@@ -25,6 +26,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
 
 	"vitess.io/vitess/go/stats"
@@ -52,6 +54,16 @@ type vstreamManager struct {
 
 	vstreamsCreated *stats.CountersWithMultiLabels
 	vstreamsLag     *stats.GaugesWithMultiLabels
+
+	// checkpoints saves the positions of the durable consumers. It is nil
+	// if --vstream-checkpoint-keyspace is not set.
+	checkpoints *vstreamCheckpoints
+
+	// consumersMu protects consumers.
+	consumersMu sync.Mutex
+	// consumers maps the durable consumers streaming through this vtgate to
+	// the owner of their lease.
+	consumers map[string]string
 }
 
 // maxSkewTimeoutSeconds is the maximum allowed skew between two streams when the MinimizeSkew flag is set
@@ -143,10 +155,19 @@ type journalEvent struct {
 func newVStreamManager(resolver *srvtopo.Resolver, serv srvtopo.Server, cell string) *vstreamManager {
 	exporter := servenv.NewExporter(cell, "VStreamManager")
 
+	var checkpoints *vstreamCheckpoints
+	if vstreamCheckpointKeyspace != "" {
+		checkpoints = &vstreamCheckpoints{
+			keyspace: vstreamCheckpointKeyspace,
+			resolver: resolver,
+		}
+	}
 	return &vstreamManager{
-		resolver: resolver,
-		toposerv: serv,
-		cell:     cell,
+		resolver:    resolver,
+		toposerv:    serv,
+		cell:        cell,
+		checkpoints: checkpoints,
+		consumers:   make(map[string]string),
 		vstreamsCreated: exporter.NewCountersWithMultiLabels(
 			"VStreamsCreated",
 			"Number of vstreams created",
@@ -160,6 +181,23 @@ func newVStreamManager(resolver *srvtopo.Resolver, serv srvtopo.Server, cell str
 
 func (vsm *vstreamManager) VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid,
 	filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func(events []*binlogdatapb.VEvent) error) error {
+	if consumer := flags.GetConsumerName(); consumer != "" {
+		var release func()
+		var err error
+		ctx, release, err = vsm.registerConsumer(ctx, consumer)
+		if err != nil {
+			return err
+		}
+		defer release()
+		checkpoint, err := vsm.checkpoints.load(ctx, consumer)
+		if err != nil {
+			return err
+		}
+		if checkpoint != nil {
+			log.Infof("Resuming vstream consumer %s from its checkpoint %v", consumer, checkpoint)
+			vgtid = checkpoint
+		}
+	}
 	vgtid, filter, flags, err := vsm.resolveParams(ctx, tabletType, vgtid, filter, flags)
 	if err != nil {
 		return err
@@ -196,7 +234,99 @@ func (vsm *vstreamManager) VStream(ctx context.Context, tabletType topodatapb.Ta
 		},
 		flags: flags,
 	}
-	return vs.stream(ctx)
+	err = vs.stream(ctx)
+	// The stream of a durable consumer is canceled when it loses its lease.
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, ctx.Err()) {
+		return cause
+	}
+	return err
+}
+
+// VStreamAck saves the position up to which a durable consumer processed the
+// events. Its next stream resumes from there. The checkpoint is deleted if
+// vgtid has no shards.
+func (vsm *vstreamManager) VStreamAck(ctx context.Context, consumer string, vgtid *binlogdatapb.VGtid) error {
+	if consumer == "" {
+		return vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "the consumer name is required")
+	}
+	if vsm.checkpoints == nil {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "durable vstream consumers require --vstream-checkpoint-keyspace")
+	}
+	// Only the stream holding the lease of the consumer can move its checkpoint.
+	vsm.consumersMu.Lock()
+	owner, ok := vsm.consumers[consumer]
+	vsm.consumersMu.Unlock()
+	if !ok {
+		return vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "vstream consumer %s is not streaming through this vtgate", consumer)
+	}
+	return vsm.checkpoints.save(ctx, consumer, owner, vgtid)
+}
+
+// registerConsumer takes the lease of the durable consumer for a stream
+// through this vtgate, and renews it until the stream ends. It returns the
+// context of the stream, which is canceled if the lease is lost, and the
+// function to call when the stream ends. A consumer can only have one stream
+// at a time, through any vtgate.
+func (vsm *vstreamManager) registerConsumer(ctx context.Context, consumer string) (context.Context, func(), error) {
+	if vsm.checkpoints == nil {
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "durable vstream consumers require --vstream-checkpoint-keyspace")
+	}
+	owner := fmt.Sprintf("%s-%s", vsm.cell, uuid.NewString())
+	vsm.consumersMu.Lock()
+	if _, ok := vsm.consumers[consumer]; ok {
+		vsm.consumersMu.Unlock()
+		return nil, nil, vterrors.Errorf(vtrpcpb.Code_ALREADY_EXISTS, "vstream consumer %s is already streaming", consumer)
+	}
+	vsm.consumers[consumer] = owner
+	vsm.consumersMu.Unlock()
+	unregister := func() {
+		vsm.consumersMu.Lock()
+		defer vsm.consumersMu.Unlock()
+		delete(vsm.consumers, consumer)
+	}
+	ttl := vstreamConsumerLeaseTTL
+	if err := vsm.checkpoints.acquire(ctx, consumer, owner, ttl); err != nil {
+		unregister()
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		renewed := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := vsm.checkpoints.acquire(ctx, consumer, owner, ttl)
+			switch {
+			case err == nil:
+				renewed = time.Now()
+			case ctx.Err() != nil:
+				return
+			case vterrors.Code(err) == vtrpcpb.Code_ALREADY_EXISTS || time.Since(renewed) >= ttl:
+				cancel(vterrors.Wrapf(err, "vstream consumer %s lost its lease", consumer))
+				return
+			default:
+				log.Warningf("Failed to renew the lease of vstream consumer %s, will retry: %v", consumer, err)
+			}
+		}
+	}()
+	return ctx, func() {
+		cancel(nil)
+		<-done
+		unregister()
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+		defer releaseCancel()
+		if err := vsm.checkpoints.release(releaseCtx, consumer, owner); err != nil {
+			log.Warningf("Failed to release the lease of vstream consumer %s: %v", consumer, err)
+		}
+	}, nil
 }
 
 // resolveParams provides defaults for the inputs if they're not specified.
@@ -594,6 +724,12 @@ func (vs *vstream) streamFromTablet(ctx context.Context, sgtid *binlogdatapb.Sha
 				InternalTables: []string{SidecarDBHeartbeatTableName},
 			}
 		}
+		if vs.flags.GetIncludeSchemaEvents() {
+			if options == nil {
+				options = &binlogdatapb.VStreamOptions{}
+			}
+			options.IncludeSchemaEvents = true
+		}
 
 		// Safe to access sgtid.Gtid here (because it can't change until streaming begins).
 		req := &binlogdatapb.VStreamRequest{
@@ -643,6 +779,11 @@ func (vs *vstream) streamFromTablet(ctx context.Context, sgtid *binlogdatapb.Sha
 					ev := event.CloneVT()
 					ev.RowEvent.TableName = sgtid.Keyspace + "." + ev.RowEvent.TableName
 					sendevents = append(sendevents, ev)
+				case binlogdatapb.VEventType_SCHEMA:
+					// Update table names and send.
+					ev := event.CloneVT()
+					ev.SchemaEvent.TableName = sgtid.Keyspace + "." + ev.SchemaEvent.TableName
+					sendevents = append(sendevents, ev)
 				case binlogdatapb.VEventType_COMMIT, binlogdatapb.VEventType_DDL, binlogdatapb.VEventType_OTHER:
 					sendevents = append(sendevents, event)
 					eventss = append(eventss, sendevents)
@@ -162,6 +162,7 @@ func TestVStreamEvents(t *testing.T) {
 
 	send2 := []*binlogdatapb.VEvent{
 		{Type: binlogdatapb.VEventType_GTID, Gtid: "gtid02"},
+		{Type: binlogdatapb.VEventType_SCHEMA, SchemaEvent: &binlogdatapb.SchemaEvent{TableName: "t0"}},
 		{Type: binlogdatapb.VEventType_DDL},
 	}
 	want2 := &binlogdatapb.VStreamResponse{Events: []*binlogdatapb.VEvent{
@@ -172,6 +173,7 @@ func TestVStreamEvents(t *testing.T) {
 				Gtid:     "gtid02",
 			}},
 		}},
+		{Type: binlogdatapb.VEventType_SCHEMA, SchemaEvent: &binlogdatapb.SchemaEvent{TableName: "TestVStream.t0"}},
 		{Type: binlogdatapb.VEventType_DDL},
 	}}
 	sbc0.AddVStreamEvents(send2, nil)
@@ -68,11 +68,18 @@ var (
 	// plan cache related flag
 	queryPlanCacheMemory int64 = 32 * 1024 * 1024 // 32mb
 
+	// result cache related flag
+	resultCacheMemory int64 = 16 * 1024 * 1024 // 16mb
+
 	maxMemoryRows   = 300000
 	warnMemoryRows  = 30000
 	maxPayloadSize  int
 	warnPayloadSize int
 
+	// spill related flags
+	spillDir         string
+	spillMemoryLimit int64 = 64 * 1024 * 1024 // 64mb
+
 	noScatter          bool
 	enableShardRouting bool
 
@@ -111,6 +118,10 @@ var (
 
 	messageStreamGracePeriod = 30 * time.Second
 
+	// vstreamCheckpointKeyspace is the keyspace the positions of the durable
+	// vstream consumers are saved in.
+	vstreamCheckpointKeyspace string
+
 	// allowKillStmt to allow execution of kill statement.
 	allowKillStmt bool
 
@@ -126,8 +137,11 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&truncateErrorLen, "truncate-error-len", truncateErrorLen, "truncate errors sent to client if they are longer than this value (0 means do not truncate)")
 	fs.IntVar(&streamBufferSize, "stream_buffer_size", streamBufferSize, "the number of bytes sent from vtgate for each stream call. It's recommended to keep this value in sync with vttablet's query-server-config-stream-buffer-size.")
 	fs.Int64Var(&queryPlanCacheMemory, "gate_query_cache_memory", queryPlanCacheMemory, "gate server query cache size in bytes, maximum amount of memory to be cached. vtgate analyzes every incoming query and generate a query plan, these plans are being cached in a lru cache. This config controls the capacity of the lru cache.")
+	fs.Int64Var(&resultCacheMemory, "result-cache-memory", resultCacheMemory, "Maximum number of bytes used to cache the results of SELECT statements on replica and rdonly targets, for the tables that set a result_cache_ttl in their vschema and the queries with a RESULT_CACHE_TTL comment directive. Set to 0 to disable the result cache.")
 	fs.IntVar(&maxMemoryRows, "max_memory_rows", maxMemoryRows, "Maximum number of rows that will be held in memory for intermediate results as well as the final result.")
 	fs.IntVar(&warnMemoryRows, "warn_memory_rows", warnMemoryRows, "Warning threshold for in-memory results. A row count higher than this amount will cause the VtGateWarnings.ResultsExceeded counter to be incremented.")
+	fs.StringVar(&spillDir, "spill-dir", spillDir, "Directory where in-memory sorts, hash joins and distincts of streaming (OLAP) queries write their rows to temporary files when a query uses more than --spill-memory-limit bytes to buffer rows. Non-streaming queries never spill, and --max_memory_rows is enforced for them. When empty, rows are not written to disk.")
+	fs.Int64Var(&spillMemoryLimit, "spill-memory-limit", spillMemoryLimit, "Maximum number of bytes a query may use to buffer rows in memory before they are written to --spill-dir.")
 	fs.StringVar(&defaultDDLStrategy, "ddl_strategy", defaultDDLStrategy, "Set default strategy for DDL statements. Override with @@ddl_strategy session variable")
 	fs.StringVar(&dbDDLPlugin, "dbddl_plugin", dbDDLPlugin, "controls how to handle CREATE/DROP DATABASE. use it if you are using your own database provisioning service")
 	fs.BoolVar(&noScatter, "no_scatter", noScatter, "when set to true, the planner will fail instead of producing a plan that includes scatter queries")
@@ -148,6 +162,7 @@ func registerFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&queryLogToFile, "log_queries_to_file", queryLogToFile, "Enable query logging to the specified file")
 	fs.IntVar(&queryLogBufferSize, "querylog-buffer-size", queryLogBufferSize, "Maximum number of buffered query logs before throttling log output")
 	fs.DurationVar(&messageStreamGracePeriod, "message_stream_grace_period", messageStreamGracePeriod, "the amount of time to give for a vttablet to resume if it ends a message stream, usually because of a reparent.")
+	fs.StringVar(&vstreamCheckpointKeyspace, "vstream-checkpoint-keyspace", vstreamCheckpointKeyspace, "The single-shard keyspace to save the positions of the durable VStream consumers in, in the vstream_checkpoint table of its sidecar database. Durable consumers are disabled if empty.")
 	fs.BoolVar(&enableViews, "enable-views", enableViews, "Enable views support in vtgate.")
 	fs.BoolVar(&enableUdfs, "track-udfs", enableUdfs, "Track UDFs in vtgate.")
 	fs.BoolVar(&allowKillStmt, "allow-kill-statement", allowKillStmt, "Allows the execution of kill statement")
@@ -341,6 +356,7 @@ func Init(
 	// connect the schema tracker with the vschema manager
 	if enableSchemaChangeSignal {
 		st.RegisterSignalReceiver(executor.vm.Rebuild)
+		st.RegisterStatisticsReceiver(executor.UpdateTableStatistics)
 	}
 
 	// TODO: call serv.WatchSrvVSchema here
@@ -595,6 +611,11 @@ func (vtg *VTGate) VStream(ctx context.Context, tabletType topodatapb.TabletType
 	return vtg.vsm.VStream(ctx, tabletType, vgtid, filter, flags, send)
 }
 
+// VStreamAck saves the position of a durable VStream consumer.
+func (vtg *VTGate) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	return vtg.vsm.VStreamAck(ctx, consumerName, vgtid)
+}
+
 // GetGatewayCacheStatus returns a displayable version of the Gateway cache.
 func (vtg *VTGate) GetGatewayCacheStatus() TabletCacheStatusList {
 	return vtg.gw.CacheStatus()
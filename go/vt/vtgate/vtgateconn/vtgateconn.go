@@ -43,6 +43,7 @@ func registerFlags(fs *pflag.FlagSet) {
 func init() {
 	servenv.OnParseFor("vttablet", registerFlags)
 	servenv.OnParseFor("vtclient", registerFlags)
+	servenv.OnParseFor("vtcdc", registerFlags)
 }
 
 // GetVTGateProtocol returns the protocol used to connect to vtgate as provided in the flag.
@@ -106,6 +107,12 @@ func (conn *VTGateConn) VStream(ctx context.Context, tabletType topodatapb.Table
 	return conn.impl.VStream(ctx, tabletType, vgtid, filter, flags)
 }
 
+// VStreamAck saves the position up to which a durable consumer, as named in
+// the flags of its streams, processed the events.
+func (conn *VTGateConn) VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error {
+	return conn.impl.VStreamAck(ctx, consumerName, vgtid)
+}
+
 // VTGateSession exposes the Vitess Execution API to the clients.
 // The object maintains client-side state and is comparable to a native MySQL connection.
 // For example, if you enable autocommit on a Session object, all subsequent calls will respect this.
@@ -176,6 +183,9 @@ type Impl interface {
 	// VStream streams binlogevents
 	VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags) (VStreamReader, error)
 
+	// VStreamAck saves the position of a durable VStream consumer.
+	VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error
+
 	// Close must be called for releasing resources.
 	Close()
 }
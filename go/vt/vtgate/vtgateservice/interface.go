@@ -44,6 +44,8 @@ type VTGateService interface {
 
 	// Update Stream methods
 	VStream(ctx context.Context, tabletType topodatapb.TabletType, vgtid *binlogdatapb.VGtid, filter *binlogdatapb.Filter, flags *vtgatepb.VStreamFlags, send func([]*binlogdatapb.VEvent) error) error
+	// VStreamAck saves the position of a durable VStream consumer.
+	VStreamAck(ctx context.Context, consumerName string, vgtid *binlogdatapb.VGtid) error
 
 	// HandlePanic should be called with defer at the beginning of each
 	// RPC implementation method, before calling any of the previous methods
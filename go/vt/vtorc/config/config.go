@@ -58,6 +58,17 @@ var (
 	recoveryPollDuration           = 1 * time.Second
 	ersEnabled                     = true
 	convertTabletsWithErrantGTIDs  = false
+	preRecoveryHook                = ""
+	postRecoveryHook               = ""
+	recoveryHookTimeout            = 30 * time.Second
+	drainUnhealthyReplicas         = false
+	unhealthyReplicaLagThreshold   = 1 * time.Minute
+	unhealthyReplicaDrainDelay     = 5 * time.Minute
+	healthyReplicaUndrainDelay     = 5 * time.Minute
+	maxDrainedReplicasPerShard     = 1
+	shardOwnership                 = false
+	instanceID                     = ""
+	instanceHeartbeatTimeout       = 1 * time.Minute
 )
 
 // RegisterFlags registers the flags required by VTOrc
@@ -79,6 +90,17 @@ func RegisterFlags(fs *pflag.FlagSet) {
 	fs.DurationVar(&recoveryPollDuration, "recovery-poll-duration", recoveryPollDuration, "Timer duration on which VTOrc polls its database to run a recovery")
 	fs.BoolVar(&ersEnabled, "allow-emergency-reparent", ersEnabled, "Whether VTOrc should be allowed to run emergency reparent operation when it detects a dead primary")
 	fs.BoolVar(&convertTabletsWithErrantGTIDs, "change-tablets-with-errant-gtid-to-drained", convertTabletsWithErrantGTIDs, "Whether VTOrc should be changing the type of tablets with errant GTIDs to DRAINED")
+	fs.StringVar(&preRecoveryHook, "pre-recovery-hook", preRecoveryHook, "Executable or http(s) URL that VTOrc calls before running a recovery, with the replication analysis as JSON on stdin or as the request body. A failure or a non-2xx response vetoes the recovery, which is then blocked for 30s, doubling with each veto in a row up to 10m")
+	fs.StringVar(&postRecoveryHook, "post-recovery-hook", postRecoveryHook, "Executable or http(s) URL that VTOrc calls after running a recovery, with the replication analysis and the outcome of the recovery as JSON on stdin or as the request body")
+	fs.DurationVar(&recoveryHookTimeout, "recovery-hook-timeout", recoveryHookTimeout, "Maximum time to wait for a pre or post recovery hook to finish")
+	fs.BoolVar(&drainUnhealthyReplicas, "drain-unhealthy-replicas", drainUnhealthyReplicas, "Whether VTOrc should change the type of REPLICA tablets that keep lagging or not replicating to DRAINED, and back to REPLICA once they recover")
+	fs.DurationVar(&unhealthyReplicaLagThreshold, "unhealthy-replica-lag-threshold", unhealthyReplicaLagThreshold, "Replication lag above which a replica is considered unhealthy by --drain-unhealthy-replicas")
+	fs.DurationVar(&unhealthyReplicaDrainDelay, "unhealthy-replica-drain-delay", unhealthyReplicaDrainDelay, "Duration for which a REPLICA tablet has to stay unhealthy before VTOrc drains it")
+	fs.DurationVar(&healthyReplicaUndrainDelay, "healthy-replica-undrain-delay", healthyReplicaUndrainDelay, "Duration for which a tablet drained by VTOrc has to stay healthy before VTOrc changes it back to REPLICA")
+	fs.IntVar(&maxDrainedReplicasPerShard, "max-drained-replicas-per-shard", maxDrainedReplicasPerShard, "VTOrc does not drain unhealthy replicas in a shard that already has this many DRAINED tablets")
+	fs.BoolVar(&shardOwnership, "shard-ownership", shardOwnership, "Whether VTOrc instances should split the shards between themselves, through elections in the global topo, so that each shard is discovered and recovered by a single instance")
+	fs.StringVar(&instanceID, "instance-id", instanceID, "Identifier of this VTOrc instance among the instances using --shard-ownership. Defaults to hostname:port")
+	fs.DurationVar(&instanceHeartbeatTimeout, "instance-heartbeat-timeout", instanceHeartbeatTimeout, "Duration after which VTOrc instances using --shard-ownership consider an instance that stopped heartbeating gone, and take over its shards")
 }
 
 // Configuration makes for vtorc configuration input, which can be provided by user via JSON formatted file.
@@ -152,6 +174,72 @@ func SetConvertTabletWithErrantGTIDs(val bool) {
 	convertTabletsWithErrantGTIDs = val
 }
 
+// PreRecoveryHook returns the executable or URL to call before running a recovery.
+func PreRecoveryHook() string {
+	return preRecoveryHook
+}
+
+// PostRecoveryHook returns the executable or URL to call after running a recovery.
+func PostRecoveryHook() string {
+	return postRecoveryHook
+}
+
+// RecoveryHookTimeout returns the maximum time to wait for a recovery hook.
+func RecoveryHookTimeout() time.Duration {
+	return recoveryHookTimeout
+}
+
+// SetRecoveryHooks sets the pre and post recovery hooks. This should only be used from tests.
+func SetRecoveryHooks(pre, post string) {
+	preRecoveryHook = pre
+	postRecoveryHook = post
+}
+
+// DrainUnhealthyReplicas reports whether VTOrc drains REPLICA tablets that stay unhealthy.
+func DrainUnhealthyReplicas() bool {
+	return drainUnhealthyReplicas
+}
+
+// UnhealthyReplicaLagThreshold returns the replication lag above which a replica is unhealthy.
+func UnhealthyReplicaLagThreshold() time.Duration {
+	return unhealthyReplicaLagThreshold
+}
+
+// UnhealthyReplicaDrainDelay returns how long a REPLICA tablet stays unhealthy before VTOrc drains it.
+func UnhealthyReplicaDrainDelay() time.Duration {
+	return unhealthyReplicaDrainDelay
+}
+
+// HealthyReplicaUndrainDelay returns how long a tablet drained by VTOrc stays healthy before VTOrc changes it back to REPLICA.
+func HealthyReplicaUndrainDelay() time.Duration {
+	return healthyReplicaUndrainDelay
+}
+
+// MaxDrainedReplicasPerShard returns the number of DRAINED tablets in a shard above which VTOrc drains no more replicas.
+func MaxDrainedReplicasPerShard() int {
+	return maxDrainedReplicasPerShard
+}
+
+// ShardOwnership reports whether VTOrc instances split the shards between themselves.
+func ShardOwnership() bool {
+	return shardOwnership
+}
+
+// InstanceID returns the identifier of this VTOrc instance, or an empty string if it is not set.
+func InstanceID() string {
+	return instanceID
+}
+
+// InstanceHeartbeatTimeout returns the duration after which a VTOrc instance that stopped heartbeating is considered gone.
+func InstanceHeartbeatTimeout() time.Duration {
+	return instanceHeartbeatTimeout
+}
+
+// SetDrainUnhealthyReplicas sets the value for the drainUnhealthyReplicas variable. This should only be used from tests.
+func SetDrainUnhealthyReplicas(val bool) {
+	drainUnhealthyReplicas = val
+}
+
 // LogConfigValues is used to log the config values.
 func LogConfigValues() {
 	b, _ := json.MarshalIndent(Config, "", "\t")
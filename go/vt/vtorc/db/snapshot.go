@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"vitess.io/vitess/go/vt/external/golib/sqlutils"
+)
+
+// TableState is the content of a table of the VTOrc database, the way the
+// database-state API returns it.
+type TableState struct {
+	TableName string
+	Rows      []sqlutils.RowMap
+}
+
+// SnapshotDB is a private in-memory VTOrc database loaded with a snapshot of
+// the tables of a VTOrc database. It lets the snapshot be analyzed without
+// touching the database of the running VTOrc.
+type SnapshotDB struct {
+	db *sql.DB
+}
+
+var _ DB = (*SnapshotDB)(nil)
+
+// OpenSnapshot creates a SnapshotDB holding the given tables. Tables and
+// columns that the VTOrc database doesn't have are ignored.
+func OpenSnapshot(tables []TableState) (*SnapshotDB, error) {
+	// Every connection to :memory: gets a database of its own, so we keep to a single one.
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+	snapshot := &SnapshotDB{db: sqlDB}
+	if err := deployStatements(sqlDB, vtorcBackend); err != nil {
+		snapshot.Close()
+		return nil, err
+	}
+	for _, table := range tables {
+		if err := snapshot.loadTable(table); err != nil {
+			snapshot.Close()
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// loadTable inserts the rows of the table.
+func (s *SnapshotDB) loadTable(table TableState) error {
+	// The table name can't be a bind variable, so only the known ones get anywhere near the query.
+	if !slices.Contains(TableNames, table.TableName) {
+		return nil
+	}
+	rows, err := s.db.Query("select * from " + table.TableName + " limit 0")
+	if err != nil {
+		return err
+	}
+	columnTypes, err := rows.ColumnTypes()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, row := range table.Rows {
+		var names, placeholders []string
+		var args []any
+		for _, columnType := range columnTypes {
+			column := columnType.Name()
+			cell, ok := row[column]
+			if !ok {
+				continue
+			}
+			names = append(names, column)
+			placeholders = append(placeholders, "?")
+			switch {
+			case !cell.Valid:
+				args = append(args, nil)
+			case strings.EqualFold(columnType.DatabaseTypeName(), "timestamp"):
+				args = append(args, snapshotTimestamp(cell.String))
+			default:
+				args = append(args, cell.String)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		query := fmt.Sprintf("replace into %s (%s) values (%s)", table.TableName, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+		if _, err := execInternal(s.db, query, args...); err != nil {
+			return fmt.Errorf("failed to load a row of %v: %w", table.TableName, err)
+		}
+	}
+	return nil
+}
+
+// snapshotTimestamp turns a timestamp back into the format the VTOrc database
+// stores them in, which the database-state API doesn't return them in.
+func snapshotTimestamp(value string) string {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(time.DateTime)
+}
+
+// QueryVTOrc runs the query on the snapshot.
+func (s *SnapshotDB) QueryVTOrc(query string, argsArray []any, onRow func(sqlutils.RowMap) error) error {
+	return sqlutils.QueryRowsMap(s.db, translateStatement(query), onRow, argsArray...)
+}
+
+// Close releases the snapshot.
+func (s *SnapshotDB) Close() {
+	_ = s.db.Close()
+}
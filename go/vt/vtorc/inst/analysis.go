@@ -17,11 +17,13 @@
 package inst
 
 import (
+	"database/sql"
 	"encoding/json"
 	"time"
 
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/db"
 )
 
 type AnalysisCode string
@@ -56,6 +58,8 @@ const (
 	LockedSemiSyncPrimaryHypothesis        AnalysisCode = "LockedSemiSyncPrimaryHypothesis"
 	LockedSemiSyncPrimary                  AnalysisCode = "LockedSemiSyncPrimary"
 	ErrantGTIDDetected                     AnalysisCode = "ErrantGTIDDetected"
+	UnhealthyReplica                       AnalysisCode = "UnhealthyReplica"
+	DrainedReplicaRecovered                AnalysisCode = "DrainedReplicaRecovered"
 )
 
 type StructureAnalysisCode string
@@ -79,6 +83,10 @@ type PeerAnalysisMap map[string]int
 
 type ReplicationAnalysisHints struct {
 	AuditAnalysis bool
+	// Snapshot, if set, is analyzed instead of the VTOrc database. The analyses
+	// that need a problem to last for some time are left out, as a snapshot
+	// is a single point in time.
+	Snapshot db.DB
 }
 
 // ReplicationAnalysis notes analysis on replication chain status, per instance
@@ -101,6 +109,8 @@ type ReplicationAnalysis struct {
 	CountValidReplicas                        uint
 	CountValidReplicatingReplicas             uint
 	ReplicationStopped                        bool
+	ReplicationLagSeconds                     sql.NullInt64
+	DrainedByVTOrc                            bool
 	ErrantGTID                                string
 	ReplicaNetTimeout                         int32
 	HeartbeatInterval                         float64
@@ -86,6 +86,7 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 		MIN(primary_instance.binary_log_pos) AS binary_log_pos,
 		MIN(primary_instance.replica_net_timeout) AS replica_net_timeout,
 		MIN(primary_instance.heartbeat_interval) AS heartbeat_interval,
+		MIN(primary_instance.replica_lag_seconds) AS replica_lag_seconds,
 		MIN(primary_tablet.info) AS primary_tablet_info,
 		MIN(
 			IFNULL(
@@ -282,7 +283,11 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 	`
 
 	clusters := make(map[string]*clusterAnalysis)
-	err := db.Db.QueryVTOrc(query, args, func(m sqlutils.RowMap) error {
+	database := db.Db
+	if hints.Snapshot != nil {
+		database = hints.Snapshot
+	}
+	err := database.QueryVTOrc(query, args, func(m sqlutils.RowMap) error {
 		a := &ReplicationAnalysis{
 			Analysis: NoProblem,
 		}
@@ -303,6 +308,7 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 		}
 
 		a.TabletType = tablet.Type
+		a.DrainedByVTOrc = IsDrainedByVTOrc(tablet)
 		a.AnalyzedKeyspace = m.GetString("keyspace")
 		a.AnalyzedShard = m.GetString("shard")
 		a.PrimaryTimeStamp = m.GetTime("primary_timestamp")
@@ -331,6 +337,7 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 		a.CountValidReplicas = m.GetUint("count_valid_replicas")
 		a.CountValidReplicatingReplicas = m.GetUint("count_valid_replicating_replicas")
 		a.ReplicationStopped = m.GetBool("replication_stopped")
+		a.ReplicationLagSeconds = m.GetNullInt64("replica_lag_seconds")
 		a.ErrantGTID = m.GetString("gtid_errant")
 
 		countValidOracleGTIDReplicas := m.GetUint("count_valid_oracle_gtid_replicas")
@@ -528,6 +535,10 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 		//			a.Analysis = PrimaryWithoutReplicas
 		//			a.Description = "Primary has no replicas"
 		//		}
+		if hints.Snapshot == nil {
+			analyzeReplicaHealth(a, time.Now())
+			runCustomAnalyses(a, time.Now())
+		}
 
 		{
 			// Moving on to structure analysis
@@ -571,7 +582,7 @@ func GetReplicationAnalysis(keyspace string, shard string, hints *ReplicationAna
 		}
 		appendAnalysis(a)
 
-		if a.CountReplicas > 0 && hints.AuditAnalysis {
+		if a.CountReplicas > 0 && hints.AuditAnalysis && hints.Snapshot == nil {
 			// Interesting enough for analysis
 			go func() {
 				_ = auditInstanceAnalysisInChangelog(a.AnalyzedInstanceAlias, a.Analysis)
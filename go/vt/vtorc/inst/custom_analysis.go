@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CustomAnalysis is an analysis that is not built into VTOrc. Custom analyses
+// run on the tablets for which none of the built-in analyses found a problem,
+// in the order in which they were registered, and the first one to detect a
+// problem sets the analysis code of the tablet.
+type CustomAnalysis struct {
+	// Code is the analysis code reported for the tablets with the problem.
+	Code AnalysisCode
+	// Description is reported alongside the code.
+	Description string
+	// Detect returns true if the analyzed tablet has the problem.
+	Detect func(a *ReplicationAnalysis) bool
+	// For is how long Detect must keep finding the problem on a tablet
+	// before it is reported. Zero reports it right away.
+	For time.Duration
+}
+
+var (
+	customAnalyses []*CustomAnalysis
+
+	// customAnalysisSinceMu protects customAnalysisSince, which records
+	// when a custom analysis first detected its problem on a tablet.
+	customAnalysisSinceMu sync.Mutex
+	customAnalysisSince   = make(map[customAnalysisKey]time.Time)
+)
+
+type customAnalysisKey struct {
+	code        AnalysisCode
+	tabletAlias string
+}
+
+// RegisterCustomAnalysis registers an analysis with VTOrc. It is meant to be
+// called from an init function, and panics if an analysis with the same code
+// is already registered. To also act on the problem, register a recovery for
+// the code with logic.RegisterCustomRecovery.
+func RegisterCustomAnalysis(analysis *CustomAnalysis) {
+	for _, registered := range customAnalyses {
+		if registered.Code == analysis.Code {
+			panic(fmt.Sprintf("custom analysis %v is already registered", analysis.Code))
+		}
+	}
+	customAnalyses = append(customAnalyses, analysis)
+}
+
+// runCustomAnalyses sets the analysis code of a tablet for which the built-in
+// analyses found no problem, if one of the custom analyses detects one.
+func runCustomAnalyses(a *ReplicationAnalysis, now time.Time) {
+	customAnalysisSinceMu.Lock()
+	defer customAnalysisSinceMu.Unlock()
+
+	// A tablet has a single analysis code, so once a problem is found the
+	// remaining analyses start over.
+	detected := a.Analysis != NoProblem
+	for _, analysis := range customAnalyses {
+		key := customAnalysisKey{code: analysis.Code, tabletAlias: a.AnalyzedInstanceAlias}
+		if detected || !analysis.Detect(a) {
+			delete(customAnalysisSince, key)
+			continue
+		}
+		since, ok := customAnalysisSince[key]
+		if !ok {
+			since = now
+			customAnalysisSince[key] = since
+		}
+		if now.Sub(since) < analysis.For {
+			continue
+		}
+		a.Analysis = analysis.Code
+		a.Description = analysis.Description
+		detected = true
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vtorc/db"
+)
+
+const replicaLagging AnalysisCode = "ReplicaLagging"
+
+// registerTestCustomAnalysis registers a custom analysis for the duration of the test.
+func registerTestCustomAnalysis(t *testing.T, analysis *CustomAnalysis) {
+	oldAnalyses := customAnalyses
+	customAnalyses = nil
+	RegisterCustomAnalysis(analysis)
+	t.Cleanup(func() {
+		customAnalyses = oldAnalyses
+		customAnalysisSince = make(map[customAnalysisKey]time.Time)
+	})
+}
+
+func isLagging(a *ReplicationAnalysis) bool {
+	return a.ReplicationLagSeconds.Valid && a.ReplicationLagSeconds.Int64 > 60
+}
+
+func TestRegisterCustomAnalysis(t *testing.T) {
+	registerTestCustomAnalysis(t, &CustomAnalysis{Code: replicaLagging, Detect: isLagging})
+	require.PanicsWithValue(t, "custom analysis ReplicaLagging is already registered", func() {
+		RegisterCustomAnalysis(&CustomAnalysis{Code: replicaLagging, Detect: isLagging})
+	})
+}
+
+func TestRunCustomAnalyses(t *testing.T) {
+	registerTestCustomAnalysis(t, &CustomAnalysis{
+		Code:        replicaLagging,
+		Description: "Replica is lagging",
+		Detect:      isLagging,
+		For:         time.Minute,
+	})
+
+	now := time.Now()
+	analyze := func(lag int64, code AnalysisCode, at time.Time) *ReplicationAnalysis {
+		a := &ReplicationAnalysis{AnalyzedInstanceAlias: "zone1-0000000100", Analysis: code}
+		a.ReplicationLagSeconds.Int64, a.ReplicationLagSeconds.Valid = lag, true
+		runCustomAnalyses(a, at)
+		return a
+	}
+
+	// The problem has to last for a minute before it is reported.
+	require.Equal(t, NoProblem, analyze(120, NoProblem, now).Analysis)
+	require.Equal(t, NoProblem, analyze(120, NoProblem, now.Add(30*time.Second)).Analysis)
+	a := analyze(120, NoProblem, now.Add(time.Minute))
+	require.Equal(t, replicaLagging, a.Analysis)
+	require.Equal(t, "Replica is lagging", a.Description)
+
+	// A built-in analysis takes precedence, and the clock starts over.
+	require.Equal(t, ReplicationStopped, analyze(120, ReplicationStopped, now.Add(2*time.Minute)).Analysis)
+	require.Equal(t, NoProblem, analyze(120, NoProblem, now.Add(3*time.Minute)).Analysis)
+
+	// So it does once the replica catches up.
+	require.Equal(t, NoProblem, analyze(0, NoProblem, now.Add(5*time.Minute)).Analysis)
+	require.Equal(t, NoProblem, analyze(120, NoProblem, now.Add(6*time.Minute)).Analysis)
+	require.Equal(t, replicaLagging, analyze(120, NoProblem, now.Add(7*time.Minute)).Analysis)
+}
+
+func TestGetReplicationAnalysisCustomAnalysis(t *testing.T) {
+	registerTestCustomAnalysis(t, &CustomAnalysis{Code: replicaLagging, Detect: isLagging})
+	defer db.ClearVTOrcDatabase()
+
+	for _, query := range append(initialSQL, `update database_instance set replica_lag_seconds = 300 where port = 6711`) {
+		_, err := db.ExecVTOrc(query)
+		require.NoError(t, err)
+	}
+
+	got, err := GetReplicationAnalysis("", "", &ReplicationAnalysisHints{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, replicaLagging, got[0].Analysis)
+	require.EqualValues(t, 300, got[0].ReplicationLagSeconds.Int64)
+	require.Equal(t, "ks", got[0].AnalyzedKeyspace)
+}
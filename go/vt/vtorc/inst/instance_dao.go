@@ -1147,14 +1147,9 @@ func ExpireStaleInstanceBinlogCoordinates() error {
 
 // GetDatabaseState takes the snapshot of the database and returns it.
 func GetDatabaseState() (string, error) {
-	type tableState struct {
-		TableName string
-		Rows      []sqlutils.RowMap
-	}
-
-	var dbState []tableState
+	var dbState []db.TableState
 	for _, tableName := range db.TableNames {
-		ts := tableState{
+		ts := db.TableState{
 			TableName: tableName,
 		}
 		err := db.QueryVTOrc("select * from "+tableName, nil, func(rowMap sqlutils.RowMap) error {
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inst
+
+import (
+	"sync"
+	"time"
+
+	"vitess.io/vitess/go/vt/external/golib/sqlutils"
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/db"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// DrainedByVTOrcTag is the tag of the tablet record that marks the tablets
+// drained by VTOrc, with the time they were drained. It is kept in the topo
+// so that any VTOrc, including one that restarted or took over the shard,
+// undrains them once they recover.
+const DrainedByVTOrcTag = "vtorc_drained_at"
+
+// IsDrainedByVTOrc returns true if VTOrc drained the tablet.
+func IsDrainedByVTOrc(tablet *topodatapb.Tablet) bool {
+	return tablet.GetType() == topodatapb.TabletType_DRAINED && tablet.GetTags()[DrainedByVTOrcTag] != ""
+}
+
+// replicaHealth records since when REPLICA tablets have been unhealthy, and
+// since when the tablets that VTOrc drained have been healthy again. The
+// timers are only kept in memory, and are rebuilt as described in
+// unhealthySince when VTOrc starts analyzing a tablet.
+var replicaHealth = struct {
+	mu             sync.Mutex
+	unhealthySince map[string]time.Time
+	healthySince   map[string]time.Time
+}{
+	unhealthySince: make(map[string]time.Time),
+	healthySince:   make(map[string]time.Time),
+}
+
+// isReplicaUnhealthy returns true if the tablet is not replicating, or lags
+// by more than --unhealthy-replica-lag-threshold.
+func isReplicaUnhealthy(a *ReplicationAnalysis) bool {
+	// IsPrimary means that the tablet has no replication source.
+	if a.IsPrimary || a.ReplicationStopped || !a.ReplicationLagSeconds.Valid {
+		return true
+	}
+	return time.Duration(a.ReplicationLagSeconds.Int64)*time.Second > config.UnhealthyReplicaLagThreshold()
+}
+
+// unhealthySince returns the earliest time the unhealthy replica is known to
+// have been unhealthy, when VTOrc first analyzes it. The lag of a replica
+// grows by at most a second per second, so a replica that lags by more than
+// the threshold has done so for at least the difference. A replica that is
+// not replicating gives no such bound.
+func unhealthySince(a *ReplicationAnalysis, now time.Time) time.Time {
+	if a.ReplicationStopped || !a.ReplicationLagSeconds.Valid {
+		return now
+	}
+	excess := time.Duration(a.ReplicationLagSeconds.Int64)*time.Second - config.UnhealthyReplicaLagThreshold()
+	if excess <= 0 {
+		return now
+	}
+	return now.Add(-excess)
+}
+
+// analyzeReplicaHealth reports REPLICA tablets that have been unhealthy for
+// --unhealthy-replica-drain-delay as UnhealthyReplica, and tablets drained by
+// VTOrc that have been healthy for --healthy-replica-undrain-delay as
+// DrainedReplicaRecovered. The two delays keep a replica at the edge of the
+// lag threshold from flapping between REPLICA and DRAINED.
+func analyzeReplicaHealth(a *ReplicationAnalysis, now time.Time) {
+	if !config.DrainUnhealthyReplicas() {
+		return
+	}
+	replicaHealth.mu.Lock()
+	defer replicaHealth.mu.Unlock()
+
+	alias := a.AnalyzedInstanceAlias
+	// A tablet VTOrc can't reach is left to the health checks of vtgate.
+	switch {
+	case a.TabletType == topodatapb.TabletType_REPLICA && a.LastCheckValid && isReplicaUnhealthy(a):
+		delete(replicaHealth.healthySince, alias)
+		since, ok := replicaHealth.unhealthySince[alias]
+		if !ok {
+			since = unhealthySince(a, now)
+			replicaHealth.unhealthySince[alias] = since
+		}
+		if now.Sub(since) < config.UnhealthyReplicaDrainDelay() {
+			return
+		}
+		// By now, fixing the replica in place has had its chance.
+		switch a.Analysis {
+		case NoProblem, ReplicationStopped, NotConnectedToPrimary:
+			a.Analysis = UnhealthyReplica
+			a.Description = "Replica has been lagging or not replicating for too long"
+		}
+	case a.TabletType == topodatapb.TabletType_DRAINED && a.DrainedByVTOrc && a.LastCheckValid && !isReplicaUnhealthy(a):
+		delete(replicaHealth.unhealthySince, alias)
+		// How long a drained tablet has been healthy can't be told from its
+		// state, so the delay starts over, which only delays the undrain.
+		since, ok := replicaHealth.healthySince[alias]
+		if !ok {
+			since = now
+			replicaHealth.healthySince[alias] = since
+		}
+		if now.Sub(since) >= config.HealthyReplicaUndrainDelay() && a.Analysis == NoProblem {
+			a.Analysis = DrainedReplicaRecovered
+			a.Description = "Replica drained by VTOrc has recovered"
+		}
+	default:
+		delete(replicaHealth.unhealthySince, alias)
+		delete(replicaHealth.healthySince, alias)
+	}
+}
+
+// ReadDrainedTabletCount returns the number of DRAINED tablets in the shard,
+// whether VTOrc drained them or not.
+func ReadDrainedTabletCount(keyspace string, shard string) (count int, err error) {
+	query := `
+		select
+			count(*) as count
+		from
+			vitess_tablet
+		where
+			keyspace = ? and shard = ? and tablet_type = ?
+		`
+	args := sqlutils.Args(keyspace, shard, int(topodatapb.TabletType_DRAINED))
+	err = db.QueryVTOrc(query, args, func(row sqlutils.RowMap) error {
+		count = row.GetInt("count")
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/db"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// enableReplicaDrain turns on --drain-unhealthy-replicas for the duration of the test.
+func enableReplicaDrain(t *testing.T) {
+	config.SetDrainUnhealthyReplicas(true)
+	t.Cleanup(func() {
+		config.SetDrainUnhealthyReplicas(false)
+		replicaHealth.unhealthySince = make(map[string]time.Time)
+		replicaHealth.healthySince = make(map[string]time.Time)
+	})
+}
+
+func TestAnalyzeReplicaHealth(t *testing.T) {
+	enableReplicaDrain(t)
+
+	now := time.Now()
+	analyze := func(tabletType topodatapb.TabletType, drainedByVTOrc bool, lag int64, code AnalysisCode, at time.Duration) AnalysisCode {
+		a := &ReplicationAnalysis{
+			AnalyzedInstanceAlias: "zone1-0000000100",
+			TabletType:            tabletType,
+			DrainedByVTOrc:        drainedByVTOrc,
+			LastCheckValid:        true,
+			Analysis:              code,
+		}
+		a.ReplicationLagSeconds.Int64, a.ReplicationLagSeconds.Valid = lag, true
+		analyzeReplicaHealth(a, now.Add(at))
+		return a.Analysis
+	}
+	replica, drained := topodatapb.TabletType_REPLICA, topodatapb.TabletType_DRAINED
+
+	// A replica has to lag for --unhealthy-replica-drain-delay before it is drained.
+	require.Equal(t, NoProblem, analyze(replica, false, 61, NoProblem, 0))
+	require.Equal(t, NoProblem, analyze(replica, false, 61, NoProblem, 4*time.Minute))
+	// Catching up, even briefly, starts over.
+	require.Equal(t, NoProblem, analyze(replica, false, 1, NoProblem, 5*time.Minute))
+	require.Equal(t, NoProblem, analyze(replica, false, 61, NoProblem, 6*time.Minute))
+	require.Equal(t, NoProblem, analyze(replica, false, 61, NoProblem, 10*time.Minute))
+	require.Equal(t, UnhealthyReplica, analyze(replica, false, 61, NoProblem, 11*time.Minute))
+	// A replica that fixReplica did not manage to get replicating again is drained too.
+	require.Equal(t, UnhealthyReplica, analyze(replica, false, 61, ReplicationStopped, 12*time.Minute))
+	// Other problems are left alone.
+	require.Equal(t, ReplicaIsWritable, analyze(replica, false, 61, ReplicaIsWritable, 13*time.Minute))
+
+	// Once drained, it has to stay healthy for --healthy-replica-undrain-delay before it is undrained.
+	require.Equal(t, NoProblem, analyze(drained, true, 0, NoProblem, 20*time.Minute))
+	require.Equal(t, NoProblem, analyze(drained, true, 120, NoProblem, 21*time.Minute))
+	require.Equal(t, NoProblem, analyze(drained, true, 0, NoProblem, 22*time.Minute))
+	require.Equal(t, NoProblem, analyze(drained, true, 0, NoProblem, 26*time.Minute))
+	require.Equal(t, DrainedReplicaRecovered, analyze(drained, true, 0, NoProblem, 27*time.Minute))
+
+	// Tablets that VTOrc did not drain stay DRAINED.
+	require.Equal(t, NoProblem, analyze(drained, false, 0, NoProblem, 60*time.Minute))
+
+	// A replica seen for the first time, for example after VTOrc restarted, has
+	// been unhealthy for at least as long as its lag exceeds the threshold.
+	require.Equal(t, NoProblem, analyze(replica, false, 61, NoProblem, 70*time.Minute))
+	require.Equal(t, NoProblem, analyze(drained, false, 0, NoProblem, 71*time.Minute))
+	require.Equal(t, UnhealthyReplica, analyze(replica, false, 360, NoProblem, 72*time.Minute))
+}
+
+func TestAnalyzeReplicaHealthDisabled(t *testing.T) {
+	a := &ReplicationAnalysis{
+		AnalyzedInstanceAlias: "zone1-0000000100",
+		TabletType:            topodatapb.TabletType_REPLICA,
+		LastCheckValid:        true,
+		ReplicationStopped:    true,
+		Analysis:              ReplicationStopped,
+	}
+	analyzeReplicaHealth(a, time.Now())
+	analyzeReplicaHealth(a, time.Now().Add(time.Hour))
+	require.Equal(t, ReplicationStopped, a.Analysis)
+}
+
+func TestDrainedReplicas(t *testing.T) {
+	enableReplicaDrain(t)
+	defer db.ClearVTOrcDatabase()
+
+	for _, query := range append(initialSQL, `update database_instance set replica_lag_seconds = 400 where port = 6711`) {
+		_, err := db.ExecVTOrc(query)
+		require.NoError(t, err)
+	}
+
+	// The replica lags by 400s, so it has been unhealthy for longer than the drain delay.
+	got, err := GetReplicationAnalysis("", "", &ReplicationAnalysisHints{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, UnhealthyReplica, got[0].Analysis)
+	require.False(t, got[0].DrainedByVTOrc)
+
+	count, err := ReadDrainedTabletCount("ks", "0")
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	// The marker is read from the tablet record.
+	tablet, err := ReadTablet("zone1-0000000100")
+	require.NoError(t, err)
+	tablet.Type = topodatapb.TabletType_DRAINED
+	require.NoError(t, SaveTablet(tablet))
+	count, err = ReadDrainedTabletCount("ks", "0")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	require.False(t, IsDrainedByVTOrc(tablet))
+
+	tablet.Tags = map[string]string{DrainedByVTOrcTag: time.Now().UTC().Format(time.RFC3339)}
+	require.NoError(t, SaveTablet(tablet))
+	require.True(t, IsDrainedByVTOrc(tablet))
+	_, err = db.ExecVTOrc(`update database_instance set replica_lag_seconds = 0 where port = 6711`)
+	require.NoError(t, err)
+	// Pretend that the drained replica has been healthy for a while.
+	replicaHealth.healthySince["zone1-0000000100"] = time.Now().Add(-time.Hour)
+	got, err = GetReplicationAnalysis("", "", &ReplicationAnalysisHints{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, DrainedReplicaRecovered, got[0].Analysis)
+	require.True(t, got[0].DrainedByVTOrc)
+}
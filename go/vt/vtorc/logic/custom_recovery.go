@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+	"vitess.io/vitess/go/vt/vttablet/tmclient"
+)
+
+// CustomRecoveryFunc fixes the problem reported by a custom analysis. It runs
+// with the shard locked, using the topo server and tablet manager client of
+// VTOrc.
+type CustomRecoveryFunc func(ctx context.Context, ts *topo.Server, tmc tmclient.TabletManagerClient, analysisEntry *inst.ReplicationAnalysis) error
+
+type customRecovery struct {
+	name    string
+	code    inst.AnalysisCode
+	recover CustomRecoveryFunc
+}
+
+// customRecoveries holds the recoveries registered with RegisterCustomRecovery.
+// The recovery function code of customRecoveries[i] is firstCustomRecoveryFunc+i.
+var customRecoveries []*customRecovery
+
+// RegisterCustomRecovery registers a recovery for the given analysis code,
+// typically one registered with inst.RegisterCustomAnalysis. The name is used
+// in metrics and audit logs. It is meant to be called from an init function,
+// and panics if the code already has a custom recovery. Codes that VTOrc
+// recovers by itself keep their built-in recovery.
+func RegisterCustomRecovery(code inst.AnalysisCode, name string, recover CustomRecoveryFunc) {
+	if getCustomRecoveryFunctionCode(code) != noRecoveryFunc {
+		panic(fmt.Sprintf("a custom recovery for %v is already registered", code))
+	}
+	customRecoveries = append(customRecoveries, &customRecovery{
+		name:    name,
+		code:    code,
+		recover: recover,
+	})
+}
+
+// getCustomRecoveryFunctionCode returns the recovery function code of the
+// custom recovery for the given analysis code, or noRecoveryFunc.
+func getCustomRecoveryFunctionCode(analysisCode inst.AnalysisCode) recoveryFunction {
+	for i, recovery := range customRecoveries {
+		if recovery.code == analysisCode {
+			return firstCustomRecoveryFunc + recoveryFunction(i)
+		}
+	}
+	return noRecoveryFunc
+}
+
+// getCustomRecovery returns the custom recovery with the given recovery
+// function code, or nil.
+func getCustomRecovery(recoveryFunctionCode recoveryFunction) *customRecovery {
+	i := int(recoveryFunctionCode - firstCustomRecoveryFunc)
+	if i < 0 || i >= len(customRecoveries) {
+		return nil
+	}
+	return customRecoveries[i]
+}
+
+// checkAndRecoverFunction returns the recovery function that runs the custom recovery.
+func (recovery *customRecovery) checkAndRecoverFunction() func(ctx context.Context, analysisEntry *inst.ReplicationAnalysis) (bool, *TopologyRecovery, error) {
+	return func(ctx context.Context, analysisEntry *inst.ReplicationAnalysis) (recoveryAttempted bool, topologyRecovery *TopologyRecovery, err error) {
+		topologyRecovery, err = AttemptRecoveryRegistration(analysisEntry)
+		if topologyRecovery == nil {
+			_ = AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another %v.", analysisEntry.AnalyzedInstanceAlias, recovery.name))
+			return false, nil, err
+		}
+		log.Infof("Analysis: %v, will run %v on %+v", analysisEntry.Analysis, recovery.name, analysisEntry.AnalyzedInstanceAlias)
+		// This has to be done in the end; whether successful or not, we should mark that the recovery is done.
+		// So that after the active period passes, we are able to run other recoveries.
+		defer func() {
+			_ = resolveRecovery(topologyRecovery, nil)
+		}()
+
+		err = recovery.recover(ctx, ts, tmc, analysisEntry)
+		return true, topologyRecovery, topologyRecovery.AddError(err)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtorc/db"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+	"vitess.io/vitess/go/vt/vttablet/tmclient"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestCustomRecovery(t *testing.T) {
+	_, err := db.OpenVTOrc()
+	require.NoError(t, err)
+	oldTs, oldRecoveries := ts, customRecoveries
+	defer func() {
+		ts, customRecoveries = oldTs, oldRecoveries
+		db.ClearVTOrcDatabase()
+	}()
+	customRecoveries = nil
+
+	const replicaLagging inst.AnalysisCode = "ReplicaLagging"
+	var recovered []string
+	RegisterCustomRecovery(replicaLagging, "DrainLaggingReplica", func(ctx context.Context, ts *topo.Server, tmc tmclient.TabletManagerClient, analysisEntry *inst.ReplicationAnalysis) error {
+		recovered = append(recovered, analysisEntry.AnalyzedInstanceAlias)
+		return errors.New("drain failed")
+	})
+	require.PanicsWithValue(t, "a custom recovery for ReplicaLagging is already registered", func() {
+		RegisterCustomRecovery(replicaLagging, "DrainLaggingReplica", nil)
+	})
+
+	code := getCheckAndRecoverFunctionCode(replicaLagging, "")
+	require.Equal(t, firstCustomRecoveryFunc, code)
+	require.True(t, hasActionableRecovery(code))
+	require.False(t, isClusterWideRecovery(code))
+	require.Equal(t, "DrainLaggingReplica", getRecoverFunctionName(code))
+	// Built-in analyses keep their recovery.
+	require.Equal(t, fixReplicaFunc, getCheckAndRecoverFunctionCode(inst.ReplicationStopped, ""))
+
+	replica := &topodatapb.Tablet{
+		Alias: &topodatapb.TabletAlias{
+			Cell: "zone1",
+			Uid:  2,
+		},
+		Hostname:      "localhost2",
+		MysqlHostname: "localhost2",
+		MysqlPort:     1200,
+		Keyspace:      "ks",
+		Shard:         "0",
+		Type:          topodatapb.TabletType_REPLICA,
+	}
+	require.NoError(t, inst.SaveTablet(replica))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts = memorytopo.NewServer(ctx, "zone1")
+
+	analysisEntry := &inst.ReplicationAnalysis{
+		AnalyzedInstanceAlias: topoproto.TabletAliasString(replica.Alias),
+		Analysis:              replicaLagging,
+	}
+	recoveryAttempted, topologyRecovery, err := getCheckAndRecoverFunction(code)(ctx, analysisEntry)
+	require.True(t, recoveryAttempted)
+	require.EqualError(t, err, "drain failed")
+	require.Equal(t, []string{"drain failed"}, topologyRecovery.AllErrors)
+	require.Equal(t, []string{"zone1-0000000002"}, recovered)
+}
@@ -40,6 +40,11 @@ import (
 
 // IsRecoveryDisabled returns true if Recoveries are disabled globally
 func IsRecoveryDisabled() (disabled bool, err error) {
+	return isRecoveryDisabledIn(db.Db)
+}
+
+// isRecoveryDisabledIn returns true if Recoveries are disabled globally in the given database
+func isRecoveryDisabledIn(database db.DB) (disabled bool, err error) {
 	query := `
 		SELECT
 			COUNT(*) as mycount
@@ -48,7 +53,7 @@ func IsRecoveryDisabled() (disabled bool, err error) {
 		WHERE
 			disable_recovery=?
 		`
-	err = db.QueryVTOrc(query, sqlutils.Args(1), func(m sqlutils.RowMap) error {
+	err = database.QueryVTOrc(query, sqlutils.Args(1), func(m sqlutils.RowMap) error {
 		mycount := m.GetInt("mycount")
 		disabled = (mycount > 0)
 		return nil
@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+)
+
+const (
+	preRecoveryHookName  = "pre-recovery"
+	postRecoveryHookName = "post-recovery"
+
+	// minVetoBackoff and maxVetoBackoff bound how long a vetoed recovery
+	// waits before the pre-recovery hook is asked again.
+	minVetoBackoff = 30 * time.Second
+	maxVetoBackoff = 10 * time.Minute
+)
+
+// vetoedRecoveries holds a *recoveryVeto for each analysis and tablet whose
+// recovery the pre-recovery hook vetoed.
+var vetoedRecoveries = cache.New(cache.NoExpiration, time.Minute)
+
+// recoveryVeto is how many times in a row the recovery of an analysis was
+// vetoed, and until when it is blocked.
+type recoveryVeto struct {
+	vetoes       int
+	blockedUntil time.Time
+}
+
+func recoveryVetoKey(analysisEntry *inst.ReplicationAnalysis) string {
+	return string(analysisEntry.Analysis) + "/" + analysisEntry.AnalyzedInstanceAlias
+}
+
+// isRecoveryVetoBlocked returns true if the recovery of the analysis was
+// vetoed and its backoff has not expired yet at now.
+func isRecoveryVetoBlocked(analysisEntry *inst.ReplicationAnalysis, now time.Time) bool {
+	veto, ok := vetoedRecoveries.Get(recoveryVetoKey(analysisEntry))
+	return ok && now.Before(veto.(*recoveryVeto).blockedUntil)
+}
+
+// recordRecoveryVeto blocks the recovery of the analysis after a veto, and
+// returns for how long. The block starts at minVetoBackoff and doubles with
+// each veto in a row, up to maxVetoBackoff.
+func recordRecoveryVeto(analysisEntry *inst.ReplicationAnalysis, now time.Time) time.Duration {
+	key := recoveryVetoKey(analysisEntry)
+	veto := &recoveryVeto{}
+	if previous, ok := vetoedRecoveries.Get(key); ok {
+		veto = previous.(*recoveryVeto)
+	}
+	veto.vetoes++
+	backoff := minVetoBackoff
+	for i := 1; i < veto.vetoes && backoff < maxVetoBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxVetoBackoff)
+	veto.blockedUntil = now.Add(backoff)
+	// The vetoes in a row are forgotten once the analysis has not been
+	// vetoed for a while.
+	vetoedRecoveries.Set(key, veto, backoff+maxVetoBackoff)
+	return backoff
+}
+
+// forgetRecoveryVetoes resets the backoff of the analysis, once the
+// pre-recovery hook lets its recovery go ahead.
+func forgetRecoveryVetoes(analysisEntry *inst.ReplicationAnalysis) {
+	vetoedRecoveries.Delete(recoveryVetoKey(analysisEntry))
+}
+
+// recoveryHookEvent is what the recovery hooks receive as JSON.
+type recoveryHookEvent struct {
+	// Hook is either pre-recovery or post-recovery.
+	Hook     string
+	Recovery string
+	Analysis *inst.ReplicationAnalysis
+	// Successful and Error are only set for the post-recovery hook.
+	Successful *bool  `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+// runPreRecoveryHook calls the pre-recovery hook, if any. An error means that
+// the hook vetoes the recovery.
+func runPreRecoveryHook(ctx context.Context, recoveryName string, analysisEntry *inst.ReplicationAnalysis) error {
+	hook := config.PreRecoveryHook()
+	if hook == "" {
+		return nil
+	}
+	return runRecoveryHook(ctx, hook, &recoveryHookEvent{
+		Hook:     preRecoveryHookName,
+		Recovery: recoveryName,
+		Analysis: analysisEntry,
+	})
+}
+
+// runPostRecoveryHook calls the post-recovery hook, if any, with the outcome of
+// the recovery. The recovery is over by then, so errors are only logged.
+func runPostRecoveryHook(recoveryName string, analysisEntry *inst.ReplicationAnalysis, recoveryErr error) {
+	hook := config.PostRecoveryHook()
+	if hook == "" {
+		return
+	}
+	successful := recoveryErr == nil
+	event := &recoveryHookEvent{
+		Hook:       postRecoveryHookName,
+		Recovery:   recoveryName,
+		Analysis:   analysisEntry,
+		Successful: &successful,
+	}
+	if recoveryErr != nil {
+		event.Error = recoveryErr.Error()
+	}
+	// The recovery may have used up the context it ran with, so the hook gets its own.
+	if err := runRecoveryHook(context.Background(), hook, event); err != nil {
+		log.Errorf("%v hook for %v on %v failed: %v", postRecoveryHookName, recoveryName, analysisEntry.AnalyzedInstanceAlias, err)
+	}
+}
+
+// runRecoveryHook calls a hook with the event as JSON. A hook that starts with
+// http:// or https:// is a webhook, which gets the event as the body of a POST
+// request and must answer with a 2xx status. Any other hook is an executable,
+// which gets the event on stdin and must exit with 0.
+func runRecoveryHook(ctx context.Context, hook string, event *recoveryHookEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.RecoveryHookTimeout())
+	defer cancel()
+
+	if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return fmt.Errorf("%v returned %v: %s", hook, resp.Status, bytes.TrimSpace(body))
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, hook)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"VTORC_HOOK="+event.Hook,
+		"VTORC_RECOVERY="+event.Recovery,
+		"VTORC_ANALYSIS="+string(event.Analysis.Analysis),
+		"VTORC_TABLET_ALIAS="+event.Analysis.AnalyzedInstanceAlias,
+		"VTORC_KEYSPACE="+event.Analysis.AnalyzedKeyspace,
+		"VTORC_SHARD="+event.Analysis.AnalyzedShard,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%v failed: %v: %s", hook, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+)
+
+func TestRecoveryHookExecutable(t *testing.T) {
+	dir := t.TempDir()
+	out := path.Join(dir, "event.json")
+	hook := path.Join(dir, "hook.sh")
+	// The hook records the event, and vetoes recoveries of zone1-0000000101.
+	script := `#!/bin/sh
+cat > ` + out + `
+echo "$VTORC_HOOK $VTORC_RECOVERY $VTORC_ANALYSIS"
+[ "$VTORC_TABLET_ALIAS" != "zone1-0000000101" ]
+`
+	require.NoError(t, os.WriteFile(hook, []byte(script), 0o755))
+	config.SetRecoveryHooks(hook, hook)
+	defer config.SetRecoveryHooks("", "")
+
+	analysisEntry := &inst.ReplicationAnalysis{
+		AnalyzedInstanceAlias: "zone1-0000000100",
+		AnalyzedKeyspace:      "ks",
+		AnalyzedShard:         "0",
+		Analysis:              inst.ReplicationStopped,
+	}
+	require.NoError(t, runPreRecoveryHook(context.Background(), FixReplicaRecoveryName, analysisEntry))
+
+	var event recoveryHookEvent
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Equal(t, preRecoveryHookName, event.Hook)
+	require.Equal(t, FixReplicaRecoveryName, event.Recovery)
+	require.Equal(t, analysisEntry.AnalyzedInstanceAlias, event.Analysis.AnalyzedInstanceAlias)
+	require.Equal(t, inst.ReplicationStopped, event.Analysis.Analysis)
+	require.Nil(t, event.Successful)
+
+	runPostRecoveryHook(FixReplicaRecoveryName, analysisEntry, errors.New("boom"))
+	event = recoveryHookEvent{}
+	data, err = os.ReadFile(out)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &event))
+	require.Equal(t, postRecoveryHookName, event.Hook)
+	require.False(t, *event.Successful)
+	require.Equal(t, "boom", event.Error)
+
+	analysisEntry.AnalyzedInstanceAlias = "zone1-0000000101"
+	err = runPreRecoveryHook(context.Background(), FixReplicaRecoveryName, analysisEntry)
+	require.ErrorContains(t, err, "exit status 1: pre-recovery FixReplica ReplicationStopped")
+}
+
+func TestRecoveryHookWebhook(t *testing.T) {
+	var events []recoveryHookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event recoveryHookEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events = append(events, event)
+		if event.Analysis.AnalyzedKeyspace == "frozen" {
+			http.Error(w, "keyspace is frozen", http.StatusConflict)
+		}
+	}))
+	defer server.Close()
+	config.SetRecoveryHooks(server.URL, server.URL)
+	defer config.SetRecoveryHooks("", "")
+
+	analysisEntry := &inst.ReplicationAnalysis{
+		AnalyzedInstanceAlias: "zone1-0000000100",
+		AnalyzedKeyspace:      "ks",
+		Analysis:              inst.DeadPrimary,
+	}
+	require.NoError(t, runPreRecoveryHook(context.Background(), RecoverDeadPrimaryRecoveryName, analysisEntry))
+	runPostRecoveryHook(RecoverDeadPrimaryRecoveryName, analysisEntry, nil)
+	require.Len(t, events, 2)
+	require.Equal(t, preRecoveryHookName, events[0].Hook)
+	require.Equal(t, postRecoveryHookName, events[1].Hook)
+	require.True(t, *events[1].Successful)
+
+	analysisEntry.AnalyzedKeyspace = "frozen"
+	err := runPreRecoveryHook(context.Background(), RecoverDeadPrimaryRecoveryName, analysisEntry)
+	require.ErrorContains(t, err, "409 Conflict: keyspace is frozen")
+}
+
+func TestNoRecoveryHooks(t *testing.T) {
+	require.NoError(t, runPreRecoveryHook(context.Background(), FixReplicaRecoveryName, &inst.ReplicationAnalysis{}))
+	runPostRecoveryHook(FixReplicaRecoveryName, &inst.ReplicationAnalysis{}, nil)
+}
+
+func TestRecoveryVetoBackoff(t *testing.T) {
+	defer vetoedRecoveries.Flush()
+
+	analysisEntry := &inst.ReplicationAnalysis{
+		AnalyzedInstanceAlias: "zone1-0000000100",
+		Analysis:              inst.ReplicationStopped,
+	}
+	other := &inst.ReplicationAnalysis{
+		AnalyzedInstanceAlias: "zone1-0000000100",
+		Analysis:              inst.ReplicaSemiSyncMustBeSet,
+	}
+	now := time.Now()
+	require.False(t, isRecoveryVetoBlocked(analysisEntry, now))
+
+	// The backoff doubles with each veto in a row, up to maxVetoBackoff.
+	for _, want := range []time.Duration{minVetoBackoff, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, maxVetoBackoff, maxVetoBackoff} {
+		backoff := recordRecoveryVeto(analysisEntry, now)
+		require.Equal(t, want, backoff)
+		require.True(t, isRecoveryVetoBlocked(analysisEntry, now.Add(backoff-time.Second)))
+		require.False(t, isRecoveryVetoBlocked(analysisEntry, now.Add(backoff)))
+	}
+	// Other analyses of the same tablet are not blocked.
+	require.False(t, isRecoveryVetoBlocked(other, now))
+
+	// Once the hook lets the recovery go ahead, the backoff starts over.
+	forgetRecoveryVetoes(analysisEntry)
+	require.False(t, isRecoveryVetoBlocked(analysisEntry, now))
+	require.Equal(t, minVetoBackoff, recordRecoveryVeto(analysisEntry, now))
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"google.golang.org/protobuf/encoding/prototext"
+
+	"vitess.io/vitess/go/mysql/replication"
+	"vitess.io/vitess/go/protoutil"
+	"vitess.io/vitess/go/vt/external/golib/sqlutils"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtctl/reparentutil"
+	"vitess.io/vitess/go/vt/vtctl/reparentutil/promotionrule"
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/db"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// RecoveryPlan is what VTOrc would do about a problem, worked out without
+// doing any of it.
+type RecoveryPlan struct {
+	Analysis *inst.ReplicationAnalysis
+	// Recovery is the name of the recovery VTOrc would run, or empty if it
+	// has none for the problem.
+	Recovery string
+	// Actionable is false for the recoveries that only report the problem.
+	Actionable bool
+	// ClusterWide is true for the recoveries that reparent the shard.
+	ClusterWide bool
+	// Skipped, if set, is why VTOrc would not run the recovery now.
+	Skipped string `json:",omitempty"`
+	Steps   []string
+	// Candidates are the tablets a reparent would consider promoting, best
+	// first, followed by the ones it can't promote.
+	Candidates []*PromotionCandidate `json:",omitempty"`
+}
+
+// PromotionCandidate is a tablet a reparent would consider promoting.
+type PromotionCandidate struct {
+	TabletAlias     string
+	TabletType      string
+	PromotionRule   promotionrule.CandidatePromotionRule
+	ExecutedGtidSet string
+	// Ineligible, if set, is why the tablet can't be promoted.
+	Ineligible string `json:",omitempty"`
+
+	position replication.Position
+}
+
+// shardTablet is a tablet of the shard being planned for, with what VTOrc
+// last knew about its MySQL.
+type shardTablet struct {
+	tablet           *topodatapb.Tablet
+	durabilityPolicy string
+	executedGtidSet  string
+	lastCheckValid   bool
+}
+
+// PlanRecoveries runs the analysis of the given keyspace and shard, either
+// may be empty, and returns the recovery VTOrc would run for each problem.
+// It analyzes the snapshot if there is one, and the VTOrc database otherwise.
+// Nothing is written, and no tablet is contacted.
+func PlanRecoveries(keyspace string, shard string, snapshot db.DB) ([]*RecoveryPlan, error) {
+	database := db.Db
+	if snapshot != nil {
+		database = snapshot
+	}
+	analysis, err := inst.GetReplicationAnalysis(keyspace, shard, &inst.ReplicationAnalysisHints{Snapshot: snapshot})
+	if err != nil {
+		return nil, err
+	}
+	recoveryDisabled, err := isRecoveryDisabledIn(database)
+	if err != nil {
+		return nil, err
+	}
+
+	shardTablets := make(map[string][]*shardTablet)
+	var plans []*RecoveryPlan
+	for _, analysisEntry := range analysis {
+		if analysisEntry.Analysis == inst.NoProblem {
+			continue
+		}
+		code := getCheckAndRecoverFunctionCode(analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias)
+		plan := &RecoveryPlan{
+			Analysis:    analysisEntry,
+			Recovery:    getRecoverFunctionName(code),
+			Actionable:  hasActionableRecovery(code),
+			ClusterWide: isClusterWideRecovery(code),
+		}
+		plans = append(plans, plan)
+
+		switch {
+		case code == noRecoveryFunc:
+			plan.Skipped = "VTOrc has no recovery for this problem"
+		case !plan.Actionable:
+			plan.Skipped = "VTOrc only reports this problem"
+		case recoveryDisabled:
+			plan.Skipped = "recoveries are disabled globally"
+		case snapshot == nil && !isShardOwned(analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard):
+			plan.Skipped = "the shard is owned by another VTOrc instance"
+		}
+		if !plan.Actionable {
+			continue
+		}
+
+		keyspaceShard := topoproto.KeyspaceShardString(analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard)
+		tablets, ok := shardTablets[keyspaceShard]
+		if !ok {
+			tablets, err = readShardTablets(database, analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard)
+			if err != nil {
+				return nil, err
+			}
+			shardTablets[keyspaceShard] = tablets
+		}
+		plan.Steps = planRecoverySteps(code, analysisEntry, tablets)
+		if plan.ClusterWide {
+			plan.Candidates = planPromotionCandidates(code, analysisEntry, tablets)
+		}
+	}
+	return plans, nil
+}
+
+// planRecoverySteps describes what the recovery would do.
+func planRecoverySteps(code recoveryFunction, analysisEntry *inst.ReplicationAnalysis, tablets []*shardTablet) []string {
+	alias := analysisEntry.AnalyzedInstanceAlias
+	keyspaceShard := topoproto.KeyspaceShardString(analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard)
+	steps := []string{fmt.Sprintf("lock shard %v", keyspaceShard)}
+	if isClusterWideRecovery(code) {
+		steps = append(steps, fmt.Sprintf("refresh all the tablets of %v and check that %v is still there", keyspaceShard, analysisEntry.Analysis))
+	} else {
+		steps = append(steps, fmt.Sprintf("refresh %v and the primary of %v, and check that %v is still there", alias, keyspaceShard, analysisEntry.Analysis))
+	}
+	if hook := config.PreRecoveryHook(); hook != "" {
+		steps = append(steps, fmt.Sprintf("run the %v hook %v, which may veto the recovery", preRecoveryHookName, hook))
+	}
+
+	primaryAlias := "the shard primary"
+	if primary := shardPrimaryOf(tablets); primary != nil {
+		primaryAlias = topoproto.TabletAliasString(primary.Alias)
+	}
+	switch code {
+	case recoverDeadPrimaryFunc, recoverPrimaryTabletDeletedFunc:
+		step := fmt.Sprintf("run EmergencyReparentShard on %v, waiting up to %v for the replicas to apply their relay logs", keyspaceShard, time.Duration(config.Config.WaitReplicasTimeoutSeconds)*time.Second)
+		if code == recoverPrimaryTabletDeletedFunc {
+			step += ", on all the tablets"
+		}
+		if config.Config.PreventCrossDataCenterPrimaryFailover {
+			step += ", promoting only a tablet in the cell of the primary"
+		}
+		steps = append(steps, step)
+	case electNewPrimaryFunc:
+		steps = append(steps, fmt.Sprintf("run PlannedReparentShard on %v, tolerating a replication lag of %v", keyspaceShard, time.Duration(config.Config.TolerableReplicationLagSeconds)*time.Second))
+	case recoverPrimaryHasPrimaryFunc:
+		steps = append(steps, fmt.Sprintf("reset the replication parameters of %v", alias))
+	case fixPrimaryFunc:
+		steps = append(steps, fmt.Sprintf("undo the demotion of %v, making it read-write", alias))
+	case fixReplicaFunc:
+		steps = append(steps,
+			fmt.Sprintf("set %v read-only", alias),
+			fmt.Sprintf("point %v at %v", alias, primaryAlias),
+		)
+	case recoverErrantGTIDDetectedFunc:
+		steps = append(steps, fmt.Sprintf("change the type of %v to DRAINED", alias))
+	case drainUnhealthyReplicaFunc:
+		steps = append(steps, fmt.Sprintf("change the type of %v to DRAINED, unless %v already has %v DRAINED tablets", alias, keyspaceShard, config.MaxDrainedReplicasPerShard()))
+	case undrainReplicaFunc:
+		steps = append(steps, fmt.Sprintf("change the type of %v back to REPLICA", alias))
+	default:
+		if recovery := getCustomRecovery(code); recovery != nil {
+			steps = append(steps, fmt.Sprintf("run the custom recovery %v on %v", recovery.name, alias))
+		}
+	}
+
+	if hook := config.PostRecoveryHook(); hook != "" {
+		steps = append(steps, fmt.Sprintf("run the %v hook %v", postRecoveryHookName, hook))
+	}
+	return steps
+}
+
+// planPromotionCandidates lists the tablets the reparent of the recovery
+// would consider, in the order EmergencyReparentShard and
+// PlannedReparentShard rank them: the most advanced replication position
+// first, then the best promotion rule.
+func planPromotionCandidates(code recoveryFunction, analysisEntry *inst.ReplicationAnalysis, tablets []*shardTablet) []*PromotionCandidate {
+	var failedPrimary *topodatapb.Tablet
+	for _, t := range tablets {
+		if topoproto.TabletAliasString(t.tablet.Alias) == analysisEntry.AnalyzedInstanceAlias && t.tablet.Type == topodatapb.TabletType_PRIMARY {
+			failedPrimary = t.tablet
+		}
+	}
+
+	var candidates []*PromotionCandidate
+	for _, t := range tablets {
+		if t.tablet == failedPrimary {
+			continue
+		}
+		candidate := &PromotionCandidate{
+			TabletAlias:     topoproto.TabletAliasString(t.tablet.Alias),
+			TabletType:      t.tablet.Type.String(),
+			PromotionRule:   promotionrule.MustNot,
+			ExecutedGtidSet: t.executedGtidSet,
+		}
+		candidates = append(candidates, candidate)
+
+		durability, err := reparentutil.GetDurabilityPolicy(t.durabilityPolicy)
+		if err != nil {
+			candidate.Ineligible = fmt.Sprintf("unknown durability policy %q", t.durabilityPolicy)
+			continue
+		}
+		candidate.PromotionRule = reparentutil.PromotionRule(durability, t.tablet)
+		candidate.position, err = replication.ParsePosition(replication.Mysql56FlavorID, t.executedGtidSet)
+		switch {
+		case err != nil:
+			candidate.Ineligible = fmt.Sprintf("unknown replication position: %v", err)
+		case !t.lastCheckValid:
+			candidate.Ineligible = "unreachable"
+		case candidate.PromotionRule == promotionrule.MustNot:
+			candidate.Ineligible = "its promotion rule is must_not"
+		case code != electNewPrimaryFunc && config.Config.PreventCrossDataCenterPrimaryFailover &&
+			failedPrimary != nil && t.tablet.Alias.Cell != failedPrimary.Alias.Cell:
+			candidate.Ineligible = "it is not in the cell of the primary"
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i], candidates[j]
+		if (ci.Ineligible == "") != (cj.Ineligible == "") {
+			return ci.Ineligible == ""
+		}
+		if !ci.position.AtLeast(cj.position) {
+			return false
+		}
+		if !cj.position.AtLeast(ci.position) {
+			return true
+		}
+		return ci.PromotionRule.BetterThan(cj.PromotionRule)
+	})
+	return candidates
+}
+
+// shardPrimaryOf returns the most recent primary among the tablets, or nil.
+func shardPrimaryOf(tablets []*shardTablet) *topodatapb.Tablet {
+	var primary *topodatapb.Tablet
+	for _, t := range tablets {
+		if t.tablet.Type != topodatapb.TabletType_PRIMARY {
+			continue
+		}
+		if primary == nil || protoutil.TimeFromProto(t.tablet.PrimaryTermStartTime).After(protoutil.TimeFromProto(primary.PrimaryTermStartTime)) {
+			primary = t.tablet
+		}
+	}
+	return primary
+}
+
+// readShardTablets reads the tablets of the shard from the given database.
+func readShardTablets(database db.DB, keyspace string, shard string) ([]*shardTablet, error) {
+	query := `
+		SELECT
+			vitess_tablet.info,
+			vitess_keyspace.durability_policy,
+			database_instance.executed_gtid_set,
+			IFNULL(database_instance.last_checked <= database_instance.last_seen, 0) AS is_last_check_valid
+		FROM
+			vitess_tablet
+			JOIN vitess_keyspace ON (vitess_keyspace.keyspace = vitess_tablet.keyspace)
+			LEFT JOIN database_instance ON (database_instance.alias = vitess_tablet.alias)
+		WHERE
+			vitess_tablet.keyspace = ? AND vitess_tablet.shard = ?
+		ORDER BY
+			vitess_tablet.alias
+		`
+	var tablets []*shardTablet
+	err := database.QueryVTOrc(query, sqlutils.Args(keyspace, shard), func(m sqlutils.RowMap) error {
+		tablet := &topodatapb.Tablet{}
+		opts := prototext.UnmarshalOptions{DiscardUnknown: true}
+		if err := opts.Unmarshal([]byte(m.GetString("info")), tablet); err != nil {
+			log.Errorf("could not read tablet %v: %v", m.GetString("info"), err)
+			return nil
+		}
+		tablets = append(tablets, &shardTablet{
+			tablet:           tablet,
+			durabilityPolicy: m.GetString("durability_policy"),
+			executedGtidSet:  m.GetString("executed_gtid_set"),
+			lastCheckValid:   m.GetBool("is_last_check_valid"),
+		})
+		return nil
+	})
+	return tablets, err
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtctl/reparentutil/promotionrule"
+	"vitess.io/vitess/go/vt/vtorc/db"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestPlanRecoveries(t *testing.T) {
+	_, err := db.OpenVTOrc()
+	require.NoError(t, err)
+	defer db.ClearVTOrcDatabase()
+
+	// The shard has lost its primary.
+	keyspaceInfo := &topo.KeyspaceInfo{Keyspace: &topodatapb.Keyspace{DurabilityPolicy: "none"}}
+	keyspaceInfo.SetKeyspaceName("ks")
+	require.NoError(t, inst.SaveKeyspace(keyspaceInfo))
+	_, err = db.ExecVTOrc(`insert into vitess_shard (keyspace, shard, primary_alias, primary_timestamp) values ('ks', '0', '', '')`)
+	require.NoError(t, err)
+	for _, tablet := range []struct {
+		uid             uint32
+		tabletType      topodatapb.TabletType
+		executedGtidSet string
+	}{
+		{100, topodatapb.TabletType_REPLICA, "729a4cc4-8680-11ed-a104-47706090afbd:1-10"},
+		{101, topodatapb.TabletType_REPLICA, "729a4cc4-8680-11ed-a104-47706090afbd:1-12"},
+		{102, topodatapb.TabletType_RDONLY, "729a4cc4-8680-11ed-a104-47706090afbd:1-12"},
+	} {
+		require.NoError(t, inst.SaveTablet(&topodatapb.Tablet{
+			Alias:         &topodatapb.TabletAlias{Cell: "zone1", Uid: tablet.uid},
+			Hostname:      "localhost",
+			MysqlHostname: "localhost",
+			MysqlPort:     int32(6700 + tablet.uid),
+			Keyspace:      "ks",
+			Shard:         "0",
+			Type:          tablet.tabletType,
+		}))
+		_, err = db.ExecVTOrc(`
+			insert into database_instance (
+				alias, hostname, port, server_id, version, binlog_format, log_bin, log_replica_updates, binary_log_file, binary_log_pos,
+				source_host, source_port, replica_net_timeout, heartbeat_interval, replica_sql_running, replica_io_running,
+				source_log_file, read_source_log_pos, relay_source_log_file, exec_source_log_pos,
+				last_checked, last_attempted_check, last_seen, executed_gtid_set
+			) values (
+				?, 'localhost', ?, ?, '8.0.31', 'ROW', 1, 1, '', 0,
+				'', 0, 8, 4, 0, 0,
+				'', 0, '', 0,
+				now(), now(), now(), ?
+			)`,
+			topoproto.TabletAliasString(&topodatapb.TabletAlias{Cell: "zone1", Uid: tablet.uid}), 6700+tablet.uid, tablet.uid, tablet.executedGtidSet,
+		)
+		require.NoError(t, err)
+	}
+
+	plans, err := PlanRecoveries("ks", "0", nil)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	plan := plans[0]
+	require.Equal(t, inst.ClusterHasNoPrimary, plan.Analysis.Analysis)
+	require.Equal(t, ElectNewPrimaryRecoveryName, plan.Recovery)
+	require.True(t, plan.Actionable)
+	require.True(t, plan.ClusterWide)
+	require.Empty(t, plan.Skipped)
+	require.Contains(t, plan.Steps, "run PlannedReparentShard on ks/0, tolerating a replication lag of 0s")
+
+	// The most advanced replica comes first, and the RDONLY can't be promoted.
+	require.Len(t, plan.Candidates, 3)
+	require.Equal(t, "zone1-0000000101", plan.Candidates[0].TabletAlias)
+	require.Equal(t, promotionrule.Neutral, plan.Candidates[0].PromotionRule)
+	require.Empty(t, plan.Candidates[0].Ineligible)
+	require.Equal(t, "zone1-0000000100", plan.Candidates[1].TabletAlias)
+	require.Empty(t, plan.Candidates[1].Ineligible)
+	require.Equal(t, "zone1-0000000102", plan.Candidates[2].TabletAlias)
+	require.Equal(t, "its promotion rule is must_not", plan.Candidates[2].Ineligible)
+
+	require.NoError(t, DisableRecovery())
+	plans, err = PlanRecoveries("ks", "0", nil)
+	require.NoError(t, err)
+	require.Equal(t, "recoveries are disabled globally", plans[0].Skipped)
+	require.NoError(t, EnableRecovery())
+
+	// A snapshot of the database gets the same plan, even once the database has moved on.
+	state, err := inst.GetDatabaseState()
+	require.NoError(t, err)
+	var tables []db.TableState
+	require.NoError(t, json.Unmarshal([]byte(state), &tables))
+	snapshot, err := db.OpenSnapshot(tables)
+	require.NoError(t, err)
+	defer snapshot.Close()
+	db.ClearVTOrcDatabase()
+
+	plans, err = PlanRecoveries("ks", "0", nil)
+	require.NoError(t, err)
+	require.Empty(t, plans)
+	plans, err = PlanRecoveries("", "", snapshot)
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	require.Equal(t, plan, plans[0])
+
+	// Nothing was written to the database.
+	plans, err = PlanRecoveries("", "", nil)
+	require.NoError(t, err)
+	require.Empty(t, plans)
+}
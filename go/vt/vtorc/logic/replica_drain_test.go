@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtctl/grpcvtctldserver/testutil"
+	"vitess.io/vitess/go/vt/vtorc/config"
+	"vitess.io/vitess/go/vt/vtorc/db"
+	"vitess.io/vitess/go/vt/vtorc/inst"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+func TestGetCheckAndRecoverFunctionCodeReplicaDrain(t *testing.T) {
+	require.Equal(t, noRecoveryFunc, getCheckAndRecoverFunctionCode(inst.UnhealthyReplica, ""))
+	require.Equal(t, noRecoveryFunc, getCheckAndRecoverFunctionCode(inst.DrainedReplicaRecovered, ""))
+
+	config.SetDrainUnhealthyReplicas(true)
+	defer config.SetDrainUnhealthyReplicas(false)
+	require.Equal(t, drainUnhealthyReplicaFunc, getCheckAndRecoverFunctionCode(inst.UnhealthyReplica, ""))
+	require.Equal(t, undrainReplicaFunc, getCheckAndRecoverFunctionCode(inst.DrainedReplicaRecovered, ""))
+	require.False(t, isClusterWideRecovery(drainUnhealthyReplicaFunc))
+	require.False(t, isClusterWideRecovery(undrainReplicaFunc))
+}
+
+func TestDrainAndUndrainReplica(t *testing.T) {
+	_, err := db.OpenVTOrc()
+	require.NoError(t, err)
+	oldTs, oldTmc := ts, tmc
+	defer func() {
+		ts, tmc = oldTs, oldTmc
+		db.ClearVTOrcDatabase()
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts = memorytopo.NewServer(ctx, "zone1")
+	tmc = &testutil.TabletManagerClient{TopoServer: ts}
+
+	keyspaceInfo := &topo.KeyspaceInfo{Keyspace: &topodatapb.Keyspace{DurabilityPolicy: "none"}}
+	keyspaceInfo.SetKeyspaceName("ks")
+	require.NoError(t, inst.SaveKeyspace(keyspaceInfo))
+
+	newTablet := func(uid uint32, tabletType topodatapb.TabletType) *topodatapb.Tablet {
+		tablet := &topodatapb.Tablet{
+			Alias:         &topodatapb.TabletAlias{Cell: "zone1", Uid: uid},
+			Hostname:      "localhost",
+			MysqlHostname: "localhost",
+			MysqlPort:     int32(6700 + uid),
+			Keyspace:      "ks",
+			Shard:         "0",
+			Type:          tabletType,
+		}
+		require.NoError(t, ts.CreateTablet(ctx, tablet))
+		require.NoError(t, inst.SaveTablet(tablet))
+		return tablet
+	}
+	newTablet(100, topodatapb.TabletType_PRIMARY)
+	replica := newTablet(101, topodatapb.TabletType_REPLICA)
+	otherReplica := newTablet(102, topodatapb.TabletType_REPLICA)
+
+	tabletType := func(tablet *topodatapb.Tablet) topodatapb.TabletType {
+		ti, err := ts.GetTablet(ctx, tablet.Alias)
+		require.NoError(t, err)
+		return ti.Type
+	}
+	drainedByVTOrc := func(tablet *topodatapb.Tablet) bool {
+		ti, err := ts.GetTablet(ctx, tablet.Alias)
+		require.NoError(t, err)
+		_, tagged := ti.Tags[inst.DrainedByVTOrcTag]
+		// The vtorc backend has to agree with the topo.
+		local, err := inst.ReadTablet(topoproto.TabletAliasString(tablet.Alias))
+		require.NoError(t, err)
+		require.Equal(t, tagged, inst.IsDrainedByVTOrc(local))
+		return tagged
+	}
+	analysisEntry := func(tablet *topodatapb.Tablet, code inst.AnalysisCode) *inst.ReplicationAnalysis {
+		return &inst.ReplicationAnalysis{
+			AnalyzedInstanceAlias: topoproto.TabletAliasString(tablet.Alias),
+			AnalyzedKeyspace:      "ks",
+			AnalyzedShard:         "0",
+			Analysis:              code,
+		}
+	}
+
+	recoveryAttempted, _, err := drainUnhealthyReplica(ctx, analysisEntry(replica, inst.UnhealthyReplica))
+	require.NoError(t, err)
+	require.True(t, recoveryAttempted)
+	require.Equal(t, topodatapb.TabletType_DRAINED, tabletType(replica))
+	require.True(t, drainedByVTOrc(replica))
+
+	// --max-drained-replicas-per-shard is 1, so the other replica stays.
+	recoveryAttempted, _, err = drainUnhealthyReplica(ctx, analysisEntry(otherReplica, inst.UnhealthyReplica))
+	require.NoError(t, err)
+	require.False(t, recoveryAttempted)
+	require.Equal(t, topodatapb.TabletType_REPLICA, tabletType(otherReplica))
+	require.False(t, drainedByVTOrc(otherReplica))
+
+	recoveryAttempted, _, err = undrainReplica(ctx, analysisEntry(replica, inst.DrainedReplicaRecovered))
+	require.NoError(t, err)
+	require.True(t, recoveryAttempted)
+	require.Equal(t, topodatapb.TabletType_REPLICA, tabletType(replica))
+	require.False(t, drainedByVTOrc(replica))
+}
@@ -0,0 +1,378 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"vitess.io/vitess/go/stats"
+	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/servenv"
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+	"vitess.io/vitess/go/vt/vtorc/config"
+)
+
+// vtorcInstancesPath is the directory of the global topo in which the VTOrc
+// instances using --shard-ownership keep a heartbeat file.
+const vtorcInstancesPath = "vtorc/instances"
+
+var ownedShardsGauge = stats.NewGaugeFunc("OwnedShards", "Number of shards owned by this VTOrc instance when using --shard-ownership", func() int64 {
+	if ownership == nil {
+		return 0
+	}
+	return int64(len(ownership.ownedShards()))
+})
+
+// ownership is set when VTOrc runs with --shard-ownership.
+var ownership *shardOwnership
+
+// shardOwnership splits the shards between the VTOrc instances, so that each
+// shard is discovered and recovered by a single instance.
+//
+// The instances heartbeat into the global topo. Each shard is assigned to one
+// of the live instances by rendezvous hashing, so that an instance joining or
+// leaving only moves the shards it gains or loses. As the instances may not
+// see the same set of live instances at the same time, an instance only owns
+// the shards assigned to it once it wins the election of the shard in the
+// global topo. When an instance stops heartbeating, the others take over its
+// shards after --instance-heartbeat-timeout.
+type shardOwnership struct {
+	ts               *topo.Server
+	id               string
+	heartbeatTimeout time.Duration
+	// onAcquire is called when the instance starts owning a shard.
+	onAcquire func(keyspace, shard string)
+
+	mu sync.Mutex
+	// members records since when the heartbeat of each instance is at
+	// its version. Comparing versions rather than timestamps written
+	// by the instances keeps this immune to clock skew.
+	members   map[string]*instanceHeartbeat
+	elections map[string]*shardElection
+}
+
+type instanceHeartbeat struct {
+	version string
+	since   time.Time
+}
+
+// shardElection is the participation of this instance in the election of a shard.
+type shardElection struct {
+	keyspace      string
+	shard         string
+	participation topo.LeaderParticipation
+	leading       atomic.Bool
+	// done is closed once the instance stops waiting for or holding the leadership.
+	done chan struct{}
+}
+
+func newShardOwnership(ts *topo.Server, id string, heartbeatTimeout time.Duration, onAcquire func(keyspace, shard string)) *shardOwnership {
+	return &shardOwnership{
+		ts:               ts,
+		id:               id,
+		heartbeatTimeout: heartbeatTimeout,
+		onAcquire:        onAcquire,
+		members:          make(map[string]*instanceHeartbeat),
+		elections:        make(map[string]*shardElection),
+	}
+}
+
+// startShardOwnership starts splitting the shards with the other VTOrc
+// instances, if --shard-ownership is set.
+func startShardOwnership() {
+	if !config.ShardOwnership() {
+		return
+	}
+	id := config.InstanceID()
+	if id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Fatalf("Failed to get the hostname for --instance-id: %v", err)
+		}
+		id = fmt.Sprintf("%v:%v", hostname, servenv.Port())
+	}
+	ownership = newShardOwnership(ts, id, config.InstanceHeartbeatTimeout(), func(keyspace, shard string) {
+		// Discover the tablets of the shard right away, rather than on the next topo refresh.
+		ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+		defer cancel()
+		refreshTabletsInKeyspaceShard(ctx, keyspace, shard, func(tabletAlias string) {
+			DiscoverInstance(tabletAlias, false /* forceDiscovery */)
+		}, false, nil)
+	})
+	log.Infof("Splitting the shards with the other VTOrc instances as %v", id)
+	ownership.refresh()
+	go func() {
+		// Heartbeat a few times per timeout, so that a slow topo does not make the others take over.
+		for range time.Tick(ownership.heartbeatTimeout / 4) { //nolint SA1015: using time.Tick leaks the underlying ticker
+			if atomic.LoadInt32(&hasReceivedSIGTERM) > 0 {
+				return
+			}
+			ownership.refresh()
+		}
+	}()
+}
+
+// stopShardOwnership hands the shards owned by this instance over to the
+// other VTOrc instances.
+func stopShardOwnership() {
+	if ownership == nil {
+		return
+	}
+	ownership.stop()
+}
+
+// isShardOwned returns true if this instance is in charge of the shard.
+func isShardOwned(keyspace, shard string) bool {
+	if ownership == nil {
+		return true
+	}
+	return ownership.owns(keyspace, shard)
+}
+
+// refresh heartbeats, and joins or leaves the elections of the watched
+// shards according to the live instances.
+func (so *shardOwnership) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+	defer cancel()
+	keyspaceShards, err := getKeyspaceShardsToWatch()
+	if err != nil {
+		log.Errorf("Failed to read the shards to watch: %v", err)
+		return
+	}
+	if err := so.update(ctx, keyspaceShards); err != nil {
+		log.Errorf("Failed to update the shards owned by %v: %v", so.id, err)
+	}
+}
+
+// update heartbeats, and joins or leaves the elections of the given shards
+// according to the live instances.
+func (so *shardOwnership) update(ctx context.Context, keyspaceShards []*topo.KeyspaceShard) error {
+	conn, err := so.ts.ConnForCell(ctx, topo.GlobalCell)
+	if err != nil {
+		return err
+	}
+	heartbeat := []byte(time.Now().UTC().Format(time.RFC3339))
+	if _, err := conn.Update(ctx, path.Join(vtorcInstancesPath, so.id), heartbeat, nil); err != nil {
+		return err
+	}
+	members, err := so.liveMembers(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	watched := make(map[string]bool, len(keyspaceShards))
+	for _, ks := range keyspaceShards {
+		key := topoproto.KeyspaceShardString(ks.Keyspace, ks.Shard)
+		watched[key] = true
+		election := so.elections[key]
+		if election != nil {
+			select {
+			case <-election.done:
+				// We lost the leadership, or failed to wait for it. Start over.
+				election.participation.Stop()
+				delete(so.elections, key)
+				election = nil
+			default:
+			}
+		}
+		assigned := preferredOwner(members, ks.Keyspace, ks.Shard) == so.id
+		switch {
+		case assigned && election == nil:
+			if err := so.join(conn, ks.Keyspace, ks.Shard); err != nil {
+				log.Errorf("Failed to join the election of %v: %v", key, err)
+			}
+		case !assigned && election != nil:
+			so.leave(key)
+		}
+	}
+	for key := range so.elections {
+		if !watched[key] {
+			so.leave(key)
+		}
+	}
+	return nil
+}
+
+// liveMembers returns the sorted ids of the instances whose heartbeat changed
+// within the heartbeat timeout, and deletes the heartbeat of the others.
+func (so *shardOwnership) liveMembers(ctx context.Context, conn topo.Conn) ([]string, error) {
+	entries, err := conn.ListDir(ctx, vtorcInstancesPath, false /* full */)
+	if err != nil && !topo.IsErrType(err, topo.NoNode) {
+		return nil, err
+	}
+	now := time.Now()
+
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	seen := make(map[string]bool, len(entries))
+	members := []string{so.id}
+	for _, entry := range entries {
+		id := entry.Name
+		seen[id] = true
+		if id == so.id {
+			continue
+		}
+		filePath := path.Join(vtorcInstancesPath, id)
+		_, version, err := conn.Get(ctx, filePath)
+		if err != nil {
+			if !topo.IsErrType(err, topo.NoNode) {
+				log.Errorf("Failed to read the heartbeat of %v: %v", id, err)
+			}
+			continue
+		}
+		member := so.members[id]
+		if member == nil || member.version != version.String() {
+			member = &instanceHeartbeat{version: version.String(), since: now}
+			so.members[id] = member
+		}
+		if now.Sub(member.since) >= so.heartbeatTimeout {
+			log.Infof("VTOrc instance %v stopped heartbeating, taking over its shards", id)
+			if err := conn.Delete(ctx, filePath, version); err != nil && !topo.IsErrType(err, topo.NoNode) && !topo.IsErrType(err, topo.BadVersion) {
+				log.Errorf("Failed to delete the heartbeat of %v: %v", id, err)
+			}
+			delete(so.members, id)
+			continue
+		}
+		members = append(members, id)
+	}
+	for id := range so.members {
+		if !seen[id] {
+			delete(so.members, id)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// join makes the instance a candidate in the election of the shard. It must
+// be called with so.mu held.
+func (so *shardOwnership) join(conn topo.Conn, keyspace, shard string) error {
+	participation, err := conn.NewLeaderParticipation(shardElectionName(keyspace, shard), so.id)
+	if err != nil {
+		return err
+	}
+	election := &shardElection{
+		keyspace:      keyspace,
+		shard:         shard,
+		participation: participation,
+		done:          make(chan struct{}),
+	}
+	so.elections[topoproto.KeyspaceShardString(keyspace, shard)] = election
+	go func() {
+		defer close(election.done)
+		ctx, err := participation.WaitForLeadership()
+		if err != nil {
+			if !topo.IsErrType(err, topo.Interrupted) {
+				log.Errorf("Failed to wait for the ownership of %v/%v: %v", keyspace, shard, err)
+			}
+			return
+		}
+		log.Infof("VTOrc instance %v now owns %v/%v", so.id, keyspace, shard)
+		election.leading.Store(true)
+		so.onAcquire(keyspace, shard)
+		<-ctx.Done()
+		election.leading.Store(false)
+		log.Infof("VTOrc instance %v no longer owns %v/%v", so.id, keyspace, shard)
+	}()
+	return nil
+}
+
+// leave withdraws the instance from the election of the shard, handing the
+// shard over if the instance owns it. It must be called with so.mu held.
+func (so *shardOwnership) leave(key string) {
+	election := so.elections[key]
+	delete(so.elections, key)
+	election.leading.Store(false)
+	election.participation.Stop()
+	<-election.done
+}
+
+// stop withdraws the instance from all its elections and removes its heartbeat.
+func (so *shardOwnership) stop() {
+	so.mu.Lock()
+	for key := range so.elections {
+		so.leave(key)
+	}
+	so.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+	defer cancel()
+	conn, err := so.ts.ConnForCell(ctx, topo.GlobalCell)
+	if err == nil {
+		err = conn.Delete(ctx, path.Join(vtorcInstancesPath, so.id), nil)
+	}
+	if err != nil && !topo.IsErrType(err, topo.NoNode) {
+		log.Errorf("Failed to delete the heartbeat of %v: %v", so.id, err)
+	}
+}
+
+// owns returns true if the instance holds the leadership of the shard.
+func (so *shardOwnership) owns(keyspace, shard string) bool {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	election := so.elections[topoproto.KeyspaceShardString(keyspace, shard)]
+	return election != nil && election.leading.Load()
+}
+
+// ownedShards returns the shards the instance holds the leadership of.
+func (so *shardOwnership) ownedShards() []*topo.KeyspaceShard {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	var owned []*topo.KeyspaceShard
+	for _, election := range so.elections {
+		if election.leading.Load() {
+			owned = append(owned, &topo.KeyspaceShard{Keyspace: election.keyspace, Shard: election.shard})
+		}
+	}
+	return owned
+}
+
+// shardElectionName returns the name of the election of the shard. It is a
+// single path component, as not all topo implementations create nested
+// election directories.
+func shardElectionName(keyspace, shard string) string {
+	return fmt.Sprintf("vtorc-%v:%v", keyspace, shard)
+}
+
+// preferredOwner returns the instance the shard is assigned to, using
+// rendezvous hashing: the instance with the highest hash of its id and the
+// shard wins.
+func preferredOwner(members []string, keyspace, shard string) string {
+	var owner string
+	var ownerScore uint64
+	for _, member := range members {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(member))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(topoproto.KeyspaceShardString(keyspace, shard)))
+		if score := h.Sum64(); owner == "" || score > ownerScore {
+			owner, ownerScore = member, score
+		}
+	}
+	return owner
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"path"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/vt/topo"
+	"vitess.io/vitess/go/vt/topo/memorytopo"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+)
+
+var testKeyspaceShards = func() []*topo.KeyspaceShard {
+	var keyspaceShards []*topo.KeyspaceShard
+	for _, shard := range []string{"-20", "20-40", "40-60", "60-80", "80-a0", "a0-c0", "c0-e0", "e0-"} {
+		keyspaceShards = append(keyspaceShards, &topo.KeyspaceShard{Keyspace: "ks", Shard: shard})
+	}
+	return keyspaceShards
+}()
+
+func ownedShardNames(so *shardOwnership) []string {
+	var names []string
+	for _, ks := range so.ownedShards() {
+		names = append(names, topoproto.KeyspaceShardString(ks.Keyspace, ks.Shard))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func preferredShardNames(members []string, id string) []string {
+	var names []string
+	for _, ks := range testKeyspaceShards {
+		if preferredOwner(members, ks.Keyspace, ks.Shard) == id {
+			names = append(names, topoproto.KeyspaceShardString(ks.Keyspace, ks.Shard))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPreferredOwner(t *testing.T) {
+	members := []string{"zone1-vtorc:15000", "zone2-vtorc:15000", "zone3-vtorc:15000"}
+	owners := make(map[string]string)
+	for _, ks := range testKeyspaceShards {
+		owner := preferredOwner(members, ks.Keyspace, ks.Shard)
+		require.Contains(t, members, owner)
+		owners[ks.Shard] = owner
+	}
+
+	// Only the shards of the instance that leaves move.
+	for _, ks := range testKeyspaceShards {
+		owner := preferredOwner(members[:2], ks.Keyspace, ks.Shard)
+		if owners[ks.Shard] != members[2] {
+			require.Equal(t, owners[ks.Shard], owner)
+		}
+	}
+	require.Empty(t, preferredOwner(nil, "ks", "-80"))
+}
+
+func TestShardOwnership(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+
+	acquired := make(chan string, 2*len(testKeyspaceShards))
+	onAcquire := func(keyspace, shard string) {
+		acquired <- topoproto.KeyspaceShardString(keyspace, shard)
+	}
+	a := newShardOwnership(ts, "a", time.Hour, onAcquire)
+	b := newShardOwnership(ts, "b", time.Hour, onAcquire)
+	defer b.stop()
+
+	// Alone, a owns all the shards.
+	require.NoError(t, a.update(ctx, testKeyspaceShards))
+	all := preferredShardNames([]string{"a"}, "a")
+	require.Eventually(t, func() bool {
+		return len(a.ownedShards()) == len(testKeyspaceShards)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, all, ownedShardNames(a))
+	require.True(t, isShardOwned("ks", "-20"), "every shard is owned without --shard-ownership")
+
+	// Once b joins, a hands it the shards assigned to it.
+	require.NoError(t, b.update(ctx, testKeyspaceShards))
+	require.NoError(t, a.update(ctx, testKeyspaceShards))
+	members := []string{"a", "b"}
+	wantA, wantB := preferredShardNames(members, "a"), preferredShardNames(members, "b")
+	require.NotEmpty(t, wantA)
+	require.NotEmpty(t, wantB)
+	require.Eventually(t, func() bool {
+		return len(b.ownedShards()) == len(wantB)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, wantA, ownedShardNames(a))
+	require.Equal(t, wantB, ownedShardNames(b))
+	require.False(t, a.owns("ks", wantB[0][len("ks/"):]))
+
+	// When a stops, b takes over all the shards.
+	a.stop()
+	require.Empty(t, a.ownedShards())
+	require.NoError(t, b.update(ctx, testKeyspaceShards))
+	require.Eventually(t, func() bool {
+		return len(b.ownedShards()) == len(testKeyspaceShards)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, all, ownedShardNames(b))
+	require.Len(t, acquired, 2*len(testKeyspaceShards))
+
+	// Shards that are no longer watched are released.
+	require.NoError(t, b.update(ctx, testKeyspaceShards[:1]))
+	require.Equal(t, []string{"ks/-20"}, ownedShardNames(b))
+}
+
+func TestShardOwnershipHeartbeatTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ts := memorytopo.NewServer(ctx, "zone1")
+	defer ts.Close()
+	conn, err := ts.ConnForCell(ctx, topo.GlobalCell)
+	require.NoError(t, err)
+
+	so := newShardOwnership(ts, "a", 100*time.Millisecond, func(keyspace, shard string) {})
+	defer so.stop()
+
+	// The heartbeat of an instance that does not update it is good until the timeout.
+	_, err = conn.Create(ctx, path.Join(vtorcInstancesPath, "b"), []byte("heartbeat"))
+	require.NoError(t, err)
+	members, err := so.liveMembers(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, members)
+
+	_, err = conn.Update(ctx, path.Join(vtorcInstancesPath, "b"), []byte("heartbeat"), nil)
+	require.NoError(t, err)
+	time.Sleep(60 * time.Millisecond)
+	members, err = so.liveMembers(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, members)
+
+	// After the timeout, the instance is gone and so is its heartbeat.
+	time.Sleep(120 * time.Millisecond)
+	members, err = so.liveMembers(ctx, conn)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, members)
+	_, _, err = conn.Get(ctx, path.Join(vtorcInstancesPath, "b"))
+	require.True(t, topo.IsErrType(err, topo.NoNode), err)
+}
@@ -70,6 +70,7 @@ func OpenTabletDiscovery() <-chan time.Time {
 	if _, err := db.ExecVTOrc("delete from vitess_tablet"); err != nil {
 		log.Error(err)
 	}
+	startShardOwnership()
 	// We refresh all information from the topo once before we start the ticks to do it on a timer.
 	populateAllInformation()
 	return time.Tick(time.Second * time.Duration(config.Config.TopoInformationRefreshSeconds)) //nolint SA1015: using time.Tick leaks the underlying ticker
@@ -90,6 +91,10 @@ func refreshAllTablets() {
 }
 
 func refreshTabletsUsing(loader func(tabletAlias string), forceRefresh bool) {
+	if ownership != nil {
+		refreshOwnedTablets(loader, forceRefresh)
+		return
+	}
 	if len(clustersToWatch) == 0 { // all known clusters
 		ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
 		defer cancel()
@@ -110,49 +115,98 @@ func refreshTabletsUsing(loader func(tabletAlias string), forceRefresh bool) {
 			}(cell)
 		}
 		wg.Wait()
+	} else {
+		keyspaceShards, _ := getKeyspaceShardsToWatch()
+		if len(keyspaceShards) == 0 {
+			log.Errorf("Found no keyspaceShards for input: %+v", clustersToWatch)
+			return
+		}
+		refreshTabletsInKeyspaceShards(keyspaceShards, loader, forceRefresh)
+	}
+}
+
+// getKeyspaceShardsToWatch returns the shards this instance watches, either
+// the ones in --clusters_to_watch or all the shards in the topology.
+func getKeyspaceShardsToWatch() ([]*topo.KeyspaceShard, error) {
+	var keyspaces []string
+	var keyspaceShards []*topo.KeyspaceShard
+	if len(clustersToWatch) == 0 { // all known keyspaces
+		ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+		defer cancel()
+		var err error
+		keyspaces, err = ts.GetKeyspaces(ctx)
+		if err != nil {
+			return nil, err
+		}
 	} else {
 		// Parse input and build list of keyspaces / shards
-		var keyspaceShards []*topo.KeyspaceShard
 		for _, ks := range clustersToWatch {
 			if strings.Contains(ks, "/") {
 				// This is a keyspace/shard specification
 				input := strings.Split(ks, "/")
 				keyspaceShards = append(keyspaceShards, &topo.KeyspaceShard{Keyspace: input[0], Shard: input[1]})
 			} else {
-				// Assume this is a keyspace and find all shards in keyspace
-				ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
-				defer cancel()
-				shards, err := ts.GetShardNames(ctx, ks)
-				if err != nil {
-					// Log the errr and continue
-					log.Errorf("Error fetching shards for keyspace: %v", ks)
-					continue
-				}
-				if len(shards) == 0 {
-					log.Errorf("Topo has no shards for ks: %v", ks)
-					continue
-				}
-				for _, s := range shards {
-					keyspaceShards = append(keyspaceShards, &topo.KeyspaceShard{Keyspace: ks, Shard: s})
-				}
+				// Assume this is a keyspace
+				keyspaces = append(keyspaces, ks)
 			}
 		}
-		if len(keyspaceShards) == 0 {
-			log.Errorf("Found no keyspaceShards for input: %+v", clustersToWatch)
-			return
+	}
+	// Find all shards in the keyspaces
+	for _, ks := range keyspaces {
+		ctx, cancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+		defer cancel()
+		shards, err := ts.GetShardNames(ctx, ks)
+		if err != nil {
+			// Log the errr and continue
+			log.Errorf("Error fetching shards for keyspace: %v", ks)
+			continue
 		}
-		refreshCtx, refreshCancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
-		defer refreshCancel()
-		var wg sync.WaitGroup
-		for _, ks := range keyspaceShards {
-			wg.Add(1)
-			go func(ks *topo.KeyspaceShard) {
-				defer wg.Done()
-				refreshTabletsInKeyspaceShard(refreshCtx, ks.Keyspace, ks.Shard, loader, forceRefresh, nil)
-			}(ks)
+		if len(shards) == 0 {
+			log.Errorf("Topo has no shards for ks: %v", ks)
+			continue
+		}
+		for _, s := range shards {
+			keyspaceShards = append(keyspaceShards, &topo.KeyspaceShard{Keyspace: ks, Shard: s})
 		}
-		wg.Wait()
 	}
+	return keyspaceShards, nil
+}
+
+// refreshOwnedTablets refreshes the tablets of the shards owned by this
+// instance, and forgets the ones of the shards owned by other instances.
+func refreshOwnedTablets(loader func(tabletAlias string), forceRefresh bool) {
+	refreshTabletsInKeyspaceShards(ownership.ownedShards(), loader, forceRefresh)
+
+	var toForget []string
+	query := "select alias, keyspace, shard from vitess_tablet"
+	err := db.QueryVTOrc(query, nil, func(row sqlutils.RowMap) error {
+		if !isShardOwned(row.GetString("keyspace"), row.GetString("shard")) {
+			toForget = append(toForget, row.GetString("alias"))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error(err)
+	}
+	for _, tabletAlias := range toForget {
+		if err := inst.ForgetInstance(tabletAlias); err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func refreshTabletsInKeyspaceShards(keyspaceShards []*topo.KeyspaceShard, loader func(tabletAlias string), forceRefresh bool) {
+	refreshCtx, refreshCancel := context.WithTimeout(context.Background(), topo.RemoteOperationTimeout)
+	defer refreshCancel()
+	var wg sync.WaitGroup
+	for _, ks := range keyspaceShards {
+		wg.Add(1)
+		go func(ks *topo.KeyspaceShard) {
+			defer wg.Done()
+			refreshTabletsInKeyspaceShard(refreshCtx, ks.Keyspace, ks.Shard, loader, forceRefresh, nil)
+		}(ks)
+	}
+	wg.Wait()
 }
 
 func refreshTabletsInCell(ctx context.Context, cell string, loader func(tabletAlias string), forceRefresh bool) {
@@ -304,6 +358,31 @@ func changeTabletType(ctx context.Context, tablet *topodatapb.Tablet, tabletType
 	return tmc.ChangeType(tmcCtx, tablet, tabletType, semiSync)
 }
 
+// setDrainedByVTOrc sets or clears the tag that marks the tablet as drained by VTOrc in the topo
+// and in the vtorc backend, so that the marker survives a restart of VTOrc or a change of the VTOrc
+// that recovers the shard.
+func setDrainedByVTOrc(ctx context.Context, tablet *topodatapb.Tablet, drained bool) error {
+	updated, err := ts.UpdateTabletFields(ctx, tablet.Alias, func(t *topodatapb.Tablet) error {
+		_, tagged := t.Tags[inst.DrainedByVTOrcTag]
+		if drained == tagged {
+			return topo.NewError(topo.NoUpdateNeeded, topoproto.TabletAliasString(t.Alias))
+		}
+		if !drained {
+			delete(t.Tags, inst.DrainedByVTOrcTag)
+			return nil
+		}
+		if t.Tags == nil {
+			t.Tags = map[string]string{}
+		}
+		t.Tags[inst.DrainedByVTOrcTag] = time.Now().UTC().Format(time.RFC3339)
+		return nil
+	})
+	if err != nil || updated == nil {
+		return err
+	}
+	return inst.SaveTablet(updated)
+}
+
 // resetReplicationParameters resets the replication parameters on the given tablet.
 func resetReplicationParameters(ctx context.Context, tablet *topodatapb.Tablet) error {
 	tmcCtx, tmcCancel := context.WithTimeout(ctx, topo.RemoteOperationTimeout)
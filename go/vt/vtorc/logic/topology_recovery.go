@@ -23,6 +23,8 @@ import (
 	"math/rand/v2"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
 	"vitess.io/vitess/go/stats"
 	"vitess.io/vitess/go/vt/log"
 	"vitess.io/vitess/go/vt/logutil"
@@ -45,6 +47,8 @@ const (
 	FixPrimaryRecoveryName                           string = "FixPrimary"
 	FixReplicaRecoveryName                           string = "FixReplica"
 	RecoverErrantGTIDDetectedName                    string = "RecoverErrantGTIDDetected"
+	DrainUnhealthyReplicaRecoveryName                string = "DrainUnhealthyReplica"
+	UndrainReplicaRecoveryName                       string = "UndrainReplica"
 )
 
 var (
@@ -54,6 +58,8 @@ var (
 		ElectNewPrimaryRecoveryName,
 		FixPrimaryRecoveryName,
 		FixReplicaRecoveryName,
+		DrainUnhealthyReplicaRecoveryName,
+		UndrainReplicaRecoveryName,
 	}
 
 	countPendingRecoveries = stats.NewGauge("PendingRecoveries", "Count of the number of pending recoveries")
@@ -77,6 +83,9 @@ var (
 
 	// recoveriesFailureCounter counts the number of failed recoveries that VTOrc has performed
 	recoveriesFailureCounter = stats.NewCountersWithSingleLabel("FailedRecoveries", "Count of the different failed recoveries performed", "RecoveryType", actionableRecoveriesNames...)
+
+	// recoveriesVetoedCounter counts the number of recoveries that the pre-recovery hook has vetoed
+	recoveriesVetoedCounter = stats.NewCountersWithSingleLabel("VetoedRecoveries", "Count of the different recoveries vetoed by the pre-recovery hook", "RecoveryType", actionableRecoveriesNames...)
 )
 
 // recoveryFunction is the code of the recovery function to be used
@@ -95,6 +104,11 @@ const (
 	fixPrimaryFunc
 	fixReplicaFunc
 	recoverErrantGTIDDetectedFunc
+	drainUnhealthyReplicaFunc
+	undrainReplicaFunc
+	// firstCustomRecoveryFunc is the code of the first recovery registered
+	// with RegisterCustomRecovery. The others follow in registration order.
+	firstCustomRecoveryFunc
 )
 
 // TopologyRecovery represents an entry in the topology_recovery table
@@ -305,6 +319,15 @@ func getCheckAndRecoverFunctionCode(analysisCode inst.AnalysisCode, tabletAlias
 			return noRecoveryFunc
 		}
 		return recoverErrantGTIDDetectedFunc
+	case inst.UnhealthyReplica, inst.DrainedReplicaRecovered:
+		if !config.DrainUnhealthyReplicas() {
+			log.Infof("VTOrc not configured to drain unhealthy replicas, skipping recovering %v", analysisCode)
+			return noRecoveryFunc
+		}
+		if analysisCode == inst.UnhealthyReplica {
+			return drainUnhealthyReplicaFunc
+		}
+		return undrainReplicaFunc
 	case inst.PrimaryHasPrimary:
 		return recoverPrimaryHasPrimaryFunc
 	case inst.LockedSemiSyncPrimary:
@@ -334,7 +357,7 @@ func getCheckAndRecoverFunctionCode(analysisCode inst.AnalysisCode, tabletAlias
 	// case inst.AllPrimaryReplicasStale:
 	//   return recoverGenericProblemFunc
 
-	return noRecoveryFunc
+	return getCustomRecoveryFunctionCode(analysisCode)
 }
 
 // hasActionableRecovery tells if a recoveryFunction has an actionable recovery or not
@@ -360,8 +383,12 @@ func hasActionableRecovery(recoveryFunctionCode recoveryFunction) bool {
 		return true
 	case recoverErrantGTIDDetectedFunc:
 		return true
+	case drainUnhealthyReplicaFunc:
+		return true
+	case undrainReplicaFunc:
+		return true
 	default:
-		return false
+		return getCustomRecovery(recoveryFunctionCode) != nil
 	}
 }
 
@@ -390,7 +417,14 @@ func getCheckAndRecoverFunction(recoveryFunctionCode recoveryFunction) (
 		return fixReplica
 	case recoverErrantGTIDDetectedFunc:
 		return recoverErrantGTIDDetected
+	case drainUnhealthyReplicaFunc:
+		return drainUnhealthyReplica
+	case undrainReplicaFunc:
+		return undrainReplica
 	default:
+		if recovery := getCustomRecovery(recoveryFunctionCode); recovery != nil {
+			return recovery.checkAndRecoverFunction()
+		}
 		return nil
 	}
 }
@@ -419,7 +453,14 @@ func getRecoverFunctionName(recoveryFunctionCode recoveryFunction) string {
 		return FixReplicaRecoveryName
 	case recoverErrantGTIDDetectedFunc:
 		return RecoverErrantGTIDDetectedName
+	case drainUnhealthyReplicaFunc:
+		return DrainUnhealthyReplicaRecoveryName
+	case undrainReplicaFunc:
+		return UndrainReplicaRecoveryName
 	default:
+		if recovery := getCustomRecovery(recoveryFunctionCode); recovery != nil {
+			return recovery.name
+		}
 		return ""
 	}
 }
@@ -486,6 +527,16 @@ func executeCheckAndRecoverFunction(analysisEntry *inst.ReplicationAnalysis) (er
 		return err
 	}
 
+	// A recovery the pre-recovery hook vetoed is blocked until its backoff expires,
+	// so that neither the shard lock is taken nor the hook called for it in the meantime.
+	if isActionableRecovery && isRecoveryVetoBlocked(analysisEntry, time.Now()) {
+		if util.ClearToLog("executeCheckAndRecoverFunction: vetoed", analysisEntry.AnalyzedInstanceAlias) {
+			log.Infof("executeCheckAndRecoverFunction: Analysis: %+v, Tablet: %+v: NOT Recovering host (blocked after a %v hook veto)",
+				analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias, preRecoveryHookName)
+		}
+		return nil
+	}
+
 	// We lock the shard here and then refresh the tablets information
 	ctx, unlock, err := LockShard(context.Background(), analysisEntry.AnalyzedInstanceAlias, getLockAction(analysisEntry.AnalyzedInstanceAlias, analysisEntry.Analysis))
 	if err != nil {
@@ -553,6 +604,20 @@ func executeCheckAndRecoverFunction(analysisEntry *inst.ReplicationAnalysis) (er
 		}
 	}
 
+	recoveryName := getRecoverFunctionName(checkAndRecoverFunctionCode)
+	if isActionableRecovery {
+		// The pre-recovery hook gets the last word on whether we go ahead.
+		if err := runPreRecoveryHook(ctx, recoveryName, analysisEntry); err != nil {
+			backoff := recordRecoveryVeto(analysisEntry, time.Now())
+			message := fmt.Sprintf("%v hook vetoed %v, blocked for %v: %v", preRecoveryHookName, recoveryName, backoff, err)
+			log.Warningf("executeCheckAndRecoverFunction: Analysis: %+v, Tablet: %+v: %s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias, message)
+			_ = inst.AuditOperation("recovery-vetoed", analysisEntry.AnalyzedInstanceAlias, message)
+			recoveriesVetoedCounter.Add(recoveryName, 1)
+			return nil
+		}
+		forgetRecoveryVetoes(analysisEntry)
+	}
+
 	// Actually attempt recovery:
 	if isActionableRecovery || util.ClearToLog("executeCheckAndRecoverFunction: recovery", analysisEntry.AnalyzedInstanceAlias) {
 		log.Infof("executeCheckAndRecoverFunction: proceeding with %+v recovery on %+v; isRecoverable?: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias, isActionableRecovery)
@@ -561,7 +626,9 @@ func executeCheckAndRecoverFunction(analysisEntry *inst.ReplicationAnalysis) (er
 	if !recoveryAttempted {
 		return err
 	}
-	recoveryName := getRecoverFunctionName(checkAndRecoverFunctionCode)
+	if isActionableRecovery {
+		runPostRecoveryHook(recoveryName, analysisEntry, err)
+	}
 	recoveriesCounter.Add(recoveryName, 1)
 	if err != nil {
 		recoveriesFailureCounter.Add(recoveryName, 1)
@@ -648,6 +715,10 @@ func CheckAndRecover() {
 	// intentionally iterating entries in random order
 	for _, j := range rand.Perm(len(replicationAnalysis)) {
 		analysisEntry := replicationAnalysis[j]
+		// Another VTOrc instance may have taken the shard over since we last refreshed its tablets.
+		if !isShardOwned(analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard) {
+			continue
+		}
 
 		go func() {
 			if err := executeCheckAndRecoverFunction(analysisEntry); err != nil {
@@ -821,3 +892,99 @@ func recoverErrantGTIDDetected(ctx context.Context, analysisEntry *inst.Replicat
 	err = changeTabletType(ctx, analyzedTablet, topodatapb.TabletType_DRAINED, reparentutil.IsReplicaSemiSync(durabilityPolicy, primaryTablet, analyzedTablet))
 	return true, topologyRecovery, err
 }
+
+// drainUnhealthyReplica changes the type of a replica tablet that has been lagging or not replicating for too long to DRAINED,
+// so that vtgate stops sending it queries.
+func drainUnhealthyReplica(ctx context.Context, analysisEntry *inst.ReplicationAnalysis) (recoveryAttempted bool, topologyRecovery *TopologyRecovery, err error) {
+	// Draining too many replicas would leave the shard without enough capacity to serve reads.
+	drainedCount, err := inst.ReadDrainedTabletCount(analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard)
+	if err != nil {
+		return false, nil, err
+	}
+	if drainedCount >= config.MaxDrainedReplicasPerShard() {
+		if util.ClearToLog("drainUnhealthyReplica", analysisEntry.AnalyzedInstanceAlias) {
+			log.Infof("Analysis: %v, not draining %+v: %v/%v already has %v DRAINED tablets", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias,
+				analysisEntry.AnalyzedKeyspace, analysisEntry.AnalyzedShard, drainedCount)
+		}
+		return false, nil, nil
+	}
+
+	topologyRecovery, err = AttemptRecoveryRegistration(analysisEntry)
+	if topologyRecovery == nil {
+		_ = AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another drainUnhealthyReplica.", analysisEntry.AnalyzedInstanceAlias))
+		return false, nil, err
+	}
+	log.Infof("Analysis: %v, will drain tablet %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias)
+	// This has to be done in the end; whether successful or not, we should mark that the recovery is done.
+	// So that after the active period passes, we are able to run other recoveries.
+	defer func() {
+		_ = resolveRecovery(topologyRecovery, nil)
+	}()
+
+	analyzedTablet, err := inst.ReadTablet(analysisEntry.AnalyzedInstanceAlias)
+	if err != nil {
+		return false, topologyRecovery, err
+	}
+
+	durabilityPolicy, err := inst.GetDurabilityPolicy(analyzedTablet.Keyspace)
+	if err != nil {
+		log.Infof("Could not read the durability policy for %v/%v", analyzedTablet.Keyspace, analyzedTablet.Shard)
+		return false, topologyRecovery, err
+	}
+
+	// A DRAINED tablet keeps replicating, so it keeps its replication source, but is no longer a candidate to ack semi-sync.
+	drainedTablet := proto.Clone(analyzedTablet).(*topodatapb.Tablet)
+	drainedTablet.Type = topodatapb.TabletType_DRAINED
+	primaryTablet, err := shardPrimary(analyzedTablet.Keyspace, analyzedTablet.Shard)
+	if err != nil {
+		log.Infof("Could not compute primary for %v/%v", analyzedTablet.Keyspace, analyzedTablet.Shard)
+		return false, topologyRecovery, err
+	}
+	if err = changeTabletType(ctx, analyzedTablet, topodatapb.TabletType_DRAINED, reparentutil.IsReplicaSemiSync(durabilityPolicy, primaryTablet, drainedTablet)); err != nil {
+		return true, topologyRecovery, err
+	}
+	_ = AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("drained unhealthy replica %v", analysisEntry.AnalyzedInstanceAlias))
+	err = setDrainedByVTOrc(ctx, analyzedTablet, true)
+	return true, topologyRecovery, err
+}
+
+// undrainReplica changes the type of a tablet that VTOrc drained back to REPLICA once it has recovered.
+func undrainReplica(ctx context.Context, analysisEntry *inst.ReplicationAnalysis) (recoveryAttempted bool, topologyRecovery *TopologyRecovery, err error) {
+	topologyRecovery, err = AttemptRecoveryRegistration(analysisEntry)
+	if topologyRecovery == nil {
+		_ = AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another undrainReplica.", analysisEntry.AnalyzedInstanceAlias))
+		return false, nil, err
+	}
+	log.Infof("Analysis: %v, will undrain tablet %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceAlias)
+	// This has to be done in the end; whether successful or not, we should mark that the recovery is done.
+	// So that after the active period passes, we are able to run other recoveries.
+	defer func() {
+		_ = resolveRecovery(topologyRecovery, nil)
+	}()
+
+	analyzedTablet, err := inst.ReadTablet(analysisEntry.AnalyzedInstanceAlias)
+	if err != nil {
+		return false, topologyRecovery, err
+	}
+
+	primaryTablet, err := shardPrimary(analyzedTablet.Keyspace, analyzedTablet.Shard)
+	if err != nil {
+		log.Infof("Could not compute primary for %v/%v", analyzedTablet.Keyspace, analyzedTablet.Shard)
+		return false, topologyRecovery, err
+	}
+
+	durabilityPolicy, err := inst.GetDurabilityPolicy(analyzedTablet.Keyspace)
+	if err != nil {
+		log.Infof("Could not read the durability policy for %v/%v", analyzedTablet.Keyspace, analyzedTablet.Shard)
+		return false, topologyRecovery, err
+	}
+
+	replicaTablet := proto.Clone(analyzedTablet).(*topodatapb.Tablet)
+	replicaTablet.Type = topodatapb.TabletType_REPLICA
+	if err = changeTabletType(ctx, analyzedTablet, topodatapb.TabletType_REPLICA, reparentutil.IsReplicaSemiSync(durabilityPolicy, primaryTablet, replicaTablet)); err != nil {
+		return true, topologyRecovery, err
+	}
+	_ = AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("undrained recovered replica %v", analysisEntry.AnalyzedInstanceAlias))
+	err = setDrainedByVTOrc(ctx, analyzedTablet, false)
+	return true, topologyRecovery, err
+}
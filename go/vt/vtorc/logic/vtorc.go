@@ -102,6 +102,8 @@ func closeVTOrc() {
 	_ = inst.AuditOperation("shutdown", "", "Triggered via SIGTERM")
 	// wait for the locks to be released
 	waitForLocksRelease()
+	// hand the shards we own over to the other VTOrc instances
+	stopShardOwnership()
 	ts.Close()
 	log.Infof("VTOrc closed")
 }
@@ -27,6 +27,7 @@ import (
 	"vitess.io/vitess/go/acl"
 	"vitess.io/vitess/go/vt/servenv"
 	"vitess.io/vitess/go/vt/vtorc/collection"
+	"vitess.io/vitess/go/vt/vtorc/db"
 	"vitess.io/vitess/go/vt/vtorc/discovery"
 	"vitess.io/vitess/go/vt/vtorc/inst"
 	"vitess.io/vitess/go/vt/vtorc/logic"
@@ -48,9 +49,11 @@ const (
 	databaseStateAPI              = "/api/database-state"
 	healthAPI                     = "/debug/health"
 	AggregatedDiscoveryMetricsAPI = "/api/aggregated-discovery-metrics"
+	recoveryPlanAPI               = "/api/recovery-plan"
 
 	shardWithoutKeyspaceFilteringErrorStr = "Filtering by shard without keyspace isn't supported"
 	notAValidValueForSeconds              = "Invalid value for seconds"
+	invalidSnapshotErrorStr               = "Invalid snapshot"
 )
 
 var (
@@ -64,6 +67,7 @@ var (
 		databaseStateAPI,
 		healthAPI,
 		AggregatedDiscoveryMetricsAPI,
+		recoveryPlanAPI,
 	}
 )
 
@@ -92,6 +96,8 @@ func (v *vtorcAPI) ServeHTTP(response http.ResponseWriter, request *http.Request
 		databaseStateAPIHandler(response)
 	case AggregatedDiscoveryMetricsAPI:
 		AggregatedDiscoveryMetricsAPIHandler(response, request)
+	case recoveryPlanAPI:
+		recoveryPlanAPIHandler(response, request)
 	default:
 		// This should be unreachable. Any endpoint which isn't registered is automatically redirected to /debug/status.
 		// This code will only be reachable if we register an API but don't handle it here. That will be a bug.
@@ -106,7 +112,7 @@ func getACLPermissionLevelForAPI(apiEndpoint string) string {
 		return acl.MONITORING
 	case disableGlobalRecoveriesAPI, enableGlobalRecoveriesAPI:
 		return acl.ADMIN
-	case replicationAnalysisAPI:
+	case replicationAnalysisAPI, recoveryPlanAPI:
 		return acl.MONITORING
 	case healthAPI, databaseStateAPI:
 		return acl.MONITORING
@@ -245,6 +251,42 @@ func replicationAnalysisAPIHandler(response http.ResponseWriter, request *http.R
 	returnAsJSON(response, http.StatusOK, analysis)
 }
 
+// recoveryPlanAPIHandler is the handler for the recoveryPlanAPI endpoint. It returns the recoveries
+// VTOrc would run, without running them. A POST request gets them for the snapshot in its body,
+// in the format of the databaseStateAPI, rather than for the live topology.
+func recoveryPlanAPIHandler(response http.ResponseWriter, request *http.Request) {
+	// This api also supports filtering by shard and keyspace provided.
+	shard := request.URL.Query().Get("shard")
+	keyspace := request.URL.Query().Get("keyspace")
+	if shard != "" && keyspace == "" {
+		http.Error(response, shardWithoutKeyspaceFilteringErrorStr, http.StatusBadRequest)
+		return
+	}
+
+	var snapshot db.DB
+	if request.Method == http.MethodPost {
+		var tables []db.TableState
+		if err := json.NewDecoder(request.Body).Decode(&tables); err != nil {
+			http.Error(response, fmt.Sprintf("%v: %v", invalidSnapshotErrorStr, err), http.StatusBadRequest)
+			return
+		}
+		snapshotDB, err := db.OpenSnapshot(tables)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("%v: %v", invalidSnapshotErrorStr, err), http.StatusBadRequest)
+			return
+		}
+		defer snapshotDB.Close()
+		snapshot = snapshotDB
+	}
+
+	plans, err := logic.PlanRecoveries(keyspace, shard, snapshot)
+	if err != nil {
+		http.Error(response, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	returnAsJSON(response, http.StatusOK, plans)
+}
+
 // healthAPIHandler is the handler for the healthAPI endpoint
 func healthAPIHandler(response http.ResponseWriter, request *http.Request) {
 	health, discoveredOnce := process.HealthTest()
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vttablet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SourceTagComment returns the comment that a bidirectional vreplication
+// workflow prefixes the statements it applies on the target with. MySQL
+// writes the statements, with their comments, in the ROWS_QUERY binlog
+// events, which is how the vstreamer of the other direction finds the
+// changes to skip.
+func SourceTagComment(tag string) string {
+	return "/* vrepl_source_tag=" + tag + " */ "
+}
+
+// HasSourceTag returns true if the statement is tagged with the source tag.
+func HasSourceTag(sql, tag string) bool {
+	return strings.HasPrefix(sql, SourceTagComment(tag))
+}
+
+// ValidateSourceTag returns an error if the source tag can't be used in a
+// comment.
+func ValidateSourceTag(tag string) error {
+	if strings.Contains(tag, "*/") || strings.ContainsAny(tag, " \t\r\n") {
+		return fmt.Errorf("invalid source tag %q: it must not contain whitespace or */", tag)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vttablet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceTag(t *testing.T) {
+	sql := SourceTagComment("wf1") + "insert into t1(id) values (1)"
+	assert.Equal(t, "/* vrepl_source_tag=wf1 */ insert into t1(id) values (1)", sql)
+	assert.True(t, HasSourceTag(sql, "wf1"))
+	assert.False(t, HasSourceTag(sql, "wf"))
+	assert.False(t, HasSourceTag("insert into t1(id) values (1)", "wf1"))
+
+	assert.NoError(t, ValidateSourceTag("commerce_wf-1"))
+	assert.Error(t, ValidateSourceTag("wf */ drop"))
+	assert.Error(t, ValidateSourceTag("wf 1"))
+}
@@ -377,6 +377,11 @@ func (client *FakeTabletManagerClient) RestoreFromBackup(ctx context.Context, ta
 
 // Throttler related methods
 
+// VerifyBackup is part of the tmclient.TabletManagerClient interface.
+func (client *FakeTabletManagerClient) VerifyBackup(ctx context.Context, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	return &tabletmanagerdatapb.VerifyBackupResponse{}, nil
+}
+
 func (client *FakeTabletManagerClient) CheckThrottler(ctx context.Context, tablet *topodatapb.Tablet, request *tabletmanagerdatapb.CheckThrottlerRequest) (*tabletmanagerdatapb.CheckThrottlerResponse, error) {
 	return &tabletmanagerdatapb.CheckThrottlerResponse{}, nil
 }
@@ -1285,6 +1285,20 @@ func (client *Client) RestoreFromBackup(ctx context.Context, tablet *topodatapb.
 	}, nil
 }
 
+// VerifyBackup is part of the tmclient.TabletManagerClient interface.
+func (client *Client) VerifyBackup(ctx context.Context, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	c, closer, err := client.dialer.dial(ctx, tablet)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+	response, err := c.VerifyBackup(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
 // Close is part of the tmclient.TabletManagerClient interface.
 func (client *Client) Close() {
 	client.dialer.Close()
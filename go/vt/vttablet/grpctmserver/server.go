@@ -613,6 +613,12 @@ func (s *server) RestoreFromBackup(request *tabletmanagerdatapb.RestoreFromBacku
 	return s.tm.RestoreFromBackup(ctx, logger, request)
 }
 
+func (s *server) VerifyBackup(ctx context.Context, request *tabletmanagerdatapb.VerifyBackupRequest) (response *tabletmanagerdatapb.VerifyBackupResponse, err error) {
+	defer s.tm.HandleRPCPanic(ctx, "VerifyBackup", request, response, true /*verbose*/, &err)
+	ctx = callinfo.GRPCCallInfo(ctx)
+	return s.tm.VerifyBackup(ctx, request)
+}
+
 func (s *server) CheckThrottler(ctx context.Context, request *tabletmanagerdatapb.CheckThrottlerRequest) (response *tabletmanagerdatapb.CheckThrottlerResponse, err error) {
 	defer s.tm.HandleRPCPanic(ctx, "CheckThrottler", request, response, false /*verbose*/, &err)
 	ctx = callinfo.GRPCCallInfo(ctx)
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tabletmanager
+
+import (
+	"time"
+
+	"vitess.io/vitess/go/vt/logutil"
+	"vitess.io/vitess/go/vt/mysqlctl"
+	"vitess.io/vitess/go/vt/mysqlctl/backupstats"
+	"vitess.io/vitess/go/vt/topo/topoproto"
+
+	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+)
+
+// startBinlogArchiver starts copying the binary logs to backup storage in
+// the background, if --binlog-archive-interval is set. Only the primary
+// archives, so that the archives of a shard form a single GTID chain.
+func (tm *TabletManager) startBinlogArchiver() {
+	interval := mysqlctl.BinlogArchiveInterval()
+	if interval <= 0 || tm.Cnf == nil {
+		return
+	}
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	tm._binlogArchiver = mysqlctl.NewBinlogArchiver(interval, tm.binlogArchiveParams)
+	tm._binlogArchiver.Open()
+}
+
+func (tm *TabletManager) stopBinlogArchiver() {
+	tm.mutex.Lock()
+	archiver := tm._binlogArchiver
+	tm.mutex.Unlock()
+
+	// If the archiver was running, wait for it to fully stop.
+	if archiver != nil {
+		archiver.Close()
+	}
+}
+
+// binlogArchiveParams returns the parameters of the next binary log
+// archive, and false if this tablet should not archive right now. While
+// an archive runs, it counts as the running backup of the tablet.
+func (tm *TabletManager) binlogArchiveParams() (mysqlctl.BackupParams, func(), bool) {
+	tablet := tm.Tablet()
+	if tablet.Type != topodatapb.TabletType_PRIMARY {
+		return mysqlctl.BackupParams{}, nil, false
+	}
+	if err := tm.beginBackup(backupModeOnline); err != nil {
+		// Some other backup is running; try again next time.
+		return mysqlctl.BackupParams{}, nil, false
+	}
+	params := mysqlctl.BackupParams{
+		Cnf:                  tm.Cnf,
+		Mysqld:               tm.MysqlDaemon,
+		Logger:               logutil.NewConsoleLogger(),
+		Concurrency:          1,
+		HookExtraEnv:         tm.hookExtraEnv(),
+		TopoServer:           tm.TopoServer,
+		Keyspace:             tablet.Keyspace,
+		Shard:                tablet.Shard,
+		TabletAlias:          topoproto.TabletAliasString(tablet.Alias),
+		BackupTime:           time.Now(),
+		Stats:                backupstats.BackupStats(),
+		MysqlShutdownTimeout: mysqlShutdownTimeout,
+	}
+	return params, func() { tm.endBackup(backupModeOnline) }, true
+}
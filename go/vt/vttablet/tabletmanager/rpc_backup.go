@@ -32,6 +32,7 @@ import (
 
 	tabletmanagerdatapb "vitess.io/vitess/go/vt/proto/tabletmanagerdata"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
 )
 
 const (
@@ -198,6 +199,34 @@ func (tm *TabletManager) RestoreFromBackup(ctx context.Context, logger logutil.L
 	return err
 }
 
+// VerifyBackup restores a backup of the tablet's shard into a scratch mysqld, and checks its tables.
+// The tablet keeps serving, and its own mysqld is left alone.
+func (tm *TabletManager) VerifyBackup(ctx context.Context, request *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	if tm.Cnf == nil {
+		return nil, fmt.Errorf("cannot verify backup without my.cnf, please restart vttablet with a my.cnf file specified")
+	}
+	mysqld, ok := tm.MysqlDaemon.(*mysqlctl.Mysqld)
+	if !ok {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "cannot verify backup without a local mysqld")
+	}
+	concurrency := int(request.Concurrency)
+	if concurrency <= 0 {
+		concurrency = restoreConcurrency
+	}
+	tablet := tm.Tablet()
+	return mysqlctl.VerifyBackup(ctx, mysqlctl.VerifyBackupParams{
+		Cnf:                  tm.Cnf,
+		Mysqld:               mysqld,
+		Logger:               logutil.NewConsoleLogger(),
+		Concurrency:          concurrency,
+		Keyspace:             tablet.Keyspace,
+		Shard:                tablet.Shard,
+		BackupName:           request.BackupName,
+		Stats:                backupstats.RestoreStats(),
+		MysqlShutdownTimeout: mysqlShutdownTimeout,
+	})
+}
+
 func (tm *TabletManager) beginBackup(backupMode string) error {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
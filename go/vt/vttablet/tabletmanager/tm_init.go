@@ -209,6 +209,9 @@ type TabletManager struct {
 	_lockTablesTimer      *time.Timer
 	// _isBackupRunning tells us whether there is a backup that is currently running
 	_isBackupRunning bool
+
+	// _binlogArchiver copies the binary logs to backup storage, if enabled.
+	_binlogArchiver *mysqlctl.BinlogArchiver
 }
 
 // BuildTabletFromInput builds a tablet record from input parameters.
@@ -403,6 +406,7 @@ func (tm *TabletManager) Start(tablet *topodatapb.Tablet, config *tabletenv.Tabl
 	// The following initializations don't need to be done
 	// in any specific order.
 	tm.startShardSync()
+	tm.startBinlogArchiver()
 	tm.exportStats()
 	servenv.OnRun(tm.registerTabletManager)
 
@@ -441,6 +445,7 @@ func (tm *TabletManager) Close() {
 	// running during lame duck.
 	tm.stopShardSync()
 	tm.stopRebuildKeyspace()
+	tm.stopBinlogArchiver()
 
 	// cleanup initialized fields in the tablet entry
 	f := func(tablet *topodatapb.Tablet) error {
@@ -472,6 +477,7 @@ func (tm *TabletManager) Stop() {
 	// here in addition to in Close() because tests do not call Close().
 	tm.stopShardSync()
 	tm.stopRebuildKeyspace()
+	tm.stopBinlogArchiver()
 
 	if tm.QueryServiceControl != nil {
 		tm.QueryServiceControl.Stats().Stop()
@@ -18,6 +18,7 @@ package vreplication
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -140,6 +141,9 @@ func (rp *ReplicatorPlan) buildFromFields(tableName string, lastpk *sqltypes.Res
 	if err := tpb.analyzePK(rp.ColInfoMap[tableName]); err != nil {
 		return nil, err
 	}
+	if err := tpb.analyzeConflictColumn(); err != nil {
+		return nil, err
+	}
 	return tpb.generate(), nil
 }
 
@@ -213,6 +217,13 @@ type TablePlan struct {
 	FieldsToSkip            map[string]bool
 	ConvertCharset          map[string](*binlogdatapb.CharsetConversion)
 	HasExtraSourcePkColumns bool
+	// ConflictResolution is how the row changes that conflict with the
+	// changes made on the target are resolved, in a bidirectional replication.
+	ConflictResolution binlogdatapb.ConflictResolution
+	// UpdateMatch selects the target row of an update that changed no rows,
+	// to tell whether it is a conflict. It is only set if the workflow records
+	// its conflicts.
+	UpdateMatch *sqlparser.ParsedQuery
 
 	TablePlanBuilder *tablePlanBuilder
 	// PartialInserts is a dynamically generated cache of insert ParsedQueries, which update only some columns.
@@ -362,6 +373,13 @@ func (tp *TablePlan) bindFieldVal(field *querypb.Field, val *sqltypes.Value) (*q
 }
 
 func (tp *TablePlan) applyChange(rowChange *binlogdatapb.RowChange, executor func(string) (*sqltypes.Result, error)) (*sqltypes.Result, error) {
+	if tp.ConflictResolution != binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION && tp.isPartial(rowChange) {
+		return nil, vterrors.Errorf(vtrpcpb.Code_FAILED_PRECONDITION, "conflict resolution %s requires full row images, got a partial row change for table %s", tp.ConflictResolution, tp.TargetName)
+	}
+	exec := executor
+	if tp.ConflictResolution == binlogdatapb.ConflictResolution_RECORD_CONFLICTS {
+		executor = tp.detectConflicts(executor)
+	}
 	// MakeRowTrusted is needed here because Proto3ToResult is not convenient.
 	var before, after bool
 	bindvars := make(map[string]*querypb.BindVariable, len(tp.Fields))
@@ -433,7 +451,20 @@ func (tp *TablePlan) applyChange(rowChange *binlogdatapb.RowChange, executor fun
 				tp.Stats.PartialQueryCount.Add([]string{"update"}, 1)
 				return execParsedQuery(upd, bindvars, executor)
 			} else {
-				return execParsedQuery(tp.Update, bindvars, executor)
+				qr, err := execParsedQuery(tp.Update, bindvars, executor)
+				var conflict *rowConflict
+				if errors.As(err, &conflict) && tp.UpdateMatch != nil {
+					// The update changes no rows either if the target row
+					// already has the values of the after image.
+					match, err := execParsedQuery(tp.UpdateMatch, bindvars, exec)
+					if err != nil {
+						return nil, err
+					}
+					if len(match.Rows) > 0 {
+						return &sqltypes.Result{}, nil
+					}
+				}
+				return qr, err
 			}
 		}
 		if tp.Delete != nil {
@@ -450,6 +481,38 @@ func (tp *TablePlan) applyChange(rowChange *binlogdatapb.RowChange, executor fun
 	return nil, nil
 }
 
+// rowConflict is returned by applyChange when a row change conflicts with the
+// changes made on the target, and the workflow records its conflicts.
+type rowConflict struct {
+	reason string
+}
+
+func (rc *rowConflict) Error() string {
+	return "row conflict: " + rc.reason
+}
+
+// detectConflicts wraps the executor of a row change to return a rowConflict
+// when the target row is missing, was changed, or already exists. The rows
+// that are not copied yet can't be told apart from the missing rows, so the
+// conflicts are only detected once the table is copied. An update that
+// changes no rows is checked with UpdateMatch by applyChange, since the rows
+// affected don't include the matched rows that already had the new values.
+func (tp *TablePlan) detectConflicts(executor func(string) (*sqltypes.Result, error)) func(string) (*sqltypes.Result, error) {
+	return func(sql string) (*sqltypes.Result, error) {
+		qr, err := executor(sql)
+		if err != nil {
+			if sqlErr, ok := sqlerror.NewSQLErrorFromError(err).(*sqlerror.SQLError); ok && sqlErr.Num == sqlerror.ERDupEntry {
+				return nil, &rowConflict{reason: sqlErr.Message}
+			}
+			return nil, err
+		}
+		if qr != nil && qr.RowsAffected == 0 && tp.Lastpk == nil {
+			return nil, &rowConflict{reason: "the target row is missing or was changed"}
+		}
+		return qr, nil
+	}
+}
+
 // applyBulkDeleteChanges applies a bulk DELETE statement from the row changes
 // to the target table -- which resulted from a DELETE statement executed on the
 // source that deleted N rows -- using an IN clause with the primary key values
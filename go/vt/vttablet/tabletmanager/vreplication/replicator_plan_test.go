@@ -27,8 +27,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/mysql/sqlerror"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/binlog/binlogplayer"
+	"vitess.io/vitess/go/vt/dbconfigs"
 	"vitess.io/vitess/go/vt/sqlparser"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
@@ -829,3 +832,177 @@ func TestBuildPlayerPlanExclude(t *testing.T) {
 	wantPlan, _ := json.Marshal(want)
 	assert.Equal(t, string(gotPlan), string(wantPlan))
 }
+
+func TestBuildPlayerPlanConflictResolution(t *testing.T) {
+	colInfos := map[string][]*ColumnInfo{
+		"t1": {{Name: "c1", IsPK: true}, {Name: "val"}, {Name: "ts"}},
+	}
+	testcases := []struct {
+		name       string
+		filter     string
+		resolution binlogdatapb.ConflictResolution
+		column     string
+		want       *TestTablePlan
+		err        string
+	}{{
+		name:       "last writer wins",
+		filter:     "select c1, val, ts from t1",
+		resolution: binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		column:     "ts",
+		want: &TestTablePlan{
+			TargetName:   "t1",
+			SendRule:     "t1",
+			InsertFront:  "insert into t1(c1,val,ts)",
+			InsertValues: "(:a_c1,:a_val,:a_ts)",
+			InsertOnDup:  " on duplicate key update val=if(ts<=values(ts), values(val), val), ts=if(ts<=values(ts), values(ts), ts)",
+			Insert:       "insert into t1(c1,val,ts) values (:a_c1,:a_val,:a_ts) on duplicate key update val=if(ts<=values(ts), values(val), val), ts=if(ts<=values(ts), values(ts), ts)",
+			Update:       "update t1 set val=:a_val, ts=:a_ts where c1=:b_c1 and ts<=:a_ts",
+			Delete:       "delete from t1 where c1=:b_c1 and ts<=:b_ts",
+			PKReferences: []string{"c1"},
+		},
+	}, {
+		name:       "record conflicts",
+		filter:     "select c1, val, ts from t1",
+		resolution: binlogdatapb.ConflictResolution_RECORD_CONFLICTS,
+		column:     "ts",
+		want: &TestTablePlan{
+			TargetName:   "t1",
+			SendRule:     "t1",
+			InsertFront:  "insert into t1(c1,val,ts)",
+			InsertValues: "(:a_c1,:a_val,:a_ts)",
+			Insert:       "insert into t1(c1,val,ts) values (:a_c1,:a_val,:a_ts)",
+			Update:       "update t1 set val=:a_val, ts=:a_ts where c1=:b_c1 and ts<=>:b_ts",
+			Delete:       "delete from t1 where c1=:b_c1 and ts<=>:b_ts",
+			PKReferences: []string{"c1"},
+		},
+	}, {
+		name:       "no timestamp column",
+		filter:     "select c1, val, ts from t1",
+		resolution: binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		err:        "conflict resolution LAST_WRITER_WINS requires a conflict timestamp column",
+	}, {
+		name:       "timestamp column not selected",
+		filter:     "select c1, val from t1",
+		resolution: binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		column:     "ts",
+		err:        "conflict timestamp column ts not found in table t1",
+	}, {
+		name:       "primary key timestamp column",
+		filter:     "select c1, val, ts from t1",
+		resolution: binlogdatapb.ConflictResolution_RECORD_CONFLICTS,
+		column:     "c1",
+		err:        "conflict timestamp column c1 not found in table t1",
+	}, {
+		name:       "aggregation",
+		filter:     "select c1, count(*) as val, ts from t1 group by c1, ts",
+		resolution: binlogdatapb.ConflictResolution_LAST_WRITER_WINS,
+		column:     "ts",
+		err:        "conflict resolution LAST_WRITER_WINS is not supported for aggregations",
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.name, func(t *testing.T) {
+			source := getSource(&binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{
+					Match:  "t1",
+					Filter: tcase.filter,
+				}},
+			})
+			source.ConflictResolution = tcase.resolution
+			source.ConflictTimestampColumn = tcase.column
+			vr := &vreplicator{
+				workflowConfig: vttablet.DefaultVReplicationConfig,
+			}
+			plan, err := vr.buildReplicatorPlan(source, colInfos, nil, binlogplayer.NewStats(), collations.MySQL8(), sqlparser.NewTestParser())
+			if tcase.err != "" {
+				require.ErrorContains(t, err, tcase.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tcase.resolution, plan.TablePlans["t1"].ConflictResolution)
+			assert.Nil(t, plan.TablePlans["t1"].MultiDelete)
+
+			gotPlan, _ := json.Marshal(plan.TablePlans["t1"])
+			wantPlan, _ := json.Marshal(tcase.want)
+			assert.Equal(t, string(wantPlan), string(gotPlan))
+		})
+	}
+}
+
+func TestApplyChangeRecordConflicts(t *testing.T) {
+	source := getSource(&binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match:  "t1",
+			Filter: "select c1, val, ts from t1",
+		}},
+	})
+	source.ConflictResolution = binlogdatapb.ConflictResolution_RECORD_CONFLICTS
+	source.ConflictTimestampColumn = "ts"
+	vr := &vreplicator{
+		workflowConfig: vttablet.DefaultVReplicationConfig,
+	}
+	colInfos := map[string][]*ColumnInfo{
+		"t1": {{Name: "c1", IsPK: true}, {Name: "val"}, {Name: "ts"}},
+	}
+	plan, err := vr.buildReplicatorPlan(source, colInfos, nil, binlogplayer.NewStats(), collations.MySQL8(), sqlparser.NewTestParser())
+	require.NoError(t, err)
+	tplan := plan.TablePlans["t1"]
+	tplan.Fields = sqltypes.MakeTestFields("c1|val|ts", "int64|varbinary|int64")
+	before := sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarBinary("a"), sqltypes.NewInt64(10)})
+	after := sqltypes.RowToProto3([]sqltypes.Value{sqltypes.NewInt64(1), sqltypes.NewVarBinary("b"), sqltypes.NewInt64(20)})
+	update := &binlogdatapb.RowChange{Before: before, After: after}
+	insert := &binlogdatapb.RowChange{After: after}
+
+	var queries []string
+	executor := func(qr *sqltypes.Result, err error) func(string) (*sqltypes.Result, error) {
+		return func(sql string) (*sqltypes.Result, error) {
+			queries = append(queries, sql)
+			return qr, err
+		}
+	}
+
+	_, err = tplan.applyChange(update, executor(&sqltypes.Result{RowsAffected: 1}, nil))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"update t1 set val='b', ts=20 where c1=1 and ts<=>10"}, queries)
+
+	// The target row is missing or was changed.
+	_, err = tplan.applyChange(update, executor(&sqltypes.Result{}, nil))
+	var conflict *rowConflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "the target row is missing or was changed", conflict.reason)
+
+	// The target row already has the values of the after-image. The rows
+	// affected reported by the client connection don't include it, so it is
+	// looked up to tell it apart from a conflict.
+	assert.Equal(t, "select 1 from t1 where c1=:b_c1 and ts<=>:b_ts for update", tplan.UpdateMatch.Query)
+	db := fakesqldb.New(t)
+	defer db.Close()
+	dbClient := binlogplayer.NewDBClient(dbconfigs.New(db.ConnParams()), sqlparser.NewTestParser())
+	require.NoError(t, dbClient.Connect())
+	defer dbClient.Close()
+	fetch := func(sql string) (*sqltypes.Result, error) {
+		return dbClient.ExecuteFetch(sql, 1)
+	}
+	unchanged := &binlogdatapb.RowChange{Before: after, After: after}
+	db.AddQuery("update t1 set val='b', ts=20 where c1=1 and ts<=>20", &sqltypes.Result{})
+	db.AddQuery("select 1 from t1 where c1=1 and ts<=>20 for update", sqltypes.MakeTestResult(sqltypes.MakeTestFields("1", "int64"), "1"))
+	_, err = tplan.applyChange(unchanged, fetch)
+	require.NoError(t, err)
+	db.AddQuery("select 1 from t1 where c1=1 and ts<=>20 for update", sqltypes.MakeTestResult(sqltypes.MakeTestFields("1", "int64")))
+	_, err = tplan.applyChange(unchanged, fetch)
+	require.ErrorAs(t, err, &conflict)
+
+	// The target row already exists.
+	dupErr := sqlerror.NewSQLErrorf(sqlerror.ERDupEntry, sqlerror.SSConstraintViolation, "Duplicate entry '1' for key 't1.PRIMARY'")
+	_, err = tplan.applyChange(insert, executor(nil, dupErr))
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "Duplicate entry '1' for key 't1.PRIMARY'", conflict.reason)
+
+	otherErr := sqlerror.NewSQLErrorf(sqlerror.ERLockDeadlock, sqlerror.SSLockDeadlock, "Deadlock found")
+	_, err = tplan.applyChange(insert, executor(nil, otherErr))
+	assert.ErrorIs(t, err, otherErr)
+
+	// The rows that are not copied yet are not conflicts.
+	tplan.Lastpk = sqltypes.MakeTestResult(sqltypes.MakeTestFields("c1", "int64"), "0")
+	_, err = tplan.applyChange(update, executor(&sqltypes.Result{}, nil))
+	require.NoError(t, err)
+}
@@ -60,6 +60,9 @@ type tablePlanBuilder struct {
 	stats             *binlogplayer.Stats
 	source            *binlogdatapb.BinlogSource
 	pkIndices         []bool
+	// conflictCol is the conflict timestamp column, if the workflow
+	// resolves the conflicts of a bidirectional replication.
+	conflictCol *colExpr
 
 	collationEnv   *collations.Environment
 	workflowConfig *vttablet.VReplicationConfig
@@ -135,7 +138,7 @@ const (
 func (vr *vreplicator) buildReplicatorPlan(source *binlogdatapb.BinlogSource, colInfoMap map[string][]*ColumnInfo, copyState map[string]*sqltypes.Result, stats *binlogplayer.Stats, collationEnv *collations.Environment, parser *sqlparser.Parser) (*ReplicatorPlan, error) {
 	filter := source.Filter
 	plan := &ReplicatorPlan{
-		VStreamFilter:  &binlogdatapb.Filter{FieldEventMode: filter.FieldEventMode},
+		VStreamFilter:  &binlogdatapb.Filter{FieldEventMode: filter.FieldEventMode, SkipSourceTag: filter.SkipSourceTag},
 		TargetTables:   make(map[string]*TablePlan),
 		TablePlans:     make(map[string]*TablePlan),
 		ColInfoMap:     colInfoMap,
@@ -305,6 +308,9 @@ func buildTablePlan(tableName string, rule *binlogdatapb.Rule, colInfos []*Colum
 	if err := tpb.analyzeExtraSourcePkCols(colInfos, sourceKeyTargetColumnNames); err != nil {
 		return nil, err
 	}
+	if err := tpb.analyzeConflictColumn(); err != nil {
+		return nil, planError(err, sqlparser.String(sel))
+	}
 
 	// if there are no columns being selected the select expression can be empty, so we "select 1" so we have a valid
 	// select to get a row back
@@ -371,6 +377,7 @@ func (tpb *tablePlanBuilder) generate() *TablePlan {
 		BulkInsertOnDup:         tpb.generateOnDupPart(sqlparser.NewTrackedBuffer(bvf.formatter)),
 		Insert:                  tpb.generateInsertStatement(),
 		Update:                  tpb.generateUpdateStatement(),
+		UpdateMatch:             tpb.generateUpdateMatchStatement(),
 		Delete:                  tpb.generateDeleteStatement(),
 		MultiDelete:             tpb.generateMultiDeleteStatement(),
 		PKReferences:            pkrefs,
@@ -378,6 +385,7 @@ func (tpb *tablePlanBuilder) generate() *TablePlan {
 		Stats:                   tpb.stats,
 		FieldsToSkip:            fieldsToSkip,
 		HasExtraSourcePkColumns: len(tpb.extraSourcePkCols) > 0,
+		ConflictResolution:      tpb.conflictResolution(),
 		TablePlanBuilder:        tpb,
 		PartialInserts:          make(map[string]*sqlparser.ParsedQuery, 0),
 		PartialUpdates:          make(map[string]*sqlparser.ParsedQuery, 0),
@@ -653,6 +661,36 @@ func (tpb *tablePlanBuilder) analyzeExtraSourcePkCols(colInfos []*ColumnInfo, so
 	return nil
 }
 
+// analyzeConflictColumn finds the conflict timestamp column of the table, if
+// the workflow resolves the conflicts of a bidirectional replication.
+func (tpb *tablePlanBuilder) analyzeConflictColumn() error {
+	resolution := tpb.source.GetConflictResolution()
+	if resolution == binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION {
+		return nil
+	}
+	if tpb.onInsert != insertNormal {
+		return fmt.Errorf("conflict resolution %s is not supported for aggregations", resolution)
+	}
+	name := tpb.source.GetConflictTimestampColumn()
+	if name == "" {
+		return fmt.Errorf("conflict resolution %s requires a conflict timestamp column", resolution)
+	}
+	cexpr := tpb.findCol(sqlparser.NewIdentifierCI(name))
+	if cexpr == nil || cexpr.isPK || tpb.isColumnGenerated(cexpr.colName) {
+		return fmt.Errorf("conflict timestamp column %s not found in table %s", name, tpb.name.String())
+	}
+	tpb.conflictCol = cexpr
+	return nil
+}
+
+// conflictResolution returns the conflict resolution of the table.
+func (tpb *tablePlanBuilder) conflictResolution() binlogdatapb.ConflictResolution {
+	if tpb.conflictCol == nil {
+		return binlogdatapb.ConflictResolution_NO_CONFLICT_RESOLUTION
+	}
+	return tpb.source.GetConflictResolution()
+}
+
 // findCol finds a column in a list of expressions
 func findCol(name sqlparser.IdentifierCI, exprs []*colExpr) *colExpr {
 	for _, cexpr := range exprs {
@@ -765,6 +803,9 @@ func (tpb *tablePlanBuilder) generateSelectPart(buf *sqlparser.TrackedBuffer, bv
 }
 
 func (tpb *tablePlanBuilder) generateOnDupPart(buf *sqlparser.TrackedBuffer) *sqlparser.ParsedQuery {
+	if tpb.conflictResolution() == binlogdatapb.ConflictResolution_LAST_WRITER_WINS {
+		return tpb.generateLastWriterWinsOnDupPart(buf)
+	}
 	if tpb.onInsert != insertOnDup {
 		return nil
 	}
@@ -799,6 +840,28 @@ func (tpb *tablePlanBuilder) generateOnDupPart(buf *sqlparser.TrackedBuffer) *sq
 	return buf.ParsedQuery()
 }
 
+// generateLastWriterWinsOnDupPart makes the insert of a row that already
+// exists on the target update it only if the conflict timestamp column of the
+// target row is not newer. The conflict timestamp column is assigned last,
+// because MySQL assigns the columns in order.
+func (tpb *tablePlanBuilder) generateLastWriterWinsOnDupPart(buf *sqlparser.TrackedBuffer) *sqlparser.ParsedQuery {
+	buf.Myprintf(" on duplicate key update ")
+	ts := tpb.conflictCol.colName
+	separator := ""
+	assign := func(cexpr *colExpr) {
+		buf.Myprintf("%s%v=if(%v<=values(%v), values(%v), %v)", separator, cexpr.colName, ts, ts, cexpr.colName, cexpr.colName)
+		separator = ", "
+	}
+	for _, cexpr := range tpb.colExprs {
+		if cexpr == tpb.conflictCol || cexpr.isPK || tpb.isColumnGenerated(cexpr.colName) {
+			continue
+		}
+		assign(cexpr)
+	}
+	assign(tpb.conflictCol)
+	return buf.ParsedQuery()
+}
+
 func (tpb *tablePlanBuilder) generateUpdateStatement() *sqlparser.ParsedQuery {
 	if tpb.onInsert == insertIgnore {
 		return tpb.generateInsertStatement()
@@ -848,6 +911,24 @@ func (tpb *tablePlanBuilder) generateUpdateStatement() *sqlparser.ParsedQuery {
 		}
 	}
 	tpb.generateWhere(buf, bvf)
+	tpb.generateConflictConstraint(buf, bvf, bvAfter)
+	return buf.ParsedQuery()
+}
+
+// generateUpdateMatchStatement generates the query that locks the target row
+// an update applies to, if the workflow records its conflicts. An update that
+// changes no rows either did not find the row, or found it with the values of
+// the after image already, and only the latter is not a conflict.
+func (tpb *tablePlanBuilder) generateUpdateMatchStatement() *sqlparser.ParsedQuery {
+	if tpb.conflictResolution() != binlogdatapb.ConflictResolution_RECORD_CONFLICTS || tpb.onInsert == insertIgnore {
+		return nil
+	}
+	bvf := &bindvarFormatter{}
+	buf := sqlparser.NewTrackedBuffer(bvf.formatter)
+	buf.Myprintf("select 1 from %v", tpb.name)
+	tpb.generateWhere(buf, bvf)
+	tpb.generateConflictConstraint(buf, bvf, bvAfter)
+	buf.WriteString(" for update")
 	return buf.ParsedQuery()
 }
 
@@ -858,6 +939,7 @@ func (tpb *tablePlanBuilder) generateDeleteStatement() *sqlparser.ParsedQuery {
 	case insertNormal:
 		buf.Myprintf("delete from %v", tpb.name)
 		tpb.generateWhere(buf, bvf)
+		tpb.generateConflictConstraint(buf, bvf, bvBefore)
 	case insertOnDup:
 		bvf.mode = bvBefore
 		buf.Myprintf("update %v set ", tpb.name)
@@ -886,7 +968,7 @@ func (tpb *tablePlanBuilder) generateDeleteStatement() *sqlparser.ParsedQuery {
 
 func (tpb *tablePlanBuilder) generateMultiDeleteStatement() *sqlparser.ParsedQuery {
 	if tpb.workflowConfig.ExperimentalFlags&vttablet.VReplicationExperimentalFlagVPlayerBatching == 0 ||
-		(len(tpb.pkCols)+len(tpb.extraSourcePkCols)) != 1 {
+		(len(tpb.pkCols)+len(tpb.extraSourcePkCols)) != 1 || tpb.conflictCol != nil {
 		return nil
 	}
 	return sqlparser.BuildParsedQuery("delete from %s where %s in %a",
@@ -923,6 +1005,30 @@ func (tpb *tablePlanBuilder) generateWhere(buf *sqlparser.TrackedBuffer, bvf *bi
 	}
 }
 
+// generateConflictConstraint adds the condition that resolves or detects the
+// conflicts to the where clause of an update or a delete. With
+// LAST_WRITER_WINS, the target row must not be newer than the change, which
+// has the timestamp of the after image for updates, and of the before image
+// for deletes. With RECORD_CONFLICTS, the target row must still be the one
+// that the change was made to on the source.
+func (tpb *tablePlanBuilder) generateConflictConstraint(buf *sqlparser.TrackedBuffer, bvf *bindvarFormatter, mode bindvarMode) {
+	switch tpb.conflictResolution() {
+	case binlogdatapb.ConflictResolution_LAST_WRITER_WINS:
+		buf.Myprintf(" and %v<=", tpb.conflictCol.colName)
+		bvf.mode = mode
+	case binlogdatapb.ConflictResolution_RECORD_CONFLICTS:
+		buf.Myprintf(" and %v<=>", tpb.conflictCol.colName)
+		bvf.mode = bvBefore
+	default:
+		return
+	}
+	if _, ok := tpb.conflictCol.expr.(*sqlparser.ColName); ok {
+		buf.Myprintf("%v", tpb.conflictCol.expr)
+	} else {
+		buf.Myprintf("(%v)", tpb.conflictCol.expr)
+	}
+}
+
 func (tpb *tablePlanBuilder) getCharsetAndCollation(pkname string) (charSet string, collation string) {
 	for _, colInfo := range tpb.colInfos {
 		if colInfo.IsPK && strings.EqualFold(colInfo.Name, pkname) {
@@ -1152,7 +1152,7 @@ func (vbc *vcopierCopyWorker) insertRows(ctx context.Context, rows []*querypb.Ro
 		&vbc.sqlbuffer,
 		rows,
 		func(sql string) (*sqltypes.Result, error) {
-			return vbc.vdbClient.ExecuteWithRetry(ctx, sql)
+			return vbc.vdbClient.ExecuteWithRetry(ctx, tagQuery(vbc.tablePlan.TablePlanBuilder.source, sql))
 		},
 	)
 }
@@ -18,6 +18,7 @@ package vreplication
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -26,18 +27,25 @@ import (
 	"strings"
 	"time"
 
+	"vitess.io/vitess/go/constants/sidecar"
 	"vitess.io/vitess/go/mysql/replication"
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/binlog/binlogplayer"
 	"vitess.io/vitess/go/vt/log"
+	"vitess.io/vitess/go/vt/sqlparser"
 	"vitess.io/vitess/go/vt/vterrors"
 	vttablet "vitess.io/vitess/go/vt/vttablet/common"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/throttlerapp"
 
 	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
 )
 
-const failedToRecordHeartbeatMsg = "failed to record heartbeat"
+const (
+	failedToRecordHeartbeatMsg = "failed to record heartbeat"
+
+	sqlInsertVReplicationConflict = "insert into %s.vreplication_conflicts(vrepl_id, table_name, change_type, before_row, after_row, reason) values (%a, %a, %a, %a, %a, %a)"
+)
 
 var (
 	// At what point should we consider the vplayer to be stalled and return an error.
@@ -133,7 +141,10 @@ func newVPlayer(vr *vreplicator, settings binlogplayer.VRSettings, copyState map
 		return vr.dbClient.Commit()
 	}
 	batchMode := false
-	if vr.workflowConfig.ExperimentalFlags&vttablet.VReplicationExperimentalFlagVPlayerBatching != 0 {
+	// The conflicts are detected from the results of the row changes, which
+	// are not known until the batch is committed.
+	if vr.workflowConfig.ExperimentalFlags&vttablet.VReplicationExperimentalFlagVPlayerBatching != 0 &&
+		vr.source.ConflictResolution != binlogdatapb.ConflictResolution_RECORD_CONFLICTS {
 		batchMode = true
 	}
 	if batchMode {
@@ -351,7 +362,7 @@ func (vp *vplayer) applyRowEvent(ctx context.Context, rowEvent *binlogdatapb.Row
 	applyFunc := func(sql string) (*sqltypes.Result, error) {
 		stats := NewVrLogStats("ROWCHANGE")
 		start := time.Now()
-		qr, err := vp.query(ctx, sql)
+		qr, err := vp.query(ctx, tagQuery(vp.vr.source, sql))
 		vp.vr.stats.QueryCount.Add(vp.phase, 1)
 		vp.vr.stats.QueryTimings.Record(vp.phase, start)
 		stats.Send(sql)
@@ -377,13 +388,80 @@ func (vp *vplayer) applyRowEvent(ctx context.Context, rowEvent *binlogdatapb.Row
 
 	for _, change := range rowEvent.RowChanges {
 		if _, err := tplan.applyChange(change, applyFunc); err != nil {
-			return err
+			var conflict *rowConflict
+			if !errors.As(err, &conflict) {
+				return err
+			}
+			if err := vp.recordConflict(ctx, tplan, change, conflict); err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// recordConflict saves a row change that conflicts with the changes made on
+// the target to the vreplication_conflicts table, instead of applying it. The
+// conflict is saved in the transaction of the change, along with the position.
+func (vp *vplayer) recordConflict(ctx context.Context, tplan *TablePlan, change *binlogdatapb.RowChange, conflict *rowConflict) error {
+	changeType := "update"
+	switch {
+	case change.Before == nil:
+		changeType = "insert"
+	case change.After == nil:
+		changeType = "delete"
+	}
+	before, err := conflictRowBindVar(tplan.Fields, change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := conflictRowBindVar(tplan.Fields, change.After)
+	if err != nil {
+		return err
+	}
+	query, err := sqlparser.BuildParsedQuery(sqlInsertVReplicationConflict, sidecar.GetIdentifier(),
+		":vrepl_id", ":table_name", ":change_type", ":before_row", ":after_row", ":reason",
+	).GenerateQuery(map[string]*querypb.BindVariable{
+		"vrepl_id":    sqltypes.Int32BindVariable(vp.vr.id),
+		"table_name":  sqltypes.StringBindVariable(tplan.TargetName),
+		"change_type": sqltypes.StringBindVariable(changeType),
+		"before_row":  before,
+		"after_row":   after,
+		"reason":      sqltypes.StringBindVariable(conflict.reason),
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := vp.query(ctx, query); err != nil {
+		return vterrors.Wrapf(err, "failed to record the conflict of a row change on table %s", tplan.TargetName)
+	}
+	vp.vr.stats.ErrorCounts.Add([]string{"Conflict"}, 1)
+	return nil
+}
+
+// conflictRowBindVar returns the row as a JSON object of the column values,
+// or NULL if there is no row.
+func conflictRowBindVar(fields []*querypb.Field, row *querypb.Row) (*querypb.BindVariable, error) {
+	if row == nil {
+		return sqltypes.NullBindVariable, nil
+	}
+	vals := sqltypes.MakeRowTrusted(fields, row)
+	obj := make(map[string]any, len(fields))
+	for i, field := range fields {
+		if vals[i].IsNull() {
+			obj[field.Name] = nil
+			continue
+		}
+		obj[field.Name] = vals[i].ToString()
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	return sqltypes.StringBindVariable(string(data)), nil
+}
+
 // updatePos should get called at a minimum of vreplicationMinimumHeartbeatUpdateInterval.
 func (vp *vplayer) updatePos(ctx context.Context, ts int64) (posReached bool, err error) {
 	update := binlogplayer.GenerateUpdatePos(vp.vr.id, vp.pos, time.Now().Unix(), ts, vp.vr.stats.CopyRowCount.Get(), vp.vr.workflowConfig.StoreCompressedGTID)
@@ -768,7 +846,7 @@ func (vp *vplayer) applyEvent(ctx context.Context, event *binlogdatapb.VEvent, m
 			// So, we apply the DDL first, and then save the position.
 			// Manual intervention may be needed if there is a partial
 			// failure here.
-			if _, err := vp.query(ctx, event.Statement); err != nil {
+			if _, err := vp.query(ctx, tagQuery(vp.vr.source, event.Statement)); err != nil {
 				return err
 			}
 			stats.Send(fmt.Sprintf("%v", event.Statement))
@@ -780,7 +858,7 @@ func (vp *vplayer) applyEvent(ctx context.Context, event *binlogdatapb.VEvent, m
 				return io.EOF
 			}
 		case binlogdatapb.OnDDLAction_EXEC_IGNORE:
-			if _, err := vp.query(ctx, event.Statement); err != nil {
+			if _, err := vp.query(ctx, tagQuery(vp.vr.source, event.Statement)); err != nil {
 				log.Infof("Ignoring error: %v for DDL: %s", err, event.Statement)
 			}
 			stats.Send(fmt.Sprintf("%v", event.Statement))
@@ -238,6 +238,9 @@ func (vr *vreplicator) replicate(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if err := vr.enableRowsQueryLogEvents(vr.dbClient); err != nil {
+		return err
+	}
 
 	colInfo, err := vr.buildColInfoMap(ctx)
 	if err != nil {
@@ -614,6 +617,32 @@ func (vr *vreplicator) setSQLMode(ctx context.Context, dbClient *vdbClient) (fun
 	return resetFunc, nil
 }
 
+// enableRowsQueryLogEvents makes MySQL log the statements that apply the row
+// changes along with the row events, if the workflow tags its statements with
+// a source tag. The peer workflow of a bidirectional replication reads the tag
+// to skip the changes that it replicated itself.
+func (vr *vreplicator) enableRowsQueryLogEvents(dbClient *vdbClient) error {
+	if vr.source.SourceTag == "" {
+		return nil
+	}
+	if err := vttablet.ValidateSourceTag(vr.source.SourceTag); err != nil {
+		return err
+	}
+	if _, err := dbClient.Execute("set @@session.binlog_rows_query_log_events=1"); err != nil {
+		return vterrors.Wrap(err, "failed to enable binlog_rows_query_log_events")
+	}
+	return nil
+}
+
+// tagQuery prefixes the statement with the source tag of the workflow, if it
+// has one.
+func tagQuery(source *binlogdatapb.BinlogSource, sql string) string {
+	if source.GetSourceTag() == "" {
+		return sql
+	}
+	return vttablet.SourceTagComment(source.SourceTag) + sql
+}
+
 // throttlerAppName returns the app name to be used by throttlerClient for this particular workflow
 // example results:
 //   - "vreplication" for most flows
@@ -1117,6 +1146,9 @@ func (vr *vreplicator) newClientConnection(ctx context.Context) (*vdbClient, err
 	if _, err := vr.setSQLMode(ctx, dbClient); err != nil {
 		return nil, vterrors.Wrap(err, "failed to set sql_mode")
 	}
+	if err := vr.enableRowsQueryLogEvents(dbClient); err != nil {
+		return nil, err
+	}
 	if err := vr.clearFKCheck(dbClient); err != nil {
 		return nil, vterrors.Wrap(err, "failed to clear foreign key check")
 	}
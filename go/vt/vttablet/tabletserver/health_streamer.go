@@ -20,11 +20,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/spf13/pflag"
+	"google.golang.org/protobuf/proto"
 
 	vtschema "vitess.io/vitess/go/vt/schema"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/schema"
@@ -80,6 +82,9 @@ type healthStreamer struct {
 	signalWhenSchemaChange bool
 
 	viewsEnabled bool
+
+	// tableStatistics are the last table statistics sent to the clients.
+	tableStatistics []*querypb.TableStatistics
 }
 
 func newHealthStreamer(env tabletenv.Env, alias *topodatapb.TabletAlias, engine *schema.Engine) *healthStreamer {
@@ -168,8 +173,11 @@ func (hs *healthStreamer) register() (chan *querypb.StreamHealthResponse, contex
 	ch := make(chan *querypb.StreamHealthResponse, streamHealthBufferSize)
 	hs.clients[ch] = struct{}{}
 
-	// Send the current state immediately.
+	// Send the current state immediately, along with the table statistics
+	// that were broadcast before this client registered.
+	hs.state.RealtimeStats.TableStatistics = hs.tableStatistics
 	ch <- hs.state.CloneVT()
+	hs.state.RealtimeStats.TableStatistics = nil
 	return ch, hs.ctx
 }
 
@@ -331,22 +339,46 @@ func (hs *healthStreamer) reload(created, altered, dropped []*schema.Table, udfs
 		}
 	}
 
+	statsChanged := hs.updateTableStatistics()
+
 	// no change detected
-	if len(tables) == 0 && len(views) == 0 && !udfsChanged {
+	if len(tables) == 0 && len(views) == 0 && !udfsChanged && !statsChanged {
 		return nil
 	}
 
 	hs.state.RealtimeStats.TableSchemaChanged = tables
 	hs.state.RealtimeStats.ViewSchemaChanged = views
 	hs.state.RealtimeStats.UdfsChanged = udfsChanged
+	if statsChanged {
+		hs.state.RealtimeStats.TableStatistics = hs.tableStatistics
+	}
 	shr := hs.state.CloneVT()
 	hs.broadCastToClients(shr)
 	hs.state.RealtimeStats.TableSchemaChanged = nil
 	hs.state.RealtimeStats.ViewSchemaChanged = nil
 	hs.state.RealtimeStats.UdfsChanged = false
+	hs.state.RealtimeStats.TableStatistics = nil
 	return nil
 }
 
+// updateTableStatistics reads the table statistics from MySQL and reports whether
+// they changed since they were last broadcast. The statistics only guide the query
+// planning of the vtgates, so failing to read them is not an error.
+func (hs *healthStreamer) updateTableStatistics() bool {
+	tableStats, err := hs.se.TableStatistics(context.Background())
+	if err != nil {
+		log.Warningf("could not read the table statistics: %v", err)
+		return false
+	}
+	if slices.EqualFunc(hs.tableStatistics, tableStats, func(a, b *querypb.TableStatistics) bool {
+		return proto.Equal(a, b)
+	}) {
+		return false
+	}
+	hs.tableStatistics = tableStats
+	return true
+}
+
 // sendUnresolvedTransactionSignal sends broadcast message about unresolved transactions.
 func (hs *healthStreamer) sendUnresolvedTransactionSignal() {
 	hs.mu.Lock()
@@ -32,6 +32,7 @@ import (
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/mysql/fakesqldb"
 	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
 	querypb "vitess.io/vitess/go/vt/proto/query"
 	topodatapb "vitess.io/vitess/go/vt/proto/topodata"
 	"vitess.io/vitess/go/vt/vtenv"
@@ -264,6 +265,16 @@ func TestReloadSchema(t *testing.T) {
 				"product|id",
 				"users|id",
 			))
+			db.AddQuery(mysql.ShowTableRowCounts, sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("table_name|table_rows", "varchar|uint64"),
+				"product|1000",
+				"users|10",
+			))
+			db.AddQuery(mysql.ShowIndexCardinalities, sqltypes.MakeTestResult(
+				sqltypes.MakeTestFields("table_name|index_name|column_name|cardinality", "varchar|varchar|varchar|int64"),
+				"product|PRIMARY|id|1000",
+				"users|PRIMARY|id|10",
+			))
 
 			se.InitDBConfig(cfg.DB.DbaWithDB())
 			hs.Open()
@@ -298,12 +309,19 @@ func TestReloadSchema(t *testing.T) {
 
 			var wg sync.WaitGroup
 			wg.Add(1)
+			tableStats := make(chan []*querypb.TableStatistics, 1)
 			go func() {
 				hs.Stream(ctx, func(response *querypb.StreamHealthResponse) error {
 					if response.RealtimeStats.TableSchemaChanged != nil {
 						assert.Equal(t, []string{"product", "users"}, response.RealtimeStats.TableSchemaChanged)
 						wg.Done()
 					}
+					if response.RealtimeStats.TableStatistics != nil {
+						select {
+						case tableStats <- response.RealtimeStats.TableStatistics:
+						default:
+						}
+					}
 					return nil
 				})
 			}()
@@ -321,6 +339,24 @@ func TestReloadSchema(t *testing.T) {
 			}
 
 			require.Equal(t, testcase.enableSchemaChange, !timeout, "If schema change tracking is enabled, then we shouldn't time out, otherwise we should")
+			if !testcase.enableSchemaChange {
+				return
+			}
+			// The table statistics are sent along with the schema changes.
+			select {
+			case stats := <-tableStats:
+				utils.MustMatch(t, []*querypb.TableStatistics{{
+					Name:     "product",
+					RowCount: 1000,
+					Indexes:  []*querypb.IndexStatistics{{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{1000}}},
+				}, {
+					Name:     "users",
+					RowCount: 10,
+					Indexes:  []*querypb.IndexStatistics{{Name: "PRIMARY", Columns: []string{"id"}, Cardinality: []uint64{10}}},
+				}}, stats)
+			case <-time.After(1 * time.Second):
+				t.Fatal("timed out waiting for the table statistics")
+			}
 		})
 	}
 }
@@ -384,6 +420,8 @@ func TestReloadView(t *testing.T) {
 	db.AddQuery(mysql.BaseShowPrimary, sqltypes.MakeTestResult(
 		sqltypes.MakeTestFields("table_name | column_name", "varchar|varchar"),
 	))
+	db.AddQuery(mysql.ShowTableRowCounts, &sqltypes.Result{})
+	db.AddQuery(mysql.ShowIndexCardinalities, &sqltypes.Result{})
 	db.AddQueryPattern(".*SELECT table_name, view_definition.*views.*", &sqltypes.Result{})
 	db.AddQuery("SELECT TABLE_NAME, CREATE_TIME FROM _vt.`tables`", &sqltypes.Result{})
 	// adding query pattern for udfs
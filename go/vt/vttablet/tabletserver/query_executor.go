@@ -66,6 +66,10 @@ type QueryExecutor struct {
 	// The target type we requested might be different from tsv's tablet type, if we had a change to the tablet type recently.
 	targetTabletType topodatapb.TabletType
 	setting          *smartconnpool.Setting
+	// rewrites are the query rules that add optimizer hints to the query.
+	rewrites []*rules.Rule
+	// throttled gives back the concurrency slots taken by the query rules that throttle the query.
+	throttled []func()
 }
 
 const (
@@ -150,6 +154,7 @@ func (qre *QueryExecutor) Execute() (reply *sqltypes.Result, err error) {
 		qre.tsv.Stats().ResultHistogram.Add(int64(len(reply.Rows)))
 	}(time.Now())
 
+	defer qre.releaseThrottled()
 	if err = qre.checkPermissions(); err != nil {
 		return nil, err
 	}
@@ -325,6 +330,7 @@ func (qre *QueryExecutor) Stream(callback StreamCallback) error {
 		qre.recordUserQuery("Stream", int64(time.Since(start)))
 	}(time.Now())
 
+	defer qre.releaseThrottled()
 	if err := qre.checkPermissions(); err != nil {
 		return err
 	}
@@ -415,6 +421,7 @@ func (qre *QueryExecutor) MessageStream(callback StreamCallback) error {
 		qre.recordUserQuery("MessageStream", int64(time.Since(start)))
 	}(time.Now())
 
+	defer qre.releaseThrottled()
 	if err := qre.checkPermissions(); err != nil {
 		return err
 	}
@@ -476,6 +483,21 @@ func (qre *QueryExecutor) checkPermissions() error {
 	default:
 		// no rules against this query. Good to proceed
 	}
+
+	throttles, rewrites := qre.plan.Rules.GetThrottlesAndRewrites(remoteAddr, username, qre.bindVars, qre.marginComments)
+	if len(throttles) > 0 {
+		startTime := time.Now()
+		for _, qr := range throttles {
+			release, err := qr.Throttle(qre.ctx)
+			if err != nil {
+				return err
+			}
+			qre.throttled = append(qre.throttled, release)
+		}
+		qre.tsv.stats.WaitTimings.Record("QueryRuleThrottle", startTime)
+	}
+	qre.rewrites = rewrites
+
 	// Skip ACL check for queries against the dummy dual table
 	if qre.plan.TableName().String() == "dual" {
 		return nil
@@ -510,6 +532,14 @@ func (qre *QueryExecutor) checkPermissions() error {
 	return nil
 }
 
+// releaseThrottled gives back the concurrency slots taken by the query rules that throttled the query.
+func (qre *QueryExecutor) releaseThrottled() {
+	for _, release := range qre.throttled {
+		release()
+	}
+	qre.throttled = nil
+}
+
 func (qre *QueryExecutor) checkAccess(authorized *tableacl.ACLResult, tableName string, callerID *querypb.VTGateCallerID) error {
 	statsKey := []string{tableName, authorized.GroupName, qre.plan.PlanID.String(), callerID.Username}
 	if !authorized.IsMember(callerID) {
@@ -806,6 +836,9 @@ func (qre *QueryExecutor) generateFinalSQL(parsedQuery *sqlparser.ParsedQuery, b
 	if err != nil {
 		return "", "", vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "%s", err)
 	}
+	if len(qre.rewrites) > 0 {
+		query = rules.RewriteQuery(query, qre.rewrites)
+	}
 	if qre.tsv.config.AnnotateQueries {
 		username := callerid.GetPrincipal(callerid.EffectiveCallerIDFromContext(qre.ctx))
 		if username == "" {
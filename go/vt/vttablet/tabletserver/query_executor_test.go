@@ -1253,6 +1253,81 @@ func TestQueryExecutorDenyListQRRetry(t *testing.T) {
 	}
 }
 
+func TestQueryExecutorQueryRuleRewrite(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table where pk = 1 limit 1000"
+	rewrittenQuery := "select /*+ MAX_EXECUTION_TIME(1000) NO_INDEX_MERGE(test_table) */ * from test_table where pk = 1 limit 1000"
+	want := &sqltypes.Result{
+		Fields: getTestTableFields(),
+	}
+	db.AddQuery(rewrittenQuery, want)
+
+	rewriteRule := rules.NewQueryRule("rewrite select", "rewrite select", rules.QRRewrite)
+	rewriteRule.SetRewrite("NO_INDEX_MERGE(test_table)", 1000)
+	rewriteRule.AddTableCond("test_table")
+
+	rulesName := "rewriteRules"
+	qrs := rules.New()
+	qrs.Add(rewriteRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	require.NoError(t, tsv.qe.queryRuleSources.SetRules(rulesName, qrs))
+
+	qre := newTestQueryExecutor(ctx, tsv, query, 0)
+	got, err := qre.Execute()
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.EqualValues(t, 1, db.GetQueryCalledNum(rewrittenQuery))
+}
+
+func TestQueryExecutorQueryRuleThrottle(t *testing.T) {
+	db := setUpQueryExecutorTest(t)
+	defer db.Close()
+	query := "select * from test_table where pk = 1 limit 1000"
+	want := &sqltypes.Result{
+		Fields: getTestTableFields(),
+	}
+	db.AddQuery(query, want)
+
+	throttleRule := rules.NewQueryRule("throttle select", "throttle select", rules.QRThrottle)
+	throttleRule.SetThrottle(0, 1)
+	throttleRule.AddTableCond("test_table")
+
+	rulesName := "throttleRules"
+	qrs := rules.New()
+	qrs.Add(throttleRule)
+
+	ctx := context.Background()
+	tsv := newTestTabletServer(ctx, noFlags, db)
+	defer tsv.StopService()
+	tsv.qe.queryRuleSources.RegisterSource(rulesName)
+	defer tsv.qe.queryRuleSources.UnRegisterSource(rulesName)
+	require.NoError(t, tsv.qe.queryRuleSources.SetRules(rulesName, qrs))
+
+	// the only concurrency slot of the rule is taken, so the query waits until it times out
+	release, err := throttleRule.Throttle(ctx)
+	require.NoError(t, err)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	qre := newTestQueryExecutor(timeoutCtx, tsv, query, 0)
+	_, err = qre.Execute()
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+
+	// once the slot is free, the query goes through and gives the slot back when it is done
+	release()
+	for i := 0; i < 2; i++ {
+		qre = newTestQueryExecutor(ctx, tsv, query, 0)
+		got, err := qre.Execute()
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+}
+
 func TestReplaceSchemaName(t *testing.T) {
 	db := setUpQueryExecutorTest(t)
 	defer db.Close()
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rules
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang.org/x/time/rate"
+
+	"vitess.io/vitess/go/vt/vterrors"
+
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// ruleLimiter holds the state of the limits of a THROTTLE rule.
+type ruleLimiter struct {
+	// qps is nil if the rate of queries is not limited.
+	qps *rate.Limiter
+	// slots is nil if the number of concurrent queries is not limited.
+	slots chan struct{}
+}
+
+func newRuleLimiter(maxQPS float64, maxConcurrency int) *ruleLimiter {
+	rl := &ruleLimiter{}
+	if maxQPS > 0 {
+		// allow a burst of one second worth of queries, and at least one query
+		rl.qps = rate.NewLimiter(rate.Limit(maxQPS), max(1, int(maxQPS)))
+	}
+	if maxConcurrency > 0 {
+		rl.slots = make(chan struct{}, maxConcurrency)
+	}
+	return rl
+}
+
+// Throttle waits until a THROTTLE rule lets the query through, that is until the query fits in
+// both the rate and the concurrency limits of the rule. The returned function gives back the
+// concurrency slot taken by the query, and must be called once the query is done.
+// Throttle fails if the query cannot get through before its context is done.
+func (qr *Rule) Throttle(ctx context.Context) (release func(), err error) {
+	rl := qr.limiter
+	if rl == nil {
+		return func() {}, nil
+	}
+	if rl.qps != nil {
+		if err := rl.qps.Wait(ctx); err != nil {
+			return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "throttled due to rule: %s", qr.Description)
+		}
+	}
+	if rl.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case rl.slots <- struct{}{}:
+		return func() { <-rl.slots }, nil
+	case <-ctx.Done():
+		return nil, vterrors.Errorf(vtrpcpb.Code_RESOURCE_EXHAUSTED, "throttled due to rule: %s", qr.Description)
+	}
+}
+
+// RewriteQuery adds the optimizer hints of the given REWRITE rules to a query.
+// MySQL only recognizes optimizer hints right after the first keyword of a statement, so the
+// hints are merged into the optimizer hint comment the query may already have there. Queries that
+// do not start with SELECT, INSERT, REPLACE, UPDATE or DELETE are returned unchanged, and
+// MAX_EXECUTION_TIME is only added to SELECT statements, the only ones it applies to.
+func RewriteQuery(query string, rewrites []*Rule) string {
+	keyword, rest, _ := strings.Cut(query, " ")
+	var isSelect bool
+	switch strings.ToLower(keyword) {
+	case "select":
+		isSelect = true
+	case "insert", "replace", "update", "delete":
+	default:
+		return query
+	}
+
+	var hints []string
+	var maxExecutionTime int64
+	for _, qr := range rewrites {
+		// with several time limits, the shortest one wins
+		if qr.maxExecutionTime > 0 && (maxExecutionTime == 0 || qr.maxExecutionTime < maxExecutionTime) {
+			maxExecutionTime = qr.maxExecutionTime
+		}
+		if qr.commentHint != "" {
+			hints = append(hints, qr.commentHint)
+		}
+	}
+	if isSelect && maxExecutionTime > 0 {
+		hints = append([]string{"MAX_EXECUTION_TIME(" + strconv.FormatInt(maxExecutionTime, 10) + ")"}, hints...)
+	}
+	if len(hints) == 0 {
+		return query
+	}
+
+	var buf strings.Builder
+	buf.Grow(len(query) + 8 + len(hints)*32)
+	buf.WriteString(keyword)
+	buf.WriteString(" /*+ ")
+	buf.WriteString(strings.Join(hints, " "))
+	if existing, ok := strings.CutPrefix(rest, "/*+ "); ok {
+		buf.WriteString(" ")
+		buf.WriteString(existing)
+	} else {
+		buf.WriteString(" */ ")
+		buf.WriteString(rest)
+	}
+	return buf.String()
+}
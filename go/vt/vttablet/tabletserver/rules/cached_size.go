@@ -45,7 +45,7 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 	}
 	size := int64(0)
 	if alloc {
-		size += int64(256)
+		size += int64(320)
 	}
 	// field Description string
 	size += hack.RuntimeAllocSize(int64(len(cached.Description)))
@@ -79,6 +79,10 @@ func (cached *Rule) CachedSize(alloc bool) int64 {
 			size += elem.CachedSize(false)
 		}
 	}
+	// field limiter *vitess.io/vitess/go/vt/vttablet/tabletserver/rules.ruleLimiter
+	size += cached.limiter.CachedSize(true)
+	// field commentHint string
+	size += hack.RuntimeAllocSize(int64(len(cached.commentHint)))
 	return size
 }
 func (cached *Rules) CachedSize(alloc bool) int64 {
@@ -128,3 +132,17 @@ func (cached *namedRegexp) CachedSize(alloc bool) int64 {
 	}
 	return size
 }
+func (cached *ruleLimiter) CachedSize(alloc bool) int64 {
+	if cached == nil {
+		return int64(0)
+	}
+	size := int64(0)
+	if alloc {
+		size += int64(16)
+	}
+	// field qps *golang.org/x/time/rate.Limiter
+	if cached.qps != nil {
+		size += hack.RuntimeAllocSize(int64(80))
+	}
+	return size
+}
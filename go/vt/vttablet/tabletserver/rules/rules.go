@@ -24,6 +24,7 @@ import (
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"vitess.io/vitess/go/sqltypes"
@@ -170,6 +171,7 @@ func (qrs *Rules) FilterByPlan(query string, planid planbuilder.PlanType, tableN
 }
 
 // GetAction runs the input against the rules engine and returns the action to be performed.
+// Rules that throttle or rewrite queries are not considered, see GetThrottlesAndRewrites.
 func (qrs *Rules) GetAction(
 	ip,
 	user string,
@@ -181,13 +183,35 @@ func (qrs *Rules) GetAction(
 	timeout time.Duration,
 	desc string) {
 	for _, qr := range qrs.rules {
-		if act := qr.GetAction(ip, user, bindVars, marginComments); act != QRContinue {
+		switch act := qr.GetAction(ip, user, bindVars, marginComments); act {
+		case QRContinue, QRThrottle, QRRewrite:
+		default:
 			return act, qr.cancelCtx, qr.timeout, qr.Description
 		}
 	}
 	return QRContinue, nil, 0, ""
 }
 
+// GetThrottlesAndRewrites runs the input against the rules engine and returns the rules
+// that throttle or rewrite the query. Unlike the other actions, these do not stop the
+// evaluation of the rules: all the matching rules apply to the query.
+func (qrs *Rules) GetThrottlesAndRewrites(
+	ip,
+	user string,
+	bindVars map[string]*querypb.BindVariable,
+	marginComments sqlparser.MarginComments,
+) (throttles, rewrites []*Rule) {
+	for _, qr := range qrs.rules {
+		switch qr.GetAction(ip, user, bindVars, marginComments) {
+		case QRThrottle:
+			throttles = append(throttles, qr)
+		case QRRewrite:
+			rewrites = append(rewrites, qr)
+		}
+	}
+	return throttles, rewrites
+}
+
 // -----------------------------------------------
 
 // Rule represents one rule (conditions-action).
@@ -224,6 +248,17 @@ type Rule struct {
 
 	// a rule can timeout.
 	timeout time.Duration
+
+	// a THROTTLE rule caps the rate and the number of concurrent queries it lets through.
+	// Zero means no limit. The limiter is shared by all the copies of the rule.
+	maxQPS         float64
+	maxConcurrency int
+	limiter        *ruleLimiter
+
+	// a REWRITE rule adds optimizer hints to the query: an arbitrary hint,
+	// and a MAX_EXECUTION_TIME in milliseconds for SELECT statements.
+	commentHint      string
+	maxExecutionTime int64
 }
 
 type namedRegexp struct {
@@ -269,6 +304,10 @@ func (qr *Rule) Equal(other *Rule) bool {
 		qr.leadingComment.Equal(other.leadingComment) &&
 		qr.trailingComment.Equal(other.trailingComment) &&
 		qr.timeout == other.timeout &&
+		qr.maxQPS == other.maxQPS &&
+		qr.maxConcurrency == other.maxConcurrency &&
+		qr.commentHint == other.commentHint &&
+		qr.maxExecutionTime == other.maxExecutionTime &&
 		reflect.DeepEqual(qr.plans, other.plans) &&
 		reflect.DeepEqual(qr.tableNames, other.tableNames) &&
 		reflect.DeepEqual(qr.bindVarConds, other.bindVarConds) &&
@@ -278,16 +317,21 @@ func (qr *Rule) Equal(other *Rule) bool {
 // Copy performs a deep copy of a Rule.
 func (qr *Rule) Copy() (newqr *Rule) {
 	newqr = &Rule{
-		Description:     qr.Description,
-		Name:            qr.Name,
-		requestIP:       qr.requestIP,
-		user:            qr.user,
-		query:           qr.query,
-		leadingComment:  qr.leadingComment,
-		trailingComment: qr.trailingComment,
-		act:             qr.act,
-		cancelCtx:       qr.cancelCtx,
-		timeout:         qr.timeout,
+		Description:      qr.Description,
+		Name:             qr.Name,
+		requestIP:        qr.requestIP,
+		user:             qr.user,
+		query:            qr.query,
+		leadingComment:   qr.leadingComment,
+		trailingComment:  qr.trailingComment,
+		act:              qr.act,
+		cancelCtx:        qr.cancelCtx,
+		timeout:          qr.timeout,
+		maxQPS:           qr.maxQPS,
+		maxConcurrency:   qr.maxConcurrency,
+		limiter:          qr.limiter,
+		commentHint:      qr.commentHint,
+		maxExecutionTime: qr.maxExecutionTime,
 	}
 	if qr.plans != nil {
 		newqr.plans = make([]planbuilder.PlanType, len(qr.plans))
@@ -339,6 +383,18 @@ func (qr *Rule) MarshalJSON() ([]byte, error) {
 	if qr.timeout != 0 {
 		safeEncode(b, `,"Timeout":`, qr.timeout)
 	}
+	if qr.maxQPS != 0 {
+		safeEncode(b, `,"MaxQPS":`, qr.maxQPS)
+	}
+	if qr.maxConcurrency != 0 {
+		safeEncode(b, `,"MaxConcurrency":`, qr.maxConcurrency)
+	}
+	if qr.commentHint != "" {
+		safeEncode(b, `,"CommentHint":`, qr.commentHint)
+	}
+	if qr.maxExecutionTime != 0 {
+		safeEncode(b, `,"MaxExecutionTime":`, qr.maxExecutionTime)
+	}
 	_, _ = b.WriteString("}")
 	return b.Bytes(), nil
 }
@@ -394,6 +450,21 @@ func (qr *Rule) SetTrailingCommentCond(pattern string) (err error) {
 	return
 }
 
+// SetThrottle sets the limits of a THROTTLE rule: the maximum rate of queries per second,
+// and the maximum number of concurrent queries. Zero means no limit.
+func (qr *Rule) SetThrottle(maxQPS float64, maxConcurrency int) {
+	qr.maxQPS = maxQPS
+	qr.maxConcurrency = maxConcurrency
+	qr.limiter = newRuleLimiter(maxQPS, maxConcurrency)
+}
+
+// SetRewrite sets the optimizer hints a REWRITE rule adds to the query: an arbitrary
+// comment hint, and a MAX_EXECUTION_TIME in milliseconds. Empty values are not added.
+func (qr *Rule) SetRewrite(commentHint string, maxExecutionTime int64) {
+	qr.commentHint = commentHint
+	qr.maxExecutionTime = maxExecutionTime
+}
+
 // makeExact forces a full string match for the regex instead of substring
 func makeExact(pattern string) string {
 	return fmt.Sprintf("^%s$", pattern)
@@ -573,6 +644,8 @@ const (
 	QRFail
 	QRFailRetry
 	QRBuffer
+	QRThrottle
+	QRRewrite
 )
 
 // MarshalJSON marshals to JSON.
@@ -586,6 +659,10 @@ func (act Action) MarshalJSON() ([]byte, error) {
 		str = "FAIL_RETRY"
 	case QRBuffer:
 		str = "BUFFER"
+	case QRThrottle:
+		str = "THROTTLE"
+	case QRRewrite:
+		str = "REWRITE"
 	default:
 		str = "INVALID"
 	}
@@ -874,9 +951,10 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 	for k, v := range ruleInfo {
 		var sv string
 		var lv []any
+		var nv json.Number
 		var ok bool
 		switch k {
-		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment":
+		case "Name", "Description", "RequestIP", "User", "Query", "Action", "LeadingComment", "TrailingComment", "CommentHint":
 			sv, ok = v.(string)
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want string for %s", k)
@@ -886,6 +964,15 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 			if !ok {
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want list for %s", k)
 			}
+		case "MaxQPS", "MaxConcurrency", "MaxExecutionTime":
+			switch v := v.(type) {
+			case json.Number:
+				nv = v
+			case float64:
+				nv = json.Number(strconv.FormatFloat(v, 'f', -1, 64))
+			default:
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want number for %s", k)
+			}
 		default:
 			return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "unrecognized tag %s", k)
 		}
@@ -958,11 +1045,51 @@ func BuildQueryRule(ruleInfo map[string]any) (qr *Rule, err error) {
 				qr.act = QRFailRetry
 			case "BUFFER":
 				qr.act = QRBuffer
+			case "THROTTLE":
+				qr.act = QRThrottle
+			case "REWRITE":
+				qr.act = QRRewrite
 			default:
 				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "invalid Action %s", sv)
 			}
+		case "MaxQPS":
+			qr.maxQPS, err = nv.Float64()
+			if err != nil || qr.maxQPS <= 0 {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want positive number for MaxQPS: %s", nv)
+			}
+		case "MaxConcurrency":
+			maxConcurrency, err := nv.Int64()
+			if err != nil || maxConcurrency <= 0 {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want positive integer for MaxConcurrency: %s", nv)
+			}
+			qr.maxConcurrency = int(maxConcurrency)
+		case "CommentHint":
+			if strings.Contains(sv, "*/") {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "CommentHint cannot close the comment: %s", sv)
+			}
+			qr.commentHint = sv
+		case "MaxExecutionTime":
+			qr.maxExecutionTime, err = nv.Int64()
+			if err != nil || qr.maxExecutionTime <= 0 {
+				return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "want positive integer for MaxExecutionTime: %s", nv)
+			}
 		}
 	}
+
+	// The limits and the hints only make sense for the matching action.
+	switch {
+	case qr.act == QRThrottle && qr.maxQPS == 0 && qr.maxConcurrency == 0:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "THROTTLE action needs MaxQPS or MaxConcurrency")
+	case qr.act != QRThrottle && (qr.maxQPS != 0 || qr.maxConcurrency != 0):
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "MaxQPS and MaxConcurrency are only allowed for the THROTTLE action")
+	case qr.act == QRRewrite && qr.commentHint == "" && qr.maxExecutionTime == 0:
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "REWRITE action needs CommentHint or MaxExecutionTime")
+	case qr.act != QRRewrite && (qr.commentHint != "" || qr.maxExecutionTime != 0):
+		return nil, vterrors.Errorf(vtrpcpb.Code_INVALID_ARGUMENT, "CommentHint and MaxExecutionTime are only allowed for the REWRITE action")
+	}
+	if qr.act == QRThrottle {
+		qr.limiter = newRuleLimiter(qr.maxQPS, qr.maxConcurrency)
+	}
 	return qr, nil
 }
 
@@ -18,6 +18,7 @@ package rules
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"reflect"
 	"regexp"
@@ -26,6 +27,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/sqlparser"
@@ -625,6 +627,139 @@ func TestImport(t *testing.T) {
 	}
 }
 
+func TestImportThrottleAndRewrite(t *testing.T) {
+	qrs := New()
+	jsondata := `[{
+		"Description": "desc1",
+		"Name": "name1",
+		"Query": "select .* from slow_table.*",
+		"Action": "THROTTLE",
+		"MaxQPS": 2.5,
+		"MaxConcurrency": 4
+	},{
+		"Description": "desc2",
+		"Name": "name2",
+		"TableNames": ["big_table"],
+		"Action": "REWRITE",
+		"CommentHint": "NO_INDEX_MERGE(big_table)",
+		"MaxExecutionTime": 1000
+	}]`
+	require.NoError(t, qrs.UnmarshalJSON([]byte(jsondata)))
+	assert.Equal(t, compacted(jsondata), marshalled(qrs))
+	assert.NotNil(t, qrs.rules[0].limiter)
+	assert.Nil(t, qrs.rules[1].limiter)
+
+	// copies of a rule share its limits
+	assert.Same(t, qrs.rules[0].limiter, qrs.Copy().rules[0].limiter)
+}
+
+func TestGetThrottlesAndRewrites(t *testing.T) {
+	qrs := New()
+
+	qr1 := NewQueryRule("rule 1", "r1", QRThrottle)
+	qr1.SetThrottle(10, 0)
+	qr2 := NewQueryRule("rule 2", "r2", QRRewrite)
+	qr2.SetRewrite("", 100)
+	qr3 := NewQueryRule("rule 3", "r3", QRFail)
+	qr3.SetUserCond("banned")
+	qr4 := NewQueryRule("rule 4", "r4", QRRewrite)
+	qr4.SetRewrite("BKA(t)", 0)
+	qr4.SetUserCond("user1")
+
+	qrs.Add(qr1)
+	qrs.Add(qr2)
+	qrs.Add(qr3)
+	qrs.Add(qr4)
+
+	// throttles and rewrites do not hide the rules that come after them
+	action, _, _, desc := qrs.GetAction("", "banned", nil, sqlparser.MarginComments{})
+	assert.Equal(t, QRFail, action)
+	assert.Equal(t, "rule 3", desc)
+	action, _, _, _ = qrs.GetAction("", "user1", nil, sqlparser.MarginComments{})
+	assert.Equal(t, QRContinue, action)
+
+	throttles, rewrites := qrs.GetThrottlesAndRewrites("", "user1", nil, sqlparser.MarginComments{})
+	assert.Equal(t, []*Rule{qr1}, throttles)
+	assert.Equal(t, []*Rule{qr2, qr4}, rewrites)
+
+	throttles, rewrites = qrs.GetThrottlesAndRewrites("", "user2", nil, sqlparser.MarginComments{})
+	assert.Equal(t, []*Rule{qr1}, throttles)
+	assert.Equal(t, []*Rule{qr2}, rewrites)
+}
+
+func TestThrottle(t *testing.T) {
+	qr := NewQueryRule("rule 1", "r1", QRThrottle)
+	qr.SetThrottle(1, 2)
+	ctx := context.Background()
+
+	// the burst of the rate limit lets the first query through right away
+	release1, err := qr.Throttle(ctx)
+	require.NoError(t, err)
+
+	// the next query does not get a token before its deadline
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = qr.Throttle(shortCtx)
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	assert.EqualError(t, err, "throttled due to rule: rule 1")
+
+	// without a rate limit, only the concurrency limit applies
+	qr.SetThrottle(0, 1)
+	release2, err := qr.Throttle(ctx)
+	require.NoError(t, err)
+	_, err = qr.Throttle(shortCtx)
+	assert.Equal(t, vtrpcpb.Code_RESOURCE_EXHAUSTED, vterrors.Code(err))
+	release2()
+	release3, err := qr.Throttle(ctx)
+	require.NoError(t, err)
+	release3()
+	release1()
+}
+
+func TestRewriteQuery(t *testing.T) {
+	hint := NewQueryRule("hint", "hint", QRRewrite)
+	hint.SetRewrite("NO_INDEX_MERGE(t)", 0)
+	timeout := NewQueryRule("timeout", "timeout", QRRewrite)
+	timeout.SetRewrite("", 1000)
+	shorterTimeout := NewQueryRule("shorter timeout", "shorter timeout", QRRewrite)
+	shorterTimeout.SetRewrite("", 500)
+
+	testcases := []struct {
+		query    string
+		rewrites []*Rule
+		want     string
+	}{{
+		query:    "select * from t",
+		rewrites: []*Rule{hint},
+		want:     "select /*+ NO_INDEX_MERGE(t) */ * from t",
+	}, {
+		query:    "select * from t",
+		rewrites: []*Rule{hint, timeout, shorterTimeout},
+		want:     "select /*+ MAX_EXECUTION_TIME(500) NO_INDEX_MERGE(t) */ * from t",
+	}, {
+		query:    "select /*+ BKA(t) */ * from t",
+		rewrites: []*Rule{timeout},
+		want:     "select /*+ MAX_EXECUTION_TIME(1000) BKA(t) */ * from t",
+	}, {
+		query:    "update t set a = 1",
+		rewrites: []*Rule{hint, timeout},
+		want:     "update /*+ NO_INDEX_MERGE(t) */ t set a = 1",
+	}, {
+		query:    "delete from t",
+		rewrites: []*Rule{timeout},
+		want:     "delete from t",
+	}, {
+		query:    "show tables",
+		rewrites: []*Rule{hint},
+		want:     "show tables",
+	}}
+	for _, tc := range testcases {
+		t.Run(tc.query, func(t *testing.T) {
+			assert.Equal(t, tc.want, RewriteQuery(tc.query, tc.rewrites))
+		})
+	}
+}
+
 type ValidJSONCase struct {
 	input string
 	op    Operator
@@ -734,6 +869,15 @@ var invalidjsons = []InvalidJSONCase{
 	{`[{"BindVarConds": [{"Name": "a", "OnAbsent": true, "OnMismatch": true, "Operator": "NOMATCH", "Value": "["}]}]`, "processing [: error parsing regexp: missing closing ]: `[$`"},
 	{`[{"Action": 1 }]`, "want string for Action"},
 	{`[{"Action": "foo" }]`, "invalid Action foo"},
+	{`[{"Action": "THROTTLE" }]`, "THROTTLE action needs MaxQPS or MaxConcurrency"},
+	{`[{"Action": "THROTTLE", "MaxQPS": "1" }]`, "want number for MaxQPS"},
+	{`[{"Action": "THROTTLE", "MaxQPS": -1 }]`, "want positive number for MaxQPS: -1"},
+	{`[{"Action": "THROTTLE", "MaxConcurrency": 1.5 }]`, "want positive integer for MaxConcurrency: 1.5"},
+	{`[{"Action": "FAIL", "MaxConcurrency": 1 }]`, "MaxQPS and MaxConcurrency are only allowed for the THROTTLE action"},
+	{`[{"Action": "REWRITE" }]`, "REWRITE action needs CommentHint or MaxExecutionTime"},
+	{`[{"Action": "REWRITE", "MaxExecutionTime": 0 }]`, "want positive integer for MaxExecutionTime: 0"},
+	{`[{"Action": "REWRITE", "CommentHint": "a */ b" }]`, "CommentHint cannot close the comment: a */ b"},
+	{`[{"MaxExecutionTime": 100 }]`, "CommentHint and MaxExecutionTime are only allowed for the REWRITE action"},
 }
 
 func TestInvalidJSON(t *testing.T) {
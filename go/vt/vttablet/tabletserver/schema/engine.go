@@ -672,6 +672,14 @@ func (se *Engine) RegisterVersionEvent() error {
 	return se.historian.RegisterVersionEvent()
 }
 
+// HasSchemaForPos returns true if GetTableForPos returns the schema as of the
+// given GTID/position, and not an older one. That is always the case when the
+// historian is disabled, as the current schema is returned then: callers must
+// only use it right after reloading the schema at that position.
+func (se *Engine) HasSchemaForPos(gtid string) (bool, error) {
+	return se.historian.HasSchemaForPos(gtid)
+}
+
 // GetTableForPos makes a best-effort attempt to return a table's schema at a specific
 // GTID/position. If it cannot get the table schema for the given GTID/position then it
 // returns the latest table schema that is available in the database -- the table schema
@@ -159,6 +159,23 @@ func (h *historian) GetTableForPos(tableName sqlparser.IdentifierCS, gtid string
 	return t, nil
 }
 
+// HasSchemaForPos returns true if GetTableForPos returns the schema as of the
+// given gtid: the historian is not tracking schemas, in which case the caller
+// falls back to the current schema, or it has loaded a schema version at or
+// after the gtid.
+func (h *historian) HasSchemaForPos(gtid string) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.isOpen {
+		return true, nil
+	}
+	pos, err := replication.DecodePosition(gtid)
+	if err != nil {
+		return false, err
+	}
+	return len(h.schemas) > 0 && h.schemas[len(h.schemas)-1].pos.AtLeast(pos), nil
+}
+
 // loadFromDB loads all rows from the schema_version table that the historian does not have as yet
 // caller should have locked h.mu
 func (h *historian) loadFromDB(ctx context.Context) error {
@@ -95,9 +95,16 @@ func TestHistorian(t *testing.T) {
 	tab, err := se.GetTableForPos(ctx, sqlparser.NewIdentifierCS("dual"), gtid1)
 	require.NoError(t, err)
 	require.Equal(t, `name:"dual"`, fmt.Sprintf("%v", tab))
+	// Without the historian, the current schema is the one for any position.
+	hasSchema, err := se.HasSchemaForPos(gtid1)
+	require.NoError(t, err)
+	require.True(t, hasSchema)
 	se.EnableHistorian(true)
 	_, err = se.GetTableForPos(ctx, sqlparser.NewIdentifierCS("t1"), gtid1)
 	require.Equal(t, "table t1 not found in vttablet schema", err.Error())
+	hasSchema, err = se.HasSchemaForPos(gtid1)
+	require.NoError(t, err)
+	require.False(t, hasSchema)
 	var blob1 string
 
 	fields := []*querypb.Field{{
@@ -135,6 +142,12 @@ func TestHistorian(t *testing.T) {
 	gtid2 := gtidPrefix + "1-20"
 	_, err = se.GetTableForPos(ctx, sqlparser.NewIdentifierCS("t1"), gtid2)
 	require.Equal(t, "table t1 not found in vttablet schema", err.Error())
+	hasSchema, err = se.HasSchemaForPos(gtid1)
+	require.NoError(t, err)
+	require.True(t, hasSchema)
+	hasSchema, err = se.HasSchemaForPos(gtid2)
+	require.NoError(t, err)
+	require.False(t, hasSchema)
 
 	table = getTable("t1", []string{"id1", "id2"}, []querypb.Type{querypb.Type_INT32, querypb.Type_VARBINARY}, []int64{0})
 	tables["t1"] = table
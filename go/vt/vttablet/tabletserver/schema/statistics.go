@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"context"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/vterrors"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/connpool"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+	vtrpcpb "vitess.io/vitess/go/vt/proto/vtrpc"
+)
+
+// maxIndexColumnCount bounds the number of index columns read from information_schema.
+const maxIndexColumnCount = 10 * maxTableCount
+
+// TableStatistics returns the approximate number of rows of the base tables of the database
+// and the estimated cardinality of their indexes, as maintained by InnoDB.
+// The tables are sorted by name.
+func (se *Engine) TableStatistics(ctx context.Context) ([]*querypb.TableStatistics, error) {
+	ctx, cancel := context.WithTimeout(ctx, se.reloadTimeout)
+	defer cancel()
+
+	conn, err := se.conns.Get(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Recycle()
+
+	return loadTableStatistics(ctx, conn.Conn)
+}
+
+func loadTableStatistics(ctx context.Context, conn *connpool.Conn) ([]*querypb.TableStatistics, error) {
+	rowCounts, err := conn.Exec(ctx, mysql.ShowTableRowCounts, maxTableCount, false)
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNKNOWN, "could not get table row counts: %v", err)
+	}
+	tables := make([]*querypb.TableStatistics, 0, len(rowCounts.Rows))
+	byName := make(map[string]*querypb.TableStatistics, len(rowCounts.Rows))
+	for _, row := range rowCounts.Rows {
+		ts := &querypb.TableStatistics{
+			Name:     row[0].ToString(),
+			RowCount: toUint64(row[1]),
+		}
+		tables = append(tables, ts)
+		byName[ts.Name] = ts
+	}
+
+	cardinalities, err := conn.Exec(ctx, mysql.ShowIndexCardinalities, maxIndexColumnCount, false)
+	if err != nil {
+		return nil, vterrors.Errorf(vtrpcpb.Code_UNKNOWN, "could not get index cardinalities: %v", err)
+	}
+	var index *querypb.IndexStatistics
+	var indexTable string
+	for _, row := range cardinalities.Rows {
+		tableName := row[0].ToString()
+		ts, ok := byName[tableName]
+		if !ok {
+			continue
+		}
+		// The rows are sorted by table and index, so the columns of an index are consecutive.
+		indexName := row[1].ToString()
+		if index == nil || indexTable != tableName || index.Name != indexName {
+			index = &querypb.IndexStatistics{Name: indexName}
+			indexTable = tableName
+			ts.Indexes = append(ts.Indexes, index)
+		}
+		// COLUMN_NAME is NULL for the key parts of functional indexes.
+		index.Columns = append(index.Columns, row[2].ToString())
+		index.Cardinality = append(index.Cardinality, toUint64(row[3]))
+	}
+	return tables, nil
+}
+
+// toUint64 returns the value of an information_schema counter, which is NULL when it is not known.
+func toUint64(v sqltypes.Value) uint64 {
+	if v.IsNull() {
+		return 0
+	}
+	n, err := v.ToCastUint64()
+	if err != nil {
+		return 0
+	}
+	return n
+}
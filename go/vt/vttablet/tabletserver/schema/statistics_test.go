@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/fakesqldb"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/test/utils"
+	"vitess.io/vitess/go/vt/dbconfigs"
+	"vitess.io/vitess/go/vt/vtenv"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/connpool"
+	"vitess.io/vitess/go/vt/vttablet/tabletserver/tabletenv"
+
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestLoadTableStatistics(t *testing.T) {
+	rowCountFields := sqltypes.MakeTestFields("table_name|table_rows", "varchar|uint64")
+	cardinalityFields := sqltypes.MakeTestFields("table_name|index_name|column_name|cardinality", "varchar|varchar|varchar|int64")
+	tests := []struct {
+		name            string
+		expectedQueries map[string]*sqltypes.Result
+		queriesToReject map[string]error
+		want            []*querypb.TableStatistics
+		expectedError   string
+	}{
+		{
+			name: "Success",
+			expectedQueries: map[string]*sqltypes.Result{
+				mysql.ShowTableRowCounts: sqltypes.MakeTestResult(rowCountFields,
+					"t1|1000",
+					"t2|null"),
+				mysql.ShowIndexCardinalities: sqltypes.MakeTestResult(cardinalityFields,
+					"t1|PRIMARY|id|1000",
+					"t1|idx_a_b|a|10",
+					"t1|idx_a_b|b|500",
+					"t2|PRIMARY|id|null",
+					"v1|PRIMARY|id|10"),
+			},
+			want: []*querypb.TableStatistics{{
+				Name:     "t1",
+				RowCount: 1000,
+				Indexes: []*querypb.IndexStatistics{{
+					Name:        "PRIMARY",
+					Columns:     []string{"id"},
+					Cardinality: []uint64{1000},
+				}, {
+					Name:        "idx_a_b",
+					Columns:     []string{"a", "b"},
+					Cardinality: []uint64{10, 500},
+				}},
+			}, {
+				Name: "t2",
+				Indexes: []*querypb.IndexStatistics{{
+					Name:        "PRIMARY",
+					Columns:     []string{"id"},
+					Cardinality: []uint64{0},
+				}},
+			}},
+		}, {
+			name: "Error in row counts",
+			queriesToReject: map[string]error{
+				mysql.ShowTableRowCounts: errors.New("some error in MySQL"),
+			},
+			expectedError: "could not get table row counts",
+		}, {
+			name: "Error in cardinalities",
+			expectedQueries: map[string]*sqltypes.Result{
+				mysql.ShowTableRowCounts: sqltypes.MakeTestResult(rowCountFields, "t1|1000"),
+			},
+			queriesToReject: map[string]error{
+				mysql.ShowIndexCardinalities: errors.New("some error in MySQL"),
+			},
+			expectedError: "could not get index cardinalities",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := fakesqldb.New(t)
+			env := tabletenv.NewEnv(vtenv.NewTestEnv(), nil, tt.name)
+			conn, err := connpool.NewConn(context.Background(), dbconfigs.New(db.ConnParams()), nil, nil, env)
+			require.NoError(t, err)
+
+			for query, result := range tt.expectedQueries {
+				db.AddQuery(query, result)
+			}
+			for query, errToThrow := range tt.queriesToReject {
+				db.AddRejectedQuery(query, errToThrow)
+			}
+
+			got, err := loadTableStatistics(context.Background(), conn)
+			if tt.expectedError != "" {
+				require.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			utils.MustMatch(t, tt.want, got)
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"vitess.io/vitess/go/mysql"
 	"vitess.io/vitess/go/mysql/collations"
@@ -65,6 +66,10 @@ type Plan struct {
 
 	env *vtenv.Environment
 
+	// evalEnv is the environment the filter and column expressions are
+	// evaluated in, if the plan has any.
+	evalEnv *evalengine.ExpressionEnv
+
 	// IsInternal is set to true if the plan is for a sidecar table.
 	IsInternal bool
 }
@@ -89,6 +94,9 @@ const (
 	NotEqual
 	// IsNotNull is used to filter a column if it is NULL
 	IsNotNull
+	// Expression is used to filter on any other expression, which is
+	// evaluated against the row
+	Expression
 )
 
 // Filter contains opcodes for filtering.
@@ -105,6 +113,10 @@ type Filter struct {
 	Vindex        vindexes.Vindex
 	VindexColumns []int
 	KeyRange      *topodatapb.KeyRange
+
+	// Expr is the expression of an Expression filter. The row
+	// matches if it evaluates to true.
+	Expr evalengine.Expr
 }
 
 // ColExpr represents a column expression.
@@ -122,6 +134,10 @@ type ColExpr struct {
 	Field *querypb.Field
 
 	FixedValue sqltypes.Value
+
+	// Expr, if set, is evaluated against the row to compute the
+	// value. If so, ColNum is ignored.
+	Expr evalengine.Expr
 }
 
 // Table contains the metadata for a table.
@@ -220,10 +236,15 @@ func compare(comparison Opcode, columnValue, filterValue sqltypes.Value, collati
 // The output of the filtering operation is stored in the 'result' argument because
 // filtering cannot be performed in-place. The result argument must be a slice of
 // length equal to ColExprs
-func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.ID) (bool, error) {
+func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.ID, now time.Time) (bool, error) {
 	if len(result) != len(plan.ColExprs) {
 		return false, fmt.Errorf("expected %d values in result slice", len(plan.ColExprs))
 	}
+	if plan.evalEnv != nil {
+		// Functions like NOW() evaluate to the time the row was written,
+		// not the time it is filtered.
+		plan.evalEnv.SetTime(now)
+	}
 	for _, filter := range plan.Filters {
 		switch filter.Opcode {
 		case VindexMatch:
@@ -238,6 +259,15 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 			if values[filter.ColNum].IsNull() {
 				return false, nil
 			}
+		case Expression:
+			plan.evalEnv.Row = values
+			result, err := plan.evalEnv.Evaluate(filter.Expr)
+			if err != nil {
+				return false, err
+			}
+			if !result.ToBoolean() {
+				return false, nil
+			}
 		default:
 			match, err := compare(filter.Opcode, values[filter.ColNum], filter.Value, plan.env.CollationEnv(), charsets[filter.ColNum])
 			if err != nil {
@@ -249,6 +279,15 @@ func (plan *Plan) filter(values, result []sqltypes.Value, charsets []collations.
 		}
 	}
 	for i, colExpr := range plan.ColExprs {
+		if colExpr.Expr != nil {
+			plan.evalEnv.Row = values
+			evalResult, err := plan.evalEnv.Evaluate(colExpr.Expr)
+			if err != nil {
+				return false, err
+			}
+			result[i] = evalResult.Value(collations.ID(colExpr.Field.Charset))
+			continue
+		}
 		if colExpr.ColNum == -1 {
 			result[i] = colExpr.FixedValue
 			continue
@@ -522,56 +561,38 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 	for _, expr := range exprs {
 		switch expr := expr.(type) {
 		case *sqlparser.ComparisonExpr:
-			opcode, err := getOpcode(expr)
+			filter, ok, err := plan.analyzeComparison(expr)
 			if err != nil {
 				return err
 			}
-			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
 			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
-			if !qualifiedName.Qualifier.IsEmpty() {
-				return fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
-			}
-			colnum, err := findColumn(plan.Table, qualifiedName.Name)
-			if err != nil {
-				return err
-			}
-			val, ok := expr.Right.(*sqlparser.Literal)
-			if !ok {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
-			// StrVal is varbinary, we do not support varchar since we would have to implement all collation types
-			if val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal {
-				return fmt.Errorf("unexpected: %v", sqlparser.String(expr))
-			}
-			pv, err := evalengine.Translate(val, &evalengine.Config{
-				Collation:   plan.env.CollationEnv().DefaultConnectionCharset(),
-				Environment: plan.env,
-			})
-			if err != nil {
-				return err
-			}
-			env := evalengine.EmptyExpressionEnv(plan.env)
-			resolved, err := env.Evaluate(pv)
-			if err != nil {
-				return err
+				// Not a comparison of a column with a literal, evaluate
+				// the whole expression instead.
+				if filter, err = plan.analyzeFilterExpr(expr); err != nil {
+					return err
+				}
 			}
-			plan.Filters = append(plan.Filters, Filter{
-				Opcode: opcode,
-				ColNum: colnum,
-				Value:  resolved.Value(plan.env.CollationEnv().DefaultConnectionCharset()),
-			})
+			plan.Filters = append(plan.Filters, filter)
 		case *sqlparser.FuncExpr:
 			if !expr.Name.EqualString("in_keyrange") {
-				return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+				filter, err := plan.analyzeFilterExpr(expr)
+				if err != nil {
+					return err
+				}
+				plan.Filters = append(plan.Filters, filter)
+				continue
 			}
 			if err := plan.analyzeInKeyRange(vschema, expr.Exprs); err != nil {
 				return err
 			}
 		case *sqlparser.IsExpr: // Needed for CreateLookupVindex with ignore_nulls
 			if expr.Right != sqlparser.IsNotNullOp {
-				return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+				filter, err := plan.analyzeFilterExpr(expr)
+				if err != nil {
+					return err
+				}
+				plan.Filters = append(plan.Filters, filter)
+				continue
 			}
 			qualifiedName, ok := expr.Left.(*sqlparser.ColName)
 			if !ok {
@@ -589,12 +610,112 @@ func (plan *Plan) analyzeWhere(vschema *localVSchema, where *sqlparser.Where) er
 				ColNum: colnum,
 			})
 		default:
-			return fmt.Errorf("unsupported constraint: %v", sqlparser.String(expr))
+			filter, err := plan.analyzeFilterExpr(expr)
+			if err != nil {
+				return err
+			}
+			plan.Filters = append(plan.Filters, filter)
 		}
 	}
 	return nil
 }
 
+// analyzeComparison builds the filter of a comparison of a column with
+// an integer or string literal. It returns false if the comparison is of
+// any other form.
+func (plan *Plan) analyzeComparison(expr *sqlparser.ComparisonExpr) (Filter, bool, error) {
+	opcode, err := getOpcode(expr)
+	if err != nil {
+		return Filter{}, false, nil
+	}
+	qualifiedName, ok := expr.Left.(*sqlparser.ColName)
+	if !ok {
+		return Filter{}, false, nil
+	}
+	if !qualifiedName.Qualifier.IsEmpty() {
+		return Filter{}, false, fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(qualifiedName))
+	}
+	colnum, err := findColumn(plan.Table, qualifiedName.Name)
+	if err != nil {
+		return Filter{}, false, err
+	}
+	val, ok := expr.Right.(*sqlparser.Literal)
+	if !ok {
+		return Filter{}, false, nil
+	}
+	// StrVal is varbinary, we do not support varchar since we would have to implement all collation types
+	if val.Type != sqlparser.IntVal && val.Type != sqlparser.StrVal {
+		return Filter{}, false, nil
+	}
+	pv, err := evalengine.Translate(val, &evalengine.Config{
+		Collation:   plan.env.CollationEnv().DefaultConnectionCharset(),
+		Environment: plan.env,
+	})
+	if err != nil {
+		return Filter{}, false, err
+	}
+	env := evalengine.EmptyExpressionEnv(plan.env)
+	resolved, err := env.Evaluate(pv)
+	if err != nil {
+		return Filter{}, false, err
+	}
+	return Filter{
+		Opcode: opcode,
+		ColNum: colnum,
+		Value:  resolved.Value(plan.env.CollationEnv().DefaultConnectionCharset()),
+	}, true, nil
+}
+
+// analyzeFilterExpr builds the filter of a constraint that is evaluated
+// against each row.
+func (plan *Plan) analyzeFilterExpr(expr sqlparser.Expr) (Filter, error) {
+	evalExpr, err := plan.translateExpr(expr)
+	if err != nil {
+		return Filter{}, vterrors.Wrapf(err, "unsupported constraint: %v", sqlparser.String(expr))
+	}
+	return Filter{
+		Opcode: Expression,
+		Expr:   evalExpr,
+	}, nil
+}
+
+// translateExpr compiles the expression with the evalengine, so that it
+// can be evaluated against the rows of the table.
+func (plan *Plan) translateExpr(expr sqlparser.Expr) (evalengine.Expr, error) {
+	evalExpr, err := evalengine.Translate(expr, &evalengine.Config{
+		ResolveColumn: plan.resolveColumn,
+		ResolveType:   plan.resolveType,
+		Collation:     plan.env.CollationEnv().DefaultConnectionCharset(),
+		Environment:   plan.env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if plan.evalEnv == nil {
+		plan.evalEnv = evalengine.EmptyExpressionEnv(plan.env)
+	}
+	return evalExpr, nil
+}
+
+func (plan *Plan) resolveColumn(colname *sqlparser.ColName) (int, error) {
+	if !colname.Qualifier.IsEmpty() {
+		return 0, fmt.Errorf("unsupported qualifier for column: %v", sqlparser.String(colname))
+	}
+	return findColumn(plan.Table, colname.Name)
+}
+
+func (plan *Plan) resolveType(expr sqlparser.Expr) (evalengine.Type, bool) {
+	colname, ok := expr.(*sqlparser.ColName)
+	if !ok {
+		return evalengine.Type{}, false
+	}
+	colnum, err := findColumn(plan.Table, colname.Name)
+	if err != nil {
+		return evalengine.Type{}, false
+	}
+	return evalengine.NewTypeFromField(plan.Table.Fields[colnum]), true
+}
+
 // splitAndExpression breaks up the Expr into AND-separated conditions
 // and appends them to filters, which can be shuffled and recombined
 // as needed.
@@ -715,19 +836,20 @@ func (plan *Plan) analyzeExpr(vschema *localVSchema, selExpr sqlparser.SelectExp
 				Field:  field,
 			}, nil
 		default:
-			return ColExpr{}, fmt.Errorf("unsupported function: %v", sqlparser.String(inner))
+			return plan.analyzeColumnExpr(aliased)
 		}
 	case *sqlparser.Literal:
-		// allow only intval 1
+		// The integer literal 1 keeps its fixed field, anything else
+		// is evaluated like other expressions.
 		if inner.Type != sqlparser.IntVal {
-			return ColExpr{}, fmt.Errorf("only integer literals are supported")
+			return plan.analyzeColumnExpr(aliased)
 		}
 		num, err := strconv.ParseInt(string(inner.Val), 0, 64)
 		if err != nil {
 			return ColExpr{}, err
 		}
 		if num != 1 {
-			return ColExpr{}, fmt.Errorf("only the integer literal 1 is supported")
+			return plan.analyzeColumnExpr(aliased)
 		}
 		return ColExpr{
 			Field: &querypb.Field{
@@ -770,9 +892,32 @@ func (plan *Plan) analyzeExpr(vschema *localVSchema, selExpr sqlparser.SelectExp
 			Field:  field,
 		}, nil
 	default:
-		log.Infof("Unsupported expression: %v", inner)
-		return ColExpr{}, fmt.Errorf("unsupported: %v", sqlparser.String(aliased.Expr))
+		return plan.analyzeColumnExpr(aliased)
+	}
+}
+
+// analyzeColumnExpr builds the column of an expression that is evaluated
+// against each row. The column is named after its alias, or the
+// expression itself.
+func (plan *Plan) analyzeColumnExpr(aliased *sqlparser.AliasedExpr) (ColExpr, error) {
+	evalExpr, err := plan.translateExpr(aliased.Expr)
+	if err != nil {
+		log.Infof("Unsupported expression: %v: %v", sqlparser.String(aliased.Expr), err)
+		return ColExpr{}, vterrors.Wrapf(err, "unsupported: %v", sqlparser.String(aliased.Expr))
+	}
+	typ, err := plan.evalEnv.TypeOf(evalExpr)
+	if err != nil {
+		return ColExpr{}, err
+	}
+	name := aliased.As.String()
+	if name == "" {
+		name = sqlparser.String(aliased.Expr)
 	}
+	return ColExpr{
+		ColNum: -1,
+		Field:  typ.ToField(name),
+		Expr:   evalExpr,
+	}, nil
 }
 
 // analyzeInKeyRange allows the following constructs: "in_keyrange('-80')",
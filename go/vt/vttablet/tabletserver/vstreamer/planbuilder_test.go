@@ -19,6 +19,7 @@ package vstreamer
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -586,7 +587,7 @@ func TestPlanBuilder(t *testing.T) {
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id, val from t1 where max(id)"},
-		outErr:  `unsupported constraint: max(id)`,
+		outErr:  `unsupported constraint: max(id): expr cannot be translated, not supported: max(id)`,
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id, val from t1 where in_keyrange(id)"},
@@ -622,8 +623,8 @@ func TestPlanBuilder(t *testing.T) {
 		outErr:  `unsupported function: max(val)`,
 	}, {
 		inTable: t1,
-		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id+1, val from t1"},
-		outErr:  `unsupported: id + 1`,
+		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select id+none, val from t1"},
+		outErr:  "unsupported: id + `none`: column `none` not found in table t1",
 	}, {
 		inTable: t1,
 		inRule:  &binlogdatapb.Rule{Match: "t1", Filter: "select t1.id, val from t1"},
@@ -747,6 +748,116 @@ func TestPlanBuilderFilterComparison(t *testing.T) {
 	}
 }
 
+func TestPlanBuilderFilterExpression(t *testing.T) {
+	t1 := &Table{
+		Name: "t1",
+		Fields: []*querypb.Field{{
+			Name:    "id",
+			Type:    sqltypes.Int64,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_BINARY_FLAG | querypb.MySqlFlag_NUM_FLAG),
+		}, {
+			Name:    "val",
+			Type:    sqltypes.VarChar,
+			Charset: uint32(collations.CollationUtf8mb4ID),
+		}, {
+			Name:    "other",
+			Type:    sqltypes.Int64,
+			Charset: collations.CollationBinaryID,
+			Flags:   uint32(querypb.MySqlFlag_BINARY_FLAG | querypb.MySqlFlag_NUM_FLAG),
+		}},
+	}
+	rows := [][]sqltypes.Value{
+		{sqltypes.NewInt64(1), sqltypes.NewVarChar("abc"), sqltypes.NewInt64(1)},
+		{sqltypes.NewInt64(2), sqltypes.NewVarChar("xyz"), sqltypes.NewInt64(3)},
+		{sqltypes.NewInt64(3), sqltypes.NULL, sqltypes.NewInt64(3)},
+		{sqltypes.NewInt64(4), sqltypes.NewVarChar("abd"), sqltypes.NULL},
+	}
+	testcases := []struct {
+		inFilter  string
+		outFields []string
+		outRows   []string
+		outErr    string
+	}{{
+		inFilter:  "select id from t1 where id = 1 or id = 4",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(1)]", "[INT64(4)]"},
+	}, {
+		inFilter:  "select id from t1 where id in (2, 3)",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(2)]", "[INT64(3)]"},
+	}, {
+		inFilter:  "select id from t1 where val like 'ab%'",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(1)]", "[INT64(4)]"},
+	}, {
+		inFilter:  "select id from t1 where id = other",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(1)]", "[INT64(3)]"},
+	}, {
+		inFilter:  "select id from t1 where upper(val) = 'XYZ'",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(2)]"},
+	}, {
+		inFilter:  "select id from t1 where val is null",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(3)]"},
+	}, {
+		// Expressions are ANDed with the other constraints.
+		inFilter:  "select id from t1 where id > 1 and (val = 'xyz' or other is null)",
+		outFields: []string{"id"},
+		outRows:   []string{"[INT64(2)]", "[INT64(4)]"},
+	}, {
+		inFilter:  "select id, id + other as total, concat(val, '!') from t1 where id < 3",
+		outFields: []string{"id", "total", "concat(val, '!')"},
+		outRows:   []string{"[INT64(1) INT64(2) VARCHAR(\"abc!\")]", "[INT64(2) INT64(5) VARCHAR(\"xyz!\")]"},
+	}, {
+		// Functions like NOW() evaluate to the time the row was written.
+		inFilter:  "select id, unix_timestamp() as ts from t1 where id = 1 and now() < '2024-06-01'",
+		outFields: []string{"id", "ts"},
+		outRows:   []string{"[INT64(1) INT64(1704164645)]"},
+	}, {
+		inFilter: "select id from t1 where id = none",
+		outErr:   "unsupported constraint: id = `none`: column `none` not found in table t1",
+	}, {
+		inFilter: "select id from t1 where id = 1 or in_keyrange('-80')",
+		outErr:   "unsupported constraint: id = 1 or in_keyrange('-80')",
+	}}
+
+	for _, tcase := range testcases {
+		t.Run(tcase.inFilter, func(t *testing.T) {
+			plan, err := buildPlan(vtenv.NewTestEnv(), t1, testLocalVSchema, &binlogdatapb.Filter{
+				Rules: []*binlogdatapb.Rule{{Match: "t1", Filter: tcase.inFilter}},
+			})
+			if tcase.outErr != "" {
+				assert.Nil(t, plan)
+				assert.ErrorContains(t, err, tcase.outErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var fields []string
+			for _, field := range plan.fields() {
+				fields = append(fields, field.Name)
+			}
+			assert.Equal(t, tcase.outFields, fields)
+
+			var got []string
+			charsets := make([]collations.ID, len(t1.Fields))
+			now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+			for _, row := range rows {
+				result := make([]sqltypes.Value, len(plan.ColExprs))
+				ok, err := plan.filter(row, result, charsets, now)
+				require.NoError(t, err)
+				if ok {
+					got = append(got, fmt.Sprintf("%v", result))
+				}
+			}
+			assert.Equal(t, tcase.outRows, got)
+		})
+	}
+}
+
 func TestCompare(t *testing.T) {
 	type testcase struct {
 		opcode                   Opcode
@@ -342,6 +342,10 @@ func (rs *rowStreamer) streamQuery(send func(*binlogdatapb.VStreamRowsResponse)
 		}
 	}
 
+	// The rows are read from a snapshot taken now, so functions like NOW()
+	// in the filter evaluate to this time for all of them.
+	now := time.Now()
+
 	pkfields := make([]*querypb.Field, len(rs.pkColumns))
 	for i, pk := range rs.pkColumns {
 		pkfields[i] = &querypb.Field{
@@ -420,7 +424,7 @@ func (rs *rowStreamer) streamQuery(send func(*binlogdatapb.VStreamRowsResponse)
 			lastpk[i] = mysqlrow[pk]
 		}
 		// Reuse the vstreamer's filter.
-		ok, err := rs.plan.filter(mysqlrow, filtered, charsets)
+		ok, err := rs.plan.filter(mysqlrow, filtered, charsets, now)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,319 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vstreamer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+	"vitess.io/vitess/go/vt/vterrors"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+// schemaChange is a table whose definition was changed by a DDL.
+type schemaChange struct {
+	table   string
+	dropped bool
+}
+
+// pendingSchema is a DDL the SCHEMA events of which are yet to be sent,
+// because the schema engine does not know the schema at its position yet.
+type pendingSchema struct {
+	pos     string
+	ddl     string
+	changes []schemaChange
+}
+
+// schemaChanges returns the tables of the filter that the DDL creates, alters,
+// renames or drops, in the order of the statement. A table renamed is dropped
+// under its old name, and created under its new one.
+func schemaChanges(query mysql.Query, dbname string, filter *binlogdatapb.Filter, parser *sqlparser.Parser) []schemaChange {
+	if query.Database != "" && query.Database != dbname {
+		return nil
+	}
+	ast, err := parser.Parse(query.SQL)
+	if err != nil {
+		return nil
+	}
+	var changes []schemaChange
+	index := make(map[string]int)
+	add := func(table sqlparser.TableName, dropped bool) {
+		if !tableMatches(table, dbname, filter) {
+			return
+		}
+		name := table.Name.String()
+		if i, ok := index[name]; ok {
+			// With several renames, only the last change of a table matters.
+			changes[i].dropped = dropped
+			return
+		}
+		index[name] = len(changes)
+		changes = append(changes, schemaChange{table: name, dropped: dropped})
+	}
+	switch stmt := ast.(type) {
+	case *sqlparser.CreateTable:
+		add(stmt.GetTable(), false)
+	case *sqlparser.AlterTable:
+		toTables := stmt.GetToTables()
+		add(stmt.GetTable(), len(toTables) > 0)
+		for _, table := range toTables {
+			add(table, false)
+		}
+	case *sqlparser.DropTable:
+		for _, table := range stmt.GetFromTables() {
+			add(table, true)
+		}
+	case *sqlparser.RenameTable:
+		for _, pair := range stmt.TablePairs {
+			add(pair.FromTable, true)
+			add(pair.ToTable, false)
+		}
+	}
+	return changes
+}
+
+// schemaEvents returns the SCHEMA events of the pending DDLs the schema engine
+// knows the schema of, and removes them from the pending ones. The DDLs are
+// pending until the historian has loaded the schema version tracked for them,
+// or right after the schema engine reloaded at their position when there is
+// no historian.
+func (vs *vstreamer) schemaEvents() ([]*binlogdatapb.VEvent, error) {
+	var vevents []*binlogdatapb.VEvent
+	for len(vs.pendingSchemas) > 0 {
+		pending := vs.pendingSchemas[0]
+		ok, err := vs.se.HasSchemaForPos(pending.pos)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		for _, change := range pending.changes {
+			schemaEvent := &binlogdatapb.SchemaEvent{
+				TableName: change.table,
+				Dropped:   change.dropped,
+				Ddl:       pending.ddl,
+				Position:  pending.pos,
+				Keyspace:  vs.vse.keyspace,
+				Shard:     vs.vse.shard,
+			}
+			if !change.dropped {
+				table, err := vs.se.GetTableForPos(vs.ctx, sqlparser.NewIdentifierCS(change.table), pending.pos)
+				if err != nil {
+					return nil, vterrors.Wrapf(err, "failed to get the schema of table %s", change.table)
+				}
+				schemaEvent.Fields = table.Fields
+				schemaEvent.PKColumns = table.PKColumns
+				schemaEvent.PKIndexName = table.PKIndexName
+				if schemaEvent.AvroSchema, err = avroSchema(vs.vse.keyspace, schemaEvent); err != nil {
+					return nil, err
+				}
+				if schemaEvent.JsonSchema, err = jsonSchema(vs.vse.keyspace, schemaEvent); err != nil {
+					return nil, err
+				}
+			}
+			vevents = append(vevents, &binlogdatapb.VEvent{
+				Type:        binlogdatapb.VEventType_SCHEMA,
+				SchemaEvent: schemaEvent,
+			})
+		}
+		vs.pendingSchemas = vs.pendingSchemas[1:]
+	}
+	return vevents, nil
+}
+
+// isBytes returns true if the values of the field are raw bytes, not text.
+func isBytes(field *querypb.Field) bool {
+	switch {
+	case field.Type == sqltypes.Bit, field.Type == sqltypes.Geometry:
+		return true
+	case sqltypes.IsBinary(field.Type):
+		return field.Charset == collations.CollationBinaryID
+	}
+	return false
+}
+
+func isNullable(field *querypb.Field) bool {
+	return field.Flags&uint32(querypb.MySqlFlag_NOT_NULL_FLAG) == 0
+}
+
+// avroName returns the name as a valid Avro name: the characters other than
+// letters, digits and underscores are replaced by underscores, and it is
+// prefixed with one if it starts with a digit.
+func avroName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+		default:
+			r = '_'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    any             `json:"type"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+// avroSchema returns the Avro schema of the rows of the table, as a record
+// named after the table in the namespace of the keyspace. The integers are
+// ints or longs depending on their size, the floating points floats or
+// doubles, the binary values bytes, and all the other values strings. The
+// nullable columns are unions with null, which they default to.
+func avroSchema(keyspace string, schemaEvent *binlogdatapb.SchemaEvent) (string, error) {
+	record := avroRecord{
+		Type:      "record",
+		Name:      avroName(schemaEvent.TableName),
+		Namespace: avroName(keyspace),
+		Fields:    make([]avroField, 0, len(schemaEvent.Fields)),
+	}
+	for _, field := range schemaEvent.Fields {
+		var typ any
+		switch {
+		case sqltypes.IsIntegral(field.Type):
+			switch field.Type {
+			case sqltypes.Int64, sqltypes.Uint32, sqltypes.Uint64:
+				typ = "long"
+			default:
+				typ = "int"
+			}
+		case field.Type == sqltypes.Float32:
+			typ = "float"
+		case field.Type == sqltypes.Float64:
+			typ = "double"
+		case isBytes(field):
+			typ = "bytes"
+		default:
+			typ = "string"
+		}
+		f := avroField{Name: avroName(field.Name), Type: typ}
+		if isNullable(field) {
+			f.Type = []any{"null", typ}
+			f.Default = json.RawMessage("null")
+		}
+		record.Fields = append(record.Fields, f)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", vterrors.Wrapf(err, "failed to render the Avro schema of table %s", schemaEvent.TableName)
+	}
+	return string(data), nil
+}
+
+// jsonSchemaProperties are the properties of a JSON Schema object, which
+// marshal in the order of the columns.
+type jsonSchemaProperties []jsonSchemaProperty
+
+type jsonSchemaProperty struct {
+	name            string
+	Type            any    `json:"type"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (properties jsonSchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, property := range properties {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(property.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(property)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type jsonSchemaObject struct {
+	Schema               string               `json:"$schema"`
+	Title                string               `json:"title"`
+	Type                 string               `json:"type"`
+	Properties           jsonSchemaProperties `json:"properties"`
+	Required             []string             `json:"required"`
+	AdditionalProperties bool                 `json:"additionalProperties"`
+}
+
+// jsonSchema returns the JSON Schema of the rows of the table, as objects with
+// all of its columns. The integers are integers, the floating points numbers,
+// the binary values base64 strings, and all the other values strings.
+func jsonSchema(keyspace string, schemaEvent *binlogdatapb.SchemaEvent) (string, error) {
+	object := jsonSchemaObject{
+		Schema:     "https://json-schema.org/draft/2020-12/schema",
+		Title:      keyspace + "." + schemaEvent.TableName,
+		Type:       "object",
+		Properties: make(jsonSchemaProperties, 0, len(schemaEvent.Fields)),
+		Required:   make([]string, 0, len(schemaEvent.Fields)),
+	}
+	for _, field := range schemaEvent.Fields {
+		property := jsonSchemaProperty{name: field.Name}
+		var typ string
+		switch {
+		case sqltypes.IsIntegral(field.Type):
+			typ = "integer"
+		case sqltypes.IsFloat(field.Type):
+			typ = "number"
+		case isBytes(field):
+			typ = "string"
+			property.ContentEncoding = "base64"
+		default:
+			typ = "string"
+		}
+		property.Type = typ
+		if isNullable(field) {
+			property.Type = []string{typ, "null"}
+		}
+		object.Properties = append(object.Properties, property)
+		object.Required = append(object.Required, field.Name)
+	}
+	data, err := json.Marshal(object)
+	if err != nil {
+		return "", vterrors.Wrapf(err, "failed to render the JSON Schema of table %s", schemaEvent.TableName)
+	}
+	return string(data), nil
+}
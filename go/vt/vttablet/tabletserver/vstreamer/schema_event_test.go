@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Vitess Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vstreamer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"vitess.io/vitess/go/mysql"
+	"vitess.io/vitess/go/mysql/collations"
+	"vitess.io/vitess/go/sqltypes"
+	"vitess.io/vitess/go/vt/sqlparser"
+
+	binlogdatapb "vitess.io/vitess/go/vt/proto/binlogdata"
+	querypb "vitess.io/vitess/go/vt/proto/query"
+)
+
+func TestSchemaChanges(t *testing.T) {
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match: "/^t/",
+		}},
+	}
+	testcases := []struct {
+		query mysql.Query
+		want  []schemaChange
+	}{{
+		query: mysql.Query{SQL: "create table t1(id int primary key)"},
+		want:  []schemaChange{{table: "t1"}},
+	}, {
+		query: mysql.Query{SQL: "create table other(id int primary key)"},
+	}, {
+		query: mysql.Query{SQL: "alter table t1 add column val varchar(128)"},
+		want:  []schemaChange{{table: "t1"}},
+	}, {
+		query: mysql.Query{SQL: "alter table t1 rename t2"},
+		want:  []schemaChange{{table: "t1", dropped: true}, {table: "t2"}},
+	}, {
+		query: mysql.Query{SQL: "drop table t1, other, t2"},
+		want:  []schemaChange{{table: "t1", dropped: true}, {table: "t2", dropped: true}},
+	}, {
+		// Swapping tables leaves them both defined.
+		query: mysql.Query{SQL: "rename table t1 to tmp, t2 to t1, tmp to t2"},
+		want:  []schemaChange{{table: "t1"}, {table: "tmp", dropped: true}, {table: "t2"}},
+	}, {
+		query: mysql.Query{SQL: "truncate table t1"},
+	}, {
+		query: mysql.Query{SQL: "create view t3 as select * from t1"},
+	}, {
+		query: mysql.Query{SQL: "create table t1(id int primary key)", Database: "otherdb"},
+	}, {
+		query: mysql.Query{SQL: "create table otherdb.t1(id int primary key)"},
+	}}
+	for _, tcase := range testcases {
+		t.Run(tcase.query.SQL, func(t *testing.T) {
+			got := schemaChanges(tcase.query, "vttest", filter, sqlparser.NewTestParser())
+			assert.Equal(t, tcase.want, got)
+		})
+	}
+}
+
+func TestSchemaRendering(t *testing.T) {
+	notNull := uint32(querypb.MySqlFlag_NOT_NULL_FLAG)
+	schemaEvent := &binlogdatapb.SchemaEvent{
+		TableName: "t1",
+		Fields: []*querypb.Field{
+			{Name: "id", Type: sqltypes.Int64, Flags: notNull | uint32(querypb.MySqlFlag_PRI_KEY_FLAG)},
+			{Name: "small", Type: sqltypes.Uint16, Flags: notNull},
+			{Name: "f", Type: sqltypes.Float32},
+			{Name: "d", Type: sqltypes.Float64},
+			{Name: "amount", Type: sqltypes.Decimal},
+			{Name: "name", Type: sqltypes.VarChar, Charset: collations.CollationUtf8mb4ID},
+			{Name: "data", Type: sqltypes.VarBinary, Charset: collations.CollationBinaryID},
+			{Name: "created-at", Type: sqltypes.Datetime, Flags: notNull},
+		},
+		PKColumns: []int64{0},
+	}
+
+	avro, err := avroSchema("commerce-1", schemaEvent)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"record","name":"t1","namespace":"commerce_1","fields":[`+
+		`{"name":"id","type":"long"},`+
+		`{"name":"small","type":"int"},`+
+		`{"name":"f","type":["null","float"],"default":null},`+
+		`{"name":"d","type":["null","double"],"default":null},`+
+		`{"name":"amount","type":["null","string"],"default":null},`+
+		`{"name":"name","type":["null","string"],"default":null},`+
+		`{"name":"data","type":["null","bytes"],"default":null},`+
+		`{"name":"created_at","type":"string"}]}`, avro)
+
+	js, err := jsonSchema("commerce-1", schemaEvent)
+	require.NoError(t, err)
+	assert.Equal(t, `{"$schema":"https://json-schema.org/draft/2020-12/schema","title":"commerce-1.t1","type":"object","properties":{`+
+		`"id":{"type":"integer"},`+
+		`"small":{"type":"integer"},`+
+		`"f":{"type":["number","null"]},`+
+		`"d":{"type":["number","null"]},`+
+		`"amount":{"type":["string","null"]},`+
+		`"name":{"type":["string","null"]},`+
+		`"data":{"type":["string","null"],"contentEncoding":"base64"},`+
+		`"created-at":{"type":"string"}},`+
+		`"required":["id","small","f","d","amount","name","data","created-at"],"additionalProperties":false}`, js)
+
+	assert.Equal(t, "_1st_table", avroName("1st table"))
+}
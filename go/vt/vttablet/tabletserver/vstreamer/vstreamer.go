@@ -77,6 +77,12 @@ type vstreamer struct {
 	plans          map[uint64]*streamerPlan
 	journalTableID uint64
 	versionTableID uint64
+	// pendingSchemas are the DDLs the SCHEMA events of which are yet to be
+	// sent, if the stream asked for them.
+	pendingSchemas []*pendingSchema
+	// skipRows is set while parsing the row events of a statement tagged with
+	// the source tag the filter skips.
+	skipRows bool
 
 	// format and pos are updated by parseEvent.
 	format  mysql.BinlogFormat
@@ -106,15 +112,19 @@ type streamerPlan struct {
 //
 // filter: the list of filtering rules. If a rule has a select expression for its filter,
 //
-//	the select list can only reference direct columns. No other expressions are allowed.
+//	the select list can reference columns, or expressions of the columns that the
+//	evalengine can evaluate against each row.
 //	The select expression is allowed to contain the special 'in_keyrange()' function which
 //	will return the keyspace id of the row. Examples:
 //	"select * from t", same as an empty Filter,
 //	"select * from t where in_keyrange('-80')", same as "-80",
 //	"select * from t where in_keyrange(col1, 'hash', '-80')",
 //	"select col1, col2 from t where...",
+//	"select col1, upper(col2) as col2 from t where col3 in (1, 2) or col4 like 'a%'",
 //	"select col1, keyspace_id() from t where...".
-//	Only "in_keyrange" and limited comparison operators (see enum Opcode in planbuilder.go) are supported in the where clause.
+//	The where clause is a list of ANDed constraints, which can include "in_keyrange".
+//	Any other constraint is evaluated against each row (see enum Opcode in planbuilder.go).
+//	Functions like NOW() evaluate to the timestamp of the binlog event of the row.
 //	Other constructs like joins, group by, etc. are not supported.
 //
 // vschema: the current vschema. This value can later be changed through the SetVSchema method.
@@ -238,8 +248,8 @@ func (vs *vstreamer) parseEvents(ctx context.Context, events <-chan mysql.Binlog
 
 		switch vevent.Type {
 		case binlogdatapb.VEventType_GTID, binlogdatapb.VEventType_BEGIN, binlogdatapb.VEventType_FIELD,
-			binlogdatapb.VEventType_JOURNAL:
-			// We never have to send GTID, BEGIN, FIELD events on their own.
+			binlogdatapb.VEventType_JOURNAL, binlogdatapb.VEventType_SCHEMA:
+			// We never have to send GTID, BEGIN, FIELD, SCHEMA events on their own.
 			// A JOURNAL event is always preceded by a BEGIN and followed by a COMMIT.
 			// So, we don't have to send it right away.
 			bufferedEvents = append(bufferedEvents, vevent)
@@ -463,7 +473,9 @@ func (vs *vstreamer) parseEvent(ev mysql.BinlogEvent) ([]*binlogdatapb.VEvent, e
 			})
 		}
 		vs.pos = replication.AppendGTID(vs.pos, gtid)
+		vs.skipRows = false
 	case ev.IsXID():
+		vs.skipRows = false
 		vevents = append(vevents, &binlogdatapb.VEvent{
 			Type: binlogdatapb.VEventType_GTID,
 			Gtid: replication.EncodePosition(vs.pos),
@@ -519,26 +531,45 @@ func (vs *vstreamer) parseEvent(ev mysql.BinlogEvent) ([]*binlogdatapb.VEvent, e
 				Type: binlogdatapb.VEventType_COMMIT,
 			})
 		case sqlparser.StmtDDL:
-			if mustSendDDL(q, vs.cp.DBName(), vs.filter, vs.vse.env.Environment().Parser()) {
+			if schema.MustReloadSchemaOnDDL(q.SQL, vs.cp.DBName(), vs.vse.env.Environment().Parser()) {
+				vs.se.ReloadAt(context.Background(), vs.pos)
+			}
+			// The SCHEMA events go before the DDL if the schema at its position is
+			// known already, which is only the case without the historian. With the
+			// historian, they are pending until the schema_version row of the DDL is
+			// streamed, and are sent with that later transaction.
+			var schemaEvents []*binlogdatapb.VEvent
+			if vs.options.GetIncludeSchemaEvents() {
+				if changes := schemaChanges(q, vs.cp.DBName(), vs.filter, vs.vse.env.Environment().Parser()); len(changes) > 0 {
+					vs.pendingSchemas = append(vs.pendingSchemas, &pendingSchema{
+						pos:     replication.EncodePosition(vs.pos),
+						ddl:     q.SQL,
+						changes: changes,
+					})
+				}
+				if schemaEvents, err = vs.schemaEvents(); err != nil {
+					return nil, err
+				}
+			}
+			vevents = append(vevents, &binlogdatapb.VEvent{
+				Type: binlogdatapb.VEventType_GTID,
+				Gtid: replication.EncodePosition(vs.pos),
+			})
+			vevents = append(vevents, schemaEvents...)
+			// The DDLs applied by the peer of a bidirectional replication are
+			// not sent back to it.
+			skipDDL := vs.filter.GetSkipSourceTag() != "" && vttablet.HasSourceTag(q.SQL, vs.filter.GetSkipSourceTag())
+			if !skipDDL && mustSendDDL(q, vs.cp.DBName(), vs.filter, vs.vse.env.Environment().Parser()) {
 				vevents = append(vevents, &binlogdatapb.VEvent{
-					Type: binlogdatapb.VEventType_GTID,
-					Gtid: replication.EncodePosition(vs.pos),
-				}, &binlogdatapb.VEvent{
 					Type:      binlogdatapb.VEventType_DDL,
 					Statement: q.SQL,
 				})
 			} else {
 				// If the DDL need not be sent, send a dummy OTHER event.
 				vevents = append(vevents, &binlogdatapb.VEvent{
-					Type: binlogdatapb.VEventType_GTID,
-					Gtid: replication.EncodePosition(vs.pos),
-				}, &binlogdatapb.VEvent{
 					Type: binlogdatapb.VEventType_OTHER,
 				})
 			}
-			if schema.MustReloadSchemaOnDDL(q.SQL, vs.cp.DBName(), vs.vse.env.Environment().Parser()) {
-				vs.se.ReloadAt(context.Background(), vs.pos)
-			}
 		case sqlparser.StmtSavepoint:
 			// We currently completely skip `SAVEPOINT ...` statements.
 			//
@@ -563,6 +594,17 @@ func (vs *vstreamer) parseEvent(ev mysql.BinlogEvent) ([]*binlogdatapb.VEvent, e
 		default:
 			return nil, fmt.Errorf("unexpected statement type %s in row-based replication: %q", cat, q.SQL)
 		}
+	case ev.IsRowsQuery():
+		// The statement of the row events that follow. The ones tagged with the
+		// source tag were applied by the workflow of the other direction of a
+		// bidirectional replication, and must not be replicated back.
+		if tag := vs.filter.GetSkipSourceTag(); tag != "" {
+			sql, err := ev.RowsQuery(vs.format)
+			if err != nil {
+				return nil, fmt.Errorf("can't get query from binlog event: %v, event data: %#v", err, ev)
+			}
+			vs.skipRows = vttablet.HasSourceTag(sql, tag)
+		}
 	case ev.IsTableMap():
 		// This is very frequent. It precedes every row event.
 		// If it's the first time for a table, we generate a FIELD
@@ -635,16 +677,24 @@ func (vs *vstreamer) parseEvent(ev mysql.BinlogEvent) ([]*binlogdatapb.VEvent, e
 		}
 
 		if id == vs.journalTableID {
-			vevents, err = vs.processJournalEvent(vevents, plan, rows)
+			vevents, err = vs.processJournalEvent(vevents, plan, rows, eventTime(ev))
 		} else if id == vs.versionTableID {
 			vs.se.RegisterVersionEvent()
+			if vs.options.GetIncludeSchemaEvents() {
+				// The historian may now know the schema of pending DDLs.
+				var schemaEvents []*binlogdatapb.VEvent
+				if schemaEvents, err = vs.schemaEvents(); err != nil {
+					return nil, err
+				}
+				vevents = append(vevents, schemaEvents...)
+			}
 			vevent := &binlogdatapb.VEvent{
 				Type: binlogdatapb.VEventType_VERSION,
 			}
 			vevents = append(vevents, vevent)
 
-		} else {
-			vevents, err = vs.processRowEvent(vevents, plan, rows)
+		} else if !vs.skipRows {
+			vevents, err = vs.processRowEvent(vevents, plan, rows, eventTime(ev))
 		}
 		if err != nil {
 			return nil, err
@@ -940,7 +990,7 @@ func encodeString(in string) string {
 	return buf.String()
 }
 
-func (vs *vstreamer) processJournalEvent(vevents []*binlogdatapb.VEvent, plan *streamerPlan, rows mysql.Rows) ([]*binlogdatapb.VEvent, error) {
+func (vs *vstreamer) processJournalEvent(vevents []*binlogdatapb.VEvent, plan *streamerPlan, rows mysql.Rows, now time.Time) ([]*binlogdatapb.VEvent, error) {
 	// Get DbName
 	params, err := vs.cp.MysqlParams()
 	if err != nil {
@@ -948,7 +998,7 @@ func (vs *vstreamer) processJournalEvent(vevents []*binlogdatapb.VEvent, plan *s
 	}
 nextrow:
 	for _, row := range rows.Rows {
-		afterOK, afterValues, _, err := vs.extractRowAndFilter(plan, row.Data, rows.DataColumns, row.NullColumns)
+		afterOK, afterValues, _, err := vs.extractRowAndFilter(plan, row.Data, rows.DataColumns, row.NullColumns, now)
 		if err != nil {
 			return nil, err
 		}
@@ -983,14 +1033,14 @@ nextrow:
 	return vevents, nil
 }
 
-func (vs *vstreamer) processRowEvent(vevents []*binlogdatapb.VEvent, plan *streamerPlan, rows mysql.Rows) ([]*binlogdatapb.VEvent, error) {
+func (vs *vstreamer) processRowEvent(vevents []*binlogdatapb.VEvent, plan *streamerPlan, rows mysql.Rows, now time.Time) ([]*binlogdatapb.VEvent, error) {
 	rowChanges := make([]*binlogdatapb.RowChange, 0, len(rows.Rows))
 	for _, row := range rows.Rows {
-		beforeOK, beforeValues, _, err := vs.extractRowAndFilter(plan, row.Identify, rows.IdentifyColumns, row.NullIdentifyColumns)
+		beforeOK, beforeValues, _, err := vs.extractRowAndFilter(plan, row.Identify, rows.IdentifyColumns, row.NullIdentifyColumns, now)
 		if err != nil {
 			return nil, err
 		}
-		afterOK, afterValues, partial, err := vs.extractRowAndFilter(plan, row.Data, rows.DataColumns, row.NullColumns)
+		afterOK, afterValues, partial, err := vs.extractRowAndFilter(plan, row.Data, rows.DataColumns, row.NullColumns, now)
 		if err != nil {
 			return nil, err
 		}
@@ -1052,11 +1102,17 @@ func (vs *vstreamer) rebuildPlans() error {
 	return nil
 }
 
+// eventTime returns the time the binlog event was written, which is the
+// time the filter expressions of its rows are evaluated at.
+func eventTime(ev mysql.BinlogEvent) time.Time {
+	return time.Unix(int64(ev.Timestamp()), 0)
+}
+
 // extractRowAndFilter takes the data and bitmaps from the binlog events and returns the following
 //   - true, if row needs to be skipped because of workflow filter rules
 //   - data values, array of one value per column
 //   - true, if the row image was partial (i.e. binlog_row_image=noblob and dml doesn't update one or more blob/text columns)
-func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataColumns, nullColumns mysql.Bitmap) (bool, []sqltypes.Value, bool, error) {
+func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataColumns, nullColumns mysql.Bitmap, now time.Time) (bool, []sqltypes.Value, bool, error) {
 	if len(data) == 0 {
 		return false, nil, false, nil
 	}
@@ -1115,7 +1171,7 @@ func (vs *vstreamer) extractRowAndFilter(plan *streamerPlan, data []byte, dataCo
 		valueIndex++
 	}
 	filtered := make([]sqltypes.Value, len(plan.ColExprs))
-	ok, err := plan.filter(values, filtered, charsets)
+	ok, err := plan.filter(values, filtered, charsets, now)
 	return ok, filtered, partial, err
 }
 
@@ -37,6 +37,7 @@ import (
 	"vitess.io/vitess/go/sqltypes"
 	"vitess.io/vitess/go/vt/log"
 	"vitess.io/vitess/go/vt/sqlparser"
+	vttablet "vitess.io/vitess/go/vt/vttablet/common"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/throttle/throttlerapp"
 	"vitess.io/vitess/go/vt/vttablet/tabletserver/vstreamer/testenv"
 
@@ -252,6 +253,50 @@ func TestSetStatement(t *testing.T) {
 	runCases(t, nil, testcases, "current", nil)
 }
 
+// TestSkipSourceTag confirms that the row changes and the DDLs of the statements
+// tagged with the source tag the filter skips are not streamed.
+func TestSkipSourceTag(t *testing.T) {
+	execStatements(t, []string{
+		"create table t1(id int, val varbinary(128), primary key(id))",
+	})
+	defer execStatements(t, []string{
+		"drop table t1",
+	})
+	filter := &binlogdatapb.Filter{
+		Rules: []*binlogdatapb.Rule{{
+			Match: "/.*/",
+		}},
+		SkipSourceTag: "wf1",
+	}
+	testcases := []testcase{{
+		input: []string{
+			"set @@session.binlog_rows_query_log_events=1",
+			"begin",
+			vttablet.SourceTagComment("wf1") + "insert into t1 values (1, 'aaa')",
+			vttablet.SourceTagComment("wf2") + "insert into t1 values (2, 'bbb')",
+			"insert into t1 values (3, 'ccc')",
+			"commit",
+		},
+		output: [][]string{{
+			`begin`,
+			`type:FIELD field_event:{table_name:"t1" fields:{name:"id" type:INT32 table:"t1" org_table:"t1" database:"vttest" org_name:"id" column_length:11 charset:63 column_type:"int(11)"} fields:{name:"val" type:VARBINARY table:"t1" org_table:"t1" database:"vttest" org_name:"val" column_length:128 charset:63 column_type:"varbinary(128)"}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{after:{lengths:1 lengths:3 values:"2bbb"}}}`,
+			`type:ROW row_event:{table_name:"t1" row_changes:{after:{lengths:1 lengths:3 values:"3ccc"}}}`,
+			`gtid`,
+			`commit`,
+		}},
+	}, {
+		input: []string{
+			vttablet.SourceTagComment("wf1") + "alter table t1 add column val2 int",
+		},
+		output: [][]string{{
+			`gtid`,
+			`other`,
+		}},
+	}}
+	runCases(t, filter, testcases, "current", nil)
+}
+
 // TestSetForeignKeyCheck confirms that the binlog RowEvent flags are set correctly when foreign_key_checks are on and off.
 func TestSetForeignKeyCheck(t *testing.T) {
 	testRowEventFlags = true
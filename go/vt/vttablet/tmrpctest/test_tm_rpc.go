@@ -1368,6 +1368,17 @@ func (fra *fakeRPCTM) RestoreFromBackup(ctx context.Context, logger logutil.Logg
 	return nil
 }
 
+var testVerifyBackupName = "backup"
+
+func (fra *fakeRPCTM) VerifyBackup(ctx context.Context, request *tabletmanagerdatapb.VerifyBackupRequest) (*tabletmanagerdatapb.VerifyBackupResponse, error) {
+	if fra.panics {
+		panic(fmt.Errorf("test-triggered panic"))
+	}
+	compare(fra.t, "VerifyBackup args", request.BackupName, testVerifyBackupName)
+	compare(fra.t, "VerifyBackup args", request.Concurrency, testBackupConcurrency)
+	return &tabletmanagerdatapb.VerifyBackupResponse{BackupName: request.BackupName, Ok: true}, nil
+}
+
 func (fra *fakeRPCTM) CheckThrottler(ctx context.Context, req *tabletmanagerdatapb.CheckThrottlerRequest) (*tabletmanagerdatapb.CheckThrottlerResponse, error) {
 	if fra.panics {
 		panic(fmt.Errorf("test-triggered panic"))
@@ -1407,6 +1418,21 @@ func tmRPCTestRestoreFromBackupPanic(ctx context.Context, t *testing.T, client t
 	expectHandleRPCPanic(t, "RestoreFromBackup", true /*verbose*/, err)
 }
 
+func tmRPCTestVerifyBackup(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet) {
+	req := &tabletmanagerdatapb.VerifyBackupRequest{BackupName: testVerifyBackupName, Concurrency: testBackupConcurrency}
+	resp, err := client.VerifyBackup(ctx, tablet, req)
+	if err != nil {
+		t.Fatalf("VerifyBackup failed: %v", err)
+	}
+	compare(t, "VerifyBackup result", resp, &tabletmanagerdatapb.VerifyBackupResponse{BackupName: testVerifyBackupName, Ok: true})
+}
+
+func tmRPCTestVerifyBackupPanic(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet) {
+	req := &tabletmanagerdatapb.VerifyBackupRequest{BackupName: testVerifyBackupName, Concurrency: testBackupConcurrency}
+	_, err := client.VerifyBackup(ctx, tablet, req)
+	expectHandleRPCPanic(t, "VerifyBackup", true /*verbose*/, err)
+}
+
 func tmRPCTestCheckThrottler(ctx context.Context, t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.Tablet, req *tabletmanagerdatapb.CheckThrottlerRequest) {
 	_, err := client.CheckThrottler(ctx, tablet, req)
 	expectHandleRPCPanic(t, "CheckThrottler", false /*verbose*/, err)
@@ -1497,6 +1523,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.T
 	// Backup / restore related methods
 	tmRPCTestBackup(ctx, t, client, tablet)
 	tmRPCTestRestoreFromBackup(ctx, t, client, tablet, restoreFromBackupRequest)
+	tmRPCTestVerifyBackup(ctx, t, client, tablet)
 
 	// Throttler related methods
 	tmRPCTestCheckThrottler(ctx, t, client, tablet, checkThrottlerRequest)
@@ -1555,6 +1582,7 @@ func Run(t *testing.T, client tmclient.TabletManagerClient, tablet *topodatapb.T
 	// Backup / restore related methods
 	tmRPCTestBackupPanic(ctx, t, client, tablet)
 	tmRPCTestRestoreFromBackupPanic(ctx, t, client, tablet, restoreFromBackupRequest)
+	tmRPCTestVerifyBackupPanic(ctx, t, client, tablet)
 
 	client.Close()
 }